@@ -0,0 +1,50 @@
+package main
+
+import (
+	"github.com/koestler/go-iotdevice/v3/alarms"
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"log"
+)
+
+func runAlarms(cfg *config.Config, stateStorage *dataflow.ValueStorage) *alarms.Engine {
+	alarmsCfg := cfg.Alarms()
+	if !alarmsCfg.Enabled() {
+		return nil
+	}
+
+	if cfg.LogWorkerStart() {
+		log.Printf("alarms: start: %d alarms, %d notifiers", len(alarmsCfg.Definitions()), len(alarmsCfg.Notifiers()))
+	}
+
+	engine, err := alarms.Run(&alarms.Environment{
+		StateStorage: stateStorage,
+	}, alarmsConfig{alarmsCfg})
+	if err != nil {
+		log.Printf("alarms: cannot start: %s", err)
+		return nil
+	}
+	return engine
+}
+
+type alarmsConfig struct {
+	config.AlarmsConfig
+}
+
+func (c alarmsConfig) Definitions() []alarms.DefinitionConfig {
+	inp := c.AlarmsConfig.Definitions()
+	oup := make([]alarms.DefinitionConfig, len(inp))
+	for i, d := range inp {
+		oup[i] = d
+	}
+	return oup
+}
+
+func (c alarmsConfig) Notifiers() []alarms.NotifierConfig {
+	inp := c.AlarmsConfig.Notifiers()
+	oup := make([]alarms.NotifierConfig, len(inp))
+	for i, n := range inp {
+		oup[i] = n
+	}
+	return oup
+}
@@ -0,0 +1,177 @@
+package alarms
+
+import (
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"strings"
+	"time"
+)
+
+type State int
+
+const (
+	// Cleared means the alarm condition is not present.
+	Cleared State = iota
+	// Active means the alarm condition is present and nobody has acknowledged the alarm yet.
+	Active
+	// Acknowledged means the alarm condition is still present but a user has seen the alarm.
+	Acknowledged
+)
+
+func (s State) String() string {
+	switch s {
+	case Active:
+		return "active"
+	case Acknowledged:
+		return "acknowledged"
+	default:
+		return "cleared"
+	}
+}
+
+type sample struct {
+	at    time.Time
+	value float64
+}
+
+type alarm struct {
+	cfg DefinitionConfig
+
+	state    State
+	since    time.Time
+	raisedAt time.Time
+	ackedBy  string
+	ackedAt  time.Time
+	value    string // formatted value that caused the last change
+
+	samples []sample       // rate alarms: values received within the interval
+	last    dataflow.Value // stuck alarms: last value received
+
+	// pendingSince is set while the delay / interval timer is running
+	pendingSince time.Time
+
+	// gen is incremented whenever the timer is cancelled to ignore timers already being run
+	gen  int
+	stop func() bool
+}
+
+func (a *alarm) matches(v dataflow.Value) bool {
+	if v.DeviceName() != a.cfg.Device() {
+		return false
+	}
+	if a.cfg.Kind() == types.AlarmOfflineKind {
+		return v.Register().Name() == device.AvailabilityRegisterName
+	}
+	return v.Register().Name() == a.cfg.Register()
+}
+
+// inside returns true when the value (or the change within the interval for rate alarms) violates the limit.
+func (a *alarm) inside(f float64) bool {
+	if a.cfg.Kind() == types.AlarmLowKind {
+		return f < a.cfg.Limit()
+	}
+	return f > a.cfg.Limit()
+}
+
+// released returns true when the value is back within the limit including the hysteresis.
+func (a *alarm) released(f float64) bool {
+	if a.cfg.Kind() == types.AlarmLowKind {
+		return f >= a.cfg.Limit()+a.cfg.Hysteresis()
+	}
+	return f <= a.cfg.Limit()-a.cfg.Hysteresis()
+}
+
+// addSample adds the value to the window of the rate alarm and returns the change (max - min) within the window.
+func (a *alarm) addSample(now time.Time, f float64) float64 {
+	start := now.Add(-a.cfg.Interval())
+	i := 0
+	for i < len(a.samples) && a.samples[i].at.Before(start) {
+		i++
+	}
+	a.samples = append(a.samples[i:], sample{at: now, value: f})
+
+	lo, hi := f, f
+	for _, s := range a.samples {
+		lo = min(lo, s.value)
+		hi = max(hi, s.value)
+	}
+	return hi - lo
+}
+
+func (a *alarm) cancelTimer() {
+	a.gen += 1
+	if a.stop != nil {
+		a.stop()
+		a.stop = nil
+	}
+	a.pendingSince = time.Time{}
+}
+
+func (a *alarm) message() string {
+	if m := a.cfg.Message(); len(m) > 0 {
+		return m
+	}
+	return a.Description()
+}
+
+// Description returns a short human-readable summary of the alarm configuration.
+func (a *alarm) Description() string {
+	c := a.cfg
+	var b strings.Builder
+	switch c.Kind() {
+	case types.AlarmHighKind:
+		fmt.Fprintf(&b, "%s.%s > %g", c.Device(), c.Register(), c.Limit())
+	case types.AlarmLowKind:
+		fmt.Fprintf(&b, "%s.%s < %g", c.Device(), c.Register(), c.Limit())
+	case types.AlarmRateKind:
+		fmt.Fprintf(&b, "%s.%s changes by more than %g within %s", c.Device(), c.Register(), c.Limit(), c.Interval())
+	case types.AlarmStuckKind:
+		fmt.Fprintf(&b, "%s.%s unchanged for %s", c.Device(), c.Register(), c.Interval())
+	case types.AlarmOfflineKind:
+		fmt.Fprintf(&b, "%s offline for %s", c.Device(), c.Interval())
+	}
+	if h := c.Hysteresis(); h > 0 {
+		fmt.Fprintf(&b, " hysteresis %g", h)
+	}
+	if d := c.Delay(); d > 0 {
+		fmt.Fprintf(&b, " delay %s", d)
+	}
+	return b.String()
+}
+
+// numericValue returns the value as used by limits; bools and enums are represented by their index.
+func numericValue(v dataflow.Value) (float64, bool) {
+	switch tv := v.(type) {
+	case dataflow.NumericRegisterValue:
+		return tv.Value(), true
+	case dataflow.IntRegisterValue:
+		return float64(tv.Value()), true
+	case dataflow.BoolRegisterValue:
+		return float64(tv.EnumIdx()), true
+	case dataflow.EnumRegisterValue:
+		return float64(tv.EnumIdx()), true
+	default:
+		return 0, false
+	}
+}
+
+// formatValue returns the value including its unit as shown in notifications.
+func formatValue(v dataflow.Value) string {
+	unit := v.Register().Unit()
+	switch tv := v.(type) {
+	case dataflow.NumericRegisterValue:
+		return fmt.Sprintf("%g%s", tv.Value(), unit)
+	case dataflow.IntRegisterValue:
+		return fmt.Sprintf("%d%s", tv.Value(), unit)
+	case dataflow.BoolRegisterValue:
+		return tv.Label()
+	case dataflow.EnumRegisterValue:
+		return tv.Value()
+	case dataflow.TextRegisterValue:
+		return tv.Value()
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,429 @@
+package alarms
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/clock"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/eventLoop"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type Config interface {
+	HistorySize() int
+	Definitions() []DefinitionConfig
+	Notifiers() []NotifierConfig
+	LogDebug() bool
+}
+
+type DefinitionConfig interface {
+	Name() string
+	Kind() types.AlarmKind
+	Device() string
+	Register() string
+	Limit() float64
+	Hysteresis() float64
+	Interval() time.Duration
+	Delay() time.Duration
+	Severity() types.AlarmSeverity
+	Message() string
+	Notifiers() []string
+}
+
+type NotifierConfig interface {
+	Name() string
+	Kind() types.AlarmNotifierKind
+	Url() *url.URL
+	Method() string
+	Token() string
+	Host() string
+	Port() int
+	User() string
+	Password() string
+	From() string
+	To() []string
+	Timeout() time.Duration
+}
+
+type Environment struct {
+	Clock        clock.Clock
+	StateStorage *dataflow.ValueStorage
+	// HttpClient is used by the webhook and ntfy notifiers; http.DefaultClient is used when nil.
+	HttpClient *http.Client
+}
+
+var ErrNotFound = errors.New("alarm not found")
+
+// Engine evaluates the alarms. Values and timers are handled by a single event loop
+// and the alarm state is only modified while holding the mutex. Each notifier has its own routine
+// sending the notifications in order.
+type Engine struct {
+	env       *Environment
+	cfg       Config
+	alarms    []*alarm
+	notifiers map[string]chan Event
+
+	// watched and availability are read-only after creation since filter is called by the storage routine.
+	watched      map[registerRef]struct{}
+	availability map[string]struct{}
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+	loop      *eventLoop.Loop
+
+	mutex       sync.Mutex
+	history     []Event
+	subscribers []chan Event
+}
+
+type registerRef struct {
+	device   string
+	register string
+}
+
+// Run creates the engine and starts evaluating the given alarms.
+func Run(env *Environment, cfg Config) (*Engine, error) {
+	if env.Clock == nil {
+		env.Clock = clock.Real()
+	}
+	if env.HttpClient == nil {
+		env.HttpClient = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Engine{
+		env:          env,
+		cfg:          cfg,
+		notifiers:    make(map[string]chan Event),
+		watched:      make(map[registerRef]struct{}),
+		availability: make(map[string]struct{}),
+		ctx:          ctx,
+		ctxCancel:    cancel,
+	}
+	e.loop = eventLoop.New(ctx, &e.mutex)
+
+	for _, nc := range cfg.Notifiers() {
+		n, err := newNotifier(env, nc)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("alarms: notifier %s: %w", nc.Name(), err)
+		}
+		queue := make(chan Event, 64)
+		e.notifiers[nc.Name()] = queue
+		e.wg.Add(1)
+		go e.notifierRoutine(nc.Name(), n, queue)
+	}
+
+	for _, dc := range cfg.Definitions() {
+		for _, n := range dc.Notifiers() {
+			if _, ok := e.notifiers[n]; !ok {
+				cancel()
+				return nil, fmt.Errorf("alarms[%s]: notifier %s not found", dc.Name(), n)
+			}
+		}
+		e.alarms = append(e.alarms, &alarm{cfg: dc})
+
+		if dc.Kind() == types.AlarmOfflineKind {
+			e.availability[dc.Device()] = struct{}{}
+		} else {
+			e.watched[registerRef{device: dc.Device(), register: dc.Register()}] = struct{}{}
+		}
+	}
+
+	// conditions only depend on the latest value of a register, older ones can be skipped when the engine is busy
+	sub := env.StateStorage.SubscribeSendInitial(ctx, e.filter,
+		dataflow.WithName("alarms"),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
+
+	e.wg.Add(1)
+	go e.mainRoutine(sub)
+
+	return e, nil
+}
+
+// Shutdown stops the engine and waits for the notification being sent to complete; queued notifications are dropped.
+func (e *Engine) Shutdown() {
+	e.ctxCancel()
+	e.wg.Wait()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for _, a := range e.alarms {
+		a.cancelTimer()
+	}
+}
+
+func (e *Engine) filter(v dataflow.Value) bool {
+	if v.Register().Name() == device.AvailabilityRegisterName {
+		if _, ok := e.availability[v.DeviceName()]; ok {
+			return true
+		}
+	}
+	_, ok := e.watched[registerRef{device: v.DeviceName(), register: v.Register().Name()}]
+	return ok
+}
+
+func (e *Engine) mainRoutine(sub dataflow.ValueSubscription) {
+	defer e.wg.Done()
+	e.loop.Run(sub.Drain(), e.handleValue)
+}
+
+func (e *Engine) handleValue(v dataflow.Value) {
+	for _, a := range e.alarms {
+		if a.matches(v) {
+			e.updateAlarm(a, v)
+		}
+	}
+}
+
+func (e *Engine) updateAlarm(a *alarm, v dataflow.Value) {
+	now := e.env.Clock.Now()
+
+	switch a.cfg.Kind() {
+	case types.AlarmHighKind, types.AlarmLowKind:
+		f, ok := numericValue(v)
+		if !ok {
+			e.cancelPending(a)
+			return
+		}
+		a.value = formatValue(v)
+		e.evaluate(a, a.inside(f), a.released(f))
+
+	case types.AlarmRateKind:
+		f, ok := numericValue(v)
+		if !ok {
+			// the rate cannot be computed across gaps
+			a.samples = a.samples[:0]
+			e.cancelPending(a)
+			return
+		}
+		a.value = formatValue(v)
+		change := a.addSample(now, f)
+		e.evaluate(a, a.inside(change), a.released(change))
+
+	case types.AlarmStuckKind:
+		if _, isNull := v.(dataflow.NullRegisterValue); isNull {
+			a.last = nil
+			a.cancelTimer()
+			return
+		}
+		if a.last != nil && a.last.Equals(v) {
+			// unchanged, keep the timer running
+			return
+		}
+		a.last = v
+		a.value = formatValue(v)
+		a.cancelTimer()
+		if a.state != Cleared {
+			e.setState(a, Cleared, "")
+		}
+		e.startTimer(a, a.cfg.Interval())
+
+	case types.AlarmOfflineKind:
+		f, ok := numericValue(v)
+		available := ok && f != 0
+		if available {
+			a.cancelTimer()
+			if a.state != Cleared {
+				e.setState(a, Cleared, "")
+			}
+		} else if a.state == Cleared && a.stop == nil {
+			e.startTimer(a, a.cfg.Interval())
+		}
+	}
+}
+
+// evaluate raises the alarm when the value is inside the limit for the configured delay
+// and clears it when the value is released including the hysteresis.
+func (e *Engine) evaluate(a *alarm, inside, released bool) {
+	if a.state == Cleared {
+		if !inside {
+			e.cancelPending(a)
+			return
+		}
+		if a.stop != nil {
+			// delay already running
+			return
+		}
+		if d := a.cfg.Delay(); d > 0 {
+			e.startTimer(a, d)
+		} else {
+			e.setState(a, Active, "")
+		}
+		return
+	}
+
+	if released {
+		e.setState(a, Cleared, "")
+	}
+}
+
+func (e *Engine) cancelPending(a *alarm) {
+	if a.state == Cleared {
+		a.cancelTimer()
+	}
+}
+
+// startTimer raises the alarm once the duration has passed unless the timer is cancelled meanwhile.
+func (e *Engine) startTimer(a *alarm, d time.Duration) {
+	a.pendingSince = e.env.Clock.Now()
+	gen := a.gen
+	a.stop = e.env.Clock.AfterFunc(d, func() {
+		e.loop.Do(func() {
+			if a.gen != gen {
+				// cancelled meanwhile
+				return
+			}
+			a.stop = nil
+			a.pendingSince = time.Time{}
+			e.setState(a, Active, "")
+		})
+	})
+}
+
+// Acknowledge marks the alarm as seen by the given user. Acknowledging an alarm that is not active has no effect.
+func (e *Engine) Acknowledge(name, user string) error {
+	a := e.getByName(name)
+	if a == nil {
+		return ErrNotFound
+	}
+
+	e.loop.Do(func() {
+		if a.state == Active {
+			e.setState(a, Acknowledged, user)
+		}
+	})
+	return nil
+}
+
+func (e *Engine) getByName(name string) *alarm {
+	for _, a := range e.alarms {
+		if a.cfg.Name() == name {
+			return a
+		}
+	}
+	return nil
+}
+
+func (e *Engine) setState(a *alarm, state State, user string) {
+	now := e.env.Clock.Now()
+	a.state = state
+	a.since = now
+
+	switch state {
+	case Active:
+		a.raisedAt = now
+		a.ackedBy = ""
+		a.ackedAt = time.Time{}
+	case Acknowledged:
+		a.ackedBy = user
+		a.ackedAt = now
+	}
+
+	ev := Event{
+		Time:     now,
+		Alarm:    a.cfg.Name(),
+		Device:   a.cfg.Device(),
+		Register: a.cfg.Register(),
+		Kind:     a.cfg.Kind(),
+		Severity: a.cfg.Severity(),
+		State:    state,
+		Value:    a.value,
+		Message:  a.message(),
+		User:     user,
+	}
+
+	if e.cfg.LogDebug() {
+		log.Printf("alarms[%s]: %s: %s", ev.Alarm, ev.State, ev.Message)
+	}
+
+	e.history = append(e.history, ev)
+	if l := len(e.history) - e.cfg.HistorySize(); l > 0 {
+		e.history = e.history[l:]
+	}
+
+	for _, c := range e.subscribers {
+		select {
+		case c <- ev:
+		default:
+			log.Printf("alarms[%s]: subscriber too slow, event dropped", ev.Alarm)
+		}
+	}
+
+	if state != Acknowledged {
+		e.notify(a, ev)
+	}
+}
+
+func (e *Engine) notify(a *alarm, ev Event) {
+	names := a.cfg.Notifiers()
+	if len(names) < 1 {
+		// default to all notifiers
+		for _, nc := range e.cfg.Notifiers() {
+			names = append(names, nc.Name())
+		}
+	}
+
+	for _, name := range names {
+		select {
+		case e.notifiers[name] <- ev:
+		default:
+			log.Printf("alarms[%s]: notifier %s too slow, notification dropped", ev.Alarm, name)
+		}
+	}
+}
+
+func (e *Engine) notifierRoutine(name string, n notifier, queue <-chan Event) {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case ev := <-queue:
+			if err := n.notify(e.ctx, ev); err != nil {
+				log.Printf("alarms[%s]: notifier %s failed: %s", ev.Alarm, name, err)
+			} else if e.cfg.LogDebug() {
+				log.Printf("alarms[%s]: notifier %s sent", ev.Alarm, name)
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel receiving all alarm events until ctx is cancelled.
+// Events are dropped when the receiver does not keep up.
+func (e *Engine) Subscribe(ctx context.Context) <-chan Event {
+	c := make(chan Event, 64)
+
+	e.mutex.Lock()
+	e.subscribers = append(e.subscribers, c)
+	e.mutex.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-e.ctx.Done():
+		}
+
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		for i, t := range e.subscribers {
+			if t == c {
+				e.subscribers = append(e.subscribers[:i], e.subscribers[i+1:]...)
+				break
+			}
+		}
+		close(c)
+	}()
+
+	return c
+}
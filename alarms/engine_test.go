@@ -0,0 +1,293 @@
+package alarms_test
+
+import (
+	"context"
+	"errors"
+	"github.com/koestler/go-iotdevice/v3/alarms"
+	"github.com/koestler/go-iotdevice/v3/clock"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	definitions []alarms.DefinitionConfig
+	notifiers   []alarms.NotifierConfig
+}
+
+func (c testConfig) HistorySize() int                       { return 10 }
+func (c testConfig) Definitions() []alarms.DefinitionConfig { return c.definitions }
+func (c testConfig) Notifiers() []alarms.NotifierConfig     { return c.notifiers }
+func (c testConfig) LogDebug() bool                         { return false }
+
+type testDefinition struct {
+	name       string
+	kind       types.AlarmKind
+	device     string
+	register   string
+	limit      float64
+	hysteresis float64
+	interval   time.Duration
+	delay      time.Duration
+	notifiers  []string
+}
+
+func (d testDefinition) Name() string                  { return d.name }
+func (d testDefinition) Kind() types.AlarmKind         { return d.kind }
+func (d testDefinition) Device() string                { return d.device }
+func (d testDefinition) Register() string              { return d.register }
+func (d testDefinition) Limit() float64                { return d.limit }
+func (d testDefinition) Hysteresis() float64           { return d.hysteresis }
+func (d testDefinition) Interval() time.Duration       { return d.interval }
+func (d testDefinition) Delay() time.Duration          { return d.delay }
+func (d testDefinition) Severity() types.AlarmSeverity { return types.AlarmWarningSeverity }
+func (d testDefinition) Message() string               { return "" }
+func (d testDefinition) Notifiers() []string           { return d.notifiers }
+
+type testNotifier struct {
+	name  string
+	kind  types.AlarmNotifierKind
+	url   *url.URL
+	token string
+	host  string
+	port  int
+}
+
+func (n testNotifier) Name() string                  { return n.name }
+func (n testNotifier) Kind() types.AlarmNotifierKind { return n.kind }
+func (n testNotifier) Url() *url.URL                 { return n.url }
+func (n testNotifier) Method() string                { return "POST" }
+func (n testNotifier) Token() string                 { return n.token }
+func (n testNotifier) Host() string                  { return n.host }
+func (n testNotifier) Port() int                     { return n.port }
+func (n testNotifier) User() string                  { return "" }
+func (n testNotifier) Password() string              { return "" }
+func (n testNotifier) From() string                  { return "iot@example.com" }
+func (n testNotifier) To() []string                  { return []string{"ops@example.com"} }
+func (n testNotifier) Timeout() time.Duration        { return time.Second }
+
+var (
+	socRegister   = dataflow.NewRegisterStruct("Battery", "SOC", "", dataflow.NumberRegister, nil, "%", 0, false)
+	availRegister = dataflow.NewRegisterStruct(
+		device.AvailabilityRegisterName, device.AvailabilityRegisterName, "",
+		dataflow.EnumRegister, map[int]string{0: "offline", 1: "online"}, "", 0, false,
+	)
+)
+
+type testSetup struct {
+	clock        *clock.Simulated
+	stateStorage *dataflow.ValueStorage
+	engine       *alarms.Engine
+}
+
+func setup(t *testing.T, cfg testConfig) *testSetup {
+	t.Helper()
+
+	s := &testSetup{
+		clock:        clock.NewSimulated(time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)),
+		stateStorage: dataflow.NewValueStorage(),
+	}
+
+	engine, err := alarms.Run(&alarms.Environment{
+		Clock:        s.clock,
+		StateStorage: s.stateStorage,
+	}, cfg)
+	if err != nil {
+		t.Fatalf("cannot start engine: %s", err)
+	}
+	s.engine = engine
+
+	t.Cleanup(func() {
+		s.engine.Shutdown()
+		s.stateStorage.Shutdown()
+	})
+	return s
+}
+
+func waitFor(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if check() {
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+func (s *testSetup) status() alarms.AlarmStatus {
+	return s.engine.Status()[0]
+}
+
+func (s *testSetup) waitForState(t *testing.T, state alarms.State) {
+	t.Helper()
+	waitFor(t, "state "+state.String(), func() bool {
+		return s.status().State == state
+	})
+}
+
+func (s *testSetup) waitForPending(t *testing.T) {
+	t.Helper()
+	waitFor(t, "pending", func() bool {
+		return !s.status().PendingSince.IsZero()
+	})
+}
+
+// fillSoc sets the value and waits until the engine has seen it.
+func (s *testSetup) fillSoc(t *testing.T, v float64, expect string) {
+	t.Helper()
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, v))
+	waitFor(t, "value "+expect, func() bool {
+		return s.status().Value == expect
+	})
+}
+
+func TestLimitAlarm(t *testing.T) {
+	s := setup(t, testConfig{definitions: []alarms.DefinitionConfig{testDefinition{
+		name:       "low-soc",
+		kind:       types.AlarmLowKind,
+		device:     "bmv0",
+		register:   "SOC",
+		limit:      20,
+		hysteresis: 5,
+		delay:      time.Minute,
+	}}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := s.engine.Subscribe(ctx)
+
+	s.fillSoc(t, 30, "30%")
+	s.fillSoc(t, 15, "15%")
+	s.waitForPending(t)
+	if expect, got := alarms.Cleared, s.status().State; expect != got {
+		t.Errorf("expect state %s during the delay but got %s", expect, got)
+	}
+
+	s.clock.Advance(time.Minute)
+	s.waitForState(t, alarms.Active)
+
+	if err := s.engine.Acknowledge("low-soc", "user0"); err != nil {
+		t.Fatalf("cannot acknowledge: %s", err)
+	}
+	if expect, got := alarms.Acknowledged, s.status().State; expect != got {
+		t.Errorf("expect state %s but got %s", expect, got)
+	}
+	if expect, got := "user0", s.status().AckedBy; expect != got {
+		t.Errorf("expect AckedBy to be '%s' but got '%s'", expect, got)
+	}
+	if err := s.engine.Acknowledge("unknown", "user0"); !errors.Is(err, alarms.ErrNotFound) {
+		t.Errorf("expect ErrNotFound but got %v", err)
+	}
+
+	// clearing requires the hysteresis to be passed
+	s.fillSoc(t, 22, "22%")
+	if expect, got := alarms.Acknowledged, s.status().State; expect != got {
+		t.Errorf("expect state %s within the hysteresis but got %s", expect, got)
+	}
+	s.fillSoc(t, 25, "25%")
+	s.waitForState(t, alarms.Cleared)
+
+	// a dip shorter than the delay does not raise the alarm
+	s.fillSoc(t, 10, "10%")
+	s.waitForPending(t)
+	s.clock.Advance(30 * time.Second)
+	s.fillSoc(t, 30, "30%")
+	s.clock.Advance(time.Minute)
+
+	expectStates := []alarms.State{alarms.Active, alarms.Acknowledged, alarms.Cleared}
+	history := s.engine.History()
+	if len(history) != len(expectStates) {
+		t.Fatalf("expect %d history entries but got %v", len(expectStates), history)
+	}
+	for i, expect := range expectStates {
+		if got := history[i].State; expect != got {
+			t.Errorf("expect history[%d] to be %s but got %s", i, expect, got)
+		}
+		select {
+		case ev := <-events:
+			if got := ev.State; expect != got {
+				t.Errorf("expect event %d to be %s but got %s", i, expect, got)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timeout waiting for event %d", i)
+		}
+	}
+	if expect, got := "15%", history[0].Value; expect != got {
+		t.Errorf("expect value of the raise event to be '%s' but got '%s'", expect, got)
+	}
+	if expect, got := "bmv0.SOC < 20 hysteresis 5 delay 1m0s", history[0].Message; expect != got {
+		t.Errorf("expect message '%s' but got '%s'", expect, got)
+	}
+}
+
+func TestRateAlarm(t *testing.T) {
+	s := setup(t, testConfig{definitions: []alarms.DefinitionConfig{testDefinition{
+		name:     "soc-jump",
+		kind:     types.AlarmRateKind,
+		device:   "bmv0",
+		register: "SOC",
+		limit:    10,
+		interval: time.Minute,
+	}}})
+
+	s.fillSoc(t, 50, "50%")
+	s.clock.Advance(30 * time.Second)
+	s.fillSoc(t, 55, "55%")
+	s.clock.Advance(20 * time.Second)
+	s.fillSoc(t, 61, "61%")
+	s.waitForState(t, alarms.Active)
+
+	// the first two samples leave the window
+	s.clock.Advance(2 * time.Minute)
+	s.fillSoc(t, 62, "62%")
+	s.waitForState(t, alarms.Cleared)
+}
+
+func TestStuckAlarm(t *testing.T) {
+	s := setup(t, testConfig{definitions: []alarms.DefinitionConfig{testDefinition{
+		name:     "soc-stuck",
+		kind:     types.AlarmStuckKind,
+		device:   "bmv0",
+		register: "SOC",
+		interval: 5 * time.Minute,
+	}}})
+
+	s.fillSoc(t, 50, "50%")
+	s.waitForPending(t)
+	s.clock.Advance(4 * time.Minute)
+	s.fillSoc(t, 51, "51%")
+	s.clock.Advance(4 * time.Minute)
+	if expect, got := alarms.Cleared, s.status().State; expect != got {
+		t.Errorf("expect state %s but got %s", expect, got)
+	}
+
+	s.clock.Advance(time.Minute)
+	s.waitForState(t, alarms.Active)
+
+	s.fillSoc(t, 52, "52%")
+	s.waitForState(t, alarms.Cleared)
+}
+
+func TestOfflineAlarm(t *testing.T) {
+	s := setup(t, testConfig{definitions: []alarms.DefinitionConfig{testDefinition{
+		name:     "bmv0-offline",
+		kind:     types.AlarmOfflineKind,
+		device:   "bmv0",
+		interval: 2 * time.Minute,
+	}}})
+
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 1))
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 0))
+	s.waitForPending(t)
+
+	s.clock.Advance(2 * time.Minute)
+	s.waitForState(t, alarms.Active)
+
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 1))
+	s.waitForState(t, alarms.Cleared)
+}
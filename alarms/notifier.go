@@ -0,0 +1,233 @@
+package alarms
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type notifier interface {
+	notify(ctx context.Context, ev Event) error
+}
+
+func newNotifier(env *Environment, cfg NotifierConfig) (notifier, error) {
+	switch cfg.Kind() {
+	case types.AlarmNotifierWebhookKind:
+		return webhookNotifier{env: env, cfg: cfg}, nil
+	case types.AlarmNotifierSmtpKind:
+		return smtpNotifier{cfg: cfg}, nil
+	case types.AlarmNotifierNtfyKind:
+		return ntfyNotifier{env: env, cfg: cfg}, nil
+	default:
+		return nil, fmt.Errorf("unknown notifier kind %s", cfg.Kind())
+	}
+}
+
+// title returns a one line summary of the event used as mail subject and ntfy title.
+func title(ev Event) string {
+	return fmt.Sprintf("%s: %s %s", ev.Severity, ev.Alarm, ev.State)
+}
+
+// body returns the text used as mail body and ntfy message.
+func body(ev Event) string {
+	var b strings.Builder
+	b.WriteString(ev.Message)
+	if len(ev.Value) > 0 {
+		fmt.Fprintf(&b, "\nvalue: %s", ev.Value)
+	}
+	fmt.Fprintf(&b, "\ntime: %s", ev.Time.Format(time.RFC3339))
+	return b.String()
+}
+
+type webhookPayload struct {
+	Time     time.Time `json:"time"`
+	Alarm    string    `json:"alarm"`
+	Device   string    `json:"device"`
+	Register string    `json:"register,omitempty"`
+	Kind     string    `json:"kind"`
+	Severity string    `json:"severity"`
+	State    string    `json:"state"`
+	Value    string    `json:"value,omitempty"`
+	Message  string    `json:"message"`
+}
+
+// webhookNotifier sends the event as json.
+type webhookNotifier struct {
+	env *Environment
+	cfg NotifierConfig
+}
+
+func (n webhookNotifier) notify(ctx context.Context, ev Event) error {
+	payload, err := json.Marshal(webhookPayload{
+		Time:     ev.Time,
+		Alarm:    ev.Alarm,
+		Device:   ev.Device,
+		Register: ev.Register,
+		Kind:     ev.Kind.String(),
+		Severity: ev.Severity.String(),
+		State:    ev.State.String(),
+		Value:    ev.Value,
+		Message:  ev.Message,
+	})
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return sendHttp(ctx, n.env.HttpClient, n.cfg, n.cfg.Method(), header, payload)
+}
+
+// ntfyNotifier publishes the event to a ntfy topic (https://docs.ntfy.sh/publish/).
+// The Url contains the server and the topic.
+type ntfyNotifier struct {
+	env *Environment
+	cfg NotifierConfig
+}
+
+func (n ntfyNotifier) notify(ctx context.Context, ev Event) error {
+	header := http.Header{}
+	header.Set("Title", title(ev))
+	priority, tag := ntfyPriority(ev)
+	header.Set("Priority", priority)
+	header.Set("Tags", tag)
+	return sendHttp(ctx, n.env.HttpClient, n.cfg, http.MethodPost, header, []byte(body(ev)))
+}
+
+func ntfyPriority(ev Event) (priority, tag string) {
+	if ev.State == Cleared {
+		return "3", "white_check_mark"
+	}
+	switch ev.Severity {
+	case types.AlarmCriticalSeverity:
+		return "5", "rotating_light"
+	case types.AlarmWarningSeverity:
+		return "4", "warning"
+	default:
+		return "3", "information_source"
+	}
+}
+
+// sendHttp is used by the http based notifiers. A token is sent as bearer authorization.
+func sendHttp(ctx context.Context, client *http.Client, cfg NotifierConfig, method string, header http.Header, payload []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, cfg.Url().String(), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("%s: %w", cfg.Kind(), err)
+	}
+	req.Header = header
+	if t := cfg.Token(); len(t) > 0 {
+		req.Header.Set("Authorization", "Bearer "+t)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cfg.Kind(), err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: %s returned %s", cfg.Kind(), cfg.Url(), resp.Status)
+	}
+	return nil
+}
+
+// smtpNotifier sends a plain text mail. Port 465 uses implicit tls, otherwise STARTTLS is used when offered
+// by the server. Authentication is only attempted when a User is configured.
+type smtpNotifier struct {
+	cfg NotifierConfig
+}
+
+func (n smtpNotifier) notify(ctx context.Context, ev Event) error {
+	if err := n.send(ctx, ev); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return nil
+}
+
+func (n smtpNotifier) send(ctx context.Context, ev Event) error {
+	cfg := n.cfg
+	host := cfg.Host()
+	addr := net.JoinHostPort(host, strconv.Itoa(cfg.Port()))
+	tlsConfig := &tls.Config{ServerName: host}
+
+	dialer := net.Dialer{Timeout: cfg.Timeout()}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(cfg.Timeout())); err != nil {
+		conn.Close()
+		return err
+	}
+	if cfg.Port() == 465 {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok && cfg.Port() != 465 {
+		if err := c.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if len(cfg.User()) > 0 {
+		if err := c.Auth(smtp.PlainAuth("", cfg.User(), cfg.Password(), host)); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(cfg.From()); err != nil {
+		return err
+	}
+	for _, to := range cfg.To() {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(n.message(ev)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+func (n smtpNotifier) message(ev Event) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", n.cfg.From())
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.cfg.To(), ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", title(ev)))
+	fmt.Fprintf(&b, "Date: %s\r\n", ev.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body(ev), "\n", "\r\n"))
+	b.WriteString("\r\n")
+	return b.Bytes()
+}
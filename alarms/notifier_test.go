@@ -0,0 +1,183 @@
+package alarms_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/koestler/go-iotdevice/v3/alarms"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type recorder struct {
+	mutex    sync.Mutex
+	requests []string
+}
+
+func (r *recorder) add(s string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.requests = append(r.requests, s)
+}
+
+func (r *recorder) get() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.requests...)
+}
+
+func startHttpServer(t *testing.T, handle func(r *http.Request, body []byte) string) (*url.URL, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		rec.add(handle(r, b))
+	}))
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL + "/alerts")
+	return u, rec
+}
+
+// startSmtpServer runs a minimal smtp server accepting all mails without tls and authentication.
+func startSmtpServer(t *testing.T) (int, *recorder) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	rec := &recorder{}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSmtp(conn, rec)
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port, rec
+}
+
+func serveSmtp(conn net.Conn, rec *recorder) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) {
+		_, _ = conn.Write([]byte(s + "\r\n"))
+	}
+
+	reply("220 localhost ESMTP")
+	var envelope []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		cmd := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch cmd {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL", "RCPT":
+			envelope = append(envelope, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			rec.add(strings.Join(envelope, "\n") + "\n" + data.String())
+			envelope = nil
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("500 unknown command")
+		}
+	}
+}
+
+func TestNotifiers(t *testing.T) {
+	webhookUrl, webhook := startHttpServer(t, func(r *http.Request, body []byte) string {
+		var payload map[string]string
+		_ = json.Unmarshal(body, &payload)
+		return r.Header.Get("Content-Type") + " " + payload["alarm"] + " " + payload["state"] + " " + payload["value"]
+	})
+	ntfyUrl, ntfy := startHttpServer(t, func(r *http.Request, body []byte) string {
+		return r.Header.Get("Authorization") + "|" + r.Header.Get("Title") + "|" + r.Header.Get("Priority") + "|" + string(body)
+	})
+	smtpPort, smtp := startSmtpServer(t)
+
+	s := setup(t, testConfig{
+		definitions: []alarms.DefinitionConfig{testDefinition{
+			name:     "high-soc",
+			kind:     types.AlarmHighKind,
+			device:   "bmv0",
+			register: "SOC",
+			limit:    90,
+		}},
+		notifiers: []alarms.NotifierConfig{
+			testNotifier{name: "hook", kind: types.AlarmNotifierWebhookKind, url: webhookUrl},
+			testNotifier{name: "ntfy", kind: types.AlarmNotifierNtfyKind, url: ntfyUrl, token: "secret"},
+			testNotifier{name: "mail", kind: types.AlarmNotifierSmtpKind, host: "127.0.0.1", port: smtpPort},
+		},
+	})
+
+	s.fillSoc(t, 95, "95%")
+	s.waitForState(t, alarms.Active)
+
+	// acknowledgements are not notified
+	if err := s.engine.Acknowledge("high-soc", "user0"); err != nil {
+		t.Fatalf("cannot acknowledge: %s", err)
+	}
+	s.fillSoc(t, 80, "80%")
+	s.waitForState(t, alarms.Cleared)
+
+	for _, r := range []*recorder{webhook, ntfy, smtp} {
+		waitFor(t, "notifications", func() bool {
+			return len(r.get()) == 2
+		})
+	}
+
+	if expect, got := "application/json high-soc active 95%", webhook.get()[0]; expect != got {
+		t.Errorf("expect webhook '%s' but got '%s'", expect, got)
+	}
+	if expect, got := "application/json high-soc cleared 80%", webhook.get()[1]; expect != got {
+		t.Errorf("expect webhook '%s' but got '%s'", expect, got)
+	}
+
+	if expect, got := "Bearer secret|Warning: high-soc active|4|bmv0.SOC > 90\nvalue: 95%\ntime: 2024-01-01T00:05:00Z", ntfy.get()[0]; expect != got {
+		t.Errorf("expect ntfy '%s' but got '%s'", expect, got)
+	}
+	if got := ntfy.get()[1]; !strings.HasPrefix(got, "Bearer secret|Warning: high-soc cleared|3|") {
+		t.Errorf("unexpected ntfy clear notification '%s'", got)
+	}
+
+	mail := smtp.get()[0]
+	for _, expect := range []string{
+		"MAIL FROM:<iot@example.com>",
+		"RCPT TO:<ops@example.com>",
+		"Subject: Warning: high-soc active\r\n",
+		"\r\nbmv0.SOC > 90\r\nvalue: 95%\r\n",
+	} {
+		if !strings.Contains(mail, expect) {
+			t.Errorf("expect mail to contain '%s' but got '%s'", expect, mail)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package alarms
+
+import (
+	"github.com/koestler/go-iotdevice/v3/types"
+	"time"
+)
+
+// Event is a state change of an alarm. It is kept in the history, sent to subscribers
+// and, except for acknowledgements, sent to the notifiers.
+type Event struct {
+	Time     time.Time
+	Alarm    string
+	Device   string
+	Register string
+	Kind     types.AlarmKind
+	Severity types.AlarmSeverity
+	State    State
+	Value    string
+	Message  string
+	// User is set when an alarm was acknowledged
+	User string
+}
+
+type AlarmStatus struct {
+	Name        string
+	Device      string
+	Register    string
+	Kind        types.AlarmKind
+	Severity    types.AlarmSeverity
+	Description string
+	Message     string
+	State       State
+	Since       time.Time
+	RaisedAt    time.Time
+	AckedBy     string
+	AckedAt     time.Time
+	Value       string
+	// PendingSince is set while the alarm condition is present but the delay / interval has not passed yet.
+	PendingSince time.Time
+}
+
+// Status returns the current state of all alarms in the configured order.
+func (e *Engine) Status() []AlarmStatus {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ret := make([]AlarmStatus, len(e.alarms))
+	for i, a := range e.alarms {
+		ret[i] = AlarmStatus{
+			Name:         a.cfg.Name(),
+			Device:       a.cfg.Device(),
+			Register:     a.cfg.Register(),
+			Kind:         a.cfg.Kind(),
+			Severity:     a.cfg.Severity(),
+			Description:  a.Description(),
+			Message:      a.message(),
+			State:        a.state,
+			Since:        a.since,
+			RaisedAt:     a.raisedAt,
+			AckedBy:      a.ackedBy,
+			AckedAt:      a.ackedAt,
+			Value:        a.value,
+			PendingSince: a.pendingSince,
+		}
+	}
+	return ret
+}
+
+// History returns the most recent events, the oldest first.
+func (e *Engine) History() []Event {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ret := make([]Event, len(e.history))
+	copy(ret, e.history)
+	return ret
+}
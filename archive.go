@@ -0,0 +1,43 @@
+package main
+
+import (
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/tsdb"
+	"log"
+)
+
+func runArchive(cfg *config.Config, stateStorage *dataflow.ValueStorage) *tsdb.Store {
+	archiveCfg := cfg.Archive()
+	if !archiveCfg.Enabled() {
+		return nil
+	}
+
+	if cfg.LogWorkerStart() {
+		log.Printf("archive: start: path=%s", archiveCfg.Path())
+	}
+
+	store, err := tsdb.Open(archiveCfg)
+	if err != nil {
+		log.Printf("archive: cannot open: %s", err)
+		return nil
+	}
+
+	store.Subscribe(stateStorage, getDeviceFiltersValueFilter(archiveCfg.Devices()))
+	return store
+}
+
+// getDeviceFiltersValueFilter returns a filter matching all values of the given devices passing the device's filter.
+func getDeviceFiltersValueFilter(devices []config.DeviceFilterConfig) dataflow.ValueFilterFunc {
+	filters := make(map[string]dataflow.ValueFilterFunc, len(devices))
+	for _, d := range devices {
+		filters[d.Name()] = dataflow.RegisterValueFilter(d.Filter())
+	}
+
+	return func(value dataflow.Value) bool {
+		if f, ok := filters[value.DeviceName()]; ok {
+			return f(value)
+		}
+		return false
+	}
+}
@@ -0,0 +1,104 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is a time source. It allows timer based logic like rules and alarms to be tested using a Simulated clock.
+type Clock interface {
+	Now() time.Time
+	// AfterFunc calls f once the duration has elapsed. The returned stop function prevents f from being called.
+	AfterFunc(d time.Duration, f func()) (stop func() bool)
+}
+
+type realClock struct{}
+
+// Real returns a clock based on the system time.
+func Real() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) func() bool {
+	return time.AfterFunc(d, f).Stop
+}
+
+// Simulated only advances when Advance is called.
+// Expired timers are run synchronously within Advance.
+type Simulated struct {
+	mutex  sync.Mutex
+	now    time.Time
+	timers []*simulatedTimer
+}
+
+type simulatedTimer struct {
+	at time.Time
+	f  func()
+}
+
+func NewSimulated(now time.Time) *Simulated {
+	return &Simulated{now: now}
+}
+
+func (c *Simulated) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.now
+}
+
+func (c *Simulated) AfterFunc(d time.Duration, f func()) func() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	t := &simulatedTimer{at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, t)
+
+	return func() bool {
+		c.mutex.Lock()
+		defer c.mutex.Unlock()
+		for i, tt := range c.timers {
+			if tt == t {
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Advance moves the clock forward by the given duration and runs all timers expiring meanwhile in order.
+// Timers created by the functions being run are considered as well.
+func (c *Simulated) Advance(d time.Duration) {
+	c.mutex.Lock()
+	end := c.now.Add(d)
+
+	for {
+		next := -1
+		for i, t := range c.timers {
+			if !t.at.After(end) && (next < 0 || t.at.Before(c.timers[next].at)) {
+				next = i
+			}
+		}
+		if next < 0 {
+			break
+		}
+
+		t := c.timers[next]
+		c.timers = append(c.timers[:next], c.timers[next+1:]...)
+		if t.at.After(c.now) {
+			c.now = t.at
+		}
+
+		// run without holding the lock since f may create new timers
+		c.mutex.Unlock()
+		t.f()
+		c.mutex.Lock()
+	}
+
+	c.now = end
+	c.mutex.Unlock()
+}
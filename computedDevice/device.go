@@ -0,0 +1,185 @@
+package computedDevice
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/expression"
+	"log"
+)
+
+type Config interface {
+	Registers() []Register
+}
+
+type Register interface {
+	Name() string
+	Expression() string
+	Type() string // number or bool
+	Category() string
+	Description() string
+	Unit() string
+	Sort() int
+}
+
+// DeviceStruct computes its registers from the values of other devices.
+// It is available as long as all devices used by its expressions are available.
+type DeviceStruct struct {
+	device.State
+	computedConfig Config
+}
+
+type computedRegister struct {
+	register   dataflow.RegisterStruct
+	expression *expression.Expression
+}
+
+func NewDevice(
+	deviceConfig device.Config,
+	computedConfig Config,
+	stateStorage *dataflow.ValueStorage,
+) *DeviceStruct {
+	return &DeviceStruct{
+		State: device.NewState(
+			deviceConfig,
+			stateStorage,
+		),
+		computedConfig: computedConfig,
+	}
+}
+
+func (d *DeviceStruct) Model() string {
+	return "Computed Device"
+}
+
+func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool) {
+	dName := d.Name()
+
+	registers := make([]computedRegister, 0, len(d.computedConfig.Registers()))
+	for _, r := range d.computedConfig.Registers() {
+		expr, err := expression.Parse(r.Expression())
+		if err != nil {
+			return fmt.Errorf("computedDevice[%s]: register %s: %w", dName, r.Name(), err), true
+		}
+		registers = append(registers, computedRegister{
+			register:   newRegister(r),
+			expression: expr,
+		})
+	}
+
+	rdb := d.RegisterDb()
+	for _, r := range registers {
+		rdb.AddStruct(r.register)
+	}
+
+	in := newInputs(registers)
+	// the computed values are filled into the same storage; a blocking subscription could deadlock the storage,
+	// only the latest value per input register matters anyway
+	sub := d.StateStorage().SubscribeSendInitial(ctx, in.filter,
+		dataflow.WithName(fmt.Sprintf("computedDevice[%s]", dName)),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
+
+	available := false
+	defer func() {
+		if available {
+			d.SetAvailable(false)
+		}
+	}()
+
+	// the initial availability of devices without any input is decided here since no value is ever received
+	if in.allAvailable() {
+		available = true
+		d.SetAvailable(true)
+		d.compute(registers, in)
+	}
+
+	// routine will return when ctx of the subscription is cancelled
+	for v := range sub.Drain() {
+		changedRef, ok := in.update(v)
+		if !ok {
+			continue
+		}
+
+		if a := in.allAvailable(); a != available {
+			available = a
+			d.SetAvailable(a)
+			if d.Config().LogDebug() {
+				log.Printf("computedDevice[%s]: available=%t", dName, a)
+			}
+		}
+
+		if !available {
+			continue
+		}
+
+		if changedRef == nil {
+			// availability changed, recompute everything
+			d.compute(registers, in)
+		} else {
+			d.compute(filterByReference(registers, *changedRef), in)
+		}
+	}
+
+	return nil, false
+}
+
+func newRegister(r Register) dataflow.RegisterStruct {
+	rt := dataflow.RegisterTypeFromString(r.Type())
+	var enum map[int]string
+	if rt == dataflow.BoolRegister {
+		enum = map[int]string{0: "false", 1: "true"}
+	}
+
+	reg := dataflow.NewRegisterStruct(
+		r.Category(),
+		r.Name(),
+		r.Description(),
+		rt,
+		enum,
+		r.Unit(),
+		r.Sort(),
+		false,
+	)
+	if rt == dataflow.NumberRegister {
+		reg = reg.WithMeta(dataflow.MetaByUnit(r.Unit()))
+	}
+	return reg
+}
+
+func filterByReference(registers []computedRegister, ref expression.Reference) (ret []computedRegister) {
+	for _, r := range registers {
+		for _, rr := range r.expression.References() {
+			if rr == ref {
+				ret = append(ret, r)
+				break
+			}
+		}
+	}
+	return
+}
+
+func (d *DeviceStruct) compute(registers []computedRegister, in *inputs) {
+	dName := d.Name()
+	out := d.Output()
+
+	for _, r := range registers {
+		result, err := r.expression.Eval(in.lookup)
+		if err != nil {
+			if d.Config().LogDebug() {
+				log.Printf("computedDevice[%s]: cannot compute %s: %s", dName, r.register.Name(), err)
+			}
+			out.Fill(dataflow.NewNullRegisterValue(dName, r.register))
+			continue
+		}
+
+		var value dataflow.Value
+		if r.register.RegisterType() == dataflow.BoolRegister {
+			value = dataflow.NewBoolRegisterValue(dName, r.register, result != 0)
+		} else {
+			value = dataflow.NewNumericRegisterValue(dName, r.register, result)
+		}
+		out.Fill(value.WithQuality(in.quality(r.expression.References())))
+	}
+}
@@ -0,0 +1,177 @@
+package computedDevice_test
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/computedDevice"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	registers []computedDevice.Register
+}
+
+func (c testConfig) Name() string                                              { return "computed" }
+func (c testConfig) Filter() dataflow.RegisterFilterConf                       { return nil }
+func (c testConfig) RegisterMeta() map[string]dataflow.RegisterMeta            { return nil }
+func (c testConfig) RegisterTransforms() map[string]dataflow.RegisterTransform { return nil }
+func (c testConfig) Deadband(dataflow.Register) (dataflow.Deadband, bool) {
+	return dataflow.Deadband{}, false
+}
+func (c testConfig) LogDebug() bool                       { return false }
+func (c testConfig) LogComDebug() bool                    { return false }
+func (c testConfig) Registers() []computedDevice.Register { return c.registers }
+
+type testRegister struct {
+	name, expression, registerType string
+}
+
+func (r testRegister) Name() string        { return r.name }
+func (r testRegister) Expression() string  { return r.expression }
+func (r testRegister) Type() string        { return r.registerType }
+func (r testRegister) Category() string    { return "Computed" }
+func (r testRegister) Description() string { return r.name }
+func (r testRegister) Unit() string        { return "" }
+func (r testRegister) Sort() int           { return 0 }
+
+var (
+	voltageRegister = dataflow.NewRegisterStruct("c", "Voltage", "", dataflow.NumberRegister, nil, "V", 0, false)
+	currentRegister = dataflow.NewRegisterStruct("c", "Current", "", dataflow.NumberRegister, nil, "A", 1, false)
+)
+
+type availabilityConfig struct {
+	name string
+}
+
+func (c availabilityConfig) Name() string                                              { return c.name }
+func (c availabilityConfig) Filter() dataflow.RegisterFilterConf                       { return nil }
+func (c availabilityConfig) RegisterMeta() map[string]dataflow.RegisterMeta            { return nil }
+func (c availabilityConfig) RegisterTransforms() map[string]dataflow.RegisterTransform { return nil }
+func (c availabilityConfig) Deadband(dataflow.Register) (dataflow.Deadband, bool) {
+	return dataflow.Deadband{}, false
+}
+func (c availabilityConfig) LogDebug() bool    { return false }
+func (c availabilityConfig) LogComDebug() bool { return false }
+
+func waitForValue(t *testing.T, storage *dataflow.ValueStorage, registerName string, check func(dataflow.Value) bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		for _, v := range storage.GetStateFiltered(func(v dataflow.Value) bool {
+			return v.DeviceName() == "computed" && v.Register().Name() == registerName
+		}) {
+			if check(v) {
+				return
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Errorf("timeout waiting for %s", registerName)
+}
+
+func TestComputedDevice(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	cfg := testConfig{registers: []computedDevice.Register{
+		testRegister{"Power", "bmv0.Voltage * bmv0.Current", "number"},
+		testRegister{"Charging", "bmv0.Current > 0", "bool"},
+	}}
+	dev := computedDevice.NewDevice(cfg, cfg, storage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = dev.Run(ctx)
+	}()
+
+	input := device.NewState(availabilityConfig{"bmv0"}, storage)
+	input.SetAvailable(true)
+	storage.Fill(dataflow.NewNumericRegisterValue("bmv0", voltageRegister, 12.5))
+	storage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, 2))
+
+	waitForValue(t, storage, "Power", func(v dataflow.Value) bool {
+		n, ok := v.(dataflow.NumericRegisterValue)
+		return ok && n.Value() == 25
+	})
+	waitForValue(t, storage, "Charging", func(v dataflow.Value) bool {
+		b, ok := v.(dataflow.BoolRegisterValue)
+		return ok && b.Value()
+	})
+	waitForValue(t, storage, device.AvailabilityRegisterName, func(v dataflow.Value) bool {
+		e, ok := v.(dataflow.EnumRegisterValue)
+		return ok && e.EnumIdx() == 1
+	})
+
+	storage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, -1))
+	waitForValue(t, storage, "Power", func(v dataflow.Value) bool {
+		n, ok := v.(dataflow.NumericRegisterValue)
+		return ok && n.Value() == -12.5
+	})
+
+	// the computed device follows the availability of its inputs
+	input.SetAvailable(false)
+	waitForValue(t, storage, device.AvailabilityRegisterName, func(v dataflow.Value) bool {
+		e, ok := v.(dataflow.EnumRegisterValue)
+		return ok && e.EnumIdx() == 0
+	})
+	waitForValue(t, storage, "Power", func(v dataflow.Value) bool {
+		return v.Quality() == dataflow.QualityCommError
+	})
+
+	cancel()
+	<-done
+}
+
+func TestComputedDeviceBurst(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	cfg := testConfig{registers: []computedDevice.Register{
+		testRegister{"Power", "bmv0.Voltage * bmv0.Current", "number"},
+	}}
+	dev := computedDevice.NewDevice(cfg, cfg, storage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_, _ = dev.Run(ctx)
+	}()
+
+	input := device.NewState(availabilityConfig{"bmv0"}, storage)
+	input.SetAvailable(true)
+	storage.Fill(dataflow.NewNumericRegisterValue("bmv0", voltageRegister, 10))
+
+	// the computed device fills into the storage it is subscribed to; a burst by many concurrent producers
+	// queues the writes of the computed device behind them and must not deadlock the storage
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for p := 0; p < 200; p++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 50; i++ {
+					storage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, float64(i)))
+				}
+			}()
+		}
+		wg.Wait()
+		storage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, 10000))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("storage is deadlocked")
+	}
+
+	waitForValue(t, storage, "Power", func(v dataflow.Value) bool {
+		n, ok := v.(dataflow.NumericRegisterValue)
+		return ok && n.Value() == 100000
+	})
+}
@@ -0,0 +1,113 @@
+package computedDevice
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/expression"
+)
+
+type inputValue struct {
+	value   float64
+	quality dataflow.Quality
+}
+
+// inputs holds the current values of all registers used by the expressions
+// and the availability of the devices they belong to.
+// refs and devices are read-only after creation since filter is called by the storage routine.
+type inputs struct {
+	refs      map[expression.Reference]struct{}
+	devices   map[string]struct{}
+	values    map[expression.Reference]inputValue
+	available map[string]bool // key: device name
+}
+
+func newInputs(registers []computedRegister) *inputs {
+	in := &inputs{
+		refs:      make(map[expression.Reference]struct{}),
+		devices:   make(map[string]struct{}),
+		values:    make(map[expression.Reference]inputValue),
+		available: make(map[string]bool),
+	}
+	for _, r := range registers {
+		for _, ref := range r.expression.References() {
+			in.refs[ref] = struct{}{}
+			in.devices[ref.Device] = struct{}{}
+			in.available[ref.Device] = false
+		}
+	}
+	return in
+}
+
+func (in *inputs) filter(v dataflow.Value) bool {
+	if _, ok := in.devices[v.DeviceName()]; !ok {
+		return false
+	}
+	registerName := v.Register().Name()
+	if registerName == device.AvailabilityRegisterName {
+		return true
+	}
+	_, ok := in.refs[expression.Reference{Device: v.DeviceName(), Register: registerName}]
+	return ok
+}
+
+// update stores the given value. It returns the changed reference or nil when the availability changed.
+func (in *inputs) update(v dataflow.Value) (changed *expression.Reference, ok bool) {
+	ref := expression.Reference{Device: v.DeviceName(), Register: v.Register().Name()}
+
+	if ref.Register == device.AvailabilityRegisterName {
+		if e, isEnum := v.(dataflow.EnumRegisterValue); isEnum {
+			in.available[ref.Device] = e.EnumIdx() == 1
+			return nil, true
+		}
+		if _, isNull := v.(dataflow.NullRegisterValue); isNull {
+			in.available[ref.Device] = false
+			return nil, true
+		}
+		return nil, false
+	}
+
+	var value float64
+	switch tv := v.(type) {
+	case dataflow.NumericRegisterValue:
+		value = tv.Value()
+	case dataflow.IntRegisterValue:
+		value = float64(tv.Value())
+	case dataflow.BoolRegisterValue:
+		value = float64(tv.EnumIdx())
+	case dataflow.EnumRegisterValue:
+		value = float64(tv.EnumIdx())
+	case dataflow.NullRegisterValue:
+		delete(in.values, ref)
+		return &ref, true
+	default:
+		// text values cannot be used in expressions
+		return nil, false
+	}
+
+	in.values[ref] = inputValue{value: value, quality: v.Quality()}
+	return &ref, true
+}
+
+func (in *inputs) allAvailable() bool {
+	for _, a := range in.available {
+		if !a {
+			return false
+		}
+	}
+	return true
+}
+
+func (in *inputs) lookup(ref expression.Reference) (float64, bool) {
+	v, ok := in.values[ref]
+	return v.value, ok
+}
+
+// quality returns the worst quality of the given inputs.
+func (in *inputs) quality(refs []expression.Reference) (q dataflow.Quality) {
+	for _, ref := range refs {
+		if v, ok := in.values[ref]; ok && v.quality > q {
+			q = v.quality
+		}
+	}
+	return
+}
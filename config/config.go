@@ -1,20 +1,25 @@
 package config
 
 import (
-	"bytes"
 	"cmp"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/koestler/go-iotdevice/v3/expression"
+	"github.com/koestler/go-iotdevice/v3/rules"
 	"github.com/koestler/go-iotdevice/v3/types"
 	"github.com/pkg/errors"
 	"golang.org/x/exp/maps"
 	"gopkg.in/yaml.v3"
 	"log"
+	"math"
+	"net/http"
 	"net/url"
 	"os"
+	"reflect"
 	"regexp"
 	"slices"
 	"strings"
+	"text/template"
 	"time"
 )
 
@@ -25,25 +30,37 @@ var nameMatcher = regexp.MustCompile(NameRegexp)
 func ReadConfigFile(exe, source string, bypassFileCheck bool) (config Config, err []error) {
 	yamlStr, e := os.ReadFile(source)
 	if e != nil {
-		return config, []error{fmt.Errorf("cannot read configuration: %v; use see `%s --help`", err, exe)}
+		return config, []error{fmt.Errorf("cannot read configuration: %v; use see `%s --help`", e, exe)}
 	}
 
-	return ReadConfig(yamlStr, bypassFileCheck)
+	return readConfig(yamlStr, source, bypassFileCheck)
 }
 
+// ReadConfig reads the configuration from a string; files given by !include are relative to the working directory.
 func ReadConfig(yamlStr []byte, bypassFileCheck bool) (config Config, err []error) {
-	var configRead configRead
+	return readConfig(yamlStr, "", bypassFileCheck)
+}
 
-	yamlStr = []byte(os.ExpandEnv(string(yamlStr)))
+func readConfig(yamlStr []byte, file string, bypassFileCheck bool) (config Config, err []error) {
+	src, e := parseSource(yamlStr, file)
+	if e != nil {
+		return config, []error{e}
+	}
 
-	d := yaml.NewDecoder(bytes.NewReader(yamlStr))
-	d.KnownFields(true)
+	if err := src.checkKnownFields(src.root, reflect.TypeOf(configRead{})); len(err) > 0 {
+		return config, err
+	}
 
-	if e := d.Decode(&configRead); e != nil {
+	var configRead configRead
+	if e := src.root.Decode(&configRead); e != nil {
 		return config, []error{fmt.Errorf("cannot parse yaml: %s", e)}
 	}
 
-	return configRead.TransformAndValidate(bypassFileCheck)
+	config, err = configRead.TransformAndValidate(bypassFileCheck)
+	for i, e := range err {
+		err[i] = src.locate(e)
+	}
+	return
 }
 
 func (c Config) PrintConfig() (err error) {
@@ -158,6 +175,8 @@ func (c configRead) TransformAndValidate(bypassFileCheck bool) (ret Config, err
 			len(ret.gpioDevices)+
 			len(ret.httpDevices)+
 			len(ret.mqttDevices)+
+			len(c.SystemDevices)+
+			len(c.ComputedDevices)+
 			len(c.GensetDevices),
 	)
 	for _, d := range ret.victronDevices {
@@ -176,6 +195,32 @@ func (c configRead) TransformAndValidate(bypassFileCheck bool) (ret Config, err
 		ret.devices = append(ret.devices, d.DeviceConfig)
 	}
 
+	ret.systemDevices, e = TransformAndValidateMapToList(
+		c.SystemDevices,
+		func(inp systemDeviceConfigRead, name string) (SystemDeviceConfig, []error) {
+			return inp.TransformAndValidate(name)
+		},
+	)
+	err = append(err, e...)
+
+	for _, d := range ret.systemDevices {
+		ret.devices = append(ret.devices, d.DeviceConfig)
+	}
+
+	computedDeviceNames := maps.Keys(c.ComputedDevices)
+	ret.computedDevices, e = TransformAndValidateMapToList(
+		c.ComputedDevices,
+		func(inp computedDeviceConfigRead, name string) (ComputedDeviceConfig, []error) {
+			return inp.TransformAndValidate(name, ret.devices, computedDeviceNames)
+		},
+	)
+	err = append(err, e...)
+	err = append(err, validateComputedDevicesAcyclic(ret.computedDevices)...)
+
+	for _, d := range ret.computedDevices {
+		ret.devices = append(ret.devices, d.DeviceConfig)
+	}
+
 	ret.gensetDevices, e = TransformAndValidateMapToList(
 		c.GensetDevices,
 		func(inp gensetDeviceConfigRead, name string) (GensetDeviceConfig, []error) {
@@ -215,6 +260,46 @@ func (c configRead) TransformAndValidate(bypassFileCheck bool) (ret Config, err
 		}
 	}
 
+	if m := ret.httpServer.metrics; len(m.view) > 0 && !existsByName(m.view, ret.views) {
+		err = append(err, fmt.Errorf("HttpServer->Metrics->View='%s' is not defined", m.view))
+	}
+	if m := ret.httpServer.metrics; m.authenticated && !ret.authentication.enabled {
+		err = append(err, errors.New("HttpServer->Metrics->Authenticated requires the Authentication section"))
+	}
+
+	ret.archive, e = c.Archive.TransformAndValidate(ret.devices)
+	err = append(err, e...)
+
+	ret.influxDbOutputs, e = TransformAndValidateMapToList(
+		c.InfluxDbOutputs,
+		func(inp influxDbOutputConfigRead, name string) (InfluxDbOutputConfig, []error) {
+			return inp.TransformAndValidate(name, ret.devices)
+		},
+	)
+	err = append(err, e...)
+
+	ret.modbusServers, e = TransformAndValidateMapToList(
+		c.ModbusServers,
+		func(inp modbusServerConfigRead, name string) (ModbusServerConfig, []error) {
+			return inp.TransformAndValidate(name, ret.devices)
+		},
+	)
+	err = append(err, e...)
+
+	ret.snapshot, e = c.Snapshot.TransformAndValidate(ret.devices)
+	err = append(err, e...)
+
+	ret.rules, e = TransformAndValidateMapToList(
+		c.Rules,
+		func(inp ruleConfigRead, name string) (RuleConfig, []error) {
+			return inp.TransformAndValidate(name, ret.devices, ret.mqttClients)
+		},
+	)
+	err = append(err, e...)
+
+	ret.alarms, e = c.Alarms.TransformAndValidate(ret.devices)
+	err = append(err, e...)
+
 	return
 }
 
@@ -280,6 +365,10 @@ func (c *httpServerConfigRead) TransformAndValidate() (ret HttpServerConfig, err
 		ret.configExpires = configExpires
 	}
 
+	var e []error
+	ret.metrics, e = c.Metrics.TransformAndValidate()
+	err = append(err, e...)
+
 	if c.LogDebug != nil && *c.LogDebug {
 		ret.logDebug = true
 	}
@@ -287,12 +376,35 @@ func (c *httpServerConfigRead) TransformAndValidate() (ret HttpServerConfig, err
 	return
 }
 
+func (c *metricsConfigRead) TransformAndValidate() (ret MetricsConfig, err []error) {
+	if c == nil {
+		return
+	}
+
+	ret.enabled = true
+	ret.view = c.View
+
+	if c.Filter == nil {
+		c.Filter = &filterConfigRead{}
+	}
+	var e []error
+	ret.filter, e = c.Filter.TransformAndValidate()
+	err = append(err, e...)
+
+	if c.Authenticated != nil && *c.Authenticated {
+		ret.authenticated = true
+	}
+
+	return
+}
+
 func (c *authenticationConfigRead) TransformAndValidate(bypassFileCheck bool) (ret AuthenticationConfig, err []error) {
 	ret.enabled = false
 	ret.jwtValidityPeriod = time.Hour
 
 	if randString, e := randomString(64); err == nil {
 		ret.jwtSecret = []byte(randString)
+		ret.jwtSecretGenerated = true
 	} else {
 		err = append(err, fmt.Errorf("Authentication->JwtSecret: error while generating random secret: %s", e))
 	}
@@ -303,11 +415,26 @@ func (c *authenticationConfigRead) TransformAndValidate(bypassFileCheck bool) (r
 
 	ret.enabled = true
 
-	if c.JwtSecret != nil {
-		if len(*c.JwtSecret) < 32 {
+	jwtSecret := c.JwtSecret
+	if len(c.JwtSecretFile) > 0 {
+		ret.jwtSecretFile = c.JwtSecretFile
+		value := ""
+		if c.JwtSecret != nil {
+			value = *c.JwtSecret
+		}
+		if s, e := readSecret("Authentication", "JwtSecret", value, c.JwtSecretFile); e != nil {
+			err = append(err, e)
+		} else {
+			jwtSecret = &s
+		}
+	}
+
+	if jwtSecret != nil {
+		if len(*jwtSecret) < 32 {
 			err = append(err, fmt.Errorf("Authentication->JwtSecret must be empty or >= 32 chars"))
 		} else {
-			ret.jwtSecret = []byte(*c.JwtSecret)
+			ret.jwtSecret = []byte(*jwtSecret)
+			ret.jwtSecretGenerated = false
 		}
 	}
 
@@ -343,6 +470,13 @@ func (c *authenticationConfigRead) TransformAndValidate(bypassFileCheck bool) (r
 		err = append(err, errors.New("Authentication->HtaccessFile must not be empty"))
 	}
 
+	if len(c.AdminUsers) > 0 {
+		ret.adminUsers = make(map[string]struct{}, len(c.AdminUsers))
+		for _, user := range c.AdminUsers {
+			ret.adminUsers[user] = struct{}{}
+		}
+	}
+
 	return
 }
 
@@ -352,13 +486,19 @@ func (c mqttClientConfigRead) TransformAndValidate(
 	mqttDevices []MqttDeviceConfig,
 ) (ret MqttClientConfig, err []error) {
 	ret = MqttClientConfig{
-		name:     name,
-		user:     c.User,
-		password: c.Password,
+		name:         name,
+		user:         c.User,
+		passwordFile: c.PasswordFile,
 	}
 
 	errPrefix := fmt.Sprintf("MqttClients->%s", ret.name)
 
+	if password, e := readSecret(errPrefix, "Password", c.Password, c.PasswordFile); e != nil {
+		err = append(err, e)
+	} else {
+		ret.password = password
+	}
+
 	if !nameMatcher.MatchString(ret.name) {
 		err = append(err, fmt.Errorf("%s name does not match %s", errPrefix, NameRegexp))
 	}
@@ -385,6 +525,7 @@ func (c mqttClientConfigRead) TransformAndValidate(
 
 	if c.ClientId == nil {
 		ret.clientId = "go-iotdevice-" + uuid.New().String()
+		ret.clientIdGenerated = true
 	} else {
 		ret.clientId = *c.ClientId
 	}
@@ -588,6 +729,22 @@ func (c mqttClientConfigRead) TransformAndValidate(
 	)
 	err = append(err, e...)
 
+	ret.alarms, e = c.Alarms.TransformAndValidate(
+		fmt.Sprintf("%s->Alarms->", errPrefix),
+		nonLoopMqttDevices,
+		ret.readOnly,
+		false,
+		"%Prefix%alarm/%AlarmName%",
+		0,
+		false,
+		false,
+		true,
+		true,
+		true,
+		false,
+	)
+	err = append(err, e...)
+
 	if c.LogDebug != nil && *c.LogDebug {
 		ret.logDebug = true
 	}
@@ -746,6 +903,7 @@ func (c mqttDeviceSectionConfigRead) TransformAndValidate(
 func (c modbusConfigRead) TransformAndValidate(name string) (ret ModbusConfig, err []error) {
 	ret = ModbusConfig{
 		name:     name,
+		kind:     types.ModbusBusSerialKind,
 		device:   c.Device,
 		baudRate: c.BaudRate,
 	}
@@ -754,12 +912,32 @@ func (c modbusConfigRead) TransformAndValidate(name string) (ret ModbusConfig, e
 		err = append(err, fmt.Errorf("Modbus->Name='%s' does not match %s", ret.name, NameRegexp))
 	}
 
-	if len(c.Device) < 1 {
-		err = append(err, fmt.Errorf("ModbusDevices->%s->Device must not be empty", name))
+	if len(c.Kind) > 0 {
+		ret.kind = types.ModbusBusKindFromString(c.Kind)
+		if ret.kind == types.ModbusBusUndefinedKind {
+			err = append(err, fmt.Errorf("Modbus->%s->Kind='%s' is invalid", name, c.Kind))
+		}
 	}
 
-	if c.BaudRate < 1 {
-		err = append(err, fmt.Errorf("ModbusDevices->%s->BaudRate must be positiv", name))
+	switch ret.kind {
+	case types.ModbusBusSerialKind:
+		if len(c.Device) < 1 {
+			err = append(err, fmt.Errorf("ModbusDevices->%s->Device must not be empty", name))
+		}
+
+		if c.BaudRate < 1 {
+			err = append(err, fmt.Errorf("ModbusDevices->%s->BaudRate must be positiv", name))
+		}
+	case types.ModbusBusTcpKind, types.ModbusBusRtuOverTcpKind:
+		if len(c.Url) < 1 {
+			err = append(err, fmt.Errorf("Modbus->%s->Url must not be empty", name))
+		} else if u, e := url.Parse(c.Url); e != nil {
+			err = append(err, fmt.Errorf("Modbus->%s->Url invalid url: %s", name, e))
+		} else if u.Scheme != "tcp" || len(u.Hostname()) < 1 {
+			err = append(err, fmt.Errorf("Modbus->%s->Url='%s' must be of the form tcp://host:port", name, c.Url))
+		} else {
+			ret.url = u
+		}
 	}
 
 	if len(c.ReadTimeout) < 1 {
@@ -777,6 +955,28 @@ func (c modbusConfigRead) TransformAndValidate(name string) (ret ModbusConfig, e
 		ret.readTimeout = readTimeout
 	}
 
+	if len(c.InterFrameDelay) < 1 {
+		if ret.kind == types.ModbusBusSerialKind && ret.baudRate > 0 {
+			// use default: the silence of 3.5 characters of 11 bits defined by the modbus rtu specification,
+			// a fixed value of 1.75ms is recommended above 19200 baud
+			if ret.baudRate > 19200 {
+				ret.interFrameDelay = 1750 * time.Microsecond
+			} else {
+				ret.interFrameDelay = time.Duration(3.5 * 11 * float64(time.Second) / float64(ret.baudRate))
+			}
+		}
+	} else if interFrameDelay, e := time.ParseDuration(c.InterFrameDelay); e != nil {
+		err = append(err, fmt.Errorf("Modbus->%s->InterFrameDelay='%s' parse error: %s",
+			name, c.InterFrameDelay, e,
+		))
+	} else if interFrameDelay < 0 {
+		err = append(err, fmt.Errorf("Modbus->%s->InterFrameDelay='%s' must be >=0",
+			name, c.InterFrameDelay,
+		))
+	} else {
+		ret.interFrameDelay = interFrameDelay
+	}
+
 	if c.LogDebug != nil && *c.LogDebug {
 		ret.logDebug = true
 	}
@@ -827,6 +1027,69 @@ func (c deviceConfigRead) TransformAndValidate(name string) (ret DeviceConfig, e
 		ret.restartIntervalMaxBackoff = restartIntervalMaxBackoff
 	}
 
+	if len(c.MaxAge) < 1 {
+		// use default 0s; never mark values as stale
+		ret.maxAge = 0
+	} else if maxAge, e := time.ParseDuration(c.MaxAge); e != nil {
+		err = append(err, fmt.Errorf("Devices->%s->MaxAge='%s' parse error: %s",
+			name, c.MaxAge, e,
+		))
+	} else if maxAge < 0 {
+		err = append(err, fmt.Errorf("Devices->%s->MaxAge='%s' must be >=0",
+			name, c.MaxAge,
+		))
+	} else {
+		ret.maxAge = maxAge
+	}
+
+	ret.registerMaxAge = make(map[string]time.Duration, len(c.RegisterMaxAge))
+	for registerName, v := range c.RegisterMaxAge {
+		if maxAge, e := time.ParseDuration(v); e != nil {
+			err = append(err, fmt.Errorf("Devices->%s->RegisterMaxAge->%s='%s' parse error: %s",
+				name, registerName, v, e,
+			))
+		} else if maxAge < 0 {
+			err = append(err, fmt.Errorf("Devices->%s->RegisterMaxAge->%s='%s' must be >=0",
+				name, registerName, v,
+			))
+		} else {
+			ret.registerMaxAge[registerName] = maxAge
+		}
+	}
+
+	ret.registerMeta = make(map[string]RegisterMetaConfig, len(c.RegisterMeta))
+	for registerName, v := range c.RegisterMeta {
+		ret.registerMeta[registerName], e = v.TransformAndValidate(name, registerName)
+		err = append(err, e...)
+	}
+
+	ret.registerTransform = make(map[string]RegisterTransformConfig, len(c.RegisterTransform))
+	for registerName, v := range c.RegisterTransform {
+		ret.registerTransform[registerName], e = v.TransformAndValidate(name, registerName)
+		err = append(err, e...)
+	}
+
+	ret.registerDeadband = make(map[string]DeadbandConfig, len(c.RegisterDeadband))
+	for registerName, v := range c.RegisterDeadband {
+		ret.registerDeadband[registerName], e = v.TransformAndValidate(
+			fmt.Sprintf("Devices->%s->RegisterDeadband->%s->", name, registerName),
+		)
+		err = append(err, e...)
+	}
+
+	ret.categoryDeadband = make(map[string]DeadbandConfig, len(c.CategoryDeadband))
+	for category, v := range c.CategoryDeadband {
+		ret.categoryDeadband[category], e = v.TransformAndValidate(
+			fmt.Sprintf("Devices->%s->CategoryDeadband->%s->", name, category),
+		)
+		err = append(err, e...)
+	}
+
+	if c.History != nil {
+		ret.history, e = c.History.TransformAndValidate(name)
+		err = append(err, e...)
+	}
+
 	if c.LogDebug != nil && *c.LogDebug {
 		ret.logDebug = true
 	}
@@ -838,6 +1101,117 @@ func (c deviceConfigRead) TransformAndValidate(name string) (ret DeviceConfig, e
 	return
 }
 
+func (c registerMetaConfigRead) TransformAndValidate(deviceName, registerName string) (ret RegisterMetaConfig, err []error) {
+	ret = RegisterMetaConfig{
+		min:         c.Min,
+		max:         c.Max,
+		step:        c.Step,
+		precision:   c.Precision,
+		deviceClass: c.DeviceClass,
+		stateClass:  c.StateClass,
+	}
+
+	if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+		err = append(err, fmt.Errorf("Devices->%s->RegisterMeta->%s->Min=%g must be <= Max=%g",
+			deviceName, registerName, *c.Min, *c.Max,
+		))
+	}
+
+	if c.Step != nil && *c.Step <= 0 {
+		err = append(err, fmt.Errorf("Devices->%s->RegisterMeta->%s->Step=%g must be >0",
+			deviceName, registerName, *c.Step,
+		))
+	}
+
+	if c.Precision != nil && *c.Precision < 0 {
+		err = append(err, fmt.Errorf("Devices->%s->RegisterMeta->%s->Precision=%d must be >=0",
+			deviceName, registerName, *c.Precision,
+		))
+	}
+
+	switch c.StateClass {
+	case "", "measurement", "total", "total_increasing":
+	default:
+		err = append(err, fmt.Errorf("Devices->%s->RegisterMeta->%s->StateClass='%s' is invalid, "+
+			"must be measurement, total or total_increasing",
+			deviceName, registerName, c.StateClass,
+		))
+	}
+
+	return
+}
+
+func (c registerTransformConfigRead) TransformAndValidate(deviceName, registerName string) (ret RegisterTransformConfig, err []error) {
+	ret = RegisterTransformConfig{
+		scale:       c.Scale,
+		offset:      c.Offset,
+		unit:        c.Unit,
+		round:       c.Round,
+		description: c.Description,
+		category:    c.Category,
+	}
+
+	if c.Scale != nil && *c.Scale == 0 {
+		err = append(err, fmt.Errorf("Devices->%s->RegisterTransform->%s->Scale must not be 0",
+			deviceName, registerName,
+		))
+	}
+
+	if c.Round != nil && *c.Round < 0 {
+		err = append(err, fmt.Errorf("Devices->%s->RegisterTransform->%s->Round=%d must be >=0",
+			deviceName, registerName, *c.Round,
+		))
+	}
+
+	return
+}
+
+func (c deadbandConfigRead) TransformAndValidate(logPrefix string) (ret DeadbandConfig, err []error) {
+	if c.Absolute == nil {
+		// use default 0; disabled
+	} else if *c.Absolute < 0 {
+		err = append(err, fmt.Errorf("%sAbsolute=%g must be >=0", logPrefix, *c.Absolute))
+	} else {
+		ret.absolute = *c.Absolute
+	}
+
+	if c.Percent == nil {
+		// use default 0; disabled
+	} else if *c.Percent < 0 {
+		err = append(err, fmt.Errorf("%sPercent=%g must be >=0", logPrefix, *c.Percent))
+	} else {
+		ret.percent = *c.Percent
+	}
+
+	if len(c.MinInterval) < 1 {
+		// use default 0s; disabled
+	} else if d, e := time.ParseDuration(c.MinInterval); e != nil {
+		err = append(err, fmt.Errorf("%sMinInterval='%s' parse error: %s", logPrefix, c.MinInterval, e))
+	} else if d < 0 {
+		err = append(err, fmt.Errorf("%sMinInterval='%s' must be >=0", logPrefix, c.MinInterval))
+	} else {
+		ret.minInterval = d
+	}
+
+	if len(c.MaxInterval) < 1 {
+		// use default 0s; disabled
+	} else if d, e := time.ParseDuration(c.MaxInterval); e != nil {
+		err = append(err, fmt.Errorf("%sMaxInterval='%s' parse error: %s", logPrefix, c.MaxInterval, e))
+	} else if d < 0 {
+		err = append(err, fmt.Errorf("%sMaxInterval='%s' must be >=0", logPrefix, c.MaxInterval))
+	} else {
+		ret.maxInterval = d
+	}
+
+	if ret.maxInterval > 0 && ret.maxInterval <= ret.minInterval {
+		err = append(err, fmt.Errorf("%sMaxInterval=%s must be greater than MinInterval=%s",
+			logPrefix, ret.maxInterval, ret.minInterval,
+		))
+	}
+
+	return
+}
+
 func (c victronDeviceConfigRead) TransformAndValidate(name string) (ret VictronDeviceConfig, err []error) {
 	ret = VictronDeviceConfig{
 		kind:   types.VictronDeviceKindFromString(c.Kind),
@@ -898,7 +1272,9 @@ func (c modbusDeviceConfigRead) TransformAndValidate(
 		err = append(err, fmt.Errorf("ModbusDevices->%s: Bus='%s' is not defidnedd", name, c.Bus))
 	}
 
-	if strings.Contains(c.Address, "0x") {
+	if ret.kind == types.ModbusBusStatusKind && len(c.Address) < 1 {
+		// the bus status device does not talk to a slave
+	} else if strings.Contains(c.Address, "0x") {
 		if n, e := fmt.Sscanf(c.Address, "0x%x", &ret.address); n != 1 || e != nil {
 			err = append(err, fmt.Errorf("ModbusDevices->%s: hex Adress=%s is invalid: %s", name, c.Address, e))
 		}
@@ -916,6 +1292,27 @@ func (c modbusDeviceConfigRead) TransformAndValidate(
 	)
 	err = append(err, e...)
 
+	if ret.kind == types.ModbusGenericKind && len(c.Registers) < 1 {
+		err = append(err, fmt.Errorf("ModbusDevices->%s->Registers must not be empty for Kind=Generic", name))
+	} else if ret.kind != types.ModbusGenericKind && len(c.Registers) > 0 {
+		err = append(err, fmt.Errorf("ModbusDevices->%s->Registers is only supported for Kind=Generic", name))
+	}
+
+	ret.registers, e = TransformAndValidateMapToList(
+		c.Registers,
+		func(inp modbusRegisterConfigRead, registerName string) (ModbusRegisterConfig, []error) {
+			return inp.TransformAndValidate(name, registerName)
+		},
+	)
+	err = append(err, e...)
+
+	for i := range ret.registers {
+		if c.Registers[ret.registers[i].name].Sort == nil {
+			// use default: order by name
+			ret.registers[i].sort = i
+		}
+	}
+
 	if len(c.PollInterval) < 1 {
 		// use default 1s
 		ret.pollInterval = time.Second
@@ -931,77 +1328,413 @@ func (c modbusDeviceConfigRead) TransformAndValidate(
 		ret.pollInterval = pollInterval
 	}
 
-	return
-}
-
-func (c relayConfigRead) TransformAndValidate() (ret RelayConfig, err []error) {
-	if c.Description != nil {
-		ret.description = *c.Description
+	if c.MaxReadGap != nil {
+		if *c.MaxReadGap < 0 || *c.MaxReadGap > 100 {
+			err = append(err, fmt.Errorf("ModbusDevices->%s->MaxReadGap=%d must be between 0 and 100", name, *c.MaxReadGap))
+		} else {
+			ret.maxReadGap = *c.MaxReadGap
+		}
 	}
 
-	if c.OpenLabel != nil {
-		ret.openLabel = *c.OpenLabel
+	if len(c.ReadTimeout) > 0 {
+		if readTimeout, e := time.ParseDuration(c.ReadTimeout); e != nil {
+			err = append(err, fmt.Errorf("ModbusDevices->%s->ReadTimeout='%s' parse error: %s",
+				name, c.ReadTimeout, e,
+			))
+		} else if readTimeout < time.Millisecond {
+			err = append(err, fmt.Errorf("ModbusDevices->%s->ReadTimeout='%s' must be >=1ms",
+				name, c.ReadTimeout,
+			))
+		} else {
+			ret.readTimeout = readTimeout
+		}
 	}
 
-	if c.ClosedLabel != nil {
-		ret.closedLabel = *c.ClosedLabel
+	if c.Retries != nil {
+		if *c.Retries < 0 || *c.Retries > 10 {
+			err = append(err, fmt.Errorf("ModbusDevices->%s->Retries=%d must be between 0 and 10", name, *c.Retries))
+		} else {
+			ret.retries = *c.Retries
+		}
 	}
 
 	return
 }
 
-func (c gpioDeviceConfigRead) TransformAndValidate(deviceName string) (ret GpioDeviceConfig, err []error) {
-	var e []error
-	ret.DeviceConfig, e = c.deviceConfigRead.TransformAndValidate(deviceName)
-	err = append(err, e...)
+// modbusRegisterLengths holds the number of 16 bit registers used by every fixed size type.
+var modbusRegisterLengths = map[string]int{
+	"bool":    1,
+	"u16":     1,
+	"i16":     1,
+	"u32":     2,
+	"i32":     2,
+	"float32": 2,
+	"float64": 4,
+}
 
-	if c.Chip == nil {
-		ret.chip = "gpiochip0"
-	} else if len(*c.Chip) < 1 {
-		err = append(err, fmt.Errorf("GpioDevices->%s->Chip must not be empty", deviceName))
+func (c modbusRegisterConfigRead) TransformAndValidate(
+	deviceName, registerName string,
+) (ret ModbusRegisterConfig, err []error) {
+	errPrefix := fmt.Sprintf("ModbusDevices->%s->Registers->%s", deviceName, registerName)
+
+	ret = ModbusRegisterConfig{
+		name:         registerName,
+		function:     c.Function,
+		registerType: c.Type,
+		wordOrder:    c.WordOrder,
+		byteOrder:    c.ByteOrder,
+		scale:        1,
+		unit:         c.Unit,
+		enum:         c.Enum,
+		category:     c.Category,
+		description:  c.Description,
+	}
+
+	bitFunction := false
+	switch c.Function {
+	case "Coil", "DiscreteInput":
+		bitFunction = true
+	case "Holding", "Input":
+	default:
+		err = append(err, fmt.Errorf("%s->Function='%s' must be Coil, DiscreteInput, Holding or Input",
+			errPrefix, c.Function,
+		))
+	}
+
+	if c.Address == nil {
+		err = append(err, fmt.Errorf("%s->Address must be set", errPrefix))
+	} else if *c.Address < 0 || *c.Address > math.MaxUint16 {
+		err = append(err, fmt.Errorf("%s->Address=%d must be between 0 and %d", errPrefix, *c.Address, math.MaxUint16))
 	} else {
-		ret.chip = *c.Chip
+		ret.address = uint16(*c.Address)
 	}
 
-	if len(c.InputDebounce) < 1 {
-		// use default 100ms
-		ret.inputDebounce = 100 * time.Millisecond
-	} else if pollInterval, e := time.ParseDuration(c.InputDebounce); e != nil {
-		err = append(err, fmt.Errorf("GpioDevices->%s->InputDebounce='%s' parse error: %s",
-			deviceName, c.InputDebounce, e,
+	if len(ret.registerType) < 1 {
+		// use default: the natural type of the function
+		if bitFunction {
+			ret.registerType = "bool"
+		} else {
+			ret.registerType = "u16"
+		}
+	}
+
+	if bitFunction && ret.registerType != "bool" {
+		err = append(err, fmt.Errorf("%s->Type='%s' must be bool for Function=%s", errPrefix, c.Type, c.Function))
+	} else if !bitFunction && ret.registerType == "bool" {
+		err = append(err, fmt.Errorf("%s->Type='%s' is only supported for Function=Coil or DiscreteInput", errPrefix, c.Type))
+	}
+
+	if ret.registerType == "string" {
+		if c.Length == nil || *c.Length < 1 || *c.Length > 125 {
+			err = append(err, fmt.Errorf("%s->Length must be between 1 and 125 registers for Type=string", errPrefix))
+		} else {
+			ret.length = *c.Length
+		}
+	} else if l, ok := modbusRegisterLengths[ret.registerType]; !ok {
+		err = append(err, fmt.Errorf("%s->Type='%s' must be bool, u16, i16, u32, i32, float32, float64 or string",
+			errPrefix, c.Type,
 		))
+	} else if c.Length != nil && *c.Length != l {
+		err = append(err, fmt.Errorf("%s->Length=%d must be %d for Type=%s", errPrefix, *c.Length, l, ret.registerType))
 	} else {
-		ret.inputDebounce = pollInterval
+		ret.length = l
+	}
+
+	for _, o := range []struct {
+		field string
+		value *string
+	}{
+		{"WordOrder", &ret.wordOrder},
+		{"ByteOrder", &ret.byteOrder},
+	} {
+		if len(*o.value) < 1 {
+			// use default: as defined by the modbus specification
+			*o.value = "BigEndian"
+		} else if *o.value != "BigEndian" && *o.value != "LittleEndian" {
+			err = append(err, fmt.Errorf("%s->%s='%s' must be BigEndian or LittleEndian", errPrefix, o.field, *o.value))
+		}
 	}
 
-	ret.inputOptions = make([]string, 0)
-	for _, opt := range c.InputOptions {
-		switch opt {
-		case "WithBiasDisabled", "WithPullDown", "WithPullUp":
-			ret.inputOptions = append(ret.inputOptions, opt)
-		default:
-			err = append(err, fmt.Errorf("GpioDevices->%s->InputOptions='%s' is invalid", deviceName, opt))
+	if c.Scale != nil {
+		if *c.Scale == 0 {
+			err = append(err, fmt.Errorf("%s->Scale must not be 0", errPrefix))
+		} else {
+			ret.scale = *c.Scale
 		}
 	}
-	if len(ret.inputOptions) > 1 {
-		err = append(err, fmt.Errorf("GpioDevices->%s->InputOptions must not contain more than one option", deviceName))
+
+	if len(c.Enum) > 0 && (ret.registerType == "string" || strings.HasPrefix(ret.registerType, "float")) {
+		err = append(err, fmt.Errorf("%s->Enum is not supported for Type=%s", errPrefix, ret.registerType))
 	}
 
-	ret.outputOptions = make([]string, 0)
-	for _, opt := range c.OutputOptions {
-		switch opt {
-		case "AsOpenDrain", "AsOpenSource", "AsPushPull":
-			ret.outputOptions = append(ret.outputOptions, opt)
-		default:
-			err = append(err, fmt.Errorf("GpioDevices->%s->OutputOptions='%s' is invalid", deviceName, opt))
-		}
+	if len(ret.category) < 1 {
+		// use default
+		ret.category = "Registers"
 	}
-	if len(ret.outputOptions) > 1 {
-		err = append(err, fmt.Errorf("GpioDevices->%s->OutputOptions must not contain more than one option", deviceName))
+
+	if len(ret.description) < 1 {
+		// use default: register name
+		ret.description = registerName
 	}
 
-	ret.inputs, e = TransformAndValidateMapToList(
-		c.Inputs,
+	if c.Sort != nil {
+		ret.sort = *c.Sort
+	}
+
+	if c.Writable != nil && *c.Writable {
+		ret.writable = true
+		if c.Function != "Coil" && c.Function != "Holding" {
+			err = append(err, fmt.Errorf("%s->Writable is only supported for Function=Coil or Holding", errPrefix))
+		} else if ret.registerType == "string" {
+			err = append(err, fmt.Errorf("%s->Writable is not supported for Type=string", errPrefix))
+		}
+	}
+
+	return
+}
+
+func (c modbusServerConfigRead) TransformAndValidate(name string, devices []DeviceConfig) (ret ModbusServerConfig, err []error) {
+	ret = ModbusServerConfig{
+		name:     name,
+		kind:     types.ModbusServerTcpKind,
+		device:   c.Device,
+		baudRate: c.BaudRate,
+		unitId:   1,
+	}
+
+	errPrefix := fmt.Sprintf("ModbusServers->%s", name)
+
+	if !nameMatcher.MatchString(ret.name) {
+		err = append(err, fmt.Errorf("%s name does not match %s", errPrefix, NameRegexp))
+	}
+
+	if len(c.Kind) > 0 {
+		ret.kind = types.ModbusServerKindFromString(c.Kind)
+		if ret.kind == types.ModbusServerUndefinedKind {
+			err = append(err, fmt.Errorf("%s->Kind='%s' is invalid", errPrefix, c.Kind))
+		}
+	}
+
+	switch ret.kind {
+	case types.ModbusServerTcpKind:
+		// use default: listen on all interfaces
+		ret.bind = c.Bind
+
+		if c.Port == nil {
+			// use default: the modbus tcp port
+			ret.port = 502
+		} else if *c.Port < 1 || *c.Port > math.MaxUint16 {
+			err = append(err, fmt.Errorf("%s->Port=%d must be between 1 and %d", errPrefix, *c.Port, math.MaxUint16))
+		} else {
+			ret.port = *c.Port
+		}
+	case types.ModbusServerRtuKind:
+		if len(c.Device) < 1 {
+			err = append(err, fmt.Errorf("%s->Device must not be empty", errPrefix))
+		}
+
+		if c.BaudRate < 1 {
+			err = append(err, fmt.Errorf("%s->BaudRate must be positiv", errPrefix))
+		}
+	}
+
+	if c.UnitId != nil {
+		if *c.UnitId < 1 || *c.UnitId > 247 {
+			err = append(err, fmt.Errorf("%s->UnitId=%d must be between 1 and 247", errPrefix, *c.UnitId))
+		} else {
+			ret.unitId = byte(*c.UnitId)
+		}
+	}
+
+	if len(c.Registers) < 1 {
+		err = append(err, fmt.Errorf("%s->Registers must not be empty", errPrefix))
+	}
+	ret.registers = make([]ModbusServerRegisterConfig, len(c.Registers))
+	for i, r := range c.Registers {
+		var e []error
+		ret.registers[i], e = r.TransformAndValidate(fmt.Sprintf("%s->Registers[%d]->", errPrefix, i), devices)
+		err = append(err, e...)
+	}
+
+	// every address must be mapped at most once per function
+	for i, a := range ret.registers {
+		for j, b := range ret.registers[:i] {
+			if a.function == b.function && a.length > 0 && b.length > 0 &&
+				int(a.address) < int(b.address)+b.length && int(b.address) < int(a.address)+a.length {
+				err = append(err, fmt.Errorf("%s->Registers[%d]->Address=%d overlaps with Registers[%d]",
+					errPrefix, i, a.address, j,
+				))
+			}
+		}
+	}
+
+	if c.LogDebug != nil && *c.LogDebug {
+		ret.logDebug = true
+	}
+
+	return
+}
+
+func (c modbusServerRegisterConfigRead) TransformAndValidate(
+	logPrefix string,
+	devices []DeviceConfig,
+) (ret ModbusServerRegisterConfig, err []error) {
+	ret = ModbusServerRegisterConfig{
+		function:     c.Function,
+		device:       c.Device,
+		register:     c.Register,
+		registerType: c.Type,
+		wordOrder:    c.WordOrder,
+		byteOrder:    c.ByteOrder,
+		scale:        1,
+	}
+
+	bitFunction := false
+	switch c.Function {
+	case "Coil", "DiscreteInput":
+		bitFunction = true
+	case "Holding", "Input":
+	default:
+		err = append(err, fmt.Errorf("%sFunction='%s' must be Coil, DiscreteInput, Holding or Input",
+			logPrefix, c.Function,
+		))
+	}
+
+	if len(c.Device) < 1 {
+		err = append(err, fmt.Errorf("%sDevice must not be empty", logPrefix))
+	} else if !existsByName(c.Device, devices) {
+		err = append(err, fmt.Errorf("%sDevice='%s' is not defined", logPrefix, c.Device))
+	}
+
+	if len(c.Register) < 1 {
+		err = append(err, fmt.Errorf("%sRegister must not be empty", logPrefix))
+	}
+
+	if len(ret.registerType) < 1 {
+		// use default: the natural type of the function
+		if bitFunction {
+			ret.registerType = "bool"
+		} else {
+			ret.registerType = "u16"
+		}
+	}
+
+	if l, ok := modbusRegisterLengths[ret.registerType]; !ok {
+		err = append(err, fmt.Errorf("%sType='%s' must be bool, u16, i16, u32, i32, float32 or float64",
+			logPrefix, c.Type,
+		))
+	} else if bitFunction && ret.registerType != "bool" {
+		err = append(err, fmt.Errorf("%sType='%s' must be bool for Function=%s", logPrefix, c.Type, c.Function))
+	} else if !bitFunction && ret.registerType == "bool" {
+		err = append(err, fmt.Errorf("%sType='%s' is only supported for Function=Coil or DiscreteInput", logPrefix, c.Type))
+	} else {
+		ret.length = l
+	}
+
+	if c.Address == nil {
+		err = append(err, fmt.Errorf("%sAddress must be set", logPrefix))
+	} else if *c.Address < 0 || *c.Address+ret.length > math.MaxUint16+1 {
+		err = append(err, fmt.Errorf("%sAddress=%d must be between 0 and %d", logPrefix, *c.Address, math.MaxUint16+1-max(ret.length, 1)))
+	} else {
+		ret.address = uint16(*c.Address)
+	}
+
+	for _, o := range []struct {
+		field string
+		value *string
+	}{
+		{"WordOrder", &ret.wordOrder},
+		{"ByteOrder", &ret.byteOrder},
+	} {
+		if len(*o.value) < 1 {
+			// use default: as defined by the modbus specification
+			*o.value = "BigEndian"
+		} else if *o.value != "BigEndian" && *o.value != "LittleEndian" {
+			err = append(err, fmt.Errorf("%s%s='%s' must be BigEndian or LittleEndian", logPrefix, o.field, *o.value))
+		}
+	}
+
+	if c.Scale != nil {
+		if *c.Scale == 0 {
+			err = append(err, fmt.Errorf("%sScale must not be 0", logPrefix))
+		} else if bitFunction {
+			err = append(err, fmt.Errorf("%sScale is not supported for Function=%s", logPrefix, c.Function))
+		} else {
+			ret.scale = *c.Scale
+		}
+	}
+
+	return
+}
+
+func (c relayConfigRead) TransformAndValidate() (ret RelayConfig, err []error) {
+	if c.Description != nil {
+		ret.description = *c.Description
+	}
+
+	if c.OpenLabel != nil {
+		ret.openLabel = *c.OpenLabel
+	}
+
+	if c.ClosedLabel != nil {
+		ret.closedLabel = *c.ClosedLabel
+	}
+
+	return
+}
+
+func (c gpioDeviceConfigRead) TransformAndValidate(deviceName string) (ret GpioDeviceConfig, err []error) {
+	var e []error
+	ret.DeviceConfig, e = c.deviceConfigRead.TransformAndValidate(deviceName)
+	err = append(err, e...)
+
+	if c.Chip == nil {
+		ret.chip = "gpiochip0"
+	} else if len(*c.Chip) < 1 {
+		err = append(err, fmt.Errorf("GpioDevices->%s->Chip must not be empty", deviceName))
+	} else {
+		ret.chip = *c.Chip
+	}
+
+	if len(c.InputDebounce) < 1 {
+		// use default 100ms
+		ret.inputDebounce = 100 * time.Millisecond
+	} else if pollInterval, e := time.ParseDuration(c.InputDebounce); e != nil {
+		err = append(err, fmt.Errorf("GpioDevices->%s->InputDebounce='%s' parse error: %s",
+			deviceName, c.InputDebounce, e,
+		))
+	} else {
+		ret.inputDebounce = pollInterval
+	}
+
+	ret.inputOptions = make([]string, 0)
+	for _, opt := range c.InputOptions {
+		switch opt {
+		case "WithBiasDisabled", "WithPullDown", "WithPullUp":
+			ret.inputOptions = append(ret.inputOptions, opt)
+		default:
+			err = append(err, fmt.Errorf("GpioDevices->%s->InputOptions='%s' is invalid", deviceName, opt))
+		}
+	}
+	if len(ret.inputOptions) > 1 {
+		err = append(err, fmt.Errorf("GpioDevices->%s->InputOptions must not contain more than one option", deviceName))
+	}
+
+	ret.outputOptions = make([]string, 0)
+	for _, opt := range c.OutputOptions {
+		switch opt {
+		case "AsOpenDrain", "AsOpenSource", "AsPushPull":
+			ret.outputOptions = append(ret.outputOptions, opt)
+		default:
+			err = append(err, fmt.Errorf("GpioDevices->%s->OutputOptions='%s' is invalid", deviceName, opt))
+		}
+	}
+	if len(ret.outputOptions) > 1 {
+		err = append(err, fmt.Errorf("GpioDevices->%s->OutputOptions must not contain more than one option", deviceName))
+	}
+
+	ret.inputs, e = TransformAndValidateMapToList(
+		c.Inputs,
 		func(inp pinConfigRead, name string) (PinConfig, []error) {
 			return inp.TransformAndValidate(name, fmt.Sprintf("GpioDevices->%s->Inputs->%s", deviceName, name))
 		},
@@ -1053,15 +1786,21 @@ func (c pinConfigRead) TransformAndValidate(name, errPrefix string) (ret PinConf
 
 func (c httpDeviceConfigRead) TransformAndValidate(name string) (ret HttpDeviceConfig, err []error) {
 	ret = HttpDeviceConfig{
-		kind:     types.HttpDeviceKindFromString(c.Kind),
-		username: c.Username,
-		password: c.Password,
+		kind:         types.HttpDeviceKindFromString(c.Kind),
+		username:     c.Username,
+		passwordFile: c.PasswordFile,
 	}
 
 	var e []error
 	ret.DeviceConfig, e = c.deviceConfigRead.TransformAndValidate(name)
 	err = append(err, e...)
 
+	if password, e := readSecret(fmt.Sprintf("HttpDevices->%s", name), "Password", c.Password, c.PasswordFile); e != nil {
+		err = append(err, e)
+	} else {
+		ret.password = password
+	}
+
 	if len(c.Url) < 1 {
 		err = append(err, fmt.Errorf("HttpDevices->%s->Url must not be empty", name))
 	} else {
@@ -1112,6 +1851,178 @@ func (c mqttDeviceConfigRead) TransformAndValidate(name string) (ret MqttDeviceC
 	return
 }
 
+func (c systemDeviceConfigRead) TransformAndValidate(name string) (ret SystemDeviceConfig, err []error) {
+	var e []error
+	ret.DeviceConfig, e = c.deviceConfigRead.TransformAndValidate(name)
+	err = append(err, e...)
+
+	if len(c.PollInterval) < 1 {
+		// use default 10s
+		ret.pollInterval = 10 * time.Second
+	} else if pollInterval, e := time.ParseDuration(c.PollInterval); e != nil {
+		err = append(err, fmt.Errorf("SystemDevices->%s->PollInterval='%s' parse error: %s",
+			name, c.PollInterval, e,
+		))
+	} else if pollInterval < time.Second {
+		err = append(err, fmt.Errorf("SystemDevices->%s->PollInterval='%s' must be >=1s",
+			name, c.PollInterval,
+		))
+	} else {
+		ret.pollInterval = pollInterval
+	}
+
+	if len(c.DiskPath) < 1 {
+		// use default: root filesystem
+		ret.diskPath = "/"
+	} else {
+		ret.diskPath = c.DiskPath
+	}
+
+	return
+}
+
+func (c computedDeviceConfigRead) TransformAndValidate(
+	name string, devices []DeviceConfig, computedDeviceNames []string,
+) (ret ComputedDeviceConfig, err []error) {
+	var e []error
+	ret.DeviceConfig, e = c.deviceConfigRead.TransformAndValidate(name)
+	err = append(err, e...)
+
+	if len(c.Registers) < 1 {
+		err = append(err, fmt.Errorf("ComputedDevices->%s->Registers must not be empty", name))
+	}
+
+	ret.registers, e = TransformAndValidateMapToList(
+		c.Registers,
+		func(inp computedRegisterConfigRead, registerName string) (ComputedRegisterConfig, []error) {
+			return inp.TransformAndValidate(name, registerName, devices, computedDeviceNames)
+		},
+	)
+	err = append(err, e...)
+
+	for i := range ret.registers {
+		if c.Registers[ret.registers[i].name].Sort == nil {
+			// use default: order by name
+			ret.registers[i].sort = i
+		}
+	}
+
+	return
+}
+
+func (c computedRegisterConfigRead) TransformAndValidate(
+	deviceName, registerName string, devices []DeviceConfig, computedDeviceNames []string,
+) (ret ComputedRegisterConfig, err []error) {
+	ret = ComputedRegisterConfig{
+		name:         registerName,
+		expression:   c.Expression,
+		registerType: c.Type,
+		category:     c.Category,
+		description:  c.Description,
+		unit:         c.Unit,
+	}
+
+	if len(ret.registerType) < 1 {
+		// use default number
+		ret.registerType = "number"
+	} else if ret.registerType != "number" && ret.registerType != "bool" {
+		err = append(err, fmt.Errorf("ComputedDevices->%s->Registers->%s->Type='%s' must be number or bool",
+			deviceName, registerName, c.Type,
+		))
+	}
+
+	if len(ret.category) < 1 {
+		// use default
+		ret.category = "Computed"
+	}
+
+	if len(ret.description) < 1 {
+		// use default: register name
+		ret.description = registerName
+	}
+
+	if c.Sort != nil {
+		ret.sort = *c.Sort
+	}
+
+	if len(c.Expression) < 1 {
+		err = append(err, fmt.Errorf("ComputedDevices->%s->Registers->%s->Expression must not be empty",
+			deviceName, registerName,
+		))
+		return
+	}
+
+	expr, e := expression.Parse(c.Expression)
+	if e != nil {
+		err = append(err, fmt.Errorf("ComputedDevices->%s->Registers->%s->Expression='%s' parse error: %s",
+			deviceName, registerName, c.Expression, e,
+		))
+		return
+	}
+
+	for _, ref := range expr.References() {
+		if ref.Device == deviceName {
+			err = append(err, fmt.Errorf("ComputedDevices->%s->Registers->%s->Expression='%s' must not reference its own device",
+				deviceName, registerName, c.Expression,
+			))
+		} else if !existsByName(ref.Device, devices) && !slices.Contains(computedDeviceNames, ref.Device) {
+			err = append(err, fmt.Errorf("ComputedDevices->%s->Registers->%s->Expression='%s': device='%s' is not defined",
+				deviceName, registerName, c.Expression, ref.Device,
+			))
+		}
+	}
+
+	return
+}
+
+// validateComputedDevicesAcyclic makes sure computed devices do not depend on each other in a loop.
+func validateComputedDevicesAcyclic(computedDevices []ComputedDeviceConfig) (err []error) {
+	deps := make(map[string][]string, len(computedDevices))
+	for _, d := range computedDevices {
+		for _, r := range d.registers {
+			expr, e := expression.Parse(r.expression)
+			if e != nil {
+				continue // reported by TransformAndValidate
+			}
+			for _, ref := range expr.References() {
+				deps[d.Name()] = append(deps[d.Name()], ref.Device)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(deps))
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch state[name] {
+		case visiting:
+			return false
+		case done:
+			return true
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if !visit(dep) {
+				return false
+			}
+		}
+		state[name] = done
+		return true
+	}
+
+	for _, d := range computedDevices {
+		if !visit(d.Name()) {
+			err = append(err, fmt.Errorf("ComputedDevices->%s: circular dependency between computed devices", d.Name()))
+			return
+		}
+	}
+	return
+}
+
 func (c gensetDeviceConfigRead) TransformAndValidate(name string, devices []DeviceConfig) (ret GensetDeviceConfig, err []error) {
 	var e []error
 	ret.DeviceConfig, e = c.deviceConfigRead.TransformAndValidate(name)
@@ -1434,6 +2345,782 @@ func (c viewDeviceConfigRead) TransformAndValidate(
 	return
 }
 
+func (c *archiveConfigRead) TransformAndValidate(devices []DeviceConfig) (ret ArchiveConfig, err []error) {
+	ret.enabled = false
+
+	if c == nil {
+		return
+	}
+
+	ret.enabled = true
+
+	if len(c.Path) > 0 {
+		ret.path = c.Path
+	} else {
+		err = append(err, errors.New("Archive->Path must be either set or the whole section must be missing"))
+	}
+
+	type durationField struct {
+		name   string
+		inp    string
+		def    time.Duration
+		target *time.Duration
+	}
+	for _, f := range []durationField{
+		{"FlushInterval", c.FlushInterval, 10 * time.Second, &ret.flushInterval},
+		{"RawRetention", c.RawRetention, 7 * 24 * time.Hour, &ret.rawRetention},
+		{"MinuteRetention", c.MinuteRetention, 30 * 24 * time.Hour, &ret.minuteRetention},
+		{"HourRetention", c.HourRetention, 2 * 365 * 24 * time.Hour, &ret.hourRetention},
+	} {
+		if len(f.inp) < 1 {
+			*f.target = f.def
+		} else if d, e := time.ParseDuration(f.inp); e != nil {
+			err = append(err, fmt.Errorf("Archive->%s='%s' parse error: %s", f.name, f.inp, e))
+		} else if d <= 0 {
+			err = append(err, fmt.Errorf("Archive->%s='%s' must be >0", f.name, f.inp))
+		} else {
+			*f.target = d
+		}
+	}
+
+	var e []error
+	ret.devices, e = TransformAndValidateDeviceFilters(c.Devices, devices, "Archive->")
+	err = append(err, e...)
+
+	if c.LogDebug != nil && *c.LogDebug {
+		ret.logDebug = true
+	}
+
+	return
+}
+
+func (c influxDbOutputConfigRead) TransformAndValidate(name string, devices []DeviceConfig) (ret InfluxDbOutputConfig, err []error) {
+	ret = InfluxDbOutputConfig{
+		name:         name,
+		database:     c.Database,
+		user:         c.User,
+		passwordFile: c.PasswordFile,
+		org:          c.Org,
+		bucket:       c.Bucket,
+		tokenFile:    c.TokenFile,
+	}
+
+	errPrefix := fmt.Sprintf("InfluxDbOutputs->%s", name)
+
+	if password, e := readSecret(errPrefix, "Password", c.Password, c.PasswordFile); e != nil {
+		err = append(err, e)
+	} else {
+		ret.password = password
+	}
+
+	if token, e := readSecret(errPrefix, "Token", c.Token, c.TokenFile); e != nil {
+		err = append(err, e)
+	} else {
+		ret.token = token
+	}
+
+	if !nameMatcher.MatchString(ret.name) {
+		err = append(err, fmt.Errorf("%s name does not match %s", errPrefix, NameRegexp))
+	}
+
+	if len(c.Url) < 1 {
+		err = append(err, fmt.Errorf("%s->Url must not be empty", errPrefix))
+	} else if u, e := url.ParseRequestURI(c.Url); e != nil {
+		err = append(err, fmt.Errorf("%s->Url invalid url: %s", errPrefix, e))
+	} else {
+		ret.url = u
+	}
+
+	if c.Version == nil {
+		// use default 2
+		ret.version = 2
+	} else if v := *c.Version; v != 1 && v != 2 {
+		err = append(err, fmt.Errorf("%s->Version=%d must be 1 or 2", errPrefix, v))
+	} else {
+		ret.version = v
+	}
+
+	if ret.version == 1 && len(c.Database) < 1 {
+		err = append(err, fmt.Errorf("%s->Database must not be empty when using Version 1", errPrefix))
+	}
+	if ret.version == 2 && len(c.Bucket) < 1 {
+		err = append(err, fmt.Errorf("%s->Bucket must not be empty when using Version 2", errPrefix))
+	}
+
+	if c.BatchSize == nil {
+		// use default 1000
+		ret.batchSize = 1000
+	} else if *c.BatchSize < 1 {
+		err = append(err, fmt.Errorf("%s->BatchSize=%d must be >=1", errPrefix, *c.BatchSize))
+	} else {
+		ret.batchSize = *c.BatchSize
+	}
+
+	type durationField struct {
+		name   string
+		inp    string
+		def    time.Duration
+		target *time.Duration
+	}
+	for _, f := range []durationField{
+		{"FlushInterval", c.FlushInterval, 10 * time.Second, &ret.flushInterval},
+		{"Timeout", c.Timeout, 10 * time.Second, &ret.timeout},
+	} {
+		if len(f.inp) < 1 {
+			*f.target = f.def
+		} else if d, e := time.ParseDuration(f.inp); e != nil {
+			err = append(err, fmt.Errorf("%s->%s='%s' parse error: %s", errPrefix, f.name, f.inp, e))
+		} else if d <= 0 {
+			err = append(err, fmt.Errorf("%s->%s='%s' must be >0", errPrefix, f.name, f.inp))
+		} else {
+			*f.target = d
+		}
+	}
+
+	ret.bufferPath = c.BufferPath
+
+	if c.MaxBufferSize == nil {
+		// use default 100MB
+		ret.maxBufferSize = 100 * 1000 * 1000
+	} else if *c.MaxBufferSize < 1 {
+		err = append(err, fmt.Errorf("%s->MaxBufferSize=%d must be >=1", errPrefix, *c.MaxBufferSize))
+	} else {
+		ret.maxBufferSize = *c.MaxBufferSize
+	}
+
+	var e []error
+	ret.devices, e = TransformAndValidateDeviceFilters(c.Devices, devices, errPrefix+"->")
+	err = append(err, e...)
+
+	if c.LogDebug != nil && *c.LogDebug {
+		ret.logDebug = true
+	}
+
+	return
+}
+
+func (c *snapshotConfigRead) TransformAndValidate(devices []DeviceConfig) (ret SnapshotConfig, err []error) {
+	ret.enabled = false
+
+	if c == nil {
+		return
+	}
+
+	ret.enabled = true
+
+	if len(c.Path) > 0 {
+		ret.path = c.Path
+	} else {
+		err = append(err, errors.New("Snapshot->Path must be either set or the whole section must be missing"))
+	}
+
+	if len(c.Interval) < 1 {
+		// use default 1m
+		ret.interval = time.Minute
+	} else if interval, e := time.ParseDuration(c.Interval); e != nil {
+		err = append(err, fmt.Errorf("Snapshot->Interval='%s' parse error: %s", c.Interval, e))
+	} else if interval <= 0 {
+		err = append(err, fmt.Errorf("Snapshot->Interval='%s' must be >0", c.Interval))
+	} else {
+		ret.interval = interval
+	}
+
+	if len(c.DefaultPolicy) < 1 {
+		// use default Command
+		ret.defaultPolicy = types.RestorePolicyCommand
+	} else if ret.defaultPolicy = types.RestorePolicyFromString(c.DefaultPolicy); ret.defaultPolicy == types.RestorePolicyUndefined {
+		err = append(err, fmt.Errorf("Snapshot->DefaultPolicy='%s' is invalid", c.DefaultPolicy))
+	}
+
+	var e []error
+	ret.devices, e = TransformAndValidateMapToList(
+		c.Devices,
+		func(inp snapshotDeviceConfigRead, name string) (SnapshotDeviceConfig, []error) {
+			return inp.TransformAndValidate(name, devices, ret.defaultPolicy)
+		},
+	)
+	err = append(err, e...)
+
+	if c.LogDebug != nil && *c.LogDebug {
+		ret.logDebug = true
+	}
+
+	return
+}
+
+func (c snapshotDeviceConfigRead) TransformAndValidate(
+	name string,
+	devices []DeviceConfig,
+	defaultPolicy types.RestorePolicy,
+) (ret SnapshotDeviceConfig, err []error) {
+	ret = SnapshotDeviceConfig{
+		name: name,
+	}
+
+	if !existsByName(name, devices) {
+		err = append(err, fmt.Errorf("Snapshot->Devices: device='%s' is not defined", name))
+	}
+
+	if len(c.Policy) < 1 {
+		// use default of the snapshot section
+		ret.policy = defaultPolicy
+	} else if ret.policy = types.RestorePolicyFromString(c.Policy); ret.policy == types.RestorePolicyUndefined {
+		err = append(err, fmt.Errorf("Snapshot->Devices->%s->Policy='%s' is invalid", name, c.Policy))
+	}
+
+	ret.registerPolicy = make(map[string]types.RestorePolicy, len(c.RegisterPolicy))
+	for registerName, v := range c.RegisterPolicy {
+		if p := types.RestorePolicyFromString(v); p == types.RestorePolicyUndefined {
+			err = append(err, fmt.Errorf("Snapshot->Devices->%s->RegisterPolicy->%s='%s' is invalid", name, registerName, v))
+		} else {
+			ret.registerPolicy[registerName] = p
+		}
+	}
+
+	return
+}
+
+func (c ruleConfigRead) TransformAndValidate(
+	name string,
+	devices []DeviceConfig,
+	mqttClients []MqttClientConfig,
+) (ret RuleConfig, err []error) {
+	ret = RuleConfig{
+		name:      name,
+		condition: c.Condition,
+	}
+
+	if len(c.Triggers) < 1 {
+		err = append(err, fmt.Errorf("Rules->%s->Triggers must not be empty", name))
+	}
+	ret.triggers = make([]RuleTriggerConfig, len(c.Triggers))
+	for i, t := range c.Triggers {
+		var e []error
+		ret.triggers[i], e = t.TransformAndValidate(fmt.Sprintf("Rules->%s->Triggers[%d]->", name, i), devices)
+		err = append(err, e...)
+	}
+
+	if len(c.Condition) > 0 {
+		if expr, e := expression.Parse(c.Condition); e != nil {
+			err = append(err, fmt.Errorf("Rules->%s->Condition='%s' parse error: %s", name, c.Condition, e))
+		} else {
+			for _, ref := range expr.References() {
+				if !existsByName(ref.Device, devices) {
+					err = append(err, fmt.Errorf("Rules->%s->Condition='%s': device='%s' is not defined",
+						name, c.Condition, ref.Device,
+					))
+				}
+			}
+		}
+	}
+
+	if len(c.Actions) < 1 {
+		err = append(err, fmt.Errorf("Rules->%s->Actions must not be empty", name))
+	}
+	ret.actions = make([]RuleActionConfig, len(c.Actions))
+	for i, a := range c.Actions {
+		var e []error
+		ret.actions[i], e = a.TransformAndValidate(fmt.Sprintf("Rules->%s->Actions[%d]->", name, i), devices, mqttClients)
+		err = append(err, e...)
+	}
+
+	if c.LogDebug != nil && *c.LogDebug {
+		ret.logDebug = true
+	}
+
+	return
+}
+
+func (c ruleTriggerConfigRead) TransformAndValidate(logPrefix string, devices []DeviceConfig) (ret RuleTriggerConfig, err []error) {
+	ret = RuleTriggerConfig{
+		kind:      types.RuleTriggerKindFromString(c.Kind),
+		device:    c.Device,
+		register:  c.Register,
+		above:     c.Above,
+		below:     c.Below,
+		available: c.Available,
+		schedule:  c.Schedule,
+	}
+
+	checkDevice := func() {
+		if len(c.Device) < 1 {
+			err = append(err, fmt.Errorf("%sDevice must not be empty", logPrefix))
+		} else if !existsByName(c.Device, devices) {
+			err = append(err, fmt.Errorf("%sDevice='%s' is not defined", logPrefix, c.Device))
+		}
+	}
+	checkRegister := func() {
+		if len(c.Register) < 1 {
+			err = append(err, fmt.Errorf("%sRegister must not be empty", logPrefix))
+		}
+	}
+
+	switch ret.kind {
+	case types.RuleTriggerChangeKind:
+		checkDevice()
+		checkRegister()
+	case types.RuleTriggerThresholdKind:
+		checkDevice()
+		checkRegister()
+		if (c.Above == nil) == (c.Below == nil) {
+			err = append(err, fmt.Errorf("%sexactly one of Above and Below must be set", logPrefix))
+		}
+		if c.Hysteresis != nil {
+			if *c.Hysteresis < 0 {
+				err = append(err, fmt.Errorf("%sHysteresis=%g must be >=0", logPrefix, *c.Hysteresis))
+			} else {
+				ret.hysteresis = *c.Hysteresis
+			}
+		}
+	case types.RuleTriggerAvailabilityKind:
+		checkDevice()
+	case types.RuleTriggerCronKind:
+		if len(c.Schedule) < 1 {
+			err = append(err, fmt.Errorf("%sSchedule must not be empty", logPrefix))
+		} else if s, e := rules.ParseSchedule(c.Schedule); e != nil {
+			err = append(err, fmt.Errorf("%sSchedule='%s' parse error: %s", logPrefix, c.Schedule, e))
+		} else if s.Next(time.Now()).IsZero() {
+			err = append(err, fmt.Errorf("%sSchedule='%s' never matches", logPrefix, c.Schedule))
+		}
+	default:
+		err = append(err, fmt.Errorf("%sKind='%s' is invalid", logPrefix, c.Kind))
+	}
+
+	if len(c.Hold) > 0 {
+		if ret.kind != types.RuleTriggerThresholdKind && ret.kind != types.RuleTriggerAvailabilityKind {
+			err = append(err, fmt.Errorf("%sHold is only supported by Threshold and Availability triggers", logPrefix))
+		} else if hold, e := time.ParseDuration(c.Hold); e != nil {
+			err = append(err, fmt.Errorf("%sHold='%s' parse error: %s", logPrefix, c.Hold, e))
+		} else if hold < 0 {
+			err = append(err, fmt.Errorf("%sHold='%s' must be >=0", logPrefix, c.Hold))
+		} else {
+			ret.hold = hold
+		}
+	}
+
+	return
+}
+
+func (c ruleActionConfigRead) TransformAndValidate(
+	logPrefix string,
+	devices []DeviceConfig,
+	mqttClients []MqttClientConfig,
+) (ret RuleActionConfig, err []error) {
+	ret = RuleActionConfig{
+		kind:       types.RuleActionKindFromString(c.Kind),
+		device:     c.Device,
+		register:   c.Register,
+		value:      c.Value,
+		mqttClient: c.MqttClient,
+		topic:      c.Topic,
+		payload:    c.Payload,
+	}
+
+	checkPayload := func() {
+		if len(c.Payload) > 0 {
+			if _, e := template.New("payload").Parse(c.Payload); e != nil {
+				err = append(err, fmt.Errorf("%sPayload parse error: %s", logPrefix, e))
+			}
+		}
+	}
+
+	switch ret.kind {
+	case types.RuleActionCommandKind:
+		if len(c.Device) < 1 {
+			err = append(err, fmt.Errorf("%sDevice must not be empty", logPrefix))
+		} else if !existsByName(c.Device, devices) {
+			err = append(err, fmt.Errorf("%sDevice='%s' is not defined", logPrefix, c.Device))
+		}
+		if len(c.Register) < 1 {
+			err = append(err, fmt.Errorf("%sRegister must not be empty", logPrefix))
+		}
+		if len(c.Value) < 1 {
+			err = append(err, fmt.Errorf("%sValue must not be empty", logPrefix))
+		}
+	case types.RuleActionMqttKind:
+		if len(c.MqttClient) < 1 {
+			err = append(err, fmt.Errorf("%sMqttClient must not be empty", logPrefix))
+		} else if !existsByName(c.MqttClient, mqttClients) {
+			err = append(err, fmt.Errorf("%sMqttClient='%s' is not defined", logPrefix, c.MqttClient))
+		}
+		if len(c.Topic) < 1 {
+			err = append(err, fmt.Errorf("%sTopic must not be empty", logPrefix))
+		}
+		checkPayload()
+
+		if c.Qos == nil {
+			ret.qos = 1
+		} else if *c.Qos == 0 || *c.Qos == 1 || *c.Qos == 2 {
+			ret.qos = *c.Qos
+		} else {
+			err = append(err, fmt.Errorf("%sQos=%d but must be 0, 1 or 2", logPrefix, *c.Qos))
+		}
+
+		if c.Retain != nil && *c.Retain {
+			ret.retain = true
+		}
+	case types.RuleActionWebhookKind:
+		if len(c.Url) < 1 {
+			err = append(err, fmt.Errorf("%sUrl must not be empty", logPrefix))
+		} else if u, e := url.ParseRequestURI(c.Url); e != nil {
+			err = append(err, fmt.Errorf("%sUrl='%s' parse error: %s", logPrefix, c.Url, e))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			err = append(err, fmt.Errorf("%sUrl='%s' must use http or https", logPrefix, c.Url))
+		} else {
+			ret.url = u
+		}
+		checkPayload()
+
+		if len(c.Method) < 1 {
+			// use default POST
+			ret.method = http.MethodPost
+		} else {
+			ret.method = strings.ToUpper(c.Method)
+		}
+
+		if len(c.Timeout) < 1 {
+			// use default 10s
+			ret.timeout = 10 * time.Second
+		} else if timeout, e := time.ParseDuration(c.Timeout); e != nil {
+			err = append(err, fmt.Errorf("%sTimeout='%s' parse error: %s", logPrefix, c.Timeout, e))
+		} else if timeout <= 0 {
+			err = append(err, fmt.Errorf("%sTimeout='%s' must be >0", logPrefix, c.Timeout))
+		} else {
+			ret.timeout = timeout
+		}
+	default:
+		err = append(err, fmt.Errorf("%sKind='%s' is invalid", logPrefix, c.Kind))
+	}
+
+	return
+}
+
+func (c *alarmsConfigRead) TransformAndValidate(devices []DeviceConfig) (ret AlarmsConfig, err []error) {
+	ret.enabled = false
+
+	if c == nil {
+		return
+	}
+
+	ret.enabled = true
+
+	if c.HistorySize == nil {
+		// use default 100
+		ret.historySize = 100
+	} else if *c.HistorySize < 1 {
+		err = append(err, fmt.Errorf("Alarms->HistorySize=%d must be >0", *c.HistorySize))
+	} else {
+		ret.historySize = *c.HistorySize
+	}
+
+	if len(c.Definitions) < 1 {
+		err = append(err, errors.New("Alarms->Definitions must not be empty"))
+	}
+
+	var e []error
+	ret.notifiers, e = TransformAndValidateMapToList(
+		c.Notifiers,
+		func(inp alarmNotifierConfigRead, name string) (AlarmNotifierConfig, []error) {
+			return inp.TransformAndValidate(name)
+		},
+	)
+	err = append(err, e...)
+
+	ret.definitions, e = TransformAndValidateMapToList(
+		c.Definitions,
+		func(inp alarmDefinitionConfigRead, name string) (AlarmDefinitionConfig, []error) {
+			return inp.TransformAndValidate(name, devices, ret.notifiers)
+		},
+	)
+	err = append(err, e...)
+
+	if c.LogDebug != nil && *c.LogDebug {
+		ret.logDebug = true
+	}
+
+	return
+}
+
+func (c alarmDefinitionConfigRead) TransformAndValidate(
+	name string,
+	devices []DeviceConfig,
+	notifiers []AlarmNotifierConfig,
+) (ret AlarmDefinitionConfig, err []error) {
+	ret = AlarmDefinitionConfig{
+		name:      name,
+		kind:      types.AlarmKindFromString(c.Kind),
+		device:    c.Device,
+		register:  c.Register,
+		message:   c.Message,
+		notifiers: c.Notifiers,
+	}
+	logPrefix := fmt.Sprintf("Alarms->Definitions->%s->", name)
+
+	if !nameMatcher.MatchString(name) {
+		err = append(err, fmt.Errorf("Alarms->Definitions->Name='%s' does not match %s", name, NameRegexp))
+	}
+
+	if ret.kind == types.AlarmUndefinedKind {
+		err = append(err, fmt.Errorf("%sKind='%s' is invalid", logPrefix, c.Kind))
+	}
+
+	if len(c.Device) < 1 {
+		err = append(err, fmt.Errorf("%sDevice must not be empty", logPrefix))
+	} else if !existsByName(c.Device, devices) {
+		err = append(err, fmt.Errorf("%sDevice='%s' is not defined", logPrefix, c.Device))
+	}
+
+	if ret.kind == types.AlarmOfflineKind {
+		if len(c.Register) > 0 {
+			err = append(err, fmt.Errorf("%sRegister is not supported by Offline alarms", logPrefix))
+		}
+	} else if len(c.Register) < 1 {
+		err = append(err, fmt.Errorf("%sRegister must not be empty", logPrefix))
+	}
+
+	hasLimit := ret.kind == types.AlarmHighKind || ret.kind == types.AlarmLowKind || ret.kind == types.AlarmRateKind
+	if !hasLimit {
+		if c.Limit != nil || c.Hysteresis != nil || len(c.Delay) > 0 {
+			err = append(err, fmt.Errorf("%sLimit, Hysteresis and Delay are only supported by High, Low and Rate alarms", logPrefix))
+		}
+	} else {
+		if c.Limit == nil {
+			err = append(err, fmt.Errorf("%sLimit must be set", logPrefix))
+		} else {
+			ret.limit = *c.Limit
+		}
+
+		if c.Hysteresis == nil {
+			// use default 0
+		} else if *c.Hysteresis < 0 {
+			err = append(err, fmt.Errorf("%sHysteresis=%g must be >=0", logPrefix, *c.Hysteresis))
+		} else {
+			ret.hysteresis = *c.Hysteresis
+		}
+
+		if len(c.Delay) < 1 {
+			// use default 0s
+		} else if delay, e := time.ParseDuration(c.Delay); e != nil {
+			err = append(err, fmt.Errorf("%sDelay='%s' parse error: %s", logPrefix, c.Delay, e))
+		} else if delay < 0 {
+			err = append(err, fmt.Errorf("%sDelay='%s' must be >=0", logPrefix, c.Delay))
+		} else {
+			ret.delay = delay
+		}
+	}
+
+	if ret.kind == types.AlarmHighKind || ret.kind == types.AlarmLowKind {
+		if len(c.Interval) > 0 {
+			err = append(err, fmt.Errorf("%sInterval is not supported by %s alarms", logPrefix, ret.kind))
+		}
+	} else if len(c.Interval) < 1 {
+		err = append(err, fmt.Errorf("%sInterval must not be empty", logPrefix))
+	} else if interval, e := time.ParseDuration(c.Interval); e != nil {
+		err = append(err, fmt.Errorf("%sInterval='%s' parse error: %s", logPrefix, c.Interval, e))
+	} else if interval <= 0 {
+		err = append(err, fmt.Errorf("%sInterval='%s' must be >0", logPrefix, c.Interval))
+	} else {
+		ret.interval = interval
+	}
+
+	if len(c.Severity) < 1 {
+		// use default Warning
+		ret.severity = types.AlarmWarningSeverity
+	} else if ret.severity = types.AlarmSeverityFromString(c.Severity); ret.severity == types.AlarmUndefinedSeverity {
+		err = append(err, fmt.Errorf("%sSeverity='%s' is invalid", logPrefix, c.Severity))
+	}
+
+	for _, n := range c.Notifiers {
+		if !existsByName(n, notifiers) {
+			err = append(err, fmt.Errorf("%sNotifiers: notifier='%s' is not defined", logPrefix, n))
+		}
+	}
+
+	return
+}
+
+func (c alarmNotifierConfigRead) TransformAndValidate(name string) (ret AlarmNotifierConfig, err []error) {
+	ret = AlarmNotifierConfig{
+		name:         name,
+		kind:         types.AlarmNotifierKindFromString(c.Kind),
+		tokenFile:    c.TokenFile,
+		host:         c.Host,
+		user:         c.User,
+		passwordFile: c.PasswordFile,
+		from:         c.From,
+		to:           c.To,
+	}
+	logPrefix := fmt.Sprintf("Alarms->Notifiers->%s->", name)
+
+	if token, e := readSecret(fmt.Sprintf("Alarms->Notifiers->%s", name), "Token", c.Token, c.TokenFile); e != nil {
+		err = append(err, e)
+	} else {
+		ret.token = token
+	}
+
+	if password, e := readSecret(fmt.Sprintf("Alarms->Notifiers->%s", name), "Password", c.Password, c.PasswordFile); e != nil {
+		err = append(err, e)
+	} else {
+		ret.password = password
+	}
+
+	checkUrl := func() {
+		if len(c.Url) < 1 {
+			err = append(err, fmt.Errorf("%sUrl must not be empty", logPrefix))
+		} else if u, e := url.ParseRequestURI(c.Url); e != nil {
+			err = append(err, fmt.Errorf("%sUrl='%s' parse error: %s", logPrefix, c.Url, e))
+		} else if u.Scheme != "http" && u.Scheme != "https" {
+			err = append(err, fmt.Errorf("%sUrl='%s' must use http or https", logPrefix, c.Url))
+		} else {
+			ret.url = u
+		}
+	}
+
+	switch ret.kind {
+	case types.AlarmNotifierWebhookKind:
+		checkUrl()
+		if len(c.Method) < 1 {
+			// use default POST
+			ret.method = http.MethodPost
+		} else {
+			ret.method = strings.ToUpper(c.Method)
+		}
+	case types.AlarmNotifierNtfyKind:
+		checkUrl()
+	case types.AlarmNotifierSmtpKind:
+		if len(c.Host) < 1 {
+			err = append(err, fmt.Errorf("%sHost must not be empty", logPrefix))
+		}
+		if c.Port == nil {
+			// use default 587 (submission)
+			ret.port = 587
+		} else if *c.Port < 1 || *c.Port > 65535 {
+			err = append(err, fmt.Errorf("%sPort=%d is invalid", logPrefix, *c.Port))
+		} else {
+			ret.port = *c.Port
+		}
+		if len(c.From) < 1 {
+			err = append(err, fmt.Errorf("%sFrom must not be empty", logPrefix))
+		}
+		if len(c.To) < 1 {
+			err = append(err, fmt.Errorf("%sTo must not be empty", logPrefix))
+		}
+	default:
+		err = append(err, fmt.Errorf("%sKind='%s' is invalid", logPrefix, c.Kind))
+	}
+
+	if len(c.Timeout) < 1 {
+		// use default 10s
+		ret.timeout = 10 * time.Second
+	} else if timeout, e := time.ParseDuration(c.Timeout); e != nil {
+		err = append(err, fmt.Errorf("%sTimeout='%s' parse error: %s", logPrefix, c.Timeout, e))
+	} else if timeout <= 0 {
+		err = append(err, fmt.Errorf("%sTimeout='%s' must be >0", logPrefix, c.Timeout))
+	} else {
+		ret.timeout = timeout
+	}
+
+	return
+}
+
+// TransformAndValidateDeviceFilters converts a Devices section with optional filters; when empty, all devices are included.
+func TransformAndValidateDeviceFilters(
+	inp map[string]deviceFilterConfigRead,
+	devices []DeviceConfig,
+	logPrefix string,
+) (ret []DeviceFilterConfig, err []error) {
+	if len(inp) == 0 {
+		// no devices given, default to all
+		inp = make(map[string]deviceFilterConfigRead, len(devices))
+		for _, dev := range devices {
+			inp[dev.Name()] = deviceFilterConfigRead{}
+		}
+	}
+
+	return TransformAndValidateMapToList(
+		inp,
+		func(inp deviceFilterConfigRead, name string) (DeviceFilterConfig, []error) {
+			return inp.TransformAndValidate(name, devices, logPrefix)
+		},
+	)
+}
+
+func (c deviceFilterConfigRead) TransformAndValidate(
+	name string,
+	devices []DeviceConfig,
+	logPrefix string,
+) (ret DeviceFilterConfig, err []error) {
+	ret = DeviceFilterConfig{
+		name: name,
+	}
+
+	if !existsByName(name, devices) {
+		err = append(err, fmt.Errorf("%sDevices: device='%s' is not defined", logPrefix, name))
+	}
+
+	if c.Filter == nil {
+		c.Filter = &filterConfigRead{}
+	}
+	var e []error
+	ret.filter, e = c.Filter.TransformAndValidate()
+	err = append(err, e...)
+
+	return
+}
+
+func (c historyConfigRead) TransformAndValidate(deviceName string) (ret HistoryConfig, err []error) {
+	ret = HistoryConfig{
+		enabled: true,
+	}
+
+	if len(c.MaxAge) < 1 {
+		// use default 1h
+		ret.maxAge = time.Hour
+	} else if maxAge, e := time.ParseDuration(c.MaxAge); e != nil {
+		err = append(err, fmt.Errorf("Devices->%s->History->MaxAge='%s' parse error: %s",
+			deviceName, c.MaxAge, e,
+		))
+	} else if maxAge < 0 {
+		err = append(err, fmt.Errorf("Devices->%s->History->MaxAge='%s' must be >=0",
+			deviceName, c.MaxAge,
+		))
+	} else {
+		ret.maxAge = maxAge
+	}
+
+	if c.MaxSamples == nil {
+		// use default 3600
+		ret.maxSamples = 3600
+	} else if *c.MaxSamples < 1 {
+		err = append(err, fmt.Errorf("Devices->%s->History->MaxSamples=%d must be >=1",
+			deviceName, *c.MaxSamples,
+		))
+	} else {
+		ret.maxSamples = *c.MaxSamples
+	}
+
+	if len(c.Resolution) < 1 {
+		// use default 0s; keep every sample
+		ret.resolution = 0
+	} else if resolution, e := time.ParseDuration(c.Resolution); e != nil {
+		err = append(err, fmt.Errorf("Devices->%s->History->Resolution='%s' parse error: %s",
+			deviceName, c.Resolution, e,
+		))
+	} else if resolution < 0 {
+		err = append(err, fmt.Errorf("Devices->%s->History->Resolution='%s' must be >=0",
+			deviceName, c.Resolution,
+		))
+	} else {
+		ret.resolution = resolution
+	}
+
+	var e []error
+	ret.filter, e = c.Filter.TransformAndValidate()
+	err = append(err, e...)
+
+	return
+}
+
 func (c filterConfigRead) TransformAndValidate() (ret FilterConfig, err []error) {
 	ret = FilterConfig{
 		includeRegisters:  c.IncludeRegisters,
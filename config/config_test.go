@@ -3,9 +3,12 @@ package config
 import (
 	"bytes"
 	"github.com/koestler/go-iotdevice/v3/types"
+	"gopkg.in/yaml.v3"
 	"log"
 	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -140,6 +143,13 @@ MqttClients:                                               # optional, when empt
       Enabled: false                                 # optional, default false, whether to enable sending realtime messages
       TopicTemplate: '%Prefix%cmnd-X/go-iotdevice/%DeviceName%/%RegisterName%' # optional, what topic to use for realtime messages
 
+    Alarms:
+      Enabled: true                                  # optional, default false, whether to publish alarm state changes
+      TopicTemplate: '%Prefix%alarm-X/%AlarmName%'   # optional, what topic to use for alarm messages
+      Retain: false                                  # optional, default true, the mqtt retain flag for alarm messages
+      Devices:                                       # optional, default all, only publish alarms of the given devices
+        bmv0:
+
     LogDebug: true                                         # optional, default false, very verbose debug log of the mqtt connection
     LogMessages: true                                      # optional, default false, log all incoming mqtt messages
 
@@ -167,6 +177,44 @@ VictronDevices:                                            # optional, a list of
         - Settings                                         # for solar devices it might make sense to not fetch / output the settings
     RestartInterval: 400ms                               # optional, default 200ms, how fast to restart the device if it fails / disconnects
     RestartIntervalMaxBackoff: 2m                        # optional, default 1m; when it fails, the restart interval is exponentially increased up to this maximum
+    MaxAge: 10s                                          # optional, default 0s (never), after which duration a value not being updated is marked as stale
+    RegisterMaxAge:                                      # optional, default empty, overrides MaxAge for single registers
+      BatteryVoltage: 5s
+    RegisterMeta:                                        # optional, default empty, overrides the metadata provided by the device
+      SOC:
+        Min: 0                                           # optional, default as provided by the device
+        Max: 100                                         # optional, default as provided by the device
+        Step: 0.1                                        # optional, default as provided by the device
+        Precision: 1                                     # optional, default as provided by the device, number of decimals to display
+        DeviceClass: battery                             # optional, default as provided by the device, e.g. voltage, power, energy
+        StateClass: measurement                          # optional, default as provided by the device, measurement, total or total_increasing
+    RegisterTransform:                                   # optional, default empty, converts the registers and values of the device
+      Power:
+        Scale: 0.001                                     # optional, default 1 or the conversion of the unit
+        Offset: 0.5                                      # optional, default 0 or the conversion of the unit
+        Unit: kW                                         # optional, default as provided by the device
+        Round: 2                                         # optional, default no rounding, number of decimals
+        Description: Battery power                       # optional, default as provided by the device
+        Category: Essential                              # optional, default as provided by the device
+      ConsumedAmpHours:
+        Unit: mAh
+    RegisterDeadband:                                    # optional, default empty, only forward significant changes of a register
+      Power:
+        Absolute: 0.5                                    # optional, default 0 (disabled), the minimal absolute change
+        Percent: 2                                       # optional, default 0 (disabled), the minimal change in percent of the last value
+        MinInterval: 1s                                  # optional, default 0s (disabled), the minimal duration between two updates
+        MaxInterval: 5m                                  # optional, default 0s (disabled), forward the value after this duration even if unchanged
+    CategoryDeadband:                                    # optional, default empty, like RegisterDeadband for all registers of a category
+      Essential:
+        MaxInterval: 1m
+    History:                                             # optional, default disabled, keep an in-memory history of the register values
+      MaxAge: 2h                                         # optional, default 1h
+      MaxSamples: 720                                    # optional, default 3600
+      Resolution: 10s                                    # optional, default 0s (keep every sample)
+      Filter:
+        IncludeRegisters:
+          - BatteryVoltage
+        DefaultInclude: false
     LogDebug: true                                       # optional, default false, enable debug log output
     LogComDebug: true                                    # optional, default false, enable a verbose log of the communication with the device
     Device: /dev/serial/by-id/usb-VictronEnergy_BV_VE_Direct_cable_VEHTVQT-if00-port0 # mandatory except if Kind: Random*, the path to the usb-to-serial converter
@@ -261,6 +309,20 @@ MqttDevices:                                               # optional, a list of
     LogComDebug: true                                    # optional, default false, enable a verbose log of the communication with the device
     Kind: GoIotdeviceV3
 
+ComputedDevices:                                           # optional, a list of devices computing its registers from values of other devices
+  bmv0-computed:                                           # mandatory, an arbitrary name used for logging and for referencing in other config sections
+    LogDebug: true                                         # optional, default false, enable debug log output
+    Registers:                                             # mandatory, at least one register must be defined
+      Power:                                               # mandatory, the name of the register
+        Expression: bmv0.MainVoltage * bmv0.Current        # mandatory, references are written as device.register
+        Unit: W                                            # optional, default empty
+        Description: Battery Power                         # optional, default the register name
+        Sort: 10                                           # optional, default the position within the sorted register names
+      Charging:
+        Expression: bmv0.Current > 0
+        Type: bool                                         # optional, default number; possibilities: number, bool
+        Category: Battery                                  # optional, default Computed
+
 GensetDevices:                                             # optional, a list generator set control devices
   genset0:                                                 # mandatory, an arbitrary name used for logging and for referencing in other config sections
     RestartInterval: 60ms                                  # optional, default 200ms, how fast to restart the device if it fails / disconnects
@@ -335,6 +397,100 @@ Views:                                                     # optional, a list of
     Devices:
       - Name: bmv0
         Title: Bmv 0
+
+Archive:                                                   # optional, default disabled, a persistent on-disk archive of the values
+  Path: /var/lib/go-iotdevice/archive                      # mandatory, directory where the archive is stored
+  FlushInterval: 20s                                       # optional, default 10s, how often the buffered values are written to disk
+  RawRetention: 48h                                        # optional, default 168h, how long raw values are kept
+  MinuteRetention: 240h                                    # optional, default 720h, how long minute rollups are kept
+  HourRetention: 8760h                                     # optional, default 17520h, how long hour rollups are kept
+  Devices:                                                 # optional, default all, a list of devices to archive
+    bmv0:
+      Filter:
+        IncludeCategories:
+          - Essential
+        DefaultInclude: false
+  LogDebug: true                                           # optional, default false, verbose debug log
+
+Snapshot:                                                  # optional, default disabled, restore state and commands after a restart
+  Path: /var/lib/go-iotdevice/snapshot.json                # mandatory, file where the snapshot is stored
+  Interval: 30s                                            # optional, default 1m, how often the snapshot is written
+  DefaultPolicy: None                                      # optional, default Command, None, State, Command or All
+  Devices:                                                 # optional, default empty, overrides the DefaultPolicy per device
+    bmv0:
+      Policy: All                                          # optional, default DefaultPolicy
+      RegisterPolicy:                                      # optional, default empty, overrides the Policy for single registers
+        BatteryVoltage: State
+  LogDebug: true                                           # optional, default false, verbose debug log
+
+Rules:                                                     # optional, default empty, automations executing actions when triggered
+  low-soc:                                                 # mandatory, an arbitrary name used for logging and in the status endpoint
+    Triggers:                                              # mandatory, the rule fires when any of the triggers fires
+      - Kind: Threshold                                    # mandatory, Change, Threshold, Availability or Cron
+        Device: bmv0                                       # mandatory for Change, Threshold and Availability
+        Register: SOC                                      # mandatory for Change and Threshold
+        Below: 20                                          # set either Above or Below for Threshold
+        Hysteresis: 5                                      # optional, default 0, the value must pass the threshold by this much to re-arm
+        Hold: 1m                                           # optional, default 0s, for Threshold and Availability, how long the condition must hold
+      - Kind: Cron
+        Schedule: "0 6 * * *"                              # mandatory for Cron, minute hour day-of-month month day-of-week
+    Condition: bmv0.Current < 0                            # optional, default always, an expression evaluated when triggered
+    Actions:                                               # mandatory, executed in order
+      - Kind: Command                                      # mandatory, Command, Mqtt or Webhook
+        Device: modbus-rtu0                                # mandatory for Command
+        Register: CH1                                      # mandatory for Command
+        Value: true                                        # mandatory for Command, converted according to the register type
+      - Kind: Mqtt
+        MqttClient: 0-local                                # mandatory for Mqtt
+        Topic: alerts/low-soc                              # mandatory for Mqtt
+        Payload: "{{.Rule}} fired at {{.Time}}"            # optional, default a json object with Rule, Time and Trigger
+        Qos: 2                                             # optional, default 1
+        Retain: true                                       # optional, default false
+      - Kind: Webhook
+        Url: https://example.com/hook                      # mandatory for Webhook
+        Method: put                                        # optional, default POST
+        Timeout: 5s                                        # optional, default 10s
+    LogDebug: true                                         # optional, default false, log when the rule is triggered
+
+Alarms:                                                    # optional, when missing: alarms are disabled
+  HistorySize: 50                                          # optional, default 100, how many state changes are kept in the history
+  Definitions:                                             # mandatory, the alarms to check
+    low-soc:                                               # mandatory, an arbitrary name used in the api, mqtt topics and notifications
+      Kind: Low                                            # mandatory, High, Low, Rate, Stuck or Offline
+      Device: bmv0                                         # mandatory, the device to watch
+      Register: SOC                                        # mandatory except for Offline
+      Limit: 20                                            # mandatory for High, Low and Rate
+      Hysteresis: 5                                        # optional, default 0, for High, Low and Rate, how far the value must be back to clear
+      Delay: 1m                                            # optional, default 0s, for High, Low and Rate, how long the condition must hold
+      Severity: Critical                                   # optional, default Warning, Info, Warning or Critical
+      Message: Battery is almost empty                     # optional, default a description of the alarm
+      Notifiers:                                           # optional, default all notifiers
+        - hook
+        - mail
+    bmv0-offline:
+      Kind: Offline
+      Device: bmv0
+      Interval: 5m                                         # mandatory for Rate, Stuck and Offline
+  Notifiers:                                               # optional, default empty, where to send notifications to
+    hook:
+      Kind: Webhook                                        # mandatory, Webhook, Smtp or Ntfy
+      Url: https://example.com/alarm                       # mandatory for Webhook and Ntfy
+      Method: put                                          # optional, default POST, only for Webhook
+      Token: hook-secret                                   # optional, default empty, sent as bearer token for Webhook and Ntfy
+      Timeout: 3s                                          # optional, default 10s
+    mail:
+      Kind: Smtp
+      Host: smtp.example.com                               # mandatory for Smtp
+      Port: 465                                            # optional, default 587, port 465 uses implicit tls
+      User: iot                                            # optional, default empty, no authentication
+      Password: Ohb8eiqu                                   # optional, default empty
+      From: iot@example.com                                # mandatory for Smtp
+      To:                                                  # mandatory for Smtp
+        - ops@example.com
+    phone:
+      Kind: Ntfy
+      Url: https://ntfy.sh/my-alarms
+  LogDebug: true                                           # optional, default false, log alarm state changes
 `
 
 	ValidDefaultConfig = `
@@ -437,6 +593,996 @@ func TestReadConfig_InvalidUnknownVersion(t *testing.T) {
 }
 
 // check that a complex example setting all available options is correctly read
+func TestReadConfig_InvalidComputedDevices(t *testing.T) {
+	tests := []struct {
+		config string
+		expect string
+	}{
+		{`
+Version: 2
+ComputedDevices:
+  c0:
+    Registers:
+      X:
+        Expression: 1 +
+`, "ComputedDevices->c0->Registers->X->Expression='1 +' parse error"},
+		{`
+Version: 2
+ComputedDevices:
+  c0:
+    Registers:
+      X:
+        Expression: unknown.Y
+`, "ComputedDevices->c0->Registers->X->Expression='unknown.Y': device='unknown' is not defined"},
+		{`
+Version: 2
+ComputedDevices:
+  c0:
+    Registers:
+      X:
+        Expression: c1.Y
+  c1:
+    Registers:
+      Y:
+        Expression: c0.X
+`, "circular dependency between computed devices"},
+	}
+
+	for _, tc := range tests {
+		_, err := ReadConfig([]byte(tc.config), true)
+		if !containsError(tc.expect, err) {
+			t.Errorf("expect error containing '%s' but got %v", tc.expect, err)
+		}
+	}
+}
+
+func TestReadConfig_SystemDevices(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+SystemDevices:
+  system0:
+    PollInterval: 30s
+    DiskPath: /data
+  system1:
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	if expect, got := 2, len(config.SystemDevices()); expect != got {
+		t.Fatalf("expect length of config.SystemDevices to be %d but got %d", expect, got)
+	}
+	if expect, got := 2, len(config.Devices()); expect != got {
+		t.Errorf("expect system devices to be listed in config.Devices, expect %d but got %d", expect, got)
+	}
+
+	sd := config.SystemDevices()[0]
+	if expect, got := "system0", sd.Name(); expect != got {
+		t.Errorf("expect name of first system device to be '%s' but got '%s'", expect, got)
+	}
+	if expect, got := 30*time.Second, sd.PollInterval(); expect != got {
+		t.Errorf("expect SystemDevices->system0->PollInterval to be %s but got %s", expect, got)
+	}
+	if expect, got := "/data", sd.DiskPath(); expect != got {
+		t.Errorf("expect SystemDevices->system0->DiskPath to be '%s' but got '%s'", expect, got)
+	}
+
+	sd = config.SystemDevices()[1]
+	if expect, got := 10*time.Second, sd.PollInterval(); expect != got {
+		t.Errorf("expect SystemDevices->system1->PollInterval to be %s but got %s", expect, got)
+	}
+	if expect, got := "/", sd.DiskPath(); expect != got {
+		t.Errorf("expect SystemDevices->system1->DiskPath to be '%s' but got '%s'", expect, got)
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+SystemDevices:
+  system0:
+    PollInterval: 10ms
+`), true)
+	if expect := "SystemDevices->system0->PollInterval='10ms' must be >=1s"; !containsError(expect, err) {
+		t.Errorf("expect error containing '%s' but got %v", expect, err)
+	}
+}
+
+func TestReadConfig_ModbusKinds(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+Modbus:
+  bus0:
+    Device: /dev/ttyUSB0
+    BaudRate: 9600
+  bus1:
+    Kind: Tcp
+    Url: tcp://192.168.1.10
+  bus2:
+    Kind: RtuOverTcp
+    Url: tcp://gateway:4001
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	if expect, got := 3, len(config.Modbus()); expect != got {
+		t.Fatalf("expect length of config.Modbus to be %d but got %d", expect, got)
+	}
+	for i, expect := range []types.ModbusBusKind{
+		types.ModbusBusSerialKind, types.ModbusBusTcpKind, types.ModbusBusRtuOverTcpKind,
+	} {
+		if got := config.Modbus()[i].Kind(); expect != got {
+			t.Errorf("expect Kind of Modbus %d to be %s but got %s", i, expect, got)
+		}
+	}
+	if expect, got := "gateway:4001", config.Modbus()[2].Url().Host; expect != got {
+		t.Errorf("expect Modbus->bus2->Url host to be '%s' but got '%s'", expect, got)
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+Modbus:
+  bus0:
+    Kind: Udp
+  bus1:
+    Kind: Tcp
+  bus2:
+    Kind: RtuOverTcp
+    Url: http://gateway
+`), true)
+	for _, expect := range []string{
+		"Modbus->bus0->Kind='Udp' is invalid",
+		"Modbus->bus1->Url must not be empty",
+		"Modbus->bus2->Url='http://gateway' must be of the form tcp://host:port",
+	} {
+		if !containsError(expect, err) {
+			t.Errorf("expect error containing '%s' but got %v", expect, err)
+		}
+	}
+}
+
+func TestReadConfig_ModbusGenericRegisters(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+Modbus:
+  bus0:
+    Kind: Tcp
+    Url: tcp://meter
+ModbusDevices:
+  sdm:
+    Bus: bus0
+    Kind: Generic
+    Address: 0x01
+    MaxReadGap: 4
+    Registers:
+      Voltage:
+        Function: Input
+        Address: 0x0000
+        Type: float32
+        Unit: V
+        Sort: 3
+      Energy:
+        Function: Holding
+        Address: 72
+        Type: u32
+        WordOrder: LittleEndian
+        Scale: 0.01
+        Writable: true
+      Relay:
+        Function: Coil
+        Address: 1
+        Writable: true
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	if expect, got := 4, config.ModbusDevices()[0].MaxReadGap(); expect != got {
+		t.Errorf("expect ModbusDevices->sdm->MaxReadGap to be %d but got %d", expect, got)
+	}
+
+	regs := config.ModbusDevices()[0].Registers()
+	if expect, got := 3, len(regs); expect != got {
+		t.Fatalf("expect %d registers but got %d", expect, got)
+	}
+	// registers are ordered by name
+	energy, relay, voltage := regs[0], regs[1], regs[2]
+	if expect, got := uint16(72), energy.Address(); expect != got {
+		t.Errorf("expect Energy->Address to be %d but got %d", expect, got)
+	}
+	if expect, got := 2, energy.Length(); expect != got {
+		t.Errorf("expect Energy->Length to be %d but got %d", expect, got)
+	}
+	if energy.WordOrder() != "LittleEndian" || energy.ByteOrder() != "BigEndian" || energy.Scale() != 0.01 {
+		t.Errorf("unexpected Energy register %v", energy)
+	}
+	if expect, got := "bool", relay.Type(); expect != got {
+		t.Errorf("expect Relay->Type to default to '%s' but got '%s'", expect, got)
+	}
+	if expect, got := 3, voltage.Sort(); expect != got {
+		t.Errorf("expect Voltage->Sort to be %d but got %d", expect, got)
+	}
+	if expect, got := "Registers", voltage.Category(); expect != got {
+		t.Errorf("expect Voltage->Category to default to '%s' but got '%s'", expect, got)
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+Modbus:
+  bus0:
+    Device: /dev/ttyUSB0
+    BaudRate: 9600
+ModbusDevices:
+  sdm:
+    Bus: bus0
+    Kind: Generic
+    Address: 0x01
+    Registers:
+      A:
+        Function: Output
+        Address: 70000
+      B:
+        Function: Input
+        Address: 0
+        Type: float32
+        Writable: true
+      C:
+        Function: Holding
+        Address: 0
+        Type: string
+  relay:
+    Bus: bus0
+    Kind: Generic
+    Address: 0x02
+`), true)
+	for _, expect := range []string{
+		"ModbusDevices->sdm->Registers->A->Function='Output' must be Coil, DiscreteInput, Holding or Input",
+		"ModbusDevices->sdm->Registers->A->Address=70000 must be between 0 and 65535",
+		"ModbusDevices->sdm->Registers->B->Writable is only supported for Function=Coil or Holding",
+		"ModbusDevices->sdm->Registers->C->Length must be between 1 and 125 registers for Type=string",
+		"ModbusDevices->relay->Registers must not be empty for Kind=Generic",
+	} {
+		if !containsError(expect, err) {
+			t.Errorf("expect error containing '%s' but got %v", expect, err)
+		}
+	}
+}
+
+func TestReadConfig_ModbusScheduler(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+Modbus:
+  bus0:
+    Device: /dev/ttyUSB0
+    BaudRate: 9600
+  bus1:
+    Device: /dev/ttyUSB1
+    BaudRate: 115200
+  bus2:
+    Device: /dev/ttyUSB2
+    BaudRate: 9600
+    InterFrameDelay: 10ms
+  bus3:
+    Kind: Tcp
+    Url: tcp://192.168.1.10
+ModbusDevices:
+  relay:
+    Kind: WaveshareRtuRelay8
+    Bus: bus0
+    Address: 0x01
+    ReadTimeout: 200ms
+    Retries: 3
+  bus0-status:
+    Kind: BusStatus
+    Bus: bus0
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	for i, expect := range []time.Duration{
+		4010416 * time.Nanosecond, 1750 * time.Microsecond, 10 * time.Millisecond, 0,
+	} {
+		if got := config.Modbus()[i].InterFrameDelay(); expect != got {
+			t.Errorf("expect InterFrameDelay of Modbus %d to be %s but got %s", i, expect, got)
+		}
+	}
+
+	status := config.ModbusDevices()[0]
+	if expect, got := types.ModbusBusStatusKind, status.Kind(); expect != got {
+		t.Errorf("expect ModbusDevices->bus0-status->Kind to be %s but got %s", expect, got)
+	}
+	if expect, got := time.Duration(0), status.ReadTimeout(); expect != got {
+		t.Errorf("expect ModbusDevices->bus0-status->ReadTimeout to be %s but got %s", expect, got)
+	}
+
+	relay := config.ModbusDevices()[1]
+	if expect, got := 200*time.Millisecond, relay.ReadTimeout(); expect != got {
+		t.Errorf("expect ModbusDevices->relay->ReadTimeout to be %s but got %s", expect, got)
+	}
+	if expect, got := 3, relay.Retries(); expect != got {
+		t.Errorf("expect ModbusDevices->relay->Retries to be %d but got %d", expect, got)
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+Modbus:
+  bus0:
+    Device: /dev/ttyUSB0
+    BaudRate: 9600
+    InterFrameDelay: -1ms
+ModbusDevices:
+  relay:
+    Kind: WaveshareRtuRelay8
+    Bus: bus0
+    ReadTimeout: 1us
+    Retries: 11
+`), true)
+	for _, expect := range []string{
+		"Modbus->bus0->InterFrameDelay='-1ms' must be >=0",
+		"ModbusDevices->relay: decimal Adress= is invalid",
+		"ModbusDevices->relay->ReadTimeout='1us' must be >=1ms",
+		"ModbusDevices->relay->Retries=11 must be between 0 and 10",
+	} {
+		if !containsError(expect, err) {
+			t.Errorf("expect error containing '%s' but got %v", expect, err)
+		}
+	}
+}
+
+func TestReadConfig_ModbusServers(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+VictronDevices:
+  bmv0:
+    Kind: RandomBmv
+ModbusServers:
+  plc:
+    Port: 5020
+    Registers:
+      - Function: Input
+        Address: 0
+        Device: bmv0
+        Register: SOC
+        Scale: 0.1
+      - Function: Input
+        Address: 1
+        Device: bmv0
+        Register: MainVoltage
+        Type: float32
+        WordOrder: LittleEndian
+  panel:
+    Kind: Rtu
+    Device: /dev/ttyUSB1
+    BaudRate: 19200
+    UnitId: 17
+    Registers:
+      - Function: Coil
+        Address: 0
+        Device: bmv0
+        Register: Relay
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	servers := config.ModbusServers()
+	if expect, got := 2, len(servers); expect != got {
+		t.Fatalf("expect length of config.ModbusServers to be %d but got %d", expect, got)
+	}
+	// servers are ordered by name
+	panel, plc := servers[0], servers[1]
+	if panel.Kind() != types.ModbusServerRtuKind || panel.UnitId() != 17 || panel.BaudRate() != 19200 {
+		t.Errorf("unexpected panel server %v", panel)
+	}
+	if expect, got := "bool", panel.Registers()[0].Type(); expect != got {
+		t.Errorf("expect Registers[0]->Type to default to '%s' but got '%s'", expect, got)
+	}
+	if plc.Kind() != types.ModbusServerTcpKind || plc.Port() != 5020 || plc.UnitId() != 1 || plc.Bind() != "" {
+		t.Errorf("unexpected plc server %v", plc)
+	}
+	if soc := plc.Registers()[0]; soc.Type() != "u16" || soc.Length() != 1 || soc.Scale() != 0.1 {
+		t.Errorf("unexpected SOC register %v", soc)
+	}
+	if voltage := plc.Registers()[1]; voltage.Length() != 2 || voltage.WordOrder() != "LittleEndian" || voltage.ByteOrder() != "BigEndian" {
+		t.Errorf("unexpected MainVoltage register %v", voltage)
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+VictronDevices:
+  bmv0:
+    Kind: RandomBmv
+ModbusServers:
+  plc:
+    Kind: Udp
+    UnitId: 0
+    Registers:
+      - Function: Holding
+        Address: 0
+        Device: bmv1
+        Register: SOC
+        Type: u32
+      - Function: Holding
+        Address: 1
+        Device: bmv0
+        Register: MainVoltage
+      - Function: Coil
+        Address: 0
+        Device: bmv0
+        Register: Relay
+        Type: u16
+  panel:
+    Kind: Rtu
+`), true)
+	for _, expect := range []string{
+		"ModbusServers->plc->Kind='Udp' is invalid",
+		"ModbusServers->plc->UnitId=0 must be between 1 and 247",
+		"ModbusServers->plc->Registers[0]->Device='bmv1' is not defined",
+		"ModbusServers->plc->Registers[1]->Address=1 overlaps with Registers[0]",
+		"ModbusServers->plc->Registers[2]->Type='u16' must be bool for Function=Coil",
+		"ModbusServers->panel->Device must not be empty",
+		"ModbusServers->panel->BaudRate must be positiv",
+		"ModbusServers->panel->Registers must not be empty",
+	} {
+		if !containsError(expect, err) {
+			t.Errorf("expect error containing '%s' but got %v", expect, err)
+		}
+	}
+}
+
+func TestReadConfig_HttpServerMetrics(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+HttpServer:
+  Bind: "[::1]"
+  Metrics:
+    Filter:
+      SkipCategories:
+        - Device Info
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	m := config.HttpServer().Metrics()
+	if !m.Enabled() {
+		t.Error("expect HttpServer->Metrics to be enabled")
+	}
+	if expect, got := "", m.View(); expect != got {
+		t.Errorf("expect HttpServer->Metrics->View to be '%s' but got '%s'", expect, got)
+	}
+	if expect, got := []string{"Device Info"}, m.Filter().SkipCategories(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect HttpServer->Metrics->Filter->SkipCategories to be %v but got %v", expect, got)
+	}
+	if m.Authenticated() {
+		t.Error("expect HttpServer->Metrics->Authenticated to default to false")
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+HttpServer:
+  Bind: "[::1]"
+  Metrics:
+    View: unknown
+    Authenticated: true
+`), true)
+	if expect := "HttpServer->Metrics->View='unknown' is not defined"; !containsError(expect, err) {
+		t.Errorf("expect error containing '%s' but got %v", expect, err)
+	}
+	if expect := "HttpServer->Metrics->Authenticated requires the Authentication section"; !containsError(expect, err) {
+		t.Errorf("expect error containing '%s' but got %v", expect, err)
+	}
+}
+
+func TestReadConfig_InfluxDbOutputs(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+InfluxDbOutputs:
+  influx0:
+    Url: http://localhost:8086/
+    Bucket: iot
+    Token: secret
+  influx1:
+    Url: http://localhost:8087/
+    Version: 1
+    Database: iot
+    BatchSize: 10
+    FlushInterval: 1m
+    BufferPath: /tmp/influx
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	if expect, got := 2, len(config.InfluxDbOutputs()); expect != got {
+		t.Fatalf("expect length of config.InfluxDbOutputs to be %d but got %d", expect, got)
+	}
+
+	o := config.InfluxDbOutputs()[0]
+	if expect, got := "http://localhost:8086/", o.Url().String(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx0->Url to be '%s' but got '%s'", expect, got)
+	}
+	if expect, got := 2, o.Version(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx0->Version to be %d but got %d", expect, got)
+	}
+	if expect, got := 1000, o.BatchSize(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx0->BatchSize to be %d but got %d", expect, got)
+	}
+	if expect, got := 10*time.Second, o.FlushInterval(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx0->FlushInterval to be %s but got %s", expect, got)
+	}
+	if expect, got := "", o.BufferPath(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx0->BufferPath to be '%s' but got '%s'", expect, got)
+	}
+
+	o = config.InfluxDbOutputs()[1]
+	if expect, got := 1, o.Version(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx1->Version to be %d but got %d", expect, got)
+	}
+	if expect, got := "iot", o.Database(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx1->Database to be '%s' but got '%s'", expect, got)
+	}
+	if expect, got := 10, o.BatchSize(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx1->BatchSize to be %d but got %d", expect, got)
+	}
+	if expect, got := time.Minute, o.FlushInterval(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx1->FlushInterval to be %s but got %s", expect, got)
+	}
+	if expect, got := "/tmp/influx", o.BufferPath(); expect != got {
+		t.Errorf("expect InfluxDbOutputs->influx1->BufferPath to be '%s' but got '%s'", expect, got)
+	}
+
+	_, err = ReadConfig([]byte(`
+Version: 2
+InfluxDbOutputs:
+  influx0:
+    Url: http://localhost:8086/
+    Version: 1
+    BatchSize: 0
+`), true)
+	for _, expect := range []string{
+		"InfluxDbOutputs->influx0->Database must not be empty when using Version 1",
+		"InfluxDbOutputs->influx0->BatchSize=0 must be >=1",
+	} {
+		if !containsError(expect, err) {
+			t.Errorf("expect error containing '%s' but got %v", expect, err)
+		}
+	}
+}
+
+func TestReadConfig_AdminUsers(t *testing.T) {
+	config, err := ReadConfig([]byte(`
+Version: 2
+Authentication:
+  HtaccessFile: ./auth.passwd
+  AdminUsers:
+    - alice
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	auth := config.Authentication()
+	if !auth.IsAdmin("alice") {
+		t.Error("expect alice to be an admin")
+	}
+	if auth.IsAdmin("bob") {
+		t.Error("expect bob not to be an admin")
+	}
+
+	config, err = ReadConfig([]byte(`
+Version: 2
+Authentication:
+  HtaccessFile: ./auth.passwd
+`), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+	if !config.Authentication().IsAdmin("bob") {
+		t.Error("expect every user to be an admin when AdminUsers is empty")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	read := func(yamlStr string) Config {
+		t.Helper()
+		config, err := ReadConfig([]byte(yamlStr), true)
+		if len(err) > 0 {
+			t.Fatalf("expect no error but got %v", err)
+		}
+		return config
+	}
+
+	running := read(`
+Version: 2
+Authentication:
+  HtaccessFile: ./auth.passwd
+MqttClients:
+  local:
+    Broker: tcp://mqtt.example.com:1883
+VictronDevices:
+  bmv0:
+    Device: /dev/ttyVE0
+    Kind: Vedirect
+  bmv1:
+    Device: /dev/ttyVE1
+    Kind: Vedirect
+HttpDevices:
+  tcw241:
+    Url: http://192.168.0.100/
+    Kind: Teracom
+Views:
+  - Name: Overview
+    Title: overview
+    Devices:
+      - Name: bmv0
+        Title: Battery Monitor
+`)
+
+	// the same file results in no difference once the generated values are taken over
+	reread := read(`
+Version: 2
+Authentication:
+  HtaccessFile: ./auth.passwd
+MqttClients:
+  local:
+    Broker: tcp://mqtt.example.com:1883
+VictronDevices:
+  bmv0:
+    Device: /dev/ttyVE0
+    Kind: Vedirect
+  bmv1:
+    Device: /dev/ttyVE1
+    Kind: Vedirect
+HttpDevices:
+  tcw241:
+    Url: http://192.168.0.100/
+    Kind: Teracom
+Views:
+  - Name: Overview
+    Title: overview
+    Devices:
+      - Name: bmv0
+        Title: Battery Monitor
+`)
+	expect := ConfigDiff{
+		MqttClients: ItemDiff{Changed: []string{"local"}},
+		Sections:    []string{"Authentication"},
+	}
+	if diff := Diff(running, reread); !reflect.DeepEqual(expect, diff) {
+		t.Errorf("expect the generated values to differ: %#v but got %#v", expect, diff)
+	}
+	reread.KeepGenerated(running)
+	if diff := Diff(running, reread); !reflect.DeepEqual(ConfigDiff{}, diff) {
+		t.Errorf("expect no difference but got %#v", diff)
+	}
+
+	c := read(`
+Version: 2
+Authentication:
+  HtaccessFile: ./auth.passwd
+  JwtValidityPeriod: 2h
+MqttClients:
+  local:
+    Broker: tcp://mqtt.example.com:1883
+Modbus:
+  bus0:
+    Device: /dev/ttyACM0
+    BaudRate: 9600
+VictronDevices:
+  bmv0:
+    Device: /dev/ttyVE0
+    Kind: Vedirect
+  bmv2:
+    Device: /dev/ttyVE2
+    Kind: Vedirect
+HttpDevices:
+  bmv1:
+    Url: http://192.168.0.101/
+    Kind: Teracom
+  tcw241:
+    Url: http://192.168.0.100/
+    Kind: Teracom
+    LogDebug: true
+Views:
+  - Name: Overview
+    Title: overview
+    Devices:
+      - Name: bmv0
+        Title: Battery
+`)
+	c.KeepGenerated(running)
+
+	expect = ConfigDiff{
+		Devices: ItemDiff{
+			Added:   []string{"bmv2"},
+			Changed: []string{"bmv1", "tcw241"},
+		},
+		Modbus:         ItemDiff{Added: []string{"bus0"}},
+		MqttForwarders: ItemDiff{Changed: []string{"local"}},
+		Views:          ItemDiff{Changed: []string{"Overview"}},
+		Sections:       []string{"Authentication"},
+	}
+	if diff := Diff(running, c); !reflect.DeepEqual(expect, diff) {
+		t.Errorf("expect %#v but got %#v", expect, diff)
+	}
+}
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestReadConfigFile_IncludesEnvAndSecrets(t *testing.T) {
+	t.Setenv("IOTDEVICE_TEST_BROKER", "tcp://mqtt.example.com:1883")
+	t.Setenv("IOTDEVICE_TEST_PORT", "8080")
+
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": `
+Version: 2
+HttpServer:
+  Bind: 127.0.0.1
+  Port: ${IOTDEVICE_TEST_PORT}
+Authentication:
+  HtaccessFile: ./auth.passwd
+  JwtSecretFile: ${IOTDEVICE_TEST_DIR}/secrets/jwt
+MqttClients:
+  local:
+    Broker: ${IOTDEVICE_TEST_BROKER}
+    User: iot
+    PasswordFile: ${IOTDEVICE_TEST_DIR}/secrets/mqtt
+VictronDevices: !include sites/a.yaml
+`,
+		"sites/a.yaml": `
+bmv0:
+  Device: /dev/ttyVE0
+  Kind: Vedirect
+`,
+		"secrets/jwt":  "0123456789abcdef0123456789abcdef\n",
+		"secrets/mqtt": "mqtt-secret\n",
+	})
+	t.Setenv("IOTDEVICE_TEST_DIR", dir)
+
+	config, err := ReadConfigFile("", filepath.Join(dir, "config.yaml"), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+
+	if expect, got := 8080, config.HttpServer().Port(); expect != got {
+		t.Errorf("expect port %d but got %d", expect, got)
+	}
+	if expect, got := "0123456789abcdef0123456789abcdef", string(config.Authentication().JwtSecret()); expect != got {
+		t.Errorf("expect jwt secret %s but got %s", expect, got)
+	}
+	mc := config.MqttClients()[0]
+	if expect, got := "tcp://mqtt.example.com:1883", mc.Broker().String(); expect != got {
+		t.Errorf("expect broker %s but got %s", expect, got)
+	}
+	if expect, got := "mqtt-secret", mc.Password(); expect != got {
+		t.Errorf("expect password %s but got %s", expect, got)
+	}
+	if devices := config.VictronDevices(); len(devices) != 1 || devices[0].Name() != "bmv0" {
+		t.Errorf("expect the included device bmv0 but got %v", devices)
+	}
+
+	// secrets read from a file are not printed
+	out, e := yaml.Marshal(config)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if strings.Contains(string(out), "mqtt-secret") || strings.Contains(string(out), "0123456789abcdef") {
+		t.Errorf("expect the secrets not to be marshaled but got:\n%s", out)
+	}
+
+	// a changed content of the secret files is detected on a reload
+	if diff := Diff(config, config); !reflect.DeepEqual(ConfigDiff{}, diff) {
+		t.Errorf("expect no difference but got %#v", diff)
+	}
+	for name, content := range map[string]string{
+		"secrets/jwt":  "fedcba9876543210fedcba9876543210\n",
+		"secrets/mqtt": "new-secret\n",
+	} {
+		if e := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); e != nil {
+			t.Fatal(e)
+		}
+	}
+	reread, err := ReadConfigFile("", filepath.Join(dir, "config.yaml"), true)
+	if len(err) > 0 {
+		t.Fatalf("expect no error but got %v", err)
+	}
+	reread.KeepGenerated(config)
+	expect := ConfigDiff{
+		MqttClients: ItemDiff{Changed: []string{"local"}},
+		Sections:    []string{"Authentication"},
+	}
+	if diff := Diff(config, reread); !reflect.DeepEqual(expect, diff) {
+		t.Errorf("expect %#v but got %#v", expect, diff)
+	}
+}
+
+func TestReadConfigFile_Positions(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"config.yaml": `Version: 2
+MqttClients:
+  local:
+    Broker: tcp://mqtt.example.com:1883
+    Password: inline
+    PasswordFile: secrets/mqtt
+VictronDevices: !include sites/a.yaml
+`,
+		"sites/a.yaml": `bmv0:
+  Device: /dev/ttyVE0
+  Kind: Vedirect
+  RestartInterval: 1ms
+`,
+		"unknown.yaml": `Version: 2
+VictronDevices: !include sites/unknown.yaml
+`,
+		"sites/unknown.yaml": `bmv0:
+  Device: /dev/ttyVE0
+  Kind: Vedirect
+  Unknown: true
+`,
+		"cycle.yaml": `Version: 2
+VictronDevices: !include cycle.yaml
+`,
+	})
+
+	_, err := ReadConfigFile("", filepath.Join(dir, "config.yaml"), true)
+	for _, expect := range []string{
+		filepath.Join(dir, "config.yaml") + ":5: MqttClients->local->Password and PasswordFile must not both be set",
+		filepath.Join(dir, "sites/a.yaml") + ":4: Devices->bmv0->RestartInterval='1ms' must be >=10ms",
+	} {
+		if !slices.ContainsFunc(err, func(e error) bool { return e.Error() == expect }) {
+			t.Errorf("expect error '%s' but got %v", expect, err)
+		}
+	}
+
+	_, err = ReadConfigFile("", filepath.Join(dir, "unknown.yaml"), true)
+	if expect := filepath.Join(dir, "sites/unknown.yaml") + ":4: field Unknown not found"; !containsError(expect, err) {
+		t.Errorf("expect error '%s' but got %v", expect, err)
+	}
+
+	_, err = ReadConfigFile("", filepath.Join(dir, "cycle.yaml"), true)
+	if expect := filepath.Join(dir, "cycle.yaml") + ":2: !include 'cycle.yaml' includes itself"; !containsError(expect, err) {
+		t.Errorf("expect error '%s' but got %v", expect, err)
+	}
+
+	// a config given as string reports the line only
+	_, err = ReadConfig([]byte(`Version: 2
+HttpDevices:
+  tcw241:
+    Kind: Teracom
+`), true)
+	if expect := "line 3: HttpDevices->tcw241->Url must not be empty"; !containsError(expect, err) {
+		t.Errorf("expect error '%s' but got %v", expect, err)
+	}
+}
+
+func TestReadConfig_InvalidRules(t *testing.T) {
+	tests := []struct {
+		config string
+		expect string
+	}{
+		{`
+Version: 2
+Rules:
+  r0:
+    Triggers:
+      - Kind: Threshold
+        Device: unknown
+        Register: SOC
+    Actions:
+      - Kind: Foo
+`, ""},
+		{`
+Version: 2
+Rules:
+  r0:
+    Triggers:
+      - Kind: Cron
+        Schedule: "0 0 30 2 *"
+    Condition: "1 +"
+    Actions:
+      - Kind: Webhook
+        Url: ftp://example.com
+`, ""},
+	}
+	expectErrors := [][]string{
+		{
+			"Rules->r0->Triggers[0]->Device='unknown' is not defined",
+			"Rules->r0->Triggers[0]->exactly one of Above and Below must be set",
+			"Rules->r0->Actions[0]->Kind='Foo' is invalid",
+		},
+		{
+			"Rules->r0->Triggers[0]->Schedule='0 0 30 2 *' never matches",
+			"Rules->r0->Condition='1 +' parse error",
+			"Rules->r0->Actions[0]->Url='ftp://example.com' must use http or https",
+		},
+	}
+
+	for i, tc := range tests {
+		_, err := ReadConfig([]byte(tc.config), true)
+		for _, expect := range expectErrors[i] {
+			if !containsError(expect, err) {
+				t.Errorf("expect error containing '%s' but got %v", expect, err)
+			}
+		}
+	}
+}
+
+func TestReadConfig_InvalidAlarms(t *testing.T) {
+	tests := []struct {
+		config string
+		expect []string
+	}{
+		{`
+Version: 2
+Alarms:
+  HistorySize: 0
+`, []string{
+			"Alarms->HistorySize=0 must be >0",
+			"Alarms->Definitions must not be empty",
+		}},
+		{`
+Version: 2
+Alarms:
+  Definitions:
+    a0:
+      Kind: Low
+      Device: unknown
+      Register: SOC
+      Interval: 1m
+      Notifiers:
+        - pager
+    a1:
+      Kind: Offline
+      Device: unknown
+      Register: Available
+      Limit: 1
+    a2:
+      Kind: Foo
+  Notifiers:
+    mail:
+      Kind: Smtp
+    hook:
+      Kind: Webhook
+      Url: ftp://example.com
+`, []string{
+			"Alarms->Definitions->a0->Device='unknown' is not defined",
+			"Alarms->Definitions->a0->Limit must be set",
+			"Alarms->Definitions->a0->Interval is not supported by Low alarms",
+			"Alarms->Definitions->a0->Notifiers: notifier='pager' is not defined",
+			"Alarms->Definitions->a1->Register is not supported by Offline alarms",
+			"Alarms->Definitions->a1->Limit, Hysteresis and Delay are only supported by High, Low and Rate alarms",
+			"Alarms->Definitions->a1->Interval must not be empty",
+			"Alarms->Definitions->a2->Kind='Foo' is invalid",
+			"Alarms->Notifiers->mail->Host must not be empty",
+			"Alarms->Notifiers->mail->From must not be empty",
+			"Alarms->Notifiers->hook->Url='ftp://example.com' must use http or https",
+		}},
+	}
+
+	for _, tc := range tests {
+		_, err := ReadConfig([]byte(tc.config), true)
+		for _, expect := range tc.expect {
+			if !containsError(expect, err) {
+				t.Errorf("expect error containing '%s' but got %v", expect, err)
+			}
+		}
+	}
+}
+
 func TestReadConfig_Complete(t *testing.T) {
 	config, err := ReadConfig([]byte(ValidCompleteConfig), true)
 	if len(err) > 0 {
@@ -830,7 +1976,7 @@ func TestReadConfig_Complete(t *testing.T) {
 					t.Errorf("expect %s->Qos to be %d but got %d", sPrefix, expect, got)
 				}
 
-				if expect, got := []string{"bmv0", "genset0", "gpio0", "modbus-rtu0", "tcw241"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
+				if expect, got := []string{"bmv0", "bmv0-computed", "genset0", "gpio0", "modbus-rtu0", "tcw241"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
 					t.Errorf("expect %s->Devices to be %v but got %v", sPrefix, expect, got)
 				} else {
 					prefix := sPrefix + "->Devices->bmv0"
@@ -886,7 +2032,7 @@ func TestReadConfig_Complete(t *testing.T) {
 					t.Errorf("expect %s->Qos to be %d but got %d", sPrefix, expect, got)
 				}
 
-				if expect, got := []string{"bmv0", "genset0", "gpio0", "modbus-rtu0", "tcw241"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
+				if expect, got := []string{"bmv0", "bmv0-computed", "genset0", "gpio0", "modbus-rtu0", "tcw241"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
 					t.Errorf("expect %s->Devices to be %v but got %v", sPrefix, expect, got)
 				} else {
 					prefix := sPrefix + "->Devices->bmv0"
@@ -942,7 +2088,7 @@ func TestReadConfig_Complete(t *testing.T) {
 					t.Errorf("expect %s->Qos to be %d but got %d", sPrefix, expect, got)
 				}
 
-				if expect, got := []string{"bmv0", "genset0", "gpio0", "modbus-rtu0", "tcw241"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
+				if expect, got := []string{"bmv0", "bmv0-computed", "genset0", "gpio0", "modbus-rtu0", "tcw241"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
 					t.Errorf("expect %s->Devices to be %v but got %v", sPrefix, expect, got)
 				} else {
 					prefix := sPrefix + "->Devices->bmv0"
@@ -970,6 +2116,31 @@ func TestReadConfig_Complete(t *testing.T) {
 				}
 			}
 
+			{
+				mcSect := mc.Alarms()
+				sPrefix := prefix + "->Alarms"
+
+				if expect, got := "my-prefix/alarm-X/low-soc", mc.AlarmsTopic("low-soc"); expect != got {
+					t.Errorf("expect %s->AlarmsTopic to be '%s' but got '%s'", prefix, expect, got)
+				}
+
+				if got := mcSect.Enabled(); !got {
+					t.Errorf("expect %s->Enabled to be true", sPrefix)
+				}
+
+				if expect, got := "%Prefix%alarm-X/%AlarmName%", mcSect.TopicTemplate(); expect != got {
+					t.Errorf("expect %s->TopicTemplate to be '%s' but got '%s'", sPrefix, expect, got)
+				}
+
+				if got := mcSect.Retain(); got {
+					t.Errorf("expect %s->Retain to be false", sPrefix)
+				}
+
+				if expect, got := []string{"bmv0"}, getNames(mcSect.Devices()); !reflect.DeepEqual(expect, got) {
+					t.Errorf("expect %s->Devices to be %v but got %v", sPrefix, expect, got)
+				}
+			}
+
 			if !mc.LogDebug() {
 				t.Error("expect MqttClients->0-local->LogDebug to be true")
 			}
@@ -1038,6 +2209,10 @@ func TestReadConfig_Complete(t *testing.T) {
 				t.Error("expect MqttClients->2-readonly->Command->Enabled to be false")
 			}
 
+			if got := mc.Alarms().Enabled(); got {
+				t.Error("expect MqttClients->2-readonly->Alarms->Enabled to be false")
+			}
+
 		}
 
 	}
@@ -1110,6 +2285,107 @@ func TestReadConfig_Complete(t *testing.T) {
 			t.Errorf("expect VictronDevices->bmv0->General->RestartIntervalMaxBackoff to be %s but got %s", expect, got)
 		}
 
+		if expect, got := 10*time.Second, vd.MaxAge("Power"); expect != got {
+			t.Errorf("expect VictronDevices->bmv0->General->MaxAge to be %s but got %s", expect, got)
+		}
+
+		if expect, got := 5*time.Second, vd.MaxAge("BatteryVoltage"); expect != got {
+			t.Errorf("expect VictronDevices->bmv0->General->RegisterMaxAge->BatteryVoltage to be %s but got %s", expect, got)
+		}
+
+		if m, ok := vd.RegisterMeta()["SOC"]; !ok {
+			t.Error("expect VictronDevices->bmv0->RegisterMeta->SOC to be set")
+		} else {
+			if m.Min() == nil || *m.Min() != 0 || m.Max() == nil || *m.Max() != 100 {
+				t.Errorf("expect VictronDevices->bmv0->RegisterMeta->SOC->Min/Max to be 0/100 but got %v/%v", m.Min(), m.Max())
+			}
+			if m.Step() == nil || *m.Step() != 0.1 {
+				t.Errorf("expect VictronDevices->bmv0->RegisterMeta->SOC->Step to be 0.1 but got %v", m.Step())
+			}
+			if m.Precision() == nil || *m.Precision() != 1 {
+				t.Errorf("expect VictronDevices->bmv0->RegisterMeta->SOC->Precision to be 1 but got %v", m.Precision())
+			}
+			if expect, got := "battery", m.DeviceClass(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterMeta->SOC->DeviceClass to be %s but got %s", expect, got)
+			}
+			if expect, got := "measurement", m.StateClass(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterMeta->SOC->StateClass to be %s but got %s", expect, got)
+			}
+		}
+
+		if expect, got := 2, len(vd.RegisterTransform()); expect != got {
+			t.Errorf("expect length of VictronDevices->bmv0->RegisterTransform to be %d but got %d", expect, got)
+		} else {
+			tr := vd.RegisterTransform()["Power"]
+			if tr.Scale() == nil || *tr.Scale() != 0.001 {
+				t.Errorf("expect VictronDevices->bmv0->RegisterTransform->Power->Scale to be 0.001 but got %v", tr.Scale())
+			}
+			if tr.Offset() == nil || *tr.Offset() != 0.5 {
+				t.Errorf("expect VictronDevices->bmv0->RegisterTransform->Power->Offset to be 0.5 but got %v", tr.Offset())
+			}
+			if expect, got := "kW", tr.Unit(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterTransform->Power->Unit to be '%s' but got '%s'", expect, got)
+			}
+			if tr.Round() == nil || *tr.Round() != 2 {
+				t.Errorf("expect VictronDevices->bmv0->RegisterTransform->Power->Round to be 2 but got %v", tr.Round())
+			}
+			if expect, got := "Battery power", tr.Description(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterTransform->Power->Description to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "Essential", tr.Category(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterTransform->Power->Category to be '%s' but got '%s'", expect, got)
+			}
+
+			tr = vd.RegisterTransform()["ConsumedAmpHours"]
+			if tr.Scale() != nil || tr.Offset() != nil || tr.Round() != nil {
+				t.Error("expect VictronDevices->bmv0->RegisterTransform->ConsumedAmpHours->Scale, Offset and Round to be nil")
+			}
+		}
+
+		if d, ok := vd.Deadband("Power", "Essential"); !ok {
+			t.Error("expect VictronDevices->bmv0->RegisterDeadband->Power to be set")
+		} else {
+			if expect, got := 0.5, d.Absolute(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterDeadband->Power->Absolute to be %g but got %g", expect, got)
+			}
+			if expect, got := 2., d.Percent(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterDeadband->Power->Percent to be %g but got %g", expect, got)
+			}
+			if expect, got := time.Second, d.MinInterval(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterDeadband->Power->MinInterval to be %s but got %s", expect, got)
+			}
+			if expect, got := 5*time.Minute, d.MaxInterval(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->RegisterDeadband->Power->MaxInterval to be %s but got %s", expect, got)
+			}
+		}
+
+		if d, ok := vd.Deadband("SOC", "Essential"); !ok {
+			t.Error("expect VictronDevices->bmv0->CategoryDeadband->Essential to be set")
+		} else if expect, got := time.Minute, d.MaxInterval(); expect != got {
+			t.Errorf("expect VictronDevices->bmv0->CategoryDeadband->Essential->MaxInterval to be %s but got %s", expect, got)
+		}
+
+		if _, ok := vd.Deadband("SOC", "Monitor"); ok {
+			t.Error("expect no deadband for VictronDevices->bmv0->SOC in category Monitor")
+		}
+
+		if h := vd.History(); !h.Enabled() {
+			t.Error("expect VictronDevices->bmv0->History to be enabled")
+		} else {
+			if expect, got := 2*time.Hour, h.MaxAge(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->History->MaxAge to be %s but got %s", expect, got)
+			}
+			if expect, got := 720, h.MaxSamples(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->History->MaxSamples to be %d but got %d", expect, got)
+			}
+			if expect, got := 10*time.Second, h.Resolution(); expect != got {
+				t.Errorf("expect VictronDevices->bmv0->History->Resolution to be %s but got %s", expect, got)
+			}
+			if expect, got := []string{"BatteryVoltage"}, h.Filter().IncludeRegisters(); !reflect.DeepEqual(expect, got) {
+				t.Errorf("expect VictronDevices->bmv0->History->Filter->IncludeRegisters to be %v but got %v", expect, got)
+			}
+		}
+
 		if !vd.LogDebug() {
 			t.Error("expect VictronDevices->bmv0->General->LogDebug to be true")
 		}
@@ -1418,6 +2694,62 @@ func TestReadConfig_Complete(t *testing.T) {
 		}
 	}
 
+	if expect, got := 1, len(config.ComputedDevices()); expect != got {
+		t.Errorf("expect length of config.ComputedDevices to be %d but got %d", expect, got)
+	} else {
+		cd := config.ComputedDevices()[0]
+
+		if expect, got := "bmv0-computed", cd.Name(); expect != got {
+			t.Errorf("expect Name of first ComputedDevice to be '%s' but got '%s'", expect, got)
+		}
+
+		if !cd.LogDebug() {
+			t.Error("expect ComputedDevices->bmv0-computed->LogDebug to be true")
+		}
+
+		if expect, got := 2, len(cd.Registers()); expect != got {
+			t.Errorf("expect length of ComputedDevices->bmv0-computed->Registers to be %d but got %d", expect, got)
+		} else {
+			// registers are sorted by name
+			charging := cd.Registers()[0]
+			if expect, got := "Charging", charging.Name(); expect != got {
+				t.Errorf("expect first register of ComputedDevices->bmv0-computed to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "bool", charging.Type(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Charging->Type to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "Battery", charging.Category(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Charging->Category to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "Charging", charging.Description(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Charging->Description to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := 0, charging.Sort(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Charging->Sort to be %d but got %d", expect, got)
+			}
+
+			power := cd.Registers()[1]
+			if expect, got := "bmv0.MainVoltage * bmv0.Current", power.Expression(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Power->Expression to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "number", power.Type(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Power->Type to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "Computed", power.Category(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Power->Category to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "Battery Power", power.Description(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Power->Description to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "W", power.Unit(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Power->Unit to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := 10, power.Sort(); expect != got {
+				t.Errorf("expect ComputedDevices->bmv0-computed->Registers->Power->Sort to be %d but got %d", expect, got)
+			}
+		}
+	}
+
 	if expect, got := 1, len(config.GensetDevices()); expect != got {
 		t.Errorf("expect length of config.GensetDevices to be %d but got %d", expect, got)
 	} else {
@@ -1676,6 +3008,279 @@ func TestReadConfig_Complete(t *testing.T) {
 			}
 		}
 	}
+
+	{
+		a := config.Archive()
+		if !a.Enabled() {
+			t.Error("expect Archive to be enabled")
+		}
+		if expect, got := "/var/lib/go-iotdevice/archive", a.Path(); expect != got {
+			t.Errorf("expect Archive->Path to be '%s' but got '%s'", expect, got)
+		}
+		if expect, got := 20*time.Second, a.FlushInterval(); expect != got {
+			t.Errorf("expect Archive->FlushInterval to be %s but got %s", expect, got)
+		}
+		if expect, got := 48*time.Hour, a.RawRetention(); expect != got {
+			t.Errorf("expect Archive->RawRetention to be %s but got %s", expect, got)
+		}
+		if expect, got := 240*time.Hour, a.MinuteRetention(); expect != got {
+			t.Errorf("expect Archive->MinuteRetention to be %s but got %s", expect, got)
+		}
+		if expect, got := 8760*time.Hour, a.HourRetention(); expect != got {
+			t.Errorf("expect Archive->HourRetention to be %s but got %s", expect, got)
+		}
+		if expect, got := []string{"bmv0"}, getNames(a.Devices()); !reflect.DeepEqual(expect, got) {
+			t.Errorf("expect Archive->Devices to be %v but got %v", expect, got)
+		} else if expect, got := []string{"Essential"}, a.Devices()[0].Filter().IncludeCategories(); !reflect.DeepEqual(expect, got) {
+			t.Errorf("expect Archive->Devices->bmv0->Filter->IncludeCategories to be %v but got %v", expect, got)
+		}
+		if !a.LogDebug() {
+			t.Error("expect Archive->LogDebug to be true")
+		}
+	}
+
+	{
+		s := config.Snapshot()
+		if !s.Enabled() {
+			t.Error("expect Snapshot to be enabled")
+		}
+		if expect, got := "/var/lib/go-iotdevice/snapshot.json", s.Path(); expect != got {
+			t.Errorf("expect Snapshot->Path to be '%s' but got '%s'", expect, got)
+		}
+		if expect, got := 30*time.Second, s.Interval(); expect != got {
+			t.Errorf("expect Snapshot->Interval to be %s but got %s", expect, got)
+		}
+		if expect, got := types.RestorePolicyNone, s.DefaultPolicy(); expect != got {
+			t.Errorf("expect Snapshot->DefaultPolicy to be %s but got %s", expect, got)
+		}
+		if expect, got := []string{"bmv0"}, getNames(s.Devices()); !reflect.DeepEqual(expect, got) {
+			t.Errorf("expect Snapshot->Devices to be %v but got %v", expect, got)
+		}
+		for _, tc := range []struct {
+			device, register string
+			expect           types.RestorePolicy
+		}{
+			{"bmv0", "BatteryVoltage", types.RestorePolicyState},
+			{"bmv0", "Power", types.RestorePolicyAll},
+			{"bmv1", "Power", types.RestorePolicyNone},
+		} {
+			if got := s.Policy(tc.device, tc.register); tc.expect != got {
+				t.Errorf("expect Snapshot policy of %s->%s to be %s but got %s", tc.device, tc.register, tc.expect, got)
+			}
+		}
+		if !s.LogDebug() {
+			t.Error("expect Snapshot->LogDebug to be true")
+		}
+	}
+
+	if expect, got := 1, len(config.Rules()); expect != got {
+		t.Errorf("expect length of config.Rules to be %d but got %d", expect, got)
+	} else {
+		r := config.Rules()[0]
+		if expect, got := "low-soc", r.Name(); expect != got {
+			t.Errorf("expect Name of first Rule to be '%s' but got '%s'", expect, got)
+		}
+		if expect, got := "bmv0.Current < 0", r.Condition(); expect != got {
+			t.Errorf("expect Rules->low-soc->Condition to be '%s' but got '%s'", expect, got)
+		}
+		if !r.LogDebug() {
+			t.Error("expect Rules->low-soc->LogDebug to be true")
+		}
+
+		if expect, got := 2, len(r.Triggers()); expect != got {
+			t.Errorf("expect length of Rules->low-soc->Triggers to be %d but got %d", expect, got)
+		} else {
+			th := r.Triggers()[0]
+			if expect, got := types.RuleTriggerThresholdKind, th.Kind(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[0]->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "bmv0", th.Device(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[0]->Device to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "SOC", th.Register(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[0]->Register to be '%s' but got '%s'", expect, got)
+			}
+			if th.Above() != nil {
+				t.Error("expect Rules->low-soc->Triggers[0]->Above to be nil")
+			}
+			if got := th.Below(); got == nil || *got != 20 {
+				t.Errorf("expect Rules->low-soc->Triggers[0]->Below to be 20 but got %v", got)
+			}
+			if expect, got := 5.0, th.Hysteresis(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[0]->Hysteresis to be %g but got %g", expect, got)
+			}
+			if expect, got := time.Minute, th.Hold(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[0]->Hold to be %s but got %s", expect, got)
+			}
+
+			cr := r.Triggers()[1]
+			if expect, got := types.RuleTriggerCronKind, cr.Kind(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[1]->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "0 6 * * *", cr.Schedule(); expect != got {
+				t.Errorf("expect Rules->low-soc->Triggers[1]->Schedule to be '%s' but got '%s'", expect, got)
+			}
+		}
+
+		if expect, got := 3, len(r.Actions()); expect != got {
+			t.Errorf("expect length of Rules->low-soc->Actions to be %d but got %d", expect, got)
+		} else {
+			cmd := r.Actions()[0]
+			if expect, got := types.RuleActionCommandKind, cmd.Kind(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[0]->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "modbus-rtu0", cmd.Device(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[0]->Device to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "CH1", cmd.Register(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[0]->Register to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "true", cmd.Value(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[0]->Value to be '%s' but got '%s'", expect, got)
+			}
+
+			mqtt := r.Actions()[1]
+			if expect, got := "0-local", mqtt.MqttClient(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[1]->MqttClient to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "alerts/low-soc", mqtt.Topic(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[1]->Topic to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "{{.Rule}} fired at {{.Time}}", mqtt.Payload(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[1]->Payload to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := byte(2), mqtt.Qos(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[1]->Qos to be %d but got %d", expect, got)
+			}
+			if !mqtt.Retain() {
+				t.Error("expect Rules->low-soc->Actions[1]->Retain to be true")
+			}
+
+			hook := r.Actions()[2]
+			if expect, got := "https://example.com/hook", hook.Url().String(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[2]->Url to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "PUT", hook.Method(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[2]->Method to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := 5*time.Second, hook.Timeout(); expect != got {
+				t.Errorf("expect Rules->low-soc->Actions[2]->Timeout to be %s but got %s", expect, got)
+			}
+		}
+	}
+
+	if a := config.Alarms(); !a.Enabled() {
+		t.Error("expect Alarms to be enabled")
+	} else {
+		if expect, got := 50, a.HistorySize(); expect != got {
+			t.Errorf("expect Alarms->HistorySize to be %d but got %d", expect, got)
+		}
+		if !a.LogDebug() {
+			t.Error("expect Alarms->LogDebug to be true")
+		}
+
+		if expect, got := []string{"bmv0-offline", "low-soc"}, getNames(a.Definitions()); !reflect.DeepEqual(expect, got) {
+			t.Errorf("expect Alarms->Definitions to be %v but got %v", expect, got)
+		} else {
+			off := a.Definitions()[0]
+			if expect, got := types.AlarmOfflineKind, off.Kind(); expect != got {
+				t.Errorf("expect Alarms->Definitions->bmv0-offline->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := 5*time.Minute, off.Interval(); expect != got {
+				t.Errorf("expect Alarms->Definitions->bmv0-offline->Interval to be %s but got %s", expect, got)
+			}
+			if expect, got := types.AlarmWarningSeverity, off.Severity(); expect != got {
+				t.Errorf("expect Alarms->Definitions->bmv0-offline->Severity to be %s but got %s", expect, got)
+			}
+			if got := off.Notifiers(); len(got) > 0 {
+				t.Errorf("expect Alarms->Definitions->bmv0-offline->Notifiers to be empty but got %v", got)
+			}
+
+			low := a.Definitions()[1]
+			if expect, got := types.AlarmLowKind, low.Kind(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "bmv0", low.Device(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Device to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "SOC", low.Register(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Register to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := 20.0, low.Limit(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Limit to be %g but got %g", expect, got)
+			}
+			if expect, got := 5.0, low.Hysteresis(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Hysteresis to be %g but got %g", expect, got)
+			}
+			if expect, got := time.Minute, low.Delay(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Delay to be %s but got %s", expect, got)
+			}
+			if expect, got := types.AlarmCriticalSeverity, low.Severity(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Severity to be %s but got %s", expect, got)
+			}
+			if expect, got := "Battery is almost empty", low.Message(); expect != got {
+				t.Errorf("expect Alarms->Definitions->low-soc->Message to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := []string{"hook", "mail"}, low.Notifiers(); !reflect.DeepEqual(expect, got) {
+				t.Errorf("expect Alarms->Definitions->low-soc->Notifiers to be %v but got %v", expect, got)
+			}
+		}
+
+		if expect, got := []string{"hook", "mail", "phone"}, getNames(a.Notifiers()); !reflect.DeepEqual(expect, got) {
+			t.Errorf("expect Alarms->Notifiers to be %v but got %v", expect, got)
+		} else {
+			hook := a.Notifiers()[0]
+			if expect, got := types.AlarmNotifierWebhookKind, hook.Kind(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->hook->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "https://example.com/alarm", hook.Url().String(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->hook->Url to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "PUT", hook.Method(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->hook->Method to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "hook-secret", hook.Token(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->hook->Token to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := 3*time.Second, hook.Timeout(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->hook->Timeout to be %s but got %s", expect, got)
+			}
+
+			mail := a.Notifiers()[1]
+			if expect, got := types.AlarmNotifierSmtpKind, mail.Kind(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "smtp.example.com", mail.Host(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->Host to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := 465, mail.Port(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->Port to be %d but got %d", expect, got)
+			}
+			if expect, got := "iot", mail.User(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->User to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "Ohb8eiqu", mail.Password(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->Password to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := "iot@example.com", mail.From(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->From to be '%s' but got '%s'", expect, got)
+			}
+			if expect, got := []string{"ops@example.com"}, mail.To(); !reflect.DeepEqual(expect, got) {
+				t.Errorf("expect Alarms->Notifiers->mail->To to be %v but got %v", expect, got)
+			}
+			if expect, got := 10*time.Second, mail.Timeout(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->mail->Timeout to be %s but got %s", expect, got)
+			}
+
+			phone := a.Notifiers()[2]
+			if expect, got := types.AlarmNotifierNtfyKind, phone.Kind(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->phone->Kind to be %s but got %s", expect, got)
+			}
+			if expect, got := "https://ntfy.sh/my-alarms", phone.Url().String(); expect != got {
+				t.Errorf("expect Alarms->Notifiers->phone->Url to be '%s' but got '%s'", expect, got)
+			}
+		}
+	}
 }
 
 func TestReadConfig_Default(t *testing.T) {
@@ -2251,6 +3856,14 @@ func TestReadConfig_Default(t *testing.T) {
 			t.Errorf("expect VictronDevices->bmv0->General->RestartIntervalMaxBackoff to be %s but got %s", expect, got)
 		}
 
+		if expect, got := time.Duration(0), vd.MaxAge("BatteryVoltage"); expect != got {
+			t.Errorf("expect VictronDevices->bmv0->General->MaxAge to be %s but got %s", expect, got)
+		}
+
+		if vd.History().Enabled() {
+			t.Error("expect VictronDevices->bmv0->History to be disabled")
+		}
+
 		if vd.LogDebug() {
 			t.Error("expect VictronDevices->bmv0->General->LogDebug to be false")
 		}
@@ -2458,6 +4071,10 @@ func TestReadConfig_Default(t *testing.T) {
 		}
 	}
 
+	if expect, got := 0, len(config.ComputedDevices()); expect != got {
+		t.Errorf("expect length of config.ComputedDevices to be %d but got %d", expect, got)
+	}
+
 	if expect, got := 1, len(config.GensetDevices()); expect != got {
 		t.Errorf("expect length of config.GensetDevices to be %d but got %d", expect, got)
 	} else {
@@ -2607,6 +4224,22 @@ func TestReadConfig_Default(t *testing.T) {
 			}
 		}
 	}
+
+	if config.Archive().Enabled() {
+		t.Error("expect Archive to be disabled")
+	}
+
+	if config.Snapshot().Enabled() {
+		t.Error("expect Snapshot to be disabled")
+	}
+
+	if expect, got := 0, len(config.Rules()); expect != got {
+		t.Errorf("expect length of config.Rules to be %d but got %d", expect, got)
+	}
+
+	if config.Alarms().Enabled() {
+		t.Error("expect Alarms to be disabled")
+	}
 }
 
 // check that configuration file in the documentation do not contain any errors
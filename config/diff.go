@@ -0,0 +1,194 @@
+package config
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// ItemDiff lists the names of the items which were added, removed or changed between two configurations.
+type ItemDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d ItemDiff) Empty() bool {
+	return len(d.Added) < 1 && len(d.Removed) < 1 && len(d.Changed) < 1
+}
+
+// ConfigDiff holds the differences between a running and a newly read configuration.
+type ConfigDiff struct {
+	Devices        ItemDiff // a device whose type changed is listed as changed
+	Modbus         ItemDiff
+	MqttClients    ItemDiff // the connection settings only
+	MqttForwarders ItemDiff // the forwarded sections only; MqttDevices is part of the devices
+	Views          ItemDiff
+	Sections       []string // all other top level sections which differ, e.g. HttpServer or Rules
+}
+
+// hot reloadable sections are compared item by item, all others only as a whole
+var itemSections = []string{
+	"MqttClients", "Modbus",
+	"VictronDevices", "ModbusDevices", "GpioDevices", "HttpDevices",
+	"MqttDevices", "SystemDevices", "ComputedDevices", "GensetDevices",
+	"Views",
+}
+
+// KeepGenerated takes over the values which were generated randomly when the running configuration was read,
+// i.e. the JwtSecret and the ClientIds if not set, such that a reloaded configuration only differs where the file does.
+func (c *Config) KeepGenerated(running Config) {
+	if c.authentication.jwtSecretGenerated && running.authentication.jwtSecretGenerated {
+		c.authentication.jwtSecret = running.authentication.jwtSecret
+	}
+
+	for i, mc := range c.mqttClients {
+		if !mc.clientIdGenerated {
+			continue
+		}
+		for _, r := range running.mqttClients {
+			if r.name == mc.name && r.clientIdGenerated {
+				c.mqttClients[i].clientId = r.clientId
+			}
+		}
+	}
+}
+
+// Diff compares the running configuration with a newly read one.
+func Diff(running, c Config) (ret ConfigDiff) {
+	o := running.convertToRead()
+	n := c.convertToRead()
+
+	ret.Devices = diffMaps(devicesByName(o), devicesByName(n))
+	ret.Modbus = diffMaps(o.Modbus, n.Modbus)
+	ret.MqttClients = diffMaps(mqttClientsConnection(o.MqttClients), mqttClientsConnection(n.MqttClients))
+	ret.MqttForwarders = diffMaps(mqttClientsForwarders(o.MqttClients), mqttClientsForwarders(n.MqttClients))
+	ret.Views = diffMaps(viewsByName(o.Views), viewsByName(n.Views))
+
+	ov := reflect.ValueOf(o)
+	nv := reflect.ValueOf(n)
+	t := ov.Type()
+	for i := 0; i < t.NumField(); i++ {
+		section := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if slices.Contains(itemSections, section) {
+			continue
+		}
+		if !reflect.DeepEqual(ov.Field(i).Interface(), nv.Field(i).Interface()) {
+			ret.Sections = append(ret.Sections, section)
+		}
+	}
+
+	return
+}
+
+func (c Config) convertToRead() configRead {
+	r, _ := c.MarshalYAML()
+	return r.(configRead)
+}
+
+// deviceKind is used to compare devices across the per type sections.
+type deviceKind struct {
+	kind string
+	read any
+}
+
+func devicesByName(c configRead) map[string]deviceKind {
+	ret := make(map[string]deviceKind)
+	add := func(kind string, name string, read any) {
+		ret[name] = deviceKind{kind, read}
+	}
+	for name, d := range c.VictronDevices {
+		add("Victron", name, d)
+	}
+	for name, d := range c.ModbusDevices {
+		add("Modbus", name, d)
+	}
+	for name, d := range c.GpioDevices {
+		add("Gpio", name, d)
+	}
+	for name, d := range c.HttpDevices {
+		add("Http", name, d)
+	}
+	for name, d := range c.MqttDevices {
+		add("Mqtt", name, d)
+	}
+	for name, d := range c.SystemDevices {
+		add("System", name, d)
+	}
+	for name, d := range c.ComputedDevices {
+		add("Computed", name, d)
+	}
+	for name, d := range c.GensetDevices {
+		add("Genset", name, d)
+	}
+	return ret
+}
+
+func mqttClientsConnection(clients map[string]mqttClientConfigRead) map[string]mqttClientConfigRead {
+	ret := make(map[string]mqttClientConfigRead, len(clients))
+	for name, c := range clients {
+		ret[name] = mqttClientConfigRead{
+			Broker:             c.Broker,
+			ProtocolVersion:    c.ProtocolVersion,
+			User:               c.User,
+			Password:           c.Password,
+			PasswordFile:       c.PasswordFile,
+			ClientId:           c.ClientId,
+			KeepAlive:          c.KeepAlive,
+			ConnectRetryDelay:  c.ConnectRetryDelay,
+			ConnectTimeout:     c.ConnectTimeout,
+			TopicPrefix:        c.TopicPrefix,
+			ReadOnly:           c.ReadOnly,
+			MaxBacklogSize:     c.MaxBacklogSize,
+			AvailabilityClient: c.AvailabilityClient,
+			LogDebug:           c.LogDebug,
+			LogMessages:        c.LogMessages,
+
+			passwordFromFile: c.passwordFromFile,
+		}
+	}
+	return ret
+}
+
+func mqttClientsForwarders(clients map[string]mqttClientConfigRead) map[string]mqttClientConfigRead {
+	ret := make(map[string]mqttClientConfigRead, len(clients))
+	for name, c := range clients {
+		ret[name] = mqttClientConfigRead{
+			AvailabilityDevice:     c.AvailabilityDevice,
+			Structure:              c.Structure,
+			Telemetry:              c.Telemetry,
+			Realtime:               c.Realtime,
+			HomeassistantDiscovery: c.HomeassistantDiscovery,
+			Command:                c.Command,
+			Alarms:                 c.Alarms,
+		}
+	}
+	return ret
+}
+
+func viewsByName(views []viewConfigRead) map[string]viewConfigRead {
+	ret := make(map[string]viewConfigRead, len(views))
+	for _, v := range views {
+		ret[v.Name] = v
+	}
+	return ret
+}
+
+func diffMaps[O any](running, c map[string]O) (ret ItemDiff) {
+	for name, n := range c {
+		if o, ok := running[name]; !ok {
+			ret.Added = append(ret.Added, name)
+		} else if !reflect.DeepEqual(o, n) {
+			ret.Changed = append(ret.Changed, name)
+		}
+	}
+	for name := range running {
+		if _, ok := c[name]; !ok {
+			ret.Removed = append(ret.Removed, name)
+		}
+	}
+	slices.Sort(ret.Added)
+	slices.Sort(ret.Removed)
+	slices.Sort(ret.Changed)
+	return
+}
@@ -73,6 +73,14 @@ func (c Config) MqttDevices() []MqttDeviceConfig {
 	return c.mqttDevices
 }
 
+func (c Config) SystemDevices() []SystemDeviceConfig {
+	return c.systemDevices
+}
+
+func (c Config) ComputedDevices() []ComputedDeviceConfig {
+	return c.computedDevices
+}
+
 func (c Config) GensetDevices() []GensetDeviceConfig {
 	return c.gensetDevices
 }
@@ -81,6 +89,30 @@ func (c Config) Views() []ViewConfig {
 	return c.views
 }
 
+func (c Config) Archive() ArchiveConfig {
+	return c.archive
+}
+
+func (c Config) InfluxDbOutputs() []InfluxDbOutputConfig {
+	return c.influxDbOutputs
+}
+
+func (c Config) ModbusServers() []ModbusServerConfig {
+	return c.modbusServers
+}
+
+func (c Config) Snapshot() SnapshotConfig {
+	return c.snapshot
+}
+
+func (c Config) Rules() []RuleConfig {
+	return c.rules
+}
+
+func (c Config) Alarms() AlarmsConfig {
+	return c.alarms
+}
+
 // Getters for HttpServerConfig struct
 
 func (c HttpServerConfig) Enabled() bool {
@@ -115,10 +147,32 @@ func (c HttpServerConfig) ConfigExpires() time.Duration {
 	return c.configExpires
 }
 
+func (c HttpServerConfig) Metrics() MetricsConfig {
+	return c.metrics
+}
+
 func (c HttpServerConfig) LogDebug() bool {
 	return c.logDebug
 }
 
+// Getters for MetricsConfig struct
+
+func (c MetricsConfig) Enabled() bool {
+	return c.enabled
+}
+
+func (c MetricsConfig) View() string {
+	return c.view
+}
+
+func (c MetricsConfig) Filter() FilterConfig {
+	return c.filter
+}
+
+func (c MetricsConfig) Authenticated() bool {
+	return c.authenticated
+}
+
 // Getters for Authentication struct
 
 func (c AuthenticationConfig) Enabled() bool {
@@ -137,6 +191,15 @@ func (c AuthenticationConfig) HtaccessFile() string {
 	return c.htaccessFile
 }
 
+// IsAdmin returns true if the user may use the admin api; when no AdminUsers are configured, every user may use it.
+func (c AuthenticationConfig) IsAdmin(user string) bool {
+	if len(c.adminUsers) == 0 {
+		return true
+	}
+	_, ok := c.adminUsers[user]
+	return ok
+}
+
 // Getters for MqttClientConfig struct
 
 func (c MqttClientConfig) getTopicTemplateOldNewPairs(oldnew ...string) []string {
@@ -269,6 +332,15 @@ func (c MqttClientConfig) CommandTopic(deviceName, registerName string) string {
 	return r.Replace(c.command.topicTemplate)
 }
 
+func (c MqttClientConfig) Alarms() MqttSectionConfig {
+	return c.alarms
+}
+
+func (c MqttClientConfig) AlarmsTopic(alarmName string) string {
+	r := strings.NewReplacer(c.getTopicTemplateOldNewPairs("%AlarmName%", alarmName)...)
+	return r.Replace(c.alarms.topicTemplate)
+}
+
 func (c MqttClientConfig) LogDebug() bool {
 	return c.logDebug
 }
@@ -329,6 +401,10 @@ func (c ModbusConfig) Name() string {
 	return c.name
 }
 
+func (c ModbusConfig) Kind() types.ModbusBusKind {
+	return c.kind
+}
+
 func (c ModbusConfig) Device() string {
 	return c.device
 }
@@ -337,10 +413,19 @@ func (c ModbusConfig) BaudRate() int {
 	return c.baudRate
 }
 
+func (c ModbusConfig) Url() *url.URL {
+	return c.url
+}
+
 func (c ModbusConfig) ReadTimeout() time.Duration {
 	return c.readTimeout
 }
 
+// InterFrameDelay is the minimal silence on the bus between the end of a response and the next request.
+func (c ModbusConfig) InterFrameDelay() time.Duration {
+	return c.interFrameDelay
+}
+
 func (c ModbusConfig) LogDebug() bool {
 	return c.logDebug
 }
@@ -363,6 +448,37 @@ func (c DeviceConfig) RestartIntervalMaxBackoff() time.Duration {
 	return c.restartIntervalMaxBackoff
 }
 
+// MaxAge returns the duration after which a value of the given register is considered stale; zero means never.
+func (c DeviceConfig) MaxAge(registerName string) time.Duration {
+	if v, ok := c.registerMaxAge[registerName]; ok {
+		return v
+	}
+	return c.maxAge
+}
+
+// RegisterMeta returns the configured metadata overrides by register name.
+func (c DeviceConfig) RegisterMeta() map[string]RegisterMetaConfig {
+	return c.registerMeta
+}
+
+// RegisterTransform returns the configured transforms by register name.
+func (c DeviceConfig) RegisterTransform() map[string]RegisterTransformConfig {
+	return c.registerTransform
+}
+
+// Deadband returns the deadband configured for the register or, if there is none, for its category.
+func (c DeviceConfig) Deadband(registerName, category string) (DeadbandConfig, bool) {
+	if d, ok := c.registerDeadband[registerName]; ok {
+		return d, true
+	}
+	d, ok := c.categoryDeadband[category]
+	return d, ok
+}
+
+func (c DeviceConfig) History() HistoryConfig {
+	return c.history
+}
+
 func (c DeviceConfig) LogDebug() bool {
 	return c.logDebug
 }
@@ -371,6 +487,76 @@ func (c DeviceConfig) LogComDebug() bool {
 	return c.logComDebug
 }
 
+// Getters for RegisterMetaConfig struct
+
+func (c RegisterMetaConfig) Min() *float64 {
+	return c.min
+}
+
+func (c RegisterMetaConfig) Max() *float64 {
+	return c.max
+}
+
+func (c RegisterMetaConfig) Step() *float64 {
+	return c.step
+}
+
+func (c RegisterMetaConfig) Precision() *int {
+	return c.precision
+}
+
+func (c RegisterMetaConfig) DeviceClass() string {
+	return c.deviceClass
+}
+
+func (c RegisterMetaConfig) StateClass() string {
+	return c.stateClass
+}
+
+// Getters for RegisterTransformConfig struct
+
+func (c RegisterTransformConfig) Scale() *float64 {
+	return c.scale
+}
+
+func (c RegisterTransformConfig) Offset() *float64 {
+	return c.offset
+}
+
+func (c RegisterTransformConfig) Unit() string {
+	return c.unit
+}
+
+func (c RegisterTransformConfig) Round() *int {
+	return c.round
+}
+
+func (c RegisterTransformConfig) Description() string {
+	return c.description
+}
+
+func (c RegisterTransformConfig) Category() string {
+	return c.category
+}
+
+// Getters for DeadbandConfig struct
+
+func (c DeadbandConfig) Absolute() float64 {
+	return c.absolute
+}
+
+func (c DeadbandConfig) Percent() float64 {
+	return c.percent
+}
+
+func (c DeadbandConfig) MinInterval() time.Duration {
+	return c.minInterval
+}
+
+func (c DeadbandConfig) MaxInterval() time.Duration {
+	return c.maxInterval
+}
+
 // Getters for VictronDeviceConfig struct
 
 func (c VictronDeviceConfig) Device() string {
@@ -424,10 +610,92 @@ func (c ModbusDeviceConfig) RelayClosedLabel(name string) string {
 	return "closed"
 }
 
+func (c ModbusDeviceConfig) Registers() []ModbusRegisterConfig {
+	return c.registers
+}
+
 func (c ModbusDeviceConfig) PollInterval() time.Duration {
 	return c.pollInterval
 }
 
+// MaxReadGap is the maximum number of unused registers read to fetch two registers by a single request.
+func (c ModbusDeviceConfig) MaxReadGap() int {
+	return c.maxReadGap
+}
+
+// ReadTimeout is how long to wait for a response of the device; 0 means the ReadTimeout of the bus is used.
+func (c ModbusDeviceConfig) ReadTimeout() time.Duration {
+	return c.readTimeout
+}
+
+// Retries is how many times a request is sent again after a timeout or a checksum mismatch.
+func (c ModbusDeviceConfig) Retries() int {
+	return c.retries
+}
+
+// Getters for ModbusRegisterConfig struct
+
+func (c ModbusRegisterConfig) Name() string {
+	return c.name
+}
+
+// Function is one of Coil, DiscreteInput, Holding or Input.
+func (c ModbusRegisterConfig) Function() string {
+	return c.function
+}
+
+func (c ModbusRegisterConfig) Address() uint16 {
+	return c.address
+}
+
+// Type is one of bool, u16, i16, u32, i32, float32, float64 or string.
+func (c ModbusRegisterConfig) Type() string {
+	return c.registerType
+}
+
+// Length is the number of 16 bit registers; it is 1 for coils and discrete inputs.
+func (c ModbusRegisterConfig) Length() int {
+	return c.length
+}
+
+// WordOrder is either BigEndian (high word first) or LittleEndian.
+func (c ModbusRegisterConfig) WordOrder() string {
+	return c.wordOrder
+}
+
+// ByteOrder is either BigEndian (high byte first) or LittleEndian.
+func (c ModbusRegisterConfig) ByteOrder() string {
+	return c.byteOrder
+}
+
+func (c ModbusRegisterConfig) Scale() float64 {
+	return c.scale
+}
+
+func (c ModbusRegisterConfig) Unit() string {
+	return c.unit
+}
+
+func (c ModbusRegisterConfig) Enum() map[int]string {
+	return c.enum
+}
+
+func (c ModbusRegisterConfig) Category() string {
+	return c.category
+}
+
+func (c ModbusRegisterConfig) Description() string {
+	return c.description
+}
+
+func (c ModbusRegisterConfig) Sort() int {
+	return c.sort
+}
+
+func (c ModbusRegisterConfig) Writable() bool {
+	return c.writable
+}
+
 // Getters for GpioDeviceConfig struct
 func (c GpioDeviceConfig) Chip() string {
 	return c.chip
@@ -507,6 +775,53 @@ func (c MqttDeviceConfig) Kind() types.MqttDeviceKind {
 	return c.kind
 }
 
+// Getters for SystemDeviceConfig struct
+
+func (c SystemDeviceConfig) PollInterval() time.Duration {
+	return c.pollInterval
+}
+
+func (c SystemDeviceConfig) DiskPath() string {
+	return c.diskPath
+}
+
+// Getters for ComputedDeviceConfig struct
+
+func (c ComputedDeviceConfig) Registers() []ComputedRegisterConfig {
+	return c.registers
+}
+
+// Getters for ComputedRegisterConfig struct
+
+func (c ComputedRegisterConfig) Name() string {
+	return c.name
+}
+
+func (c ComputedRegisterConfig) Expression() string {
+	return c.expression
+}
+
+// Type returns number or bool.
+func (c ComputedRegisterConfig) Type() string {
+	return c.registerType
+}
+
+func (c ComputedRegisterConfig) Category() string {
+	return c.category
+}
+
+func (c ComputedRegisterConfig) Description() string {
+	return c.description
+}
+
+func (c ComputedRegisterConfig) Unit() string {
+	return c.unit
+}
+
+func (c ComputedRegisterConfig) Sort() int {
+	return c.sort
+}
+
 // Getter for GensetDeviceConfig struct
 
 func (c GensetDeviceConfig) InputBindings() []GensetDeviceBindingConfig {
@@ -672,6 +987,501 @@ func (c ViewDeviceConfig) Filter() FilterConfig {
 	return c.filter
 }
 
+// Getters for ArchiveConfig struct
+
+func (c ArchiveConfig) Enabled() bool {
+	return c.enabled
+}
+
+func (c ArchiveConfig) Path() string {
+	return c.path
+}
+
+func (c ArchiveConfig) FlushInterval() time.Duration {
+	return c.flushInterval
+}
+
+func (c ArchiveConfig) RawRetention() time.Duration {
+	return c.rawRetention
+}
+
+func (c ArchiveConfig) MinuteRetention() time.Duration {
+	return c.minuteRetention
+}
+
+func (c ArchiveConfig) HourRetention() time.Duration {
+	return c.hourRetention
+}
+
+func (c ArchiveConfig) Devices() []DeviceFilterConfig {
+	return c.devices
+}
+
+func (c ArchiveConfig) LogDebug() bool {
+	return c.logDebug
+}
+
+// Getters for InfluxDbOutputConfig struct
+
+func (c InfluxDbOutputConfig) Name() string {
+	return c.name
+}
+
+func (c InfluxDbOutputConfig) Url() *url.URL {
+	return c.url
+}
+
+func (c InfluxDbOutputConfig) Version() int {
+	return c.version
+}
+
+func (c InfluxDbOutputConfig) Database() string {
+	return c.database
+}
+
+func (c InfluxDbOutputConfig) User() string {
+	return c.user
+}
+
+func (c InfluxDbOutputConfig) Password() string {
+	return c.password
+}
+
+func (c InfluxDbOutputConfig) Org() string {
+	return c.org
+}
+
+func (c InfluxDbOutputConfig) Bucket() string {
+	return c.bucket
+}
+
+func (c InfluxDbOutputConfig) Token() string {
+	return c.token
+}
+
+func (c InfluxDbOutputConfig) BatchSize() int {
+	return c.batchSize
+}
+
+func (c InfluxDbOutputConfig) FlushInterval() time.Duration {
+	return c.flushInterval
+}
+
+func (c InfluxDbOutputConfig) Timeout() time.Duration {
+	return c.timeout
+}
+
+func (c InfluxDbOutputConfig) BufferPath() string {
+	return c.bufferPath
+}
+
+func (c InfluxDbOutputConfig) MaxBufferSize() int64 {
+	return c.maxBufferSize
+}
+
+func (c InfluxDbOutputConfig) Devices() []DeviceFilterConfig {
+	return c.devices
+}
+
+func (c InfluxDbOutputConfig) LogDebug() bool {
+	return c.logDebug
+}
+
+// Getters for ModbusServerConfig struct
+
+func (c ModbusServerConfig) Name() string {
+	return c.name
+}
+
+func (c ModbusServerConfig) Kind() types.ModbusServerKind {
+	return c.kind
+}
+
+func (c ModbusServerConfig) Bind() string {
+	return c.bind
+}
+
+func (c ModbusServerConfig) Port() int {
+	return c.port
+}
+
+func (c ModbusServerConfig) Device() string {
+	return c.device
+}
+
+func (c ModbusServerConfig) BaudRate() int {
+	return c.baudRate
+}
+
+// UnitId is the slave address the server responds to.
+func (c ModbusServerConfig) UnitId() byte {
+	return c.unitId
+}
+
+func (c ModbusServerConfig) Registers() []ModbusServerRegisterConfig {
+	return c.registers
+}
+
+func (c ModbusServerConfig) LogDebug() bool {
+	return c.logDebug
+}
+
+// Getters for ModbusServerRegisterConfig struct
+
+// Function is one of Coil, DiscreteInput, Holding or Input.
+func (c ModbusServerRegisterConfig) Function() string {
+	return c.function
+}
+
+func (c ModbusServerRegisterConfig) Address() uint16 {
+	return c.address
+}
+
+func (c ModbusServerRegisterConfig) Device() string {
+	return c.device
+}
+
+func (c ModbusServerRegisterConfig) Register() string {
+	return c.register
+}
+
+// Type is one of bool, u16, i16, u32, i32, float32 or float64.
+func (c ModbusServerRegisterConfig) Type() string {
+	return c.registerType
+}
+
+// Length is the number of 16 bit registers used.
+func (c ModbusServerRegisterConfig) Length() int {
+	return c.length
+}
+
+func (c ModbusServerRegisterConfig) WordOrder() string {
+	return c.wordOrder
+}
+
+func (c ModbusServerRegisterConfig) ByteOrder() string {
+	return c.byteOrder
+}
+
+// Scale is multiplied with the raw register content to get the value; value = raw * Scale.
+func (c ModbusServerRegisterConfig) Scale() float64 {
+	return c.scale
+}
+
+// Getters for SnapshotConfig struct
+
+func (c SnapshotConfig) Enabled() bool {
+	return c.enabled
+}
+
+func (c SnapshotConfig) Path() string {
+	return c.path
+}
+
+func (c SnapshotConfig) Interval() time.Duration {
+	return c.interval
+}
+
+func (c SnapshotConfig) DefaultPolicy() types.RestorePolicy {
+	return c.defaultPolicy
+}
+
+func (c SnapshotConfig) Devices() []SnapshotDeviceConfig {
+	return c.devices
+}
+
+// Policy returns the restore policy of the given register; RegisterPolicy overrides the device Policy
+// which overrides the DefaultPolicy.
+func (c SnapshotConfig) Policy(deviceName, registerName string) types.RestorePolicy {
+	for _, d := range c.devices {
+		if d.name == deviceName {
+			if p, ok := d.registerPolicy[registerName]; ok {
+				return p
+			}
+			return d.policy
+		}
+	}
+	return c.defaultPolicy
+}
+
+func (c SnapshotConfig) LogDebug() bool {
+	return c.logDebug
+}
+
+// Getters for SnapshotDeviceConfig struct
+
+func (c SnapshotDeviceConfig) Name() string {
+	return c.name
+}
+
+func (c SnapshotDeviceConfig) Policy() types.RestorePolicy {
+	return c.policy
+}
+
+func (c SnapshotDeviceConfig) RegisterPolicy() map[string]types.RestorePolicy {
+	return c.registerPolicy
+}
+
+// Getters for RuleConfig struct
+
+func (c RuleConfig) Name() string {
+	return c.name
+}
+
+func (c RuleConfig) Triggers() []RuleTriggerConfig {
+	return c.triggers
+}
+
+func (c RuleConfig) Condition() string {
+	return c.condition
+}
+
+func (c RuleConfig) Actions() []RuleActionConfig {
+	return c.actions
+}
+
+func (c RuleConfig) LogDebug() bool {
+	return c.logDebug
+}
+
+// Getters for RuleTriggerConfig struct
+
+func (c RuleTriggerConfig) Kind() types.RuleTriggerKind {
+	return c.kind
+}
+
+func (c RuleTriggerConfig) Device() string {
+	return c.device
+}
+
+func (c RuleTriggerConfig) Register() string {
+	return c.register
+}
+
+func (c RuleTriggerConfig) Above() *float64 {
+	return c.above
+}
+
+func (c RuleTriggerConfig) Below() *float64 {
+	return c.below
+}
+
+func (c RuleTriggerConfig) Hysteresis() float64 {
+	return c.hysteresis
+}
+
+func (c RuleTriggerConfig) Hold() time.Duration {
+	return c.hold
+}
+
+func (c RuleTriggerConfig) Available() *bool {
+	return c.available
+}
+
+func (c RuleTriggerConfig) Schedule() string {
+	return c.schedule
+}
+
+// Getters for RuleActionConfig struct
+
+func (c RuleActionConfig) Kind() types.RuleActionKind {
+	return c.kind
+}
+
+func (c RuleActionConfig) Device() string {
+	return c.device
+}
+
+func (c RuleActionConfig) Register() string {
+	return c.register
+}
+
+func (c RuleActionConfig) Value() string {
+	return c.value
+}
+
+func (c RuleActionConfig) MqttClient() string {
+	return c.mqttClient
+}
+
+func (c RuleActionConfig) Topic() string {
+	return c.topic
+}
+
+func (c RuleActionConfig) Payload() string {
+	return c.payload
+}
+
+func (c RuleActionConfig) Qos() byte {
+	return c.qos
+}
+
+func (c RuleActionConfig) Retain() bool {
+	return c.retain
+}
+
+func (c RuleActionConfig) Url() *url.URL {
+	return c.url
+}
+
+func (c RuleActionConfig) Method() string {
+	return c.method
+}
+
+func (c RuleActionConfig) Timeout() time.Duration {
+	return c.timeout
+}
+
+// Getters for AlarmsConfig struct
+
+func (c AlarmsConfig) Enabled() bool {
+	return c.enabled
+}
+
+func (c AlarmsConfig) HistorySize() int {
+	return c.historySize
+}
+
+func (c AlarmsConfig) Definitions() []AlarmDefinitionConfig {
+	return c.definitions
+}
+
+func (c AlarmsConfig) Notifiers() []AlarmNotifierConfig {
+	return c.notifiers
+}
+
+func (c AlarmsConfig) LogDebug() bool {
+	return c.logDebug
+}
+
+// Getters for AlarmDefinitionConfig struct
+
+func (c AlarmDefinitionConfig) Name() string {
+	return c.name
+}
+
+func (c AlarmDefinitionConfig) Kind() types.AlarmKind {
+	return c.kind
+}
+
+func (c AlarmDefinitionConfig) Device() string {
+	return c.device
+}
+
+func (c AlarmDefinitionConfig) Register() string {
+	return c.register
+}
+
+func (c AlarmDefinitionConfig) Limit() float64 {
+	return c.limit
+}
+
+func (c AlarmDefinitionConfig) Hysteresis() float64 {
+	return c.hysteresis
+}
+
+func (c AlarmDefinitionConfig) Interval() time.Duration {
+	return c.interval
+}
+
+func (c AlarmDefinitionConfig) Delay() time.Duration {
+	return c.delay
+}
+
+func (c AlarmDefinitionConfig) Severity() types.AlarmSeverity {
+	return c.severity
+}
+
+func (c AlarmDefinitionConfig) Message() string {
+	return c.message
+}
+
+func (c AlarmDefinitionConfig) Notifiers() []string {
+	return c.notifiers
+}
+
+// Getters for AlarmNotifierConfig struct
+
+func (c AlarmNotifierConfig) Name() string {
+	return c.name
+}
+
+func (c AlarmNotifierConfig) Kind() types.AlarmNotifierKind {
+	return c.kind
+}
+
+func (c AlarmNotifierConfig) Url() *url.URL {
+	return c.url
+}
+
+func (c AlarmNotifierConfig) Method() string {
+	return c.method
+}
+
+func (c AlarmNotifierConfig) Token() string {
+	return c.token
+}
+
+func (c AlarmNotifierConfig) Host() string {
+	return c.host
+}
+
+func (c AlarmNotifierConfig) Port() int {
+	return c.port
+}
+
+func (c AlarmNotifierConfig) User() string {
+	return c.user
+}
+
+func (c AlarmNotifierConfig) Password() string {
+	return c.password
+}
+
+func (c AlarmNotifierConfig) From() string {
+	return c.from
+}
+
+func (c AlarmNotifierConfig) To() []string {
+	return c.to
+}
+
+func (c AlarmNotifierConfig) Timeout() time.Duration {
+	return c.timeout
+}
+
+// Getters for DeviceFilterConfig struct
+
+func (c DeviceFilterConfig) Name() string {
+	return c.name
+}
+
+func (c DeviceFilterConfig) Filter() FilterConfig {
+	return c.filter
+}
+
+// Getters for HistoryConfig struct
+
+func (c HistoryConfig) Enabled() bool {
+	return c.enabled
+}
+
+func (c HistoryConfig) MaxAge() time.Duration {
+	return c.maxAge
+}
+
+func (c HistoryConfig) MaxSamples() int {
+	return c.maxSamples
+}
+
+func (c HistoryConfig) Resolution() time.Duration {
+	return c.resolution
+}
+
+func (c HistoryConfig) Filter() FilterConfig {
+	return c.filter
+}
+
 // Getters for FilterConfig struct
 
 func (c FilterConfig) IncludeRegisters() []string {
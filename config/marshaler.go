@@ -2,7 +2,10 @@ package config
 
 import (
 	"fmt"
+	"github.com/koestler/go-iotdevice/v3/types"
 	"golang.org/x/exp/maps"
+	"slices"
+	"time"
 )
 
 func (c Config) MarshalYAML() (interface{}, error) {
@@ -22,8 +25,16 @@ func (c Config) MarshalYAML() (interface{}, error) {
 		GpioDevices:            convertMapToRead[GpioDeviceConfig, gpioDeviceConfigRead](c.gpioDevices),
 		HttpDevices:            convertMapToRead[HttpDeviceConfig, httpDeviceConfigRead](c.httpDevices),
 		MqttDevices:            convertMapToRead[MqttDeviceConfig, mqttDeviceConfigRead](c.mqttDevices),
+		SystemDevices:          convertMapToRead[SystemDeviceConfig, systemDeviceConfigRead](c.systemDevices),
+		ComputedDevices:        convertMapToRead[ComputedDeviceConfig, computedDeviceConfigRead](c.computedDevices),
 		GensetDevices:          convertMapToRead[GensetDeviceConfig, gensetDeviceConfigRead](c.gensetDevices),
 		Views:                  convertListToRead[ViewConfig, viewConfigRead](c.views),
+		Archive:                convertEnableableToRead[ArchiveConfig, archiveConfigRead](c.archive),
+		InfluxDbOutputs:        convertMapToRead[InfluxDbOutputConfig, influxDbOutputConfigRead](c.influxDbOutputs),
+		ModbusServers:          convertMapToRead[ModbusServerConfig, modbusServerConfigRead](c.modbusServers),
+		Snapshot:               convertEnableableToRead[SnapshotConfig, snapshotConfigRead](c.snapshot),
+		Rules:                  convertMapToRead[RuleConfig, ruleConfigRead](c.rules),
+		Alarms:                 convertEnableableToRead[AlarmsConfig, alarmsConfigRead](c.alarms),
 	}, nil
 }
 
@@ -57,6 +68,23 @@ func convertMapToRead[I mappable[O], O any](inp []I) (oup map[string]O) {
 	return
 }
 
+// secretToRead returns the file instead of the secret when it was read from a file, such that it is not printed.
+// The secret read from the file is returned as fromFile; it is kept in an unexported field of the read struct,
+// which is not marshaled but compared by Diff, such that a changed content of the file is detected on a reload.
+func secretToRead(value, file string) (v, f, fromFile string) {
+	if len(file) > 0 {
+		return "", file, value
+	}
+	return value, "", ""
+}
+
+// sortedKeys returns the keys of a set in a stable order such that marshaled configs can be compared.
+func sortedKeys(inp map[string]struct{}) []string {
+	keys := maps.Keys(inp)
+	slices.Sort(keys)
+	return keys
+}
+
 func convertListToRead[I convertable[O], O any](inp []I) (oup []O) {
 	oup = make([]O, len(inp))
 	i := 0
@@ -82,29 +110,53 @@ func (c HttpServerConfig) convertToRead() httpServerConfigRead {
 		FrontendPath:    c.frontendPath,
 		FrontendExpires: c.frontendExpires.String(),
 		ConfigExpires:   c.configExpires.String(),
+		Metrics:         c.metrics.convertToRead(),
 		LogDebug:        &c.logDebug,
 	}
 }
 
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c MetricsConfig) convertToRead() *metricsConfigRead {
+	if !c.enabled {
+		return nil
+	}
+	rf := c.filter.convertToRead()
+	return &metricsConfigRead{
+		View:          c.view,
+		Filter:        &rf,
+		Authenticated: &c.authenticated,
+	}
+}
+
 //lint:ignore U1000 linter does not catch that this is used generic code
 func (c AuthenticationConfig) convertToRead() authenticationConfigRead {
-	jwtSecret := string(c.jwtSecret)
+	jwtSecret, jwtSecretFile, jwtSecretFromFile := secretToRead(string(c.jwtSecret), c.jwtSecretFile)
+	var jwtSecretPtr *string
+	if len(jwtSecretFile) < 1 {
+		jwtSecretPtr = &jwtSecret
+	}
 	return authenticationConfigRead{
-		JwtSecret:         &jwtSecret,
+		JwtSecret:         jwtSecretPtr,
+		JwtSecretFile:     jwtSecretFile,
 		JwtValidityPeriod: c.jwtValidityPeriod.String(),
 		HtaccessFile:      &c.htaccessFile,
+		AdminUsers:        sortedKeys(c.adminUsers),
+
+		jwtSecretFromFile: jwtSecretFromFile,
 	}
 }
 
 //lint:ignore U1000 linter does not catch that this is used generic code
 func (c MqttClientConfig) convertToRead() mqttClientConfigRead {
+	password, passwordFile, passwordFromFile := secretToRead(c.password, c.passwordFile)
 	return mqttClientConfigRead{
 		Broker:          c.broker.String(),
 		ProtocolVersion: &c.protocolVersion,
 
-		User:     c.user,
-		Password: c.password,
-		ClientId: &c.clientId,
+		User:         c.user,
+		Password:     password,
+		PasswordFile: passwordFile,
+		ClientId:     &c.clientId,
 
 		KeepAlive:         c.keepAlive.String(),
 		ConnectRetryDelay: c.connectRetryDelay.String(),
@@ -122,9 +174,12 @@ func (c MqttClientConfig) convertToRead() mqttClientConfigRead {
 		Realtime:               c.realtime.convertToRead(),
 		HomeassistantDiscovery: c.homeassistantDiscovery.convertToRead(),
 		Command:                c.command.convertToRead(),
+		Alarms:                 c.alarms.convertToRead(),
 
 		LogDebug:    &c.logDebug,
 		LogMessages: &c.logMessages,
+
+		passwordFromFile: passwordFromFile,
 	}
 }
 
@@ -157,12 +212,18 @@ func (c MqttDeviceSectionConfig) convertToRead() mqttDeviceSectionConfigRead {
 
 //lint:ignore U1000 linter does not catch that this is used generic code
 func (c ModbusConfig) convertToRead() modbusConfigRead {
-	return modbusConfigRead{
-		Device:      c.device,
-		BaudRate:    c.baudRate,
-		ReadTimeout: c.readTimeout.String(),
-		LogDebug:    &c.logDebug,
+	ret := modbusConfigRead{
+		Kind:            c.kind.String(),
+		Device:          c.device,
+		BaudRate:        c.baudRate,
+		ReadTimeout:     c.readTimeout.String(),
+		InterFrameDelay: c.interFrameDelay.String(),
+		LogDebug:        &c.logDebug,
 	}
+	if c.url != nil {
+		ret.Url = c.url.String()
+	}
+	return ret
 }
 
 //lint:ignore U1000 linter does not catch that this is used generic code
@@ -171,8 +232,72 @@ func (c DeviceConfig) convertToRead() deviceConfigRead {
 		Filter:                    c.filter.convertToRead(),
 		RestartInterval:           c.restartInterval.String(),
 		RestartIntervalMaxBackoff: c.restartIntervalMaxBackoff.String(),
-		LogDebug:                  &c.logDebug,
-		LogComDebug:               &c.logComDebug,
+		MaxAge:                    c.maxAge.String(),
+		RegisterMaxAge: func(inp map[string]time.Duration) (oup map[string]string) {
+			oup = make(map[string]string, len(inp))
+			for k, v := range inp {
+				oup[k] = v.String()
+			}
+			return
+		}(c.registerMaxAge),
+		RegisterMeta: func(inp map[string]RegisterMetaConfig) (oup map[string]registerMetaConfigRead) {
+			oup = make(map[string]registerMetaConfigRead, len(inp))
+			for k, v := range inp {
+				oup[k] = v.convertToRead()
+			}
+			return
+		}(c.registerMeta),
+		RegisterTransform: func(inp map[string]RegisterTransformConfig) (oup map[string]registerTransformConfigRead) {
+			oup = make(map[string]registerTransformConfigRead, len(inp))
+			for k, v := range inp {
+				oup[k] = v.convertToRead()
+			}
+			return
+		}(c.registerTransform),
+		RegisterDeadband: convertDeadbandMapToRead(c.registerDeadband),
+		CategoryDeadband: convertDeadbandMapToRead(c.categoryDeadband),
+		History:          c.history.convertToRead(),
+		LogDebug:         &c.logDebug,
+		LogComDebug:      &c.logComDebug,
+	}
+}
+
+func (c RegisterMetaConfig) convertToRead() registerMetaConfigRead {
+	return registerMetaConfigRead{
+		Min:         c.min,
+		Max:         c.max,
+		Step:        c.step,
+		Precision:   c.precision,
+		DeviceClass: c.deviceClass,
+		StateClass:  c.stateClass,
+	}
+}
+
+func (c RegisterTransformConfig) convertToRead() registerTransformConfigRead {
+	return registerTransformConfigRead{
+		Scale:       c.scale,
+		Offset:      c.offset,
+		Unit:        c.unit,
+		Round:       c.round,
+		Description: c.description,
+		Category:    c.category,
+	}
+}
+
+func convertDeadbandMapToRead(inp map[string]DeadbandConfig) (oup map[string]deadbandConfigRead) {
+	oup = make(map[string]deadbandConfigRead, len(inp))
+	for k, v := range inp {
+		oup[k] = v.convertToRead()
+	}
+	return
+}
+
+func (c DeadbandConfig) convertToRead() deadbandConfigRead {
+	return deadbandConfigRead{
+		Absolute:    &c.absolute,
+		Percent:     &c.percent,
+		MinInterval: c.minInterval.String(),
+		MaxInterval: c.maxInterval.String(),
 	}
 }
 
@@ -201,7 +326,36 @@ func (c ModbusDeviceConfig) convertToRead() modbusDeviceConfigRead {
 			}
 			return oup
 		}(c.relays),
+		Registers:    convertMapToRead[ModbusRegisterConfig, modbusRegisterConfigRead](c.registers),
 		PollInterval: c.pollInterval.String(),
+		MaxReadGap:   &c.maxReadGap,
+		ReadTimeout: func() string {
+			if c.readTimeout > 0 {
+				return c.readTimeout.String()
+			}
+			return ""
+		}(),
+		Retries: &c.retries,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c ModbusRegisterConfig) convertToRead() modbusRegisterConfigRead {
+	address := int(c.address)
+	return modbusRegisterConfigRead{
+		Function:    c.function,
+		Address:     &address,
+		Type:        c.registerType,
+		Length:      &c.length,
+		WordOrder:   c.wordOrder,
+		ByteOrder:   c.byteOrder,
+		Scale:       &c.scale,
+		Unit:        c.unit,
+		Enum:        c.enum,
+		Category:    c.category,
+		Description: c.description,
+		Sort:        &c.sort,
+		Writable:    &c.writable,
 	}
 }
 
@@ -239,13 +393,17 @@ func (c PinConfig) convertToRead() pinConfigRead {
 
 //lint:ignore U1000 linter does not catch that this is used generic code
 func (c HttpDeviceConfig) convertToRead() httpDeviceConfigRead {
+	password, passwordFile, passwordFromFile := secretToRead(c.password, c.passwordFile)
 	return httpDeviceConfigRead{
 		deviceConfigRead: c.DeviceConfig.convertToRead(),
 		Url:              c.url.String(),
 		Kind:             c.kind.String(),
 		Username:         c.username,
-		Password:         c.password,
+		Password:         password,
+		PasswordFile:     passwordFile,
 		PollInterval:     c.pollInterval.String(),
+
+		passwordFromFile: passwordFromFile,
 	}
 }
 
@@ -257,6 +415,35 @@ func (c MqttDeviceConfig) convertToRead() mqttDeviceConfigRead {
 	}
 }
 
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c SystemDeviceConfig) convertToRead() systemDeviceConfigRead {
+	return systemDeviceConfigRead{
+		deviceConfigRead: c.DeviceConfig.convertToRead(),
+		PollInterval:     c.pollInterval.String(),
+		DiskPath:         c.diskPath,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c ComputedDeviceConfig) convertToRead() computedDeviceConfigRead {
+	return computedDeviceConfigRead{
+		deviceConfigRead: c.DeviceConfig.convertToRead(),
+		Registers:        convertMapToRead[ComputedRegisterConfig, computedRegisterConfigRead](c.registers),
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c ComputedRegisterConfig) convertToRead() computedRegisterConfigRead {
+	return computedRegisterConfigRead{
+		Expression:  c.expression,
+		Type:        c.registerType,
+		Category:    c.category,
+		Description: c.description,
+		Unit:        c.unit,
+		Sort:        &c.sort,
+	}
+}
+
 //lint:ignore U1000 linter does not catch that this is used generic code
 func (c GensetDeviceConfig) convertToRead() gensetDeviceConfigRead {
 	return gensetDeviceConfigRead{
@@ -310,7 +497,7 @@ func (c ViewConfig) convertToRead() viewConfigRead {
 		Title:        c.title,
 		Devices:      convertListToRead[ViewDeviceConfig, viewDeviceConfigRead](c.devices),
 		Autoplay:     &c.autoplay,
-		AllowedUsers: maps.Keys(c.allowedUsers),
+		AllowedUsers: sortedKeys(c.allowedUsers),
 		Hidden:       &c.hidden,
 	}
 }
@@ -324,6 +511,243 @@ func (c ViewDeviceConfig) convertToRead() viewDeviceConfigRead {
 	}
 }
 
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c ArchiveConfig) convertToRead() archiveConfigRead {
+	return archiveConfigRead{
+		Path:            c.path,
+		FlushInterval:   c.flushInterval.String(),
+		RawRetention:    c.rawRetention.String(),
+		MinuteRetention: c.minuteRetention.String(),
+		HourRetention:   c.hourRetention.String(),
+		Devices:         convertMapToRead[DeviceFilterConfig, deviceFilterConfigRead](c.devices),
+		LogDebug:        &c.logDebug,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c InfluxDbOutputConfig) convertToRead() influxDbOutputConfigRead {
+	password, passwordFile, passwordFromFile := secretToRead(c.password, c.passwordFile)
+	token, tokenFile, tokenFromFile := secretToRead(c.token, c.tokenFile)
+	return influxDbOutputConfigRead{
+		Url:           c.url.String(),
+		Version:       &c.version,
+		Database:      c.database,
+		User:          c.user,
+		Password:      password,
+		PasswordFile:  passwordFile,
+		Org:           c.org,
+		Bucket:        c.bucket,
+		Token:         token,
+		TokenFile:     tokenFile,
+		BatchSize:     &c.batchSize,
+		FlushInterval: c.flushInterval.String(),
+		Timeout:       c.timeout.String(),
+		BufferPath:    c.bufferPath,
+		MaxBufferSize: &c.maxBufferSize,
+		Devices:       convertMapToRead[DeviceFilterConfig, deviceFilterConfigRead](c.devices),
+		LogDebug:      &c.logDebug,
+
+		passwordFromFile: passwordFromFile,
+		tokenFromFile:    tokenFromFile,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c ModbusServerConfig) convertToRead() modbusServerConfigRead {
+	unitId := int(c.unitId)
+	ret := modbusServerConfigRead{
+		Kind:      c.kind.String(),
+		Device:    c.device,
+		BaudRate:  c.baudRate,
+		UnitId:    &unitId,
+		Registers: convertListToRead[ModbusServerRegisterConfig, modbusServerRegisterConfigRead](c.registers),
+		LogDebug:  &c.logDebug,
+	}
+	if c.kind == types.ModbusServerTcpKind {
+		ret.Bind = c.bind
+		ret.Port = &c.port
+	}
+	return ret
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c ModbusServerRegisterConfig) convertToRead() modbusServerRegisterConfigRead {
+	address := int(c.address)
+	return modbusServerRegisterConfigRead{
+		Function:  c.function,
+		Address:   &address,
+		Device:    c.device,
+		Register:  c.register,
+		Type:      c.registerType,
+		WordOrder: c.wordOrder,
+		ByteOrder: c.byteOrder,
+		Scale:     &c.scale,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c SnapshotConfig) convertToRead() snapshotConfigRead {
+	return snapshotConfigRead{
+		Path:          c.path,
+		Interval:      c.interval.String(),
+		DefaultPolicy: c.defaultPolicy.String(),
+		Devices:       convertMapToRead[SnapshotDeviceConfig, snapshotDeviceConfigRead](c.devices),
+		LogDebug:      &c.logDebug,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c SnapshotDeviceConfig) convertToRead() snapshotDeviceConfigRead {
+	registerPolicy := make(map[string]string, len(c.registerPolicy))
+	for k, v := range c.registerPolicy {
+		registerPolicy[k] = v.String()
+	}
+	return snapshotDeviceConfigRead{
+		Policy:         c.policy.String(),
+		RegisterPolicy: registerPolicy,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c RuleConfig) convertToRead() ruleConfigRead {
+	return ruleConfigRead{
+		Triggers:  convertListToRead[RuleTriggerConfig, ruleTriggerConfigRead](c.triggers),
+		Condition: c.condition,
+		Actions:   convertListToRead[RuleActionConfig, ruleActionConfigRead](c.actions),
+		LogDebug:  &c.logDebug,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c RuleTriggerConfig) convertToRead() ruleTriggerConfigRead {
+	ret := ruleTriggerConfigRead{
+		Kind:      c.kind.String(),
+		Device:    c.device,
+		Register:  c.register,
+		Above:     c.above,
+		Below:     c.below,
+		Available: c.available,
+		Schedule:  c.schedule,
+	}
+	if c.kind == types.RuleTriggerThresholdKind {
+		ret.Hysteresis = &c.hysteresis
+	}
+	if c.hold > 0 {
+		ret.Hold = c.hold.String()
+	}
+	return ret
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c RuleActionConfig) convertToRead() ruleActionConfigRead {
+	ret := ruleActionConfigRead{
+		Kind:       c.kind.String(),
+		Device:     c.device,
+		Register:   c.register,
+		Value:      c.value,
+		MqttClient: c.mqttClient,
+		Topic:      c.topic,
+		Payload:    c.payload,
+		Method:     c.method,
+	}
+	switch c.kind {
+	case types.RuleActionMqttKind:
+		ret.Qos = &c.qos
+		ret.Retain = &c.retain
+	case types.RuleActionWebhookKind:
+		ret.Url = c.url.String()
+		ret.Timeout = c.timeout.String()
+	}
+	return ret
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c AlarmsConfig) convertToRead() alarmsConfigRead {
+	return alarmsConfigRead{
+		HistorySize: &c.historySize,
+		Definitions: convertMapToRead[AlarmDefinitionConfig, alarmDefinitionConfigRead](c.definitions),
+		Notifiers:   convertMapToRead[AlarmNotifierConfig, alarmNotifierConfigRead](c.notifiers),
+		LogDebug:    &c.logDebug,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c AlarmDefinitionConfig) convertToRead() alarmDefinitionConfigRead {
+	ret := alarmDefinitionConfigRead{
+		Kind:      c.kind.String(),
+		Device:    c.device,
+		Register:  c.register,
+		Severity:  c.severity.String(),
+		Message:   c.message,
+		Notifiers: c.notifiers,
+	}
+	switch c.kind {
+	case types.AlarmHighKind, types.AlarmLowKind:
+		ret.Limit = &c.limit
+		ret.Hysteresis = &c.hysteresis
+	case types.AlarmRateKind:
+		ret.Limit = &c.limit
+		ret.Hysteresis = &c.hysteresis
+		ret.Interval = c.interval.String()
+	default:
+		ret.Interval = c.interval.String()
+	}
+	if c.delay > 0 {
+		ret.Delay = c.delay.String()
+	}
+	return ret
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c AlarmNotifierConfig) convertToRead() alarmNotifierConfigRead {
+	token, tokenFile, tokenFromFile := secretToRead(c.token, c.tokenFile)
+	password, passwordFile, passwordFromFile := secretToRead(c.password, c.passwordFile)
+	ret := alarmNotifierConfigRead{
+		Kind:         c.kind.String(),
+		Method:       c.method,
+		Token:        token,
+		TokenFile:    tokenFile,
+		Host:         c.host,
+		User:         c.user,
+		Password:     password,
+		PasswordFile: passwordFile,
+		From:         c.from,
+		To:           c.to,
+		Timeout:      c.timeout.String(),
+
+		passwordFromFile: passwordFromFile,
+		tokenFromFile:    tokenFromFile,
+	}
+	if c.url != nil {
+		ret.Url = c.url.String()
+	}
+	if c.kind == types.AlarmNotifierSmtpKind {
+		ret.Port = &c.port
+	}
+	return ret
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c DeviceFilterConfig) convertToRead() deviceFilterConfigRead {
+	rf := c.filter.convertToRead()
+	return deviceFilterConfigRead{
+		Filter: &rf,
+	}
+}
+
+//lint:ignore U1000 linter does not catch that this is used generic code
+func (c HistoryConfig) convertToRead() *historyConfigRead {
+	if !c.enabled {
+		return nil
+	}
+	return &historyConfigRead{
+		MaxAge:     c.maxAge.String(),
+		MaxSamples: &c.maxSamples,
+		Resolution: c.resolution.String(),
+		Filter:     c.filter.convertToRead(),
+	}
+}
+
 //lint:ignore U1000 linter does not catch that this is used generic code
 func (c FilterConfig) convertToRead() filterConfigRead {
 	return filterConfigRead{
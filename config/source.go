@@ -0,0 +1,266 @@
+package config
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const includeTag = "!include"
+
+// position is the location of a node in one of the read files; file is empty for a config given as string.
+type position struct {
+	file string
+	line int
+}
+
+func (p position) String() string {
+	if len(p.file) < 1 {
+		return fmt.Sprintf("line %d", p.line)
+	}
+	return fmt.Sprintf("%s:%d", p.file, p.line)
+}
+
+// source is a yaml document whose includes are resolved and whose environment variables are substituted.
+type source struct {
+	root      *yaml.Node
+	files     map[*yaml.Node]string // the file every node was read from
+	positions map[string]position   // the position of every key, indexed by its path, e.g. MqttClients->local->Broker
+}
+
+// parseSource parses the yaml document read from file (empty if given as string) and all files included by it.
+func parseSource(yamlStr []byte, file string) (*source, error) {
+	s := &source{
+		files:     make(map[*yaml.Node]string),
+		positions: make(map[string]position),
+	}
+
+	root, err := s.parse(yamlStr, file, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.root = root
+	s.index(root, "")
+	return s, nil
+}
+
+func (s *source) parse(yamlStr []byte, file string, includedBy []string) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlStr, &doc); err != nil {
+		if len(file) > 0 {
+			return nil, fmt.Errorf("%s: cannot parse yaml: %s", file, err)
+		}
+		return nil, fmt.Errorf("cannot parse yaml: %s", err)
+	}
+	if len(doc.Content) < 1 {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil
+	}
+
+	root := doc.Content[0]
+	if err := s.resolve(root, file, append(includedBy, file)); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// resolve replaces all !include nodes by the content of the given file and substitutes environment variables.
+func (s *source) resolve(n *yaml.Node, file string, includedBy []string) error {
+	s.files[n] = file
+
+	if n.Kind == yaml.ScalarNode && n.Tag == includeTag {
+		pos := position{file, n.Line}
+		path := os.ExpandEnv(n.Value)
+		if !filepath.IsAbs(path) && len(file) > 0 {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		if slices.Contains(includedBy, path) {
+			return fmt.Errorf("%s: %s '%s' includes itself", pos, includeTag, n.Value)
+		}
+
+		yamlStr, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %s cannot read file: %s", pos, includeTag, err)
+		}
+		included, err := s.parse(yamlStr, path, includedBy)
+		if err != nil {
+			return err
+		}
+
+		*n = *included
+		s.files[n] = path
+		return nil
+	}
+
+	if n.Kind == yaml.ScalarNode {
+		if expanded := os.ExpandEnv(n.Value); expanded != n.Value {
+			n.Value = expanded
+			if n.Style == 0 {
+				// let the decoder resolve the type of the substituted value, e.g. a Port given as ${PORT}
+				n.Tag = ""
+			}
+		}
+	}
+
+	for _, c := range n.Content {
+		if err := s.resolve(c, file, includedBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// index stores the position of all keys by their path.
+func (s *source) index(n *yaml.Node, path string) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			p := key.Value
+			if len(path) > 0 {
+				p = path + "->" + key.Value
+			}
+			s.positions[p] = position{s.files[key], key.Line}
+
+			// the errors of the fields common to all devices are reported as Devices->name->...
+			if len(path) > 0 && !strings.Contains(path, "->") && strings.HasSuffix(path, "Devices") {
+				s.positions["Devices->"+key.Value] = position{s.files[key], key.Line}
+				s.index(value, "Devices->"+key.Value)
+			}
+
+			s.index(value, p)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			p := path + "->" + strconv.Itoa(i)
+			s.positions[p] = position{s.files[c], c.Line}
+			s.index(c, p)
+		}
+	case yaml.AliasNode:
+		if n.Alias != nil {
+			s.index(n.Alias, path)
+		}
+	}
+}
+
+// locate prefixes an error of TransformAndValidate with the position of the deepest key given in its message,
+// e.g. "ModbusDevices->relay0->Bus='bus1' ..." is prefixed by the position of the Bus key of the relay0 device.
+func (s *source) locate(err error) error {
+	var pos position
+	found := false
+
+	path := ""
+	for _, part := range strings.Split(err.Error(), "->") {
+		token := part
+		if i := strings.IndexAny(part, "=:' "); i >= 0 {
+			token = part[:i]
+		}
+		if len(token) < 1 {
+			break
+		}
+
+		// Triggers[0] is the index 0 of the list Triggers
+		for _, name := range strings.Split(strings.TrimSuffix(strings.ReplaceAll(token, "]", ""), "["), "[") {
+			if len(path) > 0 {
+				path += "->"
+			}
+			path += name
+			if p, ok := s.positions[path]; ok {
+				pos, found = p, true
+			}
+		}
+
+		if token != part {
+			break
+		}
+	}
+
+	if !found {
+		return err
+	}
+	return fmt.Errorf("%s: %w", pos, err)
+}
+
+// checkKnownFields returns an error for every key which does not exist in the struct it is decoded into.
+func (s *source) checkKnownFields(n *yaml.Node, t reflect.Type) (err []error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if n.Kind == yaml.AliasNode {
+		if n.Alias == nil {
+			return nil
+		}
+		n = n.Alias
+	}
+
+	switch {
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key, value := n.Content[i], n.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			ft, ok := fields[key.Value]
+			if !ok {
+				err = append(err, fmt.Errorf("%s: field %s not found in type %s",
+					position{s.files[key], key.Line}, key.Value, t,
+				))
+				continue
+			}
+			err = append(err, s.checkKnownFields(value, ft)...)
+		}
+	case n.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 1; i < len(n.Content); i += 2 {
+			err = append(err, s.checkKnownFields(n.Content[i], t.Elem())...)
+		}
+	case n.Kind == yaml.SequenceNode && t.Kind() == reflect.Slice:
+		for _, c := range n.Content {
+			err = append(err, s.checkKnownFields(c, t.Elem())...)
+		}
+	}
+	return
+}
+
+// yamlFields returns the types of all fields of a struct by their yaml key, including the ones of inlined structs.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	ret := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := strings.Split(f.Tag.Get("yaml"), ",")
+		if slices.Contains(tag[1:], "inline") {
+			for k, v := range yamlFields(f.Type) {
+				ret[k] = v
+			}
+			continue
+		}
+		if len(tag[0]) > 0 && tag[0] != "-" {
+			ret[tag[0]] = f.Type
+		}
+	}
+	return ret
+}
+
+// readSecret returns the value or, when file is set, the content of the file without its trailing newline.
+func readSecret(errPrefix, field, value, file string) (ret string, err error) {
+	if len(file) < 1 {
+		return value, nil
+	}
+	if len(value) > 0 {
+		return "", fmt.Errorf("%s->%s and %sFile must not both be set", errPrefix, field, field)
+	}
+
+	content, e := os.ReadFile(file)
+	if e != nil {
+		if errors.Is(e, os.ErrNotExist) {
+			return "", fmt.Errorf("%s->%sFile='%s' does not exist", errPrefix, field, file)
+		}
+		return "", fmt.Errorf("%s->%sFile='%s' cannot read file: %s", errPrefix, field, file, e)
+	}
+	return strings.TrimRight(string(content), "\r\n"), nil
+}
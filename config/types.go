@@ -27,8 +27,16 @@ type Config struct {
 	gpioDevices            []GpioDeviceConfig
 	httpDevices            []HttpDeviceConfig
 	mqttDevices            []MqttDeviceConfig
+	systemDevices          []SystemDeviceConfig
+	computedDevices        []ComputedDeviceConfig
 	gensetDevices          []GensetDeviceConfig
 	views                  []ViewConfig
+	archive                ArchiveConfig
+	influxDbOutputs        []InfluxDbOutputConfig
+	modbusServers          []ModbusServerConfig
+	snapshot               SnapshotConfig
+	rules                  []RuleConfig
+	alarms                 AlarmsConfig
 }
 
 type HttpServerConfig struct {
@@ -40,14 +48,25 @@ type HttpServerConfig struct {
 	frontendPath    string
 	frontendExpires time.Duration
 	configExpires   time.Duration
+	metrics         MetricsConfig
 	logDebug        bool
 }
 
+type MetricsConfig struct {
+	enabled       bool
+	view          string
+	filter        FilterConfig
+	authenticated bool
+}
+
 type AuthenticationConfig struct {
-	enabled           bool
-	jwtSecret         []byte
-	jwtValidityPeriod time.Duration
-	htaccessFile      string
+	enabled            bool
+	jwtSecret          []byte
+	jwtSecretGenerated bool
+	jwtSecretFile      string
+	jwtValidityPeriod  time.Duration
+	htaccessFile       string
+	adminUsers         map[string]struct{}
 }
 
 type MqttClientConfig struct {
@@ -55,9 +74,11 @@ type MqttClientConfig struct {
 	broker          *url.URL
 	protocolVersion int
 
-	user     string
-	password string
-	clientId string
+	user              string
+	password          string
+	passwordFile      string
+	clientId          string
+	clientIdGenerated bool
 
 	keepAlive         time.Duration
 	connectRetryDelay time.Duration
@@ -75,6 +96,7 @@ type MqttClientConfig struct {
 	realtime               MqttSectionConfig
 	homeassistantDiscovery MqttSectionConfig
 	command                MqttSectionConfig
+	alarms                 MqttSectionConfig
 
 	logDebug    bool
 	logMessages bool
@@ -100,11 +122,14 @@ type MqttDeviceSectionConfig struct {
 }
 
 type ModbusConfig struct {
-	name        string
-	device      string
-	baudRate    int
-	readTimeout time.Duration
-	logDebug    bool
+	name            string
+	kind            types.ModbusBusKind
+	device          string
+	baudRate        int
+	url             *url.URL
+	readTimeout     time.Duration
+	interFrameDelay time.Duration
+	logDebug        bool
 }
 
 type DeviceConfig struct {
@@ -112,10 +137,42 @@ type DeviceConfig struct {
 	filter                    FilterConfig
 	restartInterval           time.Duration
 	restartIntervalMaxBackoff time.Duration
+	maxAge                    time.Duration
+	registerMaxAge            map[string]time.Duration
+	registerMeta              map[string]RegisterMetaConfig
+	registerTransform         map[string]RegisterTransformConfig
+	registerDeadband          map[string]DeadbandConfig
+	categoryDeadband          map[string]DeadbandConfig
+	history                   HistoryConfig
 	logDebug                  bool
 	logComDebug               bool
 }
 
+type RegisterMetaConfig struct {
+	min         *float64
+	max         *float64
+	step        *float64
+	precision   *int
+	deviceClass string
+	stateClass  string
+}
+
+type RegisterTransformConfig struct {
+	scale       *float64
+	offset      *float64
+	unit        string
+	round       *int
+	description string
+	category    string
+}
+
+type DeadbandConfig struct {
+	absolute    float64
+	percent     float64
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
 type VictronDeviceConfig struct {
 	DeviceConfig
 	device       string
@@ -130,7 +187,28 @@ type ModbusDeviceConfig struct {
 	kind         types.ModbusDeviceKind
 	address      byte
 	relays       map[string]RelayConfig
+	registers    []ModbusRegisterConfig
 	pollInterval time.Duration
+	maxReadGap   int
+	readTimeout  time.Duration
+	retries      int
+}
+
+type ModbusRegisterConfig struct {
+	name         string
+	function     string
+	address      uint16
+	registerType string
+	length       int
+	wordOrder    string
+	byteOrder    string
+	scale        float64
+	unit         string
+	enum         map[int]string
+	category     string
+	description  string
+	sort         int
+	writable     bool
 }
 
 type RelayConfig struct {
@@ -163,6 +241,7 @@ type HttpDeviceConfig struct {
 	kind         types.HttpDeviceKind
 	username     string
 	password     string
+	passwordFile string
 	pollInterval time.Duration
 }
 
@@ -171,6 +250,27 @@ type MqttDeviceConfig struct {
 	kind types.MqttDeviceKind
 }
 
+type SystemDeviceConfig struct {
+	DeviceConfig
+	pollInterval time.Duration
+	diskPath     string
+}
+
+type ComputedDeviceConfig struct {
+	DeviceConfig
+	registers []ComputedRegisterConfig
+}
+
+type ComputedRegisterConfig struct {
+	name         string
+	expression   string
+	registerType string
+	category     string
+	description  string
+	unit         string
+	sort         int
+}
+
 type GensetDeviceConfig struct {
 	DeviceConfig
 
@@ -228,6 +328,164 @@ type ViewDeviceConfig struct {
 	filter FilterConfig
 }
 
+type ArchiveConfig struct {
+	enabled         bool
+	path            string
+	flushInterval   time.Duration
+	rawRetention    time.Duration
+	minuteRetention time.Duration
+	hourRetention   time.Duration
+	devices         []DeviceFilterConfig
+	logDebug        bool
+}
+
+type InfluxDbOutputConfig struct {
+	name          string
+	url           *url.URL
+	version       int
+	database      string
+	user          string
+	password      string
+	passwordFile  string
+	org           string
+	bucket        string
+	token         string
+	tokenFile     string
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	bufferPath    string
+	maxBufferSize int64
+	devices       []DeviceFilterConfig
+	logDebug      bool
+}
+
+type ModbusServerConfig struct {
+	name      string
+	kind      types.ModbusServerKind
+	bind      string
+	port      int
+	device    string
+	baudRate  int
+	unitId    byte
+	registers []ModbusServerRegisterConfig
+	logDebug  bool
+}
+
+type ModbusServerRegisterConfig struct {
+	function     string
+	address      uint16
+	device       string
+	register     string
+	registerType string
+	length       int
+	wordOrder    string
+	byteOrder    string
+	scale        float64
+}
+
+type SnapshotConfig struct {
+	enabled       bool
+	path          string
+	interval      time.Duration
+	defaultPolicy types.RestorePolicy
+	devices       []SnapshotDeviceConfig
+	logDebug      bool
+}
+
+type SnapshotDeviceConfig struct {
+	name           string
+	policy         types.RestorePolicy
+	registerPolicy map[string]types.RestorePolicy
+}
+
+type RuleConfig struct {
+	name      string
+	triggers  []RuleTriggerConfig
+	condition string
+	actions   []RuleActionConfig
+	logDebug  bool
+}
+
+type RuleTriggerConfig struct {
+	kind       types.RuleTriggerKind
+	device     string
+	register   string
+	above      *float64
+	below      *float64
+	hysteresis float64
+	hold       time.Duration
+	available  *bool
+	schedule   string
+}
+
+type RuleActionConfig struct {
+	kind       types.RuleActionKind
+	device     string
+	register   string
+	value      string
+	mqttClient string
+	topic      string
+	payload    string
+	qos        byte
+	retain     bool
+	url        *url.URL
+	method     string
+	timeout    time.Duration
+}
+
+type AlarmsConfig struct {
+	enabled     bool
+	historySize int
+	definitions []AlarmDefinitionConfig
+	notifiers   []AlarmNotifierConfig
+	logDebug    bool
+}
+
+type AlarmDefinitionConfig struct {
+	name       string
+	kind       types.AlarmKind
+	device     string
+	register   string
+	limit      float64
+	hysteresis float64
+	interval   time.Duration
+	delay      time.Duration
+	severity   types.AlarmSeverity
+	message    string
+	notifiers  []string
+}
+
+type AlarmNotifierConfig struct {
+	name         string
+	kind         types.AlarmNotifierKind
+	url          *url.URL
+	method       string
+	token        string
+	tokenFile    string
+	host         string
+	port         int
+	user         string
+	password     string
+	passwordFile string
+	from         string
+	to           []string
+	timeout      time.Duration
+}
+
+type DeviceFilterConfig struct {
+	name   string
+	filter FilterConfig
+}
+
+type HistoryConfig struct {
+	enabled    bool
+	maxAge     time.Duration
+	maxSamples int
+	resolution time.Duration
+	filter     FilterConfig
+}
+
 type FilterConfig struct {
 	includeRegisters  []string
 	skipRegisters     []string
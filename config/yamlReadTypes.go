@@ -1,49 +1,69 @@
 package config
 
 type configRead struct {
-	Version                *int                               `yaml:"Version"`
-	ProjectTitle           string                             `yaml:"ProjectTitle"`
-	LogConfig              *bool                              `yaml:"LogConfig"`
-	LogWorkerStart         *bool                              `yaml:"LogWorkerStart"`
-	LogStateStorageDebug   *bool                              `yaml:"LogStateStorageDebug"`
-	LogCommandStorageDebug *bool                              `yaml:"LogCommandStorageDebug"`
-	HttpServer             *httpServerConfigRead              `yaml:"HttpServer"`
-	Authentication         *authenticationConfigRead          `yaml:"Authentication"`
-	MqttClients            map[string]mqttClientConfigRead    `yaml:"MqttClients"`
-	Modbus                 map[string]modbusConfigRead        `yaml:"Modbus"`
-	VictronDevices         map[string]victronDeviceConfigRead `yaml:"VictronDevices"`
-	ModbusDevices          map[string]modbusDeviceConfigRead  `yaml:"ModbusDevices"`
-	GpioDevices            map[string]gpioDeviceConfigRead    `yaml:"GpioDevices"`
-	HttpDevices            map[string]httpDeviceConfigRead    `yaml:"HttpDevices"`
-	MqttDevices            map[string]mqttDeviceConfigRead    `yaml:"MqttDevices"`
-	GensetDevices          map[string]gensetDeviceConfigRead  `yaml:"GensetDevices"`
-	Views                  []viewConfigRead                   `yaml:"Views"`
+	Version                *int                                `yaml:"Version"`
+	ProjectTitle           string                              `yaml:"ProjectTitle"`
+	LogConfig              *bool                               `yaml:"LogConfig"`
+	LogWorkerStart         *bool                               `yaml:"LogWorkerStart"`
+	LogStateStorageDebug   *bool                               `yaml:"LogStateStorageDebug"`
+	LogCommandStorageDebug *bool                               `yaml:"LogCommandStorageDebug"`
+	HttpServer             *httpServerConfigRead               `yaml:"HttpServer"`
+	Authentication         *authenticationConfigRead           `yaml:"Authentication"`
+	MqttClients            map[string]mqttClientConfigRead     `yaml:"MqttClients"`
+	Modbus                 map[string]modbusConfigRead         `yaml:"Modbus"`
+	VictronDevices         map[string]victronDeviceConfigRead  `yaml:"VictronDevices"`
+	ModbusDevices          map[string]modbusDeviceConfigRead   `yaml:"ModbusDevices"`
+	GpioDevices            map[string]gpioDeviceConfigRead     `yaml:"GpioDevices"`
+	HttpDevices            map[string]httpDeviceConfigRead     `yaml:"HttpDevices"`
+	MqttDevices            map[string]mqttDeviceConfigRead     `yaml:"MqttDevices"`
+	SystemDevices          map[string]systemDeviceConfigRead   `yaml:"SystemDevices"`
+	ComputedDevices        map[string]computedDeviceConfigRead `yaml:"ComputedDevices"`
+	GensetDevices          map[string]gensetDeviceConfigRead   `yaml:"GensetDevices"`
+	Views                  []viewConfigRead                    `yaml:"Views"`
+	Archive                *archiveConfigRead                  `yaml:"Archive"`
+	InfluxDbOutputs        map[string]influxDbOutputConfigRead `yaml:"InfluxDbOutputs"`
+	ModbusServers          map[string]modbusServerConfigRead   `yaml:"ModbusServers"`
+	Snapshot               *snapshotConfigRead                 `yaml:"Snapshot"`
+	Rules                  map[string]ruleConfigRead           `yaml:"Rules"`
+	Alarms                 *alarmsConfigRead                   `yaml:"Alarms"`
 }
 
 type httpServerConfigRead struct {
-	Bind            string `yaml:"Bind"`
-	Port            *int   `yaml:"Port"`
-	LogRequests     *bool  `yaml:"LogRequests"`
-	FrontendProxy   string `yaml:"FrontendProxy"`
-	FrontendPath    string `yaml:"FrontendPath"`
-	FrontendExpires string `yaml:"FrontendExpires"`
-	ConfigExpires   string `yaml:"ConfigExpires"`
-	LogDebug        *bool  `yaml:"LogDebug"`
+	Bind            string             `yaml:"Bind"`
+	Port            *int               `yaml:"Port"`
+	LogRequests     *bool              `yaml:"LogRequests"`
+	FrontendProxy   string             `yaml:"FrontendProxy"`
+	FrontendPath    string             `yaml:"FrontendPath"`
+	FrontendExpires string             `yaml:"FrontendExpires"`
+	ConfigExpires   string             `yaml:"ConfigExpires"`
+	Metrics         *metricsConfigRead `yaml:"Metrics"`
+	LogDebug        *bool              `yaml:"LogDebug"`
+}
+
+type metricsConfigRead struct {
+	View          string            `yaml:"View"`
+	Filter        *filterConfigRead `yaml:"Filter"`
+	Authenticated *bool             `yaml:"Authenticated"`
 }
 
 type authenticationConfigRead struct {
-	JwtSecret         *string `yaml:"JwtSecret"`
-	JwtValidityPeriod string  `yaml:"JwtValidityPeriod"`
-	HtaccessFile      *string `yaml:"HtaccessFile"`
+	JwtSecret         *string  `yaml:"JwtSecret"`
+	JwtSecretFile     string   `yaml:"JwtSecretFile"`
+	JwtValidityPeriod string   `yaml:"JwtValidityPeriod"`
+	HtaccessFile      *string  `yaml:"HtaccessFile"`
+	AdminUsers        []string `yaml:"AdminUsers"`
+
+	jwtSecretFromFile string
 }
 
 type mqttClientConfigRead struct {
 	Broker          string `yaml:"Broker"`
 	ProtocolVersion *int   `yaml:"ProtocolVersion"`
 
-	User     string  `yaml:"User"`
-	Password string  `yaml:"Password"`
-	ClientId *string `yaml:"ClientId"`
+	User         string  `yaml:"User"`
+	Password     string  `yaml:"Password"`
+	PasswordFile string  `yaml:"PasswordFile"`
+	ClientId     *string `yaml:"ClientId"`
 
 	KeepAlive         string  `yaml:"KeepAlive"`
 	ConnectRetryDelay string  `yaml:"ConnectRetryDelay"`
@@ -61,9 +81,12 @@ type mqttClientConfigRead struct {
 	Realtime               mqttSectionConfigRead `yaml:"Realtime"`
 	HomeassistantDiscovery mqttSectionConfigRead `yaml:"HomeassistantDiscovery"`
 	Command                mqttSectionConfigRead `yaml:"Command"`
+	Alarms                 mqttSectionConfigRead `yaml:"Alarms"`
 
 	LogDebug    *bool `yaml:"LogDebug"`
 	LogMessages *bool `yaml:"LogMessages"`
+
+	passwordFromFile string
 }
 
 type mqttClientDeviceConfigRead struct {
@@ -84,18 +107,53 @@ type mqttDeviceSectionConfigRead struct {
 }
 
 type modbusConfigRead struct {
-	Device      string `yaml:"Device"`
-	BaudRate    int    `yaml:"BaudRate"`
-	ReadTimeout string `yaml:"ReadTimeout"`
-	LogDebug    *bool  `yaml:"LogDebug"`
+	Kind            string `yaml:"Kind"`
+	Device          string `yaml:"Device"`
+	BaudRate        int    `yaml:"BaudRate"`
+	Url             string `yaml:"Url"`
+	ReadTimeout     string `yaml:"ReadTimeout"`
+	InterFrameDelay string `yaml:"InterFrameDelay"`
+	LogDebug        *bool  `yaml:"LogDebug"`
 }
 
 type deviceConfigRead struct {
-	Filter                    filterConfigRead `yaml:"Filter"`
-	RestartInterval           string           `yaml:"RestartInterval"`
-	RestartIntervalMaxBackoff string           `yaml:"RestartIntervalMaxBackoff"`
-	LogDebug                  *bool            `yaml:"LogDebug"`
-	LogComDebug               *bool            `yaml:"LogComDebug"`
+	Filter                    filterConfigRead                       `yaml:"Filter"`
+	RestartInterval           string                                 `yaml:"RestartInterval"`
+	RestartIntervalMaxBackoff string                                 `yaml:"RestartIntervalMaxBackoff"`
+	MaxAge                    string                                 `yaml:"MaxAge"`
+	RegisterMaxAge            map[string]string                      `yaml:"RegisterMaxAge"`
+	RegisterMeta              map[string]registerMetaConfigRead      `yaml:"RegisterMeta"`
+	RegisterTransform         map[string]registerTransformConfigRead `yaml:"RegisterTransform"`
+	RegisterDeadband          map[string]deadbandConfigRead          `yaml:"RegisterDeadband"`
+	CategoryDeadband          map[string]deadbandConfigRead          `yaml:"CategoryDeadband"`
+	History                   *historyConfigRead                     `yaml:"History"`
+	LogDebug                  *bool                                  `yaml:"LogDebug"`
+	LogComDebug               *bool                                  `yaml:"LogComDebug"`
+}
+
+type registerMetaConfigRead struct {
+	Min         *float64 `yaml:"Min"`
+	Max         *float64 `yaml:"Max"`
+	Step        *float64 `yaml:"Step"`
+	Precision   *int     `yaml:"Precision"`
+	DeviceClass string   `yaml:"DeviceClass"`
+	StateClass  string   `yaml:"StateClass"`
+}
+
+type registerTransformConfigRead struct {
+	Scale       *float64 `yaml:"Scale"`
+	Offset      *float64 `yaml:"Offset"`
+	Unit        string   `yaml:"Unit"`
+	Round       *int     `yaml:"Round"`
+	Description string   `yaml:"Description"`
+	Category    string   `yaml:"Category"`
+}
+
+type deadbandConfigRead struct {
+	Absolute    *float64 `yaml:"Absolute"`
+	Percent     *float64 `yaml:"Percent"`
+	MinInterval string   `yaml:"MinInterval"`
+	MaxInterval string   `yaml:"MaxInterval"`
 }
 
 type victronDeviceConfigRead struct {
@@ -108,11 +166,31 @@ type victronDeviceConfigRead struct {
 
 type modbusDeviceConfigRead struct {
 	deviceConfigRead `yaml:",inline"`
-	Bus              string                     `yaml:"Bus"`
-	Kind             string                     `yaml:"Kind"`
-	Address          string                     `yaml:"Address"`
-	Relays           map[string]relayConfigRead `yaml:"Relays"`
-	PollInterval     string                     `yaml:"PollInterval"`
+	Bus              string                              `yaml:"Bus"`
+	Kind             string                              `yaml:"Kind"`
+	Address          string                              `yaml:"Address"`
+	Relays           map[string]relayConfigRead          `yaml:"Relays"`
+	Registers        map[string]modbusRegisterConfigRead `yaml:"Registers"`
+	PollInterval     string                              `yaml:"PollInterval"`
+	MaxReadGap       *int                                `yaml:"MaxReadGap"`
+	ReadTimeout      string                              `yaml:"ReadTimeout"`
+	Retries          *int                                `yaml:"Retries"`
+}
+
+type modbusRegisterConfigRead struct {
+	Function    string         `yaml:"Function"`
+	Address     *int           `yaml:"Address"`
+	Type        string         `yaml:"Type"`
+	Length      *int           `yaml:"Length"`
+	WordOrder   string         `yaml:"WordOrder"`
+	ByteOrder   string         `yaml:"ByteOrder"`
+	Scale       *float64       `yaml:"Scale"`
+	Unit        string         `yaml:"Unit"`
+	Enum        map[int]string `yaml:"Enum"`
+	Category    string         `yaml:"Category"`
+	Description string         `yaml:"Description"`
+	Sort        *int           `yaml:"Sort"`
+	Writable    *bool          `yaml:"Writable"`
 }
 
 type relayConfigRead struct {
@@ -144,7 +222,10 @@ type httpDeviceConfigRead struct {
 	Kind             string `yaml:"Kind"`
 	Username         string `yaml:"Username"`
 	Password         string `yaml:"Password"`
+	PasswordFile     string `yaml:"PasswordFile"`
 	PollInterval     string `yaml:"PollInterval"`
+
+	passwordFromFile string
 }
 
 type mqttDeviceConfigRead struct {
@@ -152,6 +233,26 @@ type mqttDeviceConfigRead struct {
 	Kind             string `yaml:"Kind"`
 }
 
+type systemDeviceConfigRead struct {
+	deviceConfigRead `yaml:",inline"`
+	PollInterval     string `yaml:"PollInterval"`
+	DiskPath         string `yaml:"DiskPath"`
+}
+
+type computedDeviceConfigRead struct {
+	deviceConfigRead `yaml:",inline"`
+	Registers        map[string]computedRegisterConfigRead `yaml:"Registers"`
+}
+
+type computedRegisterConfigRead struct {
+	Expression  string `yaml:"Expression"`
+	Type        string `yaml:"Type"`
+	Category    string `yaml:"Category"`
+	Description string `yaml:"Description"`
+	Unit        string `yaml:"Unit"`
+	Sort        *int   `yaml:"Sort"`
+}
+
 type gensetDeviceConfigRead struct {
 	deviceConfigRead `yaml:",inline"`
 
@@ -203,6 +304,158 @@ type viewDeviceConfigRead struct {
 	Filter filterConfigRead `yaml:"Filter"`
 }
 
+type historyConfigRead struct {
+	MaxAge     string           `yaml:"MaxAge"`
+	MaxSamples *int             `yaml:"MaxSamples"`
+	Resolution string           `yaml:"Resolution"`
+	Filter     filterConfigRead `yaml:"Filter"`
+}
+
+type archiveConfigRead struct {
+	Path            string                            `yaml:"Path"`
+	FlushInterval   string                            `yaml:"FlushInterval"`
+	RawRetention    string                            `yaml:"RawRetention"`
+	MinuteRetention string                            `yaml:"MinuteRetention"`
+	HourRetention   string                            `yaml:"HourRetention"`
+	Devices         map[string]deviceFilterConfigRead `yaml:"Devices"`
+	LogDebug        *bool                             `yaml:"LogDebug"`
+}
+
+type influxDbOutputConfigRead struct {
+	Url           string                            `yaml:"Url"`
+	Version       *int                              `yaml:"Version"`
+	Database      string                            `yaml:"Database"`
+	User          string                            `yaml:"User"`
+	Password      string                            `yaml:"Password"`
+	PasswordFile  string                            `yaml:"PasswordFile"`
+	Org           string                            `yaml:"Org"`
+	Bucket        string                            `yaml:"Bucket"`
+	Token         string                            `yaml:"Token"`
+	TokenFile     string                            `yaml:"TokenFile"`
+	BatchSize     *int                              `yaml:"BatchSize"`
+	FlushInterval string                            `yaml:"FlushInterval"`
+	Timeout       string                            `yaml:"Timeout"`
+	BufferPath    string                            `yaml:"BufferPath"`
+	MaxBufferSize *int64                            `yaml:"MaxBufferSize"`
+	Devices       map[string]deviceFilterConfigRead `yaml:"Devices"`
+	LogDebug      *bool                             `yaml:"LogDebug"`
+
+	passwordFromFile string
+	tokenFromFile    string
+}
+
+type modbusServerConfigRead struct {
+	Kind      string                           `yaml:"Kind"`
+	Bind      string                           `yaml:"Bind"`
+	Port      *int                             `yaml:"Port"`
+	Device    string                           `yaml:"Device"`
+	BaudRate  int                              `yaml:"BaudRate"`
+	UnitId    *int                             `yaml:"UnitId"`
+	Registers []modbusServerRegisterConfigRead `yaml:"Registers"`
+	LogDebug  *bool                            `yaml:"LogDebug"`
+}
+
+type modbusServerRegisterConfigRead struct {
+	Function  string   `yaml:"Function"`
+	Address   *int     `yaml:"Address"`
+	Device    string   `yaml:"Device"`
+	Register  string   `yaml:"Register"`
+	Type      string   `yaml:"Type"`
+	WordOrder string   `yaml:"WordOrder"`
+	ByteOrder string   `yaml:"ByteOrder"`
+	Scale     *float64 `yaml:"Scale"`
+}
+
+type snapshotConfigRead struct {
+	Path          string                              `yaml:"Path"`
+	Interval      string                              `yaml:"Interval"`
+	DefaultPolicy string                              `yaml:"DefaultPolicy"`
+	Devices       map[string]snapshotDeviceConfigRead `yaml:"Devices"`
+	LogDebug      *bool                               `yaml:"LogDebug"`
+}
+
+type snapshotDeviceConfigRead struct {
+	Policy         string            `yaml:"Policy"`
+	RegisterPolicy map[string]string `yaml:"RegisterPolicy"`
+}
+
+type ruleConfigRead struct {
+	Triggers  []ruleTriggerConfigRead `yaml:"Triggers"`
+	Condition string                  `yaml:"Condition"`
+	Actions   []ruleActionConfigRead  `yaml:"Actions"`
+	LogDebug  *bool                   `yaml:"LogDebug"`
+}
+
+type ruleTriggerConfigRead struct {
+	Kind       string   `yaml:"Kind"`
+	Device     string   `yaml:"Device"`
+	Register   string   `yaml:"Register"`
+	Above      *float64 `yaml:"Above"`
+	Below      *float64 `yaml:"Below"`
+	Hysteresis *float64 `yaml:"Hysteresis"`
+	Hold       string   `yaml:"Hold"`
+	Available  *bool    `yaml:"Available"`
+	Schedule   string   `yaml:"Schedule"`
+}
+
+type ruleActionConfigRead struct {
+	Kind       string `yaml:"Kind"`
+	Device     string `yaml:"Device"`
+	Register   string `yaml:"Register"`
+	Value      string `yaml:"Value"`
+	MqttClient string `yaml:"MqttClient"`
+	Topic      string `yaml:"Topic"`
+	Payload    string `yaml:"Payload"`
+	Qos        *byte  `yaml:"Qos"`
+	Retain     *bool  `yaml:"Retain"`
+	Url        string `yaml:"Url"`
+	Method     string `yaml:"Method"`
+	Timeout    string `yaml:"Timeout"`
+}
+
+type alarmsConfigRead struct {
+	HistorySize *int                                 `yaml:"HistorySize"`
+	Definitions map[string]alarmDefinitionConfigRead `yaml:"Definitions"`
+	Notifiers   map[string]alarmNotifierConfigRead   `yaml:"Notifiers"`
+	LogDebug    *bool                                `yaml:"LogDebug"`
+}
+
+type alarmDefinitionConfigRead struct {
+	Kind       string   `yaml:"Kind"`
+	Device     string   `yaml:"Device"`
+	Register   string   `yaml:"Register"`
+	Limit      *float64 `yaml:"Limit"`
+	Hysteresis *float64 `yaml:"Hysteresis"`
+	Interval   string   `yaml:"Interval"`
+	Delay      string   `yaml:"Delay"`
+	Severity   string   `yaml:"Severity"`
+	Message    string   `yaml:"Message"`
+	Notifiers  []string `yaml:"Notifiers"`
+}
+
+type alarmNotifierConfigRead struct {
+	Kind         string   `yaml:"Kind"`
+	Url          string   `yaml:"Url"`
+	Method       string   `yaml:"Method"`
+	Token        string   `yaml:"Token"`
+	TokenFile    string   `yaml:"TokenFile"`
+	Host         string   `yaml:"Host"`
+	Port         *int     `yaml:"Port"`
+	User         string   `yaml:"User"`
+	Password     string   `yaml:"Password"`
+	PasswordFile string   `yaml:"PasswordFile"`
+	From         string   `yaml:"From"`
+	To           []string `yaml:"To"`
+	Timeout      string   `yaml:"Timeout"`
+
+	passwordFromFile string
+	tokenFromFile    string
+}
+
+type deviceFilterConfigRead struct {
+	Filter *filterConfigRead `yaml:"Filter"`
+}
+
 type filterConfigRead struct {
 	IncludeRegisters  []string `yaml:"IncludeRegisters"`
 	SkipRegisters     []string `yaml:"SkipRegisters"`
@@ -0,0 +1,177 @@
+package dataflow
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Deadband defines when a new value of a register is significant enough to be forwarded.
+// All fields are optional; a zero value disables the respective check.
+type Deadband struct {
+	// Absolute and Percent only apply to number and int registers. A value is forwarded when its change
+	// compared to the last forwarded value exceeds all configured deadbands.
+	Absolute float64
+	Percent  float64 // relative to the last forwarded value
+	// MinInterval is the minimal duration between two forwarded values.
+	MinInterval time.Duration
+	// MaxInterval forwards a value, even if unchanged, when the last one is older (heartbeat).
+	MaxInterval time.Duration
+}
+
+// DeadbandFunc returns the deadband for the given register; ok is false when no deadband is configured.
+type DeadbandFunc func(reg Register) (deadband Deadband, ok bool)
+
+// Refresher is a Fillable which can notify its subscribers even when a value did not change.
+type Refresher interface {
+	Fillable
+	Refresh(value Value)
+}
+
+// DeadbandStage sits between the device drivers and the ValueStorage and drops values whose change
+// is within the deadband of their register. The stage is used per device, the state is kept by register name.
+// Times are taken from the values' MeasuredAt.
+// A significant change received within the MinInterval is kept as pending and forwarded once the interval
+// has passed, unless a newer value replaces it meanwhile. Otherwise, event driven sources which only send
+// changes would keep a wrong value until their next change.
+type DeadbandStage struct {
+	output   Refresher
+	deadband DeadbandFunc
+
+	mutex   sync.Mutex
+	last    map[string]Value         // key: register name; the last forwarded value
+	pending map[string]*pendingValue // key: register name
+}
+
+type pendingValue struct {
+	value Value
+	timer *time.Timer
+}
+
+func NewDeadbandStage(output Refresher, deadband DeadbandFunc) *DeadbandStage {
+	return &DeadbandStage{
+		output:   output,
+		deadband: deadband,
+		last:     make(map[string]Value),
+		pending:  make(map[string]*pendingValue),
+	}
+}
+
+func (s *DeadbandStage) Fill(value Value) {
+	db, ok := s.deadband(value.Register())
+	if !ok {
+		s.output.Fill(value)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	name := value.Register().Name()
+	last, ok := s.last[name]
+	if _, null := value.(NullRegisterValue); null || !ok || last.Quality() != value.Quality() {
+		s.forward(name, value, false)
+		return
+	}
+
+	age := value.MeasuredAt().Sub(last.MeasuredAt())
+	if db.MaxInterval > 0 && age >= db.MaxInterval {
+		s.forward(name, value, true)
+		return
+	}
+	if significant(db, last, value) {
+		if age >= db.MinInterval {
+			s.forward(name, value, false)
+			return
+		}
+		s.setPending(name, value, db.MinInterval-age)
+	} else {
+		// the value returned into the deadband of the last forwarded value
+		s.clearPending(name)
+	}
+
+	// keep the last forwarded value but refresh its timestamps, this way it is not marked as stale
+	s.output.Fill(last.WithTimes(value.MeasuredAt(), value.SourceTime()))
+}
+
+// setPending keeps the value to be forwarded after wait; a value already pending is replaced by the newer one.
+func (s *DeadbandStage) setPending(name string, value Value, wait time.Duration) {
+	if p, ok := s.pending[name]; ok {
+		p.value = value
+		return
+	}
+
+	p := &pendingValue{value: value}
+	p.timer = time.AfterFunc(wait, func() {
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		if s.pending[name] == p {
+			s.forward(name, p.value, false)
+		}
+	})
+	s.pending[name] = p
+}
+
+// DiscardPending drops all pending values, e.g. when the device becomes unavailable.
+func (s *DeadbandStage) DiscardPending() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for name := range s.pending {
+		s.clearPending(name)
+	}
+}
+
+func (s *DeadbandStage) clearPending(name string) {
+	if p, ok := s.pending[name]; ok {
+		p.timer.Stop()
+		delete(s.pending, name)
+	}
+}
+
+func (s *DeadbandStage) forward(name string, value Value, refresh bool) {
+	s.clearPending(name)
+
+	if _, null := value.(NullRegisterValue); null {
+		delete(s.last, name)
+	} else {
+		s.last[name] = value
+	}
+
+	if refresh {
+		s.output.Refresh(value)
+	} else {
+		s.output.Fill(value)
+	}
+}
+
+func significant(db Deadband, last, value Value) bool {
+	if last.Equals(value) {
+		return false
+	}
+
+	a, aOk := deadbandNumber(last)
+	b, bOk := deadbandNumber(value)
+	if !aOk || !bOk {
+		return true
+	}
+
+	diff := math.Abs(b - a)
+	if db.Absolute > 0 && diff <= db.Absolute {
+		return false
+	}
+	if db.Percent > 0 && diff <= math.Abs(a)*db.Percent/100 {
+		return false
+	}
+	return true
+}
+
+func deadbandNumber(v Value) (float64, bool) {
+	switch tv := v.(type) {
+	case NumericRegisterValue:
+		return tv.Value(), true
+	case IntRegisterValue:
+		return float64(tv.Value()), true
+	default:
+		return 0, false
+	}
+}
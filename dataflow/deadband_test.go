@@ -0,0 +1,166 @@
+package dataflow_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// refreshRecorder records the values as "<value>" when filled and as "<value>!" when refreshed.
+type refreshRecorder []string
+
+func (r *refreshRecorder) Fill(value dataflow.Value) {
+	*r = append(*r, fmt.Sprint(value.GenericValue()))
+}
+
+func (r *refreshRecorder) Refresh(value dataflow.Value) {
+	*r = append(*r, fmt.Sprint(value.GenericValue())+"!")
+}
+
+func TestDeadbandStage(t *testing.T) {
+	power := dataflow.NewRegisterStruct("Essential", "Power", "", dataflow.NumberRegister, nil, "W", 0, false)
+	mode := dataflow.NewRegisterStruct("Essential", "Mode", "", dataflow.TextRegister, nil, "", 0, false)
+	other := dataflow.NewRegisterStruct("Other", "Other", "", dataflow.NumberRegister, nil, "W", 0, false)
+
+	rec := &refreshRecorder{}
+	stage := dataflow.NewDeadbandStage(rec, func(reg dataflow.Register) (dataflow.Deadband, bool) {
+		if reg.Category() != "Essential" {
+			return dataflow.Deadband{}, false
+		}
+		return dataflow.Deadband{
+			Absolute:    1,
+			Percent:     5,
+			MinInterval: 2 * time.Second,
+			MaxInterval: time.Minute,
+		}, true
+	})
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fill := func(reg dataflow.Register, offset time.Duration, v interface{}) {
+		var value dataflow.Value
+		switch tv := v.(type) {
+		case float64:
+			value = dataflow.NewNumericRegisterValue("dev", reg, tv)
+		case string:
+			value = dataflow.NewTextRegisterValue("dev", reg, tv)
+		}
+		stage.Fill(value.WithTimes(start.Add(offset), time.Time{}))
+	}
+
+	fill(power, 0, 100.)               // first value is forwarded
+	fill(power, 5*time.Second, 104.)   // within 5%: the last value is kept
+	fill(power, 10*time.Second, 106.)  // exceeds both deadbands
+	fill(power, 11*time.Second, 120.)  // within the min interval
+	fill(power, 13*time.Second, 120.)  // after the min interval
+	fill(power, 75*time.Second, 120.5) // heartbeat
+	fill(mode, 0, "on")
+	fill(mode, 5*time.Second, "on")
+	fill(mode, 10*time.Second, "off")
+	fill(other, 0, 1.)
+	fill(other, time.Millisecond, 1.1)
+
+	expect := []string{"100", "100", "106", "106", "120", "120.5!", "on", "on", "off", "1", "1.1"}
+	if !reflect.DeepEqual(expect, []string(*rec)) {
+		t.Errorf("expect %v but got %v", expect, *rec)
+	}
+}
+
+// lockedRecorder is a refreshRecorder which can be filled by the timers of the stage.
+type lockedRecorder struct {
+	mutex sync.Mutex
+	rec   refreshRecorder
+}
+
+func (r *lockedRecorder) Fill(value dataflow.Value) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rec.Fill(value)
+}
+
+func (r *lockedRecorder) Refresh(value dataflow.Value) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.rec.Refresh(value)
+}
+
+func (r *lockedRecorder) get() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.rec...)
+}
+
+func TestDeadbandStagePending(t *testing.T) {
+	const minInterval = 50 * time.Millisecond
+	power := dataflow.NewRegisterStruct("Essential", "Power", "", dataflow.NumberRegister, nil, "W", 0, false)
+
+	rec := &lockedRecorder{}
+	stage := dataflow.NewDeadbandStage(rec, func(reg dataflow.Register) (dataflow.Deadband, bool) {
+		return dataflow.Deadband{Absolute: 1, MinInterval: minInterval}, true
+	})
+	fill := func(v float64) {
+		stage.Fill(dataflow.NewNumericRegisterValue("dev", power, v))
+	}
+	waitFor := func(expect []string) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if reflect.DeepEqual(expect, rec.get()) {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Errorf("expect %v but got %v", expect, rec.get())
+	}
+
+	fill(100)
+	fill(110) // within the min interval: the last value is kept and 110 is pending
+	fill(120) // replaces the pending value
+	waitFor([]string{"100", "100", "100", "120"})
+
+	fill(140)   // the min interval has passed: sent immediately
+	fill(150)   // pending
+	fill(140.5) // back within the deadband of the last forwarded value: nothing is sent later
+	time.Sleep(2 * minInterval)
+	if expect, got := []string{"100", "100", "100", "120", "140", "140", "140"}, rec.get(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect %v but got %v", expect, got)
+	}
+}
+
+func TestValueStorageRefresh(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	storage.Fill(dataflow.NewNumericRegisterValue("dev", reg, 1))
+	storage.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	initial, subscription := storage.SubscribeReturnInitial(ctx, dataflow.AllValueFilter)
+	if len(initial) != 1 {
+		t.Fatalf("expect one initial value but got %v", initial)
+	}
+
+	storage.Fill(dataflow.NewNumericRegisterValue("dev", reg, 1))
+	storage.Refresh(dataflow.NewNumericRegisterValue("dev", reg, 1))
+	storage.Wait()
+
+	select {
+	case v := <-subscription.Drain():
+		if expect, got := 1., v.GenericValue(); expect != got {
+			t.Errorf("expect refreshed value %v but got %v", expect, got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expect the refreshed value to be sent to the subscribers")
+	}
+
+	select {
+	case v := <-subscription.Drain():
+		t.Errorf("expect only one value but got %v", v)
+	default:
+	}
+}
@@ -0,0 +1,106 @@
+package dataflow
+
+import (
+	"time"
+)
+
+// HistoryConfig defines how many samples of a register are kept in memory.
+// Samples older than MaxAge are dropped; at most MaxSamples are kept. When Resolution is > 0, all samples falling into
+// the same interval are merged into one sample: numeric values are averaged, for all other types the newest value wins.
+type HistoryConfig struct {
+	MaxAge     time.Duration
+	MaxSamples int
+	Resolution time.Duration
+}
+
+// HistoryConfigFunc returns the history configuration for the register of the given value; ok=false disables the history.
+type HistoryConfigFunc func(Value) (cfg HistoryConfig, ok bool)
+
+// historyRing is a fixed size ring buffer holding the samples of one register.
+type historyRing struct {
+	cfg     HistoryConfig
+	samples []Value
+	start   int
+	length  int
+
+	// number of samples merged into the newest sample; used for averaging
+	mergedCount int
+}
+
+func newHistoryRing(cfg HistoryConfig) *historyRing {
+	return &historyRing{
+		cfg:     cfg,
+		samples: make([]Value, cfg.MaxSamples),
+	}
+}
+
+func (r *historyRing) newestIdx() int {
+	return (r.start + r.length - 1) % len(r.samples)
+}
+
+func (r *historyRing) push(v Value) {
+	if len(r.samples) < 1 {
+		return
+	}
+
+	if r.length > 0 && r.cfg.Resolution > 0 {
+		newest := r.samples[r.newestIdx()]
+		if newest.SampleTime().Truncate(r.cfg.Resolution).Equal(v.SampleTime().Truncate(r.cfg.Resolution)) {
+			r.samples[r.newestIdx()] = r.merge(newest, v)
+			return
+		}
+	}
+
+	if r.length < len(r.samples) {
+		r.length += 1
+	} else {
+		r.start = (r.start + 1) % len(r.samples)
+	}
+	r.samples[r.newestIdx()] = v
+	r.mergedCount = 1
+}
+
+func (r *historyRing) merge(old, v Value) Value {
+	r.mergedCount += 1
+
+	oldNumeric, oldOk := old.(NumericRegisterValue)
+	newNumeric, newOk := v.(NumericRegisterValue)
+	if !oldOk || !newOk {
+		return v
+	}
+
+	avg := oldNumeric.Value() + (newNumeric.Value()-oldNumeric.Value())/float64(r.mergedCount)
+	return NewNumericRegisterValue(v.DeviceName(), v.Register(), avg).
+		WithTimes(v.MeasuredAt(), v.SourceTime()).
+		WithQuality(v.Quality())
+}
+
+// expire removes all samples older than now - MaxAge
+func (r *historyRing) expire(now time.Time) {
+	if r.cfg.MaxAge <= 0 {
+		return
+	}
+	limit := now.Add(-r.cfg.MaxAge)
+	for r.length > 0 && r.samples[r.start].SampleTime().Before(limit) {
+		r.samples[r.start] = nil
+		r.start = (r.start + 1) % len(r.samples)
+		r.length -= 1
+	}
+}
+
+// get returns all samples within [from, to] from the oldest to the newest; zero times mean unbounded.
+func (r *historyRing) get(from, to time.Time) (result []Value) {
+	result = make([]Value, 0, r.length)
+	for i := 0; i < r.length; i += 1 {
+		v := r.samples[(r.start+i)%len(r.samples)]
+		t := v.SampleTime()
+		if !from.IsZero() && t.Before(from) {
+			continue
+		}
+		if !to.IsZero() && t.After(to) {
+			continue
+		}
+		result = append(result, v)
+	}
+	return
+}
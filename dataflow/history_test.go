@@ -0,0 +1,141 @@
+package dataflow_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"testing"
+	"time"
+)
+
+func historyTestValue(reg dataflow.Register, v float64, t time.Time) dataflow.Value {
+	return dataflow.NewNumericRegisterValue("device-0", reg, v).WithTimes(time.Now(), t)
+}
+
+func getHistoryAsValues(values []dataflow.Value) (ret []float64) {
+	ret = make([]float64, len(values))
+	for i, v := range values {
+		ret[i] = v.(dataflow.NumericRegisterValue).Value()
+	}
+	return
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValueStorageHistory(t *testing.T) {
+	now := time.Now()
+	regA := getSimpleTestRegister("cat", "register-a")
+	regB := getSimpleTestRegister("cat", "register-b")
+
+	t.Run("disabled", func(t *testing.T) {
+		storage := dataflow.NewValueStorage()
+		defer storage.Shutdown()
+
+		storage.Fill(historyTestValue(regA, 1, now))
+		storage.Wait()
+
+		if got := storage.GetHistoryFiltered(dataflow.AllValueFilter, time.Time{}, time.Time{}); len(got) != 0 {
+			t.Errorf("expect empty history but got %v", got)
+		}
+	})
+
+	t.Run("maxSamples", func(t *testing.T) {
+		storage := dataflow.NewValueStorage()
+		defer storage.Shutdown()
+		storage.SetHistory(func(v dataflow.Value) (dataflow.HistoryConfig, bool) {
+			return dataflow.HistoryConfig{MaxSamples: 3}, v.Register().Name() == "register-a"
+		})
+
+		for i := 0; i < 5; i += 1 {
+			storage.Fill(historyTestValue(regA, float64(i), now.Add(time.Duration(i)*time.Second)))
+			storage.Fill(historyTestValue(regB, float64(i), now.Add(time.Duration(i)*time.Second)))
+		}
+		storage.Wait()
+
+		got := getHistoryAsValues(storage.GetHistoryFiltered(dataflow.AllValueFilter, time.Time{}, time.Time{}))
+		if expect := []float64{2, 3, 4}; !equalFloats(expect, got) {
+			t.Errorf("expect %v but got %v", expect, got)
+		}
+
+		got = getHistoryAsValues(storage.GetHistoryFiltered(
+			dataflow.AllValueFilter, now.Add(3*time.Second), now.Add(3*time.Second),
+		))
+		if expect := []float64{3}; !equalFloats(expect, got) {
+			t.Errorf("expect %v but got %v", expect, got)
+		}
+	})
+
+	t.Run("maxAge", func(t *testing.T) {
+		storage := dataflow.NewValueStorage()
+		defer storage.Shutdown()
+		storage.SetHistory(func(v dataflow.Value) (dataflow.HistoryConfig, bool) {
+			return dataflow.HistoryConfig{MaxSamples: 100, MaxAge: time.Minute}, true
+		})
+
+		storage.Fill(historyTestValue(regA, 1, now.Add(-2*time.Minute)))
+		storage.Fill(historyTestValue(regA, 2, now.Add(-30*time.Second)))
+		storage.Fill(historyTestValue(regA, 3, now))
+		storage.Wait()
+
+		got := getHistoryAsValues(storage.GetHistoryFiltered(dataflow.AllValueFilter, time.Time{}, time.Time{}))
+		if expect := []float64{2, 3}; !equalFloats(expect, got) {
+			t.Errorf("expect %v but got %v", expect, got)
+		}
+	})
+
+	t.Run("resolution", func(t *testing.T) {
+		storage := dataflow.NewValueStorage()
+		defer storage.Shutdown()
+		storage.SetHistory(func(v dataflow.Value) (dataflow.HistoryConfig, bool) {
+			return dataflow.HistoryConfig{MaxSamples: 100, Resolution: time.Minute}, true
+		})
+
+		base := now.Truncate(time.Minute)
+		storage.Fill(historyTestValue(regA, 1, base))
+		storage.Fill(historyTestValue(regA, 2, base.Add(10*time.Second)))
+		storage.Fill(historyTestValue(regA, 6, base.Add(20*time.Second)))
+		storage.Fill(historyTestValue(regA, 10, base.Add(time.Minute)))
+		storage.Wait()
+
+		got := getHistoryAsValues(storage.GetHistoryFiltered(dataflow.AllValueFilter, time.Time{}, time.Time{}))
+		if expect := []float64{3, 10}; !equalFloats(expect, got) {
+			t.Errorf("expect %v but got %v", expect, got)
+		}
+	})
+
+	t.Run("setAgain", func(t *testing.T) {
+		storage := dataflow.NewValueStorage()
+		defer storage.Shutdown()
+		historyConfig := func(maxSamples int) dataflow.HistoryConfigFunc {
+			return func(v dataflow.Value) (dataflow.HistoryConfig, bool) {
+				return dataflow.HistoryConfig{MaxSamples: maxSamples}, true
+			}
+		}
+		storage.SetHistory(historyConfig(10))
+
+		storage.Fill(historyTestValue(regA, 1, now))
+		storage.Fill(historyTestValue(regA, 2, now.Add(time.Second)))
+		storage.Wait()
+
+		// an unchanged configuration keeps the samples
+		storage.SetHistory(historyConfig(10))
+		got := getHistoryAsValues(storage.GetHistoryFiltered(dataflow.AllValueFilter, time.Time{}, time.Time{}))
+		if expect := []float64{1, 2}; !equalFloats(expect, got) {
+			t.Errorf("expect %v but got %v", expect, got)
+		}
+
+		// a changed one starts over
+		storage.SetHistory(historyConfig(5))
+		if got := storage.GetHistoryFiltered(dataflow.AllValueFilter, time.Time{}, time.Time{}); len(got) != 0 {
+			t.Errorf("expect empty history but got %v", got)
+		}
+	})
+}
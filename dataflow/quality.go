@@ -0,0 +1,39 @@
+package dataflow
+
+// Quality describes how trustworthy a value is. The zero value is QualityGood.
+type Quality int
+
+const (
+	QualityGood Quality = iota
+	QualityStale
+	QualityUncertain
+	QualityCommError
+)
+
+func (q Quality) String() string {
+	switch q {
+	case QualityGood:
+		return "good"
+	case QualityStale:
+		return "stale"
+	case QualityUncertain:
+		return "uncertain"
+	case QualityCommError:
+		return "comm-error"
+	default:
+		return ""
+	}
+}
+
+func QualityFromString(s string) Quality {
+	switch s {
+	case "stale":
+		return QualityStale
+	case "uncertain":
+		return QualityUncertain
+	case "comm-error":
+		return QualityCommError
+	default:
+		return QualityGood
+	}
+}
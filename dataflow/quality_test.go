@@ -0,0 +1,100 @@
+package dataflow_test
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"testing"
+	"time"
+)
+
+func receiveValue(t *testing.T, c <-chan dataflow.Value) dataflow.Value {
+	t.Helper()
+	select {
+	case v := <-c:
+		return v
+	case <-time.After(2 * time.Second):
+		t.Fatal("expect a value but got none")
+		return nil
+	}
+}
+
+func TestValueStorageMarkStale(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	storage.SetMaxAge(func(v dataflow.Value) time.Duration {
+		if v.Register().Name() == "register-a" {
+			return 10 * time.Millisecond
+		}
+		return 0
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	subscription := storage.SubscribeSendInitial(ctx, dataflow.EmptyFilter)
+
+	regA := getSimpleTestRegister("cat", "register-a")
+	regB := getSimpleTestRegister("cat", "register-b")
+	storage.Fill(dataflow.NewNumericRegisterValue("device-0", regA, 1))
+	storage.Fill(dataflow.NewNumericRegisterValue("device-0", regB, 2))
+	storage.Wait()
+
+	receiveValue(t, subscription.Drain())
+	receiveValue(t, subscription.Drain())
+
+	stale := receiveValue(t, subscription.Drain())
+	if expect, got := "register-a", stale.Register().Name(); expect != got {
+		t.Errorf("expect '%s' to become stale but got '%s'", expect, got)
+	}
+	if expect, got := dataflow.QualityStale, stale.Quality(); expect != got {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+
+	for _, v := range storage.GetState() {
+		expect := dataflow.QualityGood
+		if v.Register().Name() == "register-a" {
+			expect = dataflow.QualityStale
+		}
+		if got := v.Quality(); expect != got {
+			t.Errorf("expect %s of %s but got %s", expect, v.Register().Name(), got)
+		}
+	}
+
+	// the same value received again must be forwarded since the quality changed
+	storage.Fill(dataflow.NewNumericRegisterValue("device-0", regA, 1))
+	fresh := receiveValue(t, subscription.Drain())
+	if expect, got := dataflow.QualityGood, fresh.Quality(); expect != got {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+}
+
+func TestValueStorageSetQualityFiltered(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	fillSetA(storage)
+	storage.Wait()
+
+	storage.SetQualityFiltered(dataflow.DeviceNameValueFilter("device-0"), dataflow.QualityCommError)
+	storage.Wait()
+
+	for _, v := range storage.GetState() {
+		expect := dataflow.QualityGood
+		if v.DeviceName() == "device-0" {
+			expect = dataflow.QualityCommError
+		}
+		if got := v.Quality(); expect != got {
+			t.Errorf("expect %s of %s but got %s", expect, v, got)
+		}
+	}
+}
+
+func TestQualityFromString(t *testing.T) {
+	for _, q := range []dataflow.Quality{
+		dataflow.QualityGood, dataflow.QualityStale, dataflow.QualityUncertain, dataflow.QualityCommError,
+	} {
+		if got := dataflow.QualityFromString(q.String()); q != got {
+			t.Errorf("expect %s but got %s", q, got)
+		}
+	}
+}
@@ -16,6 +16,7 @@ type Register interface {
 	Unit() string
 	Sort() int
 	Writable() bool
+	Meta() RegisterMeta
 }
 
 type RegisterStruct struct {
@@ -27,6 +28,7 @@ type RegisterStruct struct {
 	unit         string
 	sort         int
 	writable     bool
+	meta         RegisterMeta
 }
 
 func NewRegisterStruct(
@@ -59,6 +61,7 @@ func NewRegisterStructByInterface(reg Register) RegisterStruct {
 		unit:         reg.Unit(),
 		sort:         reg.Sort(),
 		writable:     reg.Writable(),
+		meta:         reg.Meta(),
 	}
 }
 
@@ -94,6 +97,16 @@ func (r RegisterStruct) Writable() bool {
 	return r.writable
 }
 
+func (r RegisterStruct) Meta() RegisterMeta {
+	return r.meta
+}
+
+// WithMeta returns a copy of the register with the given metadata.
+func (r RegisterStruct) WithMeta(meta RegisterMeta) RegisterStruct {
+	r.meta = meta
+	return r
+}
+
 func FilterRegisters[R Register](input []R, filterConf RegisterFilterConf) (output []R) {
 	output = make([]R, 0, len(input))
 	f := RegisterFilter(filterConf)
@@ -118,6 +131,7 @@ func (r RegisterStruct) Equals(b RegisterStruct) bool {
 		r.unit == b.unit &&
 		r.sort == b.sort &&
 		r.writable == b.writable &&
+		r.meta.Equals(b.meta) &&
 		mapEquals(r.enum, b.enum)
 }
 
@@ -6,14 +6,57 @@ import (
 	"sync"
 )
 
+type RegisterEventType int
+
+const (
+	RegisterAdded RegisterEventType = iota
+	RegisterUpdated
+	RegisterRemoved
+)
+
+func (t RegisterEventType) String() string {
+	switch t {
+	case RegisterAdded:
+		return "added"
+	case RegisterUpdated:
+		return "updated"
+	case RegisterRemoved:
+		return "removed"
+	default:
+		return "unknown"
+	}
+}
+
+// RegisterEvent is sent to the subscribers of a RegisterDb whenever a register is added, changed or removed.
+// For RegisterRemoved, Register is the last known version of the register.
+type RegisterEvent struct {
+	Type     RegisterEventType
+	Register RegisterStruct
+}
+
+// RegisterSubscription either receives all events or, when created by Subscribe, only the added and updated registers.
 type RegisterSubscription struct {
 	ctx           context.Context
+	eventChannel  chan RegisterEvent
 	outputChannel chan RegisterStruct
 	filter        RegisterFilterFunc
 }
 
+func (s RegisterSubscription) send(ev RegisterEvent) {
+	if !s.filter(ev.Register) {
+		return
+	}
+	if s.eventChannel != nil {
+		s.eventChannel <- ev
+	} else if ev.Type != RegisterRemoved {
+		s.outputChannel <- ev.Register
+	}
+}
+
 type RegisterDb struct {
-	registers     map[string]RegisterStruct // key: register name
+	registers     map[string]RegisterStruct    // key: register name
+	transforms    map[string]RegisterTransform // key: register name
+	metaOverrides map[string]RegisterMeta      // key: register name
 	subscriptions *list.List[RegisterSubscription]
 	lock          sync.RWMutex
 }
@@ -25,6 +68,22 @@ func NewRegisterDb() (registerDb *RegisterDb) {
 	}
 }
 
+// SetMetaOverrides sets metadata which is merged into the metadata provided by the device
+// of all registers added afterward.
+func (rdb *RegisterDb) SetMetaOverrides(overrides map[string]RegisterMeta) {
+	rdb.lock.Lock()
+	defer rdb.lock.Unlock()
+	rdb.metaOverrides = overrides
+}
+
+// SetTransforms sets the transforms applied to all registers added afterward.
+// They are applied before the metadata overrides.
+func (rdb *RegisterDb) SetTransforms(transforms map[string]RegisterTransform) {
+	rdb.lock.Lock()
+	defer rdb.lock.Unlock()
+	rdb.transforms = transforms
+}
+
 func (rdb *RegisterDb) Add(registers ...Register) {
 	// convert interface type to structs
 	registerStructs := make([]RegisterStruct, len(registers))
@@ -39,24 +98,85 @@ func (rdb *RegisterDb) AddStruct(registerStructs ...RegisterStruct) {
 	defer rdb.lock.Unlock()
 
 	for _, reg := range registerStructs {
-		// check if present and equal
-		oldReg, ok := rdb.registers[reg.Name()]
-		if ok && reg.Equals(oldReg) {
-			continue
+		rdb.addUnlocked(reg)
+	}
+}
+
+// Replace sets the given registers as the full set of registers; all other registers are removed.
+func (rdb *RegisterDb) Replace(registers ...Register) {
+	registerStructs := make([]RegisterStruct, len(registers))
+	for i, r := range registers {
+		registerStructs[i] = NewRegisterStructByInterface(r)
+	}
+	rdb.ReplaceStruct(registerStructs...)
+}
+
+func (rdb *RegisterDb) ReplaceStruct(registerStructs ...RegisterStruct) {
+	rdb.lock.Lock()
+	defer rdb.lock.Unlock()
+
+	keep := make(map[string]struct{}, len(registerStructs))
+	for _, reg := range registerStructs {
+		keep[reg.Name()] = struct{}{}
+	}
+	for name := range rdb.registers {
+		if _, ok := keep[name]; !ok {
+			rdb.removeUnlocked(name)
 		}
+	}
+
+	for _, reg := range registerStructs {
+		rdb.addUnlocked(reg)
+	}
+}
+
+// Remove removes the registers by name; unknown names are ignored.
+func (rdb *RegisterDb) Remove(registerNames ...string) {
+	rdb.lock.Lock()
+	defer rdb.lock.Unlock()
+
+	for _, name := range registerNames {
+		rdb.removeUnlocked(name)
+	}
+}
 
-		// save to map
-		rdb.registers[reg.Name()] = reg
+func (rdb *RegisterDb) addUnlocked(reg RegisterStruct) {
+	if t, ok := rdb.transforms[reg.Name()]; ok {
+		reg = t.Register(reg)
+	}
+	if o, ok := rdb.metaOverrides[reg.Name()]; ok {
+		reg = reg.WithMeta(reg.Meta().Merge(o))
+	}
 
-		// forward to subscriptions
-		for e := rdb.subscriptions.Front(); e != nil; e = e.Next() {
-			s := e.Value
-			if s.filter(reg) {
-				s.outputChannel <- reg
-			}
+	// check if present and equal
+	evType := RegisterAdded
+	if oldReg, ok := rdb.registers[reg.Name()]; ok {
+		if reg.Equals(oldReg) {
+			return
 		}
+		evType = RegisterUpdated
 	}
 
+	// save to map
+	rdb.registers[reg.Name()] = reg
+
+	rdb.forwardToSubscriptions(RegisterEvent{Type: evType, Register: reg})
+}
+
+func (rdb *RegisterDb) removeUnlocked(registerName string) {
+	reg, ok := rdb.registers[registerName]
+	if !ok {
+		return
+	}
+	delete(rdb.registers, registerName)
+
+	rdb.forwardToSubscriptions(RegisterEvent{Type: RegisterRemoved, Register: reg})
+}
+
+func (rdb *RegisterDb) forwardToSubscriptions(ev RegisterEvent) {
+	for e := rdb.subscriptions.Front(); e != nil; e = e.Next() {
+		e.Value.send(ev)
+	}
 }
 
 func (rdb *RegisterDb) GetAll() []RegisterStruct {
@@ -97,13 +217,30 @@ func (rdb *RegisterDb) GetByName(registerName string) (reg RegisterStruct, ok bo
 	return
 }
 
+// Subscribe returns a channel receiving all registers initially followed by all added or updated registers.
 func (rdb *RegisterDb) Subscribe(ctx context.Context, filter RegisterFilterFunc) <-chan RegisterStruct {
 	s := RegisterSubscription{
 		ctx:           ctx,
 		outputChannel: make(chan RegisterStruct, 16),
 		filter:        filter,
 	}
+	rdb.subscribe(s, func() { close(s.outputChannel) })
+	return s.outputChannel
+}
 
+// SubscribeEvents returns a channel receiving a RegisterAdded event for all registers initially
+// followed by all add, update and remove events.
+func (rdb *RegisterDb) SubscribeEvents(ctx context.Context, filter RegisterFilterFunc) <-chan RegisterEvent {
+	s := RegisterSubscription{
+		ctx:          ctx,
+		eventChannel: make(chan RegisterEvent, 16),
+		filter:       filter,
+	}
+	rdb.subscribe(s, func() { close(s.eventChannel) })
+	return s.eventChannel
+}
+
+func (rdb *RegisterDb) subscribe(s RegisterSubscription, closeChannel func()) {
 	rdb.lock.Lock()
 	defer rdb.lock.Unlock()
 
@@ -114,7 +251,7 @@ func (rdb *RegisterDb) Subscribe(ctx context.Context, filter RegisterFilterFunc)
 	go func(initialRegisters []RegisterStruct) {
 		// sending initial set of registers to the output chan
 		for _, reg := range initialRegisters {
-			s.outputChannel <- reg
+			s.send(RegisterEvent{Type: RegisterAdded, Register: reg})
 		}
 
 		<-s.ctx.Done()
@@ -125,8 +262,6 @@ func (rdb *RegisterDb) Subscribe(ctx context.Context, filter RegisterFilterFunc)
 		rdb.lock.Unlock()
 
 		// close output channel
-		close(s.outputChannel)
-	}(rdb.getFilteredUnlocked(filter))
-
-	return s.outputChannel
+		closeChannel()
+	}(rdb.getFilteredUnlocked(s.filter))
 }
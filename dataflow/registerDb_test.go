@@ -109,6 +109,67 @@ func TestRegisterDb(t *testing.T) {
 	wgSubscribe.Wait()
 }
 
+func TestRegisterDbEvents(t *testing.T) {
+	reg := func(name, desc string) dataflow.RegisterStruct {
+		return dataflow.NewRegisterStruct("c", name, desc, dataflow.NumberRegister, nil, "", 0, false)
+	}
+
+	rdb := dataflow.NewRegisterDb()
+	rdb.Add(reg("A", "a"), reg("B", "b"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := rdb.SubscribeEvents(ctx, dataflow.AllRegisterFilter)
+	registers := rdb.Subscribe(ctx, dataflow.AllRegisterFilter)
+
+	got := make([]string, 0)
+	for i := 0; i < 2; i++ {
+		ev := <-events
+		got = append(got, fmt.Sprintf("%s %s", ev.Type, ev.Register.Name()))
+	}
+	slices.Sort(got)
+	if expect := []string{"added A", "added B"}; !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect initial events %v but got %v", expect, got)
+	}
+
+	// the initial registers are sent concurrently; wait for them before changing the db
+	initial := []string{(<-registers).Name(), (<-registers).Name()}
+	slices.Sort(initial)
+
+	rdb.Add(reg("A", "a"))       // unchanged: no event
+	rdb.Add(reg("A", "a2"))      // updated
+	rdb.Remove("B", "unknown")   // removed
+	rdb.Replace(reg("C", "c"))   // A is removed and C added
+	rdb.Replace(reg("C", "c"))   // unchanged: no event
+	rdb.AddStruct(reg("B", "b")) // added again
+
+	got = got[:0]
+	for i := 0; i < 5; i++ {
+		ev := <-events
+		got = append(got, fmt.Sprintf("%s %s %s", ev.Type, ev.Register.Name(), ev.Register.Description()))
+	}
+	expect := []string{"updated A a2", "removed B b", "removed A a2", "added C c", "added B b"}
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect events %v but got %v", expect, got)
+	}
+
+	if got := nameSlice(rdb.GetAll()); !reflect.DeepEqual([]string{"B", "C"}, got) {
+		t.Errorf("expect registers B, C but got %v", got)
+	}
+
+	cancel()
+
+	// Subscribe only receives added and updated registers
+	got = initial
+	for r := range registers {
+		got = append(got, r.Name())
+	}
+	if expect := []string{"A", "B", "A", "C", "B"}; !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect registers %v but got %v", expect, got)
+	}
+	for range events {
+	}
+}
+
 func nameSlice(list []dataflow.RegisterStruct) []string {
 	ret := make([]string, len(list))
 	for i, r := range list {
@@ -129,6 +190,7 @@ func mockRegister(t *testing.T, name string) dataflow.Register {
 	m.EXPECT().Unit().Return("")
 	m.EXPECT().Sort().Return(0)
 	m.EXPECT().Writable().Return(false)
+	m.EXPECT().Meta().Return(dataflow.RegisterMeta{})
 
 	return m
 }
@@ -0,0 +1,155 @@
+package dataflow
+
+import (
+	"fmt"
+	"math"
+)
+
+// RegisterMeta describes the valid range and the semantics of a register.
+// All fields are optional; nil pointers and empty strings mean unknown.
+type RegisterMeta struct {
+	Min       *float64
+	Max       *float64
+	Step      *float64
+	Precision *int // number of decimal places worth displaying
+
+	// DeviceClass and StateClass use the vocabulary of Home Assistant,
+	// e.g. voltage, current, power, energy, temperature, battery and measurement, total_increasing.
+	DeviceClass string
+	StateClass  string
+}
+
+const (
+	StateClassMeasurement     = "measurement"
+	StateClassTotal           = "total"
+	StateClassTotalIncreasing = "total_increasing"
+)
+
+// MetaByUnit derives the device and state class from the given unit.
+// Unknown units result in an empty RegisterMeta.
+func MetaByUnit(unit string) (m RegisterMeta) {
+	switch unit {
+	case "V", "mV":
+		m.DeviceClass = "voltage"
+	case "A", "mA":
+		m.DeviceClass = "current"
+	case "W", "kW":
+		m.DeviceClass = "power"
+	case "VA":
+		m.DeviceClass = "apparent_power"
+	case "var":
+		m.DeviceClass = "reactive_power"
+	case "Wh", "kWh":
+		m.DeviceClass = "energy"
+		m.StateClass = StateClassTotalIncreasing
+		return
+	case "varh", "kvarh":
+		m.DeviceClass = "reactive_energy"
+		m.StateClass = StateClassTotalIncreasing
+		return
+	case "°C", "°F", "K":
+		m.DeviceClass = "temperature"
+	case "Hz":
+		m.DeviceClass = "frequency"
+	case "s", "min", "h":
+		m.DeviceClass = "duration"
+	case "%":
+		// no device class since percent is used for many things, e.g. state of charge, humidity or duty cycle
+	default:
+		return
+	}
+	m.StateClass = StateClassMeasurement
+	return
+}
+
+// WithPrecision returns m with the precision set.
+func (m RegisterMeta) WithPrecision(precision int) RegisterMeta {
+	m.Precision = &precision
+	return m
+}
+
+// WithRange returns m with min and max set.
+func (m RegisterMeta) WithRange(min, max float64) RegisterMeta {
+	m.Min = &min
+	m.Max = &max
+	return m
+}
+
+// WithDeviceClass returns m with the device class set.
+func (m RegisterMeta) WithDeviceClass(deviceClass string) RegisterMeta {
+	m.DeviceClass = deviceClass
+	return m
+}
+
+// WithStateClass returns m with the state class set.
+func (m RegisterMeta) WithStateClass(stateClass string) RegisterMeta {
+	m.StateClass = stateClass
+	return m
+}
+
+// Merge returns m where every field set in o overrides the one of m.
+func (m RegisterMeta) Merge(o RegisterMeta) RegisterMeta {
+	if o.Min != nil {
+		m.Min = o.Min
+	}
+	if o.Max != nil {
+		m.Max = o.Max
+	}
+	if o.Step != nil {
+		m.Step = o.Step
+	}
+	if o.Precision != nil {
+		m.Precision = o.Precision
+	}
+	if o.DeviceClass != "" {
+		m.DeviceClass = o.DeviceClass
+	}
+	if o.StateClass != "" {
+		m.StateClass = o.StateClass
+	}
+	return m
+}
+
+// CheckRange returns an error when v lies outside the min / max range or is not a multiple of step above min.
+func (m RegisterMeta) CheckRange(v float64) error {
+	if m.Min != nil && v < *m.Min {
+		return fmt.Errorf("value %g is below min %g", v, *m.Min)
+	}
+	if m.Max != nil && v > *m.Max {
+		return fmt.Errorf("value %g is above max %g", v, *m.Max)
+	}
+	if m.Step != nil && *m.Step > 0 {
+		var base float64
+		if m.Min != nil {
+			base = *m.Min
+		}
+		n := (v - base) / *m.Step
+		if math.Abs(n-math.Round(n)) > 1e-9 {
+			return fmt.Errorf("value %g is not a multiple of step %g", v, *m.Step)
+		}
+	}
+	return nil
+}
+
+func (m RegisterMeta) Equals(b RegisterMeta) bool {
+	return floatPtrEquals(m.Min, b.Min) &&
+		floatPtrEquals(m.Max, b.Max) &&
+		floatPtrEquals(m.Step, b.Step) &&
+		intPtrEquals(m.Precision, b.Precision) &&
+		m.DeviceClass == b.DeviceClass &&
+		m.StateClass == b.StateClass
+}
+
+func floatPtrEquals(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func intPtrEquals(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,99 @@
+package dataflow_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"testing"
+)
+
+func TestMetaByUnit(t *testing.T) {
+	tests := []struct {
+		unit        string
+		deviceClass string
+		stateClass  string
+	}{
+		{"V", "voltage", dataflow.StateClassMeasurement},
+		{"W", "power", dataflow.StateClassMeasurement},
+		{"kWh", "energy", dataflow.StateClassTotalIncreasing},
+		{"°C", "temperature", dataflow.StateClassMeasurement},
+		{"%", "", dataflow.StateClassMeasurement},
+		{"", "", ""},
+	}
+
+	for _, tc := range tests {
+		m := dataflow.MetaByUnit(tc.unit)
+		if m.DeviceClass != tc.deviceClass || m.StateClass != tc.stateClass {
+			t.Errorf("unit=%s: expect %s/%s but got %s/%s",
+				tc.unit, tc.deviceClass, tc.stateClass, m.DeviceClass, m.StateClass,
+			)
+		}
+	}
+}
+
+func TestRegisterMetaMerge(t *testing.T) {
+	step := 0.5
+	base := dataflow.MetaByUnit("W").WithPrecision(2).WithRange(0, 1000)
+	got := base.Merge(dataflow.RegisterMeta{Step: &step, DeviceClass: "apparent_power"})
+
+	expect := dataflow.MetaByUnit("W").WithPrecision(2).WithRange(0, 1000).WithDeviceClass("apparent_power")
+	expect.Step = &step
+	if !got.Equals(expect) {
+		t.Errorf("expect %#v but got %#v", expect, got)
+	}
+
+	if base.Equals(got) {
+		t.Error("expect base not to be modified")
+	}
+}
+
+func TestRegisterMetaCheckRange(t *testing.T) {
+	step := 0.5
+	m := dataflow.RegisterMeta{}.WithRange(10, 20)
+	m.Step = &step
+
+	for _, v := range []float64{10, 10.5, 20} {
+		if err := m.CheckRange(v); err != nil {
+			t.Errorf("expect %g to be valid, got: %s", v, err)
+		}
+	}
+	for _, v := range []float64{9.5, 20.5, 10.2} {
+		if err := m.CheckRange(v); err == nil {
+			t.Errorf("expect %g to be invalid", v)
+		}
+	}
+
+	if err := (dataflow.RegisterMeta{}).CheckRange(-1e9); err != nil {
+		t.Errorf("expect an unbounded register to accept any value, got: %s", err)
+	}
+}
+
+func TestRegisterDbMetaOverrides(t *testing.T) {
+	min := 5.0
+	rdb := dataflow.NewRegisterDb()
+	rdb.SetMetaOverrides(map[string]dataflow.RegisterMeta{
+		"Power": {Min: &min, StateClass: dataflow.StateClassTotal},
+	})
+	rdb.Add(
+		dataflow.NewRegisterStruct("c", "Power", "", dataflow.NumberRegister, nil, "W", 0, false).
+			WithMeta(dataflow.MetaByUnit("W")),
+		dataflow.NewRegisterStruct("c", "Other", "", dataflow.NumberRegister, nil, "W", 1, false),
+	)
+
+	reg, ok := rdb.GetByName("Power")
+	if !ok {
+		t.Fatal("expect Power to exist")
+	}
+	m := reg.Meta()
+	if m.Min == nil || *m.Min != min {
+		t.Errorf("expect min to be overridden")
+	}
+	if expect, got := dataflow.StateClassTotal, m.StateClass; expect != got {
+		t.Errorf("expect state class %s but got %s", expect, got)
+	}
+	if expect, got := "power", m.DeviceClass; expect != got {
+		t.Errorf("expect device class %s to be kept but got %s", expect, got)
+	}
+
+	if reg, _ := rdb.GetByName("Other"); !reg.Meta().Equals(dataflow.RegisterMeta{}) {
+		t.Errorf("expect Other not to be modified")
+	}
+}
@@ -0,0 +1,178 @@
+package dataflow
+
+import (
+	"math"
+)
+
+// RegisterTransform normalizes a register and its values: value' = round(value * Scale + Offset).
+// All fields are optional. When neither Scale nor Offset is set but Unit is, the conversion is looked up
+// by UnitConversion using the unit of the device.
+// Scale, Offset, Unit and Round are only applied to read-only number registers since commands are always
+// sent in the units of the device. Description and Category are applied to all registers.
+type RegisterTransform struct {
+	Scale       *float64
+	Offset      *float64
+	Unit        string
+	Round       *int // number of decimal places
+	Description string
+	Category    string
+}
+
+// UnitConversion returns the factor and offset to convert a value from one unit into another.
+// ok is false when the conversion is unknown.
+func UnitConversion(from, to string) (scale, offset float64, ok bool) {
+	type pair struct{ from, to string }
+	switch (pair{from, to}) {
+	case pair{"mV", "V"}, pair{"mA", "A"}, pair{"mAh", "Ah"}, pair{"W", "kW"}, pair{"Wh", "kWh"}, pair{"varh", "kvarh"}:
+		return 1e-3, 0, true
+	case pair{"V", "mV"}, pair{"A", "mA"}, pair{"Ah", "mAh"}, pair{"kW", "W"}, pair{"kWh", "Wh"}, pair{"kvarh", "varh"}:
+		return 1e3, 0, true
+	case pair{"°F", "°C"}:
+		return 5. / 9., -32. * 5. / 9., true
+	case pair{"°C", "°F"}:
+		return 9. / 5., 32, true
+	case pair{"K", "°C"}:
+		return 1, -273.15, true
+	case pair{"°C", "K"}:
+		return 1, 273.15, true
+	case pair{"s", "min"}, pair{"min", "h"}:
+		return 1. / 60., 0, true
+	case pair{"min", "s"}, pair{"h", "min"}:
+		return 60, 0, true
+	case pair{"s", "h"}:
+		return 1. / 3600., 0, true
+	case pair{"h", "s"}:
+		return 3600, 0, true
+	}
+	return 1, 0, from == to
+}
+
+// linear returns the factor and offset applied to values of a register with the given unit.
+func (t RegisterTransform) linear(fromUnit string) (scale, offset float64) {
+	if t.Scale == nil && t.Offset == nil {
+		if t.Unit != "" {
+			if s, o, ok := UnitConversion(fromUnit, t.Unit); ok {
+				return s, o
+			}
+		}
+		return 1, 0
+	}
+
+	scale = 1
+	if t.Scale != nil {
+		scale = *t.Scale
+	}
+	if t.Offset != nil {
+		offset = *t.Offset
+	}
+	return
+}
+
+func (t RegisterTransform) numeric(reg Register) bool {
+	return reg.RegisterType() == NumberRegister && !reg.Writable()
+}
+
+// Register returns a copy of the register with the transform applied, including its metadata.
+func (t RegisterTransform) Register(reg RegisterStruct) RegisterStruct {
+	if t.Description != "" {
+		reg.description = t.Description
+	}
+	if t.Category != "" {
+		reg.category = t.Category
+	}
+	if !t.numeric(reg) {
+		return reg
+	}
+
+	scale, offset := t.linear(reg.unit)
+	m := reg.meta
+	if m.Min != nil {
+		v := *m.Min*scale + offset
+		m.Min = &v
+	}
+	if m.Max != nil {
+		v := *m.Max*scale + offset
+		m.Max = &v
+	}
+	if scale < 0 && m.Min != nil && m.Max != nil {
+		m.Min, m.Max = m.Max, m.Min
+	}
+	if m.Step != nil {
+		v := *m.Step * math.Abs(scale)
+		m.Step = &v
+	}
+	if t.Round != nil {
+		m.Precision = t.Round
+	}
+
+	if t.Unit != "" && t.Unit != reg.unit {
+		// the classes derived from the old unit do not necessarily match the new one
+		byUnit := MetaByUnit(t.Unit)
+		if byUnit.DeviceClass != "" || byUnit.StateClass != "" {
+			m.DeviceClass = byUnit.DeviceClass
+			m.StateClass = byUnit.StateClass
+		}
+		reg.unit = t.Unit
+	}
+	reg.meta = m
+
+	return reg
+}
+
+// Value returns the value converted and attached to the transformed register.
+func (t RegisterTransform) Value(value Value) Value {
+	orig := value.Register()
+	reg := t.Register(NewRegisterStructByInterface(orig))
+
+	switch v := value.(type) {
+	case NumericRegisterValue:
+		v.register = reg
+		if t.numeric(orig) {
+			scale, offset := t.linear(orig.Unit())
+			v.value = v.value*scale + offset
+			if t.Round != nil {
+				p := math.Pow10(*t.Round)
+				v.value = math.Round(v.value*p) / p
+			}
+		}
+		return v
+	case TextRegisterValue:
+		v.register = reg
+		return v
+	case EnumRegisterValue:
+		v.register = reg
+		return v
+	case BoolRegisterValue:
+		v.register = reg
+		return v
+	case IntRegisterValue:
+		v.register = reg
+		return v
+	case NullRegisterValue:
+		v.register = reg
+		return v
+	default:
+		return value
+	}
+}
+
+// TransformStage sits between the device drivers and the ValueStorage. It applies the register transforms
+// of a device to all values before forwarding them to the output.
+type TransformStage struct {
+	output     Fillable
+	transforms map[string]RegisterTransform // key: register name
+}
+
+func NewTransformStage(output Fillable, transforms map[string]RegisterTransform) *TransformStage {
+	return &TransformStage{
+		output:     output,
+		transforms: transforms,
+	}
+}
+
+func (s *TransformStage) Fill(value Value) {
+	if t, ok := s.transforms[value.Register().Name()]; ok {
+		value = t.Value(value)
+	}
+	s.output.Fill(value)
+}
@@ -0,0 +1,120 @@
+package dataflow_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestUnitConversion(t *testing.T) {
+	tests := []struct {
+		from, to    string
+		inp, expect float64
+		ok          bool
+	}{
+		{"Wh", "kWh", 1500, 1.5, true},
+		{"°F", "°C", 212, 100, true},
+		{"°C", "°F", -40, -40, true},
+		{"s", "h", 5400, 1.5, true},
+		{"V", "V", 12, 12, true},
+		{"V", "W", 12, 12, false},
+	}
+
+	for _, tc := range tests {
+		scale, offset, ok := dataflow.UnitConversion(tc.from, tc.to)
+		if ok != tc.ok {
+			t.Errorf("%s->%s: expect ok=%t but got %t", tc.from, tc.to, tc.ok, ok)
+			continue
+		}
+		if got := tc.inp*scale + offset; math.Abs(got-tc.expect) > 1e-9 {
+			t.Errorf("%s->%s: expect %g to be converted to %g but got %g", tc.from, tc.to, tc.inp, tc.expect, got)
+		}
+	}
+}
+
+func TestRegisterTransform(t *testing.T) {
+	round := 2
+	transform := dataflow.RegisterTransform{
+		Unit:        "kWh",
+		Round:       &round,
+		Description: "Energy total",
+		Category:    "Energy",
+	}
+
+	reg := dataflow.NewRegisterStruct("Meter", "E", "E", dataflow.NumberRegister, nil, "Wh", 0, false).
+		WithMeta(dataflow.MetaByUnit("Wh").WithRange(0, 1e6))
+	got := transform.Register(reg)
+
+	if expect, got := "kWh", got.Unit(); expect != got {
+		t.Errorf("expect unit '%s' but got '%s'", expect, got)
+	}
+	if expect, got := "Energy total", got.Description(); expect != got {
+		t.Errorf("expect description '%s' but got '%s'", expect, got)
+	}
+	if expect, got := "Energy", got.Category(); expect != got {
+		t.Errorf("expect category '%s' but got '%s'", expect, got)
+	}
+	expectMeta := dataflow.MetaByUnit("kWh").WithRange(0, 1000).WithPrecision(2)
+	if !got.Meta().Equals(expectMeta) {
+		t.Errorf("expect meta %#v but got %#v", expectMeta, got.Meta())
+	}
+
+	measuredAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	value := dataflow.NewNumericRegisterValue("dev", reg, 1234.5678).
+		WithTimes(measuredAt, time.Time{}).
+		WithQuality(dataflow.QualityUncertain)
+	tv := transform.Value(value)
+	if expect, got := 1.23, tv.(dataflow.NumericRegisterValue).Value(); expect != got {
+		t.Errorf("expect value %g but got %g", expect, got)
+	}
+	if expect, got := "kWh", tv.Register().Unit(); expect != got {
+		t.Errorf("expect unit of the value's register '%s' but got '%s'", expect, got)
+	}
+	if !tv.MeasuredAt().Equal(measuredAt) || tv.Quality() != dataflow.QualityUncertain {
+		t.Error("expect times and quality to be preserved")
+	}
+
+	// writable registers keep their units and values, only description and category are replaced
+	writable := dataflow.NewRegisterStruct("Meter", "Limit", "Limit", dataflow.NumberRegister, nil, "Wh", 0, true)
+	wv := transform.Value(dataflow.NewNumericRegisterValue("dev", writable, 1234.5678))
+	if expect, got := 1234.5678, wv.(dataflow.NumericRegisterValue).Value(); expect != got {
+		t.Errorf("expect value of writable register %g but got %g", expect, got)
+	}
+	if expect, got := "Wh", wv.Register().Unit(); expect != got {
+		t.Errorf("expect unit of writable register '%s' but got '%s'", expect, got)
+	}
+	if expect, got := "Energy total", wv.Register().Description(); expect != got {
+		t.Errorf("expect description of writable register '%s' but got '%s'", expect, got)
+	}
+}
+
+type fillRecorder []dataflow.Value
+
+func (r *fillRecorder) Fill(value dataflow.Value) {
+	*r = append(*r, value)
+}
+
+func TestTransformStage(t *testing.T) {
+	scale, offset := 2.0, -1.0
+	rec := &fillRecorder{}
+	stage := dataflow.NewTransformStage(rec, map[string]dataflow.RegisterTransform{
+		"A": {Scale: &scale, Offset: &offset, Unit: "V"},
+	})
+
+	regA := dataflow.NewRegisterStruct("c", "A", "A", dataflow.NumberRegister, nil, "mV", 0, false)
+	regB := dataflow.NewRegisterStruct("c", "B", "B", dataflow.NumberRegister, nil, "mV", 0, false)
+	stage.Fill(dataflow.NewNumericRegisterValue("dev", regA, 10))
+	stage.Fill(dataflow.NewNumericRegisterValue("dev", regB, 10))
+
+	if len(*rec) != 2 {
+		t.Fatalf("expect 2 values but got %d", len(*rec))
+	}
+	// an explicit scale / offset takes precedence over the unit conversion
+	if expect, got := 19.0, (*rec)[0].(dataflow.NumericRegisterValue).Value(); expect != got {
+		t.Errorf("expect transformed value %g but got %g", expect, got)
+	}
+	if expect, got := 10.0, (*rec)[1].(dataflow.NumericRegisterValue).Value(); expect != got {
+		t.Errorf("expect untouched value %g but got %g", expect, got)
+	}
+}
@@ -7,6 +7,8 @@ const (
 	NumberRegister
 	TextRegister
 	EnumRegister
+	BoolRegister
+	IntRegister
 )
 
 func (rt RegisterType) String() string {
@@ -17,11 +19,28 @@ func (rt RegisterType) String() string {
 		return "string"
 	case EnumRegister:
 		return "enum"
+	case BoolRegister:
+		return "bool"
+	case IntRegister:
+		return "int"
 	default:
 		return ""
 	}
 }
 
+// CompatString returns the type as known by clients of the v2 api which only know number, string and enum.
+// Booleans are presented as an enum with the indexes 0 (false) and 1 (true), integers as numbers.
+func (rt RegisterType) CompatString() string {
+	switch rt {
+	case BoolRegister:
+		return EnumRegister.String()
+	case IntRegister:
+		return NumberRegister.String()
+	default:
+		return rt.String()
+	}
+}
+
 func RegisterTypeFromString(s string) RegisterType {
 	switch s {
 	case "number":
@@ -30,6 +49,10 @@ func RegisterTypeFromString(s string) RegisterType {
 		return TextRegister
 	case "enum":
 		return EnumRegister
+	case "bool":
+		return BoolRegister
+	case "int":
+		return IntRegister
 	default:
 		return UndefinedRegister
 	}
@@ -51,6 +51,56 @@ func getTestEnumRegister() dataflow.RegisterStruct {
 	)
 }
 
+func getTestBoolRegister() dataflow.RegisterStruct {
+	return dataflow.NewRegisterStruct(
+		"test-bool-register-category",
+		"test-bool-register-name",
+		"test-bool-register-description",
+		dataflow.BoolRegister,
+		map[int]string{0: "Off", 1: "On"},
+		"",
+		43,
+		true,
+	)
+}
+
+func getTestIntRegister() dataflow.RegisterStruct {
+	return dataflow.NewRegisterStruct(
+		"test-int-register-category",
+		"test-int-register-name",
+		"test-int-register-description",
+		dataflow.IntRegister,
+		map[int]string{},
+		"test-int-register-unit",
+		44,
+		false,
+	)
+}
+
+func TestRegisterTypeCompatString(t *testing.T) {
+	tests := []struct {
+		rt                   dataflow.RegisterType
+		expect, expectCompat string
+	}{
+		{dataflow.NumberRegister, "number", "number"},
+		{dataflow.TextRegister, "string", "string"},
+		{dataflow.EnumRegister, "enum", "enum"},
+		{dataflow.BoolRegister, "bool", "enum"},
+		{dataflow.IntRegister, "int", "number"},
+	}
+	for _, tc := range tests {
+		if got := tc.rt.String(); tc.expect != got {
+			t.Errorf("expect '%s' but got '%s'", tc.expect, got)
+		}
+		if got := tc.rt.CompatString(); tc.expectCompat != got {
+			t.Errorf("expect compat '%s' but got '%s'", tc.expectCompat, got)
+		}
+		if got := dataflow.RegisterTypeFromString(tc.expect); tc.rt != got {
+			t.Errorf("expect %s to be parsed but got %s", tc.expect, got)
+		}
+	}
+}
+
 func TestTextRegisterCreatorAndGetters(t *testing.T) {
 	register := getTestTextRegister()
 
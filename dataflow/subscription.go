@@ -0,0 +1,240 @@
+package dataflow
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy defines what happens when a subscriber does not keep up with the values sent to it.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks the storage until the subscriber has received the value.
+	// Only use it for subscribers that never wait for anything else than the storage.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest drops the oldest queued value to make room for the new one.
+	OverflowDropOldest
+	// OverflowCoalesce keeps only the latest queued value per device and register once the channel is full.
+	OverflowCoalesce
+	// OverflowDisconnect cancels the subscription when its queue is full; its channel is closed.
+	OverflowDisconnect
+)
+
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowCoalesce:
+		return "coalesce"
+	case OverflowDisconnect:
+		return "disconnect"
+	default:
+		return "block"
+	}
+}
+
+type subscriptionOptions struct {
+	name     string
+	policy   OverflowPolicy
+	capacity int
+}
+
+type SubscriptionOption func(o *subscriptionOptions)
+
+// WithName sets the name under which the subscription is listed in the stats.
+func WithName(name string) SubscriptionOption {
+	return func(o *subscriptionOptions) {
+		o.name = name
+	}
+}
+
+// WithOverflowPolicy sets what happens when the queue of the subscription is full; the default is OverflowBlock.
+func WithOverflowPolicy(policy OverflowPolicy) SubscriptionOption {
+	return func(o *subscriptionOptions) {
+		o.policy = policy
+	}
+}
+
+// WithCapacity sets the size of the queue of the subscription.
+func WithCapacity(capacity int) SubscriptionOption {
+	return func(o *subscriptionOptions) {
+		if capacity > 0 {
+			o.capacity = capacity
+		}
+	}
+}
+
+// SubscriptionStats are the metrics of a single subscription.
+type SubscriptionStats struct {
+	Name     string
+	Policy   OverflowPolicy
+	Capacity int
+	// QueueDepth is the number of values waiting to be received by the subscriber.
+	QueueDepth int
+	// Delivered counts the values put into the channel of the subscriber, Dropped the ones discarded because
+	// the queue was full or, for OverflowCoalesce, replaced by a newer value of the same register.
+	Delivered    uint64
+	Dropped      uint64
+	Disconnected bool
+}
+
+type subscriptionState struct {
+	opts   subscriptionOptions
+	cancel context.CancelFunc
+
+	delivered    atomic.Uint64
+	dropped      atomic.Uint64
+	disconnected atomic.Bool
+
+	// used by OverflowCoalesce: values waiting to be moved to the output channel by the pump routine
+	mutex    sync.Mutex
+	order    []StateKey
+	pending  map[StateKey]Value
+	inFlight bool // the pump routine holds a value not yet sent to the output channel
+	signal   chan struct{}
+}
+
+type ValueSubscription struct {
+	ctx           context.Context
+	outputChannel chan Value
+	filter        ValueFilterFunc
+	state         *subscriptionState
+}
+
+func (s *ValueSubscription) Drain() <-chan Value {
+	return s.outputChannel
+}
+
+func (s *ValueSubscription) Stats() SubscriptionStats {
+	st := s.state
+	depth := len(s.outputChannel)
+	if st.opts.policy == OverflowCoalesce {
+		st.mutex.Lock()
+		depth += len(st.order)
+		st.mutex.Unlock()
+	}
+
+	return SubscriptionStats{
+		Name:         st.opts.name,
+		Policy:       st.opts.policy,
+		Capacity:     cap(s.outputChannel),
+		QueueDepth:   depth,
+		Delivered:    st.delivered.Load(),
+		Dropped:      st.dropped.Load(),
+		Disconnected: st.disconnected.Load(),
+	}
+}
+
+// deliver is called by the storage routine while holding the storage mutex.
+// Except for OverflowBlock, it never blocks.
+func (s *ValueSubscription) deliver(v Value) {
+	st := s.state
+	switch st.opts.policy {
+	case OverflowDropOldest:
+		for {
+			select {
+			case s.outputChannel <- v:
+				st.delivered.Add(1)
+				return
+			default:
+			}
+			select {
+			case <-s.outputChannel:
+				st.dropped.Add(1)
+			default:
+			}
+		}
+	case OverflowCoalesce:
+		k := StateKey{deviceName: v.DeviceName(), registerName: v.Register().Name()}
+		st.mutex.Lock()
+		// values are only coalesced once the output channel is full; send directly as long as nothing is queued
+		if len(st.order) < 1 && !st.inFlight {
+			select {
+			case s.outputChannel <- v:
+				st.mutex.Unlock()
+				st.delivered.Add(1)
+				return
+			default:
+			}
+		}
+		if _, ok := st.pending[k]; ok {
+			st.dropped.Add(1)
+		} else {
+			st.order = append(st.order, k)
+		}
+		st.pending[k] = v
+		st.mutex.Unlock()
+
+		select {
+		case st.signal <- struct{}{}:
+		default:
+		}
+	case OverflowDisconnect:
+		if st.disconnected.Load() {
+			return
+		}
+		select {
+		case s.outputChannel <- v:
+			st.delivered.Add(1)
+		default:
+			st.dropped.Add(1)
+			st.disconnected.Store(true)
+			st.cancel()
+		}
+	default:
+		select {
+		case s.outputChannel <- v:
+			st.delivered.Add(1)
+		case <-s.ctx.Done():
+		}
+	}
+}
+
+// pop returns the oldest pending value of a coalescing subscription.
+func (st *subscriptionState) pop() (v Value, ok bool) {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if len(st.order) < 1 {
+		return nil, false
+	}
+	k := st.order[0]
+	st.order = st.order[1:]
+	v = st.pending[k]
+	delete(st.pending, k)
+	st.inFlight = true
+	return v, true
+}
+
+func (st *subscriptionState) sent() {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+	st.inFlight = false
+}
+
+// pump moves the pending values of a coalescing subscription to its output channel until the context is done.
+func (s *ValueSubscription) pump() {
+	st := s.state
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-st.signal:
+		}
+
+		for {
+			v, ok := st.pop()
+			if !ok {
+				break
+			}
+			select {
+			case s.outputChannel <- v:
+				st.delivered.Add(1)
+				st.sent()
+			case <-s.ctx.Done():
+				return
+			}
+		}
+	}
+}
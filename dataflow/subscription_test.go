@@ -2,11 +2,14 @@ package dataflow_test
 
 import (
 	"context"
+	"fmt"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	mock_dataflow "github.com/koestler/go-iotdevice/v3/dataflow/mock"
 	"go.uber.org/mock/gomock"
+	"reflect"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestValueStorageSubscribe(t *testing.T) {
@@ -125,3 +128,187 @@ func TestValueStorageSubscribeWithFilter(t *testing.T) {
 		}
 	})
 }
+
+// fillBlocked fills n values per register into the storage and fails when the storage gets wedged.
+func fillBlocked(t *testing.T, storage *dataflow.ValueStorage, regs []dataflow.Register, n int) {
+	t.Helper()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 1; i <= n; i++ {
+			for _, reg := range regs {
+				storage.Fill(dataflow.NewNumericRegisterValue("dev", reg, float64(i)))
+			}
+		}
+		storage.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("storage is blocked by a subscriber which does not read")
+	}
+}
+
+func drainValues(sub dataflow.ValueSubscription) (ret []string) {
+	for {
+		select {
+		case v, ok := <-sub.Drain():
+			if !ok {
+				return
+			}
+			ret = append(ret, fmt.Sprintf("%s=%v", v.Register().Name(), v.GenericValue()))
+		case <-time.After(100 * time.Millisecond):
+			return
+		}
+	}
+}
+
+func TestSubscriptionDropOldest(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	sub := storage.SubscribeSendInitial(ctx, dataflow.AllValueFilter,
+		dataflow.WithName("slow"),
+		dataflow.WithOverflowPolicy(dataflow.OverflowDropOldest),
+		dataflow.WithCapacity(3),
+	)
+
+	fillBlocked(t, storage, []dataflow.Register{reg}, 100)
+
+	stats := sub.Stats()
+	if stats.Name != "slow" || stats.Policy != dataflow.OverflowDropOldest || stats.Capacity != 3 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if expect, got := 3, stats.QueueDepth; expect != got {
+		t.Errorf("expect queue depth %d but got %d", expect, got)
+	}
+	if expect, got := uint64(97), stats.Dropped; expect != got {
+		t.Errorf("expect %d dropped values but got %d", expect, got)
+	}
+
+	expect := []string{"A=98", "A=99", "A=100"}
+	if got := drainValues(sub); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect the newest values %v but got %v", expect, got)
+	}
+}
+
+func TestSubscriptionCoalesce(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	regA := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	regB := dataflow.NewRegisterStruct("c", "B", "", dataflow.NumberRegister, nil, "", 0, false)
+	sub := storage.SubscribeSendInitial(ctx, dataflow.AllValueFilter,
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+		dataflow.WithCapacity(1),
+	)
+
+	fillBlocked(t, storage, []dataflow.Register{regA, regB}, 100)
+
+	// at most one value per register is queued besides the ones already moved to the channel by the pump routine
+	got := drainValues(sub)
+	if len(got) > 4 {
+		t.Errorf("expect at most 4 values but got %v", got)
+	}
+	last := make(map[string]string)
+	for _, v := range got {
+		last[v[:1]] = v
+	}
+	if expect := map[string]string{"A": "A=100", "B": "B=100"}; !reflect.DeepEqual(expect, last) {
+		t.Errorf("expect the latest values per register %v but got %v", expect, got)
+	}
+
+	stats := sub.Stats()
+	if expect, got := uint64(200), stats.Delivered+stats.Dropped; expect != got {
+		t.Errorf("expect delivered + dropped to be %d but got %d (%+v)", expect, got, stats)
+	}
+}
+
+func TestSubscriptionCoalesceOnlyWhenFull(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	sub := storage.SubscribeSendInitial(ctx, dataflow.AllValueFilter,
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+		dataflow.WithCapacity(8),
+	)
+
+	fillBlocked(t, storage, []dataflow.Register{reg}, 5)
+
+	// the channel never got full: no value is skipped
+	expect := []string{"A=1", "A=2", "A=3", "A=4", "A=5"}
+	if got := drainValues(sub); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect %v but got %v", expect, got)
+	}
+}
+
+func TestSubscriptionDisconnect(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	sub := storage.SubscribeSendInitial(ctx, dataflow.AllValueFilter,
+		dataflow.WithOverflowPolicy(dataflow.OverflowDisconnect),
+		dataflow.WithCapacity(2),
+	)
+
+	fillBlocked(t, storage, []dataflow.Register{reg}, 10)
+
+	if !sub.Stats().Disconnected {
+		t.Error("expect the subscription to be disconnected")
+	}
+
+	expect := []string{"A=1", "A=2"}
+	if got := drainValues(sub); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect the queued values %v but got %v", expect, got)
+	}
+	if _, ok := <-sub.Drain(); ok {
+		t.Error("expect the channel to be closed")
+	}
+
+	// the disconnected subscription is removed from the storage
+	deadline := time.Now().Add(time.Second)
+	for len(storage.SubscriptionStats()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expect the subscription to be removed")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubscriptionBlockCancel(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	sub := storage.SubscribeSendInitial(ctx, dataflow.AllValueFilter, dataflow.WithCapacity(1))
+
+	if expect, got := dataflow.OverflowBlock, sub.Stats().Policy; expect != got {
+		t.Errorf("expect default policy %s but got %s", expect, got)
+	}
+
+	// the storage blocks until the subscription is canceled
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	fillBlocked(t, storage, []dataflow.Register{reg}, 10)
+}
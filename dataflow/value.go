@@ -1,6 +1,9 @@
 package dataflow
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type Value interface {
 	DeviceName() string
@@ -8,11 +11,28 @@ type Value interface {
 	String() string
 	GenericValue() interface{}
 	Equals(comp Value) bool
+	MeasuredAt() time.Time
+	SourceTime() time.Time
+	SampleTime() time.Time
+	WithTimes(measuredAt, sourceTime time.Time) Value
+	Quality() Quality
+	WithQuality(quality Quality) Value
 }
 
 type RegisterValue struct {
 	deviceName string
 	register   Register
+	measuredAt time.Time
+	sourceTime time.Time
+	quality    Quality
+}
+
+func newRegisterValue(deviceName string, register Register) RegisterValue {
+	return RegisterValue{
+		deviceName: deviceName,
+		register:   register,
+		measuredAt: time.Now(),
+	}
 }
 
 func (v RegisterValue) DeviceName() string {
@@ -23,6 +43,34 @@ func (v RegisterValue) Register() Register {
 	return v.register
 }
 
+// MeasuredAt returns the time when the value was read / received by this process.
+func (v RegisterValue) MeasuredAt() time.Time {
+	return v.measuredAt
+}
+
+// SourceTime returns the time supplied by the device itself; it is zero when the device did not supply one.
+func (v RegisterValue) SourceTime() time.Time {
+	return v.sourceTime
+}
+
+// SampleTime returns the best known time of the sample: the source time if present and the measured time otherwise.
+func (v RegisterValue) SampleTime() time.Time {
+	if !v.sourceTime.IsZero() {
+		return v.sourceTime
+	}
+	return v.measuredAt
+}
+
+func (v RegisterValue) Quality() Quality {
+	return v.quality
+}
+
+func (v RegisterValue) withTimes(measuredAt, sourceTime time.Time) RegisterValue {
+	v.measuredAt = measuredAt
+	v.sourceTime = sourceTime
+	return v
+}
+
 type NumericRegisterValue struct {
 	RegisterValue
 	value float64
@@ -48,13 +96,20 @@ func (v NumericRegisterValue) Equals(comp Value) bool {
 	return v.Register().Name() == comp.Register().Name() && v.value == numericComp.value
 }
 
+func (v NumericRegisterValue) WithTimes(measuredAt, sourceTime time.Time) Value {
+	v.RegisterValue = v.RegisterValue.withTimes(measuredAt, sourceTime)
+	return v
+}
+
+func (v NumericRegisterValue) WithQuality(quality Quality) Value {
+	v.quality = quality
+	return v
+}
+
 func NewNumericRegisterValue(deviceName string, register Register, value float64) NumericRegisterValue {
 	return NumericRegisterValue{
-		RegisterValue: RegisterValue{
-			deviceName: deviceName,
-			register:   register,
-		},
-		value: value,
+		RegisterValue: newRegisterValue(deviceName, register),
+		value:         value,
 	}
 }
 
@@ -83,13 +138,20 @@ func (v TextRegisterValue) Equals(comp Value) bool {
 	return v.Register().Name() == comp.Register().Name() && v.value == textComp.value
 }
 
+func (v TextRegisterValue) WithTimes(measuredAt, sourceTime time.Time) Value {
+	v.RegisterValue = v.RegisterValue.withTimes(measuredAt, sourceTime)
+	return v
+}
+
+func (v TextRegisterValue) WithQuality(quality Quality) Value {
+	v.quality = quality
+	return v
+}
+
 func NewTextRegisterValue(deviceName string, register Register, value string) TextRegisterValue {
 	return TextRegisterValue{
-		RegisterValue: RegisterValue{
-			deviceName: deviceName,
-			register:   register,
-		},
-		value: value,
+		RegisterValue: newRegisterValue(deviceName, register),
+		value:         value,
 	}
 }
 
@@ -128,13 +190,120 @@ func (v EnumRegisterValue) Equals(comp Value) bool {
 	return v.Register().Name() == comp.Register().Name() && v.value == enumComp.value
 }
 
+func (v EnumRegisterValue) WithTimes(measuredAt, sourceTime time.Time) Value {
+	v.RegisterValue = v.RegisterValue.withTimes(measuredAt, sourceTime)
+	return v
+}
+
+func (v EnumRegisterValue) WithQuality(quality Quality) Value {
+	v.quality = quality
+	return v
+}
+
 func NewEnumRegisterValue(deviceName string, register Register, value int) EnumRegisterValue {
 	return EnumRegisterValue{
-		RegisterValue: RegisterValue{
-			deviceName: deviceName,
-			register:   register,
-		},
-		value: value,
+		RegisterValue: newRegisterValue(deviceName, register),
+		value:         value,
+	}
+}
+
+type BoolRegisterValue struct {
+	RegisterValue
+	value bool
+}
+
+func (v BoolRegisterValue) String() string {
+	if label, ok := v.Register().Enum()[v.EnumIdx()]; ok {
+		return fmt.Sprintf("%s=%t:%s", v.Register().Name(), v.value, label)
+	}
+	return fmt.Sprintf("%s=%t", v.Register().Name(), v.value)
+}
+
+func (v BoolRegisterValue) Value() bool {
+	return v.value
+}
+
+// EnumIdx returns 0 for false and 1 for true; this is how booleans are presented to clients of the v2 api.
+func (v BoolRegisterValue) EnumIdx() int {
+	if v.value {
+		return 1
+	}
+	return 0
+}
+
+// Label returns the text configured for the current state, e.g. On / Off; it is empty when there is none.
+func (v BoolRegisterValue) Label() string {
+	return v.Register().Enum()[v.EnumIdx()]
+}
+
+func (v BoolRegisterValue) GenericValue() interface{} {
+	return v.value
+}
+
+func (v BoolRegisterValue) Equals(comp Value) bool {
+	boolComp, ok := comp.(BoolRegisterValue)
+	if !ok {
+		return false
+	}
+	return v.Register().Name() == comp.Register().Name() && v.value == boolComp.value
+}
+
+func (v BoolRegisterValue) WithTimes(measuredAt, sourceTime time.Time) Value {
+	v.RegisterValue = v.RegisterValue.withTimes(measuredAt, sourceTime)
+	return v
+}
+
+func (v BoolRegisterValue) WithQuality(quality Quality) Value {
+	v.quality = quality
+	return v
+}
+
+func NewBoolRegisterValue(deviceName string, register Register, value bool) BoolRegisterValue {
+	return BoolRegisterValue{
+		RegisterValue: newRegisterValue(deviceName, register),
+		value:         value,
+	}
+}
+
+type IntRegisterValue struct {
+	RegisterValue
+	value int64
+}
+
+func (v IntRegisterValue) String() string {
+	return fmt.Sprintf("%s=%d%s", v.Register().Name(), v.value, v.Register().Unit())
+}
+
+func (v IntRegisterValue) Value() int64 {
+	return v.value
+}
+
+func (v IntRegisterValue) GenericValue() interface{} {
+	return v.value
+}
+
+func (v IntRegisterValue) Equals(comp Value) bool {
+	intComp, ok := comp.(IntRegisterValue)
+	if !ok {
+		return false
+	}
+	return v.Register().Name() == comp.Register().Name() && v.value == intComp.value
+}
+
+func (v IntRegisterValue) WithTimes(measuredAt, sourceTime time.Time) Value {
+	v.RegisterValue = v.RegisterValue.withTimes(measuredAt, sourceTime)
+	return v
+}
+
+func (v IntRegisterValue) WithQuality(quality Quality) Value {
+	v.quality = quality
+	return v
+}
+
+func NewIntRegisterValue(deviceName string, register Register, value int64) IntRegisterValue {
+	return IntRegisterValue{
+		RegisterValue: newRegisterValue(deviceName, register),
+		value:         value,
 	}
 }
 
@@ -155,11 +324,18 @@ func (v NullRegisterValue) Equals(comp Value) bool {
 	return ok
 }
 
+func (v NullRegisterValue) WithTimes(measuredAt, sourceTime time.Time) Value {
+	v.RegisterValue = v.RegisterValue.withTimes(measuredAt, sourceTime)
+	return v
+}
+
+func (v NullRegisterValue) WithQuality(quality Quality) Value {
+	v.quality = quality
+	return v
+}
+
 func NewNullRegisterValue(deviceName string, register Register) NullRegisterValue {
 	return NullRegisterValue{
-		RegisterValue: RegisterValue{
-			deviceName: deviceName,
-			register:   register,
-		},
+		RegisterValue: newRegisterValue(deviceName, register),
 	}
 }
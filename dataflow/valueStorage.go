@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/koestler/go-list"
 	"sync"
+	"time"
 )
 
 type StateKey struct {
@@ -19,18 +20,28 @@ type ValueStorage struct {
 	subscriptions *list.List[ValueSubscription]
 	mutex         sync.RWMutex
 
-	inputChannel   chan Value
+	inputChannel   chan storageInput
 	inputWaitGroup sync.WaitGroup
-}
 
-type ValueSubscription struct {
-	ctx           context.Context
-	outputChannel chan Value
-	filter        ValueFilterFunc
+	maxAge MaxAgeFunc
+
+	historyConfig HistoryConfigFunc
+	history       map[StateKey]*historyRing
 }
 
-func (s *ValueSubscription) Drain() <-chan Value {
-	return s.outputChannel
+// MaxAgeFunc returns the duration after which the given value is considered stale; zero means never.
+type MaxAgeFunc func(Value) time.Duration
+
+// staleCheckInterval defines how often the state is checked for values older than their max-age
+const staleCheckInterval = 250 * time.Millisecond
+
+type storageInput struct {
+	value Value
+	// refresh forces the subscribers to be notified even when the value did not change
+	refresh bool
+	// qualityFilter, when set, changes the quality of all stored values matching it instead of storing value
+	qualityFilter ValueFilterFunc
+	quality       Quality
 }
 
 func NewValueStorage() (valueStorage *ValueStorage) {
@@ -41,7 +52,8 @@ func NewValueStorage() (valueStorage *ValueStorage) {
 		ctxCancel:     cancel,
 		state:         make(map[StateKey]Value),
 		subscriptions: list.New[ValueSubscription](),
-		inputChannel:  make(chan Value, 32),
+		inputChannel:  make(chan storageInput, 32),
+		history:       make(map[StateKey]*historyRing),
 	}
 
 	// start main go routine
@@ -54,15 +66,34 @@ func (vs *ValueStorage) Shutdown() {
 	vs.ctxCancel()
 }
 
+// SetMaxAge configures after which duration a value not being updated is marked as stale.
+func (vs *ValueStorage) SetMaxAge(maxAge MaxAgeFunc) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	vs.maxAge = maxAge
+}
+
 func (vs *ValueStorage) mainStorageRoutine() {
+	staleTicker := time.NewTicker(staleCheckInterval)
+	defer staleTicker.Stop()
+
 	for {
 		select {
 		case <-vs.ctx.Done():
 			return
-		case newValue := <-vs.inputChannel:
+		case now := <-staleTicker.C:
+			vs.mutex.Lock()
+			vs.markStale(now)
+			vs.mutex.Unlock()
+		case in := <-vs.inputChannel:
 			vs.mutex.Lock()
-			if vs.updateState(newValue) {
-				vs.forwardToSubscriptions(newValue)
+			if in.qualityFilter != nil {
+				vs.setQuality(in.qualityFilter, in.quality)
+			} else {
+				if vs.updateState(in.value) || in.refresh {
+					vs.forwardToSubscriptions(in.value)
+				}
+				vs.updateHistory(in.value)
 			}
 			vs.mutex.Unlock()
 			vs.inputWaitGroup.Done()
@@ -78,7 +109,9 @@ func (vs *ValueStorage) updateState(newValue Value) (updated bool) {
 
 	currentValue, ok := vs.state[k]
 
-	if ok && currentValue.Equals(newValue) {
+	if ok && currentValue.Equals(newValue) && currentValue.Quality() == newValue.Quality() {
+		// keep the timestamps of the newest reading but do not notify subscribers
+		vs.state[k] = newValue
 		return false
 	}
 
@@ -93,16 +126,144 @@ func (vs *ValueStorage) updateState(newValue Value) (updated bool) {
 	return true
 }
 
+// SetHistory enables the in-memory history for all registers for which historyConfig returns ok.
+// The samples of registers whose configuration is unchanged are kept; this way it can be applied again on a reload.
+func (vs *ValueStorage) SetHistory(historyConfig HistoryConfigFunc) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+	vs.historyConfig = historyConfig
+
+	for k, ring := range vs.history {
+		if historyConfig == nil || ring.length < 1 {
+			delete(vs.history, k)
+			continue
+		}
+		if cfg, enabled := historyConfig(ring.samples[ring.newestIdx()]); !enabled || cfg != ring.cfg {
+			delete(vs.history, k)
+		}
+	}
+}
+
+func (vs *ValueStorage) updateHistory(newValue Value) {
+	if vs.historyConfig == nil {
+		return
+	}
+	if _, ok := newValue.(NullRegisterValue); ok {
+		return
+	}
+
+	k := StateKey{
+		deviceName:   newValue.DeviceName(),
+		registerName: newValue.Register().Name(),
+	}
+
+	ring, ok := vs.history[k]
+	if !ok {
+		cfg, enabled := vs.historyConfig(newValue)
+		if !enabled || cfg.MaxSamples < 1 {
+			return
+		}
+		ring = newHistoryRing(cfg)
+		vs.history[k] = ring
+	}
+
+	ring.push(newValue)
+	ring.expire(time.Now())
+}
+
+// GetHistoryFiltered returns the samples within [from, to] of all registers matching the filter.
+// The samples of each register are ordered from the oldest to the newest. Zero times mean unbounded.
+func (vs *ValueStorage) GetHistoryFiltered(filter ValueFilterFunc, from, to time.Time) (result []Value) {
+	vs.mutex.Lock()
+	defer vs.mutex.Unlock()
+
+	now := time.Now()
+	result = make([]Value, 0)
+	for _, ring := range vs.history {
+		ring.expire(now)
+		if ring.length < 1 || !filter(ring.samples[ring.newestIdx()]) {
+			continue
+		}
+		result = append(result, ring.get(from, to)...)
+	}
+	return
+}
+
+// markStale sets the quality of all good values older than their max-age to stale and notifies the subscribers
+func (vs *ValueStorage) markStale(now time.Time) {
+	if vs.maxAge == nil {
+		return
+	}
+
+	for k, v := range vs.state {
+		if v.Quality() != QualityGood {
+			continue
+		}
+		maxAge := vs.maxAge(v)
+		if maxAge <= 0 || now.Sub(v.MeasuredAt()) <= maxAge {
+			continue
+		}
+		staleValue := v.WithQuality(QualityStale)
+		vs.state[k] = staleValue
+		vs.forwardToSubscriptions(staleValue)
+	}
+}
+
+// SetQualityFiltered sets the quality of all values in the state matching the filter and notifies the subscribers.
+// This is used to mark all values of a device as comm-error when the device becomes unavailable.
+// The change is queued like Fill; this way values filled before are processed first and cannot overwrite it.
+func (vs *ValueStorage) SetQualityFiltered(filter ValueFilterFunc, quality Quality) {
+	vs.inputWaitGroup.Add(1)
+	vs.inputChannel <- storageInput{qualityFilter: filter, quality: quality}
+}
+
+func (vs *ValueStorage) setQuality(filter ValueFilterFunc, quality Quality) {
+	for k, v := range vs.state {
+		if v.Quality() == quality || !filter(v) {
+			continue
+		}
+		newValue := v.WithQuality(quality)
+		vs.state[k] = newValue
+		vs.forwardToSubscriptions(newValue)
+	}
+}
+
 // copy the input value to all subscribed output channels
 func (vs *ValueStorage) forwardToSubscriptions(newValue Value) {
 	for e := vs.subscriptions.Front(); e != nil; e = e.Next() {
 		s := e.Value
 		if s.filter(newValue) {
-			s.outputChannel <- newValue
+			s.deliver(newValue)
 		}
 	}
 }
 
+// SubscriptionStats returns the metrics of all current subscriptions.
+func (vs *ValueStorage) SubscriptionStats() []SubscriptionStats {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+
+	ret := make([]SubscriptionStats, 0, vs.subscriptions.Len())
+	for e := vs.subscriptions.Front(); e != nil; e = e.Next() {
+		ret = append(ret, e.Value.Stats())
+	}
+	return ret
+}
+
+// ValueCount returns the number of values currently stored.
+func (vs *ValueStorage) ValueCount() int {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+	return len(vs.state)
+}
+
+// SubscriptionCount returns the number of current subscriptions.
+func (vs *ValueStorage) SubscriptionCount() int {
+	vs.mutex.RLock()
+	defer vs.mutex.RUnlock()
+	return vs.subscriptions.Len()
+}
+
 func (vs *ValueStorage) GetState() (result []Value) {
 	vs.mutex.RLock()
 	defer vs.mutex.RUnlock()
@@ -136,7 +297,13 @@ func (vs *ValueStorage) getStateFilteredUnlocked(filter ValueFilterFunc) (result
 
 func (vs *ValueStorage) Fill(value Value) {
 	vs.inputWaitGroup.Add(1)
-	vs.inputChannel <- value
+	vs.inputChannel <- storageInput{value: value}
+}
+
+// Refresh stores the value like Fill but notifies the subscribers even when the value did not change.
+func (vs *ValueStorage) Refresh(value Value) {
+	vs.inputWaitGroup.Add(1)
+	vs.inputChannel <- storageInput{value: value, refresh: true}
 }
 
 // Wait until all inputs are processed (useful for testing)
@@ -146,37 +313,69 @@ func (vs *ValueStorage) Wait() {
 
 const subscriptionDefaultCap = 128
 
-func (vs *ValueStorage) newSubscription(ctx context.Context, filter ValueFilterFunc) (
+func (vs *ValueStorage) newSubscription(ctx context.Context, filter ValueFilterFunc, sendInitial bool, options []SubscriptionOption) (
 	initial []Value, subscription ValueSubscription, elem *list.Element[ValueSubscription],
 ) {
+	opts := subscriptionOptions{capacity: subscriptionDefaultCap}
+	for _, o := range options {
+		o(&opts)
+	}
+
 	vs.mutex.Lock()
 	defer vs.mutex.Unlock()
 
 	initial = vs.getStateFilteredUnlocked(filter)
 
+	capacity := opts.capacity
+	if sendInitial && opts.policy != OverflowBlock {
+		// make room for the initial values; this way they are never dropped
+		capacity += len(initial)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
 	subscription = ValueSubscription{
 		ctx:           ctx,
-		outputChannel: make(chan Value, subscriptionDefaultCap),
+		outputChannel: make(chan Value, capacity),
 		filter:        filter,
+		state: &subscriptionState{
+			opts:    opts,
+			cancel:  cancel,
+			pending: make(map[StateKey]Value),
+			signal:  make(chan struct{}, 1),
+		},
 	}
 	elem = vs.subscriptions.PushBack(subscription)
 
+	if sendInitial && opts.policy != OverflowBlock {
+		// send the initial values while holding the lock; this way no newer value can overtake them
+		for _, v := range initial {
+			subscription.deliver(v)
+		}
+		initial = nil
+	}
+
 	return
 }
 
-func (vs *ValueStorage) SubscribeReturnInitial(ctx context.Context, filter ValueFilterFunc) (initial []Value, subscription ValueSubscription) {
-	initial, subscription, elem := vs.newSubscription(ctx, filter)
-	go vs.sendInitialAndCleanupValueSubscription([]Value{}, subscription, elem)
+// SubscribeReturnInitial returns the current state matching the filter and a subscription receiving all changes.
+func (vs *ValueStorage) SubscribeReturnInitial(
+	ctx context.Context, filter ValueFilterFunc, options ...SubscriptionOption,
+) (initial []Value, subscription ValueSubscription) {
+	initial, subscription, elem := vs.newSubscription(ctx, filter, false, options)
+	go vs.runSubscription(nil, subscription, elem)
 	return
 }
 
-func (vs *ValueStorage) SubscribeSendInitial(ctx context.Context, filter ValueFilterFunc) (subscription ValueSubscription) {
-	initial, subscription, elem := vs.newSubscription(ctx, filter)
-	go vs.sendInitialAndCleanupValueSubscription(initial, subscription, elem)
+// SubscribeSendInitial returns a subscription receiving the current state matching the filter followed by all changes.
+func (vs *ValueStorage) SubscribeSendInitial(
+	ctx context.Context, filter ValueFilterFunc, options ...SubscriptionOption,
+) (subscription ValueSubscription) {
+	initial, subscription, elem := vs.newSubscription(ctx, filter, true, options)
+	go vs.runSubscription(initial, subscription, elem)
 	return
 }
 
-func (vs *ValueStorage) sendInitialAndCleanupValueSubscription(
+func (vs *ValueStorage) runSubscription(
 	initial []Value,
 	subscription ValueSubscription,
 	elem *list.Element[ValueSubscription],
@@ -192,15 +391,21 @@ func (vs *ValueStorage) sendInitialAndCleanupValueSubscription(
 		close(subscription.outputChannel)
 	}()
 
-	// this never blocks since channel is always buffered, big enough and main routine is blocked
+	// only used by OverflowBlock; the other policies deliver the initial values in newSubscription
 	for _, initialV := range initial {
 		select {
 		case subscription.outputChannel <- initialV:
+			subscription.state.delivered.Add(1)
 		case <-subscription.ctx.Done():
 			return
 		}
 	}
 
+	if subscription.state.opts.policy == OverflowCoalesce {
+		subscription.pump()
+		return
+	}
+
 	// wait for the cancellation of the subscription context
 	<-subscription.ctx.Done()
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestNewNumericRegisterValue(t *testing.T) {
@@ -117,6 +118,61 @@ func TestNewEnumRegisterValue(t *testing.T) {
 	}
 }
 
+func TestNewBoolRegisterValue(t *testing.T) {
+	testReg := getTestBoolRegister()
+
+	brv := dataflow.NewBoolRegisterValue(
+		"device-name",
+		testReg,
+		true,
+	)
+
+	if expect, got := "test-bool-register-name=true:On", brv.String(); expect != got {
+		t.Errorf("expect '%s' but got '%s'", expect, got)
+	}
+	if expect, got := 1, brv.EnumIdx(); expect != got {
+		t.Errorf("expect %d but got %d", expect, got)
+	}
+	if expect, got := "On", brv.Label(); expect != got {
+		t.Errorf("expect '%s' but got '%s'", expect, got)
+	}
+	if expect, got := true, brv.GenericValue(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect %#v but got %#v", expect, got)
+	}
+	if brv.Equals(dataflow.NewBoolRegisterValue("device-name", testReg, false)) {
+		t.Error("expect true to NOT be equal to false")
+	}
+	if !brv.Equals(dataflow.NewBoolRegisterValue("device-name", testReg, true)) {
+		t.Error("expect true to be equal to true")
+	}
+	if brv.Equals(dataflow.NewEnumRegisterValue("device-name", testReg, 1)) {
+		t.Error("expect a bool to NOT be equal to an enum")
+	}
+}
+
+func TestNewIntRegisterValue(t *testing.T) {
+	testReg := getTestIntRegister()
+
+	irv := dataflow.NewIntRegisterValue(
+		"device-name",
+		testReg,
+		-42,
+	)
+
+	if expect, got := "test-int-register-name=-42test-int-register-unit", irv.String(); expect != got {
+		t.Errorf("expect '%s' but got '%s'", expect, got)
+	}
+	if expect, got := int64(-42), irv.Value(); expect != got {
+		t.Errorf("expect %d but got %d", expect, got)
+	}
+	if expect, got := int64(-42), irv.GenericValue(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect %#v but got %#v", expect, got)
+	}
+	if irv.Equals(dataflow.NewIntRegisterValue("device-name", testReg, 42)) {
+		t.Error("expect -42 to NOT be equal to 42")
+	}
+}
+
 func TestNewNullRegisterValue(t *testing.T) {
 	testReg := getTestEnumRegister()
 
@@ -138,3 +194,66 @@ func TestNewNullRegisterValue(t *testing.T) {
 		t.Errorf("expect nil but got %#v", got)
 	}
 }
+
+func TestRegisterValueTimes(t *testing.T) {
+	before := time.Now()
+	nrv := dataflow.NewNumericRegisterValue(
+		"device-name",
+		getTestNumberRegister(),
+		3.14,
+	)
+	after := time.Now()
+
+	if got := nrv.MeasuredAt(); got.Before(before) || got.After(after) {
+		t.Errorf("expect measured at between %s and %s but got %s", before, after, got)
+	}
+	if got := nrv.SourceTime(); !got.IsZero() {
+		t.Errorf("expect zero source time but got %s", got)
+	}
+	if expect, got := nrv.MeasuredAt(), nrv.SampleTime(); !expect.Equal(got) {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+
+	measuredAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	sourceTime := time.Date(2024, 1, 2, 3, 4, 0, 0, time.UTC)
+	withTimes := nrv.WithTimes(measuredAt, sourceTime)
+
+	if expect, got := measuredAt, withTimes.MeasuredAt(); !expect.Equal(got) {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+	if expect, got := sourceTime, withTimes.SourceTime(); !expect.Equal(got) {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+	if expect, got := sourceTime, withTimes.SampleTime(); !expect.Equal(got) {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+	if _, ok := withTimes.(dataflow.NumericRegisterValue); !ok {
+		t.Errorf("expect WithTimes to keep the type but got %#v", withTimes)
+	}
+
+	// timestamps must not be considered when comparing values
+	if !nrv.Equals(withTimes) {
+		t.Errorf("expect %#v to be equal to %#v", withTimes, nrv)
+	}
+}
+
+func TestValueStorageKeepsNewestTime(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	reg := getTestNumberRegister()
+	first := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	second := first.Add(time.Minute)
+
+	storage.Fill(dataflow.NewNumericRegisterValue("device-name", reg, 1).WithTimes(first, time.Time{}))
+	storage.Fill(dataflow.NewNumericRegisterValue("device-name", reg, 1).WithTimes(second, time.Time{}))
+	storage.Wait()
+
+	state := storage.GetState()
+	if expect, got := 1, len(state); expect != got {
+		t.Fatalf("expect %d values but got %d", expect, got)
+	}
+	if expect, got := second, state[0].MeasuredAt(); !expect.Equal(got) {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+}
@@ -2,12 +2,18 @@ package device
 
 import (
 	"context"
+	"fmt"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"sync"
+	"time"
 )
 
 type Config interface {
 	Name() string
 	Filter() dataflow.RegisterFilterConf
+	RegisterMeta() map[string]dataflow.RegisterMeta
+	RegisterTransforms() map[string]dataflow.RegisterTransform
+	Deadband(reg dataflow.Register) (dataflow.Deadband, bool)
 	LogDebug() bool
 	LogComDebug() bool
 }
@@ -24,22 +30,45 @@ type Device interface {
 type State struct {
 	deviceConfig Config
 	stateStorage *dataflow.ValueStorage
+	output       *dataflow.TransformStage
+	deadband     *dataflow.DeadbandStage
 	registerDb   *dataflow.RegisterDb
 
 	unavailableValue dataflow.Value
 	availableValue   dataflow.Value
+
+	pollStats *pollStats
+}
+
+// PollStats summarizes the durations of all successful polls of a device.
+type PollStats struct {
+	Count uint64
+	Sum   time.Duration
+	Last  time.Duration
+}
+
+type pollStats struct {
+	PollStats
+	lock sync.Mutex
 }
 
 func NewState(deviceConfig Config, stateStorage *dataflow.ValueStorage) State {
 	registerDb := dataflow.NewRegisterDb()
+	registerDb.SetTransforms(deviceConfig.RegisterTransforms())
+	registerDb.SetMetaOverrides(deviceConfig.RegisterMeta())
 	registerDb.Add(availabilityRegister)
+	deadband := dataflow.NewDeadbandStage(stateStorage, deviceConfig.Deadband)
 	return State{
 		deviceConfig: deviceConfig,
 		stateStorage: stateStorage,
+		output:       dataflow.NewTransformStage(deadband, deviceConfig.RegisterTransforms()),
+		deadband:     deadband,
 		registerDb:   registerDb,
 
 		unavailableValue: dataflow.NewEnumRegisterValue(deviceConfig.Name(), availabilityRegister, 0),
 		availableValue:   dataflow.NewEnumRegisterValue(deviceConfig.Name(), availabilityRegister, 1),
+
+		pollStats: &pollStats{},
 	}
 }
 
@@ -55,27 +84,96 @@ func (c *State) StateStorage() *dataflow.ValueStorage {
 	return c.stateStorage
 }
 
+// Output is where the drivers fill their values into; it applies the configured register transforms
+// and deadbands before the values are stored in the StateStorage.
+func (c *State) Output() dataflow.Fillable {
+	return c.output
+}
+
 func (c *State) RegisterDb() *dataflow.RegisterDb {
 	return c.registerDb
 }
 
+// ReplaceRegisters sets the given registers as the full set of registers of the device;
+// the availability register is kept. The values of the dropped registers are removed.
+func (c *State) ReplaceRegisters(registers ...dataflow.Register) {
+	keep := make(map[string]struct{}, len(registers))
+	for _, r := range registers {
+		keep[r.Name()] = struct{}{}
+	}
+	var dropped []dataflow.Register
+	for _, r := range c.registerDb.GetAll() {
+		if _, ok := keep[r.Name()]; !ok && r.Name() != AvailabilityRegisterName {
+			dropped = append(dropped, r)
+		}
+	}
+
+	c.registerDb.Replace(append(registers, availabilityRegister)...)
+	c.removeValues(dropped)
+}
+
+// RemoveRegisters removes the given registers and their values.
+func (c *State) RemoveRegisters(registers ...dataflow.Register) {
+	names := make([]string, len(registers))
+	for i, r := range registers {
+		names[i] = r.Name()
+	}
+	c.registerDb.Remove(names...)
+	c.removeValues(registers)
+}
+
+func (c *State) removeValues(registers []dataflow.Register) {
+	for _, r := range registers {
+		c.output.Fill(dataflow.NewNullRegisterValue(c.Name(), r))
+	}
+}
+
+// ObservePoll is called by polling drivers after every successful poll.
+func (c *State) ObservePoll(took time.Duration) {
+	c.pollStats.lock.Lock()
+	defer c.pollStats.lock.Unlock()
+	c.pollStats.Count += 1
+	c.pollStats.Sum += took
+	c.pollStats.Last = took
+}
+
+func (c *State) PollStats() PollStats {
+	c.pollStats.lock.Lock()
+	defer c.pollStats.lock.Unlock()
+	return c.pollStats.PollStats
+}
+
 func (c *State) SetAvailable(v bool) {
+	// create new values to get the current timestamp
 	if v {
-		c.stateStorage.Fill(c.availableValue)
+		c.stateStorage.Fill(dataflow.NewEnumRegisterValue(c.Name(), availabilityRegister, 1))
 	} else {
-		c.stateStorage.Fill(c.unavailableValue)
+		c.stateStorage.Fill(dataflow.NewEnumRegisterValue(c.Name(), availabilityRegister, 0))
+
+		// values of an unavailable device cannot be trusted anymore;
+		// pending values are dropped and the quality change is queued after all values filled before
+		c.deadband.DiscardPending()
+		devName := c.Name()
+		c.stateStorage.SetQualityFiltered(func(value dataflow.Value) bool {
+			return value.DeviceName() == devName && value.Register().Name() != AvailabilityRegisterName
+		}, dataflow.QualityCommError)
 	}
 }
 
 func (c *State) SubscribeAvailableSendInitial(ctx context.Context) <-chan bool {
 	devName := c.Name()
-	initialState, subscription := c.stateStorage.SubscribeReturnInitial(ctx, func(value dataflow.Value) bool {
+	filter := func(value dataflow.Value) bool {
 		if value.DeviceName() != devName {
 			return false
 		}
 		reg := value.Register()
 		return reg.RegisterType() == dataflow.EnumRegister && reg.Name() == AvailabilityRegisterName
-	})
+	}
+	// availChan is unbuffered and its readers might fill the storage themselves
+	initialState, subscription := c.stateStorage.SubscribeReturnInitial(ctx, filter,
+		dataflow.WithName(fmt.Sprintf("device[%s]->availability", devName)),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
 
 	avail, initialOk := c.GetAvailableByState(initialState)
 	availChan := make(chan bool)
@@ -0,0 +1,132 @@
+package device_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"testing"
+	"time"
+)
+
+type testConfig struct{}
+
+func (c testConfig) Name() string                                              { return "dev" }
+func (c testConfig) Filter() dataflow.RegisterFilterConf                       { return nil }
+func (c testConfig) RegisterMeta() map[string]dataflow.RegisterMeta            { return nil }
+func (c testConfig) RegisterTransforms() map[string]dataflow.RegisterTransform { return nil }
+func (c testConfig) Deadband(dataflow.Register) (dataflow.Deadband, bool) {
+	return dataflow.Deadband{}, false
+}
+func (c testConfig) LogDebug() bool    { return false }
+func (c testConfig) LogComDebug() bool { return false }
+
+func TestSubscribeAvailableDoesNotBlockStorage(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+	state := device.NewState(testConfig{}, storage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// nobody reads from the channel until all values are filled
+	availChan := state.SubscribeAvailableSendInitial(ctx)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			state.SetAvailable(i%2 == 0)
+		}
+		state.SetAvailable(true)
+		storage.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("storage is blocked by the availability subscription")
+	}
+
+	// the latest state is received eventually
+	last, received := false, false
+drain:
+	for {
+		select {
+		case avail := <-availChan:
+			last, received = avail, true
+		case <-time.After(200 * time.Millisecond):
+			break drain
+		}
+	}
+	if !received || !last {
+		t.Errorf("expect to receive available=true as the latest state but got received=%t, available=%t", received, last)
+	}
+}
+
+func TestSetUnavailableMarksAllValuesCommError(t *testing.T) {
+	for run := 0; run < 100; run++ {
+		storage := dataflow.NewValueStorage()
+		state := device.NewState(testConfig{}, storage)
+
+		// the values are still queued when the device becomes unavailable
+		for i := 0; i < 64; i++ {
+			reg := dataflow.NewRegisterStruct("c", fmt.Sprintf("Reg%d", i), "", dataflow.NumberRegister, nil, "", i, false)
+			state.Output().Fill(dataflow.NewNumericRegisterValue("dev", reg, float64(i)))
+		}
+		state.SetAvailable(false)
+		storage.Wait()
+
+		values := storage.GetStateFiltered(dataflow.DeviceNameValueFilter("dev"))
+		if expect, got := 65, len(values); expect != got {
+			t.Fatalf("expect %d values but got %d", expect, got)
+		}
+		for _, v := range values {
+			if v.Register().Name() == device.AvailabilityRegisterName {
+				continue
+			}
+			if expect, got := dataflow.QualityCommError, v.Quality(); expect != got {
+				t.Fatalf("expect %s of %s but got %s", expect, v, got)
+			}
+		}
+		storage.Shutdown()
+	}
+}
+
+func TestRemovedRegistersLoseTheirValues(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+	state := device.NewState(testConfig{}, storage)
+
+	regA := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	regB := dataflow.NewRegisterStruct("c", "B", "", dataflow.NumberRegister, nil, "", 1, false)
+	regC := dataflow.NewRegisterStruct("c", "C", "", dataflow.NumberRegister, nil, "", 2, false)
+	state.ReplaceRegisters(regA, regB, regC)
+	for _, reg := range []dataflow.Register{regA, regB, regC} {
+		state.Output().Fill(dataflow.NewNumericRegisterValue("dev", reg, 1))
+	}
+	storage.Wait()
+
+	names := func() map[string]bool {
+		ret := make(map[string]bool)
+		for _, v := range storage.GetStateFiltered(dataflow.DeviceNameValueFilter("dev")) {
+			ret[v.Register().Name()] = true
+		}
+		return ret
+	}
+
+	state.ReplaceRegisters(regA, regB)
+	storage.Wait()
+	if got := names(); !got["A"] || !got["B"] || got["C"] {
+		t.Errorf("expect the values of A and B but got %v", got)
+	}
+
+	state.RemoveRegisters(regB)
+	storage.Wait()
+	if got := names(); !got["A"] || got["B"] {
+		t.Errorf("expect the value of A but got %v", got)
+	}
+	if _, ok := state.RegisterDb().GetByName("B"); ok {
+		t.Error("expect register B to be removed")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"github.com/koestler/go-iotdevice/v3/computedDevice"
 	"github.com/koestler/go-iotdevice/v3/config"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
@@ -13,6 +14,7 @@ import (
 	"github.com/koestler/go-iotdevice/v3/mqttDevice"
 	"github.com/koestler/go-iotdevice/v3/pool"
 	"github.com/koestler/go-iotdevice/v3/restarter"
+	"github.com/koestler/go-iotdevice/v3/systemDevice"
 	"github.com/koestler/go-iotdevice/v3/victronDevice"
 	"log"
 )
@@ -29,63 +31,103 @@ func runNonMqttGensetDevices(
 	commandStorage *dataflow.ValueStorage,
 ) {
 	for _, deviceConfig := range cfg.VictronDevices() {
-		if cfg.LogWorkerStart() {
-			log.Printf("device[%s]: start victron type", deviceConfig.Name())
-		}
-
-		deviceConfig := victronDeviceConfig{deviceConfig}
-		dev := victronDevice.NewDevice(deviceConfig, deviceConfig, stateStorage)
-		watchedDev := restarter.CreateRestarter[device.Device](deviceConfig, dev)
-		watchedDev.Run()
-		devicePool.Add(watchedDev)
+		runVictronDevice(cfg, deviceConfig, devicePool, stateStorage)
 	}
 
 	for _, deviceConfig := range cfg.ModbusDevices() {
-		if cfg.LogWorkerStart() {
-			log.Printf("device[%s]: start modbus type", deviceConfig.Name())
-		}
+		runModbusDevice(cfg, deviceConfig, devicePool, modbusPool, stateStorage, commandStorage)
+	}
 
-		deviceConfig := modbusDeviceConfig{deviceConfig}
-		modbusInstance := modbusPool.GetByName(deviceConfig.Bus())
-		if modbusInstance == nil {
-			log.Printf("device[%s]: start failed: bus=%s unavailable", deviceConfig.Name(), deviceConfig.Bus())
-			continue
-		}
+	for _, deviceConfig := range cfg.GpioDevices() {
+		runGpioDevice(cfg, deviceConfig, devicePool, stateStorage, commandStorage)
+	}
 
-		dev := modbusDevice.NewDevice(deviceConfig, deviceConfig, modbusInstance, stateStorage, commandStorage)
-		watchedDev := restarter.CreateRestarter[device.Device](deviceConfig, dev)
-		watchedDev.Run()
-		devicePool.Add(watchedDev)
+	for _, deviceConfig := range cfg.HttpDevices() {
+		runHttpDevice(cfg, deviceConfig, devicePool, stateStorage, commandStorage)
 	}
+}
 
-	for _, deviceConfig := range cfg.GpioDevices() {
-		if cfg.LogWorkerStart() {
-			log.Printf("device[%s]: start gpio type", deviceConfig.Name())
-		}
+func runVictronDevice(
+	cfg *config.Config,
+	deviceConfig config.VictronDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start victron type", deviceConfig.Name())
+	}
 
-		deviceConfig := gpioDeviceConfig{deviceConfig}
+	dCfg := victronDeviceConfig{deviceConfig}
+	dev := victronDevice.NewDevice(dCfg, dCfg, stateStorage)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
+}
 
-		dev, err := gpioDevice.NewDevice(deviceConfig, deviceConfig, stateStorage, commandStorage)
-		if err != nil {
-			log.Printf("device[%s]: start failed: %s", deviceConfig.Name(), err)
-			continue
-		}
-		watchedDev := restarter.CreateRestarter[device.Device](deviceConfig, dev)
-		watchedDev.Run()
-		devicePool.Add(watchedDev)
+func runModbusDevice(
+	cfg *config.Config,
+	deviceConfig config.ModbusDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	modbusPool *pool.Pool[*modbus.ModbusStruct],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start modbus type", deviceConfig.Name())
 	}
 
-	for _, deviceConfig := range cfg.HttpDevices() {
-		if cfg.LogWorkerStart() {
-			log.Printf("device[%s]: start tearacom type", deviceConfig.Name())
-		}
+	dCfg := modbusDeviceConfig{deviceConfig}
+	modbusInstance := modbusPool.GetByName(dCfg.Bus())
+	if modbusInstance == nil {
+		log.Printf("device[%s]: start failed: bus=%s unavailable", dCfg.Name(), dCfg.Bus())
+		return
+	}
+
+	dev := modbusDevice.NewDevice(dCfg, dCfg, modbusInstance, stateStorage, commandStorage)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
+}
+
+func runGpioDevice(
+	cfg *config.Config,
+	deviceConfig config.GpioDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start gpio type", deviceConfig.Name())
+	}
+
+	dCfg := gpioDeviceConfig{deviceConfig}
 
-		deviceConfig := httpDeviceConfig{deviceConfig}
-		dev := httpDevice.NewDevice(deviceConfig, deviceConfig, stateStorage, commandStorage)
-		watchedDev := restarter.CreateRestarter[device.Device](deviceConfig, dev)
-		watchedDev.Run()
-		devicePool.Add(watchedDev)
+	dev, err := gpioDevice.NewDevice(dCfg, dCfg, stateStorage, commandStorage)
+	if err != nil {
+		log.Printf("device[%s]: start failed: %s", dCfg.Name(), err)
+		return
 	}
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
+}
+
+func runHttpDevice(
+	cfg *config.Config,
+	deviceConfig config.HttpDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start tearacom type", deviceConfig.Name())
+	}
+
+	dCfg := httpDeviceConfig{deviceConfig}
+	dev := httpDevice.NewDevice(dCfg, dCfg, stateStorage, commandStorage)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
 }
 
 func runMqttDevices(
@@ -96,18 +138,92 @@ func runMqttDevices(
 	commandStorage *dataflow.ValueStorage,
 ) {
 	for _, deviceConfig := range cfg.MqttDevices() {
-		if cfg.LogWorkerStart() {
-			log.Printf("device[%s]: start mqtt type", deviceConfig.Name())
-		}
+		runMqttDevice(cfg, deviceConfig, devicePool, mqttClientPool, stateStorage, commandStorage)
+	}
+}
+
+func runMqttDevice(
+	cfg *config.Config,
+	deviceConfig config.MqttDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	mqttClientPool *pool.Pool[mqttClient.Client],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start mqtt type", deviceConfig.Name())
+	}
+
+	dCfg := mqttDeviceConfig{deviceConfig, cfg.MqttClients()}
+	dev := mqttDevice.NewDevice(dCfg, dCfg, stateStorage, commandStorage, mqttClientPool)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
+}
+
+func runSystemDevices(
+	cfg *config.Config,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	mqttClientPool *pool.Pool[mqttClient.Client],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	for _, deviceConfig := range cfg.SystemDevices() {
+		runSystemDevice(cfg, deviceConfig, devicePool, mqttClientPool, stateStorage, commandStorage)
+	}
+}
+
+func runSystemDevice(
+	cfg *config.Config,
+	deviceConfig config.SystemDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	mqttClientPool *pool.Pool[mqttClient.Client],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start system type", deviceConfig.Name())
+	}
+
+	storages := []systemDevice.Storage{
+		{Name: "State", Storage: stateStorage},
+		{Name: "Command", Storage: commandStorage},
+	}
 
-		deviceConfig := mqttDeviceConfig{deviceConfig, cfg.MqttClients()}
-		dev := mqttDevice.NewDevice(deviceConfig, deviceConfig, stateStorage, commandStorage, mqttClientPool)
-		watchedDev := restarter.CreateRestarter[device.Device](deviceConfig, dev)
-		watchedDev.Run()
-		devicePool.Add(watchedDev)
+	dCfg := systemDeviceConfig{deviceConfig}
+	dev := systemDevice.NewDevice(dCfg, dCfg, stateStorage, storages, mqttClientPool)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
+}
+
+func runComputedDevices(
+	cfg *config.Config,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+) {
+	for _, deviceConfig := range cfg.ComputedDevices() {
+		runComputedDevice(cfg, deviceConfig, devicePool, stateStorage)
 	}
 }
 
+func runComputedDevice(
+	cfg *config.Config,
+	deviceConfig config.ComputedDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start computed type", deviceConfig.Name())
+	}
+
+	dCfg := computedDeviceConfig{deviceConfig}
+	dev := computedDevice.NewDevice(dCfg, dCfg, stateStorage)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
+}
+
 func runGensetDevices(
 	cfg *config.Config,
 	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
@@ -115,19 +231,31 @@ func runGensetDevices(
 	commandStorage *dataflow.ValueStorage,
 ) {
 	for _, deviceConfig := range cfg.GensetDevices() {
-		if cfg.LogWorkerStart() {
-			log.Printf("device[%s]: start genset type", deviceConfig.Name())
-		}
+		runGensetDevice(cfg, deviceConfig, devicePool, stateStorage, commandStorage)
+	}
+}
 
-		deviceConfig := gensetDeviceConfig{deviceConfig}
-		dev := gensetDevice.NewDevice(deviceConfig, deviceConfig, stateStorage, commandStorage)
-		watchedDev := restarter.CreateRestarter[device.Device](deviceConfig, dev)
-		watchedDev.Run()
-		devicePool.Add(watchedDev)
+func runGensetDevice(
+	cfg *config.Config,
+	deviceConfig config.GensetDeviceConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf("device[%s]: start genset type", deviceConfig.Name())
 	}
+
+	dCfg := gensetDeviceConfig{deviceConfig}
+	dev := gensetDevice.NewDevice(dCfg, dCfg, stateStorage, commandStorage)
+	watchedDev := restarter.CreateRestarter[device.Device](dCfg, dev)
+	watchedDev.Run()
+	devicePool.Add(watchedDev)
 }
 
-// the following structs / methods are used to cast config.FilterConfig into dataflow.RegisterFilterConf
+// the following structs / methods are used to cast config.FilterConfig into dataflow.RegisterFilterConf,
+// config.RegisterMetaConfig into dataflow.RegisterMeta, config.RegisterTransformConfig into dataflow.RegisterTransform
+// and config.DeadbandConfig into dataflow.Deadband
 
 type victronDeviceConfig struct {
 	config.VictronDeviceConfig
@@ -137,6 +265,18 @@ func (c victronDeviceConfig) Filter() dataflow.RegisterFilterConf {
 	return c.VictronDeviceConfig.Filter()
 }
 
+func (c victronDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.VictronDeviceConfig.RegisterMeta())
+}
+
+func (c victronDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.VictronDeviceConfig.RegisterTransform())
+}
+
+func (c victronDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.VictronDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
 type modbusDeviceConfig struct {
 	config.ModbusDeviceConfig
 }
@@ -145,6 +285,27 @@ func (c modbusDeviceConfig) Filter() dataflow.RegisterFilterConf {
 	return c.ModbusDeviceConfig.Filter()
 }
 
+func (c modbusDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.ModbusDeviceConfig.RegisterMeta())
+}
+
+func (c modbusDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.ModbusDeviceConfig.RegisterTransform())
+}
+
+func (c modbusDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.ModbusDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
+func (c modbusDeviceConfig) Registers() []modbusDevice.Register {
+	inp := c.ModbusDeviceConfig.Registers()
+	oup := make([]modbusDevice.Register, len(inp))
+	for i, r := range inp {
+		oup[i] = r
+	}
+	return oup
+}
+
 type gpioDeviceConfig struct {
 	config.GpioDeviceConfig
 }
@@ -153,6 +314,18 @@ func (c gpioDeviceConfig) Filter() dataflow.RegisterFilterConf {
 	return c.GpioDeviceConfig.Filter()
 }
 
+func (c gpioDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.GpioDeviceConfig.RegisterMeta())
+}
+
+func (c gpioDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.GpioDeviceConfig.RegisterTransform())
+}
+
+func (c gpioDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.GpioDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
 func (c gpioDeviceConfig) Inputs() []gpioDevice.Pin {
 	inp := c.GpioDeviceConfig.Inputs()
 	oup := make([]gpioDevice.Pin, len(inp))
@@ -179,6 +352,18 @@ func (c httpDeviceConfig) Filter() dataflow.RegisterFilterConf {
 	return c.HttpDeviceConfig.Filter()
 }
 
+func (c httpDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.HttpDeviceConfig.RegisterMeta())
+}
+
+func (c httpDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.HttpDeviceConfig.RegisterTransform())
+}
+
+func (c httpDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.HttpDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
 type mqttDeviceConfig struct {
 	config.MqttDeviceConfig
 	mqttClients []config.MqttClientConfig
@@ -188,6 +373,67 @@ func (c mqttDeviceConfig) Filter() dataflow.RegisterFilterConf {
 	return c.MqttDeviceConfig.Filter()
 }
 
+func (c mqttDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.MqttDeviceConfig.RegisterMeta())
+}
+
+func (c mqttDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.MqttDeviceConfig.RegisterTransform())
+}
+
+func (c mqttDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.MqttDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
+type systemDeviceConfig struct {
+	config.SystemDeviceConfig
+}
+
+func (c systemDeviceConfig) Filter() dataflow.RegisterFilterConf {
+	return c.SystemDeviceConfig.Filter()
+}
+
+func (c systemDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.SystemDeviceConfig.RegisterMeta())
+}
+
+func (c systemDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.SystemDeviceConfig.RegisterTransform())
+}
+
+func (c systemDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.SystemDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
+type computedDeviceConfig struct {
+	config.ComputedDeviceConfig
+}
+
+func (c computedDeviceConfig) Filter() dataflow.RegisterFilterConf {
+	return c.ComputedDeviceConfig.Filter()
+}
+
+func (c computedDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.ComputedDeviceConfig.RegisterMeta())
+}
+
+func (c computedDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.ComputedDeviceConfig.RegisterTransform())
+}
+
+func (c computedDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.ComputedDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
+func (c computedDeviceConfig) Registers() []computedDevice.Register {
+	inp := c.ComputedDeviceConfig.Registers()
+	oup := make([]computedDevice.Register, len(inp))
+	for i, r := range inp {
+		oup[i] = r
+	}
+	return oup
+}
+
 type gensetDeviceConfig struct {
 	config.GensetDeviceConfig
 }
@@ -196,6 +442,18 @@ func (c gensetDeviceConfig) Filter() dataflow.RegisterFilterConf {
 	return c.GensetDeviceConfig.Filter()
 }
 
+func (c gensetDeviceConfig) RegisterMeta() map[string]dataflow.RegisterMeta {
+	return convertRegisterMeta(c.GensetDeviceConfig.RegisterMeta())
+}
+
+func (c gensetDeviceConfig) RegisterTransforms() map[string]dataflow.RegisterTransform {
+	return convertRegisterTransforms(c.GensetDeviceConfig.RegisterTransform())
+}
+
+func (c gensetDeviceConfig) Deadband(reg dataflow.Register) (dataflow.Deadband, bool) {
+	return convertDeadband(c.GensetDeviceConfig.Deadband(reg.Name(), reg.Category()))
+}
+
 func (c gensetDeviceConfig) InputBindings() []gensetDevice.Binding {
 	inp := c.GensetDeviceConfig.InputBindings()
 	oup := make([]gensetDevice.Binding, len(inp))
@@ -233,3 +491,42 @@ func (c mqttDeviceConfig) MqttClientTopics() map[string][]string {
 
 	return ret
 }
+
+func convertRegisterMeta(inp map[string]config.RegisterMetaConfig) map[string]dataflow.RegisterMeta {
+	oup := make(map[string]dataflow.RegisterMeta, len(inp))
+	for registerName, m := range inp {
+		oup[registerName] = dataflow.RegisterMeta{
+			Min:         m.Min(),
+			Max:         m.Max(),
+			Step:        m.Step(),
+			Precision:   m.Precision(),
+			DeviceClass: m.DeviceClass(),
+			StateClass:  m.StateClass(),
+		}
+	}
+	return oup
+}
+
+func convertRegisterTransforms(inp map[string]config.RegisterTransformConfig) map[string]dataflow.RegisterTransform {
+	oup := make(map[string]dataflow.RegisterTransform, len(inp))
+	for registerName, t := range inp {
+		oup[registerName] = dataflow.RegisterTransform{
+			Scale:       t.Scale(),
+			Offset:      t.Offset(),
+			Unit:        t.Unit(),
+			Round:       t.Round(),
+			Description: t.Description(),
+			Category:    t.Category(),
+		}
+	}
+	return oup
+}
+
+func convertDeadband(d config.DeadbandConfig, ok bool) (dataflow.Deadband, bool) {
+	return dataflow.Deadband{
+		Absolute:    d.Absolute(),
+		Percent:     d.Percent(),
+		MinInterval: d.MinInterval(),
+		MaxInterval: d.MaxInterval(),
+	}, ok
+}
@@ -0,0 +1,61 @@
+package eventLoop
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"sync"
+)
+
+// Loop handles values and delayed functions, e.g. timer callbacks, by a single routine. Both are run while
+// holding the mutex, hence the state guarded by it can safely be read by other routines as well.
+type Loop struct {
+	ctx    context.Context
+	mutex  *sync.Mutex
+	events chan event
+}
+
+type event struct {
+	f    func()
+	done chan struct{}
+}
+
+func New(ctx context.Context, mutex *sync.Mutex) *Loop {
+	return &Loop{
+		ctx:    ctx,
+		mutex:  mutex,
+		events: make(chan event),
+	}
+}
+
+// Run calls handle for every value and runs the functions passed to Do until the context is done
+// or the values channel is closed.
+func (l *Loop) Run(values <-chan dataflow.Value, handle func(v dataflow.Value)) {
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case v, ok := <-values:
+			if !ok {
+				return
+			}
+			l.mutex.Lock()
+			handle(v)
+			l.mutex.Unlock()
+		case ev := <-l.events:
+			l.mutex.Lock()
+			ev.f()
+			l.mutex.Unlock()
+			close(ev.done)
+		}
+	}
+}
+
+// Do runs f within the loop and waits for it to complete; f is not run once the context is done.
+func (l *Loop) Do(f func()) {
+	ev := event{f: f, done: make(chan struct{})}
+	select {
+	case l.events <- ev:
+		<-ev.done
+	case <-l.ctx.Done():
+	}
+}
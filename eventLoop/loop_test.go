@@ -0,0 +1,50 @@
+package eventLoop_test
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/eventLoop"
+	"sync"
+	"testing"
+)
+
+func TestLoop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var mutex sync.Mutex
+	l := eventLoop.New(ctx, &mutex)
+
+	values := make(chan dataflow.Value)
+	var got []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Run(values, func(v dataflow.Value) {
+			got = append(got, v.String())
+		})
+	}()
+
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	values <- dataflow.NewNumericRegisterValue("dev", reg, 1)
+	l.Do(func() {
+		got = append(got, "do")
+	})
+	values <- dataflow.NewNumericRegisterValue("dev", reg, 2)
+
+	cancel()
+	<-done
+
+	// functions passed after the loop stopped are not run
+	l.Do(func() {
+		t.Error("expect f not to be run after cancel")
+	})
+
+	expect := []string{"A=1.000000", "do", "A=2.000000"}
+	if len(got) != len(expect) {
+		t.Fatalf("expect %v but got %v", expect, got)
+	}
+	for i := range expect {
+		if got[i] != expect[i] {
+			t.Errorf("expect %v but got %v", expect, got)
+		}
+	}
+}
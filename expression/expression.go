@@ -0,0 +1,85 @@
+// Package expression implements the small arithmetic / logical language used by computed devices.
+//
+// Values are float64; booleans are represented as 1 (true) and 0 (false) and every non-zero value is true.
+// Supported are the operators + - * / % ! && || == != < <= > >=, parentheses, the literals true and false,
+// the functions abs, min, max, round, floor, ceil and if(condition, then, else) and references to register
+// values written as device.register. Device names may contain a dash, hence a subtraction directly following
+// a device name must be separated by a space.
+package expression
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Reference points to the value of a register of a device.
+type Reference struct {
+	Device   string
+	Register string
+}
+
+func (r Reference) String() string {
+	return r.Device + "." + r.Register
+}
+
+// LookupFunc returns the current value of the referenced register; ok is false when it is unknown.
+type LookupFunc func(ref Reference) (value float64, ok bool)
+
+var ErrUnknownReference = errors.New("unknown reference")
+var ErrDivisionByZero = errors.New("division by zero")
+
+type Expression struct {
+	src  string
+	root node
+	refs []Reference
+}
+
+// Parse compiles the given source.
+func Parse(src string) (*Expression, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected '%s' at position %d", t.text, t.pos)
+	}
+
+	e := &Expression{src: src, root: root}
+	seen := make(map[Reference]struct{})
+	root.walk(func(n node) {
+		if r, ok := n.(refNode); ok {
+			if _, ok := seen[r.ref]; !ok {
+				seen[r.ref] = struct{}{}
+				e.refs = append(e.refs, r.ref)
+			}
+		}
+	})
+	return e, nil
+}
+
+func (e *Expression) String() string {
+	return e.src
+}
+
+// References returns all registers used by the expression in order of their first appearance.
+func (e *Expression) References() []Reference {
+	return e.refs
+}
+
+// Eval evaluates the expression. It fails when a used reference is unknown or on a division by zero.
+func (e *Expression) Eval(lookup LookupFunc) (float64, error) {
+	return e.root.eval(lookup)
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+package expression_test
+
+import (
+	"errors"
+	"github.com/koestler/go-iotdevice/v3/expression"
+	"reflect"
+	"testing"
+)
+
+var testValues = map[expression.Reference]float64{
+	{Device: "shelly-em3", Register: "Emeter1Power"}: 100,
+	{Device: "shelly-em3", Register: "Emeter2Power"}: 200,
+	{Device: "shelly-em3", Register: "Emeter3Power"}: -50,
+	{Device: "bmv0", Register: "MainVoltage"}:        12.5,
+	{Device: "bmv0", Register: "Current"}:            -4,
+	{Device: "bmv0", Register: "SOC"}:                85,
+}
+
+func testLookup(ref expression.Reference) (float64, bool) {
+	v, ok := testValues[ref]
+	return v, ok
+}
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-2 * -3", 6},
+		{"7 % 4", 3},
+		{"1.5e2", 150},
+		{"shelly-em3.Emeter1Power + shelly-em3.Emeter2Power + shelly-em3.Emeter3Power", 250},
+		{"bmv0.MainVoltage*bmv0.Current", -50},
+		{"bmv0.SOC-80", 5},
+		{"bmv0.SOC > 80 && bmv0.Current < 0", 1},
+		{"bmv0.SOC > 90 || false", 0},
+		{"!(bmv0.SOC >= 85)", 0},
+		{"bmv0.SOC == 85", 1},
+		{"abs(bmv0.Current)", 4},
+		{"max(1, bmv0.SOC, 3)", 85},
+		{"min(1, -2, 3)", -2},
+		{"round(2.5) + floor(1.9) + ceil(1.1)", 6},
+		{"if(bmv0.SOC > 50, 1, 1 / 0)", 1},
+		{"false && unknown.Register", 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.src, func(t *testing.T) {
+			e, err := expression.Parse(tc.src)
+			if err != nil {
+				t.Fatalf("cannot parse: %s", err)
+			}
+			got, err := e.Eval(testLookup)
+			if err != nil {
+				t.Fatalf("cannot eval: %s", err)
+			}
+			if got != tc.expect {
+				t.Errorf("expect %g but got %g", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	tests := []struct {
+		src    string
+		expect error
+	}{
+		{"bmv0.Unknown + 1", expression.ErrUnknownReference},
+		{"1 / (bmv0.SOC - 85)", expression.ErrDivisionByZero},
+	}
+
+	for _, tc := range tests {
+		e, err := expression.Parse(tc.src)
+		if err != nil {
+			t.Fatalf("%s: cannot parse: %s", tc.src, err)
+		}
+		if _, err := e.Eval(testLookup); !errors.Is(err, tc.expect) {
+			t.Errorf("%s: expect error %s but got %v", tc.src, tc.expect, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"1 2",
+		"foo(1)",
+		"abs(1, 2)",
+		"bmv0.",
+		"bmv0",
+		"1 # 2",
+	} {
+		if _, err := expression.Parse(src); err == nil {
+			t.Errorf("expect '%s' to fail", src)
+		}
+	}
+}
+
+func TestReferences(t *testing.T) {
+	e, err := expression.Parse("a.X + b-c.Y * a.X - if(d.Z, 1, 2)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []expression.Reference{
+		{Device: "a", Register: "X"},
+		{Device: "b-c", Register: "Y"},
+		{Device: "d", Register: "Z"},
+	}
+	if got := e.References(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect %v but got %v", expect, got)
+	}
+}
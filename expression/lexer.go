@@ -0,0 +1,109 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenRef
+	tokenOp
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "!", "<", ">"}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func lex(src string) (tokens []token, err error) {
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case isDigit(c) || c == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			start := i
+			for i < len(src) && (isDigit(src[i]) || src[i] == '.') {
+				i++
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{tokenNumber, src[start:i], start})
+		case isLetter(c):
+			start := i
+			for i < len(src) && (isLetter(src[i]) || isDigit(src[i]) || src[i] == '-') {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				// device.register reference; the register name must not contain a dash
+				i++
+				regStart := i
+				for i < len(src) && (isLetter(src[i]) || isDigit(src[i])) {
+					i++
+				}
+				if regStart == i {
+					return nil, fmt.Errorf("missing register name after '%s' at position %d", src[start:regStart], start)
+				}
+				tokens = append(tokens, token{tokenRef, src[start:i], start})
+				continue
+			}
+			// a dash is only allowed within device names, give it back otherwise
+			ident := strings.TrimRight(src[start:i], "-")
+			if dash := strings.IndexByte(ident, '-'); dash >= 0 {
+				ident = ident[:dash]
+			}
+			i = start + len(ident)
+			tokens = append(tokens, token{tokenIdent, ident, start})
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character '%c' at position %d", c, i)
+			}
+			tokens = append(tokens, token{tokenOp, op, i})
+			i += len(op)
+		}
+	}
+	tokens = append(tokens, token{tokenEOF, "end of expression", len(src)})
+	return
+}
@@ -0,0 +1,208 @@
+package expression
+
+import (
+	"fmt"
+	"math"
+)
+
+type node interface {
+	eval(lookup LookupFunc) (float64, error)
+	walk(f func(node))
+}
+
+type constNode struct {
+	value float64
+}
+
+func (n constNode) eval(LookupFunc) (float64, error) {
+	return n.value, nil
+}
+
+func (n constNode) walk(f func(node)) {
+	f(n)
+}
+
+type refNode struct {
+	ref Reference
+}
+
+func (n refNode) eval(lookup LookupFunc) (float64, error) {
+	if v, ok := lookup(n.ref); ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("%w: %s", ErrUnknownReference, n.ref)
+}
+
+func (n refNode) walk(f func(node)) {
+	f(n)
+}
+
+type unaryNode struct {
+	op      string
+	operand node
+}
+
+func (n unaryNode) eval(lookup LookupFunc) (float64, error) {
+	v, err := n.operand.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == "-" {
+		return -v, nil
+	}
+	return boolToFloat(v == 0), nil
+}
+
+func (n unaryNode) walk(f func(node)) {
+	f(n)
+	n.operand.walk(f)
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+func (n binaryNode) eval(lookup LookupFunc) (float64, error) {
+	l, err := n.left.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	// short-circuit logical operators
+	switch n.op {
+	case "&&":
+		if l == 0 {
+			return 0, nil
+		}
+	case "||":
+		if l != 0 {
+			return 1, nil
+		}
+	}
+
+	r, err := n.right.eval(lookup)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return l / r, nil
+	case "%":
+		if r == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return math.Mod(l, r), nil
+	case "&&", "||":
+		return boolToFloat(r != 0), nil
+	case "==":
+		return boolToFloat(l == r), nil
+	case "!=":
+		return boolToFloat(l != r), nil
+	case "<":
+		return boolToFloat(l < r), nil
+	case "<=":
+		return boolToFloat(l <= r), nil
+	case ">":
+		return boolToFloat(l > r), nil
+	case ">=":
+		return boolToFloat(l >= r), nil
+	default:
+		return 0, fmt.Errorf("unknown operator '%s'", n.op)
+	}
+}
+
+func (n binaryNode) walk(f func(node)) {
+	f(n)
+	n.left.walk(f)
+	n.right.walk(f)
+}
+
+type function struct {
+	minArgs int
+	maxArgs int // -1 means unlimited
+	eval    func(lookup LookupFunc, args []node) (float64, error)
+}
+
+func evalArgs(lookup LookupFunc, args []node) ([]float64, error) {
+	ret := make([]float64, len(args))
+	for i, a := range args {
+		v, err := a.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = v
+	}
+	return ret, nil
+}
+
+func unaryFunction(f func(float64) float64) function {
+	return function{1, 1, func(lookup LookupFunc, args []node) (float64, error) {
+		v, err := args[0].eval(lookup)
+		if err != nil {
+			return 0, err
+		}
+		return f(v), nil
+	}}
+}
+
+func foldFunction(f func(a, b float64) float64) function {
+	return function{1, -1, func(lookup LookupFunc, args []node) (float64, error) {
+		values, err := evalArgs(lookup, args)
+		if err != nil {
+			return 0, err
+		}
+		ret := values[0]
+		for _, v := range values[1:] {
+			ret = f(ret, v)
+		}
+		return ret, nil
+	}}
+}
+
+var functions = map[string]function{
+	"abs":   unaryFunction(math.Abs),
+	"round": unaryFunction(math.Round),
+	"floor": unaryFunction(math.Floor),
+	"ceil":  unaryFunction(math.Ceil),
+	"min":   foldFunction(math.Min),
+	"max":   foldFunction(math.Max),
+	// if only evaluates the selected branch
+	"if": {3, 3, func(lookup LookupFunc, args []node) (float64, error) {
+		c, err := args[0].eval(lookup)
+		if err != nil {
+			return 0, err
+		}
+		if c != 0 {
+			return args[1].eval(lookup)
+		}
+		return args[2].eval(lookup)
+	}},
+}
+
+type callNode struct {
+	name string
+	f    function
+	args []node
+}
+
+func (n callNode) eval(lookup LookupFunc) (float64, error) {
+	return n.f.eval(lookup, n.args)
+}
+
+func (n callNode) walk(f func(node)) {
+	f(n)
+	for _, a := range n.args {
+		a.walk(f)
+	}
+}
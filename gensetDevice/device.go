@@ -75,8 +75,9 @@ func NewDevice(
 func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool) {
 	dName := d.Config().Name()
 	ss := d.StateStorage()
+	out := d.Output()
 
-	initialState := genset.Off
+	initialState := restoredStateNode(ss, dName)
 	d.controller = genset.NewController(
 		genset.Params{
 			// Transition params
@@ -127,9 +128,14 @@ func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 		deviceName := b.DeviceName()
 		registerName := b.RegisterName()
 
-		sub := d.StateStorage().SubscribeSendInitial(ctx, func(v dataflow.Value) bool {
+		filter := func(v dataflow.Value) bool {
 			return v.DeviceName() == deviceName && v.Register().Name() == registerName
-		})
+		}
+		// the controller fills its state into the same storage; a blocking subscription could deadlock it
+		sub := d.StateStorage().SubscribeSendInitial(ctx, filter,
+			dataflow.WithName(fmt.Sprintf("gensetDevice[%s]->input[%s]", dName, b.Name())),
+			dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+		)
 
 		setter, err := d.inpSetter(b.Name())
 		if err != nil {
@@ -148,7 +154,7 @@ func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 	{
 		for _, r := range commandRegisters {
 			registerName := r.Name()
-			_, sub := d.commandStorage.SubscribeReturnInitial(ctx, func(v dataflow.Value) bool {
+			initial, sub := d.commandStorage.SubscribeReturnInitial(ctx, func(v dataflow.Value) bool {
 				return v.DeviceName() == dName && v.Register().Name() == registerName
 			})
 
@@ -158,6 +164,12 @@ func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 			}
 
 			go func() {
+				// apply commands sent before the start, e.g. restored from a snapshot
+				for _, v := range initial {
+					log.Printf("gensetDevice[%s]: initial command %v", dName, v)
+					setter(d.controller, v)
+				}
+
 				// routine will return when ctx of the subscription is cancelled
 				for v := range sub.Drain() {
 					log.Printf("gensetDevice[%s]: command %v", dName, v)
@@ -189,21 +201,22 @@ func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 	d.controller.OnStateUpdate = func(s genset.State) {
 		if lastState != s.Node {
 			log.Printf("gensetDevice[%s]: state changed: %s", dName, s.Node)
-			ss.Fill(dataflow.NewEnumRegisterValue(dName, StateRegister, int(s.Node)))
+			lastState = s.Node
 		}
-		ss.Fill(dataflow.NewTextRegisterValue(dName, StateChangedRegister, s.Changed.String()))
+		out.Fill(dataflow.NewEnumRegisterValue(dName, StateRegister, int(s.Node)))
+		out.Fill(dataflow.NewTextRegisterValue(dName, StateChangedRegister, s.Changed.String()))
 	}
 
 	// handle output updates
 	d.controller.OnOutputUpdate = func(o genset.Outputs) {
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, IgnitionRegister, boolToOnOff(o.Ignition)))
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, StarterRegister, boolToOnOff(o.Starter)))
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, FanRegister, boolToOnOff(o.Fan)))
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, PumpRegister, boolToOnOff(o.Pump)))
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, LoadRegister, boolToOnOff(o.Load)))
-		ss.Fill(dataflow.NewNumericRegisterValue(dName, TimeInStateRegister, o.TimeInState.Seconds()))
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, IoCheckRegister, boolToOnOff(o.IoCheck)))
-		ss.Fill(dataflow.NewEnumRegisterValue(dName, OutputCheckRegister, boolToOnOff(o.OutputCheck)))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, IgnitionRegister, o.Ignition))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, StarterRegister, o.Starter))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, FanRegister, o.Fan))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, PumpRegister, o.Pump))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, LoadRegister, o.Load))
+		out.Fill(dataflow.NewNumericRegisterValue(dName, TimeInStateRegister, o.TimeInState.Seconds()))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, IoCheckRegister, o.IoCheck))
+		out.Fill(dataflow.NewBoolRegisterValue(dName, OutputCheckRegister, o.OutputCheck))
 	}
 
 	// start the controller
@@ -216,6 +229,24 @@ func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 	return nil, false
 }
 
+// restoredStateNode returns the state found in the storage, e.g. restored from a snapshot.
+// Only the latched Error and Reset states are restored, all other states are recomputed from Off
+// since the timers and outputs of a running sequence were interrupted.
+func restoredStateNode(ss *dataflow.ValueStorage, dName string) genset.StateNode {
+	values := ss.GetStateFiltered(func(v dataflow.Value) bool {
+		return v.DeviceName() == dName && v.Register().Name() == StateRegister.Name()
+	})
+	for _, v := range values {
+		if ev, ok := v.(dataflow.EnumRegisterValue); ok {
+			if n := genset.StateNode(ev.EnumIdx()); n == genset.Error || n == genset.Reset {
+				log.Printf("gensetDevice[%s]: restored state: %s", dName, n)
+				return n
+			}
+		}
+	}
+	return genset.Off
+}
+
 func (d *DeviceStruct) Model() string {
 	return "Genset Controller"
 }
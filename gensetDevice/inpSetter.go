@@ -10,22 +10,30 @@ import (
 
 var ErrUnknownInputName = errors.New("unknown input name")
 
-func (d *DeviceStruct) enumSetter(
+// boolSetter accepts bool values as well as enum values of bound inputs; any enum index but 0 is considered true.
+func (d *DeviceStruct) boolSetter(
 	name string,
 	reg dataflow.RegisterStruct,
 	f func(bool) func(genset.Inputs) genset.Inputs,
 ) func(*genset.Controller, dataflow.Value) {
 	return func(c *genset.Controller, v dataflow.Value) {
-		if ev, ok := v.(dataflow.EnumRegisterValue); ok {
-			c.UpdateInputs(f(ev.EnumIdx() != 0))
-			d.StateStorage().Fill(dataflow.NewEnumRegisterValue(
-				d.Config().Name(),
-				reg,
-				ev.EnumIdx(),
-			))
-		} else {
-			log.Printf("gensetDevice: %s: expected an enum, got %s", name, v.Register().RegisterType())
+		var b bool
+		switch bv := v.(type) {
+		case dataflow.BoolRegisterValue:
+			b = bv.Value()
+		case dataflow.EnumRegisterValue:
+			b = bv.EnumIdx() != 0
+		default:
+			log.Printf("gensetDevice: %s: expected a bool, got %s", name, v.Register().RegisterType())
+			return
 		}
+
+		c.UpdateInputs(f(b))
+		d.Output().Fill(dataflow.NewBoolRegisterValue(
+			d.Config().Name(),
+			reg,
+			b,
+		))
 	}
 }
 
@@ -37,7 +45,7 @@ func (d *DeviceStruct) numberSetter(
 	return func(c *genset.Controller, v dataflow.Value) {
 		if nv, ok := v.(dataflow.NumericRegisterValue); ok {
 			c.UpdateInputs(f(nv.Value()))
-			d.StateStorage().Fill(dataflow.NewNumericRegisterValue(
+			d.Output().Fill(dataflow.NewNumericRegisterValue(
 				d.Config().Name(),
 				reg,
 				nv.Value(),
@@ -51,7 +59,7 @@ func (d *DeviceStruct) numberSetter(
 func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow.Value), error) {
 	switch name {
 	case "ArmSwitch":
-		return d.enumSetter(name, ArmSwitchRegister,
+		return d.boolSetter(name, ArmSwitchRegister,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.ArmSwitch = v
@@ -59,7 +67,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "ArmSwitchRO":
-		return d.enumSetter(name, ArmSwitchRegisterRO,
+		return d.boolSetter(name, ArmSwitchRegisterRO,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.ArmSwitch = v
@@ -67,7 +75,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "CommandSwitch":
-		return d.enumSetter(name, CommandSwitchRegister,
+		return d.boolSetter(name, CommandSwitchRegister,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.CommandSwitch = v
@@ -75,7 +83,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "CommandSwitchRO":
-		return d.enumSetter(name, CommandSwitchRegisterRO,
+		return d.boolSetter(name, CommandSwitchRegisterRO,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.CommandSwitch = v
@@ -83,7 +91,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "ResetSwitch":
-		return d.enumSetter(name, ResetSwitchRegister,
+		return d.boolSetter(name, ResetSwitchRegister,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.ResetSwitch = v
@@ -91,7 +99,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "ResetSwitchRO":
-		return d.enumSetter(name, ResetSwitchRegisterRO,
+		return d.boolSetter(name, ResetSwitchRegisterRO,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.ResetSwitch = v
@@ -99,7 +107,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "IOAvailable":
-		return d.enumSetter(name, IOAvailableRegister,
+		return d.boolSetter(name, IOAvailableRegister,
 			func(v bool) func(genset.Inputs) genset.Inputs {
 				return func(i genset.Inputs) genset.Inputs {
 					i.IOAvailable = v
@@ -107,7 +115,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 				}
 			}), nil
 	case "FireDetected":
-		return d.enumSetter(name, FireDetectedRegister, func(v bool) func(genset.Inputs) genset.Inputs {
+		return d.boolSetter(name, FireDetectedRegister, func(v bool) func(genset.Inputs) genset.Inputs {
 			return func(i genset.Inputs) genset.Inputs {
 				i.FireDetected = v
 				return i
@@ -135,7 +143,7 @@ func (d *DeviceStruct) inpSetter(name string) (func(*genset.Controller, dataflow
 			}
 		}), nil
 	case "OutputAvailable":
-		return d.enumSetter(name, OutputAvailableRegister, func(v bool) func(genset.Inputs) genset.Inputs {
+		return d.boolSetter(name, OutputAvailableRegister, func(v bool) func(genset.Inputs) genset.Inputs {
 			return func(i genset.Inputs) genset.Inputs {
 				i.OutputAvailable = v
 				return i
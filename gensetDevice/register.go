@@ -71,7 +71,7 @@ func NewOnOffRegister(
 ) dataflow.RegisterStruct {
 	return dataflow.NewRegisterStruct(
 		category, name, description,
-		dataflow.EnumRegister, OnOffEnum, "", sort, writable,
+		dataflow.BoolRegister, OnOffEnum, "", sort, writable,
 	)
 }
 
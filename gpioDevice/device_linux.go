@@ -96,7 +96,8 @@ func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 
 	if len(oupRegisters) > 0 {
 		// setup subscription to listen for updates of writable registers
-		_, commandSubscription := d.commandStorage.SubscribeReturnInitial(ctx, dataflow.DeviceNonNullValueFilter(dName))
+		// commands sent before the start, e.g. restored from a snapshot, are executed first
+		commandSubscription := d.commandStorage.SubscribeSendInitial(ctx, dataflow.DeviceNonNullValueFilter(dName))
 
 		for {
 			select {
@@ -180,7 +181,7 @@ func (d *DeviceStruct) setupInputs(chip *gpiocdev.Chip, regMap map[string]GpioRe
 			continue
 		}
 
-		d.StateStorage().Fill(dataflow.NewEnumRegisterValue(d.Name(), reg, v))
+		d.Output().Fill(dataflow.NewBoolRegisterValue(d.Name(), reg, v == 1))
 	}
 
 	// do not close lines, the caller should do this
@@ -209,7 +210,7 @@ func (d *DeviceStruct) eventHandler(regList []GpioRegister) func(e gpiocdev.Line
 			log.Printf("gpioDevice[%s]: set input: register %s, value=%v", d.Name(), reg, v)
 		}
 
-		d.StateStorage().Fill(dataflow.NewEnumRegisterValue(d.Name(), reg, v))
+		d.Output().Fill(dataflow.NewBoolRegisterValue(d.Name(), reg, v == 1))
 	}
 }
 
@@ -248,7 +249,7 @@ func (d *DeviceStruct) setupOutputs(chip *gpiocdev.Chip, regMap map[string]GpioR
 			continue
 		}
 
-		d.StateStorage().Fill(dataflow.NewEnumRegisterValue(d.Name(), reg, v))
+		d.Output().Fill(dataflow.NewBoolRegisterValue(d.Name(), reg, v == 1))
 	}
 
 	// configure as output, set the initial values, and additional options
@@ -295,17 +296,13 @@ func (d *DeviceStruct) execCommand(chip *gpiocdev.Chip, oupRegisters map[string]
 		return
 	}
 
-	enumValue, ok := value.(dataflow.EnumRegisterValue)
+	boolValue, ok := value.(dataflow.BoolRegisterValue)
 	if !ok {
-		// ignore non enum values
+		// ignore non bool values
 		return
 	}
 
-	v := enumValue.EnumIdx()
-	if !isValidValue(v) {
-		log.Printf("gpioDevice[%s]: invalid value %d for register %s", dName, v, reg)
-		return
-	}
+	v := boolToValue(boolValue.Value())
 
 	if d.Config().LogDebug() {
 		log.Printf("gpioDevice[%s]: write register %s, value=%d", dName, reg, v)
@@ -329,10 +326,10 @@ func (d *DeviceStruct) execCommand(chip *gpiocdev.Chip, oupRegisters map[string]
 	}
 
 	// set the current state immediately after a successful write
-	d.StateStorage().Fill(dataflow.NewEnumRegisterValue(
+	d.Output().Fill(dataflow.NewBoolRegisterValue(
 		dName,
 		value.Register(),
-		enumValue.EnumIdx(),
+		boolValue.Value(),
 	))
 
 	// reset the command; this allows the same command (e.g. toggle) to be sent again
@@ -40,7 +40,7 @@ func pinToRegister(chip *gpiocdev.Chip, b Pin, category string, sort int, writab
 	r = GpioRegister{
 		RegisterStruct: dataflow.NewRegisterStruct(
 			category, b.Name(), b.Description(),
-			dataflow.EnumRegister,
+			dataflow.BoolRegister,
 			map[int]string{
 				0: b.LowLabel(),
 				1: b.HighLabel(),
@@ -58,6 +58,13 @@ func isValidValue(value int) bool {
 	return value == 0 || value == 1
 }
 
+func boolToValue(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 func addToRegisterDb(rdb *dataflow.RegisterDb, registers map[string]GpioRegister) {
 	dataflowRegisters := make([]dataflow.RegisterStruct, 0, len(registers))
 	for _, r := range registers {
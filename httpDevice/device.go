@@ -33,6 +33,7 @@ type DeviceStruct struct {
 	impl        Implementation
 
 	sort map[string]int
+	seen map[string]struct{} // key: register name; the registers sent by the device during the current poll
 }
 
 func NewDevice(
@@ -57,6 +58,7 @@ func NewDevice(
 		},
 
 		sort: make(map[string]int),
+		seen: make(map[string]struct{}),
 	}
 
 	// setup impl
@@ -76,9 +78,11 @@ func (ds *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool
 	}
 
 	execPoll := func() error {
+		start := time.Now()
 		if err := ds.poll(); err != nil {
 			return fmt.Errorf("httpDevice[%s]: error: %s", ds.Name(), err)
 		} else {
+			ds.ObservePoll(time.Since(start))
 			if ds.Config().LogDebug() {
 				log.Printf("httpDevice[%s]: poll request successful", ds.Config().Name())
 			}
@@ -96,7 +100,8 @@ func (ds *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool
 	}()
 
 	// setup subscription to listen for updates of writable registers
-	_, commandSubscription := ds.commandStorage.SubscribeReturnInitial(ctx, dataflow.DeviceNonNullValueFilter(ds.Config().Name()))
+	// commands sent before the start, e.g. restored from a snapshot, are executed first
+	commandSubscription := ds.commandStorage.SubscribeSendInitial(ctx, dataflow.DeviceNonNullValueFilter(ds.Config().Name()))
 
 	execCommand := func(value dataflow.Value) {
 		if ds.Config().LogDebug() {
@@ -204,25 +209,52 @@ func (ds *DeviceStruct) poll() error {
 		return fmt.Errorf("GET %s failed with code: %d", ds.pollRequest.URL.String(), resp.StatusCode)
 	}
 
+	ds.seen = make(map[string]struct{})
 	if err := ds.impl.HandleResponse(body); err != nil {
 		return err
 	}
+	ds.removeUnseenRegisters()
 
 	return nil
 }
 
+// removeUnseenRegisters removes the registers and values no longer sent by the device, e.g. after it was reconfigured.
+func (ds *DeviceStruct) removeUnseenRegisters() {
+	var remove []dataflow.Register
+	var names []string
+	for _, r := range ds.RegisterDb().GetAll() {
+		name := r.Name()
+		if _, ok := ds.seen[name]; !ok && name != device.AvailabilityRegisterName {
+			remove = append(remove, r)
+			names = append(names, name)
+		}
+	}
+	if len(remove) < 1 {
+		return
+	}
+
+	if ds.Config().LogDebug() {
+		log.Printf("httpDevice[%s]: remove registers: %v", ds.Name(), names)
+	}
+	ds.RemoveRegisters(remove...)
+}
+
 func (ds *DeviceStruct) addIgnoreRegister(
 	category, registerName, description, unit string,
 	registerType dataflow.RegisterType,
 	enum map[int]string,
 	writable bool,
+	meta dataflow.RegisterMeta,
 ) dataflow.Register {
+	ds.seen[registerName] = struct{}{}
+
 	// check if this register exists already and the properties are still the same
 	if r, ok := ds.RegisterDb().GetByName(registerName); ok {
 		if r.Category() == category &&
 			r.Description() == description &&
 			r.RegisterType() == registerType &&
-			r.Unit() == unit {
+			r.Unit() == unit &&
+			r.Meta().Equals(meta) {
 			return r
 		}
 	}
@@ -238,7 +270,7 @@ func (ds *DeviceStruct) addIgnoreRegister(
 		unit,
 		sort,
 		writable,
-	)
+	).WithMeta(meta)
 
 	// check if register is on ignore list
 	if !ds.registerFilter(r) {
@@ -103,22 +103,28 @@ func (c *ShellyEm3Device) text(category, registerName, description, value string
 	}
 
 	register := c.ds.addIgnoreRegister(
-		category, registerName, description, "", dataflow.TextRegister, nil, false,
+		category, registerName, description, "", dataflow.TextRegister, nil, false, dataflow.RegisterMeta{},
 	)
 	if register == nil {
 		return
 	}
-	c.ds.StateStorage().Fill(dataflow.NewTextRegisterValue(c.ds.Name(), register, value))
+	c.ds.Output().Fill(dataflow.NewTextRegisterValue(c.ds.Name(), register, value))
 }
 
 func (c *ShellyEm3Device) number(category, registerName, description, unit string, value float64) {
+	c.numberWithMeta(category, registerName, description, unit, value, dataflow.MetaByUnit(unit))
+}
+
+func (c *ShellyEm3Device) numberWithMeta(
+	category, registerName, description, unit string, value float64, meta dataflow.RegisterMeta,
+) {
 	register := c.ds.addIgnoreRegister(
-		category, registerName, description, unit, dataflow.NumberRegister, nil, false,
+		category, registerName, description, unit, dataflow.NumberRegister, nil, false, meta,
 	)
 	if register == nil {
 		return
 	}
-	c.ds.StateStorage().Fill(dataflow.NewNumericRegisterValue(c.ds.Name(), register, value))
+	c.ds.Output().Fill(dataflow.NewNumericRegisterValue(c.ds.Name(), register, value))
 }
 
 func (c *ShellyEm3Device) boolean(category, registerName, description string, value bool) {
@@ -130,6 +136,7 @@ func (c *ShellyEm3Device) boolean(category, registerName, description string, va
 			1: "true",
 		},
 		false,
+		dataflow.RegisterMeta{},
 	)
 	if register == nil {
 		return
@@ -139,7 +146,7 @@ func (c *ShellyEm3Device) boolean(category, registerName, description string, va
 	if value {
 		intValue = 1
 	}
-	c.ds.StateStorage().Fill(dataflow.NewEnumRegisterValue(c.ds.Name(), register, intValue))
+	c.ds.Output().Fill(dataflow.NewEnumRegisterValue(c.ds.Name(), register, intValue))
 }
 
 func (c *ShellyEm3Device) extractRegistersAndValues(s ShellyEm3StatusStruct) {
@@ -153,7 +160,9 @@ func (c *ShellyEm3Device) extractRegistersAndValues(s ShellyEm3StatusStruct) {
 			regName := fmt.Sprintf("Emeter%d", idx+1)
 			desc := fmt.Sprintf("P%d", idx+1)
 			c.number("Essential", regName+"Power", desc+" Power", "W", e.Power)
-			c.number(cat, regName+"Pf", desc+" Power Factor", "", e.Pf)
+			c.numberWithMeta(cat, regName+"Pf", desc+" Power Factor", "", e.Pf,
+				dataflow.RegisterMeta{DeviceClass: "power_factor", StateClass: dataflow.StateClassMeasurement}.WithRange(-1, 1),
+			)
 			c.number(cat, regName+"Current", desc+" Current", "A", e.Current)
 			c.number("Essential", regName+"Voltage", desc+" Voltage", "V", e.Voltage)
 			c.boolean(cat, regName+"IsValid", desc+" Is Valid", e.IsValid)
@@ -206,6 +215,8 @@ func (c *ShellyEm3Device) extractRegistersAndValues(s ShellyEm3StatusStruct) {
 		c.boolean(cat, "HasUpdate", "Has update", s.HasUpdate)
 		c.text(cat, "Mac", "MAC", s.Mac)
 		c.number(cat, "CfgChangedCnt", "Configuration changed counter", "", float64(s.CfgChangedCnt))
-		c.number(cat, "Uptime", "Uptime", "s", float64(s.Uptime))
+		c.numberWithMeta(cat, "Uptime", "Uptime", "s", float64(s.Uptime),
+			dataflow.MetaByUnit("s").WithStateClass(dataflow.StateClassTotalIncreasing),
+		)
 	}
 }
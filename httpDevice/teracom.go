@@ -59,27 +59,38 @@ func (c *TeracomDevice) GetCategorySort(category string) int {
 }
 
 func (c *TeracomDevice) CommandValueRequest(value dataflow.Value) (*http.Request, OnCommandSuccess, error) {
-	if value.Register().RegisterType() != dataflow.EnumRegister {
-		return nil, nil, fmt.Errorf("only enum implemented")
-	}
-
-	enum := value.(dataflow.EnumRegisterValue)
-
-	// control relays
+	// control relays; the pulse registers trigger a pulse when set to true
+	relayName, pulse := strings.CutSuffix(value.Register().Name(), "Pulse")
 	var cmd string
-	switch v := enum.Value(); v {
-	case "ON":
-		cmd = "ron"
-	case "OFF":
-		cmd = "rof"
-	case "in pulse":
+	switch v := value.(type) {
+	case dataflow.EnumRegisterValue:
+		if pulse {
+			return nil, nil, fmt.Errorf("the pulse register only accepts bool values")
+		}
+		switch v.Value() {
+		case "ON":
+			cmd = "ron"
+		case "OFF":
+			cmd = "rof"
+		case "in pulse":
+			cmd = "rpl"
+		default:
+			return nil, nil, fmt.Errorf("unsupported relay value=%s", v.Value())
+		}
+	case dataflow.BoolRegisterValue:
+		if !pulse {
+			return nil, nil, fmt.Errorf("only enum implemented for relays")
+		}
+		if !v.Value() {
+			return nil, nil, fmt.Errorf("a pulse can only be started")
+		}
 		cmd = "rpl"
 	default:
-		return nil, nil, fmt.Errorf("unsupported value=%s", v)
+		return nil, nil, fmt.Errorf("only enum and bool implemented")
 	}
 
 	var param string
-	switch name := value.Register().Name(); name {
+	switch relayName {
 	case "R1":
 		param = "1"
 	case "R2":
@@ -89,14 +100,16 @@ func (c *TeracomDevice) CommandValueRequest(value dataflow.Value) (*http.Request
 	case "R4":
 		param = "8"
 	default:
-		return nil, nil, fmt.Errorf("unsupported register Name=%s", name)
+		return nil, nil, fmt.Errorf("unsupported register Name=%s", value.Register().Name())
 	}
 
 	values := url.Values{}
 	values.Set(cmd, param)
 
 	onSuccess := func() {
-		c.relay(value.Register().Name(), value.Register().Description(), enum.Value(), value.Register().Writable())
+		if enumValue, ok := value.(dataflow.EnumRegisterValue); ok {
+			c.relay(relayName, value.Register().Description(), enumValue.Value(), value.Register().Writable())
+		}
 	}
 
 	req, err := http.NewRequest("POST", "/monitor/monitor.htm", strings.NewReader(values.Encode()))
@@ -213,15 +226,15 @@ func (c *TeracomDevice) text(category, registerName, description, value string)
 	}
 
 	register := c.ds.addIgnoreRegister(
-		category, registerName, description, "", dataflow.TextRegister, nil, false,
+		category, registerName, description, "", dataflow.TextRegister, nil, false, dataflow.RegisterMeta{},
 	)
 	if register == nil {
 		return
 	}
-	c.ds.StateStorage().Fill(dataflow.NewTextRegisterValue(c.ds.Name(), register, value))
+	c.ds.Output().Fill(dataflow.NewTextRegisterValue(c.ds.Name(), register, value))
 }
 
-func (c *TeracomDevice) number(category, registerName, description, unit string, value string) {
+func (c *TeracomDevice) number(category, registerName, description, unit string, value string, meta dataflow.RegisterMeta) {
 	if value == "---" {
 		// this is teracom's way of encoding null
 		return
@@ -233,25 +246,38 @@ func (c *TeracomDevice) number(category, registerName, description, unit string,
 	}
 
 	register := c.ds.addIgnoreRegister(
-		category, registerName, description, unit, dataflow.NumberRegister, nil, false,
+		category, registerName, description, unit, dataflow.NumberRegister, nil, false, meta,
+	)
+	if register == nil {
+		return
+	}
+	c.ds.Output().Fill(dataflow.NewNumericRegisterValue(c.ds.Name(), register, floatValue))
+}
+
+func (c *TeracomDevice) boolean(
+	category, registerName, description string, labels map[int]string, value bool, writable bool,
+) {
+	register := c.ds.addIgnoreRegister(
+		category, registerName, description, "", dataflow.BoolRegister, labels, writable, dataflow.RegisterMeta{},
 	)
 	if register == nil {
 		return
 	}
-	c.ds.StateStorage().Fill(dataflow.NewNumericRegisterValue(c.ds.Name(), register, floatValue))
+
+	c.ds.Output().Fill(dataflow.NewBoolRegisterValue(c.ds.Name(), register, value))
 }
 
 func (c *TeracomDevice) enum(
 	category, registerName, description string, enum map[int]string, strValue string, writable bool,
 ) {
 	register := c.ds.addIgnoreRegister(
-		category, registerName, description, "", dataflow.EnumRegister, enum, writable,
+		category, registerName, description, "", dataflow.EnumRegister, enum, writable, dataflow.RegisterMeta{},
 	)
 	if register == nil {
 		return
 	}
 
-	enumIdx := func(string) int {
+	enumIdx := func(strValue string) int {
 		for idx, v := range enum {
 			if v == strValue {
 				return idx
@@ -260,7 +286,7 @@ func (c *TeracomDevice) enum(
 		return -1
 	}(strValue)
 
-	c.ds.StateStorage().Fill(dataflow.NewEnumRegisterValue(c.ds.Name(), register, enumIdx))
+	c.ds.Output().Fill(dataflow.NewEnumRegisterValue(c.ds.Name(), register, enumIdx))
 }
 
 func (c *TeracomDevice) relay(
@@ -277,6 +303,19 @@ func (c *TeracomDevice) relay(
 	)
 }
 
+// relayPulse adds a command only register used to start a pulse of the given relay
+func (c *TeracomDevice) relayPulse(registerName, description string) {
+	c.ds.addIgnoreRegister(
+		"Relays", registerName+"Pulse", description+" pulse", "", dataflow.BoolRegister,
+		map[int]string{
+			0: "idle",
+			1: "in pulse",
+		},
+		true,
+		dataflow.RegisterMeta{},
+	)
+}
+
 func (c *TeracomDevice) alarm(category, registerName, description string, strValue string) {
 	enum := map[int]string{
 		0: "OK",
@@ -284,7 +323,7 @@ func (c *TeracomDevice) alarm(category, registerName, description string, strVal
 	}
 
 	register := c.ds.addIgnoreRegister(
-		category, registerName, description, "", dataflow.EnumRegister, enum, false,
+		category, registerName, description, "", dataflow.EnumRegister, enum, false, dataflow.RegisterMeta{},
 	)
 	if register == nil {
 		return
@@ -295,7 +334,20 @@ func (c *TeracomDevice) alarm(category, registerName, description string, strVal
 		enumIdx = 1
 	}
 
-	c.ds.StateStorage().Fill(dataflow.NewEnumRegisterValue(c.ds.Name(), register, enumIdx))
+	c.ds.Output().Fill(dataflow.NewEnumRegisterValue(c.ds.Name(), register, enumIdx))
+}
+
+// measurementMeta derives the device class from the unit and the precision from the number of decimals sent;
+// teracom formats each value with a fixed number of decimals.
+func measurementMeta(unit, value string) dataflow.RegisterMeta {
+	m := dataflow.MetaByUnit(unit)
+	if unit == "%RH" {
+		m = m.WithDeviceClass("humidity").WithStateClass(dataflow.StateClassMeasurement)
+	}
+	if i := strings.IndexByte(value, '.'); i >= 0 {
+		m = m.WithPrecision(len(value) - i - 1)
+	}
+	return m
 }
 
 func (c *TeracomDevice) extractRegistersAndValues(s teracomStatusStruct) {
@@ -331,12 +383,12 @@ func (c *TeracomDevice) extractRegistersAndValues(s teracomStatusStruct) {
 			regName := fmt.Sprintf("S%dV%d", sIdx, vIdx)
 			desc := s.Description
 
-			c.number("Sensors", regName, desc, i.Unit, i.Value)
+			c.number("Sensors", regName, desc, i.Unit, i.Value, measurementMeta(i.Unit, i.Value))
 			c.alarm("Alarms", regName+"Alarm", desc, i.Alarm)
 
-			c.number("Settings", regName+"Min", desc+" Min", i.Unit, i.Min)
-			c.number("Settings", regName+"Max", desc+" Max", i.Unit, i.Max)
-			c.number("Settings", regName+"Hys", desc+" Hysteresis", i.Unit, i.Hys)
+			c.number("Settings", regName+"Min", desc+" Min", i.Unit, i.Min, dataflow.RegisterMeta{})
+			c.number("Settings", regName+"Max", desc+" Max", i.Unit, i.Max, dataflow.RegisterMeta{})
+			c.number("Settings", regName+"Hys", desc+" Hysteresis", i.Unit, i.Hys, dataflow.RegisterMeta{})
 		}
 
 		item(sIdx, 1, s, s.Item1)
@@ -363,14 +415,14 @@ func (c *TeracomDevice) extractRegistersAndValues(s teracomStatusStruct) {
 		regName := fmt.Sprintf("%s%d", regNamePrefix, sIdx)
 		desc := a.Description
 
-		c.number(valueCat, regName, desc, a.Unit, a.Value)
+		c.number(valueCat, regName, desc, a.Unit, a.Value, measurementMeta(a.Unit, a.Value))
 		c.alarm("Alarms", regName+"Alarm", desc, a.Alarm)
 
-		c.number("Settings", regName+"Min", desc+" Min", a.Unit, a.Min)
-		c.number("Settings", regName+"Max", desc+" Max", a.Unit, a.Max)
-		c.number("Settings", regName+"Hys", desc+" Hysteresis", a.Unit, a.Hys)
-		c.number("Settings", regName+"Offset", desc+" Offset", a.Unit, a.Offset)
-		c.number("Settings", regName+"Multiplier", desc+" Multiplier", a.Unit, a.Multiplier)
+		c.number("Settings", regName+"Min", desc+" Min", a.Unit, a.Min, dataflow.RegisterMeta{})
+		c.number("Settings", regName+"Max", desc+" Max", a.Unit, a.Max, dataflow.RegisterMeta{})
+		c.number("Settings", regName+"Hys", desc+" Hysteresis", a.Unit, a.Hys, dataflow.RegisterMeta{})
+		c.number("Settings", regName+"Offset", desc+" Offset", a.Unit, a.Offset, dataflow.RegisterMeta{})
+		c.number("Settings", regName+"Multiplier", desc+" Multiplier", a.Unit, a.Multiplier, dataflow.RegisterMeta{})
 	}
 	analog("AI", "Analog Inputs", 1, m.AI.AI1)
 	analog("AI", "Analog Inputs", 2, m.AI.AI2)
@@ -388,12 +440,12 @@ func (c *TeracomDevice) extractRegistersAndValues(s teracomStatusStruct) {
 		regName := fmt.Sprintf("DI%d", sIdx)
 		desc := a.Description
 
-		c.enum("Digital Inputs", regName, desc,
+		c.boolean("Digital Inputs", regName, desc,
 			map[int]string{
 				0: "OPEN",
 				1: "CLOSED",
 			},
-			a.Value,
+			a.Value == "CLOSED",
 			false,
 		)
 		c.alarm("Alarms", regName+"Alarm", desc, a.Alarm)
@@ -410,7 +462,9 @@ func (c *TeracomDevice) extractRegistersAndValues(s teracomStatusStruct) {
 
 		writable := r.Control == "0"
 		c.relay(regName, desc, r.Value, writable)
-		if !writable {
+		if writable {
+			c.relayPulse(regName, desc)
+		} else {
 			c.text("Relays", regName+"Control", desc+" is controlled by", r.Control)
 		}
 	}
@@ -1,20 +1,29 @@
 package main
 
 import (
+	"github.com/koestler/go-iotdevice/v3/alarms"
 	"github.com/koestler/go-iotdevice/v3/config"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
 	"github.com/koestler/go-iotdevice/v3/httpServer"
+	"github.com/koestler/go-iotdevice/v3/mqttClient"
 	"github.com/koestler/go-iotdevice/v3/pool"
 	"github.com/koestler/go-iotdevice/v3/restarter"
+	"github.com/koestler/go-iotdevice/v3/rules"
+	"github.com/koestler/go-iotdevice/v3/tsdb"
 	"log"
 )
 
 func runHttpServer(
 	cfg *config.Config,
 	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	mqttClientPool *pool.Pool[mqttClient.Client],
 	stateStorage *dataflow.ValueStorage,
 	commandStorage *dataflow.ValueStorage,
+	archive *tsdb.Store,
+	rulesEngine *rules.Engine,
+	alarmEngine *alarms.Engine,
+	reload func() (httpServer.ReloadReport, []error),
 ) *httpServer.HttpServer {
 	httpServerCfg := cfg.HttpServer()
 	if !httpServerCfg.Enabled() {
@@ -31,22 +40,29 @@ func runHttpServer(
 				cfg.HttpServer(),
 				cfg.LogConfig(),
 			},
-			ProjectTitle: cfg.ProjectTitle(),
-			Views: func(inp []config.ViewConfig) (oup []httpServer.ViewConfig) {
-				oup = make([]httpServer.ViewConfig, len(inp))
-				for i, r := range inp {
-					oup[i] = viewConfig{r}
-				}
-				return oup
-			}(cfg.Views()),
+			ProjectTitle:   cfg.ProjectTitle(),
+			Views:          convertViews(cfg.Views()),
 			Authentication: cfg.Authentication(),
 			DevicePool:     devicePool,
 			StateStorage:   stateStorage,
 			CommandStorage: commandStorage,
+			Archive:        archive,
+			Rules:          rulesEngine,
+			Alarms:         alarmEngine,
+			MqttClientPool: mqttClientPool,
+			Reload:         reload,
 		},
 	)
 }
 
+func convertViews(inp []config.ViewConfig) (oup []httpServer.ViewConfig) {
+	oup = make([]httpServer.ViewConfig, len(inp))
+	for i, r := range inp {
+		oup[i] = viewConfig{r}
+	}
+	return oup
+}
+
 type httpServerConfig struct {
 	config.HttpServerConfig
 	logConfig bool
@@ -60,6 +76,18 @@ func (c httpServerConfig) BuildVersion() string {
 	return buildVersion
 }
 
+func (c httpServerConfig) Metrics() httpServer.MetricsConfig {
+	return metricsConfig{c.HttpServerConfig.Metrics()}
+}
+
+type metricsConfig struct {
+	config.MetricsConfig
+}
+
+func (c metricsConfig) Filter() dataflow.RegisterFilterConf {
+	return c.MetricsConfig.Filter()
+}
+
 type viewConfig struct {
 	config.ViewConfig
 }
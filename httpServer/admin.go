@@ -0,0 +1,201 @@
+package httpServer
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/restarter"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+type adminDeviceResponse struct {
+	Name        string     `json:"name" example:"bmv0"`
+	Model       string     `json:"model" example:"BMV-702"`
+	State       string     `json:"state" example:"backoff"`
+	Restarts    uint64     `json:"restarts" example:"3"`
+	LastError   string     `json:"lastError,omitempty" example:"ping failed: timeout"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	NextRetry   *time.Time `json:"nextRetry,omitempty"`
+}
+
+// ReloadChanges lists the names of the items started, stopped or restarted by a reload.
+type ReloadChanges struct {
+	Added     []string `json:"added,omitempty" example:"bmv1"`
+	Removed   []string `json:"removed,omitempty" example:"bmv2"`
+	Restarted []string `json:"restarted,omitempty" example:"bmv0"`
+}
+
+// ReloadReport is the result of a successful configuration reload.
+type ReloadReport struct {
+	Devices         ReloadChanges `json:"devices"`
+	Modbus          ReloadChanges `json:"modbus"`
+	MqttClients     ReloadChanges `json:"mqttClients"`
+	MqttForwarders  []string      `json:"mqttForwarders,omitempty" example:"local"`
+	Views           ReloadChanges `json:"views"`
+	RestartRequired []string      `json:"restartRequired,omitempty" example:"HttpServer"`
+}
+
+type reloadErrorResponse struct {
+	Message string   `json:"message" example:"configuration is invalid; nothing was changed"`
+	Errors  []string `json:"errors" example:"Views->0->Devices->0->Name='bmv9' is not defined"`
+}
+
+// setupAdminDevicesGetJson godoc
+// @Summary List devices
+// @Description Outputs the state of all devices: running, backoff (waiting for a restart after an error), paused or stopped,
+// @Description as well as the number of restarts, the last error and when the next restart is attempted.
+// @Description Only available when authentication is enabled; requires a logged-in user listed in AdminUsers.
+// @Produce json
+// @success 200 {array} adminDeviceResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/devices [get]
+// @Security ApiKeyAuth
+func setupAdminDevicesGetJson(r *gin.RouterGroup, env *Environment) {
+	if !env.Authentication.Enabled() {
+		return
+	}
+
+	relativePath := "admin/devices"
+	r.GET(relativePath, func(c *gin.Context) {
+		// check authorization
+		if !isAdminAuthenticated(env, c) {
+			jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+			return
+		}
+
+		devices := env.DevicePool.GetAll()
+		ret := make([]adminDeviceResponse, 0, len(devices))
+		for _, dev := range devices {
+			ret = append(ret, compileAdminDeviceResponse(dev))
+		}
+		slices.SortFunc(ret, func(a, b adminDeviceResponse) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		c.JSON(http.StatusOK, ret)
+	})
+	if env.Config.LogConfig() {
+		log.Printf("httpServer: GET %s%s -> serve device states as json", r.BasePath(), relativePath)
+	}
+}
+
+// setupAdminDevicesPost godoc
+// @Summary Control device
+// @Description Restarts a device (a disabled device is enabled again), disables it (the device is stopped until it is enabled)
+// @Description or enables it without restarting the process.
+// @Description Only available when authentication is enabled; requires a logged-in user listed in AdminUsers.
+// @Param deviceName path string true "Device name as provided by the admin devices endpoint"
+// @Param action path string true "restart, disable or enable"
+// @Produce json
+// @success 200 {object} adminDeviceResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /admin/devices/{deviceName}/{action} [post]
+// @Security ApiKeyAuth
+func setupAdminDevicesPost(r *gin.RouterGroup, env *Environment) {
+	if !env.Authentication.Enabled() {
+		return
+	}
+
+	actions := []struct {
+		name string
+		exec func(dev *restarter.Restarter[device.Device])
+	}{
+		{"restart", (*restarter.Restarter[device.Device]).Restart},
+		{"disable", (*restarter.Restarter[device.Device]).Pause},
+		{"enable", (*restarter.Restarter[device.Device]).Resume},
+	}
+
+	// add dynamic routes
+	for _, dev := range env.DevicePool.GetAll() {
+		for _, action := range actions {
+			relativePath := "admin/devices/" + dev.Name() + "/" + action.name
+			r.POST(relativePath, func(c *gin.Context) {
+				// check authorization
+				user := c.GetString("AuthUser")
+				if !isAdminAuthenticated(env, c) {
+					jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+					return
+				}
+
+				log.Printf("httpServer: user=%s: %s device=%s", user, action.name, dev.Name())
+				action.exec(dev)
+				c.JSON(http.StatusOK, compileAdminDeviceResponse(dev))
+			})
+			if env.Config.LogConfig() {
+				log.Printf("httpServer: POST %s%s -> %s device", r.BasePath(), relativePath, action.name)
+			}
+		}
+	}
+}
+
+func isAdminAuthenticated(env *Environment, c *gin.Context) bool {
+	user := c.GetString("AuthUser")
+	return len(user) > 0 && env.Authentication.IsAdmin(user)
+}
+
+func compileAdminDeviceResponse(dev *restarter.Restarter[device.Device]) adminDeviceResponse {
+	status := dev.Status()
+	ret := adminDeviceResponse{
+		Name:      dev.Name(),
+		Model:     dev.Service().Model(),
+		State:     status.State.String(),
+		Restarts:  status.Restarts,
+		NextRetry: optionalTime(status.NextRetry),
+	}
+	if status.LastError != nil {
+		ret.LastError = status.LastError.Error()
+		ret.LastErrorAt = optionalTime(status.LastErrorAt)
+	}
+	return ret
+}
+
+// setupAdminReload godoc
+// @Summary Reload configuration
+// @Description Re-reads the configuration file and applies the differences to the running process:
+// @Description only added, removed or changed devices, modbus buses, mqtt clients, mqtt forwarders and views
+// @Description are started, stopped or restarted. Changes to other sections are listed in restartRequired and need a restart.
+// @Description When the new configuration is invalid, nothing is changed and the validation errors are returned.
+// @Description Only available when authentication is enabled; requires a logged-in user listed in AdminUsers.
+// @Produce json
+// @success 200 {object} ReloadReport
+// @Failure 403 {object} ErrorResponse
+// @Failure 422 {object} reloadErrorResponse
+// @Router /admin/reload [post]
+// @Security ApiKeyAuth
+func setupAdminReload(r *gin.RouterGroup, env *Environment) {
+	if !env.Authentication.Enabled() || env.Reload == nil {
+		return
+	}
+
+	relativePath := "admin/reload"
+	r.POST(relativePath, func(c *gin.Context) {
+		// check authorization
+		user := c.GetString("AuthUser")
+		if !isAdminAuthenticated(env, c) {
+			jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+			return
+		}
+
+		log.Printf("httpServer: user=%s: reload config", user)
+		report, errs := env.Reload()
+		if len(errs) > 0 {
+			resp := reloadErrorResponse{
+				Message: "configuration is invalid; nothing was changed",
+				Errors:  make([]string, len(errs)),
+			}
+			for i, err := range errs {
+				resp.Errors[i] = err.Error()
+			}
+			c.JSON(http.StatusUnprocessableEntity, resp)
+			return
+		}
+		c.JSON(http.StatusOK, report)
+	})
+	if env.Config.LogConfig() {
+		log.Printf("httpServer: POST %s%s -> reload config", r.BasePath(), relativePath)
+	}
+}
@@ -0,0 +1,194 @@
+package httpServer
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/alarms"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+type alarmResponse struct {
+	Name         string     `json:"name" example:"low-soc"`
+	Device       string     `json:"device" example:"bmv0"`
+	Register     string     `json:"register,omitempty" example:"SOC"`
+	Kind         string     `json:"kind" example:"Low"`
+	Severity     string     `json:"severity" example:"Warning"`
+	Description  string     `json:"description" example:"bmv0.SOC < 20 hysteresis 5 delay 1m0s"`
+	Message      string     `json:"message" example:"Battery low"`
+	State        string     `json:"state" example:"active"`
+	Since        *time.Time `json:"since,omitempty"`
+	RaisedAt     *time.Time `json:"raisedAt,omitempty"`
+	AckedBy      string     `json:"ackedBy,omitempty" example:"admin"`
+	AckedAt      *time.Time `json:"ackedAt,omitempty"`
+	Value        string     `json:"value,omitempty" example:"15%"`
+	PendingSince *time.Time `json:"pendingSince,omitempty"`
+}
+
+type alarmEventResponse struct {
+	Time     time.Time `json:"time"`
+	Alarm    string    `json:"alarm" example:"low-soc"`
+	State    string    `json:"state" example:"acknowledged"`
+	Value    string    `json:"value,omitempty" example:"15%"`
+	Message  string    `json:"message" example:"Battery low"`
+	Severity string    `json:"severity" example:"Warning"`
+	User     string    `json:"user,omitempty" example:"admin"`
+}
+
+type alarmsResponse struct {
+	Alarms  []alarmResponse      `json:"alarms"`
+	History []alarmEventResponse `json:"history"`
+}
+
+type alarmsPostRequest struct {
+	Acknowledge []string `json:"acknowledge" example:"low-soc"`
+}
+
+// setupAlarmsGetJson godoc
+// @Summary Alarms status
+// @Description Outputs the state of all configured alarms and the most recent alarm events.
+// @Description When authentication is enabled, a logged-in user is required.
+// @Produce json
+// @success 200 {object} alarmsResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /alarms [get]
+// @Security ApiKeyAuth
+func setupAlarmsGetJson(r *gin.RouterGroup, env *Environment) {
+	if env.Alarms == nil {
+		return
+	}
+
+	relativePath := "alarms"
+	r.GET(relativePath, func(c *gin.Context) {
+		// check authorization
+		if env.Authentication.Enabled() && len(c.GetString("AuthUser")) < 1 {
+			jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+			return
+		}
+
+		jsonGetResponse(c, compileAlarmsResponse(env.Alarms))
+	})
+	if env.Config.LogConfig() {
+		log.Printf("httpServer: GET %s%s -> serve alarms as json", r.BasePath(), relativePath)
+	}
+}
+
+// setupAlarmsPost godoc
+// @Summary Acknowledge alarms
+// @Description Acknowledges the given alarms. Alarms that are not active remain unchanged.
+// @Description When authentication is enabled, a logged-in user is required and recorded as the acknowledging user.
+// @Accept json
+// @Produce json
+// @Param request body alarmsPostRequest true "alarms to acknowledge"
+// @success 200 {object} alarmsResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /alarms [post]
+// @Security ApiKeyAuth
+func setupAlarmsPost(r *gin.RouterGroup, env *Environment) {
+	if env.Alarms == nil {
+		return
+	}
+
+	relativePath := "alarms"
+	r.POST(relativePath, func(c *gin.Context) {
+		// check authorization
+		user := c.GetString("AuthUser")
+		if env.Authentication.Enabled() && len(user) < 1 {
+			jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+			return
+		}
+
+		var req alarmsPostRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			jsonErrorResponse(c, http.StatusUnprocessableEntity, errors.New("Invalid json body provided"))
+			return
+		}
+
+		// check all names before acknowledging any alarm
+		known := make(map[string]struct{})
+		for _, s := range env.Alarms.Status() {
+			known[s.Name] = struct{}{}
+		}
+		for _, name := range req.Acknowledge {
+			if _, ok := known[name]; !ok {
+				jsonErrorResponse(c, http.StatusNotFound, errors.Errorf("alarm '%s' not found", name))
+				return
+			}
+		}
+
+		for _, name := range req.Acknowledge {
+			if err := env.Alarms.Acknowledge(name, user); err != nil {
+				jsonErrorResponse(c, http.StatusInternalServerError, err)
+				return
+			}
+		}
+
+		c.JSON(http.StatusOK, compileAlarmsResponse(env.Alarms))
+	})
+	if env.Config.LogConfig() {
+		log.Printf("httpServer: POST %s%s -> acknowledge alarms", r.BasePath(), relativePath)
+	}
+}
+
+func compileAlarmsResponse(engine *alarms.Engine) alarmsResponse {
+	history := engine.History()
+	ret := alarmsResponse{
+		Alarms:  compileAlarmResponses(engine.Status(), nil),
+		History: make([]alarmEventResponse, len(history)),
+	}
+	for i, ev := range history {
+		ret.History[i] = alarmEventResponse{
+			Time:     ev.Time,
+			Alarm:    ev.Alarm,
+			State:    ev.State.String(),
+			Value:    ev.Value,
+			Message:  ev.Message,
+			Severity: ev.Severity.String(),
+			User:     ev.User,
+		}
+	}
+	return ret
+}
+
+// compileAlarmResponses converts the status of all alarms matching the filter; a nil filter matches all alarms.
+func compileAlarmResponses(status []alarms.AlarmStatus, filter func(s alarms.AlarmStatus) bool) []alarmResponse {
+	ret := make([]alarmResponse, 0, len(status))
+	for _, s := range status {
+		if filter != nil && !filter(s) {
+			continue
+		}
+		ret = append(ret, alarmResponse{
+			Name:         s.Name,
+			Device:       s.Device,
+			Register:     s.Register,
+			Kind:         s.Kind.String(),
+			Severity:     s.Severity.String(),
+			Description:  s.Description,
+			Message:      s.Message,
+			State:        s.State.String(),
+			Since:        optionalTime(s.Since),
+			RaisedAt:     optionalTime(s.RaisedAt),
+			AckedBy:      s.AckedBy,
+			AckedAt:      optionalTime(s.AckedAt),
+			Value:        s.Value,
+			PendingSince: optionalTime(s.PendingSince),
+		})
+	}
+	return ret
+}
+
+// getViewAlarmFilter returns a filter matching the alarms of the view's devices.
+func getViewAlarmFilter(viewDevices []ViewDeviceConfig) func(s alarms.AlarmStatus) bool {
+	devices := make(map[string]struct{}, len(viewDevices))
+	for _, vd := range viewDevices {
+		devices[vd.Name()] = struct{}{}
+	}
+
+	return func(s alarms.AlarmStatus) bool {
+		_, ok := devices[s.Device]
+		return ok
+	}
+}
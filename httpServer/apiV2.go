@@ -13,6 +13,15 @@ func addApiV2Routes(r *gin.Engine, env *Environment) {
 	setupRegisters(v2, env)
 	setupValuesGetJson(v2, env)
 	setupValuesPatch(v2, env)
+	setupHistoryGetJson(v2, env)
+	setupArchiveGetJson(v2, env)
+	setupArchiveExport(v2, env)
+	setupRulesGetJson(v2, env)
+	setupAlarmsGetJson(v2, env)
+	setupAlarmsPost(v2, env)
+	setupAdminDevicesGetJson(v2, env)
+	setupAdminDevicesPost(v2, env)
+	setupAdminReload(v2, env)
 	setupDocs(v2, env)
 
 	v2Ws := r.Group("/api/v2/")
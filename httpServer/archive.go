@@ -0,0 +1,201 @@
+package httpServer
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/tsdb"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+type archivePointResponse struct {
+	Time  time.Time `json:"time"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Avg   float64   `json:"avg"`
+	Count int       `json:"count"`
+}
+
+// archiveResponse maps the register name to its points ordered by time.
+type archiveResponse map[string][]archivePointResponse
+
+// setupArchiveGetJson godoc
+// @Summary Archived values
+// @Description Outputs the values stored in the on-disk archive, either raw or as minute / hour rollups.
+// @Param viewName path string true "View name as provided by the config endpoint"
+// @Param deviceName path string true "Device name as provided in devices array of the config endpoint"
+// @Param register query []string false "Register names to include; all when omitted" collectionFormat(multi)
+// @Param from query string false "RFC3339 start time; unbounded when omitted"
+// @Param to query string false "RFC3339 end time; unbounded when omitted"
+// @Param resolution query string false "raw (default), 1m or 1h"
+// @Produce json
+// @success 200 {object} archiveResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /views/{viewName}/devices/{deviceName}/archive [get]
+// @Security ApiKeyAuth
+func setupArchiveGetJson(r *gin.RouterGroup, env *Environment) {
+	if env.Archive == nil {
+		return
+	}
+
+	// add dynamic routes
+	for _, v := range env.Views {
+		view := v
+		for _, vd := range view.Devices() {
+			viewDevice := vd
+
+			device := env.DevicePool.GetByName(viewDevice.Name())
+			if device == nil {
+				continue
+			}
+
+			relativePath := "views/" + view.Name() + "/devices/" + viewDevice.Name() + "/archive"
+
+			viewFilter := getViewSeriesFilter(env, []ViewDeviceConfig{viewDevice})
+			r.GET(relativePath, func(c *gin.Context) {
+				// check authorization
+				if !isViewAuthenticated(view, c, true) {
+					jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+					return
+				}
+
+				res, from, to, err := parseArchiveQuery(c)
+				if err != nil {
+					jsonErrorResponse(c, http.StatusUnprocessableEntity, err)
+					return
+				}
+
+				filter := viewFilter
+				if registerNames := c.QueryArray("register"); len(registerNames) > 0 {
+					names := make(map[string]struct{}, len(registerNames))
+					for _, n := range registerNames {
+						names[n] = struct{}{}
+					}
+					filter = func(device, register string) bool {
+						_, ok := names[register]
+						return ok && viewFilter(device, register)
+					}
+				}
+
+				series, err := env.Archive.Query(res, from, to, filter)
+				if err != nil {
+					jsonErrorResponse(c, http.StatusInternalServerError, err)
+					return
+				}
+				jsonGetResponse(c, compileArchiveResponse(series[viewDevice.Name()]))
+			})
+			if env.Config.LogConfig() {
+				log.Printf("httpServer: GET %s%s -> serve archive as json", r.BasePath(), relativePath)
+			}
+		}
+	}
+}
+
+// setupArchiveExport godoc
+// @Summary Export archive
+// @Description Exports all archived values of all devices of the view as csv with the columns
+// @Description time, device, register, min, max, avg, count.
+// @Param viewName path string true "View name as provided by the config endpoint"
+// @Param from query string false "RFC3339 start time; unbounded when omitted"
+// @Param to query string false "RFC3339 end time; unbounded when omitted"
+// @Param resolution query string false "raw (default), 1m or 1h"
+// @Produce text/csv
+// @success 200
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /views/{viewName}/archive/export [get]
+// @Security ApiKeyAuth
+func setupArchiveExport(r *gin.RouterGroup, env *Environment) {
+	if env.Archive == nil {
+		return
+	}
+
+	// add dynamic routes
+	for _, v := range env.Views {
+		view := v
+		relativePath := "views/" + view.Name() + "/archive/export"
+		viewFilter := getViewSeriesFilter(env, view.Devices())
+
+		r.GET(relativePath, func(c *gin.Context) {
+			// check authorization
+			if !isViewAuthenticated(view, c, true) {
+				jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+				return
+			}
+
+			res, from, to, err := parseArchiveQuery(c)
+			if err != nil {
+				jsonErrorResponse(c, http.StatusUnprocessableEntity, err)
+				return
+			}
+
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-%s.csv\"", view.Name(), res))
+			c.Status(http.StatusOK)
+			if err := env.Archive.Export(c.Writer, res, from, to, viewFilter); err != nil {
+				log.Printf("httpServer: %s%s: export failed: %s", r.BasePath(), relativePath, err)
+			}
+		})
+		if env.Config.LogConfig() {
+			log.Printf("httpServer: GET %s%s -> serve archive export as csv", r.BasePath(), relativePath)
+		}
+	}
+}
+
+func parseArchiveQuery(c *gin.Context) (res tsdb.Resolution, from, to time.Time, err error) {
+	var ok bool
+	if res, ok = tsdb.ResolutionFromString(c.Query("resolution")); !ok {
+		err = fmt.Errorf("invalid resolution='%s', expect raw, 1m or 1h", c.Query("resolution"))
+		return
+	}
+	if from, err = parseOptionalTimeQuery(c, "from"); err != nil {
+		return
+	}
+	to, err = parseOptionalTimeQuery(c, "to")
+	return
+}
+
+// getViewSeriesFilter applies the view device filters to the archived series.
+// Registers unknown to the device (e.g. because it is not connected) are matched by their name only.
+func getViewSeriesFilter(env *Environment, viewDevices []ViewDeviceConfig) tsdb.SeriesFilter {
+	filters := make(map[string]dataflow.RegisterFilterFunc)
+	for _, vd := range viewDevices {
+		filters[vd.Name()] = dataflow.RegisterFilter(vd.Filter())
+	}
+
+	return func(deviceName, registerName string) bool {
+		f, ok := filters[deviceName]
+		if !ok {
+			return false // device not included
+		}
+
+		if dev := env.DevicePool.GetByName(deviceName); dev != nil {
+			if reg, ok := dev.Service().RegisterDb().GetByName(registerName); ok {
+				return f(reg)
+			}
+		}
+		return f(dataflow.NewRegisterStruct("", registerName, "", dataflow.NumberRegister, nil, "", 0, false))
+	}
+}
+
+func compileArchiveResponse(series map[string][]tsdb.Point) (response archiveResponse) {
+	response = make(archiveResponse, len(series))
+	for registerName, points := range series {
+		r := make([]archivePointResponse, len(points))
+		for i, p := range points {
+			r[i] = archivePointResponse{
+				Time:  p.Time,
+				Min:   p.Min,
+				Max:   p.Max,
+				Avg:   p.Avg,
+				Count: p.Count,
+			}
+		}
+		response[registerName] = r
+	}
+	return
+}
@@ -0,0 +1,122 @@
+package httpServer
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+type historySampleResponse struct {
+	Time    time.Time     `json:"time"`
+	Value   valueResponse `json:"value"`
+	Quality string        `json:"quality" example:"good"`
+}
+
+// historyResponse maps the register name to its samples ordered from the oldest to the newest.
+type historyResponse map[string][]historySampleResponse
+
+// setupHistoryGetJson godoc
+// @Summary Value history
+// @Description Outputs the samples kept in the in-memory history of the registers of a device.
+// @Description Only registers with an enabled history are included.
+// @Param viewName path string true "View name as provided by the config endpoint"
+// @Param deviceName path string true "Device name as provided in devices array of the config endpoint"
+// @Param register query []string false "Register names to include; all when omitted" collectionFormat(multi)
+// @Param from query string false "RFC3339 start time; unbounded when omitted"
+// @Param to query string false "RFC3339 end time; unbounded when omitted"
+// @Produce json
+// @success 200 {object} historyResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Router /views/{viewName}/devices/{deviceName}/history [get]
+// @Security ApiKeyAuth
+func setupHistoryGetJson(r *gin.RouterGroup, env *Environment) {
+	// add dynamic routes
+	for _, v := range env.Views {
+		view := v
+		for _, vd := range view.Devices() {
+			viewDevice := vd
+
+			device := env.DevicePool.GetByName(viewDevice.Name())
+			if device == nil {
+				continue
+			}
+
+			relativePath := "views/" + view.Name() + "/devices/" + viewDevice.Name() + "/history"
+
+			viewFilter := getViewValueFilter([]ViewDeviceConfig{viewDevice})
+			r.GET(relativePath, func(c *gin.Context) {
+				// check authorization
+				if !isViewAuthenticated(view, c, true) {
+					jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+					return
+				}
+
+				from, err := parseOptionalTimeQuery(c, "from")
+				if err != nil {
+					jsonErrorResponse(c, http.StatusUnprocessableEntity, err)
+					return
+				}
+				to, err := parseOptionalTimeQuery(c, "to")
+				if err != nil {
+					jsonErrorResponse(c, http.StatusUnprocessableEntity, err)
+					return
+				}
+
+				filter := viewFilter
+				if registerNames := c.QueryArray("register"); len(registerNames) > 0 {
+					filter = getRegisterNamesValueFilter(viewFilter, registerNames)
+				}
+
+				values := env.StateStorage.GetHistoryFiltered(filter, from, to)
+				jsonGetResponse(c, compileHistoryResponse(values))
+			})
+			if env.Config.LogConfig() {
+				log.Printf("httpServer: GET %s%s -> serve history as json", r.BasePath(), relativePath)
+			}
+		}
+	}
+}
+
+func parseOptionalTimeQuery(c *gin.Context, key string) (time.Time, error) {
+	s := c.Query(key)
+	if len(s) < 1 {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s='%s', expect a RFC3339 time", key, s)
+	}
+	return t, nil
+}
+
+func getRegisterNamesValueFilter(filter dataflow.ValueFilterFunc, registerNames []string) dataflow.ValueFilterFunc {
+	names := make(map[string]struct{}, len(registerNames))
+	for _, n := range registerNames {
+		names[n] = struct{}{}
+	}
+
+	return func(value dataflow.Value) bool {
+		if _, ok := names[value.Register().Name()]; !ok {
+			return false
+		}
+		return filter(value)
+	}
+}
+
+func compileHistoryResponse(values []dataflow.Value) (response historyResponse) {
+	response = make(historyResponse)
+	for _, value := range values {
+		registerName := value.Register().Name()
+		response[registerName] = append(response[registerName], historySampleResponse{
+			Time:    value.SampleTime(),
+			Value:   compileValueResponse(value),
+			Quality: value.Quality().String(),
+		})
+	}
+	return
+}
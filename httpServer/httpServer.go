@@ -4,20 +4,27 @@ import (
 	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/alarms"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/mqttClient"
 	"github.com/koestler/go-iotdevice/v3/pool"
 	"github.com/koestler/go-iotdevice/v3/restarter"
+	"github.com/koestler/go-iotdevice/v3/rules"
+	"github.com/koestler/go-iotdevice/v3/tsdb"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
 )
 
 type HttpServer struct {
-	config Config
-	server *http.Server
+	config  Config
+	server  *http.Server
+	env     *Environment
+	handler atomic.Pointer[gin.Engine]
 }
 
 type Environment struct {
@@ -28,6 +35,13 @@ type Environment struct {
 	DevicePool     *pool.Pool[*restarter.Restarter[device.Device]]
 	StateStorage   *dataflow.ValueStorage
 	CommandStorage *dataflow.ValueStorage
+	Archive        *tsdb.Store
+	Rules          *rules.Engine
+	Alarms         *alarms.Engine
+	MqttClientPool *pool.Pool[mqttClient.Client]
+	Reload         func() (ReloadReport, []error) // re-reads the configuration file; nil if not supported
+
+	wsClients *atomic.Int64 // number of connected websocket clients; shared by all reloaded environments
 }
 
 type Config interface {
@@ -41,6 +55,14 @@ type Config interface {
 	FrontendPath() string
 	FrontendExpires() time.Duration
 	ConfigExpires() time.Duration
+	Metrics() MetricsConfig
+}
+
+type MetricsConfig interface {
+	Enabled() bool
+	View() string
+	Filter() dataflow.RegisterFilterConf
+	Authenticated() bool
 }
 
 type ViewConfig interface {
@@ -64,26 +86,27 @@ type AuthenticationConfig interface {
 	JwtSecret() []byte
 	JwtValidityPeriod() time.Duration
 	HtaccessFile() string
+	IsAdmin(user string) bool
 }
 
 func Run(env *Environment) (httpServer *HttpServer) {
 	cfg := env.Config
+	env.wsClients = new(atomic.Int64)
 
 	gin.SetMode("release")
-	engine := gin.New()
-	if cfg.LogRequests() {
-		engine.Use(gin.Logger())
+	httpServer = &HttpServer{
+		config: cfg,
+		env:    env,
 	}
-	engine.Use(gin.Recovery())
-	engine.Use(authJwtMiddleware(env))
-
-	addApiV2Routes(engine, env)
-	setupFrontend(engine, env)
+	httpServer.handler.Store(newEngine(env))
 
 	server := &http.Server{
-		Addr:    cfg.Bind() + ":" + strconv.Itoa(cfg.Port()),
-		Handler: engine,
+		Addr: cfg.Bind() + ":" + strconv.Itoa(cfg.Port()),
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			httpServer.handler.Load().ServeHTTP(w, r)
+		}),
 	}
+	httpServer.server = server
 
 	go func() {
 		if cfg.LogDebug() {
@@ -94,10 +117,30 @@ func Run(env *Environment) (httpServer *HttpServer) {
 		}
 	}()
 
-	return &HttpServer{
-		config: cfg,
-		server: server,
+	return
+}
+
+func newEngine(env *Environment) *gin.Engine {
+	engine := gin.New()
+	if env.Config.LogRequests() {
+		engine.Use(gin.Logger())
 	}
+	engine.Use(gin.Recovery())
+	engine.Use(authJwtMiddleware(env))
+
+	addApiV2Routes(engine, env)
+	setupMetrics(engine, env)
+	setupFrontend(engine, env)
+	return engine
+}
+
+// Reload rebuilds all routes for the given views and the devices currently in the device pool.
+// Requests in progress, including open websockets, are completed using the old routes.
+func (s *HttpServer) Reload(views []ViewConfig) {
+	env := *s.env
+	env.Views = views
+	s.env = &env
+	s.handler.Store(newEngine(&env))
 }
 
 func (s *HttpServer) Shutdown() {
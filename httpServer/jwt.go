@@ -5,6 +5,7 @@ import (
 	"github.com/golang-jwt/jwt"
 	"github.com/pkg/errors"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -28,7 +29,8 @@ func createJwtToken(config AuthenticationConfig, user string) (tokenStr string,
 func authJwtMiddleware(env *Environment) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// extract jwt token from authorization header if present
-		tokenStr := c.GetHeader("Authorization")
+		// the Bearer prefix is optional; it is sent by e.g. Prometheus
+		tokenStr := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
 		if len(tokenStr) < 1 {
 			c.Next()
 			return
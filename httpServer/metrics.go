@@ -0,0 +1,199 @@
+package httpServer
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// setupMetrics serves all register values and some internal counters in the Prometheus text exposition format.
+func setupMetrics(r *gin.Engine, env *Environment) {
+	cfg := env.Config.Metrics()
+	if !cfg.Enabled() {
+		return
+	}
+
+	var view ViewConfig
+	for _, v := range env.Views {
+		if v.Name() == cfg.View() {
+			view = v
+		}
+	}
+
+	registerFilter := dataflow.RegisterValueFilter(cfg.Filter())
+	valueFilter := func(value dataflow.Value) bool {
+		return value.Quality() == dataflow.QualityGood && registerFilter(value)
+	}
+	deviceFilter := func(deviceName string) bool { return true }
+	if view != nil {
+		viewFilter := getViewValueFilter(view.Devices())
+		valueFilter = func(value dataflow.Value) bool {
+			return value.Quality() == dataflow.QualityGood && viewFilter(value) && registerFilter(value)
+		}
+		deviceFilter = func(deviceName string) bool {
+			return slices.ContainsFunc(view.Devices(), func(vd ViewDeviceConfig) bool {
+				return vd.Name() == deviceName
+			})
+		}
+	}
+
+	r.GET("/metrics", func(c *gin.Context) {
+		// check authorization
+		if view != nil {
+			if !isViewAuthenticated(view, c, !cfg.Authenticated()) {
+				jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+				return
+			}
+		} else if cfg.Authenticated() && len(c.GetString("AuthUser")) < 1 {
+			jsonErrorResponse(c, http.StatusUnauthorized, errors.New("Authentication required"))
+			return
+		}
+
+		var b bytes.Buffer
+		writeValueMetrics(&b, env.StateStorage.GetStateFiltered(valueFilter))
+		writeDeviceMetrics(&b, env, deviceFilter)
+		writeMqttMetrics(&b, env)
+
+		b.WriteString("# HELP iotdevice_websocket_clients Number of connected websocket clients.\n")
+		b.WriteString("# TYPE iotdevice_websocket_clients gauge\n")
+		writeSample(&b, "iotdevice_websocket_clients", nil, float64(env.wsClients.Load()))
+
+		c.Data(http.StatusOK, metricsContentType, b.Bytes())
+	})
+	if env.Config.LogConfig() {
+		log.Printf("httpServer: GET /metrics -> serve metrics in prometheus format")
+	}
+}
+
+func writeValueMetrics(b *bytes.Buffer, values []dataflow.Value) {
+	slices.SortFunc(values, func(a, b dataflow.Value) int {
+		if c := strings.Compare(a.DeviceName(), b.DeviceName()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Register().Name(), b.Register().Name())
+	})
+
+	b.WriteString("# HELP iotdevice_register_value Current value of a register; enums and bools are given by their index.\n")
+	b.WriteString("# TYPE iotdevice_register_value gauge\n")
+	for _, value := range values {
+		var v float64
+		switch value := value.(type) {
+		case dataflow.NumericRegisterValue:
+			v = value.Value()
+		case dataflow.IntRegisterValue:
+			v = float64(value.Value())
+		case dataflow.EnumRegisterValue:
+			v = float64(value.EnumIdx())
+		case dataflow.BoolRegisterValue:
+			v = float64(value.EnumIdx())
+		default:
+			continue
+		}
+
+		reg := value.Register()
+		writeSample(b, "iotdevice_register_value", []string{
+			"device", value.DeviceName(),
+			"register", reg.Name(),
+			"category", reg.Category(),
+			"unit", reg.Unit(),
+		}, v)
+	}
+}
+
+func writeDeviceMetrics(b *bytes.Buffer, env *Environment, deviceFilter func(deviceName string) bool) {
+	devices := env.DevicePool.GetAll()
+	names := make([]string, 0, len(devices))
+	for name := range devices {
+		if deviceFilter(name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	b.WriteString("# HELP iotdevice_restarts_total Number of restarts of a device, after an error or on request.\n")
+	b.WriteString("# TYPE iotdevice_restarts_total counter\n")
+	for _, name := range names {
+		writeSample(b, "iotdevice_restarts_total", []string{"device", name}, float64(devices[name].Restarts()))
+	}
+
+	b.WriteString("# HELP iotdevice_poll_duration_seconds Duration of the successful polls of a device.\n")
+	b.WriteString("# TYPE iotdevice_poll_duration_seconds summary\n")
+	for _, name := range names {
+		p, ok := devices[name].Service().(interface{ PollStats() device.PollStats })
+		if !ok {
+			continue
+		}
+		stats := p.PollStats()
+		writeSample(b, "iotdevice_poll_duration_seconds_sum", []string{"device", name}, stats.Sum.Seconds())
+		writeSample(b, "iotdevice_poll_duration_seconds_count", []string{"device", name}, float64(stats.Count))
+	}
+
+	b.WriteString("# HELP iotdevice_modbus_crc_errors_total Number of modbus responses with an invalid checksum.\n")
+	b.WriteString("# TYPE iotdevice_modbus_crc_errors_total counter\n")
+	for _, name := range names {
+		if m, ok := devices[name].Service().(interface{ CrcErrors() uint64 }); ok {
+			writeSample(b, "iotdevice_modbus_crc_errors_total", []string{"device", name}, float64(m.CrcErrors()))
+		}
+	}
+}
+
+func writeMqttMetrics(b *bytes.Buffer, env *Environment) {
+	if env.MqttClientPool == nil {
+		return
+	}
+
+	clients := env.MqttClientPool.GetAll()
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	b.WriteString("# HELP iotdevice_mqtt_published_total Number of messages published by a mqtt client.\n")
+	b.WriteString("# TYPE iotdevice_mqtt_published_total counter\n")
+	for _, name := range names {
+		writeSample(b, "iotdevice_mqtt_published_total", []string{"client", name}, float64(clients[name].Stats().Published))
+	}
+
+	b.WriteString("# HELP iotdevice_mqtt_backlog Number of messages waiting to be published.\n")
+	b.WriteString("# TYPE iotdevice_mqtt_backlog gauge\n")
+	for _, name := range names {
+		writeSample(b, "iotdevice_mqtt_backlog", []string{"client", name}, float64(clients[name].BacklogSize()))
+	}
+
+	b.WriteString("# HELP iotdevice_mqtt_backlog_dropped_total Number of messages discarded from the backlog.\n")
+	b.WriteString("# TYPE iotdevice_mqtt_backlog_dropped_total counter\n")
+	for _, name := range names {
+		writeSample(b, "iotdevice_mqtt_backlog_dropped_total", []string{"client", name}, float64(clients[name].Stats().BacklogDropped))
+	}
+}
+
+// writeSample writes one line; labels are given as name, value pairs.
+func writeSample(b *bytes.Buffer, name string, labels []string, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", labels[i], metricsLabelEscaper.Replace(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+}
+
+var metricsLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
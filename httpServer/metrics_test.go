@@ -0,0 +1,33 @@
+package httpServer
+
+import (
+	"bytes"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"testing"
+)
+
+func TestWriteValueMetrics(t *testing.T) {
+	number := dataflow.NewRegisterStruct("Essential", "Power", "", dataflow.NumberRegister, nil, "W", 0, false)
+	enum := dataflow.NewRegisterStruct("Essential", "Mode", "", dataflow.EnumRegister, map[int]string{0: "off", 1: "on"}, "", 0, false)
+	boolean := dataflow.NewRegisterStruct("Relay", "CH1", "", dataflow.BoolRegister, nil, "", 0, true)
+	text := dataflow.NewRegisterStruct("Product", "Serial", "", dataflow.TextRegister, nil, "", 0, false)
+
+	var b bytes.Buffer
+	writeValueMetrics(&b, []dataflow.Value{
+		dataflow.NewBoolRegisterValue("dev", boolean, true),
+		dataflow.NewTextRegisterValue("dev", text, "HQ1234"),
+		dataflow.NewEnumRegisterValue("dev", enum, 1),
+		dataflow.NewNumericRegisterValue("dev", number, 42.5),
+		dataflow.NewBoolRegisterValue("other", boolean, false),
+	})
+
+	expect := "# HELP iotdevice_register_value Current value of a register; enums and bools are given by their index.\n" +
+		"# TYPE iotdevice_register_value gauge\n" +
+		`iotdevice_register_value{device="dev",register="CH1",category="Relay",unit=""} 1` + "\n" +
+		`iotdevice_register_value{device="dev",register="Mode",category="Essential",unit=""} 1` + "\n" +
+		`iotdevice_register_value{device="dev",register="Power",category="Essential",unit="W"} 42.5` + "\n" +
+		`iotdevice_register_value{device="other",register="CH1",category="Relay",unit=""} 0` + "\n"
+	if got := b.String(); expect != got {
+		t.Errorf("expect\n%s\nbut got\n%s", expect, got)
+	}
+}
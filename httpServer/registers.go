@@ -13,12 +13,19 @@ type registerResponse struct {
 	Category    string         `json:"category" example:"Monitor"`
 	Name        string         `json:"name" example:"PanelPower"`
 	Description string         `json:"description" example:"Panel power"`
-	Type        string         `json:"type" example:"numeric"`
+	Type        string         `json:"type" example:"numeric"` // number, string or enum; kept for compatibility
+	ValueType   string         `json:"valueType" example:"bool"`
 	Enum        map[int]string `json:"enum,omitempty"`
 	Unit        string         `json:"unit,omitempty" example:"W"`
 	Sort        int            `json:"sort" example:"100"`
 	Writable    bool           `json:"commandable" example:"false"` // json is kept at commandable for compatibility reasons
 	// consider changing when going to majer version 4
+	Min         *float64 `json:"min,omitempty" example:"0"`
+	Max         *float64 `json:"max,omitempty" example:"100"`
+	Step        *float64 `json:"step,omitempty" example:"0.5"`
+	Precision   *int     `json:"precision,omitempty" example:"1"`
+	DeviceClass string   `json:"deviceClass,omitempty" example:"power"`
+	StateClass  string   `json:"stateClass,omitempty" example:"measurement"`
 }
 
 // setupRegisters godoc
@@ -70,15 +77,23 @@ func setupRegisters(r *gin.RouterGroup, env *Environment) {
 }
 
 func createRegisterResponse(r dataflow.Register) registerResponse {
+	meta := r.Meta()
 	return registerResponse{
 		Category:    r.Category(),
 		Name:        r.Name(),
 		Description: r.Description(),
-		Type:        r.RegisterType().String(),
+		Type:        r.RegisterType().CompatString(),
+		ValueType:   r.RegisterType().String(),
 		Enum:        r.Enum(),
 		Unit:        r.Unit(),
 		Sort:        r.Sort(),
 		Writable:    r.Writable(),
+		Min:         meta.Min,
+		Max:         meta.Max,
+		Step:        meta.Step,
+		Precision:   meta.Precision,
+		DeviceClass: meta.DeviceClass,
+		StateClass:  meta.StateClass,
 	}
 }
 
@@ -0,0 +1,93 @@
+package httpServer
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/rules"
+	"github.com/pkg/errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+type ruleResponse struct {
+	Name          string                `json:"name" example:"low-soc"`
+	Condition     string                `json:"condition,omitempty" example:"bmv0.Current < 0"`
+	Triggers      []ruleTriggerResponse `json:"triggers"`
+	FireCount     int                   `json:"fireCount" example:"3"`
+	LastTriggered *time.Time            `json:"lastTriggered,omitempty"`
+	LastFired     *time.Time            `json:"lastFired,omitempty"`
+	LastError     string                `json:"lastError,omitempty" example:"command: register relay0.CH1 not found"`
+	LastErrorAt   *time.Time            `json:"lastErrorAt,omitempty"`
+}
+
+type ruleTriggerResponse struct {
+	Kind        string     `json:"kind" example:"Threshold"`
+	Description string     `json:"description" example:"bmv0.SOC < 20 hysteresis 5 hold 1m0s"`
+	State       string     `json:"state" example:"pending"`
+	Since       *time.Time `json:"since,omitempty"`
+	Next        *time.Time `json:"next,omitempty"`
+}
+
+// setupRulesGetJson godoc
+// @Summary Rules status
+// @Description Outputs the state of all configured rules and their triggers.
+// @Description When authentication is enabled, a logged-in user is required.
+// @Produce json
+// @success 200 {array} ruleResponse
+// @Failure 403 {object} ErrorResponse
+// @Router /rules [get]
+// @Security ApiKeyAuth
+func setupRulesGetJson(r *gin.RouterGroup, env *Environment) {
+	if env.Rules == nil {
+		return
+	}
+
+	relativePath := "rules"
+	r.GET(relativePath, func(c *gin.Context) {
+		// check authorization
+		if env.Authentication.Enabled() && len(c.GetString("AuthUser")) < 1 {
+			jsonErrorResponse(c, http.StatusForbidden, errors.New("User is not allowed here"))
+			return
+		}
+
+		jsonGetResponse(c, compileRulesResponse(env.Rules.Status()))
+	})
+	if env.Config.LogConfig() {
+		log.Printf("httpServer: GET %s%s -> serve rules status as json", r.BasePath(), relativePath)
+	}
+}
+
+func compileRulesResponse(status []rules.RuleStatus) []ruleResponse {
+	ret := make([]ruleResponse, len(status))
+	for i, s := range status {
+		triggers := make([]ruleTriggerResponse, len(s.Triggers))
+		for j, t := range s.Triggers {
+			triggers[j] = ruleTriggerResponse{
+				Kind:        t.Kind.String(),
+				Description: t.Description,
+				State:       t.State.String(),
+				Since:       optionalTime(t.Since),
+				Next:        optionalTime(t.Next),
+			}
+		}
+
+		ret[i] = ruleResponse{
+			Name:          s.Name,
+			Condition:     s.Condition,
+			Triggers:      triggers,
+			FireCount:     s.FireCount,
+			LastTriggered: optionalTime(s.LastTriggered),
+			LastFired:     optionalTime(s.LastFired),
+			LastError:     s.LastError,
+			LastErrorAt:   optionalTime(s.LastErrorAt),
+		}
+	}
+	return ret
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -6,18 +6,35 @@ import (
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/pkg/errors"
 	"log"
+	"math"
 	"net/http"
+	"time"
 )
 
 type valueResponse interface{}
 type values1DResponse map[string]valueResponse
 type values2DResponse map[string]map[string]valueResponse
 
+// valueMetaResponse holds the timestamps and the quality of a value; SourceTime is only present when supplied by the device.
+type valueMetaResponse struct {
+	MeasuredAt time.Time  `json:"measuredAt"`
+	SourceTime *time.Time `json:"sourceTime,omitempty"`
+	Quality    string     `json:"quality" example:"good"`
+}
+
+type valueWithMetaResponse struct {
+	Value valueResponse `json:"value"`
+	valueMetaResponse
+}
+type values1DWithMetaResponse map[string]valueWithMetaResponse
+
 // setupValuesGetJson godoc
 // @Summary List values
 // @Description Outputs the latest values of all the registers of a device.
+// @Description When meta=true is given, every value is returned as an object including its timestamps and quality.
 // @Param viewName path string true "View name as provided by the config endpoint"
 // @Param deviceName path string true "Device name as provided in devices array of the config endpoint"
+// @Param meta query bool false "Include timestamps"
 // @Produce json
 // @success 200 {object} values1DResponse
 // @Failure 404 {object} ErrorResponse
@@ -45,6 +62,10 @@ func setupValuesGetJson(r *gin.RouterGroup, env *Environment) {
 					return
 				}
 				values := env.StateStorage.GetStateFiltered(filter)
+				if c.Query("meta") == "true" {
+					jsonGetResponse(c, compile1DValueWithMetaResponse(values))
+					return
+				}
 				jsonGetResponse(c, compile1DValueResponse(values))
 			})
 			if env.Config.LogConfig() {
@@ -102,6 +123,9 @@ func setupValuesPatch(r *gin.RouterGroup, env *Environment) {
 					invalidType := func(t string) {
 						jsonErrorResponse(c, http.StatusUnprocessableEntity, fmt.Errorf("expect type of %s to be a %s", registerName, t))
 					}
+					outOfRange := func(err error) {
+						jsonErrorResponse(c, http.StatusUnprocessableEntity, fmt.Errorf("invalid %s: %s", registerName, err))
+					}
 
 					switch register.RegisterType() {
 					case dataflow.TextRegister:
@@ -113,6 +137,10 @@ func setupValuesPatch(r *gin.RouterGroup, env *Environment) {
 						}
 					case dataflow.NumberRegister:
 						if v, ok := value.(float64); ok {
+							if err := register.Meta().CheckRange(v); err != nil {
+								outOfRange(err)
+								return
+							}
 							inputs = append(inputs, dataflow.NewNumericRegisterValue(deviceName, register, v))
 						} else {
 							invalidType("float")
@@ -126,6 +154,27 @@ func setupValuesPatch(r *gin.RouterGroup, env *Environment) {
 							invalidType("float")
 							return
 						}
+					case dataflow.BoolRegister:
+						// accept the enum index 0 / 1 used by v2 clients as well
+						if v, ok := value.(bool); ok {
+							inputs = append(inputs, dataflow.NewBoolRegisterValue(deviceName, register, v))
+						} else if v, ok := value.(float64); ok && (v == 0 || v == 1) {
+							inputs = append(inputs, dataflow.NewBoolRegisterValue(deviceName, register, v == 1))
+						} else {
+							invalidType("bool")
+							return
+						}
+					case dataflow.IntRegister:
+						if v, ok := value.(float64); ok && v == math.Trunc(v) {
+							if err := register.Meta().CheckRange(v); err != nil {
+								outOfRange(err)
+								return
+							}
+							inputs = append(inputs, dataflow.NewIntRegisterValue(deviceName, register, int64(v)))
+						} else {
+							invalidType("integer")
+							return
+						}
 					}
 				}
 
@@ -141,10 +190,38 @@ func setupValuesPatch(r *gin.RouterGroup, env *Environment) {
 	}
 }
 
+// compileValueResponse returns the value as known by v2 clients; booleans are encoded as enum index 0 / 1.
+func compileValueResponse(value dataflow.Value) valueResponse {
+	if b, ok := value.(dataflow.BoolRegisterValue); ok {
+		return b.EnumIdx()
+	}
+	return value.GenericValue()
+}
+
 func compile1DValueResponse(values []dataflow.Value) (response values1DResponse) {
 	response = make(map[string]valueResponse, len(values))
 	for _, value := range values {
-		response[value.Register().Name()] = value.GenericValue()
+		response[value.Register().Name()] = compileValueResponse(value)
+	}
+	return
+}
+
+func compile1DValueWithMetaResponse(values []dataflow.Value) (response values1DWithMetaResponse) {
+	response = make(map[string]valueWithMetaResponse, len(values))
+	for _, value := range values {
+		response[value.Register().Name()] = valueWithMetaResponse{
+			Value:             compileValueResponse(value),
+			valueMetaResponse: compileValueMetaResponse(value),
+		}
+	}
+	return
+}
+
+func compileValueMetaResponse(value dataflow.Value) (response valueMetaResponse) {
+	response.MeasuredAt = value.MeasuredAt()
+	response.Quality = value.Quality().String()
+	if st := value.SourceTime(); !st.IsZero() {
+		response.SourceTime = &st
 	}
 	return
 }
@@ -165,7 +242,26 @@ func append2DValueResponse(response map[string]map[string]valueResponse, value d
 		response[d0] = make(map[string]valueResponse)
 	}
 
-	response[d0][d1] = value.GenericValue()
+	response[d0][d1] = compileValueResponse(value)
+}
+
+func compile2DValueMetaResponse(values []dataflow.Value) (response map[string]map[string]valueMetaResponse) {
+	response = make(map[string]map[string]valueMetaResponse)
+	for _, value := range values {
+		append2DValueMetaResponse(response, value)
+	}
+	return
+}
+
+func append2DValueMetaResponse(response map[string]map[string]valueMetaResponse, value dataflow.Value) {
+	d0 := value.DeviceName()
+	d1 := value.Register().Name()
+
+	if _, ok := response[d0]; !ok {
+		response[d0] = make(map[string]valueMetaResponse)
+	}
+
+	response[d0][d1] = compileValueMetaResponse(value)
 }
 
 func getViewValueFilter(viewDevices []ViewDeviceConfig) dataflow.ValueFilterFunc {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/gin-gonic/gin"
+	"github.com/koestler/go-iotdevice/v3/alarms"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/mileusna/useragent"
 	"log"
@@ -13,10 +14,14 @@ import (
 )
 
 // registers / values maps use deviceName as the first dimension and registerName as the second dimension.
+// alarms contains the state of the alarms of the view's devices; all of them on init and the changed ones on alarm.
+// On register-removed, registers contains the registers which were removed from their device.
 type outputMessage struct {
-	Operation string                                 `json:"op" example:"init"`
-	Registers map[string]map[string]registerResponse `json:"registers,omitempty"`
-	Values    map[string]map[string]valueResponse    `json:"values,omitempty"`
+	Operation string                                  `json:"op" example:"init"`
+	Registers map[string]map[string]registerResponse  `json:"registers,omitempty"`
+	Values    map[string]map[string]valueResponse     `json:"values,omitempty"`
+	Meta      map[string]map[string]valueMetaResponse `json:"meta,omitempty"`
+	Alarms    []alarmResponse                         `json:"alarms,omitempty"`
 }
 
 type authMessage struct {
@@ -26,6 +31,9 @@ type authMessage struct {
 // setupViewWs godoc
 // @Summary Realtime values websocket
 // @Description Websocket that sends all registers and values initially and sends updates of changed values subsequently.
+// @Description When alarms are configured, the state of the alarms of the view's devices is sent initially
+// @Description and whenever an alarm changes (op=alarm).
+// @Description When a device no longer provides a register, it is sent with op=register-removed.
 // @Param viewName path string true "View name as provided by the config endpoint"
 // @Produce json
 // @success 200 {array} outputMessage
@@ -39,6 +47,7 @@ func setupValuesWs(r *gin.RouterGroup, env *Environment) {
 		relativePath := "views/" + view.Name() + "/ws"
 		logPrefix := fmt.Sprintf("httpServer: %s%s", r.BasePath(), relativePath)
 		viewFilter := getViewValueFilter(view.Devices())
+		alarmFilter := getViewAlarmFilter(view.Devices())
 
 		// the follow line uses a loop variable; it must be outside the closure
 		r.GET(relativePath, func(c *gin.Context) {
@@ -75,6 +84,9 @@ func setupValuesWs(r *gin.RouterGroup, env *Environment) {
 				log.Printf("%s: connection established to %s", logPrefix, c.ClientIP())
 			}
 
+			env.wsClients.Add(1)
+			defer env.wsClients.Add(-1)
+
 			senderCtx, senderCancel := context.WithCancel(c)
 			defer senderCancel()
 
@@ -83,7 +95,7 @@ func setupValuesWs(r *gin.RouterGroup, env *Environment) {
 				if valueSenderStarted {
 					return
 				}
-				go wsValuesSender(env, viewFilter, conn, senderCtx, logPrefix)
+				go wsValuesSender(env, view.Devices(), viewFilter, alarmFilter, conn, senderCtx, logPrefix)
 				valueSenderStarted = true
 			}
 
@@ -125,7 +137,9 @@ func setupValuesWs(r *gin.RouterGroup, env *Environment) {
 
 func wsValuesSender(
 	env *Environment,
+	viewDevices []ViewDeviceConfig,
 	viewFilter dataflow.ValueFilterFunc,
+	alarmFilter func(s alarms.AlarmStatus) bool,
 	conn *websocket.Conn,
 	ctx context.Context,
 	logPrefix string,
@@ -136,13 +150,27 @@ func wsValuesSender(
 		defer log.Printf("%s: tx routine closed", logPrefix)
 	}
 
-	initial, subscription := env.StateStorage.SubscribeReturnInitial(ctx, viewFilter)
+	// a slow client must not block the storage; only the latest value per register is kept
+	initial, subscription := env.StateStorage.SubscribeReturnInitial(ctx, viewFilter,
+		dataflow.WithName(logPrefix),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
+
+	removedC := subscribeRemovedRegisters(ctx, env, viewDevices)
+
+	var alarmsC <-chan alarms.Event
+	var initialAlarms []alarmResponse
+	if env.Alarms != nil {
+		alarmsC = env.Alarms.Subscribe(ctx)
+		initialAlarms = compileAlarmResponses(env.Alarms.Status(), alarmFilter)
+	}
 
 	// send all values after initial connect
 	registers := compile2DRegisterResponse(initial)
 	{
 		values := compile2DValueResponse(initial)
-		if err := wsSendResponse(ctx, conn, "init", registers, values); err != nil {
+		meta := compile2DValueMetaResponse(initial)
+		if err := wsSendResponse(ctx, conn, "init", registers, values, meta, initialAlarms); err != nil {
 			log.Printf("%s: error while sending initial values: %s", logPrefix, err)
 			return
 		}
@@ -160,18 +188,20 @@ func wsValuesSender(
 
 		newRegisters := make(map[string]map[string]registerResponse)
 		newValues := make(map[string]map[string]valueResponse)
+		newMeta := make(map[string]map[string]valueMetaResponse)
 		for {
 			select {
 			case <-ticker.C:
 				if len(newValues) > 0 {
 					// there is data to send, send it
-					if err := wsSendResponse(ctx, conn, "inc", newRegisters, newValues); err != nil {
+					if err := wsSendResponse(ctx, conn, "inc", newRegisters, newValues, newMeta, nil); err != nil {
 						log.Printf("%s: error while sending value: %s", logPrefix, err)
 						return
 					}
 
 					clear(newRegisters)
 					clear(newValues)
+					clear(newMeta)
 				} else {
 					// no data to send; stop timer
 					ticker.Stop()
@@ -183,6 +213,7 @@ func wsValuesSender(
 						append2DRegisterResponse(newRegisters, v)
 					}
 					append2DValueResponse(newValues, v)
+					append2DValueMetaResponse(newMeta, v)
 
 					if !tickerRunning {
 						ticker.Reset(tickerDuration)
@@ -192,17 +223,79 @@ func wsValuesSender(
 					// subscription was shutdown, stop
 					return
 				}
+			case rm := <-removedC:
+				removed := map[string]map[string]registerResponse{
+					rm.deviceName: {rm.register.Name(): createRegisterResponse(rm.register)},
+				}
+				for _, m := range []map[string]map[string]registerResponse{registers, newRegisters} {
+					delete(m[rm.deviceName], rm.register.Name())
+				}
+				delete(newValues[rm.deviceName], rm.register.Name())
+				delete(newMeta[rm.deviceName], rm.register.Name())
+
+				if err := wsSendResponse(ctx, conn, "register-removed", removed, nil, nil, nil); err != nil {
+					log.Printf("%s: error while sending removed register: %s", logPrefix, err)
+					return
+				}
+			case ev, ok := <-alarmsC:
+				if !ok {
+					return
+				}
+				changed := compileAlarmResponses(env.Alarms.Status(), func(s alarms.AlarmStatus) bool {
+					return s.Name == ev.Alarm && alarmFilter(s)
+				})
+				if len(changed) < 1 {
+					continue
+				}
+				if err := wsSendResponse(ctx, conn, "alarm", nil, nil, nil, changed); err != nil {
+					log.Printf("%s: error while sending alarm: %s", logPrefix, err)
+					return
+				}
 			}
 		}
 	}
 }
 
+type removedRegister struct {
+	deviceName string
+	register   dataflow.RegisterStruct
+}
+
+// subscribeRemovedRegisters returns a channel receiving the registers removed from the view's devices.
+func subscribeRemovedRegisters(ctx context.Context, env *Environment, viewDevices []ViewDeviceConfig) <-chan removedRegister {
+	out := make(chan removedRegister)
+	for _, vd := range viewDevices {
+		deviceWatcher := env.DevicePool.GetByName(vd.Name())
+		if deviceWatcher == nil {
+			continue
+		}
+
+		deviceName := vd.Name()
+		events := deviceWatcher.Service().RegisterDb().SubscribeEvents(ctx, dataflow.RegisterFilter(vd.Filter()))
+		go func() {
+			// drain the subscription until it is closed; this way the register db is never blocked
+			for ev := range events {
+				if ev.Type != dataflow.RegisterRemoved || ctx.Err() != nil {
+					continue
+				}
+				select {
+				case out <- removedRegister{deviceName: deviceName, register: ev.Register}:
+				case <-ctx.Done():
+				}
+			}
+		}()
+	}
+	return out
+}
+
 func wsSendResponse(
 	ctx context.Context,
 	conn *websocket.Conn,
 	operation string,
 	registers map[string]map[string]registerResponse,
 	values map[string]map[string]valueResponse,
+	meta map[string]map[string]valueMetaResponse,
+	alarmStates []alarmResponse,
 ) error {
 	w, err := conn.Writer(ctx, websocket.MessageText)
 	if err != nil {
@@ -213,6 +306,8 @@ func wsSendResponse(
 		Operation: operation,
 		Registers: registers,
 		Values:    values,
+		Meta:      meta,
+		Alarms:    alarmStates,
 	})
 	err2 := w.Close()
 	if err1 != nil {
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/influxDb"
+	"github.com/koestler/go-iotdevice/v3/pool"
+	"github.com/koestler/go-iotdevice/v3/restarter"
+	"log"
+)
+
+func runInfluxDbOutputs(
+	cfg *config.Config,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+) *pool.Pool[*influxDb.Writer] {
+	writerPool := pool.RunPool[*influxDb.Writer]()
+
+	model := func(deviceName string) string {
+		if d := devicePool.GetByName(deviceName); d != nil {
+			return d.Service().Model()
+		}
+		return ""
+	}
+
+	for _, outputCfg := range cfg.InfluxDbOutputs() {
+		if cfg.LogWorkerStart() {
+			log.Printf("influxDb[%s]: start: url=%s", outputCfg.Name(), outputCfg.Url().Redacted())
+		}
+
+		writer := influxDb.New(outputCfg, model)
+		writer.Subscribe(stateStorage, getDeviceFiltersValueFilter(outputCfg.Devices()))
+		writerPool.Add(writer)
+	}
+
+	return writerPool
+}
@@ -0,0 +1,93 @@
+package influxDb
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// buffer is a file holding the lines which could not be written yet; one line per line.
+type buffer struct {
+	path    string
+	maxSize int64
+}
+
+func newBuffer(dir, name string, maxSize int64) *buffer {
+	return &buffer{
+		path:    filepath.Join(dir, name+".lp"),
+		maxSize: maxSize,
+	}
+}
+
+// append adds the lines to the end of the buffer; when the buffer would exceed its max size,
+// the newest lines are dropped and their number is returned.
+func (b *buffer) append(lines []string) (dropped int, err error) {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0755); err != nil {
+		return 0, fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	f, err := os.OpenFile(b.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return 0, err
+	}
+	size := info.Size()
+
+	w := bufio.NewWriter(f)
+	for i, line := range lines {
+		size += int64(len(line)) + 1
+		if size > b.maxSize {
+			dropped = len(lines) - i
+			break
+		}
+		_, _ = w.WriteString(line)
+		_ = w.WriteByte('\n')
+	}
+
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return dropped, err
+	}
+	return dropped, f.Close()
+}
+
+// read returns all lines of the buffer.
+func (b *buffer) read() ([]string, error) {
+	data, err := os.ReadFile(b.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 1 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), nil
+}
+
+// replace sets the content of the buffer to the given lines; the file is removed when there are none.
+func (b *buffer) replace(lines []string) error {
+	if len(lines) < 1 {
+		err := os.Remove(b.path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// write to a temporary file first to never lose the buffer
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, b.path)
+}
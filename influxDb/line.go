@@ -0,0 +1,71 @@
+package influxDb
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// newlines are not supported by the line protocol; they are replaced by a literal \n
+var (
+	measurementEscaper = strings.NewReplacer(`,`, `\,`, ` `, `\ `, "\n", `\n`)
+	tagEscaper         = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+	stringEscaper      = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+)
+
+// formatLine converts a value into a line of the InfluxDB line protocol:
+// the register name is used as measurement, numbers are written into the float field value,
+// enums and bools into value (index) and text (label, if any) and texts into the field text.
+// ok is false for values which cannot be represented.
+func formatLine(value dataflow.Value, model string) (line string, ok bool) {
+	var fields string
+	switch v := value.(type) {
+	case dataflow.NumericRegisterValue:
+		if math.IsNaN(v.Value()) || math.IsInf(v.Value(), 0) {
+			// not supported by the line protocol
+			return "", false
+		}
+		fields = "value=" + formatFloat(v.Value())
+	case dataflow.IntRegisterValue:
+		fields = "value=" + formatFloat(float64(v.Value()))
+	case dataflow.EnumRegisterValue:
+		fields = "value=" + formatFloat(float64(v.EnumIdx())) + ",text=" + formatString(v.Value())
+	case dataflow.BoolRegisterValue:
+		fields = "value=" + formatFloat(float64(v.EnumIdx()))
+		if label := v.Label(); len(label) > 0 {
+			fields += ",text=" + formatString(label)
+		}
+	case dataflow.TextRegisterValue:
+		fields = "text=" + formatString(v.Value())
+	default:
+		return "", false
+	}
+
+	reg := value.Register()
+
+	var b strings.Builder
+	b.WriteString(measurementEscaper.Replace(reg.Name()))
+	// tags must be sorted by key; tags with empty values are not allowed
+	for _, tag := range [][2]string{
+		{"category", reg.Category()},
+		{"device", value.DeviceName()},
+		{"model", model},
+		{"unit", reg.Unit()},
+	} {
+		if len(tag[1]) > 0 {
+			b.WriteString("," + tag[0] + "=" + tagEscaper.Replace(tag[1]))
+		}
+	}
+	b.WriteString(" " + fields + " ")
+	b.WriteString(strconv.FormatInt(value.SampleTime().UnixNano(), 10))
+	return b.String(), true
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatString(s string) string {
+	return `"` + stringEscaper.Replace(s) + `"`
+}
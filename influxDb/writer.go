@@ -0,0 +1,282 @@
+// Package influxDb writes values to the http write endpoint of an InfluxDB using the line protocol.
+package influxDb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Config interface {
+	Name() string
+	Url() *url.URL
+	Version() int
+	Database() string
+	User() string
+	Password() string
+	Org() string
+	Bucket() string
+	Token() string
+	BatchSize() int
+	FlushInterval() time.Duration
+	Timeout() time.Duration
+	BufferPath() string
+	MaxBufferSize() int64
+	LogDebug() bool
+}
+
+// ModelFunc returns the model of the given device; it is written as tag.
+type ModelFunc func(deviceName string) string
+
+// Writer collects values and writes them in batches of at most BatchSize lines
+// whenever a batch is full or every FlushInterval.
+// Batches that cannot be written because the endpoint is unreachable are appended to a buffer file
+// and written before any new values once the endpoint is reachable again.
+type Writer struct {
+	cfg        Config
+	model      ModelFunc
+	httpClient *http.Client
+	buffer     *buffer
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+
+	mutex   sync.Mutex
+	pending []string
+	full    chan struct{}
+}
+
+// errPermanent marks errors where a retry is pointless, e.g. when the endpoint rejects the data.
+var errPermanent = errors.New("rejected")
+
+func New(cfg Config, model ModelFunc) *Writer {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Writer{
+		cfg:        cfg,
+		model:      model,
+		httpClient: &http.Client{Timeout: cfg.Timeout()},
+		ctx:        ctx,
+		ctxCancel:  cancel,
+		full:       make(chan struct{}, 1),
+	}
+	if len(cfg.BufferPath()) > 0 {
+		w.buffer = newBuffer(cfg.BufferPath(), cfg.Name(), cfg.MaxBufferSize())
+	}
+
+	w.wg.Add(1)
+	go w.mainRoutine()
+
+	return w
+}
+
+func (w *Writer) Name() string {
+	return w.cfg.Name()
+}
+
+// Shutdown stops the writer and writes the pending values; they are buffered when this fails.
+func (w *Writer) Shutdown() {
+	w.ctxCancel()
+	w.wg.Wait()
+	w.flush()
+}
+
+// Subscribe writes all values of the storage passing the filter.
+func (w *Writer) Subscribe(storage *dataflow.ValueStorage, filter dataflow.ValueFilterFunc) {
+	subscription := storage.SubscribeSendInitial(w.ctx, filter,
+		dataflow.WithName(fmt.Sprintf("influxDb[%s]", w.cfg.Name())),
+	)
+
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		for value := range subscription.Drain() {
+			if value.Quality() != dataflow.QualityGood {
+				continue
+			}
+			w.Add(value)
+		}
+	}()
+}
+
+// Add queues a value to be written with the next batch.
+func (w *Writer) Add(value dataflow.Value) {
+	line, ok := formatLine(value, w.model(value.DeviceName()))
+	if !ok {
+		return
+	}
+
+	w.mutex.Lock()
+	w.pending = append(w.pending, line)
+	full := len(w.pending) >= w.cfg.BatchSize()
+	w.mutex.Unlock()
+
+	if full {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (w *Writer) mainRoutine() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.cfg.FlushInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.flush()
+		case <-w.full:
+			w.flush()
+		}
+	}
+}
+
+// flush writes the buffered lines followed by the pending lines.
+func (w *Writer) flush() {
+	w.mutex.Lock()
+	lines := w.pending
+	w.pending = nil
+	w.mutex.Unlock()
+
+	if w.buffer != nil {
+		if err := w.writeBuffer(); err != nil {
+			w.bufferLines(lines, err)
+			return
+		}
+	}
+
+	for len(lines) > 0 {
+		n := min(len(lines), w.cfg.BatchSize())
+		if err := w.write(lines[:n]); err != nil {
+			if errors.Is(err, errPermanent) {
+				log.Printf("influxDb[%s]: %d lines dropped: %s", w.cfg.Name(), n, err)
+			} else {
+				w.bufferLines(lines, err)
+				return
+			}
+		}
+		lines = lines[n:]
+	}
+}
+
+// writeBuffer writes all lines of the buffer file; it returns an error when the endpoint is unreachable.
+func (w *Writer) writeBuffer() error {
+	lines, err := w.buffer.read()
+	if err != nil {
+		log.Printf("influxDb[%s]: cannot read buffer: %s", w.cfg.Name(), err)
+		return nil
+	}
+	if len(lines) < 1 {
+		return nil
+	}
+
+	if w.cfg.LogDebug() {
+		log.Printf("influxDb[%s]: write %d buffered lines", w.cfg.Name(), len(lines))
+	}
+
+	for i := 0; i < len(lines); i += w.cfg.BatchSize() {
+		batch := lines[i:min(i+w.cfg.BatchSize(), len(lines))]
+		if err := w.write(batch); err != nil {
+			if errors.Is(err, errPermanent) {
+				log.Printf("influxDb[%s]: %d buffered lines dropped: %s", w.cfg.Name(), len(batch), err)
+				continue
+			}
+			if e := w.buffer.replace(lines[i:]); e != nil {
+				log.Printf("influxDb[%s]: cannot write buffer: %s", w.cfg.Name(), e)
+			}
+			return err
+		}
+	}
+
+	if err := w.buffer.replace(nil); err != nil {
+		log.Printf("influxDb[%s]: cannot write buffer: %s", w.cfg.Name(), err)
+	}
+	return nil
+}
+
+func (w *Writer) bufferLines(lines []string, cause error) {
+	if len(lines) < 1 {
+		return
+	}
+	if w.buffer == nil {
+		log.Printf("influxDb[%s]: %d lines dropped: %s", w.cfg.Name(), len(lines), cause)
+		return
+	}
+
+	if w.cfg.LogDebug() {
+		log.Printf("influxDb[%s]: buffer %d lines: %s", w.cfg.Name(), len(lines), cause)
+	}
+	if dropped, err := w.buffer.append(lines); err != nil {
+		log.Printf("influxDb[%s]: cannot write buffer, %d lines dropped: %s", w.cfg.Name(), len(lines), err)
+	} else if dropped > 0 {
+		log.Printf("influxDb[%s]: buffer is full, %d lines dropped", w.cfg.Name(), dropped)
+	}
+}
+
+func (w *Writer) write(lines []string) error {
+	body := strings.Join(lines, "\n") + "\n"
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, w.writeUrl(), strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %s", errPermanent, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if w.cfg.Version() == 1 {
+		if len(w.cfg.User()) > 0 {
+			req.SetBasicAuth(w.cfg.User(), w.cfg.Password())
+		}
+	} else if len(w.cfg.Token()) > 0 {
+		req.Header.Set("Authorization", "Token "+w.cfg.Token())
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 == 2 {
+		if w.cfg.LogDebug() {
+			log.Printf("influxDb[%s]: %d lines written", w.cfg.Name(), len(lines))
+		}
+		return nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	msg = bytes.TrimSpace(msg)
+	if resp.StatusCode/100 == 4 && resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusRequestTimeout {
+		return fmt.Errorf("%w: status=%d: %s", errPermanent, resp.StatusCode, msg)
+	}
+	return fmt.Errorf("status=%d: %s", resp.StatusCode, msg)
+}
+
+func (w *Writer) writeUrl() string {
+	u := *w.cfg.Url()
+	q := url.Values{}
+	q.Set("precision", "ns")
+	if w.cfg.Version() == 1 {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/write"
+		q.Set("db", w.cfg.Database())
+	} else {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/api/v2/write"
+		q.Set("org", w.cfg.Org())
+		q.Set("bucket", w.cfg.Bucket())
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
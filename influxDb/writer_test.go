@@ -0,0 +1,191 @@
+package influxDb_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/influxDb"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	url        *url.URL
+	version    int
+	batchSize  int
+	bufferPath string
+}
+
+func (c testConfig) Name() string                 { return "test" }
+func (c testConfig) Url() *url.URL                { return c.url }
+func (c testConfig) Version() int                 { return c.version }
+func (c testConfig) Database() string             { return "iot" }
+func (c testConfig) User() string                 { return "user" }
+func (c testConfig) Password() string             { return "secret" }
+func (c testConfig) Org() string                  { return "home" }
+func (c testConfig) Bucket() string               { return "iot" }
+func (c testConfig) Token() string                { return "token" }
+func (c testConfig) BatchSize() int               { return c.batchSize }
+func (c testConfig) FlushInterval() time.Duration { return time.Hour }
+func (c testConfig) Timeout() time.Duration       { return time.Second }
+func (c testConfig) BufferPath() string           { return c.bufferPath }
+func (c testConfig) MaxBufferSize() int64         { return 1 << 20 }
+func (c testConfig) LogDebug() bool               { return false }
+
+// fakeEndpoint records all requests; it answers with status as long as it is not 0, with 204 otherwise.
+type fakeEndpoint struct {
+	mutex    sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   []string
+}
+
+func (e *fakeEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	if e.status != 0 {
+		w.WriteHeader(e.status)
+		return
+	}
+	e.requests = append(e.requests, r)
+	e.bodies = append(e.bodies, string(body))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (e *fakeEndpoint) lines() (ret []string) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for _, b := range e.bodies {
+		ret = append(ret, strings.Split(strings.TrimSuffix(b, "\n"), "\n")...)
+	}
+	return
+}
+
+func startEndpoint(t *testing.T) (*fakeEndpoint, *url.URL) {
+	endpoint := &fakeEndpoint{}
+	server := httptest.NewServer(endpoint)
+	t.Cleanup(server.Close)
+	u, _ := url.Parse(server.URL)
+	return endpoint, u
+}
+
+var (
+	ts      = time.Unix(1700000000, 0)
+	voltage = dataflow.NewRegisterStruct("Battery", "Voltage", "", dataflow.NumberRegister, nil, "V", 0, false)
+	state   = dataflow.NewRegisterStruct("Charger State", "State", "", dataflow.EnumRegister, map[int]string{0: "Off", 1: "Bulk"}, "", 0, false)
+	label   = dataflow.NewRegisterStruct("Device Info", "Product Name", "", dataflow.TextRegister, nil, "", 0, false)
+)
+
+func model(string) string { return "BMV-702" }
+
+func TestWriterV2(t *testing.T) {
+	endpoint, u := startEndpoint(t)
+
+	w := influxDb.New(testConfig{url: u, version: 2, batchSize: 2}, model)
+	w.Add(dataflow.NewNumericRegisterValue("bmv 0", voltage, 12.5).WithTimes(ts, time.Time{}))
+	w.Add(dataflow.NewEnumRegisterValue("bmv 0", state, 1).WithTimes(ts, time.Time{}))
+	w.Add(dataflow.NewTextRegisterValue("bmv 0", label, `say "hi"`).WithTimes(ts, time.Time{}))
+	w.Shutdown()
+
+	expect := []string{
+		`Voltage,category=Battery,device=bmv\ 0,model=BMV-702,unit=V value=12.5 1700000000000000000`,
+		`State,category=Charger\ State,device=bmv\ 0,model=BMV-702 value=1,text="Bulk" 1700000000000000000`,
+		`Product\ Name,category=Device\ Info,device=bmv\ 0,model=BMV-702 text="say \"hi\"" 1700000000000000000`,
+	}
+	if got := endpoint.lines(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect lines\n%s\nbut got\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+
+	if expect, got := 2, len(endpoint.requests); expect != got {
+		t.Fatalf("expect %d batches but got %d", expect, got)
+	}
+	r := endpoint.requests[0]
+	if expect, got := "/api/v2/write", r.URL.Path; expect != got {
+		t.Errorf("expect path %s but got %s", expect, got)
+	}
+	if expect, got := "bucket=iot&org=home&precision=ns", r.URL.RawQuery; expect != got {
+		t.Errorf("expect query %s but got %s", expect, got)
+	}
+	if expect, got := "Token token", r.Header.Get("Authorization"); expect != got {
+		t.Errorf("expect authorization %s but got %s", expect, got)
+	}
+}
+
+func TestWriterV1(t *testing.T) {
+	endpoint, u := startEndpoint(t)
+
+	w := influxDb.New(testConfig{url: u, version: 1, batchSize: 100}, model)
+	w.Add(dataflow.NewNumericRegisterValue("bmv0", voltage, 12.5).WithTimes(ts, time.Time{}))
+	w.Shutdown()
+
+	if expect, got := 1, len(endpoint.requests); expect != got {
+		t.Fatalf("expect %d batches but got %d", expect, got)
+	}
+	r := endpoint.requests[0]
+	if expect, got := "/write", r.URL.Path; expect != got {
+		t.Errorf("expect path %s but got %s", expect, got)
+	}
+	if expect, got := "db=iot&precision=ns", r.URL.RawQuery; expect != got {
+		t.Errorf("expect query %s but got %s", expect, got)
+	}
+	if user, password, ok := r.BasicAuth(); !ok || user != "user" || password != "secret" {
+		t.Errorf("expect basic auth user:secret but got %s:%s", user, password)
+	}
+}
+
+func TestWriterBuffer(t *testing.T) {
+	endpoint, u := startEndpoint(t)
+	cfg := testConfig{url: u, version: 2, batchSize: 100, bufferPath: t.TempDir()}
+
+	// the endpoint is unavailable: the values are buffered on disk
+	endpoint.status = http.StatusServiceUnavailable
+	w := influxDb.New(cfg, model)
+	w.Add(dataflow.NewNumericRegisterValue("bmv0", voltage, 12.5).WithTimes(ts, time.Time{}))
+	w.Shutdown()
+
+	// the endpoint is available again: the buffer is written before the new values
+	endpoint.status = 0
+	w = influxDb.New(cfg, model)
+	w.Add(dataflow.NewNumericRegisterValue("bmv0", voltage, 12.6).WithTimes(ts.Add(time.Second), time.Time{}))
+	w.Shutdown()
+
+	expect := []string{
+		`Voltage,category=Battery,device=bmv0,model=BMV-702,unit=V value=12.5 1700000000000000000`,
+		`Voltage,category=Battery,device=bmv0,model=BMV-702,unit=V value=12.6 1700000001000000000`,
+	}
+	if got := endpoint.lines(); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect lines\n%s\nbut got\n%s", strings.Join(expect, "\n"), strings.Join(got, "\n"))
+	}
+
+	// the buffer is empty now
+	w = influxDb.New(cfg, model)
+	w.Shutdown()
+	if expect, got := 2, len(endpoint.lines()); expect != got {
+		t.Errorf("expect no lines to be written twice, got %d lines", got)
+	}
+}
+
+func TestWriterDropsRejectedBatches(t *testing.T) {
+	endpoint, u := startEndpoint(t)
+	cfg := testConfig{url: u, version: 2, batchSize: 100, bufferPath: t.TempDir()}
+
+	endpoint.status = http.StatusBadRequest
+	w := influxDb.New(cfg, model)
+	w.Add(dataflow.NewNumericRegisterValue("bmv0", voltage, 12.5).WithTimes(ts, time.Time{}))
+	w.Shutdown()
+
+	endpoint.status = 0
+	w = influxDb.New(cfg, model)
+	w.Shutdown()
+
+	if got := endpoint.lines(); len(got) > 0 {
+		t.Errorf("expect rejected lines not to be buffered but got %v", got)
+	}
+}
@@ -100,6 +100,8 @@ func main() {
 		}
 		stateStorage := runStorage(stateStorageLogPrefix)
 		defer stateStorage.Shutdown()
+		stateStorage.SetMaxAge(getStateStorageMaxAge(cfg))
+		stateStorage.SetHistory(getStateStorageHistory(cfg))
 
 		commandStorageLogPrefix := ""
 		if cfg.LogCommandStorageDebug() {
@@ -108,6 +110,12 @@ func main() {
 		commandStorage := runStorage(commandStorageLogPrefix)
 		defer commandStorage.Shutdown()
 
+		// restore the snapshot before any device is started
+		snapshotter := runSnapshot(cfg, stateStorage, commandStorage)
+		if snapshotter != nil {
+			defer snapshotter.Shutdown()
+		}
+
 		// start modbus device handlers
 		modbusPool := runModbus(cfg)
 		defer modbusPool.Shutdown()
@@ -126,15 +134,63 @@ func main() {
 		// start mqtt clients
 		runMqttDevices(cfg, devicePool, mqttClientPool, stateStorage, commandStorage)
 
+		// start system devices
+		runSystemDevices(cfg, devicePool, mqttClientPool, stateStorage, commandStorage)
+
+		// start computed devices
+		runComputedDevices(cfg, devicePool, stateStorage)
+
+		// start alarms
+		alarmEngine := runAlarms(cfg, stateStorage)
+		if alarmEngine != nil {
+			defer alarmEngine.Shutdown()
+		}
+
 		// start mqtt forwarders
-		runMqttForwarders(cfg, devicePool, mqttClientPool, stateStorage, commandStorage)
+		forwarders := runMqttForwarders(cfg, devicePool, mqttClientPool, stateStorage, commandStorage, alarmEngine)
 
 		// start genset devices
 		runGensetDevices(cfg, devicePool, stateStorage, commandStorage)
 
+		// start rules
+		rulesEngine := runRules(cfg, devicePool, mqttClientPool, stateStorage, commandStorage)
+		if rulesEngine != nil {
+			defer rulesEngine.Shutdown()
+		}
+
+		// start archive
+		archive := runArchive(cfg, stateStorage)
+		if archive != nil {
+			defer archive.Shutdown()
+		}
+
+		// start influxDb outputs
+		influxDbPool := runInfluxDbOutputs(cfg, devicePool, stateStorage)
+		defer influxDbPool.Shutdown()
+
+		// start modbus servers
+		modbusServerPool := runModbusServers(cfg, devicePool, stateStorage, commandStorage)
+		defer modbusServerPool.Shutdown()
+
+		// setup config reloading
+		configReloader := &reloader{
+			cmdName:        cmdName,
+			path:           string(cmdOptions.Config),
+			started:        cfg,
+			cfg:            cfg,
+			modbusPool:     modbusPool,
+			devicePool:     devicePool,
+			mqttClientPool: mqttClientPool,
+			stateStorage:   stateStorage,
+			commandStorage: commandStorage,
+			alarmEngine:    alarmEngine,
+			forwarders:     forwarders,
+		}
+
 		// start http server
-		httpServer := runHttpServer(cfg, devicePool, stateStorage, commandStorage)
+		httpServer := runHttpServer(cfg, devicePool, mqttClientPool, stateStorage, commandStorage, archive, rulesEngine, alarmEngine, configReloader.Reload)
 		if httpServer != nil {
+			configReloader.setHttpServer(httpServer)
 			defer httpServer.Shutdown()
 		}
 
@@ -143,8 +199,20 @@ func main() {
 		signal.Notify(gracefulStop, syscall.SIGTERM)
 		signal.Notify(gracefulStop, syscall.SIGINT)
 
+		// setup SIGHUP handler to reload the config
+		reload := make(chan os.Signal, 1)
+		signal.Notify(reload, syscall.SIGHUP)
+
 		// wait for something to trigger a shutdown
-		sig := <-gracefulStop
+		var sig os.Signal
+		for sig == nil {
+			select {
+			case <-reload:
+				log.Printf("main: caught SIGHUP; reload config")
+				_, _ = configReloader.Reload()
+			case sig = <-gracefulStop:
+			}
+		}
 
 		if cfg.LogWorkerStart() {
 			log.Printf("main: graceful shutdown; caught signal: %+v", sig)
@@ -4,6 +4,7 @@ import (
 	"github.com/koestler/go-iotdevice/v3/config"
 	"github.com/koestler/go-iotdevice/v3/modbus"
 	"github.com/koestler/go-iotdevice/v3/pool"
+	"github.com/koestler/go-iotdevice/v3/types"
 	"log"
 )
 
@@ -14,18 +15,33 @@ func runModbus(
 	modbusPool = pool.RunPool[*modbus.ModbusStruct]()
 
 	for _, mbCfg := range cfg.Modbus() {
-		if cfg.LogWorkerStart() {
+		runModbusBus(cfg, mbCfg, modbusPool)
+	}
+
+	return
+}
+
+func runModbusBus(
+	cfg *config.Config,
+	mbCfg config.ModbusConfig,
+	modbusPool *pool.Pool[*modbus.ModbusStruct],
+) {
+	if cfg.LogWorkerStart() {
+		if mbCfg.Kind() == types.ModbusBusSerialKind {
 			log.Printf(
 				"modbus[%s]: start: device='%s', baudRate=%d, readTimeout=%s",
 				mbCfg.Name(), mbCfg.Device(), mbCfg.BaudRate(), mbCfg.ReadTimeout(),
 			)
-		}
-		if mb, err := modbus.New(mbCfg); err != nil {
-			log.Printf("modbus[%s]: start failed: %s", mbCfg.Name(), err)
 		} else {
-			modbusPool.Add(mb)
+			log.Printf(
+				"modbus[%s]: start: kind=%s, url='%s', readTimeout=%s",
+				mbCfg.Name(), mbCfg.Kind(), mbCfg.Url(), mbCfg.ReadTimeout(),
+			)
 		}
 	}
-
-	return
+	if mb, err := modbus.New(mbCfg); err != nil {
+		log.Printf("modbus[%s]: start failed: %s", mbCfg.Name(), err)
+	} else {
+		modbusPool.Add(mb)
+	}
 }
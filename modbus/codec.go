@@ -0,0 +1,125 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// RegisterCodec converts between numbers and the content of one or more consecutive 16 bit registers.
+// The supported data types are u16, i16, u32, i32, float32 and float64.
+type RegisterCodec struct {
+	DataType string
+	Length   int  // number of 16 bit registers
+	WordSwap bool // the least significant word is sent first
+	ByteSwap bool // the least significant byte of each word is sent first
+	Scale    float64
+}
+
+// NewRegisterCodec creates a codec from the configured word and byte order, which are either BigEndian or LittleEndian.
+func NewRegisterCodec(dataType string, length int, wordOrder, byteOrder string, scale float64) RegisterCodec {
+	return RegisterCodec{
+		DataType: dataType,
+		Length:   length,
+		WordSwap: wordOrder == "LittleEndian",
+		ByteSwap: byteOrder == "LittleEndian",
+		Scale:    scale,
+	}
+}
+
+// Swap converts between the order on the bus and big endian; it is its own inverse.
+func (c RegisterCodec) Swap(data []byte) []byte {
+	ret := make([]byte, len(data))
+	copy(ret, data)
+	if c.ByteSwap {
+		for i := 0; i+1 < len(ret); i += 2 {
+			ret[i], ret[i+1] = ret[i+1], ret[i]
+		}
+	}
+	if c.WordSwap {
+		for i, j := 0, len(ret)-2; i < j; i, j = i+2, j-2 {
+			ret[i], ret[i+1], ret[j], ret[j+1] = ret[j], ret[j+1], ret[i], ret[i+1]
+		}
+	}
+	return ret
+}
+
+// Encode divides the value by the scale and converts it into the content of the registers.
+func (c RegisterCodec) Encode(value float64) ([]byte, error) {
+	return c.EncodeRaw(value / c.Scale)
+}
+
+// EncodeRaw converts the value into the content of the registers without applying the scale.
+// Values of integer types are rounded; an error is returned when they are out of the range of the type.
+func (c RegisterCodec) EncodeRaw(raw float64) ([]byte, error) {
+	inRange := func(min, max float64) error {
+		raw = math.Round(raw)
+		if raw < min || raw > max {
+			return fmt.Errorf("value %v is out of the range of %s", raw, c.DataType)
+		}
+		return nil
+	}
+
+	data := make([]byte, 2*c.Length)
+	switch c.DataType {
+	case "u16":
+		if err := inRange(0, math.MaxUint16); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint16(data, uint16(raw))
+	case "i16":
+		if err := inRange(math.MinInt16, math.MaxInt16); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint16(data, uint16(int16(raw)))
+	case "u32":
+		if err := inRange(0, math.MaxUint32); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(data, uint32(raw))
+	case "i32":
+		if err := inRange(math.MinInt32, math.MaxInt32); err != nil {
+			return nil, err
+		}
+		binary.BigEndian.PutUint32(data, uint32(int32(raw)))
+	case "float32":
+		binary.BigEndian.PutUint32(data, math.Float32bits(float32(raw)))
+	case "float64":
+		binary.BigEndian.PutUint64(data, math.Float64bits(raw))
+	default:
+		return nil, fmt.Errorf("type %s is not implemented", c.DataType)
+	}
+
+	return c.Swap(data), nil
+}
+
+// Decode converts the content of the registers into a number and multiplies it by the scale.
+func (c RegisterCodec) Decode(data []byte) (float64, error) {
+	raw, err := c.DecodeRaw(data)
+	return raw * c.Scale, err
+}
+
+// DecodeRaw converts the content of the registers into a number without applying the scale.
+func (c RegisterCodec) DecodeRaw(data []byte) (float64, error) {
+	if len(data) != 2*c.Length {
+		return 0, fmt.Errorf("expect %d bytes but got %d", 2*c.Length, len(data))
+	}
+	data = c.Swap(data)
+
+	switch c.DataType {
+	case "u16":
+		return float64(binary.BigEndian.Uint16(data)), nil
+	case "i16":
+		return float64(int16(binary.BigEndian.Uint16(data))), nil
+	case "u32":
+		return float64(binary.BigEndian.Uint32(data)), nil
+	case "i32":
+		return float64(int32(binary.BigEndian.Uint32(data))), nil
+	case "float32":
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data))), nil
+	case "float64":
+		return math.Float64frombits(binary.BigEndian.Uint64(data)), nil
+	default:
+		return 0, fmt.Errorf("type %s is not implemented", c.DataType)
+	}
+}
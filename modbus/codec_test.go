@@ -0,0 +1,70 @@
+package modbus_test
+
+import (
+	"bytes"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"testing"
+)
+
+func TestRegisterCodec(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec modbus.RegisterCodec
+		value float64
+		data  []byte
+	}{
+		{"u16", modbus.NewRegisterCodec("u16", 1, "BigEndian", "BigEndian", 0.1), 123.4, []byte{0x04, 0xd2}},
+		{"i16", modbus.NewRegisterCodec("i16", 1, "BigEndian", "BigEndian", 1), -2, []byte{0xff, 0xfe}},
+		{"u32", modbus.NewRegisterCodec("u32", 2, "BigEndian", "BigEndian", 1), 0x01020304, []byte{0x01, 0x02, 0x03, 0x04}},
+		{"u32WordSwap", modbus.NewRegisterCodec("u32", 2, "LittleEndian", "BigEndian", 1), 0x01020304, []byte{0x03, 0x04, 0x01, 0x02}},
+		{"u32ByteSwap", modbus.NewRegisterCodec("u32", 2, "BigEndian", "LittleEndian", 1), 0x01020304, []byte{0x02, 0x01, 0x04, 0x03}},
+		{"i32", modbus.NewRegisterCodec("i32", 2, "LittleEndian", "LittleEndian", 1), -1, []byte{0xff, 0xff, 0xff, 0xff}},
+		{"float32", modbus.NewRegisterCodec("float32", 2, "BigEndian", "BigEndian", 1), 1.5, []byte{0x3f, 0xc0, 0x00, 0x00}},
+		{"float64", modbus.NewRegisterCodec("float64", 4, "BigEndian", "BigEndian", 1), -2, []byte{0xc0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := tc.codec.Encode(tc.value)
+			if err != nil {
+				t.Fatalf("expect no error but got %s", err)
+			}
+			if !bytes.Equal(tc.data, data) {
+				t.Errorf("expect %x but got %x", tc.data, data)
+			}
+
+			value, err := tc.codec.Decode(tc.data)
+			if err != nil {
+				t.Fatalf("expect no error but got %s", err)
+			}
+			if tc.value != value {
+				t.Errorf("expect %v but got %v", tc.value, value)
+			}
+		})
+	}
+
+	if _, err := modbus.NewRegisterCodec("u16", 1, "BigEndian", "BigEndian", 1).Encode(70000); err == nil {
+		t.Error("expect an error when encoding a value out of the range of the type")
+	}
+	if _, err := modbus.NewRegisterCodec("u32", 2, "BigEndian", "BigEndian", 1).Decode([]byte{0x01, 0x02}); err == nil {
+		t.Error("expect an error when decoding too few bytes")
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	// read holding register 0x0000 of slave 1, a frame commonly found in the documentation of devices
+	frame := modbus.AppendChecksum([]byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01})
+	if expect := []byte{0x01, 0x03, 0x00, 0x00, 0x00, 0x01, 0x84, 0x0a}; !bytes.Equal(expect, frame) {
+		t.Errorf("expect %x but got %x", expect, frame)
+	}
+	if !modbus.ValidChecksum(frame) {
+		t.Error("expect the checksum to be valid")
+	}
+	frame[2] ^= 0xff
+	if modbus.ValidChecksum(frame) {
+		t.Error("expect the checksum of a corrupted frame to be invalid")
+	}
+	if modbus.ValidChecksum([]byte{0x01}) {
+		t.Error("expect a too short frame to be invalid")
+	}
+}
@@ -0,0 +1,27 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"github.com/sigurn/crc16"
+)
+
+var crcTable = crc16.MakeTable(crc16.CRC16_MODBUS)
+
+// Checksum returns the crc16 of the given part of a rtu frame.
+func Checksum(data []byte) uint16 {
+	return crc16.Checksum(data, crcTable)
+}
+
+// AppendChecksum completes a rtu frame by appending its crc16; it is sent in little endian.
+func AppendChecksum(frame []byte) []byte {
+	return binary.LittleEndian.AppendUint16(frame, Checksum(frame))
+}
+
+// ValidChecksum returns true when the last two bytes of the rtu frame are the crc16 of the rest.
+func ValidChecksum(frame []byte) bool {
+	n := len(frame) - 2
+	if n < 0 {
+		return false
+	}
+	return Checksum(frame[:n]) == binary.LittleEndian.Uint16(frame[n:])
+}
@@ -1,11 +1,18 @@
 package modbus
 
-import "time"
+import (
+	"github.com/koestler/go-iotdevice/v3/types"
+	"net/url"
+	"time"
+)
 
 type Config interface {
 	Name() string
+	Kind() types.ModbusBusKind
 	Device() string
 	BaudRate() int
+	Url() *url.URL
 	ReadTimeout() time.Duration
+	InterFrameDelay() time.Duration
 	LogDebug() bool
 }
@@ -1,104 +1,97 @@
 package modbus
 
 import (
-	"bufio"
 	"fmt"
-	"github.com/tarm/serial"
-	"io"
+	"github.com/koestler/go-iotdevice/v3/types"
 	"log"
 	"sync"
+	"time"
 )
 
+// transport sends a request given as rtu frame (address, function code, payload, crc16) and reads the response
+// into responseBuf, again as rtu frame, such that the device drivers are independent of the underlying transport.
+type transport interface {
+	writeRead(request []byte, responseBuf []byte, timeout time.Duration) error
+	close() error
+}
+
 type ModbusStruct struct {
 	cfg Config
 
-	ioPort *serial.Port
-	reader *bufio.Reader
+	transport transport
+
+	// the scheduler sends one request after the other; commands are sent before polls
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	queues   [priorityCount][]*job
+	shutdown bool
+	done     chan struct{}
+	lastEnd  time.Time // only accessed by the scheduler routine
 
-	mutex sync.Mutex
+	statsMutex sync.Mutex
+	stats      map[byte]*SlaveStats
 }
 
 func New(cfg Config) (*ModbusStruct, error) {
-	if cfg.LogDebug() {
-		log.Printf("modbus[%s]: create device=%v", cfg.Name(), cfg.Device())
+	md := &ModbusStruct{
+		cfg:   cfg,
+		done:  make(chan struct{}),
+		stats: make(map[byte]*SlaveStats),
 	}
-
-	options := serial.Config{
-		Name:        cfg.Device(),
-		Baud:        cfg.BaudRate(),
-		ReadTimeout: cfg.ReadTimeout(),
-	}
-
-	ioHandle, err := serial.OpenPort(&options)
-	if err != nil {
-		return nil, fmt.Errorf("cannot open device: %v", cfg.Device())
+	md.cond = sync.NewCond(&md.mutex)
+
+	switch cfg.Kind() {
+	case types.ModbusBusSerialKind:
+		t, err := newSerialTransport(md)
+		if err != nil {
+			return nil, err
+		}
+		md.transport = t
+	case types.ModbusBusTcpKind:
+		md.transport = newTcpTransport(md, true)
+	case types.ModbusBusRtuOverTcpKind:
+		md.transport = newTcpTransport(md, false)
+	default:
+		return nil, fmt.Errorf("unknown kind: %s", cfg.Kind())
 	}
 
-	if cfg.LogDebug() {
-		log.Printf("modbus[%s]: Open succeeded", cfg.Name())
-	}
+	go md.runScheduler()
 
-	return &ModbusStruct{
-		cfg:    cfg,
-		ioPort: ioHandle,
-		reader: bufio.NewReader(ioHandle),
-	}, nil
+	return md, nil
 }
 
 func (md *ModbusStruct) Name() string {
 	return md.cfg.Name()
 }
 
+// Shutdown rejects all queued requests, waits for the current request to complete and closes the transport.
 func (md *ModbusStruct) Shutdown() {
-	if err := md.ioPort.Close(); err != nil {
-		md.debugPrintf("Shutdown err=%v", err)
-	} else {
-		md.debugPrintf("Shutdown successful")
-	}
-}
-
-func (md *ModbusStruct) WriteRead(request []byte, responseBuf []byte) error {
 	md.mutex.Lock()
-	defer md.mutex.Unlock()
-
-	// flush receiver
-	md.RecvFlush()
-
-	// send request
-	if _, err := md.Write(request); err != nil {
-		return err
+	md.shutdown = true
+	queues := md.queues
+	md.queues = [priorityCount][]*job{}
+	md.cond.Broadcast()
+	md.mutex.Unlock()
+
+	for _, q := range queues {
+		for _, j := range q {
+			j.result <- errShutdown
+		}
 	}
+	<-md.done
 
-	// read response or return error
-	_, err := io.ReadFull(md, responseBuf)
-	return err
-}
-
-func (md *ModbusStruct) Read(b []byte) (n int, err error) {
-	n, err = md.ioPort.Read(b)
-	if err != nil {
-		md.debugPrintf("Read error: %v\n", err)
+	if err := md.transport.close(); err != nil {
+		md.debugPrintf("Shutdown err=%v", err)
 	} else {
-		md.debugPrintf("Read b=%x len=%v", b, len(b))
+		md.debugPrintf("Shutdown successful")
 	}
-	return
 }
 
-func (md *ModbusStruct) Write(b []byte) (n int, err error) {
-	md.debugPrintf("Write b=%x len=%v", b, len(b))
-	n, err = md.ioPort.Write(b)
-	if err != nil {
-		log.Printf("Write error: %v\n", err)
-		return 0, err
-	}
-	return
+// WriteRead sends a request with poll priority using the read timeout of the bus and no retries.
+func (md *ModbusStruct) WriteRead(request []byte, responseBuf []byte) error {
+	return md.WriteReadWithOptions(RequestOptions{}, request, responseBuf)
 }
 
-func (md *ModbusStruct) RecvFlush() {
-	if err := md.ioPort.Flush(); err != nil {
-		md.debugPrintf("Flush err=%v", err)
-	} else {
-		md.debugPrintf("Flush err=%v", err)
-	}
-	md.reader.Reset(md.ioPort)
+func (md *ModbusStruct) logPrintf(format string, v ...interface{}) {
+	log.Printf("modbus[%s]: %s", md.cfg.Name(), fmt.Sprintf(format, v...))
 }
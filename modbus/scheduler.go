@@ -0,0 +1,197 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Priority defines the order in which queued requests are sent; requests of the same priority are sent in order.
+type Priority int
+
+const (
+	PriorityPoll Priority = iota
+	PriorityCommand
+	priorityCount
+)
+
+// RequestOptions define how a single request is sent.
+type RequestOptions struct {
+	Priority Priority
+	// Timeout is how long to wait for the response; the ReadTimeout of the bus is used when 0.
+	Timeout time.Duration
+	// Retries is how many times the request is sent again after a timeout or a checksum mismatch.
+	Retries int
+}
+
+// ErrTimeout is returned when the slave did not respond within the timeout.
+var ErrTimeout = errors.New("timeout")
+
+// ErrChecksumMismatch is returned when the crc16 of a response is invalid; usually caused by noise on the bus.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+var errShutdown = errors.New("modbus is shut down")
+
+// SlaveStats holds the counters of the requests sent to a single slave address.
+type SlaveStats struct {
+	Address    byte
+	Requests   uint64 // every sent request including retries
+	Timeouts   uint64
+	CrcErrors  uint64
+	Exceptions uint64
+}
+
+// BusStats is a snapshot of the state of the scheduler.
+type BusStats struct {
+	QueueLength int
+	Slaves      []SlaveStats // ordered by address
+}
+
+type job struct {
+	opts        RequestOptions
+	request     []byte
+	responseBuf []byte
+	result      chan error
+}
+
+// WriteReadWithOptions queues the request and waits until it is sent and its response is read.
+func (md *ModbusStruct) WriteReadWithOptions(opts RequestOptions, request []byte, responseBuf []byte) error {
+	if len(request) < 4 {
+		return fmt.Errorf("request too short: %x", request)
+	}
+	if opts.Priority < 0 || opts.Priority >= priorityCount {
+		return fmt.Errorf("invalid priority: %d", opts.Priority)
+	}
+
+	j := &job{
+		opts:        opts,
+		request:     request,
+		responseBuf: responseBuf,
+		result:      make(chan error, 1),
+	}
+
+	md.mutex.Lock()
+	if md.shutdown {
+		md.mutex.Unlock()
+		return errShutdown
+	}
+	md.queues[opts.Priority] = append(md.queues[opts.Priority], j)
+	md.cond.Signal()
+	md.mutex.Unlock()
+
+	return <-j.result
+}
+
+// Stats returns the current queue length and the counters of all slaves a request was sent to.
+func (md *ModbusStruct) Stats() (ret BusStats) {
+	md.mutex.Lock()
+	for _, q := range md.queues {
+		ret.QueueLength += len(q)
+	}
+	md.mutex.Unlock()
+
+	md.statsMutex.Lock()
+	ret.Slaves = make([]SlaveStats, 0, len(md.stats))
+	for _, s := range md.stats {
+		ret.Slaves = append(ret.Slaves, *s)
+	}
+	md.statsMutex.Unlock()
+
+	slices.SortFunc(ret.Slaves, func(a, b SlaveStats) int {
+		return int(a.Address) - int(b.Address)
+	})
+	return
+}
+
+func (md *ModbusStruct) runScheduler() {
+	defer close(md.done)
+	for {
+		j := md.next()
+		if j == nil {
+			return
+		}
+		j.result <- md.execute(j)
+	}
+}
+
+// next blocks until a request is queued and returns the oldest one of the highest priority; nil after a shutdown.
+func (md *ModbusStruct) next() *job {
+	md.mutex.Lock()
+	defer md.mutex.Unlock()
+	for {
+		if md.shutdown {
+			return nil
+		}
+		for p := priorityCount - 1; p >= 0; p-- {
+			if q := md.queues[p]; len(q) > 0 {
+				md.queues[p] = q[1:]
+				return q[0]
+			}
+		}
+		md.cond.Wait()
+	}
+}
+
+func (md *ModbusStruct) execute(j *job) (err error) {
+	timeout := j.opts.Timeout
+	if timeout <= 0 {
+		timeout = md.cfg.ReadTimeout()
+	}
+
+	for attempt := 0; attempt <= j.opts.Retries; attempt++ {
+		if attempt > 0 {
+			md.debugPrintf("retry %d of request %x after: %v", attempt, j.request, err)
+		}
+
+		// keep the bus silent between two frames
+		if wait := time.Until(md.lastEnd.Add(md.cfg.InterFrameDelay())); wait > 0 {
+			time.Sleep(wait)
+		}
+		err = md.transport.writeRead(j.request, j.responseBuf, timeout)
+		md.lastEnd = time.Now()
+		if err == nil {
+			err = checkResponse(j.responseBuf)
+		}
+		md.count(j.request[0], err)
+
+		if !errors.Is(err, ErrTimeout) && !errors.Is(err, ErrChecksumMismatch) {
+			return
+		}
+	}
+	return
+}
+
+func checkResponse(response []byte) error {
+	n := len(response) - 2
+	if n < 2 {
+		return nil
+	}
+	received := binary.LittleEndian.Uint16(response[n:])
+	if computed := Checksum(response[:n]); received != computed {
+		return fmt.Errorf("%w: received != computed: %x != %x", ErrChecksumMismatch, received, computed)
+	}
+	return nil
+}
+
+func (md *ModbusStruct) count(address byte, err error) {
+	md.statsMutex.Lock()
+	defer md.statsMutex.Unlock()
+
+	s, ok := md.stats[address]
+	if !ok {
+		s = &SlaveStats{Address: address}
+		md.stats[address] = s
+	}
+
+	s.Requests += 1
+	switch {
+	case errors.Is(err, ErrTimeout):
+		s.Timeouts += 1
+	case errors.Is(err, ErrChecksumMismatch):
+		s.CrcErrors += 1
+	case errors.Is(err, ErrException):
+		s.Exceptions += 1
+	}
+}
@@ -0,0 +1,258 @@
+package modbus
+
+import (
+	"errors"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type schedulerConfig struct {
+	interFrameDelay time.Duration
+}
+
+func (c schedulerConfig) Name() string                   { return "test" }
+func (c schedulerConfig) Kind() types.ModbusBusKind      { return types.ModbusBusSerialKind }
+func (c schedulerConfig) Device() string                 { return "" }
+func (c schedulerConfig) BaudRate() int                  { return 0 }
+func (c schedulerConfig) Url() *url.URL                  { return nil }
+func (c schedulerConfig) ReadTimeout() time.Duration     { return time.Second }
+func (c schedulerConfig) InterFrameDelay() time.Duration { return c.interFrameDelay }
+func (c schedulerConfig) LogDebug() bool                 { return false }
+
+// fakeTransport records the requests and answers them using the respond function.
+type fakeTransport struct {
+	mutex    sync.Mutex
+	requests [][]byte
+	times    []time.Time
+	timeouts []time.Duration
+	respond  func(request []byte, responseBuf []byte) error
+}
+
+func (t *fakeTransport) writeRead(request []byte, responseBuf []byte, timeout time.Duration) error {
+	t.mutex.Lock()
+	t.requests = append(t.requests, request)
+	t.times = append(t.times, time.Now())
+	t.timeouts = append(t.timeouts, timeout)
+	t.mutex.Unlock()
+	return t.respond(request, responseBuf)
+}
+
+func (t *fakeTransport) close() error { return nil }
+
+func (t *fakeTransport) requestsSnapshot() [][]byte {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return append([][]byte(nil), t.requests...)
+}
+
+func newScheduler(cfg Config, t transport) *ModbusStruct {
+	md := &ModbusStruct{
+		cfg:       cfg,
+		transport: t,
+		done:      make(chan struct{}),
+		stats:     make(map[byte]*SlaveStats),
+	}
+	md.cond = sync.NewCond(&md.mutex)
+	go md.runScheduler()
+	return md
+}
+
+// frame returns a rtu frame with a valid checksum.
+func frame(data ...byte) []byte {
+	return AppendChecksum(data)
+}
+
+// echo answers every request with the request itself.
+func echo(request []byte, responseBuf []byte) error {
+	copy(responseBuf, request)
+	return nil
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	block := make(chan struct{})
+	ft := &fakeTransport{}
+	ft.respond = func(request []byte, responseBuf []byte) error {
+		if request[0] == 0 {
+			<-block
+		}
+		return echo(request, responseBuf)
+	}
+	md := newScheduler(schedulerConfig{}, ft)
+	defer md.Shutdown()
+
+	var wg sync.WaitGroup
+	send := func(address byte, priority Priority) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := frame(address, 0x03, 0, 0)
+			if err := md.WriteReadWithOptions(RequestOptions{Priority: priority}, request, make([]byte, len(request))); err != nil {
+				t.Errorf("expect no error but got %s", err)
+			}
+		}()
+	}
+	waitQueue := func(n int) {
+		for md.Stats().QueueLength != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// the first request blocks the bus until all others are queued
+	send(0, PriorityPoll)
+	for len(ft.requestsSnapshot()) < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	send(1, PriorityPoll)
+	waitQueue(1)
+	send(2, PriorityPoll)
+	waitQueue(2)
+	send(3, PriorityCommand)
+	waitQueue(3)
+	close(block)
+	wg.Wait()
+
+	var got []byte
+	for _, r := range ft.requests {
+		got = append(got, r[0])
+	}
+	if expect := []byte{0, 3, 1, 2}; string(got) != string(expect) {
+		t.Errorf("expect order %v but got %v", expect, got)
+	}
+}
+
+func TestScheduler_RetriesAndStats(t *testing.T) {
+	calls := 0
+	ft := &fakeTransport{}
+	ft.respond = func(request []byte, responseBuf []byte) error {
+		calls++
+		switch request[0] {
+		case 1:
+			// first a timeout, then a corrupted response, then a valid response
+			switch calls {
+			case 1:
+				return ErrTimeout
+			case 2:
+				copy(responseBuf, request)
+				responseBuf[len(responseBuf)-1] ^= 0xff
+				return nil
+			}
+		case 2:
+			return exceptionError(request[1], 0x02)
+		case 3:
+			return ErrTimeout
+		}
+		return echo(request, responseBuf)
+	}
+	md := newScheduler(schedulerConfig{}, ft)
+	defer md.Shutdown()
+
+	request := frame(1, 0x03, 0, 0)
+	if err := md.WriteReadWithOptions(RequestOptions{Retries: 2}, request, make([]byte, len(request))); err != nil {
+		t.Errorf("expect the third attempt to succeed but got %s", err)
+	}
+
+	// exceptions are not retried
+	request = frame(2, 0x03, 0, 0)
+	if err := md.WriteReadWithOptions(RequestOptions{Retries: 2}, request, make([]byte, len(request))); !errors.Is(err, ErrException) {
+		t.Errorf("expect an exception but got %v", err)
+	}
+
+	request = frame(3, 0x03, 0, 0)
+	err := md.WriteReadWithOptions(RequestOptions{Retries: 1, Timeout: time.Millisecond}, request, make([]byte, len(request)))
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expect a timeout but got %v", err)
+	}
+
+	if expect := []time.Duration{time.Second, time.Second, time.Second, time.Second, time.Millisecond, time.Millisecond}; len(ft.timeouts) != len(expect) {
+		t.Errorf("expect %d requests but got %d", len(expect), len(ft.timeouts))
+	} else {
+		for i := range expect {
+			if ft.timeouts[i] != expect[i] {
+				t.Errorf("expect timeout of request %d to be %s but got %s", i, expect[i], ft.timeouts[i])
+			}
+		}
+	}
+
+	expect := []SlaveStats{
+		{Address: 1, Requests: 3, Timeouts: 1, CrcErrors: 1},
+		{Address: 2, Requests: 1, Exceptions: 1},
+		{Address: 3, Requests: 2, Timeouts: 2},
+	}
+	got := md.Stats()
+	if len(got.Slaves) != len(expect) {
+		t.Fatalf("expect %v but got %v", expect, got.Slaves)
+	}
+	for i := range expect {
+		if got.Slaves[i] != expect[i] {
+			t.Errorf("expect %v but got %v", expect[i], got.Slaves[i])
+		}
+	}
+}
+
+func TestScheduler_InterFrameDelay(t *testing.T) {
+	const delay = 20 * time.Millisecond
+	ft := &fakeTransport{respond: echo}
+	md := newScheduler(schedulerConfig{interFrameDelay: delay}, ft)
+	defer md.Shutdown()
+
+	for i := 0; i < 3; i++ {
+		request := frame(1, 0x03, 0, 0)
+		if err := md.WriteReadWithOptions(RequestOptions{}, request, make([]byte, len(request))); err != nil {
+			t.Fatalf("expect no error but got %s", err)
+		}
+	}
+
+	for i := 1; i < len(ft.times); i++ {
+		if d := ft.times[i].Sub(ft.times[i-1]); d < delay {
+			t.Errorf("expect at least %s between requests but got %s", delay, d)
+		}
+	}
+}
+
+func TestScheduler_Shutdown(t *testing.T) {
+	block := make(chan struct{})
+	ft := &fakeTransport{}
+	ft.respond = func(request []byte, responseBuf []byte) error {
+		<-block
+		return echo(request, responseBuf)
+	}
+	md := newScheduler(schedulerConfig{}, ft)
+
+	results := make(chan error, 2)
+	send := func() {
+		request := frame(1, 0x03, 0, 0)
+		results <- md.WriteReadWithOptions(RequestOptions{}, request, make([]byte, len(request)))
+	}
+	go send()
+	for len(ft.requestsSnapshot()) < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go send()
+	for md.Stats().QueueLength < 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	shutdownDone := make(chan struct{})
+	go func() {
+		md.Shutdown()
+		close(shutdownDone)
+	}()
+
+	// the queued request is rejected while the current one is still running
+	if err := <-results; !errors.Is(err, errShutdown) {
+		t.Errorf("expect the queued request to be rejected but got %v", err)
+	}
+	close(block)
+	if err := <-results; err != nil {
+		t.Errorf("expect the running request to complete but got %s", err)
+	}
+	<-shutdownDone
+
+	request := frame(1, 0x03, 0, 0)
+	if err := md.WriteReadWithOptions(RequestOptions{}, request, make([]byte, len(request))); !errors.Is(err, errShutdown) {
+		t.Errorf("expect requests after shutdown to be rejected but got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package modbus
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/tarm/serial"
+	"io"
+	"time"
+)
+
+// serialTransport talks modbus rtu on a RS485 serial device.
+type serialTransport struct {
+	md *ModbusStruct
+
+	ioPort *serial.Port
+	reader *bufio.Reader
+}
+
+func newSerialTransport(md *ModbusStruct) (*serialTransport, error) {
+	cfg := md.cfg
+	md.debugPrintf("create device=%v", cfg.Device())
+
+	options := serial.Config{
+		Name:        cfg.Device(),
+		Baud:        cfg.BaudRate(),
+		ReadTimeout: cfg.ReadTimeout(),
+	}
+
+	ioHandle, err := serial.OpenPort(&options)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open device: %v", cfg.Device())
+	}
+
+	md.debugPrintf("Open succeeded")
+
+	return &serialTransport{
+		md:     md,
+		ioPort: ioHandle,
+		reader: bufio.NewReader(ioHandle),
+	}, nil
+}
+
+func (t *serialTransport) close() error {
+	return t.ioPort.Close()
+}
+
+func (t *serialTransport) writeRead(request []byte, responseBuf []byte, timeout time.Duration) error {
+	// flush receiver
+	t.recvFlush()
+
+	// send request
+	if _, err := t.Write(request); err != nil {
+		return err
+	}
+	deadline := time.Now().Add(timeout)
+
+	// read address and function code first to detect exception responses, which are shorter
+	if err := t.readFull(responseBuf[:2], deadline); err != nil {
+		return err
+	}
+	if responseBuf[1]&0x80 != 0 {
+		frame := make([]byte, 5)
+		copy(frame, responseBuf[:2])
+		if err := t.readFull(frame[2:], deadline); err != nil {
+			return err
+		}
+		if err := checkResponse(frame); err != nil {
+			return err
+		}
+		return exceptionError(frame[1], frame[2])
+	}
+
+	// read response or return error
+	return t.readFull(responseBuf[2:], deadline)
+}
+
+// readFull reads until b is full; the port returns io.EOF when nothing is received within the read timeout of the bus.
+func (t *serialTransport) readFull(b []byte, deadline time.Time) error {
+	for n := 0; n < len(b); {
+		m, err := t.Read(b[n:])
+		n += m
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		if m == 0 && time.Now().After(deadline) {
+			return fmt.Errorf("%w: received %d of %d bytes", ErrTimeout, n, len(b))
+		}
+	}
+	return nil
+}
+
+func (t *serialTransport) Read(b []byte) (n int, err error) {
+	n, err = t.ioPort.Read(b)
+	if err != nil {
+		t.md.debugPrintf("Read error: %v\n", err)
+	} else {
+		t.md.debugPrintf("Read b=%x len=%v", b, len(b))
+	}
+	return
+}
+
+func (t *serialTransport) Write(b []byte) (n int, err error) {
+	t.md.debugPrintf("Write b=%x len=%v", b, len(b))
+	n, err = t.ioPort.Write(b)
+	if err != nil {
+		t.md.logPrintf("Write error: %v", err)
+		return 0, err
+	}
+	return
+}
+
+func (t *serialTransport) recvFlush() {
+	if err := t.ioPort.Flush(); err != nil {
+		t.md.debugPrintf("Flush err=%v", err)
+	} else {
+		t.md.debugPrintf("Flush err=%v", err)
+	}
+	t.reader.Reset(t.ioPort)
+}
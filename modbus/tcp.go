@@ -0,0 +1,212 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	defaultTcpPort = "502"
+	dialTimeout    = 5 * time.Second
+	mbapHeaderLen  = 7
+)
+
+// ErrException is returned when a slave responds with a modbus exception instead of the requested data.
+var ErrException = errors.New("exception response")
+
+// tcpTransport talks either modbus tcp, where every pdu is framed by a mbap header, or plain rtu frames over
+// a tcp connection, as used by most serial to ethernet gateways.
+// The connection is established on the first request and re-established on the next request after any error.
+type tcpTransport struct {
+	md   *ModbusStruct
+	mbap bool
+
+	conn          net.Conn
+	transactionId uint16
+	shutdown      bool
+}
+
+func newTcpTransport(md *ModbusStruct, mbap bool) *tcpTransport {
+	return &tcpTransport{
+		md:   md,
+		mbap: mbap,
+	}
+}
+
+func (t *tcpTransport) address() string {
+	u := t.md.cfg.Url()
+	if len(u.Port()) < 1 {
+		return net.JoinHostPort(u.Hostname(), defaultTcpPort)
+	}
+	return u.Host
+}
+
+func (t *tcpTransport) connect() error {
+	if t.shutdown {
+		return errShutdown
+	}
+	if t.conn != nil {
+		return nil
+	}
+
+	address := t.address()
+	t.md.debugPrintf("connect to %s", address)
+	conn, err := net.DialTimeout("tcp", address, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to %s: %w", address, err)
+	}
+	t.conn = conn
+	return nil
+}
+
+func (t *tcpTransport) disconnect() {
+	if t.conn == nil {
+		return
+	}
+	if err := t.conn.Close(); err != nil {
+		t.md.debugPrintf("close err=%v", err)
+	}
+	t.conn = nil
+}
+
+func (t *tcpTransport) close() error {
+	t.shutdown = true
+	t.disconnect()
+	return nil
+}
+
+func (t *tcpTransport) writeRead(request []byte, responseBuf []byte, timeout time.Duration) error {
+	for attempt := 0; ; attempt++ {
+		reused := t.conn != nil
+		if err := t.connect(); err != nil {
+			return err
+		}
+
+		err := t.exchange(request, responseBuf, timeout)
+		if err == nil || errors.Is(err, ErrException) {
+			return err
+		}
+
+		// drop the connection; a late response to this request must not be read as response to the next one
+		t.disconnect()
+
+		// the slave or a gateway in between may have closed an idle connection; retry once on a new one
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		if !reused || attempt > 0 {
+			return err
+		}
+		t.md.debugPrintf("connection lost: %v; reconnect", err)
+	}
+}
+
+func (t *tcpTransport) exchange(request []byte, responseBuf []byte, timeout time.Duration) error {
+	if err := t.conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	if t.mbap {
+		return t.exchangeMbap(request, responseBuf)
+	}
+	return t.exchangeRtu(request, responseBuf)
+}
+
+// exchangeRtu sends the rtu frame unchanged and reads the rtu response frame.
+func (t *tcpTransport) exchangeRtu(request []byte, responseBuf []byte) error {
+	if err := t.write(request); err != nil {
+		return err
+	}
+
+	// read address and function code first to detect exception responses, which are shorter
+	if _, err := io.ReadFull(t.conn, responseBuf[:2]); err != nil {
+		return err
+	}
+	if responseBuf[1]&0x80 != 0 {
+		frame := make([]byte, 5)
+		copy(frame, responseBuf[:2])
+		if _, err := io.ReadFull(t.conn, frame[2:]); err != nil {
+			return err
+		}
+		t.md.debugPrintf("Read b=%x len=%v", frame, len(frame))
+		return exceptionError(frame[1], frame[2])
+	}
+
+	if _, err := io.ReadFull(t.conn, responseBuf[2:]); err != nil {
+		return err
+	}
+	t.md.debugPrintf("Read b=%x len=%v", responseBuf, len(responseBuf))
+	return nil
+}
+
+// exchangeMbap converts the rtu frame into a modbus tcp frame and the response back into a rtu frame.
+func (t *tcpTransport) exchangeMbap(request []byte, responseBuf []byte) error {
+	// frame structure of modbus tcp
+	// 2 bytes transaction id
+	// 2 bytes protocol id, always 0
+	// 2 bytes length of the following bytes
+	// 1 byte unit id, the rtu device address
+	// n bytes pdu, function code and payload
+	unitId := request[0]
+	pdu := request[1 : len(request)-2]
+
+	t.transactionId += 1
+	frame := make([]byte, mbapHeaderLen+len(pdu))
+	binary.BigEndian.PutUint16(frame[0:], t.transactionId)
+	binary.BigEndian.PutUint16(frame[2:], 0)
+	binary.BigEndian.PutUint16(frame[4:], uint16(1+len(pdu)))
+	frame[6] = unitId
+	copy(frame[mbapHeaderLen:], pdu)
+
+	if err := t.write(frame); err != nil {
+		return err
+	}
+
+	header := make([]byte, mbapHeaderLen)
+	if _, err := io.ReadFull(t.conn, header); err != nil {
+		return err
+	}
+	if received := binary.BigEndian.Uint16(header[0:]); received != t.transactionId {
+		return fmt.Errorf("transaction id in response != id in request: %d != %d", received, t.transactionId)
+	}
+	if received := binary.BigEndian.Uint16(header[2:]); received != 0 {
+		return fmt.Errorf("invalid protocol id in response: %d", received)
+	}
+	length := int(binary.BigEndian.Uint16(header[4:]))
+	if length < 2 || length > 254 {
+		return fmt.Errorf("invalid length in response: %d", length)
+	}
+
+	responsePdu := make([]byte, length-1)
+	if _, err := io.ReadFull(t.conn, responsePdu); err != nil {
+		return err
+	}
+	t.md.debugPrintf("Read b=%x%x len=%v", header, responsePdu, mbapHeaderLen+len(responsePdu))
+
+	if responsePdu[0]&0x80 != 0 && len(responsePdu) >= 2 {
+		return exceptionError(responsePdu[0], responsePdu[1])
+	}
+
+	// slave address, pdu, 16bit crc
+	if expected := len(responseBuf) - 3; len(responsePdu) != expected {
+		return fmt.Errorf("response length != expected length: %d != %d", len(responsePdu), expected)
+	}
+	responseBuf[0] = header[6]
+	copy(responseBuf[1:], responsePdu)
+	binary.LittleEndian.PutUint16(responseBuf[len(responseBuf)-2:], Checksum(responseBuf[:len(responseBuf)-2]))
+	return nil
+}
+
+func (t *tcpTransport) write(b []byte) error {
+	t.md.debugPrintf("Write b=%x len=%v", b, len(b))
+	_, err := t.conn.Write(b)
+	return err
+}
+
+func exceptionError(functionCode, exceptionCode byte) error {
+	return fmt.Errorf("%w: function=0x%02x, code=0x%02x", ErrException, functionCode&0x7f, exceptionCode)
+}
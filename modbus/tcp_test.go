@@ -0,0 +1,233 @@
+package modbus_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"github.com/koestler/go-iotdevice/v3/modbusDevice"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"github.com/sigurn/crc16"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	kind types.ModbusBusKind
+	url  *url.URL
+}
+
+func (c testConfig) Name() string                   { return "test" }
+func (c testConfig) Kind() types.ModbusBusKind      { return c.kind }
+func (c testConfig) Device() string                 { return "" }
+func (c testConfig) BaudRate() int                  { return 0 }
+func (c testConfig) Url() *url.URL                  { return c.url }
+func (c testConfig) ReadTimeout() time.Duration     { return time.Second }
+func (c testConfig) InterFrameDelay() time.Duration { return 0 }
+func (c testConfig) LogDebug() bool                 { return false }
+
+var crcTable = crc16.MakeTable(crc16.CRC16_MODBUS)
+
+// fakeRelay is an in-process tcp slave emulating a Waveshare relay board with the given device address.
+type fakeRelay struct {
+	t        *testing.T
+	mbap     bool
+	address  byte
+	listener net.Listener
+
+	mutex  sync.Mutex
+	relays byte
+
+	connections   atomic.Int32
+	closeAfterOne atomic.Bool // close every connection after one response
+}
+
+func runFakeRelay(t *testing.T, mbap bool, address byte) *fakeRelay {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot listen: %s", err)
+	}
+	s := &fakeRelay{t: t, mbap: mbap, address: address, listener: l}
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s.connections.Add(1)
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeRelay) config(kind types.ModbusBusKind) testConfig {
+	return testConfig{kind: kind, url: &url.URL{Scheme: "tcp", Host: s.listener.Addr().String()}}
+}
+
+func (s *fakeRelay) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		var unitId byte
+		var header []byte
+		var pdu []byte
+		if s.mbap {
+			header = make([]byte, 7)
+			if _, err := io.ReadFull(conn, header); err != nil {
+				return
+			}
+			unitId = header[6]
+			pdu = make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+			if _, err := io.ReadFull(conn, pdu); err != nil {
+				return
+			}
+		} else {
+			// all requests of the relay board are 8 bytes long
+			frame := make([]byte, 8)
+			if _, err := io.ReadFull(conn, frame); err != nil {
+				return
+			}
+			if crc16.Checksum(frame[:6], crcTable) != binary.LittleEndian.Uint16(frame[6:]) {
+				s.t.Errorf("invalid crc in request %x", frame)
+				return
+			}
+			unitId = frame[0]
+			pdu = frame[1:6]
+		}
+
+		if unitId != s.address {
+			continue
+		}
+
+		response := s.handle(pdu)
+		if s.mbap {
+			binary.BigEndian.PutUint16(header[4:], uint16(1+len(response)))
+			_, _ = conn.Write(append(header, response...))
+		} else {
+			frame := append([]byte{unitId}, response...)
+			frame = binary.LittleEndian.AppendUint16(frame, crc16.Checksum(frame, crcTable))
+			_, _ = conn.Write(frame)
+		}
+
+		if s.closeAfterOne.Load() {
+			return
+		}
+	}
+}
+
+func (s *fakeRelay) handle(pdu []byte) []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	switch pdu[0] {
+	case 0x01: // read relays
+		return []byte{0x01, 0x01, s.relays}
+	case 0x05: // write relay
+		relay := binary.BigEndian.Uint16(pdu[1:])
+		if binary.BigEndian.Uint16(pdu[3:]) == 0xFF00 {
+			s.relays |= 1 << relay
+		} else {
+			s.relays &^= 1 << relay
+		}
+		return pdu
+	default: // illegal function
+		return []byte{pdu[0] | 0x80, 0x01}
+	}
+}
+
+func newModbus(t *testing.T, cfg testConfig) *modbus.ModbusStruct {
+	t.Helper()
+	mb, err := modbus.New(cfg)
+	if err != nil {
+		t.Fatalf("cannot create modbus: %s", err)
+	}
+	t.Cleanup(mb.Shutdown)
+	return mb
+}
+
+func TestTcpTransports(t *testing.T) {
+	tests := []struct {
+		name string
+		kind types.ModbusBusKind
+		mbap bool
+	}{
+		{"Tcp", types.ModbusBusTcpKind, true},
+		{"RtuOverTcp", types.ModbusBusRtuOverTcpKind, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			slave := runFakeRelay(t, tc.mbap, 3)
+			mb := newModbus(t, slave.config(tc.kind))
+
+			// the unchanged relay driver builds rtu frames; the transport converts them if needed
+			if err := modbusDevice.WaveshareWriteRelay(mb.WriteRead, 3, 2, modbusDevice.RelayClose); err != nil {
+				t.Fatalf("cannot write relay: %s", err)
+			}
+			state, err := modbusDevice.WaveshareReadRelays(mb.WriteRead, 3)
+			if err != nil {
+				t.Fatalf("cannot read relays: %s", err)
+			}
+			if expect := [8]bool{false, false, true}; state != expect {
+				t.Errorf("expected state %v, got %v", expect, state)
+			}
+
+			// exceptions are returned as error without dropping the connection
+			_, err = modbusDevice.WaveshareReadSoftwareRevision(mb.WriteRead, 3)
+			if !errors.Is(err, modbus.ErrException) {
+				t.Errorf("expected an exception, got %v", err)
+			}
+			if _, err := modbusDevice.WaveshareReadRelays(mb.WriteRead, 3); err != nil {
+				t.Errorf("cannot read relays after exception: %s", err)
+			}
+			if got := slave.connections.Load(); got != 1 {
+				t.Errorf("expected 1 connection, got %d", got)
+			}
+		})
+	}
+}
+
+func TestTcpTransportReconnect(t *testing.T) {
+	slave := runFakeRelay(t, true, 1)
+	slave.closeAfterOne.Store(true)
+	mb := newModbus(t, slave.config(types.ModbusBusTcpKind))
+
+	// every request after the first one is sent on a connection closed by the slave and must be retried
+	for i := 0; i < 3; i++ {
+		if _, err := modbusDevice.WaveshareReadRelays(mb.WriteRead, 1); err != nil {
+			t.Fatalf("request %d failed: %s", i, err)
+		}
+	}
+	if got := slave.connections.Load(); got != 3 {
+		t.Errorf("expected 3 connections, got %d", got)
+	}
+}
+
+func TestTcpTransportUnreachable(t *testing.T) {
+	slave := runFakeRelay(t, false, 1)
+	cfg := slave.config(types.ModbusBusRtuOverTcpKind)
+	_ = slave.listener.Close()
+
+	mb := newModbus(t, cfg)
+	if _, err := modbusDevice.WaveshareReadRelays(mb.WriteRead, 1); err == nil {
+		t.Errorf("expected an error when the slave is unreachable")
+	}
+}
+
+func TestTcpTransportShutdown(t *testing.T) {
+	slave := runFakeRelay(t, true, 1)
+	mb, err := modbus.New(slave.config(types.ModbusBusTcpKind))
+	if err != nil {
+		t.Fatalf("cannot create modbus: %s", err)
+	}
+	mb.Shutdown()
+	if _, err := modbusDevice.WaveshareReadRelays(mb.WriteRead, 1); err == nil {
+		t.Errorf("expected an error after shutdown")
+	}
+}
@@ -0,0 +1,113 @@
+package modbusDevice
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"log"
+	"time"
+)
+
+// busStatus exposes the statistics of the scheduler of the bus; it does not send any requests itself.
+type busStatus struct {
+	c *DeviceStruct
+
+	registerFilter dataflow.RegisterFilterFunc
+	registers      map[string]dataflow.Register // key: register name; nil when filtered
+	sort           int
+}
+
+func runBusStatus(ctx context.Context, c *DeviceStruct) (err error, immediateError bool) {
+	log.Printf("device[%s]: start bus status of bus=%s", c.Name(), c.modbusConfig.Bus())
+
+	bs := &busStatus{
+		c:              c,
+		registerFilter: dataflow.RegisterFilter(c.Config().Filter()),
+		registers:      make(map[string]dataflow.Register),
+	}
+
+	bs.poll()
+
+	// send connected now, disconnected when this routine stops
+	c.SetAvailable(true)
+	defer func() {
+		c.SetAvailable(false)
+	}()
+
+	ticker := time.NewTicker(c.modbusConfig.PollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-ticker.C:
+			bs.poll()
+		}
+	}
+}
+
+func (bs *busStatus) poll() {
+	start := time.Now()
+	stats := bs.c.modbus.Stats()
+
+	const cat = "Bus"
+	var total modbus.SlaveStats
+	for _, s := range stats.Slaves {
+		total.Requests += s.Requests
+		total.Timeouts += s.Timeouts
+		total.CrcErrors += s.CrcErrors
+		total.Exceptions += s.Exceptions
+	}
+	bs.integer(cat, "QueueLength", "Queued requests", int64(stats.QueueLength), dataflow.StateClassMeasurement)
+	bs.counters(cat, "", "", total)
+
+	for _, s := range stats.Slaves {
+		bs.counters(
+			fmt.Sprintf("Slave 0x%02X", s.Address),
+			fmt.Sprintf("Slave%02X", s.Address),
+			fmt.Sprintf("Slave 0x%02X ", s.Address),
+			s,
+		)
+	}
+
+	bs.c.ObservePoll(time.Since(start))
+}
+
+func (bs *busStatus) counters(category, namePrefix, descriptionPrefix string, s modbus.SlaveStats) {
+	const sc = dataflow.StateClassTotalIncreasing
+	bs.integer(category, namePrefix+"Requests", descriptionPrefix+"Requests", int64(s.Requests), sc)
+	bs.integer(category, namePrefix+"Timeouts", descriptionPrefix+"Timeouts", int64(s.Timeouts), sc)
+	bs.integer(category, namePrefix+"CrcErrors", descriptionPrefix+"CRC errors", int64(s.CrcErrors), sc)
+	bs.integer(category, namePrefix+"Exceptions", descriptionPrefix+"Exception responses", int64(s.Exceptions), sc)
+}
+
+func (bs *busStatus) integer(category, registerName, description string, value int64, stateClass string) {
+	register := bs.addIgnoreRegister(registerName, func() dataflow.RegisterStruct {
+		bs.sort += 1
+		return dataflow.NewRegisterStruct(
+			category, registerName, description, dataflow.IntRegister, nil, "", bs.sort-1, false,
+		).WithMeta(dataflow.RegisterMeta{StateClass: stateClass})
+	})
+	if register == nil {
+		return
+	}
+	bs.c.Output().Fill(dataflow.NewIntRegisterValue(bs.c.Name(), register, value))
+}
+
+// addIgnoreRegister creates the register on first use; it returns nil when the register is filtered.
+func (bs *busStatus) addIgnoreRegister(registerName string, create func() dataflow.RegisterStruct) dataflow.Register {
+	if r, ok := bs.registers[registerName]; ok {
+		return r
+	}
+
+	r := create()
+	if !bs.registerFilter(r) {
+		bs.registers[registerName] = nil
+		return nil
+	}
+
+	bs.c.RegisterDb().AddStruct(r)
+	bs.registers[registerName] = r
+	return r
+}
@@ -0,0 +1,74 @@
+package modbusDevice
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"testing"
+)
+
+type busStatusFilter struct{}
+
+func (busStatusFilter) IncludeRegisters() []string  { return nil }
+func (busStatusFilter) SkipRegisters() []string     { return []string{"Slave0ATimeouts"} }
+func (busStatusFilter) IncludeCategories() []string { return nil }
+func (busStatusFilter) SkipCategories() []string    { return nil }
+func (busStatusFilter) DefaultInclude() bool        { return true }
+
+// busStatusConfig is the testConfig with a filter skipping one of the bus status registers.
+type busStatusConfig struct {
+	testConfig
+}
+
+func (busStatusConfig) Filter() dataflow.RegisterFilterConf { return busStatusFilter{} }
+
+func TestBusStatus(t *testing.T) {
+	slave := newFakeSlave()
+	slave.stats = modbus.BusStats{
+		QueueLength: 3,
+		Slaves: []modbus.SlaveStats{
+			{Address: 0x01, Requests: 10, Timeouts: 2, CrcErrors: 1},
+			{Address: 0x0A, Requests: 5, Exceptions: 4},
+		},
+	}
+
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+	c := NewDevice(busStatusConfig{}, busStatusConfig{}, slave, storage, dataflow.NewValueStorage())
+
+	bs := &busStatus{
+		c:              c,
+		registerFilter: dataflow.RegisterFilter(c.Config().Filter()),
+		registers:      make(map[string]dataflow.Register),
+	}
+	bs.poll()
+	storage.Wait()
+
+	got := make(map[string]string)
+	for _, v := range storage.GetStateFiltered(func(dataflow.Value) bool { return true }) {
+		got[v.Register().Name()] = v.Register().Category() + ": " + v.String()
+	}
+
+	expect := map[string]string{
+		"QueueLength":       "Bus: QueueLength=3",
+		"Requests":          "Bus: Requests=15",
+		"Timeouts":          "Bus: Timeouts=2",
+		"CrcErrors":         "Bus: CrcErrors=1",
+		"Exceptions":        "Bus: Exceptions=4",
+		"Slave01Requests":   "Slave 0x01: Slave01Requests=10",
+		"Slave01Timeouts":   "Slave 0x01: Slave01Timeouts=2",
+		"Slave01CrcErrors":  "Slave 0x01: Slave01CrcErrors=1",
+		"Slave01Exceptions": "Slave 0x01: Slave01Exceptions=0",
+		"Slave0ARequests":   "Slave 0x0A: Slave0ARequests=5",
+		"Slave0ACrcErrors":  "Slave 0x0A: Slave0ACrcErrors=0",
+		"Slave0AExceptions": "Slave 0x0A: Slave0AExceptions=4",
+	}
+	for name, e := range expect {
+		if got[name] != e {
+			t.Errorf("expect %s but got %s", e, got[name])
+		}
+	}
+	// Slave0ATimeouts is skipped by the filter
+	if len(got) != len(expect) {
+		t.Errorf("expect %d values but got %d", len(expect), len(got))
+	}
+}
@@ -2,10 +2,13 @@ package modbusDevice
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/modbus"
 	"github.com/koestler/go-iotdevice/v3/types"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,13 +19,36 @@ type Config interface {
 	RelayDescription(name string) string
 	RelayOpenLabel(name string) string
 	RelayClosedLabel(name string) string
+	Registers() []Register
 	PollInterval() time.Duration
+	MaxReadGap() int
+	ReadTimeout() time.Duration
+	Retries() int
+}
+
+// Register is a register of the Generic kind as defined in the configuration.
+type Register interface {
+	Name() string
+	Function() string // Coil, DiscreteInput, Holding or Input
+	Address() uint16
+	Type() string // bool, u16, i16, u32, i32, float32, float64 or string
+	Length() int  // number of 16 bit registers
+	WordOrder() string
+	ByteOrder() string
+	Scale() float64
+	Unit() string
+	Enum() map[int]string
+	Category() string
+	Description() string
+	Sort() int
+	Writable() bool
 }
 
 type Modbus interface {
 	Name() string
 	Shutdown()
-	WriteRead(request []byte, responseBuf []byte) error
+	WriteReadWithOptions(opts modbus.RequestOptions, request []byte, responseBuf []byte) error
+	Stats() modbus.BusStats
 }
 
 type DeviceStruct struct {
@@ -31,6 +57,8 @@ type DeviceStruct struct {
 
 	commandStorage *dataflow.ValueStorage
 	modbus         Modbus
+
+	crcErrors atomic.Uint64
 }
 
 func NewDevice(
@@ -57,6 +85,10 @@ func (c *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 		return runWaveshareRtuRelay8(ctx, c)
 	case types.ModbusFinder7M38Kind:
 		return runFinder7M38(ctx, c)
+	case types.ModbusGenericKind:
+		return runGeneric(ctx, c)
+	case types.ModbusBusStatusKind:
+		return runBusStatus(ctx, c)
 	default:
 		return fmt.Errorf("unknown device kind: %s", c.modbusConfig.Kind().String()), true
 	}
@@ -65,3 +97,34 @@ func (c *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool)
 func (c *DeviceStruct) Model() string {
 	return c.modbusConfig.Kind().String()
 }
+
+// pollWriteRead sends a request with poll priority using the timeout and retries of the device.
+func (c *DeviceStruct) pollWriteRead(request []byte, responseBuf []byte) error {
+	return c.modbus.WriteReadWithOptions(c.requestOptions(modbus.PriorityPoll), request, responseBuf)
+}
+
+// commandWriteRead sends a request with command priority, i.e. before all queued polls of the bus.
+func (c *DeviceStruct) commandWriteRead(request []byte, responseBuf []byte) error {
+	return c.modbus.WriteReadWithOptions(c.requestOptions(modbus.PriorityCommand), request, responseBuf)
+}
+
+func (c *DeviceStruct) requestOptions(priority modbus.Priority) modbus.RequestOptions {
+	return modbus.RequestOptions{
+		Priority: priority,
+		Timeout:  c.modbusConfig.ReadTimeout(),
+		Retries:  c.modbusConfig.Retries(),
+	}
+}
+
+// CrcErrors returns the number of responses received with an invalid checksum.
+func (c *DeviceStruct) CrcErrors() uint64 {
+	return c.crcErrors.Load()
+}
+
+// countCrcError counts checksum mismatches and passes the error through.
+func (c *DeviceStruct) countCrcError(err error) error {
+	if errors.Is(err, ErrChecksumMismatch) {
+		c.crcErrors.Add(1)
+	}
+	return err
+}
@@ -29,27 +29,45 @@ func runFinder7M38(ctx context.Context, c *DeviceStruct) (err error, immediateEr
 	registers = dataflow.FilterRegisters(registers, c.Config().Filter())
 	addToRegisterDb(c.RegisterDb(), registers)
 
+	// plan the requests; the registers that are static are skipped in the reduced set
+	reducedRegisters := make([]FinderRegister, 0, len(registers))
+	for _, register := range registers {
+		if c := register.Category(); c != "Device Info" && c != "Energy Counter" {
+			reducedRegisters = append(reducedRegisters, register)
+		}
+	}
+	fullPlan := planReads(registers, FinderRegister.readSpan, c.modbusConfig.MaxReadGap())
+	reducedPlan := planReads(reducedRegisters, FinderRegister.readSpan, c.modbusConfig.MaxReadGap())
+
 	// setup polling
 	execPoll := func(ctx context.Context, reducedSet bool) error {
 		start := time.Now()
 
-		// fetch registers
-		for _, register := range registers {
+		plan := fullPlan
+		if reducedSet {
+			plan = reducedPlan
+		}
 
-			if reducedSet {
-				// skip registers that are static
-				if c := register.Category(); c == "Device Info" || c == "Energy Counter" {
-					continue
-				}
+		// fetch registers
+		for _, block := range plan {
+			if c.Config().LogComDebug() {
+				log.Printf("finder7N38Device[%s]: read address=%d, count=%d, registers=%d",
+					c.Name(), block.address, block.count, len(block.registers),
+				)
 			}
 
-			v, err := FinderReadRegister(c, register)
-
+			response, err := FinderReadInputRegisters(c, block.address, block.count)
 			if err != nil {
 				return err
 			}
 
-			c.StateStorage().Fill(v)
+			for i, register := range block.registers {
+				v, err := FinderDecodeRegister(c, register, block.registerData(response, i))
+				if err != nil {
+					return err
+				}
+				c.Output().Fill(v)
+			}
 
 			// abort loop when context expires
 			select {
@@ -60,6 +78,8 @@ func runFinder7M38(ctx context.Context, c *DeviceStruct) (err error, immediateEr
 			}
 		}
 
+		c.ObservePoll(time.Since(start))
+
 		if c.Config().LogDebug() {
 			log.Printf(
 				"finder7N38Device[%s]: registers fetched, took=%.3fs",
@@ -280,31 +300,37 @@ func FinderReadRegister(c *DeviceStruct, reg FinderRegister) (v dataflow.Value,
 		)
 	}
 
+	span := reg.readSpan()
+	response, err := FinderReadInputRegisters(c, span.address, span.count)
+	if err != nil {
+		return nil, err
+	}
+
+	return FinderDecodeRegister(c, reg, response)
+}
+
+// FinderDecodeRegister converts the content of the registers of reg into a value.
+func FinderDecodeRegister(c *DeviceStruct, reg FinderRegister, response []byte) (v dataflow.Value, err error) {
 	switch rt := reg.RegisterType(); rt {
 	case dataflow.NumberRegister:
 		switch frt := reg.registerType; frt {
 		case FinderTFloat:
-			return FinderReadFloatRegister(c, reg)
+			return FinderDecodeFloatRegister(c, reg, response)
 		case FinderT1:
-			return FinderReadUInt16Register(c, reg)
+			return FinderDecodeUInt16Register(c, reg, response)
 		default:
-			return nil, fmt.Errorf("FinderReadRegister does not implement finderRegisterType=%d", frt)
+			return nil, fmt.Errorf("FinderDecodeRegister does not implement finderRegisterType=%d", frt)
 		}
 	case dataflow.EnumRegister:
-		return FinderReadEnumRegister(c, reg)
+		return FinderDecodeEnumRegister(c, reg, response)
 	case dataflow.TextRegister:
-		return FinderReadStringRegister(c, reg)
+		return FinderDecodeStringRegister(c, reg, response)
 	default:
-		return nil, fmt.Errorf("FinderReadRegister does not implement registerType=%s", rt)
+		return nil, fmt.Errorf("FinderDecodeRegister does not implement registerType=%s", rt)
 	}
 }
 
-func FinderReadFloatRegister(c *DeviceStruct, register FinderRegister) (v dataflow.Value, err error) {
-	response, err := FinderReadInputRegisters(c, register)
-	if err != nil {
-		return nil, err
-	}
-
+func FinderDecodeFloatRegister(c *DeviceStruct, register FinderRegister, response []byte) (v dataflow.Value, err error) {
 	var floatValue float32
 	buf := bytes.NewReader(response)
 	if err := binary.Read(buf, binary.BigEndian, &floatValue); err != nil {
@@ -312,7 +338,7 @@ func FinderReadFloatRegister(c *DeviceStruct, register FinderRegister) (v datafl
 	}
 
 	if c.Config().LogDebug() {
-		log.Printf("FinderReadFloatRegister: registerName=%s, floatValue=%f", register.Name(), floatValue)
+		log.Printf("FinderDecodeFloatRegister: registerName=%s, floatValue=%f", register.Name(), floatValue)
 	}
 
 	v = dataflow.NewNumericRegisterValue(
@@ -324,12 +350,7 @@ func FinderReadFloatRegister(c *DeviceStruct, register FinderRegister) (v datafl
 	return
 }
 
-func FinderReadUInt16Register(c *DeviceStruct, register FinderRegister) (v dataflow.Value, err error) {
-	response, err := FinderReadInputRegisters(c, register)
-	if err != nil {
-		return nil, err
-	}
-
+func FinderDecodeUInt16Register(c *DeviceStruct, register FinderRegister, response []byte) (v dataflow.Value, err error) {
 	var uint16Value uint16
 	buf := bytes.NewReader(response)
 	if err := binary.Read(buf, binary.BigEndian, &uint16Value); err != nil {
@@ -337,7 +358,7 @@ func FinderReadUInt16Register(c *DeviceStruct, register FinderRegister) (v dataf
 	}
 
 	if c.Config().LogDebug() {
-		log.Printf("FinderReadUInt16Register: registerName=%s, uint16Value=%d", register.Name(), uint16Value)
+		log.Printf("FinderDecodeUInt16Register: registerName=%s, uint16Value=%d", register.Name(), uint16Value)
 	}
 
 	v = dataflow.NewNumericRegisterValue(
@@ -349,12 +370,7 @@ func FinderReadUInt16Register(c *DeviceStruct, register FinderRegister) (v dataf
 	return
 }
 
-func FinderReadEnumRegister(c *DeviceStruct, register FinderRegister) (v dataflow.Value, err error) {
-	response, err := FinderReadInputRegisters(c, register)
-	if err != nil {
-		return nil, err
-	}
-
+func FinderDecodeEnumRegister(c *DeviceStruct, register FinderRegister, response []byte) (v dataflow.Value, err error) {
 	var uint16Value uint16
 	buf := bytes.NewReader(response)
 	if err := binary.Read(buf, binary.BigEndian, &uint16Value); err != nil {
@@ -362,7 +378,7 @@ func FinderReadEnumRegister(c *DeviceStruct, register FinderRegister) (v dataflo
 	}
 
 	if c.Config().LogDebug() {
-		log.Printf("FinderReadEnumRegister: registerName=%s, uint16Value=%d", register.Name(), uint16Value)
+		log.Printf("FinderDecodeEnumRegister: registerName=%s, uint16Value=%d", register.Name(), uint16Value)
 	}
 
 	enumIdx := int(uint16Value)
@@ -384,14 +400,9 @@ func FinderReadEnumRegister(c *DeviceStruct, register FinderRegister) (v dataflo
 	return
 }
 
-func FinderReadStringRegister(c *DeviceStruct, register FinderRegister) (v dataflow.Value, err error) {
-	response, err := FinderReadInputRegisters(c, register)
-	if err != nil {
-		return nil, err
-	}
-
+func FinderDecodeStringRegister(c *DeviceStruct, register FinderRegister, response []byte) (v dataflow.Value, err error) {
 	if c.Config().LogDebug() {
-		log.Printf("FinderReadStringRegister: registerName=%s, stringValue=%s", register.Name(), response)
+		log.Printf("FinderDecodeStringRegister: registerName=%s, stringValue=%s", register.Name(), response)
 	}
 
 	v = dataflow.NewTextRegisterValue(
@@ -403,10 +414,11 @@ func FinderReadStringRegister(c *DeviceStruct, register FinderRegister) (v dataf
 	return
 }
 
-func FinderReadInputRegisters(c *DeviceStruct, register FinderRegister) (response []byte, err error) {
+// FinderReadInputRegisters reads count input registers beginning at the given protocol address.
+func FinderReadInputRegisters(c *DeviceStruct, address uint16, count int) (response []byte, err error) {
 	// the finder relay sometimes just doesn't answer. retry after a short pause
 	for retry := 0; retry < 8; retry++ {
-		response, err = FinderReadInputRegistersRaw(c, register)
+		response, err = FinderReadInputRegistersRaw(c, address, count)
 		if err == nil {
 			return
 		}
@@ -414,48 +426,23 @@ func FinderReadInputRegisters(c *DeviceStruct, register FinderRegister) (respons
 	return
 }
 
-func FinderReadInputRegistersRaw(c *DeviceStruct, register FinderRegister) (response []byte, err error) {
-	var requestPayload bytes.Buffer
-
-	// write starting register
-	err = binary.Write(&requestPayload, byteOrder, register.addressBegin-InputRegisterAddressOffset)
-	if err != nil {
-		return
-	}
-	// write register count
-	err = binary.Write(&requestPayload, byteOrder, uint16(register.CountRegisters()))
-	if err != nil {
-		return
-	}
-
-	// finder registers are 16 bit wide
-	responsePayloadLength := register.CountBytes()
-
+func FinderReadInputRegistersRaw(c *DeviceStruct, address uint16, count int) (response []byte, err error) {
 	begin := time.Now()
-	response, err = callFunction(
-		c.modbus.WriteRead,
+	response, err = readRange(
+		c.pollWriteRead,
 		c.modbusConfig.Address(),
 		FinderFunctionReadInputRegisters,
-		requestPayload.Bytes(),
-		1+responsePayloadLength, // 1 byte for byte count + payload
+		address,
+		count,
 	)
+	err = c.countCrcError(err)
 	if c.Config().LogDebug() {
-		log.Printf("FinderReadInputRegisters: callFunction: took=%s", time.Since(begin))
+		log.Printf("FinderReadInputRegisters: address=%d, count=%d, took=%s", address, count, time.Since(begin))
 	}
 
 	if err != nil {
-		return
+		return nil, fmt.Errorf("FinderReadInputRegisters: %w", err)
 	}
 
-	byteCount := response[0]
-
-	if int(byteCount) != responsePayloadLength {
-		err = fmt.Errorf("FinderReadInputRegisters: expected byte count to be %d but got %d", responsePayloadLength, byteCount)
-		return
-	}
-
-	// first byte should be payload length
-	response = response[1:]
-
 	return
 }
@@ -63,15 +63,20 @@ func NewFinderRegister(
 		expectF(4)
 	}
 
+	reg := dataflow.NewRegisterStruct(
+		category, name, description,
+		rt,
+		enum,
+		unit,
+		sort,
+		false,
+	)
+	if rt == dataflow.NumberRegister {
+		reg = reg.WithMeta(dataflow.MetaByUnit(unit))
+	}
+
 	return FinderRegister{
-		dataflow.NewRegisterStruct(
-			category, name, description,
-			rt,
-			enum,
-			unit,
-			sort,
-			false,
-		),
+		reg,
 		registerType,
 		addressBegin,
 		addressEnd,
@@ -95,3 +100,11 @@ func (r FinderRegister) CountBytes() int {
 	// finder registers are 16 bit wide
 	return r.CountRegisters() * 2
 }
+
+func (r FinderRegister) readSpan() readSpan {
+	return readSpan{
+		function: FinderFunctionReadInputRegisters,
+		address:  r.addressBegin - InputRegisterAddressOffset,
+		count:    r.CountRegisters(),
+	}
+}
@@ -0,0 +1,247 @@
+package modbusDevice
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"log"
+	"time"
+)
+
+func runGeneric(ctx context.Context, c *DeviceStruct) (err error, immediateError bool) {
+	log.Printf("device[%s]: start generic source", c.Name())
+
+	// assign registers
+	registers := make([]GenericRegister, 0, len(c.modbusConfig.Registers()))
+	for _, r := range c.modbusConfig.Registers() {
+		registers = append(registers, NewGenericRegister(r))
+	}
+	registers = dataflow.FilterRegisters(registers, c.Config().Filter())
+	for _, r := range registers {
+		c.RegisterDb().AddStruct(r.RegisterStruct)
+	}
+
+	plan := planReads(registers, GenericRegister.readSpan, c.modbusConfig.MaxReadGap())
+
+	if err := c.execGenericPoll(ctx, plan); err != nil {
+		return err, true
+	}
+
+	// send connected now, disconnected when this routine stops
+	c.SetAvailable(true)
+	defer func() {
+		c.SetAvailable(false)
+	}()
+
+	// setup subscription to listen for updates of writable registers
+	// commands sent before the start, e.g. restored from a snapshot, are executed first
+	commandSubscription := c.commandStorage.SubscribeSendInitial(ctx, dataflow.DeviceNonNullValueFilter(c.Config().Name()))
+
+	ticker := time.NewTicker(c.modbusConfig.PollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-ticker.C:
+			if err := c.execGenericPoll(ctx, plan); err != nil {
+				return err, false
+			}
+		case value := <-commandSubscription.Drain():
+			c.execGenericCommand(registers, value)
+		}
+	}
+}
+
+func (c *DeviceStruct) execGenericPoll(ctx context.Context, plan []readBlock[GenericRegister]) error {
+	start := time.Now()
+
+	for _, block := range plan {
+		if c.Config().LogComDebug() {
+			log.Printf("genericDevice[%s]: read function=0x%02x, address=%d, count=%d, registers=%d",
+				c.Name(), block.function, block.address, block.count, len(block.registers),
+			)
+		}
+
+		data, err := readRange(c.pollWriteRead, c.modbusConfig.Address(), block.function, block.address, block.count)
+		if c.countCrcError(err) != nil {
+			return fmt.Errorf("genericDevice[%s]: read failed: %s", c.Name(), err)
+		}
+
+		for i, register := range block.registers {
+			if register.IsBit() {
+				c.Output().Fill(register.decodeBit(c.Name(), block.bitState(data, i)))
+				continue
+			}
+			v, err := register.decodeRegisters(c.Name(), block.registerData(data, i))
+			if err != nil {
+				return fmt.Errorf("genericDevice[%s]: %s", c.Name(), err)
+			}
+			c.Output().Fill(v)
+		}
+
+		// abort loop when context expires
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			// continue the loop
+		}
+	}
+
+	c.ObservePoll(time.Since(start))
+
+	if c.Config().LogDebug() {
+		log.Printf(
+			"genericDevice[%s]: registers fetched, took=%.3fs",
+			c.Name(),
+			time.Since(start).Seconds(),
+		)
+	}
+
+	return nil
+}
+
+func (c *DeviceStruct) genericReadRegister(register GenericRegister) (dataflow.Value, error) {
+	if c.Config().LogComDebug() {
+		log.Printf("genericDevice[%s]: read registerName=%s, function=0x%02x, address=%d, length=%d",
+			c.Name(), register.Name(), register.readFunction, register.address, register.length,
+		)
+	}
+
+	data, err := readRange(c.pollWriteRead, c.modbusConfig.Address(), register.readFunction, register.address, register.length)
+	if err != nil {
+		return nil, err
+	}
+
+	if register.IsBit() {
+		return register.decodeBit(c.Name(), data[0]&1 != 0), nil
+	}
+	return register.decodeRegisters(c.Name(), data)
+}
+
+// readRange reads count coils / discrete inputs (packed into bits) or 16 bit registers starting at address.
+func readRange(
+	writeRead WriteReadBusFunc,
+	deviceAddress byte,
+	functionCode FunctionCode,
+	address uint16,
+	count int,
+) ([]byte, error) {
+	// payload structure:
+	// 2 bytes for the starting address
+	// 2 bytes for the number of coils / registers
+	var payload bytes.Buffer
+	if err := binary.Write(&payload, byteOrder, address); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&payload, byteOrder, uint16(count)); err != nil {
+		return nil, err
+	}
+
+	byteCount := 2 * count
+	if functionCode == FunctionReadCoils || functionCode == FunctionReadDiscreteInputs {
+		byteCount = (count + 7) / 8
+	}
+
+	// response: 1 byte byte count, n bytes data
+	response, err := callFunction(writeRead, deviceAddress, functionCode, payload.Bytes(), 1+byteCount)
+	if err != nil {
+		return nil, err
+	}
+	if received := int(response[0]); received != byteCount {
+		return nil, fmt.Errorf("byte count in response != expected: %d != %d", received, byteCount)
+	}
+	return response[1:], nil
+}
+
+func (c *DeviceStruct) execGenericCommand(registers []GenericRegister, value dataflow.Value) {
+	if c.Config().LogDebug() {
+		log.Printf(
+			"genericDevice[%s]: value command: %s",
+			c.Config().Name(), value.String(),
+		)
+	}
+
+	// reset the command; this allows the same command to be sent again
+	defer c.commandStorage.Fill(dataflow.NewNullRegisterValue(c.Config().Name(), value.Register()))
+
+	var register GenericRegister
+	found := false
+	for _, r := range registers {
+		if r.Name() == value.Register().Name() && r.Writable() {
+			register, found = r, true
+			break
+		}
+	}
+	if !found {
+		// unknown or read only register
+		return
+	}
+
+	if err := c.countCrcError(c.genericWriteRegister(register, value)); err != nil {
+		log.Printf("genericDevice[%s]: write of register %s failed: %s", c.Config().Name(), register.Name(), err)
+		return
+	}
+
+	// set the current state immediately after a successful write
+	if v, err := c.genericReadRegister(register); c.countCrcError(err) == nil {
+		c.Output().Fill(v)
+	}
+
+	if c.Config().LogDebug() {
+		log.Printf("genericDevice[%s]: command request successful", c.Config().Name())
+	}
+}
+
+func (c *DeviceStruct) genericWriteRegister(register GenericRegister, value dataflow.Value) error {
+	var payload bytes.Buffer
+	if err := binary.Write(&payload, byteOrder, register.address); err != nil {
+		return err
+	}
+
+	if register.IsBit() {
+		boolValue, ok := value.(dataflow.BoolRegisterValue)
+		if !ok {
+			return fmt.Errorf("cannot write a %s value to a coil", value.Register().RegisterType())
+		}
+		state := coilOff
+		if boolValue.Value() {
+			state = coilOn
+		}
+		if err := binary.Write(&payload, byteOrder, state); err != nil {
+			return err
+		}
+		// the response echoes address and value
+		_, err := callFunction(c.commandWriteRead, c.modbusConfig.Address(), FunctionWriteSingleCoil, payload.Bytes(), 4)
+		return err
+	}
+
+	data, err := register.encodeRegisters(value)
+	if err != nil {
+		return err
+	}
+
+	if register.length == 1 {
+		payload.Write(data)
+		// the response echoes address and value
+		_, err = callFunction(c.commandWriteRead, c.modbusConfig.Address(), FunctionWriteSingleRegister, payload.Bytes(), 4)
+		return err
+	}
+
+	// payload structure:
+	// 2 bytes for the starting address
+	// 2 bytes for the number of registers
+	// 1 byte for the number of bytes
+	// n bytes data
+	if err := binary.Write(&payload, byteOrder, uint16(register.length)); err != nil {
+		return err
+	}
+	payload.WriteByte(byte(len(data)))
+	payload.Write(data)
+	// the response contains address and number of registers
+	_, err = callFunction(c.commandWriteRead, c.modbusConfig.Address(), FunctionWriteMultipleRegisters, payload.Bytes(), 4)
+	return err
+}
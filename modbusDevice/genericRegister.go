@@ -0,0 +1,150 @@
+package modbusDevice
+
+import (
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"strings"
+)
+
+const (
+	FunctionReadCoils              FunctionCode = 0x01
+	FunctionReadDiscreteInputs     FunctionCode = 0x02
+	FunctionReadHoldingRegisters   FunctionCode = 0x03
+	FunctionReadInputRegisters     FunctionCode = 0x04
+	FunctionWriteSingleCoil        FunctionCode = 0x05
+	FunctionWriteSingleRegister    FunctionCode = 0x06
+	FunctionWriteMultipleRegisters FunctionCode = 0x10
+)
+
+// values written by FunctionWriteSingleCoil
+const (
+	coilOff uint16 = 0x0000
+	coilOn  uint16 = 0xFF00
+)
+
+// GenericRegister is a register of a device whose register map is defined in the configuration.
+type GenericRegister struct {
+	dataflow.RegisterStruct
+	readFunction FunctionCode
+	address      uint16
+	length       int
+	codec        modbus.RegisterCodec
+}
+
+func NewGenericRegister(r Register) GenericRegister {
+	var rt dataflow.RegisterType
+	enum := r.Enum()
+
+	switch {
+	case r.Type() == "bool":
+		rt = dataflow.BoolRegister
+		if len(enum) < 1 {
+			enum = map[int]string{0: "false", 1: "true"}
+		}
+	case r.Type() == "string":
+		rt = dataflow.TextRegister
+	case len(enum) > 0:
+		rt = dataflow.EnumRegister
+	default:
+		rt = dataflow.NumberRegister
+	}
+
+	reg := dataflow.NewRegisterStruct(
+		r.Category(), r.Name(), r.Description(),
+		rt,
+		enum,
+		r.Unit(),
+		r.Sort(),
+		r.Writable(),
+	)
+	if rt == dataflow.NumberRegister {
+		reg = reg.WithMeta(dataflow.MetaByUnit(r.Unit()))
+	}
+
+	var readFunction FunctionCode
+	switch r.Function() {
+	case "Coil":
+		readFunction = FunctionReadCoils
+	case "DiscreteInput":
+		readFunction = FunctionReadDiscreteInputs
+	case "Holding":
+		readFunction = FunctionReadHoldingRegisters
+	default:
+		readFunction = FunctionReadInputRegisters
+	}
+
+	return GenericRegister{
+		RegisterStruct: reg,
+		readFunction:   readFunction,
+		address:        r.Address(),
+		length:         r.Length(),
+		codec:          modbus.NewRegisterCodec(r.Type(), r.Length(), r.WordOrder(), r.ByteOrder(), r.Scale()),
+	}
+}
+
+// IsBit returns true for coils and discrete inputs.
+func (r GenericRegister) IsBit() bool {
+	return r.readFunction == FunctionReadCoils || r.readFunction == FunctionReadDiscreteInputs
+}
+
+func (r GenericRegister) readSpan() readSpan {
+	return readSpan{
+		function: r.readFunction,
+		address:  r.address,
+		count:    r.length,
+	}
+}
+
+// decodeBit converts the state of a coil or discrete input into a value.
+func (r GenericRegister) decodeBit(deviceName string, state bool) dataflow.Value {
+	return dataflow.NewBoolRegisterValue(deviceName, r, state)
+}
+
+// decodeRegisters converts the content of the 16 bit registers of a holding or input register into a value.
+func (r GenericRegister) decodeRegisters(deviceName string, data []byte) (dataflow.Value, error) {
+	if len(data) != 2*r.length {
+		return nil, fmt.Errorf("register %s: expect %d bytes but got %d", r.Name(), 2*r.length, len(data))
+	}
+
+	if r.codec.DataType == "string" {
+		text := string(r.codec.Swap(data))
+		return dataflow.NewTextRegisterValue(deviceName, r, strings.TrimRight(text, "\x00 ")), nil
+	}
+
+	if r.RegisterType() == dataflow.EnumRegister {
+		raw, err := r.codec.DecodeRaw(data)
+		if err != nil {
+			return nil, fmt.Errorf("register %s: %w", r.Name(), err)
+		}
+		enumIdx := int(raw)
+		if _, ok := r.Enum()[enumIdx]; !ok {
+			return nil, fmt.Errorf("register %s: invalid enumIdx=%d", r.Name(), enumIdx)
+		}
+		return dataflow.NewEnumRegisterValue(deviceName, r, enumIdx), nil
+	}
+
+	f, err := r.codec.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("register %s: %w", r.Name(), err)
+	}
+	return dataflow.NewNumericRegisterValue(deviceName, r, f), nil
+}
+
+// encodeRegisters converts a command value into the content of the 16 bit registers of a holding register.
+func (r GenericRegister) encodeRegisters(value dataflow.Value) (data []byte, err error) {
+	switch v := value.(type) {
+	case dataflow.NumericRegisterValue:
+		data, err = r.codec.Encode(v.Value())
+	case dataflow.EnumRegisterValue:
+		data, err = r.codec.EncodeRaw(float64(v.EnumIdx()))
+	case dataflow.IntRegisterValue:
+		data, err = r.codec.Encode(float64(v.Value()))
+	default:
+		return nil, fmt.Errorf("register %s: cannot write a %s value", r.Name(), value.Register().RegisterType())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("register %s: %w", r.Name(), err)
+	}
+	return data, nil
+}
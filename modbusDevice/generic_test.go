@@ -0,0 +1,299 @@
+package modbusDevice
+
+import (
+	"context"
+	"encoding/binary"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"math"
+	"testing"
+	"time"
+)
+
+// fakeSlave is an in-memory modbus rtu slave with address 1.
+type fakeSlave struct {
+	coils   map[uint16]bool
+	holding map[uint16]uint16
+	input   map[uint16]uint16
+	calls   int
+	opts    []modbus.RequestOptions
+	stats   modbus.BusStats
+}
+
+func newFakeSlave() *fakeSlave {
+	return &fakeSlave{
+		coils:   make(map[uint16]bool),
+		holding: make(map[uint16]uint16),
+		input:   make(map[uint16]uint16),
+	}
+}
+
+func (s *fakeSlave) Name() string { return "fake" }
+func (s *fakeSlave) Shutdown()    {}
+
+func (s *fakeSlave) Stats() modbus.BusStats { return s.stats }
+
+func (s *fakeSlave) WriteReadWithOptions(opts modbus.RequestOptions, request []byte, responseBuf []byte) error {
+	s.calls++
+	s.opts = append(s.opts, opts)
+	pdu := request[1 : len(request)-2]
+	fc := FunctionCode(pdu[0])
+	address := binary.BigEndian.Uint16(pdu[1:])
+	count := binary.BigEndian.Uint16(pdu[3:])
+
+	response := []byte{request[0], pdu[0]}
+	switch fc {
+	case FunctionReadCoils, FunctionReadDiscreteInputs:
+		data := make([]byte, (count+7)/8)
+		for i := uint16(0); i < count; i++ {
+			if s.coils[address+i] {
+				data[i/8] |= 1 << (i % 8)
+			}
+		}
+		response = append(append(response, byte(len(data))), data...)
+	case FunctionReadHoldingRegisters, FunctionReadInputRegisters:
+		m := s.holding
+		if fc == FunctionReadInputRegisters {
+			m = s.input
+		}
+		response = append(response, byte(2*count))
+		for i := uint16(0); i < count; i++ {
+			response = binary.BigEndian.AppendUint16(response, m[address+i])
+		}
+	case FunctionWriteSingleCoil:
+		s.coils[address] = count == coilOn
+		response = append(response, pdu[1:5]...)
+	case FunctionWriteSingleRegister:
+		s.holding[address] = count
+		response = append(response, pdu[1:5]...)
+	case FunctionWriteMultipleRegisters:
+		for i := uint16(0); i < count; i++ {
+			s.holding[address+i] = binary.BigEndian.Uint16(pdu[6+2*i:])
+		}
+		response = append(response, pdu[1:5]...)
+	}
+
+	response = modbus.AppendChecksum(response)
+	copy(responseBuf, response)
+	return nil
+}
+
+type testRegister struct {
+	function, registerType, wordOrder, byteOrder string
+	address                                      uint16
+	length                                       int
+	scale                                        float64
+	enum                                         map[int]string
+	writable                                     bool
+}
+
+func (r testRegister) Name() string         { return "reg" }
+func (r testRegister) Function() string     { return r.function }
+func (r testRegister) Address() uint16      { return r.address }
+func (r testRegister) Type() string         { return r.registerType }
+func (r testRegister) Length() int          { return r.length }
+func (r testRegister) WordOrder() string    { return r.wordOrder }
+func (r testRegister) ByteOrder() string    { return r.byteOrder }
+func (r testRegister) Scale() float64       { return r.scale }
+func (r testRegister) Unit() string         { return "" }
+func (r testRegister) Enum() map[int]string { return r.enum }
+func (r testRegister) Category() string     { return "Test" }
+func (r testRegister) Description() string  { return "Test Register" }
+func (r testRegister) Sort() int            { return 0 }
+func (r testRegister) Writable() bool       { return r.writable }
+
+// testConfig is used as device and as modbus config.
+type testConfig struct{}
+
+func (testConfig) Name() string                                              { return "generic" }
+func (testConfig) Filter() dataflow.RegisterFilterConf                       { return nil }
+func (testConfig) RegisterMeta() map[string]dataflow.RegisterMeta            { return nil }
+func (testConfig) RegisterTransforms() map[string]dataflow.RegisterTransform { return nil }
+func (testConfig) Deadband(dataflow.Register) (dataflow.Deadband, bool) {
+	return dataflow.Deadband{}, false
+}
+func (testConfig) LogDebug() bool    { return false }
+func (testConfig) LogComDebug() bool { return false }
+
+func (testConfig) Bus() string                         { return "bus0" }
+func (testConfig) Kind() types.ModbusDeviceKind        { return types.ModbusGenericKind }
+func (testConfig) Address() byte                       { return 1 }
+func (testConfig) RelayDescription(name string) string { return name }
+func (testConfig) RelayOpenLabel(string) string        { return "open" }
+func (testConfig) RelayClosedLabel(string) string      { return "closed" }
+func (testConfig) Registers() []Register               { return nil }
+func (testConfig) PollInterval() time.Duration         { return time.Second }
+func (testConfig) MaxReadGap() int                     { return 0 }
+func (testConfig) ReadTimeout() time.Duration          { return 0 }
+func (testConfig) Retries() int                        { return 2 }
+
+func TestGenericRegister_Decode(t *testing.T) {
+	slave := newFakeSlave()
+	// 0x0000-0x0001: float32 230.5 high word first
+	f := math.Float32bits(230.5)
+	slave.input[0], slave.input[1] = uint16(f>>16), uint16(f)
+	// 0x0010-0x0011: u32 0x00012345 low word first
+	slave.input[0x10], slave.input[0x11] = 0x2345, 0x0001
+	// 0x0020: i16 -5 with swapped bytes
+	slave.input[0x20] = 0xFBFF
+	// 0x0030-0x0033: string "SDM630"
+	slave.input[0x30], slave.input[0x31], slave.input[0x32] = 0x5344, 0x4d36, 0x3330
+	// 0x0040: enum 2
+	slave.input[0x40] = 2
+	slave.coils[7] = true
+
+	tests := []struct {
+		name     string
+		register testRegister
+		expect   string
+	}{
+		{"float32", testRegister{function: "Input", registerType: "float32", address: 0, length: 2, scale: 1}, "reg=230.500000"},
+		{"u32 word swap", testRegister{function: "Input", registerType: "u32", address: 0x10, length: 2, wordOrder: "LittleEndian", scale: 0.01}, "reg=745.650000"},
+		{"i16 byte swap", testRegister{function: "Input", registerType: "i16", address: 0x20, length: 1, byteOrder: "LittleEndian", scale: 1}, "reg=-5.000000"},
+		{"string", testRegister{function: "Input", registerType: "string", address: 0x30, length: 4, scale: 1}, "reg=SDM630"},
+		{"enum", testRegister{function: "Input", registerType: "u16", address: 0x40, length: 1, scale: 1, enum: map[int]string{2: "Running"}}, "reg=2:Running"},
+		{"coil", testRegister{function: "Coil", registerType: "bool", address: 7, length: 1, scale: 1}, "reg=true:true"},
+	}
+
+	c := NewDevice(testConfig{}, testConfig{}, slave, dataflow.NewValueStorage(), dataflow.NewValueStorage())
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := NewGenericRegister(tc.register)
+			v, err := c.genericReadRegister(reg)
+			if err != nil {
+				t.Fatalf("expect no error but got %s", err)
+			}
+			if got := v.String(); got != tc.expect {
+				t.Errorf("expect %s but got %s", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestGenericRegister_Write(t *testing.T) {
+	slave := newFakeSlave()
+	c := NewDevice(testConfig{}, testConfig{}, slave, dataflow.NewValueStorage(), dataflow.NewValueStorage())
+
+	tests := []struct {
+		name     string
+		register testRegister
+		value    func(r dataflow.Register) dataflow.Value
+		expect   string
+	}{
+		{
+			"u16 scaled",
+			testRegister{function: "Holding", registerType: "u16", address: 1, length: 1, scale: 0.1, writable: true},
+			func(r dataflow.Register) dataflow.Value { return dataflow.NewNumericRegisterValue("dev", r, 12.3) },
+			"reg=12.300000",
+		},
+		{
+			"float32 word swap",
+			testRegister{function: "Holding", registerType: "float32", address: 2, length: 2, wordOrder: "LittleEndian", scale: 1, writable: true},
+			func(r dataflow.Register) dataflow.Value { return dataflow.NewNumericRegisterValue("dev", r, -1.5) },
+			"reg=-1.500000",
+		},
+		{
+			"enum",
+			testRegister{function: "Holding", registerType: "i32", address: 4, length: 2, scale: 1, enum: map[int]string{0: "Off", 1: "On"}, writable: true},
+			func(r dataflow.Register) dataflow.Value { return dataflow.NewEnumRegisterValue("dev", r, 1) },
+			"reg=1:On",
+		},
+		{
+			"coil",
+			testRegister{function: "Coil", registerType: "bool", address: 3, length: 1, scale: 1, writable: true},
+			func(r dataflow.Register) dataflow.Value { return dataflow.NewBoolRegisterValue("dev", r, true) },
+			"reg=true:true",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := NewGenericRegister(tc.register)
+			if err := c.genericWriteRegister(reg, tc.value(reg)); err != nil {
+				t.Fatalf("expect no error but got %s", err)
+			}
+			v, err := c.genericReadRegister(reg)
+			if err != nil {
+				t.Fatalf("expect no error but got %s", err)
+			}
+			if got := v.String(); got != tc.expect {
+				t.Errorf("expect %s but got %s", tc.expect, got)
+			}
+		})
+	}
+
+	for i, opts := range slave.opts {
+		if opts.Retries != 2 {
+			t.Errorf("expect request %d to use the retries of the device but got %d", i, opts.Retries)
+		}
+	}
+	if p := slave.opts[0].Priority; p != modbus.PriorityCommand {
+		t.Errorf("expect the write to be sent with command priority but got %d", p)
+	}
+	if p := slave.opts[1].Priority; p != modbus.PriorityPoll {
+		t.Errorf("expect the read to be sent with poll priority but got %d", p)
+	}
+
+	reg := NewGenericRegister(testRegister{function: "Holding", registerType: "u16", address: 1, length: 1, scale: 1, writable: true})
+	if err := c.genericWriteRegister(reg, dataflow.NewNumericRegisterValue("dev", reg, 70000)); err == nil {
+		t.Error("expect an error when writing a value out of the range of the type")
+	}
+}
+
+type includeAllFilter struct{}
+
+func (includeAllFilter) IncludeRegisters() []string  { return nil }
+func (includeAllFilter) SkipRegisters() []string     { return nil }
+func (includeAllFilter) IncludeCategories() []string { return nil }
+func (includeAllFilter) SkipCategories() []string    { return nil }
+func (includeAllFilter) DefaultInclude() bool        { return true }
+
+// restoredCommandConfig is the testConfig with a single writable holding register.
+type restoredCommandConfig struct {
+	testConfig
+}
+
+func (restoredCommandConfig) Filter() dataflow.RegisterFilterConf { return includeAllFilter{} }
+
+func (restoredCommandConfig) Registers() []Register {
+	return []Register{testRegister{function: "Holding", registerType: "u16", address: 1, length: 1, scale: 1, writable: true}}
+}
+
+func TestGeneric_RestoredCommand(t *testing.T) {
+	slave := newFakeSlave()
+	stateStorage := dataflow.NewValueStorage()
+	defer stateStorage.Shutdown()
+	commandStorage := dataflow.NewValueStorage()
+	defer commandStorage.Shutdown()
+
+	cfg := restoredCommandConfig{}
+	c := NewDevice(cfg, cfg, slave, stateStorage, commandStorage)
+
+	// a command restored from a snapshot is in the command storage before the device starts
+	reg := NewGenericRegister(cfg.Registers()[0])
+	commandStorage.Fill(dataflow.NewNumericRegisterValue(cfg.Name(), reg, 42))
+	commandStorage.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		runGeneric(ctx, c)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, v := range stateStorage.GetStateFiltered(dataflow.DeviceNameValueFilter(cfg.Name())) {
+			if v.String() == "reg=42.000000" {
+				return
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Error("expect the restored command to be written to the device")
+}
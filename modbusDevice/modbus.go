@@ -4,7 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
-	"github.com/sigurn/crc16"
+	"github.com/koestler/go-iotdevice/v3/modbus"
 )
 
 type WriteReadBusFunc func(request []byte, responseBuf []byte) error
@@ -13,6 +13,9 @@ type FunctionCode byte
 var byteOrder = binary.BigEndian
 var checksumByteOrder = binary.LittleEndian
 
+// ErrChecksumMismatch is returned when the crc16 of a response is invalid; usually caused by noise on the bus.
+var ErrChecksumMismatch = modbus.ErrChecksumMismatch
+
 func callFunction(
 	writeRead WriteReadBusFunc,
 	deviceAddress byte,
@@ -24,7 +27,7 @@ func callFunction(
 	// 1 byte Device Address
 	// 1 byte Function Code
 	// n bytes payload
-	// 2 bytes crc16 computeChecksum
+	// 2 bytes crc16 checksum
 	var request bytes.Buffer
 
 	err = binary.Write(&request, byteOrder, deviceAddress)
@@ -42,7 +45,7 @@ func callFunction(
 		return
 	}
 
-	checksum := computeChecksum(request.Bytes())
+	checksum := modbus.Checksum(request.Bytes())
 	err = binary.Write(&request, checksumByteOrder, checksum)
 	if err != nil {
 		return
@@ -57,11 +60,11 @@ func callFunction(
 		return
 	}
 
-	// check computeChecksum
+	// check checksum
 	received := checksumByteOrder.Uint16(response[len(response)-2:])
-	computed := computeChecksum(response[:len(response)-2])
+	computed := modbus.Checksum(response[:len(response)-2])
 	if received != computed {
-		return nil, fmt.Errorf("computeChecksum missmatch received != computed : %x != %x", received, computed)
+		return nil, fmt.Errorf("%w received != computed : %x != %x", ErrChecksumMismatch, received, computed)
 	}
 
 	// check slave address
@@ -76,9 +79,3 @@ func callFunction(
 
 	return response[2 : len(response)-2], nil
 }
-
-var crcTable = crc16.MakeTable(crc16.CRC16_MODBUS)
-
-func computeChecksum(data []byte) uint16 {
-	return crc16.Checksum(data, crcTable)
-}
@@ -0,0 +1,90 @@
+package modbusDevice
+
+import (
+	"cmp"
+	"slices"
+)
+
+// maximum number of registers / coils per read request, given by the maximum pdu length of 253 bytes
+const (
+	maxReadRegisters = 125
+	maxReadBits      = 2000
+)
+
+// readSpan is a range of consecutive registers or coils read by a single function code.
+type readSpan struct {
+	function FunctionCode
+	address  uint16
+	count    int
+}
+
+func (s readSpan) end() int {
+	return int(s.address) + s.count
+}
+
+func (s readSpan) isBit() bool {
+	return s.function == FunctionReadCoils || s.function == FunctionReadDiscreteInputs
+}
+
+// readBlock is a single read request covering the spans of one or more registers.
+type readBlock[R any] struct {
+	readSpan
+	registers []R
+	spans     []readSpan
+}
+
+// planReads merges the registers into as few read requests as possible. Registers of the same function code are
+// merged when at most maxGap unused registers lie between them and the request does not exceed the maximum pdu length.
+func planReads[R any](registers []R, span func(R) readSpan, maxGap int) (blocks []readBlock[R]) {
+	type item struct {
+		register R
+		span     readSpan
+	}
+	items := make([]item, len(registers))
+	for i, r := range registers {
+		items[i] = item{r, span(r)}
+	}
+	slices.SortStableFunc(items, func(a, b item) int {
+		if c := cmp.Compare(a.span.function, b.span.function); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.span.address, b.span.address)
+	})
+
+	for _, it := range items {
+		if n := len(blocks); n > 0 {
+			b := &blocks[n-1]
+			maxCount := maxReadRegisters
+			if b.isBit() {
+				maxCount = maxReadBits
+			}
+			end := max(b.end(), it.span.end())
+			if b.function == it.span.function &&
+				int(it.span.address) <= b.end()+maxGap &&
+				end-int(b.address) <= maxCount {
+				b.count = end - int(b.address)
+				b.registers = append(b.registers, it.register)
+				b.spans = append(b.spans, it.span)
+				continue
+			}
+		}
+		blocks = append(blocks, readBlock[R]{
+			readSpan:  it.span,
+			registers: []R{it.register},
+			spans:     []readSpan{it.span},
+		})
+	}
+	return
+}
+
+// registerData returns the bytes of the i-th register of the block given the response data of the whole block.
+func (b readBlock[R]) registerData(data []byte, i int) []byte {
+	offset := 2 * (int(b.spans[i].address) - int(b.address))
+	return data[offset : offset+2*b.spans[i].count]
+}
+
+// bitState returns the state of the i-th coil / discrete input of the block given the response data of the whole block.
+func (b readBlock[R]) bitState(data []byte, i int) bool {
+	offset := int(b.spans[i].address) - int(b.address)
+	return data[offset/8]&(1<<(offset%8)) != 0
+}
@@ -0,0 +1,130 @@
+package modbusDevice
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestPlanReads(t *testing.T) {
+	spans := []readSpan{
+		{FunctionReadInputRegisters, 10, 2},
+		{FunctionReadInputRegisters, 0, 2},
+		{FunctionReadInputRegisters, 2, 1},
+		{FunctionReadInputRegisters, 2, 1}, // same register, e.g. different bits
+		{FunctionReadInputRegisters, 5, 2},
+		{FunctionReadHoldingRegisters, 3, 1},
+		{FunctionReadCoils, 0, 1},
+		{FunctionReadCoils, 1500, 1},
+		{FunctionReadCoils, 2100, 1},
+	}
+	identity := func(s readSpan) readSpan { return s }
+
+	blockSpans := func(blocks []readBlock[readSpan]) (ret []readSpan) {
+		for _, b := range blocks {
+			ret = append(ret, b.readSpan)
+		}
+		return
+	}
+
+	tests := []struct {
+		name   string
+		maxGap int
+		expect []readSpan
+	}{
+		{"adjacent only", 0, []readSpan{
+			{FunctionReadCoils, 0, 1},
+			{FunctionReadCoils, 1500, 1},
+			{FunctionReadCoils, 2100, 1},
+			{FunctionReadHoldingRegisters, 3, 1},
+			{FunctionReadInputRegisters, 0, 3},
+			{FunctionReadInputRegisters, 5, 2},
+			{FunctionReadInputRegisters, 10, 2},
+		}},
+		{"gap of 2", 2, []readSpan{
+			{FunctionReadCoils, 0, 1},
+			{FunctionReadCoils, 1500, 1},
+			{FunctionReadCoils, 2100, 1},
+			{FunctionReadHoldingRegisters, 3, 1},
+			{FunctionReadInputRegisters, 0, 7},
+			{FunctionReadInputRegisters, 10, 2},
+		}},
+		{"max pdu", 100000, []readSpan{
+			{FunctionReadCoils, 0, 1501},
+			{FunctionReadCoils, 2100, 1},
+			{FunctionReadHoldingRegisters, 3, 1},
+			{FunctionReadInputRegisters, 0, 12},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := blockSpans(planReads(spans, identity, tc.maxGap))
+			if !reflect.DeepEqual(tc.expect, got) {
+				t.Errorf("expect %v but got %v", tc.expect, got)
+			}
+		})
+	}
+
+	blocks := planReads([]readSpan{{FunctionReadInputRegisters, 0, 100}, {FunctionReadInputRegisters, 100, 30}}, identity, 0)
+	if expect, got := 2, len(blocks); expect != got {
+		t.Errorf("expect %d blocks since 130 registers exceed the maximum pdu length but got %d", expect, got)
+	}
+}
+
+func TestPlanReads_Finder7M38(t *testing.T) {
+	slave := newFakeSlave()
+	c := NewDevice(testConfig{}, testConfig{}, slave, dataflow.NewValueStorage(), dataflow.NewValueStorage())
+
+	registers := RegisterList7M38()
+	for _, r := range registers {
+		address := r.addressBegin - InputRegisterAddressOffset
+		if r.registerType == FinderTFloat {
+			f := math.Float32bits(float32(address) / 10)
+			slave.input[address], slave.input[address+1] = uint16(f>>16), uint16(f)
+		} else {
+			slave.input[address] = 0x4142 + address
+		}
+	}
+	slave.input[30101-InputRegisterAddressOffset] = 0b101 // phase 1 and 3 invalid
+
+	// read every register by its own request
+	expect := make(map[string]string)
+	for _, r := range registers {
+		v, err := FinderReadRegister(c, r)
+		if err != nil {
+			t.Fatalf("cannot read register %s: %s", r.Name(), err)
+		}
+		expect[r.Name()+r.Description()] = v.String()
+	}
+	single := slave.calls
+
+	// read them again with the planned requests
+	slave.calls = 0
+	got := make(map[string]string)
+	for _, block := range planReads(registers, FinderRegister.readSpan, 0) {
+		response, err := FinderReadInputRegisters(c, block.address, block.count)
+		if err != nil {
+			t.Fatalf("cannot read block %v: %s", block.readSpan, err)
+		}
+		for i, r := range block.registers {
+			v, err := FinderDecodeRegister(c, r, block.registerData(response, i))
+			if err != nil {
+				t.Fatalf("cannot decode register %s: %s", r.Name(), err)
+			}
+			got[r.Name()+r.Description()] = v.String()
+		}
+	}
+
+	if !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect the planned reads to decode the same values\nexpect: %v\ngot:    %v", expect, got)
+	}
+	t.Logf("requests: single=%d, planned=%d", single, slave.calls)
+	if slave.calls*4 > single {
+		t.Errorf("expect the planned reads to need far less than %d requests, got %d", single, slave.calls)
+	}
+	if expect, got := "P2valid=0:valid", got["P2validPhase 2 measurement"]; expect != got {
+		t.Errorf("expect %s but got %s", expect, got)
+	}
+}
@@ -15,7 +15,7 @@ func runWaveshareRtuRelay8(ctx context.Context, c *DeviceStruct) (err error, imm
 	log.Printf("device[%s]: start waveshare RTU Relay 8 source", c.Name())
 
 	// get software version
-	if version, err := WaveshareReadSoftwareRevision(c.modbus.WriteRead, c.modbusConfig.Address()); err != nil {
+	if version, err := WaveshareReadSoftwareRevision(c.pollWriteRead, c.modbusConfig.Address()); c.countCrcError(err) != nil {
 		return fmt.Errorf("waveshareDevice[%s]: WaveshareReadSoftwareRevision failed: %s", c.Name(), err), true
 	} else {
 		log.Printf("waveshareDevice[%s]: source: version=%s", c.Name(), version)
@@ -37,7 +37,8 @@ func runWaveshareRtuRelay8(ctx context.Context, c *DeviceStruct) (err error, imm
 	}()
 
 	// setup subscription to listen for updates of writable registers
-	_, commandSubscription := c.commandStorage.SubscribeReturnInitial(ctx, dataflow.DeviceNonNullValueFilter(c.Config().Name()))
+	// commands sent before the start, e.g. restored from a snapshot, are executed first
+	commandSubscription := c.commandStorage.SubscribeSendInitial(ctx, dataflow.DeviceNonNullValueFilter(c.Config().Name()))
 
 	ticker := time.NewTicker(c.modbusConfig.PollInterval())
 	defer ticker.Stop()
@@ -59,26 +60,26 @@ func (c *DeviceStruct) execPoll(registers []dataflow.RegisterStruct) error {
 	start := time.Now()
 
 	// fetch registers
-	state, err := WaveshareReadRelays(c.modbus.WriteRead, c.modbusConfig.Address())
-	if err != nil {
+	state, err := WaveshareReadRelays(c.pollWriteRead, c.modbusConfig.Address())
+	if c.countCrcError(err) != nil {
 		return fmt.Errorf("waveshareDevice[%s]: read failed: %s", c.Name(), err)
 	}
 
 	for _, register := range registers {
-		value := 0
+		value := false
 		if address, err := waveshareRtuRelay8RegisterAddress(register); err == nil {
-			if state[address] {
-				value = 1
-			}
+			value = state[address]
 		}
 
-		c.StateStorage().Fill(dataflow.NewEnumRegisterValue(
+		c.Output().Fill(dataflow.NewBoolRegisterValue(
 			c.Name(),
 			register,
 			value,
 		))
 	}
 
+	c.ObservePoll(time.Since(start))
+
 	if c.Config().LogDebug() {
 		log.Printf(
 			"waveshareDevice[%s]: registers fetched, took=%.3fs",
@@ -98,20 +99,15 @@ func (c *DeviceStruct) execCommand(value dataflow.Value) {
 		)
 	}
 
-	enumValue, ok := value.(dataflow.EnumRegisterValue)
+	boolValue, ok := value.(dataflow.BoolRegisterValue)
 	if !ok {
-		// unable to handle non enum value
+		// unable to handle non bool value
 		return
 	}
 
-	var command Command
-	switch enumValue.EnumIdx() {
-	case 0:
-		command = RelayOpen
-	case 1:
+	command := RelayOpen
+	if boolValue.Value() {
 		command = RelayClose
-	default:
-		return
 	}
 
 	var relayNr uint16
@@ -135,17 +131,17 @@ func (c *DeviceStruct) execCommand(value dataflow.Value) {
 		)
 	}
 
-	if err := WaveshareWriteRelay(c.modbus.WriteRead, c.modbusConfig.Address(), relayNr, command); err != nil {
+	if err := WaveshareWriteRelay(c.commandWriteRead, c.modbusConfig.Address(), relayNr, command); c.countCrcError(err) != nil {
 		log.Printf(
 			"waveshareDevice[%s]: command request genration failed: %s",
 			c.Config().Name(), err,
 		)
 	} else {
 		// set the current state immediately after a successful write
-		c.StateStorage().Fill(dataflow.NewEnumRegisterValue(
+		c.Output().Fill(dataflow.NewBoolRegisterValue(
 			c.Name(),
 			value.Register(),
-			enumValue.EnumIdx(),
+			boolValue.Value(),
 		))
 
 		if c.Config().LogDebug() {
@@ -171,7 +167,7 @@ func (c *DeviceStruct) getWaveshareRtuRelay8Registers() (registers []dataflow.Re
 
 		r := dataflow.NewRegisterStruct(
 			category, name, description,
-			dataflow.EnumRegister,
+			dataflow.BoolRegister,
 			enum,
 			"",
 			int(i),
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/modbusServer"
+	"github.com/koestler/go-iotdevice/v3/pool"
+	"github.com/koestler/go-iotdevice/v3/restarter"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"log"
+)
+
+func runModbusServers(
+	cfg *config.Config,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) *pool.Pool[*modbusServer.Server] {
+	serverPool := pool.RunPool[*modbusServer.Server]()
+
+	env := &modbusServer.Environment{
+		StateStorage:   stateStorage,
+		CommandStorage: commandStorage,
+		RegisterByName: func(deviceName, registerName string) (dataflow.Register, bool) {
+			dev := devicePool.GetByName(deviceName)
+			if dev == nil {
+				return nil, false
+			}
+			return dev.Service().RegisterDb().GetByName(registerName)
+		},
+	}
+
+	for _, serverCfg := range cfg.ModbusServers() {
+		if cfg.LogWorkerStart() {
+			if serverCfg.Kind() == types.ModbusServerTcpKind {
+				log.Printf(
+					"modbusServer[%s]: start: bind='%s', port=%d, unitId=%d, registers=%d",
+					serverCfg.Name(), serverCfg.Bind(), serverCfg.Port(), serverCfg.UnitId(), len(serverCfg.Registers()),
+				)
+			} else {
+				log.Printf(
+					"modbusServer[%s]: start: device='%s', baudRate=%d, unitId=%d, registers=%d",
+					serverCfg.Name(), serverCfg.Device(), serverCfg.BaudRate(), serverCfg.UnitId(), len(serverCfg.Registers()),
+				)
+			}
+		}
+
+		if server, err := modbusServer.Run(modbusServerConfig{serverCfg}, env); err != nil {
+			log.Printf("modbusServer[%s]: start failed: %s", serverCfg.Name(), err)
+		} else {
+			serverPool.Add(server)
+		}
+	}
+
+	return serverPool
+}
+
+type modbusServerConfig struct {
+	config.ModbusServerConfig
+}
+
+func (c modbusServerConfig) Registers() []modbusServer.RegisterConfig {
+	inp := c.ModbusServerConfig.Registers()
+	oup := make([]modbusServer.RegisterConfig, len(inp))
+	for i, r := range inp {
+		oup[i] = r
+	}
+	return oup
+}
@@ -0,0 +1,333 @@
+package modbusServer
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+)
+
+const (
+	functionReadCoils              byte = 0x01
+	functionReadDiscreteInputs     byte = 0x02
+	functionReadHoldingRegisters   byte = 0x03
+	functionReadInputRegisters     byte = 0x04
+	functionWriteSingleCoil        byte = 0x05
+	functionWriteSingleRegister    byte = 0x06
+	functionWriteMultipleCoils     byte = 0x0F
+	functionWriteMultipleRegisters byte = 0x10
+)
+
+// maximum number of coils / registers per request, given by the maximum pdu length of 253 bytes
+const (
+	maxReadBits       = 2000
+	maxReadRegisters  = 125
+	maxWriteBits      = 1968
+	maxWriteRegisters = 123
+)
+
+// values written by functionWriteSingleCoil
+const (
+	coilOff uint16 = 0x0000
+	coilOn  uint16 = 0xFF00
+)
+
+// exception is the code sent to the master when a request cannot be executed.
+type exception byte
+
+const (
+	exceptionIllegalFunction                    exception = 0x01
+	exceptionIllegalDataAddress                 exception = 0x02
+	exceptionIllegalDataValue                   exception = 0x03
+	exceptionServerDeviceFailure                exception = 0x04
+	exceptionGatewayTargetDeviceFailedToRespond exception = 0x0B
+)
+
+func (e exception) Error() string {
+	switch e {
+	case exceptionIllegalFunction:
+		return "illegal function"
+	case exceptionIllegalDataAddress:
+		return "illegal data address"
+	case exceptionIllegalDataValue:
+		return "illegal data value"
+	case exceptionServerDeviceFailure:
+		return "server device failure"
+	case exceptionGatewayTargetDeviceFailedToRespond:
+		return "gateway target device failed to respond"
+	default:
+		return fmt.Sprintf("exception 0x%02x", byte(e))
+	}
+}
+
+// handle executes the request given as pdu (function code and data) and returns the response pdu.
+func (s *Server) handle(pdu []byte) []byte {
+	if len(pdu) < 1 {
+		return []byte{0x80, byte(exceptionIllegalFunction)}
+	}
+
+	functionCode := pdu[0]
+	data, err := s.execute(functionCode, pdu[1:])
+	if err != nil {
+		var ex exception
+		if !errors.As(err, &ex) {
+			ex = exceptionServerDeviceFailure
+		}
+		s.debugPrintf("function=0x%02x failed: %s", functionCode, err)
+		return []byte{functionCode | 0x80, byte(ex)}
+	}
+
+	return append([]byte{functionCode}, data...)
+}
+
+func (s *Server) execute(functionCode byte, data []byte) ([]byte, error) {
+	switch functionCode {
+	case functionReadCoils:
+		return s.readBits(coilTable, data)
+	case functionReadDiscreteInputs:
+		return s.readBits(discreteInputTable, data)
+	case functionReadHoldingRegisters:
+		return s.readRegisters(holdingTable, data)
+	case functionReadInputRegisters:
+		return s.readRegisters(inputTable, data)
+	case functionWriteSingleCoil:
+		return s.writeSingleCoil(data)
+	case functionWriteSingleRegister:
+		return s.writeSingleRegister(data)
+	case functionWriteMultipleCoils:
+		return s.writeMultipleCoils(data)
+	case functionWriteMultipleRegisters:
+		return s.writeMultipleRegisters(data)
+	default:
+		return nil, exceptionIllegalFunction
+	}
+}
+
+// parseRange reads the starting address and the number of coils / registers of a request.
+func parseRange(data []byte, maxCount int) (address uint16, count int, err error) {
+	if len(data) < 4 {
+		return 0, 0, fmt.Errorf("%w: request too short", exceptionIllegalDataValue)
+	}
+	address = binary.BigEndian.Uint16(data)
+	count = int(binary.BigEndian.Uint16(data[2:]))
+	if count < 1 || count > maxCount {
+		return 0, 0, fmt.Errorf("%w: count=%d must be between 1 and %d", exceptionIllegalDataValue, count, maxCount)
+	}
+	if int(address)+count > 0x10000 {
+		return 0, 0, fmt.Errorf("%w: address=%d count=%d exceeds the address space", exceptionIllegalDataAddress, address, count)
+	}
+	return
+}
+
+// value returns the current value of a mapping; unavailable values are reported to the master as an exception
+// instead of sending a value which looks valid.
+func value(values map[valueKey]dataflow.Value, m mapping) (dataflow.Value, error) {
+	v, ok := values[m.key]
+	if _, isNull := v.(dataflow.NullRegisterValue); !ok || isNull || v.Quality() == dataflow.QualityCommError {
+		return nil, fmt.Errorf("%w: %s is not available", exceptionGatewayTargetDeviceFailedToRespond, m)
+	}
+	return v, nil
+}
+
+// readBits returns the state of count coils / discrete inputs; unmapped addresses read as off.
+func (s *Server) readBits(t table, data []byte) ([]byte, error) {
+	address, count, err := parseRange(data, maxReadBits)
+	if err != nil {
+		return nil, err
+	}
+
+	values := s.values()
+	ret := make([]byte, 1+(count+7)/8)
+	ret[0] = byte(len(ret) - 1)
+	for _, m := range s.overlapping(t, address, count) {
+		v, err := value(values, m)
+		if err != nil {
+			return nil, err
+		}
+		state, err := m.encodeBit(v)
+		if err != nil {
+			return nil, err
+		}
+		if state {
+			i := int(m.address) - int(address)
+			ret[1+i/8] |= 1 << (i % 8)
+		}
+	}
+	return ret, nil
+}
+
+// readRegisters returns the content of count holding / input registers; unmapped addresses read as 0.
+func (s *Server) readRegisters(t table, data []byte) ([]byte, error) {
+	address, count, err := parseRange(data, maxReadRegisters)
+	if err != nil {
+		return nil, err
+	}
+
+	values := s.values()
+	ret := make([]byte, 1+2*count)
+	ret[0] = byte(2 * count)
+	for _, m := range s.overlapping(t, address, count) {
+		v, err := value(values, m)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := m.encodeRegisters(v)
+		if err != nil {
+			return nil, err
+		}
+		// a request may start or end in the middle of a multi register value
+		for i := 0; i < m.length; i++ {
+			if a := int(m.address) + i - int(address); a >= 0 && a < count {
+				copy(ret[1+2*a:3+2*a], encoded[2*i:2*i+2])
+			}
+		}
+	}
+	return ret, nil
+}
+
+// command converts a number written by the master into a value of the register of the mapping.
+func (s *Server) command(m mapping, f float64) (dataflow.Value, error) {
+	register, ok := s.env.RegisterByName(m.key.device, m.key.register)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not available", exceptionGatewayTargetDeviceFailedToRespond, m)
+	}
+	if !register.Writable() {
+		return nil, fmt.Errorf("%w: %s is not writable", exceptionIllegalDataAddress, m)
+	}
+	v, err := commandValue(m.key.device, register, f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %s", exceptionIllegalDataValue, m, err)
+	}
+	return v, nil
+}
+
+// fill sends the commands once all values of a request are known to be valid.
+func (s *Server) fill(commands []dataflow.Value) {
+	for _, c := range commands {
+		s.debugPrintf("command: %s", c)
+		s.env.CommandStorage.Fill(c)
+	}
+}
+
+func (s *Server) writeSingleCoil(data []byte) ([]byte, error) {
+	if len(data) != 4 {
+		return nil, fmt.Errorf("%w: invalid request length", exceptionIllegalDataValue)
+	}
+	address := binary.BigEndian.Uint16(data)
+	state := binary.BigEndian.Uint16(data[2:])
+	if state != coilOff && state != coilOn {
+		return nil, fmt.Errorf("%w: coil value=0x%04x must be 0x0000 or 0xFF00", exceptionIllegalDataValue, state)
+	}
+
+	m, ok := s.containing(coilTable, address)
+	if !ok {
+		return nil, fmt.Errorf("%w: coil address=%d is not mapped", exceptionIllegalDataAddress, address)
+	}
+	f := 0.0
+	if state == coilOn {
+		f = 1
+	}
+	c, err := s.command(m, f)
+	if err != nil {
+		return nil, err
+	}
+	s.fill([]dataflow.Value{c})
+
+	// the response echoes address and value
+	return data, nil
+}
+
+func (s *Server) writeMultipleCoils(data []byte) ([]byte, error) {
+	address, count, err := parseRange(data, maxWriteBits)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 5 || int(data[4]) != (count+7)/8 || len(data) != 5+int(data[4]) {
+		return nil, fmt.Errorf("%w: invalid byte count", exceptionIllegalDataValue)
+	}
+
+	commands := make([]dataflow.Value, 0, count)
+	for i := 0; i < count; i++ {
+		m, ok := s.containing(coilTable, address+uint16(i))
+		if !ok {
+			return nil, fmt.Errorf("%w: coil address=%d is not mapped", exceptionIllegalDataAddress, int(address)+i)
+		}
+		f := 0.0
+		if data[5+i/8]&(1<<(i%8)) != 0 {
+			f = 1
+		}
+		c, err := s.command(m, f)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, c)
+	}
+	s.fill(commands)
+
+	// the response contains address and number of coils
+	return data[:4], nil
+}
+
+func (s *Server) writeSingleRegister(data []byte) ([]byte, error) {
+	if len(data) != 4 {
+		return nil, fmt.Errorf("%w: invalid request length", exceptionIllegalDataValue)
+	}
+	address := binary.BigEndian.Uint16(data)
+
+	m, ok := s.containing(holdingTable, address)
+	if !ok {
+		return nil, fmt.Errorf("%w: holding register address=%d is not mapped", exceptionIllegalDataAddress, address)
+	}
+	if m.length != 1 {
+		return nil, fmt.Errorf("%w: %s uses %d registers and must be written at once", exceptionIllegalDataAddress, m, m.length)
+	}
+	f, err := m.decodeRegisters(data[2:4])
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.command(m, f)
+	if err != nil {
+		return nil, err
+	}
+	s.fill([]dataflow.Value{c})
+
+	// the response echoes address and value
+	return data, nil
+}
+
+func (s *Server) writeMultipleRegisters(data []byte) ([]byte, error) {
+	address, count, err := parseRange(data, maxWriteRegisters)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 5 || int(data[4]) != 2*count || len(data) != 5+2*count {
+		return nil, fmt.Errorf("%w: invalid byte count", exceptionIllegalDataValue)
+	}
+
+	var commands []dataflow.Value
+	end := int(address) + count
+	for a := int(address); a < end; {
+		m, ok := s.containing(holdingTable, uint16(a))
+		if !ok {
+			return nil, fmt.Errorf("%w: holding register address=%d is not mapped", exceptionIllegalDataAddress, a)
+		}
+		if int(m.address) != a || m.end() > end {
+			return nil, fmt.Errorf("%w: %s uses %d registers and must be written at once", exceptionIllegalDataAddress, m, m.length)
+		}
+		offset := 5 + 2*(a-int(address))
+		f, err := m.decodeRegisters(data[offset : offset+2*m.length])
+		if err != nil {
+			return nil, err
+		}
+		c, err := s.command(m, f)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, c)
+		a = m.end()
+	}
+	s.fill(commands)
+
+	// the response contains address and number of registers
+	return data[:4], nil
+}
@@ -0,0 +1,38 @@
+package modbusServer
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/types"
+)
+
+type Config interface {
+	Name() string
+	Kind() types.ModbusServerKind
+	Bind() string
+	Port() int
+	Device() string
+	BaudRate() int
+	UnitId() byte
+	Registers() []RegisterConfig
+	LogDebug() bool
+}
+
+// RegisterConfig maps a register of a device onto one or more consecutive modbus addresses.
+type RegisterConfig interface {
+	Function() string
+	Address() uint16
+	Device() string
+	Register() string
+	Type() string
+	Length() int
+	WordOrder() string
+	ByteOrder() string
+	Scale() float64
+}
+
+type Environment struct {
+	StateStorage   *dataflow.ValueStorage
+	CommandStorage *dataflow.ValueStorage
+	// RegisterByName returns the register of a device; it is used to convert the values written by the master.
+	RegisterByName func(deviceName, registerName string) (dataflow.Register, bool)
+}
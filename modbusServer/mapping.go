@@ -0,0 +1,137 @@
+package modbusServer
+
+import (
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"math"
+)
+
+// table is one of the four address spaces of the modbus data model.
+type table int
+
+const (
+	coilTable table = iota
+	discreteInputTable
+	holdingTable
+	inputTable
+)
+
+func tableFromFunction(function string) table {
+	switch function {
+	case "Coil":
+		return coilTable
+	case "DiscreteInput":
+		return discreteInputTable
+	case "Holding":
+		return holdingTable
+	default:
+		return inputTable
+	}
+}
+
+type valueKey struct {
+	device, register string
+}
+
+// mapping places the value of a register of a device at an address of the server.
+type mapping struct {
+	key     valueKey
+	address uint16
+	length  int
+	codec   modbus.RegisterCodec
+}
+
+func newMapping(r RegisterConfig) mapping {
+	return mapping{
+		key:     valueKey{r.Device(), r.Register()},
+		address: r.Address(),
+		length:  r.Length(),
+		codec:   modbus.NewRegisterCodec(r.Type(), r.Length(), r.WordOrder(), r.ByteOrder(), r.Scale()),
+	}
+}
+
+func (m mapping) end() int {
+	return int(m.address) + m.length
+}
+
+func (m mapping) String() string {
+	return fmt.Sprintf("%s.%s", m.key.device, m.key.register)
+}
+
+// numericValue converts all non-text values into a number; enums are represented by their index.
+func numericValue(value dataflow.Value) (float64, error) {
+	switch v := value.(type) {
+	case dataflow.NumericRegisterValue:
+		return v.Value(), nil
+	case dataflow.IntRegisterValue:
+		return float64(v.Value()), nil
+	case dataflow.EnumRegisterValue:
+		return float64(v.EnumIdx()), nil
+	case dataflow.BoolRegisterValue:
+		if v.Value() {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("cannot convert a %s value to a number", value.Register().RegisterType())
+	}
+}
+
+// encodeBit converts a value into the state of a coil or discrete input.
+func (m mapping) encodeBit(value dataflow.Value) (bool, error) {
+	f, err := numericValue(value)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", m, err)
+	}
+	return f != 0, nil
+}
+
+// encodeRegisters converts a value into the content of the 16 bit registers of a holding or input register.
+func (m mapping) encodeRegisters(value dataflow.Value) ([]byte, error) {
+	f, err := numericValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m, err)
+	}
+	data, err := m.codec.Encode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", m, err)
+	}
+	return data, nil
+}
+
+// decodeRegisters converts the content of the 16 bit registers written by the master into a number.
+func (m mapping) decodeRegisters(data []byte) (float64, error) {
+	f, err := m.codec.Decode(data)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", m, err)
+	}
+	return f, nil
+}
+
+// commandValue converts a number written by the master according to the type of the target register.
+func commandValue(deviceName string, register dataflow.Register, f float64) (dataflow.Value, error) {
+	switch register.RegisterType() {
+	case dataflow.NumberRegister:
+		if err := register.Meta().CheckRange(f); err != nil {
+			return nil, err
+		}
+		return dataflow.NewNumericRegisterValue(deviceName, register, f), nil
+	case dataflow.IntRegister:
+		i := math.Round(f)
+		if err := register.Meta().CheckRange(i); err != nil {
+			return nil, err
+		}
+		return dataflow.NewIntRegisterValue(deviceName, register, int64(i)), nil
+	case dataflow.BoolRegister:
+		return dataflow.NewBoolRegisterValue(deviceName, register, f != 0), nil
+	case dataflow.EnumRegister:
+		idx := int(math.Round(f))
+		if _, ok := register.Enum()[idx]; !ok {
+			return nil, fmt.Errorf("value=%d is not a valid enum", idx)
+		}
+		return dataflow.NewEnumRegisterValue(deviceName, register, idx), nil
+	default:
+		return nil, fmt.Errorf("unsupported register type %s", register.RegisterType())
+	}
+}
@@ -0,0 +1,120 @@
+package modbusServer
+
+import (
+	"errors"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"github.com/tarm/serial"
+	"io"
+	"time"
+)
+
+// rtuReadTimeout is the silence on the line after which a partially received frame is discarded.
+const rtuReadTimeout = 100 * time.Millisecond
+
+func (s *Server) openRtu() error {
+	port, err := serial.OpenPort(&serial.Config{
+		Name:        s.cfg.Device(),
+		Baud:        s.cfg.BaudRate(),
+		ReadTimeout: rtuReadTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot open device: %v", s.cfg.Device())
+	}
+	s.port = port
+	s.debugPrintf("opened device=%s", s.cfg.Device())
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.serveRtu(port)
+	}()
+	return nil
+}
+
+// serveRtu reads rtu frames (slave address, pdu, crc16) from the serial port until the server is shut down.
+// The port returns io.EOF when nothing was received within the read timeout.
+func (s *Server) serveRtu(port io.ReadWriter) {
+	buf := make([]byte, 256)
+	var frame []byte
+	for {
+		n, err := port.Read(buf)
+		if s.ctx.Err() != nil {
+			return
+		}
+		if n > 0 {
+			frame = s.processRtu(port, append(frame, buf[:n]...))
+		}
+
+		if errors.Is(err, io.EOF) {
+			// silence on the line: the frame is complete; this handles requests of unknown length
+			if len(frame) >= 4 && modbus.ValidChecksum(frame) {
+				s.handleRtu(port, frame)
+			} else if len(frame) > 0 {
+				s.debugPrintf("discard incomplete frame: %x", frame)
+			}
+			frame = frame[:0]
+		} else if err != nil {
+			s.logPrintf("read failed: %s", err)
+			time.Sleep(time.Second)
+			frame = frame[:0]
+		}
+	}
+}
+
+// processRtu handles all complete frames at the start of the buffer and returns the remaining bytes.
+func (s *Server) processRtu(w io.Writer, frame []byte) []byte {
+	for len(frame) >= 4 {
+		n, ok := rtuRequestLength(frame)
+		if !ok || len(frame) < n {
+			// wait for more bytes or for the silence marking the end of the frame
+			break
+		}
+		if !modbus.ValidChecksum(frame[:n]) {
+			// resynchronize
+			frame = frame[1:]
+			continue
+		}
+		s.handleRtu(w, frame[:n])
+		frame = frame[n:]
+	}
+	return frame
+}
+
+// rtuRequestLength returns the length of a request frame determined by its function code.
+func rtuRequestLength(frame []byte) (int, bool) {
+	switch frame[1] {
+	case functionReadCoils, functionReadDiscreteInputs,
+		functionReadHoldingRegisters, functionReadInputRegisters,
+		functionWriteSingleCoil, functionWriteSingleRegister:
+		return 8, true
+	case functionWriteMultipleCoils, functionWriteMultipleRegisters:
+		// slave address, function code, address, count, byte count, data, crc
+		if len(frame) < 7 {
+			return 7, true
+		}
+		return 9 + int(frame[6]), true
+	default:
+		return 0, false
+	}
+}
+
+func (s *Server) handleRtu(w io.Writer, frame []byte) {
+	slaveAddress := frame[0]
+	if slaveAddress != 0 && slaveAddress != s.cfg.UnitId() {
+		// request for another slave on the bus
+		return
+	}
+
+	response := s.handle(frame[1 : len(frame)-2])
+	if slaveAddress == 0 {
+		// broadcast requests are not answered
+		return
+	}
+
+	response = append([]byte{slaveAddress}, response...)
+	response = modbus.AppendChecksum(response)
+	if _, err := w.Write(response); err != nil {
+		s.logPrintf("write failed: %s", err)
+	}
+}
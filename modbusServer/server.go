@@ -0,0 +1,158 @@
+package modbusServer
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"io"
+	"log"
+	"net"
+	"slices"
+	"sync"
+)
+
+// Server is a modbus slave exposing the values of the state storage as coils, discrete inputs, holding
+// and input registers. Values written by the master are sent to the command storage.
+type Server struct {
+	cfg Config
+	env *Environment
+
+	tables [4][]mapping // sorted by address
+	keys   map[valueKey]struct{}
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+
+	listener net.Listener
+	port     io.Closer
+
+	connsMutex sync.Mutex
+	conns      map[net.Conn]struct{}
+}
+
+// Run creates the server and starts listening for requests.
+func Run(cfg Config, env *Environment) (*Server, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		cfg:       cfg,
+		env:       env,
+		keys:      make(map[valueKey]struct{}),
+		ctx:       ctx,
+		ctxCancel: cancel,
+		conns:     make(map[net.Conn]struct{}),
+	}
+
+	for _, r := range cfg.Registers() {
+		m := newMapping(r)
+		t := tableFromFunction(r.Function())
+		s.tables[t] = append(s.tables[t], m)
+		s.keys[m.key] = struct{}{}
+	}
+	for _, t := range s.tables {
+		slices.SortFunc(t, func(a, b mapping) int {
+			return int(a.address) - int(b.address)
+		})
+	}
+
+	var err error
+	switch cfg.Kind() {
+	case types.ModbusServerTcpKind:
+		err = s.listenTcp()
+	case types.ModbusServerRtuKind:
+		err = s.openRtu()
+	default:
+		err = fmt.Errorf("unknown kind: %s", cfg.Kind())
+	}
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *Server) Name() string {
+	return s.cfg.Name()
+}
+
+// Addr returns the address the tcp server listens on; it returns nil for rtu servers.
+func (s *Server) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func (s *Server) Shutdown() {
+	s.ctxCancel()
+
+	if s.listener != nil {
+		if err := s.listener.Close(); err != nil {
+			s.debugPrintf("close listener err=%v", err)
+		}
+	}
+	if s.port != nil {
+		if err := s.port.Close(); err != nil {
+			s.debugPrintf("close port err=%v", err)
+		}
+	}
+
+	s.connsMutex.Lock()
+	for conn := range s.conns {
+		if err := conn.Close(); err != nil {
+			s.debugPrintf("close connection err=%v", err)
+		}
+	}
+	s.connsMutex.Unlock()
+
+	s.wg.Wait()
+	s.debugPrintf("shutdown completed")
+}
+
+// values returns the current state of all mapped registers.
+func (s *Server) values() map[valueKey]dataflow.Value {
+	state := s.env.StateStorage.GetStateFiltered(func(v dataflow.Value) bool {
+		_, ok := s.keys[valueKey{v.DeviceName(), v.Register().Name()}]
+		return ok
+	})
+
+	ret := make(map[valueKey]dataflow.Value, len(state))
+	for _, v := range state {
+		ret[valueKey{v.DeviceName(), v.Register().Name()}] = v
+	}
+	return ret
+}
+
+// overlapping returns all mappings of the table touching the given address range.
+func (s *Server) overlapping(t table, address uint16, count int) (ret []mapping) {
+	for _, m := range s.tables[t] {
+		if int(m.address) < int(address)+count && int(address) < m.end() {
+			ret = append(ret, m)
+		}
+	}
+	return
+}
+
+// containing returns the mapping of the table covering the given address.
+func (s *Server) containing(t table, address uint16) (mapping, bool) {
+	for _, m := range s.tables[t] {
+		if m.address <= address && int(address) < m.end() {
+			return m, true
+		}
+	}
+	return mapping{}, false
+}
+
+func (s *Server) logPrintf(format string, v ...interface{}) {
+	log.Printf("modbusServer[%s]: %s", s.cfg.Name(), fmt.Sprintf(format, v...))
+}
+
+func (s *Server) debugPrintf(format string, v ...interface{}) {
+	// check if debug output is enabled
+	if !s.cfg.LogDebug() {
+		return
+	}
+	s.logPrintf(format, v...)
+}
@@ -0,0 +1,353 @@
+package modbusServer
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"io"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	registers []RegisterConfig
+}
+
+func (c testConfig) Name() string                 { return "test" }
+func (c testConfig) Kind() types.ModbusServerKind { return types.ModbusServerTcpKind }
+func (c testConfig) Bind() string                 { return "127.0.0.1" }
+func (c testConfig) Port() int                    { return 0 }
+func (c testConfig) Device() string               { return "" }
+func (c testConfig) BaudRate() int                { return 0 }
+func (c testConfig) UnitId() byte                 { return 1 }
+func (c testConfig) Registers() []RegisterConfig  { return c.registers }
+func (c testConfig) LogDebug() bool               { return false }
+
+type testRegister struct {
+	function, device, register, registerType, wordOrder string
+	address                                             uint16
+	length                                              int
+	scale                                               float64
+}
+
+func (r testRegister) Function() string  { return r.function }
+func (r testRegister) Address() uint16   { return r.address }
+func (r testRegister) Device() string    { return r.device }
+func (r testRegister) Register() string  { return r.register }
+func (r testRegister) Type() string      { return r.registerType }
+func (r testRegister) Length() int       { return r.length }
+func (r testRegister) WordOrder() string { return r.wordOrder }
+func (r testRegister) ByteOrder() string { return "BigEndian" }
+func (r testRegister) Scale() float64    { return r.scale }
+
+var (
+	socRegister      = dataflow.NewRegisterStruct("Battery", "StateOfCharge", "State of charge", dataflow.NumberRegister, nil, "%", 0, false)
+	voltageRegister  = dataflow.NewRegisterStruct("Battery", "Voltage", "Voltage", dataflow.NumberRegister, nil, "V", 1, false)
+	ch1Register      = dataflow.NewRegisterStruct("Relays", "CH1", "Relay 1", dataflow.BoolRegister, nil, "", 0, true)
+	ch2Register      = dataflow.NewRegisterStruct("Relays", "CH2", "Relay 2", dataflow.BoolRegister, nil, "", 1, true)
+	modeRegister     = dataflow.NewRegisterStruct("Control", "Mode", "Mode", dataflow.EnumRegister, map[int]string{0: "Off", 1: "On", 2: "Auto"}, "", 0, true)
+	setpointRegister = dataflow.NewRegisterStruct("Control", "Setpoint", "Setpoint", dataflow.NumberRegister, nil, "W", 1, true)
+)
+
+type testEnv struct {
+	server         *Server
+	stateStorage   *dataflow.ValueStorage
+	commandStorage *dataflow.ValueStorage
+}
+
+func runTestServer(t *testing.T) testEnv {
+	t.Helper()
+
+	registers := map[string]map[string]dataflow.Register{
+		"bmv":   {"StateOfCharge": socRegister, "Voltage": voltageRegister},
+		"relay": {"CH1": ch1Register, "CH2": ch2Register},
+		"ctrl":  {"Mode": modeRegister, "Setpoint": setpointRegister},
+	}
+
+	env := testEnv{
+		stateStorage:   dataflow.NewValueStorage(),
+		commandStorage: dataflow.NewValueStorage(),
+	}
+	env.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv", socRegister, 87.5))
+	env.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv", voltageRegister, 12.5))
+	env.stateStorage.Fill(dataflow.NewBoolRegisterValue("relay", ch1Register, true))
+	env.stateStorage.Fill(dataflow.NewBoolRegisterValue("relay", ch2Register, false))
+	env.stateStorage.Fill(dataflow.NewEnumRegisterValue("ctrl", modeRegister, 1))
+	env.stateStorage.Fill(dataflow.NewNumericRegisterValue("ctrl", setpointRegister, -12.34))
+	env.stateStorage.Wait()
+
+	cfg := testConfig{registers: []RegisterConfig{
+		testRegister{function: "Input", address: 0, device: "bmv", register: "StateOfCharge", registerType: "u16", length: 1, scale: 0.1},
+		testRegister{function: "Input", address: 1, device: "bmv", register: "Voltage", registerType: "float32", length: 2, scale: 1},
+		testRegister{function: "Input", address: 5, device: "bmv", register: "Missing", registerType: "u16", length: 1, scale: 1},
+		testRegister{function: "Coil", address: 0, device: "relay", register: "CH1", registerType: "bool", length: 1, scale: 1},
+		testRegister{function: "Coil", address: 1, device: "relay", register: "CH2", registerType: "bool", length: 1, scale: 1},
+		testRegister{function: "Holding", address: 0, device: "ctrl", register: "Mode", registerType: "u16", length: 1, scale: 1},
+		testRegister{function: "Holding", address: 1, device: "ctrl", register: "Setpoint", registerType: "i32", wordOrder: "LittleEndian", length: 2, scale: 0.01},
+		testRegister{function: "Holding", address: 10, device: "bmv", register: "StateOfCharge", registerType: "u16", length: 1, scale: 1},
+	}}
+
+	server, err := Run(cfg, &Environment{
+		StateStorage:   env.stateStorage,
+		CommandStorage: env.commandStorage,
+		RegisterByName: func(deviceName, registerName string) (dataflow.Register, bool) {
+			r, ok := registers[deviceName][registerName]
+			return r, ok
+		},
+	})
+	if err != nil {
+		t.Fatalf("cannot run server: %s", err)
+	}
+	t.Cleanup(server.Shutdown)
+	env.server = server
+	return env
+}
+
+func (env testEnv) dial(t *testing.T) net.Conn {
+	t.Helper()
+	conn, err := net.Dial("tcp", env.server.Addr().String())
+	if err != nil {
+		t.Fatalf("cannot connect: %s", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// request sends a pdu framed by a mbap header and returns the response pdu.
+func request(t *testing.T, conn net.Conn, unitId byte, pdu ...byte) []byte {
+	t.Helper()
+	_ = conn.SetDeadline(time.Now().Add(time.Second))
+
+	header := []byte{0x12, 0x34, 0, 0, 0, 0, unitId}
+	binary.BigEndian.PutUint16(header[4:], uint16(len(pdu)+1))
+	if _, err := conn.Write(append(header, pdu...)); err != nil {
+		t.Fatalf("write failed: %s", err)
+	}
+
+	if _, err := io.ReadFull(conn, header); err != nil {
+		t.Fatalf("read failed: %s", err)
+	}
+	if header[0] != 0x12 || header[1] != 0x34 || header[6] != unitId {
+		t.Fatalf("unexpected header: %x", header)
+	}
+	response := make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+	if _, err := io.ReadFull(conn, response); err != nil {
+		t.Fatalf("read failed: %s", err)
+	}
+	return response
+}
+
+func (env testEnv) command(t *testing.T, deviceName, registerName string) string {
+	t.Helper()
+	env.commandStorage.Wait()
+	values := env.commandStorage.GetStateFiltered(func(v dataflow.Value) bool {
+		return v.DeviceName() == deviceName && v.Register().Name() == registerName
+	})
+	if len(values) != 1 {
+		return ""
+	}
+	return values[0].String()
+}
+
+func TestServer_Read(t *testing.T) {
+	env := runTestServer(t)
+	conn := env.dial(t)
+
+	voltage := math.Float32bits(12.5)
+	tests := []struct {
+		name   string
+		pdu    []byte
+		expect []byte
+	}{
+		{
+			"input registers",
+			[]byte{0x04, 0, 0, 0, 4},
+			[]byte{0x04, 8, 0x03, 0x6B, byte(voltage >> 24), byte(voltage >> 16), byte(voltage >> 8), byte(voltage), 0, 0},
+		},
+		{
+			"start in the middle of a value",
+			[]byte{0x04, 0, 2, 0, 1},
+			[]byte{0x04, 2, byte(voltage >> 8), byte(voltage)},
+		},
+		{
+			"coils",
+			[]byte{0x01, 0, 0, 0, 3},
+			[]byte{0x01, 1, 0x01},
+		},
+		{
+			"holding registers",
+			[]byte{0x03, 0, 0, 0, 3},
+			// -1234 = 0xFFFFFB2E with the low word first
+			[]byte{0x03, 6, 0x00, 0x01, 0xFB, 0x2E, 0xFF, 0xFF},
+		},
+		{
+			"unavailable value",
+			[]byte{0x04, 0, 4, 0, 2},
+			[]byte{0x84, 0x0B},
+		},
+		{
+			"count too large",
+			[]byte{0x03, 0, 0, 0, 126},
+			[]byte{0x83, 0x03},
+		},
+		{
+			"beyond the address space",
+			[]byte{0x04, 0xFF, 0xFF, 0, 2},
+			[]byte{0x84, 0x02},
+		},
+		{
+			"unsupported function",
+			[]byte{0x2B, 0x0E, 0x01, 0x00},
+			[]byte{0xAB, 0x01},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := request(t, conn, 1, tc.pdu...); !bytes.Equal(got, tc.expect) {
+				t.Errorf("expect %x but got %x", tc.expect, got)
+			}
+		})
+	}
+
+	// requests for another unit are rejected
+	if got, expect := request(t, conn, 2, 0x01, 0, 0, 0, 1), []byte{0x81, 0x0B}; !bytes.Equal(got, expect) {
+		t.Errorf("expect %x but got %x", expect, got)
+	}
+}
+
+func TestServer_Write(t *testing.T) {
+	env := runTestServer(t)
+	conn := env.dial(t)
+
+	tests := []struct {
+		name     string
+		pdu      []byte
+		expect   []byte
+		device   string
+		register string
+		command  string
+	}{
+		{
+			"single coil",
+			[]byte{0x05, 0, 1, 0xFF, 0x00},
+			[]byte{0x05, 0, 1, 0xFF, 0x00},
+			"relay", "CH2", "CH2=true",
+		},
+		{
+			"multiple coils",
+			[]byte{0x0F, 0, 0, 0, 2, 1, 0x02},
+			[]byte{0x0F, 0, 0, 0, 2},
+			"relay", "CH1", "CH1=false",
+		},
+		{
+			"single register enum",
+			[]byte{0x06, 0, 0, 0, 2},
+			[]byte{0x06, 0, 0, 0, 2},
+			"ctrl", "Mode", "Mode=2:Auto",
+		},
+		{
+			"multiple registers",
+			// 1234 = 0x000004D2 with the low word first
+			[]byte{0x10, 0, 1, 0, 2, 4, 0x04, 0xD2, 0x00, 0x00},
+			[]byte{0x10, 0, 1, 0, 2},
+			"ctrl", "Setpoint", "Setpoint=12.340000W",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := request(t, conn, 1, tc.pdu...); !bytes.Equal(got, tc.expect) {
+				t.Errorf("expect %x but got %x", tc.expect, got)
+			}
+			if got := env.command(t, tc.device, tc.register); got != tc.command {
+				t.Errorf("expect command %s but got %s", tc.command, got)
+			}
+		})
+	}
+
+	exceptions := []struct {
+		name   string
+		pdu    []byte
+		expect []byte
+	}{
+		{"unmapped coil", []byte{0x05, 0, 7, 0xFF, 0x00}, []byte{0x85, 0x02}},
+		{"invalid coil value", []byte{0x05, 0, 0, 0x12, 0x34}, []byte{0x85, 0x03}},
+		{"part of a multi register value", []byte{0x06, 0, 1, 0, 1}, []byte{0x86, 0x02}},
+		{"read only register", []byte{0x06, 0, 10, 0, 1}, []byte{0x86, 0x02}},
+		{"invalid enum", []byte{0x06, 0, 0, 0, 5}, []byte{0x86, 0x03}},
+		{"partially mapped range", []byte{0x10, 0, 0, 0, 2, 4, 0, 1, 0, 0}, []byte{0x90, 0x02}},
+		{"invalid byte count", []byte{0x10, 0, 1, 0, 2, 3, 0, 1, 0}, []byte{0x90, 0x03}},
+	}
+	for _, tc := range exceptions {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := request(t, conn, 1, tc.pdu...); !bytes.Equal(got, tc.expect) {
+				t.Errorf("expect %x but got %x", tc.expect, got)
+			}
+		})
+	}
+
+	// a rejected request must not send any command
+	if got := env.command(t, "ctrl", "Mode"); got != "Mode=2:Auto" {
+		t.Errorf("expect command Mode=2:Auto but got %s", got)
+	}
+}
+
+// fakePort returns the given chunks followed by io.EOF, as a serial port does on a read timeout.
+// The second timeout stops the server.
+type fakePort struct {
+	chunks   [][]byte
+	timeouts int
+	stop     func()
+	written  bytes.Buffer
+}
+
+func (p *fakePort) Read(b []byte) (int, error) {
+	if len(p.chunks) < 1 {
+		if p.timeouts++; p.timeouts > 1 {
+			p.stop()
+		}
+		return 0, io.EOF
+	}
+	n := copy(b, p.chunks[0])
+	p.chunks = p.chunks[1:]
+	return n, nil
+}
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	return p.written.Write(b)
+}
+
+func rtuFrame(data ...byte) []byte {
+	return modbus.AppendChecksum(data)
+}
+
+func TestServer_Rtu(t *testing.T) {
+	env := runTestServer(t)
+
+	request := rtuFrame(0x01, 0x01, 0, 0, 0, 2)
+	port := &fakePort{stop: env.server.ctxCancel, chunks: [][]byte{
+		// a request for another slave is ignored
+		rtuFrame(0x02, 0x01, 0, 0, 0, 2),
+		// a request split into two chunks
+		request[:3],
+		request[3:],
+		// a broadcast is executed but not answered
+		rtuFrame(0x00, 0x05, 0, 1, 0xFF, 0x00),
+		// a frame with an invalid checksum is discarded
+		{0x01, 0x04, 0, 0, 0, 1, 0, 0},
+	}}
+	env.server.serveRtu(port)
+
+	expect := rtuFrame(0x01, 0x01, 1, 0x01)
+	if got := port.written.Bytes(); !bytes.Equal(got, expect) {
+		t.Errorf("expect %x but got %x", expect, got)
+	}
+	if got := env.command(t, "relay", "CH2"); got != "CH2=true" {
+		t.Errorf("expect command CH2=true but got %s", got)
+	}
+}
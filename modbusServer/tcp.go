@@ -0,0 +1,110 @@
+package modbusServer
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	mbapHeaderLen = 7
+	maxPduLen     = 253
+)
+
+func (s *Server) listenTcp() error {
+	address := net.JoinHostPort(s.cfg.Bind(), strconv.Itoa(s.cfg.Port()))
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	s.listener = listener
+	s.debugPrintf("listen on %s", listener.Addr())
+
+	s.wg.Add(1)
+	go s.acceptTcp()
+	return nil
+}
+
+func (s *Server) acceptTcp() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logPrintf("accept failed: %s", err)
+			time.Sleep(100 * time.Millisecond)
+			continue
+		}
+
+		s.connsMutex.Lock()
+		if s.ctx.Err() != nil {
+			s.connsMutex.Unlock()
+			_ = conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.connsMutex.Unlock()
+
+		go s.serveTcp(conn)
+	}
+}
+
+// serveTcp handles the requests of a single master; every pdu is framed by a mbap header.
+func (s *Server) serveTcp(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.connsMutex.Lock()
+		delete(s.conns, conn)
+		s.connsMutex.Unlock()
+		_ = conn.Close()
+	}()
+
+	s.debugPrintf("connection from %s", conn.RemoteAddr())
+
+	header := make([]byte, mbapHeaderLen)
+	for {
+		if _, err := io.ReadFull(conn, header); err != nil {
+			if !errors.Is(err, io.EOF) && s.ctx.Err() == nil {
+				s.debugPrintf("read from %s failed: %s", conn.RemoteAddr(), err)
+			}
+			return
+		}
+
+		// header structure:
+		// 2 bytes transaction id, echoed in the response
+		// 2 bytes protocol id, always 0
+		// 2 bytes length of the unit id and the pdu
+		// 1 byte unit id
+		protocolId := binary.BigEndian.Uint16(header[2:])
+		length := int(binary.BigEndian.Uint16(header[4:]))
+		if protocolId != 0 || length < 2 || length > maxPduLen+1 {
+			s.debugPrintf("invalid header from %s: %x", conn.RemoteAddr(), header)
+			return
+		}
+
+		pdu := make([]byte, length-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			s.debugPrintf("read from %s failed: %s", conn.RemoteAddr(), err)
+			return
+		}
+
+		var response []byte
+		if unitId := header[6]; unitId == s.cfg.UnitId() || unitId == 0 || unitId == 0xFF {
+			response = s.handle(pdu)
+		} else {
+			response = []byte{pdu[0] | 0x80, byte(exceptionGatewayTargetDeviceFailedToRespond)}
+		}
+
+		binary.BigEndian.PutUint16(header[4:], uint16(len(response)+1))
+		if _, err := conn.Write(append(header, response...)); err != nil {
+			s.debugPrintf("write to %s failed: %s", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
@@ -16,22 +16,30 @@ func runMqttClient(
 	mqttClientPool = pool.RunPool[mqttClient.Client]()
 
 	for _, c := range cfg.MqttClients() {
-		if cfg.LogWorkerStart() {
-			log.Printf(
-				"mqttClient[%s]: start: Broker='%s', ClientId='%s'",
-				c.Name(), c.Broker(), c.ClientId(),
-			)
-		}
-
-		mcCfg := mqttClientConfig{c}
-		client := mqttClient.NewV5(mcCfg)
-		client.Run()
-		mqttClientPool.Add(client)
+		runMqttClientInstance(cfg, c, mqttClientPool)
 	}
 
 	return
 }
 
+func runMqttClientInstance(
+	cfg *config.Config,
+	c config.MqttClientConfig,
+	mqttClientPool *pool.Pool[mqttClient.Client],
+) {
+	if cfg.LogWorkerStart() {
+		log.Printf(
+			"mqttClient[%s]: start: Broker='%s', ClientId='%s'",
+			c.Name(), c.Broker(), c.ClientId(),
+		)
+	}
+
+	mcCfg := mqttClientConfig{c}
+	client := mqttClient.NewV5(mcCfg)
+	client.Run()
+	mqttClientPool.Add(client)
+}
+
 type mqttClientConfig struct {
 	config.MqttClientConfig
 }
@@ -72,6 +80,10 @@ func (c forwarderConfig) Command() mqttForwarders.MqttSectionConfig {
 	return forwarderMqttSectionConfig{c.MqttClientConfig.Command()}
 }
 
+func (c forwarderConfig) Alarms() mqttForwarders.MqttSectionConfig {
+	return forwarderMqttSectionConfig{c.MqttClientConfig.Alarms()}
+}
+
 type forwarderMqttSectionConfig struct {
 	config.MqttSectionConfig
 }
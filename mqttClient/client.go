@@ -7,6 +7,7 @@ import (
 	"github.com/koestler/go-iotdevice/v3/queue"
 	"log"
 	"sync"
+	"sync/atomic"
 )
 
 type ClientStruct struct {
@@ -21,6 +22,8 @@ type ClientStruct struct {
 	cm             *autopaho.ConnectionManager
 	router         *paho.StandardRouter
 	publishBacklog queue.Fifo[*paho.Publish]
+	published      atomic.Uint64
+	backlogFailed  atomic.Uint64
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -39,6 +42,17 @@ func (c *ClientStruct) GetCtx() context.Context {
 	return c.ctx
 }
 
+func (c *ClientStruct) BacklogSize() int {
+	return c.publishBacklog.Len()
+}
+
+func (c *ClientStruct) Stats() Stats {
+	return Stats{
+		Published:      c.published.Load(),
+		BacklogDropped: c.publishBacklog.Dropped() + c.backlogFailed.Load(),
+	}
+}
+
 func (c *ClientStruct) AddRoute(subscribeTopic string, messageHandler MessageHandler) {
 	s := subscription{subscribeTopic: subscribeTopic}
 
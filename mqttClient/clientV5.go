@@ -121,7 +121,10 @@ func (c *ClientStruct) onConnectionUp() func(*autopaho.ConnectionManager, *paho.
 				}
 
 				if _, err := c.cm.Publish(c.ctx, p); err != nil {
+					c.backlogFailed.Add(1)
 					log.Printf("mqttClientV5[%s]: cannot publish backlog, truncating: %s", c.cfg.Name(), err)
+				} else {
+					c.published.Add(1)
 				}
 			}
 		}()
@@ -172,6 +175,8 @@ func (c *ClientStruct) Publish(topic string, payload []byte, qos byte, retain bo
 			log.Printf("mqttClientV5[%s]: error during publish, add to backlog: %s", c.cfg.Name(), err)
 		}
 		c.publishBacklog.Enqueue(p)
+	} else {
+		c.published.Add(1)
 	}
 }
 
@@ -42,6 +42,13 @@ type Client interface {
 	Shutdown()
 	Publish(topic string, payload []byte, qos byte, retain bool)
 	AddRoute(subscribeTopic string, messageHandler MessageHandler)
+	BacklogSize() int // number of messages waiting to be published
+	Stats() Stats
+}
+
+type Stats struct {
+	Published      uint64 // number of messages successfully published
+	BacklogDropped uint64 // number of messages discarded from the backlog
 }
 
 type MessageHandler func(Message)
@@ -2,6 +2,7 @@ package mqttDevice
 
 import (
 	"context"
+	"fmt"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
 	"github.com/koestler/go-iotdevice/v3/mqttClient"
@@ -13,6 +14,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type Config interface {
@@ -140,7 +142,8 @@ func (c *DeviceStruct) updateRegisters(registers []mqttForwarders.StructRegister
 			structRegs = append(structRegs, r)
 		}
 	}
-	c.RegisterDb().Add(structRegs...)
+	// the structure message contains all registers; the ones no longer sent are removed
+	c.ReplaceRegisters(structRegs...)
 }
 
 func (c *DeviceStruct) setupAvailabilitySubscription(mc mqttClient.Client, availabilityTopics []string) {
@@ -198,15 +201,23 @@ func (c *DeviceStruct) setupTelemetrySubscription(mc mqttClient.Client, topic st
 
 			case dataflow.NumberRegister:
 				if v, ok := telemetryMessage.NumericValues[register.Name()]; ok {
-					c.StateStorage().Fill(dataflow.NewNumericRegisterValue(c.Name(), register, v.Value))
+					c.Output().Fill(withRemoteTime(dataflow.NewNumericRegisterValue(c.Name(), register, v.Value), v.Time, v.SourceTime))
 				}
 			case dataflow.TextRegister:
 				if v, ok := telemetryMessage.TextValues[register.Name()]; ok {
-					c.StateStorage().Fill(dataflow.NewTextRegisterValue(c.Name(), register, v.Value))
+					c.Output().Fill(withRemoteTime(dataflow.NewTextRegisterValue(c.Name(), register, v.Value), v.Time, v.SourceTime))
 				}
 			case dataflow.EnumRegister:
 				if v, ok := telemetryMessage.EnumValues[register.Name()]; ok {
-					c.StateStorage().Fill(dataflow.NewEnumRegisterValue(c.Name(), register, v.EnumIdx))
+					c.Output().Fill(withRemoteTime(dataflow.NewEnumRegisterValue(c.Name(), register, v.EnumIdx), v.Time, v.SourceTime))
+				}
+			case dataflow.BoolRegister:
+				if v, ok := telemetryMessage.EnumValues[register.Name()]; ok {
+					c.Output().Fill(withRemoteTime(dataflow.NewBoolRegisterValue(c.Name(), register, v.EnumIdx != 0), v.Time, v.SourceTime))
+				}
+			case dataflow.IntRegister:
+				if v, ok := telemetryMessage.NumericValues[register.Name()]; ok {
+					c.Output().Fill(withRemoteTime(dataflow.NewIntRegisterValue(c.Name(), register, int64(v.Value)), v.Time, v.SourceTime))
 				}
 			default:
 				if c.Config().LogDebug() {
@@ -264,23 +275,57 @@ func (c *DeviceStruct) setupRealtimeSubscription(mc mqttClient.Client, topicTemp
 			return
 		}
 
+		var value dataflow.Value
 		switch register.RegisterType() {
 		case dataflow.NumberRegister:
 			if v := realtimeMessage.NumericValue; v != nil {
-				c.StateStorage().Fill(dataflow.NewNumericRegisterValue(c.Name(), register, *v))
+				value = dataflow.NewNumericRegisterValue(c.Name(), register, *v)
 			}
 		case dataflow.TextRegister:
 			if v := realtimeMessage.TextValue; v != nil {
-				c.StateStorage().Fill(dataflow.NewTextRegisterValue(c.Name(), register, *v))
+				value = dataflow.NewTextRegisterValue(c.Name(), register, *v)
 			}
 		case dataflow.EnumRegister:
 			if v := realtimeMessage.EnumIdx; v != nil {
-				c.StateStorage().Fill(dataflow.NewEnumRegisterValue(c.Name(), register, *v))
+				value = dataflow.NewEnumRegisterValue(c.Name(), register, *v)
 			}
+		case dataflow.BoolRegister:
+			if v := realtimeMessage.BoolValue; v != nil {
+				value = dataflow.NewBoolRegisterValue(c.Name(), register, *v)
+			} else if v := realtimeMessage.EnumIdx; v != nil {
+				value = dataflow.NewBoolRegisterValue(c.Name(), register, *v != 0)
+			}
+		case dataflow.IntRegister:
+			if v := realtimeMessage.IntValue; v != nil {
+				value = dataflow.NewIntRegisterValue(c.Name(), register, *v)
+			} else if v := realtimeMessage.NumericValue; v != nil {
+				value = dataflow.NewIntRegisterValue(c.Name(), register, int64(*v))
+			}
+		}
+		if value == nil {
+			return
 		}
+
+		value = withRemoteTime(value, realtimeMessage.Time, realtimeMessage.SourceTime)
+		value = value.WithQuality(dataflow.QualityFromString(realtimeMessage.Quality))
+		c.Output().Fill(value)
 	})
 }
 
+// withRemoteTime sets the time of the sample on the remote instance as source time of the value.
+// The source time of the remote is preferred over its measured time. Missing or invalid times are ignored.
+func withRemoteTime(v dataflow.Value, remoteTime, remoteSourceTime string) dataflow.Value {
+	for _, ts := range []string{remoteSourceTime, remoteTime} {
+		if len(ts) < 1 {
+			continue
+		}
+		if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+			return v.WithTimes(v.MeasuredAt(), t)
+		}
+	}
+	return v
+}
+
 func (c *DeviceStruct) runCommandForwarder(
 	ctx context.Context,
 	mc mqttClient.Client,
@@ -299,7 +344,11 @@ func (c *DeviceStruct) runCommandForwarder(
 		return ok
 	}
 
-	subscription := c.commandStorage.SubscribeSendInitial(ctx, filter)
+	// publishing blocks while the broker is unreachable; only the latest command per register is sent then
+	subscription := c.commandStorage.SubscribeSendInitial(ctx, filter,
+		dataflow.WithName(fmt.Sprintf("mqttDevice[%s]->mqttClient[%s]->command", c.Name(), mc.Name())),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
 	for command := range subscription.Drain() {
 		topic := strings.Replace(topicTemplate, "%RegisterName%", command.Register().Name(), 1)
 
@@ -314,6 +363,17 @@ func (c *DeviceStruct) runCommandForwarder(
 		} else if enum, ok := command.(dataflow.EnumRegisterValue); ok {
 			v := enum.EnumIdx()
 			msg.EnumIdx = &v
+		} else if boolean, ok := command.(dataflow.BoolRegisterValue); ok {
+			// also send the enum index, the remote might run an older version
+			v := boolean.Value()
+			idx := boolean.EnumIdx()
+			msg.BoolValue = &v
+			msg.EnumIdx = &idx
+		} else if integer, ok := command.(dataflow.IntRegisterValue); ok {
+			v := integer.Value()
+			f := float64(v)
+			msg.IntValue = &v
+			msg.NumericValue = &f
 		} else {
 			continue
 		}
@@ -21,7 +21,11 @@ func (s StructRegister) Description() string {
 	return s.StructRegister.Description
 }
 
+// RegisterType prefers the exact ValueType; it is missing when the remote runs an older version.
 func (s StructRegister) RegisterType() dataflow.RegisterType {
+	if len(s.StructRegister.ValueType) > 0 {
+		return dataflow.RegisterTypeFromString(s.StructRegister.ValueType)
+	}
 	return dataflow.RegisterTypeFromString(s.StructRegister.Type)
 }
 
@@ -40,3 +44,14 @@ func (s StructRegister) Sort() int {
 func (s StructRegister) Writable() bool {
 	return s.StructRegister.Writable
 }
+
+func (s StructRegister) Meta() dataflow.RegisterMeta {
+	return dataflow.RegisterMeta{
+		Min:         s.StructRegister.Min,
+		Max:         s.StructRegister.Max,
+		Step:        s.StructRegister.Step,
+		Precision:   s.StructRegister.Precision,
+		DeviceClass: s.StructRegister.DeviceClass,
+		StateClass:  s.StructRegister.StateClass,
+	}
+}
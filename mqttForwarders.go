@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/alarms"
 	"github.com/koestler/go-iotdevice/v3/config"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
@@ -10,16 +12,39 @@ import (
 	"github.com/koestler/go-iotdevice/v3/restarter"
 )
 
+// runMqttForwarders starts the forwarders of all mqtt clients; the returned cancel functions stop them by client name.
 func runMqttForwarders(
 	cfg *config.Config,
 	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
 	mqttClientPool *pool.Pool[mqttClient.Client],
 	stateStorage *dataflow.ValueStorage,
 	commandStorage *dataflow.ValueStorage,
-) {
+	alarmEngine *alarms.Engine,
+) map[string]context.CancelFunc {
+	ret := make(map[string]context.CancelFunc)
 	for _, c := range cfg.MqttClients() {
-		forwarderCfg := forwarderConfig{c}
-		client := mqttClientPool.GetByName(c.Name())
-		go mqttForwarders.RunMqttForwarders(forwarderCfg, client, devicePool, stateStorage, commandStorage)
+		if cancel := runMqttForwarder(c, devicePool, mqttClientPool, stateStorage, commandStorage, alarmEngine); cancel != nil {
+			ret[c.Name()] = cancel
+		}
 	}
+	return ret
+}
+
+func runMqttForwarder(
+	c config.MqttClientConfig,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	mqttClientPool *pool.Pool[mqttClient.Client],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+	alarmEngine *alarms.Engine,
+) context.CancelFunc {
+	client := mqttClientPool.GetByName(c.Name())
+	if client == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(client.GetCtx())
+	forwarderCfg := forwarderConfig{c}
+	go mqttForwarders.RunMqttForwarders(ctx, forwarderCfg, client, devicePool, stateStorage, commandStorage, alarmEngine)
+	return cancel
 }
@@ -0,0 +1,89 @@
+package mqttForwarders
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/alarms"
+	"github.com/koestler/go-iotdevice/v3/mqttClient"
+	"log"
+)
+
+// AlarmMessage is the payload of the alarm topics. It contains the current state of the alarm.
+type AlarmMessage struct {
+	State    string `json:"State"`
+	Severity string `json:"Severity"`
+	Kind     string `json:"Kind"`
+	Device   string `json:"Device"`
+	Register string `json:"Register,omitempty"`
+	Value    string `json:"Value,omitempty"`
+	Message  string `json:"Message"`
+	Time     string `json:"Time,omitempty"`
+	AckedBy  string `json:"AckedBy,omitempty"`
+}
+
+func runAlarmsForwarder(
+	ctx context.Context,
+	cfg Config,
+	mc mqttClient.Client,
+	engine *alarms.Engine,
+) {
+	devices := make(map[string]struct{})
+	for _, d := range cfg.Alarms().Devices() {
+		devices[d.Name()] = struct{}{}
+	}
+
+	if cfg.LogDebug() {
+		log.Printf("mqttClient[%s]->alarms: start", mc.Name())
+	}
+
+	go func() {
+		events := engine.Subscribe(ctx)
+
+		// send the current state of all alarms initially
+		for _, s := range engine.Status() {
+			if _, ok := devices[s.Device]; ok {
+				publishAlarmMessage(cfg, mc, s.Name, AlarmMessage{
+					State:    s.State.String(),
+					Severity: s.Severity.String(),
+					Kind:     s.Kind.String(),
+					Device:   s.Device,
+					Register: s.Register,
+					Value:    s.Value,
+					Message:  s.Message,
+					Time:     formatValueTime(s.Since),
+					AckedBy:  s.AckedBy,
+				})
+			}
+		}
+
+		// for loop ends when the subscription is canceled and closes its output chan
+		for ev := range events {
+			if _, ok := devices[ev.Device]; ok {
+				publishAlarmMessage(cfg, mc, ev.Alarm, AlarmMessage{
+					State:    ev.State.String(),
+					Severity: ev.Severity.String(),
+					Kind:     ev.Kind.String(),
+					Device:   ev.Device,
+					Register: ev.Register,
+					Value:    ev.Value,
+					Message:  ev.Message,
+					Time:     formatValueTime(ev.Time),
+					AckedBy:  ev.User,
+				})
+			}
+		}
+	}()
+}
+
+func publishAlarmMessage(cfg Config, mc mqttClient.Client, alarmName string, msg AlarmMessage) {
+	mCfg := cfg.Alarms()
+
+	if cfg.LogDebug() {
+		log.Printf("mqttClient[%s]->alarms[%s]: send: %s", mc.Name(), alarmName, msg.State)
+	}
+
+	if payload, err := json.Marshal(msg); err != nil {
+		log.Printf("mqttClient[%s]->alarms[%s]: cannot generate message: %s", mc.Name(), alarmName, err)
+	} else {
+		mc.Publish(cfg.AlarmsTopic(alarmName), payload, mCfg.Qos(), mCfg.Retain())
+	}
+}
@@ -6,12 +6,15 @@ import (
 	"github.com/koestler/go-iotdevice/v3/device"
 	"github.com/koestler/go-iotdevice/v3/mqttClient"
 	"log"
+	"math"
 )
 
 type CommandMessage struct {
 	NumericValue *float64 `json:"NumVal,omitempty"`
 	TextValue    *string  `json:"TextVal,omitempty"`
 	EnumIdx      *int     `json:"EnumIdx,omitempty"`
+	BoolValue    *bool    `json:"BoolVal,omitempty"`
+	IntValue     *int64   `json:"IntVal,omitempty"`
 }
 
 func runCommandForwarder(
@@ -41,12 +44,13 @@ func commandRoutine(
 		case <-ctx.Done():
 			return
 		case reg := <-regSubscription:
-			setupCommandSubscription(cfg, dev, mc, commandStorage, reg)
+			setupCommandSubscription(ctx, cfg, dev, mc, commandStorage, reg)
 		}
 	}
 }
 
 func setupCommandSubscription(
+	ctx context.Context,
 	cfg Config,
 	dev device.Device,
 	mc mqttClient.Client,
@@ -70,46 +74,64 @@ func setupCommandSubscription(
 	}
 
 	mc.AddRoute(topic, func(m mqttClient.Message) {
+		// routes cannot be removed; ignore the messages once the forwarder is stopped, e.g. by a config reload
+		if ctx.Err() != nil {
+			return
+		}
+
 		msg, err := parseCommandMessagePayload(m.Payload())
 		if err != nil {
 			log.Printf("mqttDevice[%s]->mqttClient[%s]->command: cannod parse message: %s", mc.Name(), dev.Name(), err)
 			return
 		}
 
-		switch register.RegisterType() {
-		case dataflow.NumberRegister:
-			if v := msg.NumericValue; v != nil {
-				rv := dataflow.NewNumericRegisterValue(deviceName, register, *v)
-				commandStorage.Fill(rv)
-				if logDebug {
-					log.Printf("mqttDevice[%s]->mqttClient[%s]->command: send NumericValue deviceName=%s: %s", mc.Name(), dev.Name(), deviceName, rv.String())
-				}
-				return
-			}
-		case dataflow.TextRegister:
-			if v := msg.TextValue; v != nil {
-				rv := dataflow.NewTextRegisterValue(deviceName, register, *v)
-				commandStorage.Fill(rv)
-				if logDebug {
-					log.Printf("mqttDevice[%s]->mqttClient[%s]->command: send TextValue deviceName=%s: %s", mc.Name(), dev.Name(), deviceName, rv.String())
-				}
-				return
-			}
-		case dataflow.EnumRegister:
-			if v := msg.EnumIdx; v != nil {
-				rv := dataflow.NewEnumRegisterValue(deviceName, register, *v)
-				commandStorage.Fill(rv)
-				if logDebug {
-					log.Printf("mqttDevice[%s]->mqttClient[%s]->command: send EnumValue deviceName=%s: %s", mc.Name(), dev.Name(), deviceName, rv.String())
-				}
-				return
+		if rv, ok := convertCommandMessageToValue(deviceName, register, msg); ok {
+			commandStorage.Fill(rv)
+			if logDebug {
+				log.Printf("mqttDevice[%s]->mqttClient[%s]->command: send deviceName=%s: %s", mc.Name(), dev.Name(), deviceName, rv.String())
 			}
+			return
 		}
 
 		log.Printf("mqttDevice[%s]->mqttClient[%s]->command: invalid command message: %#v", mc.Name(), dev.Name(), msg)
 	})
 }
 
+// convertCommandMessageToValue returns the command value for the given register.
+// Boolean registers also accept an EnumIdx of 0 / 1 and integer registers a whole NumVal
+// since older clients only know the number, text and enum types.
+func convertCommandMessageToValue(deviceName string, register dataflow.Register, msg CommandMessage) (dataflow.Value, bool) {
+	switch register.RegisterType() {
+	case dataflow.NumberRegister:
+		if v := msg.NumericValue; v != nil {
+			return dataflow.NewNumericRegisterValue(deviceName, register, *v), true
+		}
+	case dataflow.TextRegister:
+		if v := msg.TextValue; v != nil {
+			return dataflow.NewTextRegisterValue(deviceName, register, *v), true
+		}
+	case dataflow.EnumRegister:
+		if v := msg.EnumIdx; v != nil {
+			return dataflow.NewEnumRegisterValue(deviceName, register, *v), true
+		}
+	case dataflow.BoolRegister:
+		if v := msg.BoolValue; v != nil {
+			return dataflow.NewBoolRegisterValue(deviceName, register, *v), true
+		}
+		if v := msg.EnumIdx; v != nil && (*v == 0 || *v == 1) {
+			return dataflow.NewBoolRegisterValue(deviceName, register, *v == 1), true
+		}
+	case dataflow.IntRegister:
+		if v := msg.IntValue; v != nil {
+			return dataflow.NewIntRegisterValue(deviceName, register, *v), true
+		}
+		if v := msg.NumericValue; v != nil && *v == math.Trunc(*v) {
+			return dataflow.NewIntRegisterValue(deviceName, register, int64(*v)), true
+		}
+	}
+	return nil, false
+}
+
 func parseCommandMessagePayload(payload []byte) (msg CommandMessage, err error) {
 	err = json.Unmarshal(payload, &msg)
 	return
@@ -1,6 +1,8 @@
 package mqttForwarders
 
 import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/alarms"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
 	"github.com/koestler/go-iotdevice/v3/mqttClient"
@@ -34,6 +36,9 @@ type Config interface {
 	Command() MqttSectionConfig
 	CommandTopic(deviceName, registerName string) string
 
+	Alarms() MqttSectionConfig
+	AlarmsTopic(alarmName string) string
+
 	LogDebug() bool
 }
 
@@ -50,17 +55,20 @@ type MqttDeviceSectionConfig interface {
 	Filter() dataflow.RegisterFilterConf
 }
 
+// RunMqttForwarders starts all forwarders of the given client; they stop when ctx is done.
 func RunMqttForwarders(
+	ctx context.Context,
 	cfg Config,
 	mc mqttClient.Client,
 	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
 	stateStorage *dataflow.ValueStorage,
 	commandStorage *dataflow.ValueStorage,
+	alarmEngine *alarms.Engine,
 ) {
 	if sCfg := cfg.HomeassistantDiscovery(); sCfg.Enabled() {
 		for _, deviceConfig := range cfg.HomeassistantDiscovery().Devices() {
 			if dev := devicePool.GetByName(deviceConfig.Name()); dev != nil {
-				runHomeassistantDiscoveryForwarder(mc.GetCtx(), cfg, dev.Service(), mc, deviceConfig.Filter())
+				runHomeassistantDiscoveryForwarder(ctx, cfg, dev.Service(), mc, deviceConfig.Filter())
 			} else {
 				log.Printf("RunMqttForwarders: dev=%s not found", deviceConfig.Name())
 			}
@@ -74,7 +82,7 @@ func RunMqttForwarders(
 	if sCfg := cfg.AvailabilityDevice(); sCfg.Enabled() {
 		for _, deviceConfig := range sCfg.Devices() {
 			if dev := devicePool.GetByName(deviceConfig.Name()); dev != nil {
-				runAvailabilityForwarder(mc.GetCtx(), cfg, dev.Service(), mc)
+				runAvailabilityForwarder(ctx, cfg, dev.Service(), mc)
 			} else {
 				log.Printf("RunMqttForwarders: dev=%s not found", deviceConfig.Name())
 			}
@@ -84,7 +92,7 @@ func RunMqttForwarders(
 	if sCfg := cfg.Structure(); sCfg.Enabled() {
 		for _, deviceConfig := range sCfg.Devices() {
 			if dev := devicePool.GetByName(deviceConfig.Name()); dev != nil {
-				runStructureForwarder(mc.GetCtx(), cfg, dev.Service(), mc, deviceConfig.Filter())
+				runStructureForwarder(ctx, cfg, dev.Service(), mc, deviceConfig.Filter())
 			} else {
 				log.Printf("RunMqttForwarders: dev=%s not found", deviceConfig.Name())
 			}
@@ -94,7 +102,7 @@ func RunMqttForwarders(
 	if sCfg := cfg.Telemetry(); sCfg.Enabled() {
 		for _, deviceConfig := range sCfg.Devices() {
 			if dev := devicePool.GetByName(deviceConfig.Name()); dev != nil {
-				runTelemetryForwarder(mc.GetCtx(), cfg, dev.Service(), mc, stateStorage, deviceConfig.Filter())
+				runTelemetryForwarder(ctx, cfg, dev.Service(), mc, stateStorage, deviceConfig.Filter())
 			} else {
 				log.Printf("RunMqttForwarders: dev=%s not found", deviceConfig.Name())
 			}
@@ -104,7 +112,7 @@ func RunMqttForwarders(
 	if sCfg := cfg.Realtime(); sCfg.Enabled() {
 		for _, deviceConfig := range sCfg.Devices() {
 			if dev := devicePool.GetByName(deviceConfig.Name()); dev != nil {
-				runRealtimeForwarder(mc.GetCtx(), cfg, dev.Service(), mc, stateStorage, deviceConfig.Filter())
+				runRealtimeForwarder(ctx, cfg, dev.Service(), mc, stateStorage, deviceConfig.Filter())
 			} else {
 				log.Printf("RunMqttForwarders: dev=%s not found", deviceConfig.Name())
 			}
@@ -114,10 +122,14 @@ func RunMqttForwarders(
 	if sCfg := cfg.Command(); sCfg.Enabled() {
 		for _, deviceConfig := range cfg.Command().Devices() {
 			if dev := devicePool.GetByName(deviceConfig.Name()); dev != nil {
-				runCommandForwarder(mc.GetCtx(), cfg, dev.Service(), mc, commandStorage, deviceConfig.Filter())
+				runCommandForwarder(ctx, cfg, dev.Service(), mc, commandStorage, deviceConfig.Filter())
 			} else {
 				log.Printf("RunMqttForwarders: dev=%s not found", deviceConfig.Name())
 			}
 		}
 	}
+
+	if sCfg := cfg.Alarms(); sCfg.Enabled() && alarmEngine != nil {
+		runAlarmsForwarder(ctx, cfg, mc, alarmEngine)
+	}
 }
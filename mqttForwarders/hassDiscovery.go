@@ -27,9 +27,34 @@ type homeassistantDiscoveryBaseMessage struct {
 
 type homeassistantDiscoverySensorMessage struct {
 	homeassistantDiscoveryBaseMessage
-	StateTopic        string `json:"stat_t"`
-	ValueTemplate     string `json:"val_tpl"`
-	UnitOfMeasurement string `json:"unit_of_meas,omitempty"`
+	StateTopic                string `json:"stat_t"`
+	ValueTemplate             string `json:"val_tpl"`
+	UnitOfMeasurement         string `json:"unit_of_meas,omitempty"`
+	DeviceClass               string `json:"dev_cla,omitempty"`
+	StateClass                string `json:"stat_cla,omitempty"`
+	SuggestedDisplayPrecision *int   `json:"sug_dsp_prc,omitempty"`
+}
+
+type homeassistantDiscoveryBinarySensorMessage struct {
+	homeassistantDiscoveryBaseMessage
+	StateTopic    string `json:"stat_t"`
+	ValueTemplate string `json:"val_tpl"`
+	PayloadOff    string `json:"pl_off"`
+	PayloadOn     string `json:"pl_on"`
+	DeviceClass   string `json:"dev_cla,omitempty"`
+}
+
+type homeassistantDiscoveryNumberMessage struct {
+	homeassistantDiscoveryBaseMessage
+	CommandTopic      string   `json:"cmd_t"`
+	CommandTemplate   string   `json:"cmd_tpl"`
+	StateTopic        string   `json:"stat_t"`
+	ValueTemplate     string   `json:"val_tpl"`
+	UnitOfMeasurement string   `json:"unit_of_meas,omitempty"`
+	DeviceClass       string   `json:"dev_cla,omitempty"`
+	Min               float64  `json:"min"`
+	Max               float64  `json:"max"`
+	Step              *float64 `json:"step,omitempty"`
 }
 
 type homeassistantDiscoverySwitchMessage struct {
@@ -115,14 +140,33 @@ func homeassistantDiscoveryOnUpdateModeRoutine(
 		)
 	}
 
-	regSubscription := dev.RegisterDb().Subscribe(ctx, filter)
+	regSubscription := dev.RegisterDb().SubscribeEvents(ctx, filter)
 
+	// the discovery topic of each register; it changes when e.g. a sensor becomes a number
+	topics := make(map[string]string)
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case reg := <-regSubscription:
-			publishHomeassistantDiscoveryMessage(cfg, mc, dev.Name(), reg, commandFilter)
+		case ev := <-regSubscription:
+			regName := ev.Register.Name()
+			if ev.Type == dataflow.RegisterRemoved {
+				if topic, ok := topics[regName]; ok {
+					removeHomeassistantDiscoveryMessage(cfg, mc, dev.Name(), topic)
+					delete(topics, regName)
+				}
+				continue
+			}
+
+			topic := publishHomeassistantDiscoveryMessage(cfg, mc, dev.Name(), ev.Register, commandFilter)
+			if old, ok := topics[regName]; ok && old != topic {
+				removeHomeassistantDiscoveryMessage(cfg, mc, dev.Name(), old)
+			}
+			if topic != "" {
+				topics[regName] = topic
+			} else {
+				delete(topics, regName)
+			}
 		}
 	}
 }
@@ -165,20 +209,29 @@ func publishHomeassistantDiscoveryMessage(
 	deviceName string,
 	register dataflow.Register,
 	commandFilter dataflow.RegisterFilterFunc,
-) {
+) (topic string) {
 	mCfg := cfg.HomeassistantDiscovery()
 
-	var topic string
 	var msg interface{}
 
 	switch register.RegisterType() {
 	case dataflow.NumberRegister:
-		topic, msg = getHomeassistantDiscoverySensorMessage(
-			cfg,
-			deviceName,
-			register,
-			"{{ value_json.NumVal }}",
-		)
+		if meta := register.Meta(); commandFilter(register) && meta.Min != nil && meta.Max != nil {
+			topic, msg = getHomeassistantDiscoveryNumberMessage(
+				cfg,
+				deviceName,
+				register,
+				"{{ value_json.NumVal }}",
+				`{"NumVal": {{ value }}}`,
+			)
+		} else {
+			topic, msg = getHomeassistantDiscoverySensorMessage(
+				cfg,
+				deviceName,
+				register,
+				"{{ value_json.NumVal }}",
+			)
+		}
 	case dataflow.TextRegister:
 		topic, msg = getHomeassistantDiscoverySensorMessage(
 			cfg,
@@ -186,6 +239,45 @@ func publishHomeassistantDiscoveryMessage(
 			register,
 			"{{ value_json.TextVal }}",
 		)
+	case dataflow.IntRegister:
+		if meta := register.Meta(); commandFilter(register) && meta.Min != nil && meta.Max != nil {
+			topic, msg = getHomeassistantDiscoveryNumberMessage(
+				cfg,
+				deviceName,
+				register,
+				"{{ value_json.IntVal }}",
+				`{"IntVal": {{ value | int }}}`,
+			)
+		} else {
+			topic, msg = getHomeassistantDiscoverySensorMessage(
+				cfg,
+				deviceName,
+				register,
+				"{{ value_json.IntVal }}",
+			)
+		}
+	case dataflow.BoolRegister:
+		const valueTemplate = "{{ 'ON' if value_json.BoolVal else 'OFF' }}"
+		if commandFilter(register) {
+			off, on := false, true
+			topic, msg = getHomeassistantDiscoverySwitchMessage(
+				cfg,
+				deviceName,
+				register,
+				valueTemplate,
+				getCommandPayload(CommandMessage{BoolValue: &off}, mc.Name(), deviceName),
+				getCommandPayload(CommandMessage{BoolValue: &on}, mc.Name(), deviceName),
+				"OFF",
+				"ON",
+			)
+		} else {
+			topic, msg = getHomeassistantDiscoveryBinarySensorMessage(
+				cfg,
+				deviceName,
+				register,
+				valueTemplate,
+			)
+		}
 	case dataflow.EnumRegister:
 		// generate Jinja2 template to translate enumIdx to string
 		enum := register.Enum()
@@ -226,13 +318,14 @@ func publishHomeassistantDiscoveryMessage(
 			)
 		}
 	default:
-		return
+		return ""
 	}
 
 	if payload, err := json.Marshal(msg); err != nil {
 		log.Printf("mqttClient[%s]->device[%s]->homeassistantDiscovery: cannot generate discovery message: %s",
 			mc.Name(), deviceName, err,
 		)
+		return ""
 	} else {
 		mc.Publish(
 			topic,
@@ -241,6 +334,19 @@ func publishHomeassistantDiscoveryMessage(
 			mCfg.Retain(),
 		)
 	}
+	return topic
+}
+
+// removeHomeassistantDiscoveryMessage deletes the entity in Home Assistant by sending an empty retained payload.
+func removeHomeassistantDiscoveryMessage(cfg Config, mc mqttClient.Client, deviceName, topic string) {
+	if cfg.LogDebug() {
+		log.Printf("mqttClient[%s]->device[%s]->homeassistantDiscovery: remove topic=%s",
+			mc.Name(), deviceName, topic,
+		)
+	}
+
+	mCfg := cfg.HomeassistantDiscovery()
+	mc.Publish(topic, []byte{}, mCfg.Qos(), true)
 }
 
 func getHomeassistantDiscoverySensorMessage(
@@ -253,11 +359,66 @@ func getHomeassistantDiscoverySensorMessage(
 
 	topic = cfg.HomeassistantDiscoveryTopic("sensor", cfg.ClientId(), uniqueId)
 
+	meta := register.Meta()
 	msg = homeassistantDiscoverySensorMessage{
 		homeassistantDiscoveryBaseMessage: base,
 		StateTopic:                        cfg.RealtimeTopic(deviceName, register.Name()),
 		ValueTemplate:                     valueTemplate,
 		UnitOfMeasurement:                 register.Unit(),
+		DeviceClass:                       meta.DeviceClass,
+		StateClass:                        meta.StateClass,
+		SuggestedDisplayPrecision:         meta.Precision,
+	}
+
+	return
+}
+
+// getHomeassistantDiscoveryNumberMessage must only be used for registers with a min and a max.
+func getHomeassistantDiscoveryNumberMessage(
+	cfg Config,
+	deviceName string,
+	register dataflow.Register,
+	valueTemplate,
+	commandTemplate string,
+) (topic string, msg homeassistantDiscoveryNumberMessage) {
+	uniqueId, base := getHomeassistantDiscoveryBaseMessage(cfg, deviceName, register)
+
+	topic = cfg.HomeassistantDiscoveryTopic("number", cfg.ClientId(), uniqueId)
+
+	meta := register.Meta()
+	msg = homeassistantDiscoveryNumberMessage{
+		homeassistantDiscoveryBaseMessage: base,
+		CommandTopic:                      cfg.CommandTopic(deviceName, register.Name()),
+		CommandTemplate:                   commandTemplate,
+		StateTopic:                        cfg.RealtimeTopic(deviceName, register.Name()),
+		ValueTemplate:                     valueTemplate,
+		UnitOfMeasurement:                 register.Unit(),
+		DeviceClass:                       meta.DeviceClass,
+		Min:                               *meta.Min,
+		Max:                               *meta.Max,
+		Step:                              meta.Step,
+	}
+
+	return
+}
+
+func getHomeassistantDiscoveryBinarySensorMessage(
+	cfg Config,
+	deviceName string,
+	register dataflow.Register,
+	valueTemplate string,
+) (topic string, msg homeassistantDiscoveryBinarySensorMessage) {
+	uniqueId, base := getHomeassistantDiscoveryBaseMessage(cfg, deviceName, register)
+
+	topic = cfg.HomeassistantDiscoveryTopic("binary_sensor", cfg.ClientId(), uniqueId)
+
+	msg = homeassistantDiscoveryBinarySensorMessage{
+		homeassistantDiscoveryBaseMessage: base,
+		StateTopic:                        cfg.RealtimeTopic(deviceName, register.Name()),
+		ValueTemplate:                     valueTemplate,
+		PayloadOff:                        "OFF",
+		PayloadOn:                         "ON",
+		DeviceClass:                       register.Meta().DeviceClass,
 	}
 
 	return
@@ -2,6 +2,7 @@ package mqttForwarders
 
 import (
 	"context"
+	"fmt"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
 	"github.com/koestler/go-iotdevice/v3/mqttClient"
@@ -9,10 +10,18 @@ import (
 	"time"
 )
 
+// RealtimeMessage is the payload of the realtime topics.
+// Boolean values are additionally sent as EnumIdx and integer values as NumVal; this way consumers
+// only knowing the number, text and enum types keep working.
 type RealtimeMessage struct {
 	NumericValue *float64 `json:"NumVal,omitempty"`
 	TextValue    *string  `json:"TextVal,omitempty"`
 	EnumIdx      *int     `json:"EnumIdx,omitempty"`
+	BoolValue    *bool    `json:"BoolVal,omitempty"`
+	IntValue     *int64   `json:"IntVal,omitempty"`
+	Time         string   `json:"Time,omitempty"`
+	SourceTime   string   `json:"SrcTime,omitempty"`
+	Quality      string   `json:"Quality,omitempty"`
 }
 
 func runRealtimeForwarder(
@@ -56,7 +65,10 @@ func realtimeImmediateModeRoutine(
 		)
 	}
 
-	subscription := storage.SubscribeSendInitial(ctx, filter)
+	subscription := storage.SubscribeSendInitial(ctx, filter,
+		dataflow.WithName(fmt.Sprintf("mqttClient[%s]->device[%s]->realtime", mc.Name(), dev.Name())),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
 	// for loop ends when subscription is canceled and closes its output chan
 	for value := range subscription.Drain() {
 		publishRealtimeMessage(cfg, mc, dev.Name(), value)
@@ -85,13 +97,16 @@ func realtimeDelayedUpdateModeRoutine(
 
 	updates := make(map[string]dataflow.Value)
 
-	subscription := storage.SubscribeSendInitial(ctx, filter)
+	subscription := storage.SubscribeSendInitial(ctx, filter,
+		dataflow.WithName(fmt.Sprintf("mqttClient[%s]->device[%s]->realtime", mc.Name(), dev.Name())),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case value := <-subscription.Drain():
-			if value.Register().RegisterType() == dataflow.NumberRegister {
+			if rt := value.Register().RegisterType(); rt == dataflow.NumberRegister || rt == dataflow.IntRegister {
 				// new numeric value received, save the newest version per register name
 				updates[value.Register().Name()] = value
 			} else {
@@ -139,7 +154,15 @@ func publishRealtimeMessage(cfg Config, mc mqttClient.Client, devName string, va
 }
 
 func convertValueToRealtimeMessage(value dataflow.Value) interface{} {
-	ret := RealtimeMessage{}
+	ret := RealtimeMessage{
+		Time:       formatValueTime(value.MeasuredAt()),
+		SourceTime: formatValueTime(value.SourceTime()),
+	}
+
+	// good is the default and omitted to keep the messages short
+	if q := value.Quality(); q != dataflow.QualityGood {
+		ret.Quality = q.String()
+	}
 
 	if numeric, ok := value.(dataflow.NumericRegisterValue); ok {
 		v := numeric.Value()
@@ -150,7 +173,25 @@ func convertValueToRealtimeMessage(value dataflow.Value) interface{} {
 	} else if enum, ok := value.(dataflow.EnumRegisterValue); ok {
 		v := enum.EnumIdx()
 		ret.EnumIdx = &v
+	} else if boolean, ok := value.(dataflow.BoolRegisterValue); ok {
+		v := boolean.Value()
+		idx := boolean.EnumIdx()
+		ret.BoolValue = &v
+		ret.EnumIdx = &idx
+	} else if integer, ok := value.(dataflow.IntRegisterValue); ok {
+		v := integer.Value()
+		f := float64(v)
+		ret.IntValue = &v
+		ret.NumericValue = &f
 	}
 
 	return ret
 }
+
+// formatValueTime returns the RFC3339 representation of t with sub-second precision or an empty string for a zero time.
+func formatValueTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-iotdevice/v3/device"
 	"github.com/koestler/go-iotdevice/v3/mqttClient"
-	"golang.org/x/exp/maps"
 	"log"
 	"math"
 	"time"
@@ -15,11 +14,18 @@ type StructRegister struct {
 	Category    string         `json:"Cat" example:"Monitor"`
 	Name        string         `json:"Name" example:"PanelPower"`
 	Description string         `json:"Desc" example:"Panel power"`
-	Type        string         `json:"Type" example:"number"`
+	Type        string         `json:"Type" example:"number"` // number, string or enum; kept for compatibility
+	ValueType   string         `json:"VType,omitempty" example:"bool"`
 	Enum        map[int]string `json:"Enum,omitempty"`
 	Unit        string         `json:"Unit,omitempty" example:"W"`
 	Sort        int            `json:"Sort" example:"100"`
 	Writable    bool           `json:"Cmnd" example:"false"`
+	Min         *float64       `json:"Min,omitempty"`
+	Max         *float64       `json:"Max,omitempty"`
+	Step        *float64       `json:"Step,omitempty"`
+	Precision   *int           `json:"Prec,omitempty" example:"1"`
+	DeviceClass string         `json:"DevCla,omitempty" example:"power"`
+	StateClass  string         `json:"StatCla,omitempty" example:"measurement"`
 }
 
 type StructureMessage struct {
@@ -65,35 +71,29 @@ func structureOnUpdateModeRoutine(
 		)
 	}
 
-	regSubscription := dev.RegisterDb().Subscribe(ctx, filter)
+	regSubscription := dev.RegisterDb().SubscribeEvents(ctx, filter)
 	structureTopic := cfg.StructureTopic(devCfg.Name())
 
-	// when a new register arrives, wait until no new register is received for 100ms
-	// and then send all updates together
+	// when a register is added, updated or removed, wait until no further change is received for 100ms
+	// and then send the full set of registers; the message is retained and thus must contain all of them
 	ticker := time.NewTicker(math.MaxInt64)
 	defer ticker.Stop()
 
-	registers := make(map[string]dataflow.RegisterStruct)
+	changed := false
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case reg := <-regSubscription:
-			if len(registers) < 1 {
+		case <-regSubscription:
+			if !changed {
 				ticker.Reset(100 * time.Millisecond)
+				changed = true
 			}
-			regName := reg.Name()
-			if regName == device.AvailabilityRegisterName {
-				// do not use Availability as a register in mqtt; availability is handled separately
-				continue
-			}
-
-			registers[regName] = reg
 		case <-ticker.C:
 			ticker.Stop()
+			changed = false
 
-			publishStruct(cfg, mc, dev.Name(), structureTopic, maps.Values(registers))
-			clear(registers)
+			publishStruct(cfg, mc, dev.Name(), structureTopic, dev.RegisterDb().GetFiltered(filter))
 		}
 	}
 }
@@ -124,8 +124,7 @@ func structurePeriodicModeRoutine(
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			registers := dev.RegisterDb().GetFiltered(filter)
-			publishStruct(cfg, mc, dev.Name(), structureTopic, registers)
+			publishStruct(cfg, mc, dev.Name(), structureTopic, dev.RegisterDb().GetFiltered(filter))
 		}
 	}
 }
@@ -225,14 +224,22 @@ func applyWritableFiltersToRegisters(cfg Config, devName string, inp []dataflow.
 }
 
 func NewStructRegister(reg dataflow.Register) StructRegister {
+	meta := reg.Meta()
 	return StructRegister{
 		Category:    reg.Category(),
 		Name:        reg.Name(),
 		Description: reg.Description(),
-		Type:        reg.RegisterType().String(),
+		Type:        reg.RegisterType().CompatString(),
+		ValueType:   reg.RegisterType().String(),
 		Enum:        reg.Enum(),
 		Unit:        reg.Unit(),
 		Sort:        reg.Sort(),
 		Writable:    reg.Writable(),
+		Min:         meta.Min,
+		Max:         meta.Max,
+		Step:        meta.Step,
+		Precision:   meta.Precision,
+		DeviceClass: meta.DeviceClass,
+		StateClass:  meta.StateClass,
 	}
 }
@@ -23,12 +23,16 @@ type NumericTelemetryValue struct {
 	Description string  `json:"Desc"`
 	Value       float64 `json:"Val"`
 	Unit        string  `json:"Unit,omitempty"`
+	Time        string  `json:"Time,omitempty"`
+	SourceTime  string  `json:"SrcTime,omitempty"`
 }
 
 type TextTelemetryValue struct {
 	Category    string `json:"Cat"`
 	Description string `json:"Desc"`
 	Value       string `json:"Val"`
+	Time        string `json:"Time,omitempty"`
+	SourceTime  string `json:"SrcTime,omitempty"`
 }
 
 type EnumTelemetryValue struct {
@@ -36,6 +40,8 @@ type EnumTelemetryValue struct {
 	Description string `json:"Desc"`
 	EnumIdx     int    `json:"Idx"`
 	Value       string `json:"Val"`
+	Time        string `json:"Time,omitempty"`
+	SourceTime  string `json:"SrcTime,omitempty"`
 }
 
 func runTelemetryForwarder(
@@ -123,18 +129,29 @@ func runTelemetryForwarder(
 	}(mc)
 }
 
+// convertValuesToNumericTelemetryValues includes integer values for compatibility with existing consumers.
 func convertValuesToNumericTelemetryValues(values []dataflow.Value) (ret map[string]NumericTelemetryValue) {
 	ret = make(map[string]NumericTelemetryValue)
 
 	for _, value := range values {
-		if numeric, ok := value.(dataflow.NumericRegisterValue); ok {
-			reg := value.Register()
-			ret[reg.Name()] = NumericTelemetryValue{
-				Category:    reg.Category(),
-				Description: reg.Description(),
-				Value:       numeric.Value(),
-				Unit:        reg.Unit(),
-			}
+		var v float64
+		switch numeric := value.(type) {
+		case dataflow.NumericRegisterValue:
+			v = numeric.Value()
+		case dataflow.IntRegisterValue:
+			v = float64(numeric.Value())
+		default:
+			continue
+		}
+
+		reg := value.Register()
+		ret[reg.Name()] = NumericTelemetryValue{
+			Category:    reg.Category(),
+			Description: reg.Description(),
+			Value:       v,
+			Unit:        reg.Unit(),
+			Time:        formatValueTime(value.MeasuredAt()),
+			SourceTime:  formatValueTime(value.SourceTime()),
 		}
 	}
 
@@ -151,6 +168,8 @@ func convertValuesToTextTelemetryValues(values []dataflow.Value) (ret map[string
 				Category:    reg.Category(),
 				Description: reg.Description(),
 				Value:       text.Value(),
+				Time:        formatValueTime(value.MeasuredAt()),
+				SourceTime:  formatValueTime(value.SourceTime()),
 			}
 		}
 	}
@@ -158,18 +177,30 @@ func convertValuesToTextTelemetryValues(values []dataflow.Value) (ret map[string
 	return
 }
 
+// convertValuesToEnumTelemetryValues includes boolean values (index 0 / 1) for compatibility with existing consumers.
 func convertValuesToEnumTelemetryValues(values []dataflow.Value) (ret map[string]EnumTelemetryValue) {
 	ret = make(map[string]EnumTelemetryValue)
 
 	for _, value := range values {
-		if enum, ok := value.(dataflow.EnumRegisterValue); ok {
-			reg := value.Register()
-			ret[reg.Name()] = EnumTelemetryValue{
-				Category:    reg.Category(),
-				Description: reg.Description(),
-				EnumIdx:     enum.EnumIdx(),
-				Value:       enum.Value(),
-			}
+		var idx int
+		var label string
+		switch enum := value.(type) {
+		case dataflow.EnumRegisterValue:
+			idx, label = enum.EnumIdx(), enum.Value()
+		case dataflow.BoolRegisterValue:
+			idx, label = enum.EnumIdx(), enum.Label()
+		default:
+			continue
+		}
+
+		reg := value.Register()
+		ret[reg.Name()] = EnumTelemetryValue{
+			Category:    reg.Category(),
+			Description: reg.Description(),
+			EnumIdx:     idx,
+			Value:       label,
+			Time:        formatValueTime(value.MeasuredAt()),
+			SourceTime:  formatValueTime(value.SourceTime()),
 		}
 	}
 
@@ -8,6 +8,7 @@ import (
 type Fifo[T any] struct {
 	maxLength int
 	list      list.List[T]
+	dropped   uint64
 	lock      sync.Mutex
 }
 
@@ -16,20 +17,22 @@ func NewFifo[T any](maxLength int) Fifo[T] {
 }
 
 func (q *Fifo[T]) Enqueue(value T) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+
 	if q.maxLength < 1 {
 		// when maxLength is zero or lower, store nothing
+		q.dropped += 1
 		return
 	}
 
-	q.lock.Lock()
-	defer q.lock.Unlock()
-
 	q.list.PushBack(value)
 
 	// when list gets to long; truncate first element
 	if q.list.Len() > q.maxLength {
 		if elem := q.list.Front(); elem != nil {
 			q.list.Remove(elem)
+			q.dropped += 1
 		}
 	}
 }
@@ -47,3 +50,16 @@ func (q *Fifo[T]) Dequeue() (value T, ok bool) {
 	v := q.list.Remove(elem)
 	return v, true
 }
+
+func (q *Fifo[T]) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.list.Len()
+}
+
+// Dropped returns the number of values discarded because the queue was full.
+func (q *Fifo[T]) Dropped() uint64 {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return q.dropped
+}
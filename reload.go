@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/alarms"
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/httpServer"
+	"github.com/koestler/go-iotdevice/v3/modbus"
+	"github.com/koestler/go-iotdevice/v3/mqttClient"
+	"github.com/koestler/go-iotdevice/v3/pool"
+	"github.com/koestler/go-iotdevice/v3/restarter"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// reloader re-reads the configuration file and applies the differences to the running devices, modbus buses,
+// mqtt clients, mqtt forwarders and views. All other sections are only applied on a restart of the process.
+type reloader struct {
+	mutex sync.Mutex
+
+	cmdName string
+	path    string
+	started *config.Config // the config the process was started with; used for the sections which cannot be reloaded
+	cfg     *config.Config // the currently applied config
+
+	modbusPool     *pool.Pool[*modbus.ModbusStruct]
+	devicePool     *pool.Pool[*restarter.Restarter[device.Device]]
+	mqttClientPool *pool.Pool[mqttClient.Client]
+	stateStorage   *dataflow.ValueStorage
+	commandStorage *dataflow.ValueStorage
+	alarmEngine    *alarms.Engine
+	forwarders     map[string]context.CancelFunc
+	httpServer     *httpServer.HttpServer
+}
+
+func (r *reloader) setHttpServer(s *httpServer.HttpServer) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.httpServer = s
+}
+
+// Reload reads the configuration file again; when it is invalid, the errors are returned and nothing is changed.
+func (r *reloader) Reload() (report httpServer.ReloadReport, errs []error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	cfg, errs := config.ReadConfigFile(r.cmdName, r.path, false)
+	if len(errs) > 0 {
+		for _, e := range errs {
+			log.Printf("reload: config error: %v", e)
+		}
+		log.Printf("reload: configuration is invalid; nothing was changed")
+		return report, errs
+	}
+	cfg.KeepGenerated(*r.cfg)
+
+	old := r.cfg
+	diff := config.Diff(*old, cfg)
+
+	// devices depending on a restarted modbus bus or mqtt client are restarted as well
+	restartBuses := union(diff.Modbus.Added, diff.Modbus.Changed)
+	restartClients := union(diff.MqttClients.Added, diff.MqttClients.Changed, diff.MqttClients.Removed)
+	restartDevices := diff.Devices.Changed
+	for _, d := range cfg.ModbusDevices() {
+		if slices.Contains(restartBuses, d.Bus()) {
+			restartDevices = union(restartDevices, []string{d.Name()})
+		}
+	}
+	for _, d := range cfg.MqttDevices() {
+		oldTopics := mqttDeviceConfig{d, old.MqttClients()}.MqttClientTopics()
+		newTopics := mqttDeviceConfig{d, cfg.MqttClients()}.MqttClientTopics()
+		dependsOnRestartedClient := slices.ContainsFunc(restartClients, func(name string) bool {
+			_, ok := newTopics[name]
+			return ok
+		})
+		if dependsOnRestartedClient || !maps.EqualFunc(oldTopics, newTopics, slices.Equal) {
+			restartDevices = union(restartDevices, []string{d.Name()})
+		}
+	}
+	restartDevices = minus(restartDevices, diff.Devices.Added)
+	stopDevices := union(diff.Devices.Removed, restartDevices)
+	startDevices := union(diff.Devices.Added, restartDevices)
+
+	// forwarders hold the device instances; restart them whenever one of their devices is stopped or started
+	var restartForwarders []string
+	for _, c := range cfg.MqttClients() {
+		devices := forwardedDevices(c)
+		for _, oc := range old.MqttClients() {
+			if oc.Name() == c.Name() {
+				devices = union(devices, forwardedDevices(oc))
+			}
+		}
+		if slices.Contains(restartClients, c.Name()) ||
+			slices.Contains(diff.MqttForwarders.Changed, c.Name()) ||
+			slices.ContainsFunc(devices, func(name string) bool { return slices.Contains(stopDevices, name) }) ||
+			slices.ContainsFunc(devices, func(name string) bool { return slices.Contains(startDevices, name) }) {
+			restartForwarders = append(restartForwarders, c.Name())
+		}
+	}
+
+	// stop
+	for _, name := range union(restartForwarders, diff.MqttClients.Removed) {
+		if cancel, ok := r.forwarders[name]; ok {
+			cancel()
+			delete(r.forwarders, name)
+		}
+	}
+	for _, name := range stopDevices {
+		if dev := r.devicePool.GetByName(name); dev != nil {
+			log.Printf("device[%s]: stop", name)
+			r.devicePool.Remove(dev)
+			dev.Shutdown()
+		}
+	}
+	for _, name := range union(diff.Modbus.Removed, diff.Modbus.Changed) {
+		if mb := r.modbusPool.GetByName(name); mb != nil {
+			log.Printf("modbus[%s]: stop", name)
+			r.modbusPool.Remove(mb)
+			mb.Shutdown()
+		}
+	}
+	for _, name := range union(diff.MqttClients.Removed, diff.MqttClients.Changed) {
+		if mc := r.mqttClientPool.GetByName(name); mc != nil {
+			log.Printf("mqttClient[%s]: stop", name)
+			r.mqttClientPool.Remove(mc)
+			mc.Shutdown()
+		}
+	}
+
+	// the values of removed devices would otherwise stay in the storages forever
+	for _, name := range diff.Devices.Removed {
+		removeDeviceValues(r.stateStorage, name)
+		removeDeviceValues(r.commandStorage, name)
+	}
+
+	// start
+	r.cfg = &cfg
+	r.stateStorage.SetMaxAge(getStateStorageMaxAge(r.cfg))
+	r.stateStorage.SetHistory(getStateStorageHistory(r.cfg))
+	for _, c := range cfg.Modbus() {
+		if slices.Contains(restartBuses, c.Name()) {
+			runModbusBus(r.cfg, c, r.modbusPool)
+		}
+	}
+	for _, c := range cfg.MqttClients() {
+		if slices.Contains(diff.MqttClients.Added, c.Name()) || slices.Contains(diff.MqttClients.Changed, c.Name()) {
+			runMqttClientInstance(r.cfg, c, r.mqttClientPool)
+		}
+	}
+	r.runDevices(startDevices)
+	// restarted devices might have lost registers whose values would otherwise stay forever
+	for _, name := range restartDevices {
+		if dev := r.devicePool.GetByName(name); dev != nil {
+			removeUnknownRegisterValues(dev.GetCtx(), dev.Service(), r.stateStorage, r.commandStorage)
+		}
+	}
+	for _, c := range cfg.MqttClients() {
+		if slices.Contains(restartForwarders, c.Name()) {
+			if cancel := runMqttForwarder(c, r.devicePool, r.mqttClientPool, r.stateStorage, r.commandStorage, r.alarmEngine); cancel != nil {
+				r.forwarders[c.Name()] = cancel
+			}
+		}
+	}
+
+	// the routes hold the device instances as well
+	if r.httpServer != nil && (len(stopDevices) > 0 || len(startDevices) > 0 || !diff.Views.Empty()) {
+		r.httpServer.Reload(convertViews(cfg.Views()))
+	}
+
+	report = httpServer.ReloadReport{
+		Devices:         httpServer.ReloadChanges{Added: diff.Devices.Added, Removed: diff.Devices.Removed, Restarted: restartDevices},
+		Modbus:          httpServer.ReloadChanges{Added: diff.Modbus.Added, Removed: diff.Modbus.Removed, Restarted: diff.Modbus.Changed},
+		MqttClients:     httpServer.ReloadChanges{Added: diff.MqttClients.Added, Removed: diff.MqttClients.Removed, Restarted: diff.MqttClients.Changed},
+		MqttForwarders:  restartForwarders,
+		Views:           httpServer.ReloadChanges{Added: diff.Views.Added, Removed: diff.Views.Removed, Restarted: diff.Views.Changed},
+		RestartRequired: config.Diff(*r.started, cfg).Sections,
+	}
+	logReloadReport(report)
+	return report, nil
+}
+
+// runDevices starts the given devices in the same order as on startup.
+func (r *reloader) runDevices(names []string) {
+	cfg := r.cfg
+	for _, c := range cfg.VictronDevices() {
+		if slices.Contains(names, c.Name()) {
+			runVictronDevice(cfg, c, r.devicePool, r.stateStorage)
+		}
+	}
+	for _, c := range cfg.ModbusDevices() {
+		if slices.Contains(names, c.Name()) {
+			runModbusDevice(cfg, c, r.devicePool, r.modbusPool, r.stateStorage, r.commandStorage)
+		}
+	}
+	for _, c := range cfg.GpioDevices() {
+		if slices.Contains(names, c.Name()) {
+			runGpioDevice(cfg, c, r.devicePool, r.stateStorage, r.commandStorage)
+		}
+	}
+	for _, c := range cfg.HttpDevices() {
+		if slices.Contains(names, c.Name()) {
+			runHttpDevice(cfg, c, r.devicePool, r.stateStorage, r.commandStorage)
+		}
+	}
+	for _, c := range cfg.MqttDevices() {
+		if slices.Contains(names, c.Name()) {
+			runMqttDevice(cfg, c, r.devicePool, r.mqttClientPool, r.stateStorage, r.commandStorage)
+		}
+	}
+	for _, c := range cfg.SystemDevices() {
+		if slices.Contains(names, c.Name()) {
+			runSystemDevice(cfg, c, r.devicePool, r.mqttClientPool, r.stateStorage, r.commandStorage)
+		}
+	}
+	for _, c := range cfg.ComputedDevices() {
+		if slices.Contains(names, c.Name()) {
+			runComputedDevice(cfg, c, r.devicePool, r.stateStorage)
+		}
+	}
+	for _, c := range cfg.GensetDevices() {
+		if slices.Contains(names, c.Name()) {
+			runGensetDevice(cfg, c, r.devicePool, r.stateStorage, r.commandStorage)
+		}
+	}
+}
+
+func logReloadReport(report httpServer.ReloadReport) {
+	logChanges := func(kind string, c httpServer.ReloadChanges) {
+		if len(c.Added) > 0 || len(c.Removed) > 0 || len(c.Restarted) > 0 {
+			log.Printf("reload: %s: added=%v, removed=%v, restarted=%v", kind, c.Added, c.Removed, c.Restarted)
+		}
+	}
+	logChanges("devices", report.Devices)
+	logChanges("modbus", report.Modbus)
+	logChanges("mqttClients", report.MqttClients)
+	if len(report.MqttForwarders) > 0 {
+		log.Printf("reload: mqttForwarders: restarted=%v", report.MqttForwarders)
+	}
+	logChanges("views", report.Views)
+	if len(report.RestartRequired) > 0 {
+		log.Printf("reload: changes of %v are only applied after a restart", report.RestartRequired)
+	}
+	log.Printf("reload: completed")
+}
+
+// forwardedDevices returns the names of all devices used by any forwarder of the given client.
+func forwardedDevices(c config.MqttClientConfig) (ret []string) {
+	sections := []config.MqttSectionConfig{
+		c.AvailabilityDevice(), c.Structure(), c.Telemetry(), c.Realtime(), c.HomeassistantDiscovery(), c.Command(),
+	}
+	for _, s := range sections {
+		if !s.Enabled() {
+			continue
+		}
+		for _, d := range s.Devices() {
+			ret = union(ret, []string{d.Name()})
+		}
+	}
+	return
+}
+
+// union returns the sorted list of all names contained in any of the given lists.
+func union(lists ...[]string) (ret []string) {
+	for _, l := range lists {
+		for _, name := range l {
+			if !slices.Contains(ret, name) {
+				ret = append(ret, name)
+			}
+		}
+	}
+	slices.Sort(ret)
+	return
+}
+
+// minus returns the names of a which are not contained in b.
+func minus(a, b []string) (ret []string) {
+	for _, name := range a {
+		if !slices.Contains(b, name) {
+			ret = append(ret, name)
+		}
+	}
+	return
+}
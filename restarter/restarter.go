@@ -6,6 +6,7 @@ import (
 	"context"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -19,14 +20,57 @@ type Restartable interface {
 	Run(ctx context.Context) (err error, immediateError bool)
 }
 
+type State int
+
+const (
+	StateStopped State = iota
+	StateRunning
+	StateBackoff
+	StatePaused
+)
+
+func (s State) String() string {
+	switch s {
+	case StateStopped:
+		return "stopped"
+	case StateRunning:
+		return "running"
+	case StateBackoff:
+		return "backoff"
+	case StatePaused:
+		return "paused"
+	default:
+		return ""
+	}
+}
+
+// Status is a snapshot of the state of a Restarter.
+type Status struct {
+	State       State
+	Restarts    uint64
+	LastError   error     // nil when the service never terminated with an error
+	LastErrorAt time.Time // zero when there is no LastError
+	NextRetry   time.Time // only set in the StateBackoff
+}
+
 type Restarter[S Restartable] struct {
-	config    Config
-	service   S
-	isRunning bool
+	config   Config
+	service  S
+	restarts atomic.Uint64
 
 	ctx    context.Context
 	cancel context.CancelFunc
 	wg     sync.WaitGroup
+
+	mutex       sync.Mutex
+	state       State
+	paused      bool
+	restart     bool               // a restart was requested
+	runCancel   context.CancelFunc // stops the current run of the service; nil when it is not running
+	lastError   error
+	lastErrorAt time.Time
+	nextRetry   time.Time
+	wake        chan struct{} // signals the routine that paused, restart was changed
 }
 
 func CreateRestarter[S Restartable](config Config, service S) (w *Restarter[S]) {
@@ -36,6 +80,7 @@ func CreateRestarter[S Restartable](config Config, service S) (w *Restarter[S])
 		service: service,
 		ctx:     ctx,
 		cancel:  cancel,
+		wake:    make(chan struct{}, 1),
 	}
 
 	return
@@ -50,45 +95,212 @@ func (w *Restarter[S]) Run() {
 	w.wg.Add(1)
 	go func() {
 		defer w.wg.Done()
+		defer w.setState(StateStopped)
 
 		immediateErrorsInARow := 0
 		first := true
 		for {
+			if !w.waitWhilePaused() {
+				return
+			}
+
+			runCtx, runCancel := context.WithCancel(w.ctx)
+			w.mutex.Lock()
+			if w.paused {
+				// paused since waitWhilePaused returned
+				w.mutex.Unlock()
+				runCancel()
+				continue
+			}
+			w.state = StateRunning
+			w.restart = false
+			w.runCancel = runCancel
+			w.mutex.Unlock()
+			// requests received so far are handled by this run
+			select {
+			case <-w.wake:
+			default:
+			}
+
 			if first {
 				first = false
 			} else {
+				w.restarts.Add(1)
 				log.Printf("restarter[%s]: start", w.service.Name())
 			}
 
 			start := time.Now()
-			err, immediateError := w.service.Run(w.ctx)
-			if err == nil {
+			err, immediateError := w.service.Run(runCtx)
+			runCancel()
+
+			if w.ctx.Err() != nil {
 				// shutdown
 				return
-			} else {
-				log.Printf("restarter[%s]: terminated with error: %s", w.service.Name(), err)
-				runningFor := time.Since(start)
-
-				if immediateError {
-					immediateErrorsInARow += 1
-				} else {
-					immediateErrorsInARow = 0
-				}
+			}
 
-				retryIn := w.getRestartInterval(immediateErrorsInARow)
+			w.mutex.Lock()
+			interrupted := w.paused || w.restart
+			w.runCancel = nil
+			if err != nil {
+				w.lastError = err
+				w.lastErrorAt = time.Now()
+			}
+			w.mutex.Unlock()
 
-				log.Printf("restarter[%s]: error after %s, expoential backoff, retry in %s", w.service.Name(), runningFor, retryIn)
+			if interrupted {
+				log.Printf("restarter[%s]: stopped on request", w.service.Name())
+				immediateErrorsInARow = 0
+				continue
+			}
 
-				select {
-				case <-w.ctx.Done():
+			if err == nil {
+				// the service terminated by itself; it is only started again on request
+				w.setState(StateStopped)
+				if !w.sleep(0) {
 					return
-				case <-time.After(retryIn):
 				}
+				continue
+			}
+
+			log.Printf("restarter[%s]: terminated with error: %s", w.service.Name(), err)
+			runningFor := time.Since(start)
+
+			if immediateError {
+				immediateErrorsInARow += 1
+			} else {
+				immediateErrorsInARow = 0
+			}
+
+			retryIn := w.getRestartInterval(immediateErrorsInARow)
+
+			log.Printf("restarter[%s]: error after %s, expoential backoff, retry in %s", w.service.Name(), runningFor, retryIn)
+
+			w.mutex.Lock()
+			w.state = StateBackoff
+			w.nextRetry = time.Now().Add(retryIn)
+			w.mutex.Unlock()
+
+			if !w.sleep(retryIn) {
+				return
 			}
 		}
 	}()
 }
 
+// waitWhilePaused blocks as long as the restarter is paused; it returns false on shutdown.
+func (w *Restarter[S]) waitWhilePaused() bool {
+	for {
+		w.mutex.Lock()
+		paused := w.paused
+		if paused {
+			w.state = StatePaused
+			w.nextRetry = time.Time{}
+		}
+		w.mutex.Unlock()
+
+		if !paused {
+			return true
+		}
+
+		select {
+		case <-w.ctx.Done():
+			return false
+		case <-w.wake:
+		}
+	}
+}
+
+// sleep waits for the given duration (forever when 0) or until a request is received; it returns false on shutdown.
+func (w *Restarter[S]) sleep(d time.Duration) bool {
+	var timeout <-chan time.Time
+	if d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	defer func() {
+		w.mutex.Lock()
+		w.nextRetry = time.Time{}
+		w.mutex.Unlock()
+	}()
+
+	select {
+	case <-w.ctx.Done():
+		return false
+	case <-w.wake:
+	case <-timeout:
+	}
+	return true
+}
+
+func (w *Restarter[S]) setState(state State) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.state = state
+}
+
+func (w *Restarter[S]) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pause stops the service until Resume or Restart is called.
+func (w *Restarter[S]) Pause() {
+	w.mutex.Lock()
+	w.paused = true
+	cancel := w.runCancel
+	w.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	w.signal()
+}
+
+// Resume starts a paused service again.
+func (w *Restarter[S]) Resume() {
+	w.mutex.Lock()
+	w.paused = false
+	w.mutex.Unlock()
+	w.signal()
+}
+
+// Restart stops the service if it is running and starts it immediately; a paused service is resumed.
+func (w *Restarter[S]) Restart() {
+	w.mutex.Lock()
+	w.paused = false
+	w.restart = true
+	cancel := w.runCancel
+	w.mutex.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	w.signal()
+}
+
+func (w *Restarter[S]) Status() Status {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return Status{
+		State:       w.state,
+		Restarts:    w.restarts.Load(),
+		LastError:   w.lastError,
+		LastErrorAt: w.lastErrorAt,
+		NextRetry:   w.nextRetry,
+	}
+}
+
+// LastError returns the error the service terminated with most recently; nil when it never failed.
+func (w *Restarter[S]) LastError() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.lastError
+}
+
 func (w *Restarter[S]) Shutdown() {
 	w.cancel()
 	w.wg.Wait()
@@ -107,7 +319,14 @@ func (w *Restarter[S]) Service() S {
 }
 
 func (w *Restarter[S]) IsRunning() bool {
-	return w.isRunning
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.state == StateRunning
+}
+
+// Restarts returns how many times the service was started again, after an error or on request.
+func (w *Restarter[S]) Restarts() uint64 {
+	return w.restarts.Load()
 }
 
 func (w *Restarter[S]) getRestartInterval(errorsInARow int) time.Duration {
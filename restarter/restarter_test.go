@@ -0,0 +1,115 @@
+package restarter_test
+
+import (
+	"context"
+	"errors"
+	"github.com/koestler/go-iotdevice/v3/restarter"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testConfig struct{}
+
+func (testConfig) RestartInterval() time.Duration           { return time.Hour }
+func (testConfig) RestartIntervalMaxBackoff() time.Duration { return time.Hour }
+
+// testService runs until its context is canceled; it fails immediately while fail is set.
+type testService struct {
+	starts atomic.Int32
+	fail   atomic.Bool
+}
+
+func (s *testService) Name() string { return "test" }
+
+func (s *testService) Run(ctx context.Context) (err error, immediateError bool) {
+	s.starts.Add(1)
+	if s.fail.Load() {
+		return errors.New("broken"), true
+	}
+	<-ctx.Done()
+	return nil, false
+}
+
+func waitFor(t *testing.T, r *restarter.Restarter[*testService], state restarter.State) restarter.Status {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		status := r.Status()
+		if status.State == state {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expect state %s but got %s", state, status.State)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRestarterPauseResume(t *testing.T) {
+	service := &testService{}
+	r := restarter.CreateRestarter(testConfig{}, service)
+	r.Run()
+	defer r.Shutdown()
+
+	waitFor(t, r, restarter.StateRunning)
+
+	r.Pause()
+	waitFor(t, r, restarter.StatePaused)
+	if r.IsRunning() {
+		t.Error("expect a paused service not to be running")
+	}
+
+	r.Resume()
+	status := waitFor(t, r, restarter.StateRunning)
+	if expect, got := int32(2), service.starts.Load(); expect != got {
+		t.Errorf("expect %d starts but got %d", expect, got)
+	}
+	if expect, got := uint64(1), status.Restarts; expect != got {
+		t.Errorf("expect %d restarts but got %d", expect, got)
+	}
+	if status.LastError != nil {
+		t.Errorf("expect no last error but got %s", status.LastError)
+	}
+}
+
+func TestRestarterBackoffRestart(t *testing.T) {
+	service := &testService{}
+	service.fail.Store(true)
+	r := restarter.CreateRestarter(testConfig{}, service)
+	r.Run()
+	defer r.Shutdown()
+
+	status := waitFor(t, r, restarter.StateBackoff)
+	if status.LastError == nil || status.LastError.Error() != "broken" {
+		t.Errorf("expect last error 'broken' but got %v", status.LastError)
+	}
+	if status.LastErrorAt.IsZero() {
+		t.Error("expect the time of the last error to be set")
+	}
+	if d := time.Until(status.NextRetry); d < 59*time.Minute {
+		t.Errorf("expect the next retry in about 1h but got %s", d)
+	}
+
+	// a restart does not wait for the backoff
+	service.fail.Store(false)
+	r.Restart()
+	status = waitFor(t, r, restarter.StateRunning)
+	if !status.NextRetry.IsZero() {
+		t.Errorf("expect no next retry while running but got %s", status.NextRetry)
+	}
+
+	// a restart stops the running service and starts it again
+	r.Restart()
+	deadline := time.Now().Add(time.Second)
+	for service.starts.Load() < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect 3 starts but got %d", service.starts.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waitFor(t, r, restarter.StateRunning)
+	if expect, got := uint64(2), r.Restarts(); expect != got {
+		t.Errorf("expect %d restarts but got %d", expect, got)
+	}
+}
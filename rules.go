@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/mqttClient"
+	"github.com/koestler/go-iotdevice/v3/pool"
+	"github.com/koestler/go-iotdevice/v3/restarter"
+	"github.com/koestler/go-iotdevice/v3/rules"
+	"log"
+)
+
+func runRules(
+	cfg *config.Config,
+	devicePool *pool.Pool[*restarter.Restarter[device.Device]],
+	mqttClientPool *pool.Pool[mqttClient.Client],
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) *rules.Engine {
+	if len(cfg.Rules()) < 1 {
+		return nil
+	}
+
+	if cfg.LogWorkerStart() {
+		log.Printf("rules: start: %d rules", len(cfg.Rules()))
+	}
+
+	configs := make([]rules.Config, len(cfg.Rules()))
+	for i, r := range cfg.Rules() {
+		configs[i] = ruleConfig{r}
+	}
+
+	engine, err := rules.Run(&rules.Environment{
+		StateStorage:   stateStorage,
+		CommandStorage: commandStorage,
+		RegisterByName: func(deviceName, registerName string) (dataflow.Register, bool) {
+			dev := devicePool.GetByName(deviceName)
+			if dev == nil {
+				return nil, false
+			}
+			return dev.Service().RegisterDb().GetByName(registerName)
+		},
+		MqttClientByName: func(clientName string) (rules.Publisher, bool) {
+			client := mqttClientPool.GetByName(clientName)
+			return client, client != nil
+		},
+	}, configs)
+	if err != nil {
+		log.Printf("rules: cannot start: %s", err)
+		return nil
+	}
+	return engine
+}
+
+type ruleConfig struct {
+	config.RuleConfig
+}
+
+func (c ruleConfig) Triggers() []rules.TriggerConfig {
+	inp := c.RuleConfig.Triggers()
+	oup := make([]rules.TriggerConfig, len(inp))
+	for i, t := range inp {
+		oup[i] = t
+	}
+	return oup
+}
+
+func (c ruleConfig) Actions() []rules.ActionConfig {
+	inp := c.RuleConfig.Actions()
+	oup := make([]rules.ActionConfig, len(inp))
+	for i, a := range inp {
+		oup[i] = a
+	}
+	return oup
+}
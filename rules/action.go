@@ -0,0 +1,194 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"net/http"
+	"strconv"
+	"text/template"
+	"time"
+)
+
+// Event describes why a rule fired. It is available in the payload templates of mqtt and webhook actions
+// and sent as json when no payload is configured.
+type Event struct {
+	Rule    string
+	Time    time.Time
+	Trigger string
+}
+
+type action interface {
+	execute(ctx context.Context, ev Event) error
+}
+
+func newAction(env *Environment, cfg ActionConfig) (action, error) {
+	switch cfg.Kind() {
+	case types.RuleActionCommandKind:
+		return commandAction{env: env, cfg: cfg}, nil
+	case types.RuleActionMqttKind:
+		payload, err := newPayload(cfg.Payload())
+		if err != nil {
+			return nil, err
+		}
+		return mqttAction{env: env, cfg: cfg, payload: payload}, nil
+	case types.RuleActionWebhookKind:
+		payload, err := newPayload(cfg.Payload())
+		if err != nil {
+			return nil, err
+		}
+		return webhookAction{env: env, cfg: cfg, payload: payload}, nil
+	default:
+		return nil, fmt.Errorf("unknown action kind %s", cfg.Kind())
+	}
+}
+
+type payloadFunc func(ev Event) ([]byte, error)
+
+func newPayload(tmpl string) (payloadFunc, error) {
+	if len(tmpl) < 1 {
+		return func(ev Event) ([]byte, error) {
+			return json.Marshal(ev)
+		}, nil
+	}
+
+	t, err := template.New("payload").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("payload: %w", err)
+	}
+	return func(ev Event) ([]byte, error) {
+		var b bytes.Buffer
+		if err := t.Execute(&b, ev); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	}, nil
+}
+
+// commandAction writes a value to the command storage like a http PATCH request does.
+type commandAction struct {
+	env *Environment
+	cfg ActionConfig
+}
+
+func (a commandAction) execute(_ context.Context, _ Event) error {
+	deviceName, registerName := a.cfg.Device(), a.cfg.Register()
+	register, ok := a.env.RegisterByName(deviceName, registerName)
+	if !ok {
+		return fmt.Errorf("command: register %s.%s not found", deviceName, registerName)
+	}
+	if !register.Writable() {
+		return fmt.Errorf("command: register %s.%s is not writable", deviceName, registerName)
+	}
+
+	v, err := commandValue(deviceName, register, a.cfg.Value())
+	if err != nil {
+		return fmt.Errorf("command: %s.%s: %w", deviceName, registerName, err)
+	}
+	a.env.CommandStorage.Fill(v)
+	return nil
+}
+
+// commandValue converts the configured value according to the register type.
+// Enum values are either given by their index or their text.
+func commandValue(deviceName string, register dataflow.Register, s string) (dataflow.Value, error) {
+	switch register.RegisterType() {
+	case dataflow.TextRegister:
+		return dataflow.NewTextRegisterValue(deviceName, register, s), nil
+	case dataflow.NumberRegister:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value='%s' is not a number", s)
+		}
+		if err := register.Meta().CheckRange(f); err != nil {
+			return nil, err
+		}
+		return dataflow.NewNumericRegisterValue(deviceName, register, f), nil
+	case dataflow.IntRegister:
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("value='%s' is not an integer", s)
+		}
+		if err := register.Meta().CheckRange(float64(i)); err != nil {
+			return nil, err
+		}
+		return dataflow.NewIntRegisterValue(deviceName, register, i), nil
+	case dataflow.BoolRegister:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("value='%s' is not a bool", s)
+		}
+		return dataflow.NewBoolRegisterValue(deviceName, register, b), nil
+	case dataflow.EnumRegister:
+		if idx, err := strconv.Atoi(s); err == nil {
+			if _, ok := register.Enum()[idx]; ok {
+				return dataflow.NewEnumRegisterValue(deviceName, register, idx), nil
+			}
+		}
+		for idx, text := range register.Enum() {
+			if text == s {
+				return dataflow.NewEnumRegisterValue(deviceName, register, idx), nil
+			}
+		}
+		return nil, fmt.Errorf("value='%s' is not a valid enum", s)
+	default:
+		return nil, fmt.Errorf("unsupported register type %s", register.RegisterType())
+	}
+}
+
+type mqttAction struct {
+	env     *Environment
+	cfg     ActionConfig
+	payload payloadFunc
+}
+
+func (a mqttAction) execute(_ context.Context, ev Event) error {
+	client, ok := a.env.MqttClientByName(a.cfg.MqttClient())
+	if !ok {
+		return fmt.Errorf("mqtt: client %s not found", a.cfg.MqttClient())
+	}
+
+	payload, err := a.payload(ev)
+	if err != nil {
+		return fmt.Errorf("mqtt: cannot render payload: %w", err)
+	}
+
+	client.Publish(a.cfg.Topic(), payload, a.cfg.Qos(), a.cfg.Retain())
+	return nil
+}
+
+type webhookAction struct {
+	env     *Environment
+	cfg     ActionConfig
+	payload payloadFunc
+}
+
+func (a webhookAction) execute(ctx context.Context, ev Event) error {
+	payload, err := a.payload(ev)
+	if err != nil {
+		return fmt.Errorf("webhook: cannot render payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.cfg.Timeout())
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, a.cfg.Method(), a.cfg.Url().String(), bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.env.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook: %s returned %s", a.cfg.Url(), resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package rules_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/rules"
+	"testing"
+	"time"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// 2024-01-01 is a Monday
+	t0 := time.Date(2024, 1, 1, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		schedule string
+		expect   time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 1, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"5/15 * * * *", time.Date(2024, 1, 1, 10, 20, 0, 0, time.UTC)},
+		{"0 6 * * *", time.Date(2024, 1, 2, 6, 0, 0, 0, time.UTC)},
+		{"30 8-12 * * *", time.Date(2024, 1, 1, 10, 30, 0, 0, time.UTC)},
+		{"0 0 1 * *", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 12 * * 6,7", time.Date(2024, 1, 6, 12, 0, 0, 0, time.UTC)},
+		{"0 12 * * 0", time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC)},
+		// day of month or day of week
+		{"0 12 15 * 3", time.Date(2024, 1, 3, 12, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.schedule, func(t *testing.T) {
+			s, err := rules.ParseSchedule(tc.schedule)
+			if err != nil {
+				t.Fatalf("cannot parse: %s", err)
+			}
+			if got := s.Next(t0); !got.Equal(tc.expect) {
+				t.Errorf("expect %s but got %s", tc.expect, got)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, src := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"@sometimes",
+	} {
+		if _, err := rules.ParseSchedule(src); err == nil {
+			t.Errorf("expect '%s' to fail", src)
+		}
+	}
+}
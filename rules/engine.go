@@ -0,0 +1,411 @@
+package rules
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/clock"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/eventLoop"
+	"github.com/koestler/go-iotdevice/v3/expression"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+type Config interface {
+	Name() string
+	Triggers() []TriggerConfig
+	Condition() string
+	Actions() []ActionConfig
+	LogDebug() bool
+}
+
+type TriggerConfig interface {
+	Kind() types.RuleTriggerKind
+	Device() string
+	Register() string
+	Above() *float64
+	Below() *float64
+	Hysteresis() float64
+	Hold() time.Duration
+	Available() *bool
+	Schedule() string
+}
+
+type ActionConfig interface {
+	Kind() types.RuleActionKind
+	Device() string
+	Register() string
+	Value() string
+	MqttClient() string
+	Topic() string
+	Payload() string
+	Qos() byte
+	Retain() bool
+	Url() *url.URL
+	Method() string
+	Timeout() time.Duration
+}
+
+// Publisher is implemented by the mqtt clients.
+type Publisher interface {
+	Publish(topic string, payload []byte, qos byte, retain bool)
+}
+
+type Environment struct {
+	Clock          clock.Clock
+	StateStorage   *dataflow.ValueStorage
+	CommandStorage *dataflow.ValueStorage
+	// RegisterByName returns the register of a device; it is used to convert the values of command actions.
+	RegisterByName func(deviceName, registerName string) (dataflow.Register, bool)
+	// MqttClientByName returns the client used by mqtt actions.
+	MqttClientByName func(clientName string) (Publisher, bool)
+	// HttpClient is used by webhook actions; http.DefaultClient is used when nil.
+	HttpClient *http.Client
+}
+
+// Engine evaluates the rules. Values and timers are handled by a single routine, hence the trigger state
+// is only modified while holding the mutex. Actions are executed in their own routine to not block the engine.
+type Engine struct {
+	env   *Environment
+	rules []*rule
+
+	// refs and availability are read-only after creation since filter is called by the storage routine.
+	refs         map[expression.Reference]struct{}
+	availability map[string]struct{}
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+	loop      *eventLoop.Loop
+
+	mutex  sync.Mutex
+	values map[expression.Reference]float64
+}
+
+type rule struct {
+	cfg       Config
+	triggers  []*trigger
+	condition *expression.Expression
+	actions   []action
+
+	fireCount     int
+	lastTriggered time.Time
+	lastFired     time.Time
+	lastError     string
+	lastErrorAt   time.Time
+}
+
+// Run creates the engine and starts evaluating the given rules.
+func Run(env *Environment, configs []Config) (*Engine, error) {
+	if env.Clock == nil {
+		env.Clock = clock.Real()
+	}
+	if env.HttpClient == nil {
+		env.HttpClient = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Engine{
+		env:          env,
+		refs:         make(map[expression.Reference]struct{}),
+		availability: make(map[string]struct{}),
+		ctx:          ctx,
+		ctxCancel:    cancel,
+		values:       make(map[expression.Reference]float64),
+	}
+	e.loop = eventLoop.New(ctx, &e.mutex)
+
+	for _, cfg := range configs {
+		r, err := e.newRule(cfg)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("rules[%s]: %w", cfg.Name(), err)
+		}
+		e.rules = append(e.rules, r)
+	}
+
+	// actions write commands and values back into the storages; the engine must never hold up the state storage
+	sub := env.StateStorage.SubscribeSendInitial(ctx, e.filter,
+		dataflow.WithName("rules"),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
+
+	e.mutex.Lock()
+	for _, r := range e.rules {
+		for _, t := range r.triggers {
+			if t.cfg.Kind() == types.RuleTriggerCronKind {
+				e.scheduleCron(r, t)
+			}
+		}
+	}
+	e.mutex.Unlock()
+
+	e.wg.Add(1)
+	go e.mainRoutine(sub)
+
+	return e, nil
+}
+
+func (e *Engine) newRule(cfg Config) (*rule, error) {
+	r := &rule{cfg: cfg}
+
+	for _, tc := range cfg.Triggers() {
+		t, err := newTrigger(tc)
+		if err != nil {
+			return nil, err
+		}
+		r.triggers = append(r.triggers, t)
+
+		switch tc.Kind() {
+		case types.RuleTriggerChangeKind, types.RuleTriggerThresholdKind:
+			e.refs[expression.Reference{Device: tc.Device(), Register: tc.Register()}] = struct{}{}
+		case types.RuleTriggerAvailabilityKind:
+			e.availability[tc.Device()] = struct{}{}
+		}
+	}
+
+	if src := cfg.Condition(); len(src) > 0 {
+		expr, err := expression.Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("condition: %w", err)
+		}
+		r.condition = expr
+		for _, ref := range expr.References() {
+			e.refs[ref] = struct{}{}
+		}
+	}
+
+	for _, ac := range cfg.Actions() {
+		a, err := newAction(e.env, ac)
+		if err != nil {
+			return nil, err
+		}
+		r.actions = append(r.actions, a)
+	}
+
+	return r, nil
+}
+
+// Shutdown stops the engine and waits for running actions to complete.
+func (e *Engine) Shutdown() {
+	e.ctxCancel()
+	e.wg.Wait()
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	for _, r := range e.rules {
+		for _, t := range r.triggers {
+			t.cancelTimer()
+		}
+	}
+}
+
+func (e *Engine) filter(v dataflow.Value) bool {
+	if v.Register().Name() == device.AvailabilityRegisterName {
+		if _, ok := e.availability[v.DeviceName()]; ok {
+			return true
+		}
+	}
+	_, ok := e.refs[expression.Reference{Device: v.DeviceName(), Register: v.Register().Name()}]
+	return ok
+}
+
+func (e *Engine) mainRoutine(sub dataflow.ValueSubscription) {
+	defer e.wg.Done()
+	e.loop.Run(sub.Drain(), e.handleValue)
+}
+
+func (e *Engine) handleValue(v dataflow.Value) {
+	ref := expression.Reference{Device: v.DeviceName(), Register: v.Register().Name()}
+	if f, ok := numericValue(v); ok {
+		e.values[ref] = f
+	} else {
+		delete(e.values, ref)
+	}
+
+	for _, r := range e.rules {
+		for _, t := range r.triggers {
+			if t.matches(v) {
+				e.updateTrigger(r, t, v)
+			}
+		}
+	}
+}
+
+func (e *Engine) updateTrigger(r *rule, t *trigger, v dataflow.Value) {
+	switch t.cfg.Kind() {
+	case types.RuleTriggerChangeKind:
+		if _, isNull := v.(dataflow.NullRegisterValue); isNull {
+			return
+		}
+		changed := t.last != nil && !t.last.Equals(v)
+		t.last = v
+		if changed {
+			e.fire(r, t)
+		}
+
+	case types.RuleTriggerThresholdKind:
+		f, ok := numericValue(v)
+		if !ok {
+			if t.state == TriggerPending {
+				e.setTriggerState(t, TriggerIdle)
+			}
+			return
+		}
+		switch t.state {
+		case TriggerIdle:
+			if t.inside(f) {
+				e.activate(r, t)
+			}
+		default:
+			if t.released(f) {
+				e.setTriggerState(t, TriggerIdle)
+			}
+		}
+
+	case types.RuleTriggerAvailabilityKind:
+		f, ok := numericValue(v)
+		available := ok && f != 0
+		if !t.known {
+			// the first value received only tells the current availability
+			t.known = true
+			t.available = available
+			return
+		}
+		if available == t.available {
+			return
+		}
+		t.available = available
+		e.setTriggerState(t, TriggerIdle)
+		if want := t.cfg.Available(); want == nil || *want == available {
+			e.activate(r, t)
+		}
+	}
+}
+
+// activate fires the trigger immediately or once the hold time has passed.
+func (e *Engine) activate(r *rule, t *trigger) {
+	hold := t.cfg.Hold()
+	if hold <= 0 {
+		e.setTriggerState(t, TriggerActive)
+		e.fire(r, t)
+		return
+	}
+
+	e.setTriggerState(t, TriggerPending)
+	t.next = t.since.Add(hold)
+	gen := t.gen
+	t.stop = e.env.Clock.AfterFunc(hold, func() {
+		e.loop.Do(func() {
+			if t.gen != gen {
+				// cancelled meanwhile
+				return
+			}
+			t.stop = nil
+			e.setTriggerState(t, TriggerActive)
+			e.fire(r, t)
+		})
+	})
+}
+
+func (e *Engine) setTriggerState(t *trigger, state TriggerState) {
+	t.cancelTimer()
+	t.state = state
+	t.since = e.env.Clock.Now()
+}
+
+func (e *Engine) scheduleCron(r *rule, t *trigger) {
+	now := e.env.Clock.Now()
+	next := t.schedule.Next(now)
+	if next.IsZero() {
+		return
+	}
+
+	t.next = next
+	gen := t.gen
+	t.stop = e.env.Clock.AfterFunc(next.Sub(now), func() {
+		e.loop.Do(func() {
+			if t.gen != gen {
+				return
+			}
+			t.stop = nil
+			e.fire(r, t)
+			e.scheduleCron(r, t)
+		})
+	})
+}
+
+func (e *Engine) lookup(ref expression.Reference) (float64, bool) {
+	v, ok := e.values[ref]
+	return v, ok
+}
+
+// fire checks the condition of the rule and executes its actions.
+func (e *Engine) fire(r *rule, t *trigger) {
+	name := r.cfg.Name()
+	now := e.env.Clock.Now()
+	r.lastTriggered = now
+
+	if r.condition != nil {
+		c, err := r.condition.Eval(e.lookup)
+		if err != nil {
+			r.setError(now, fmt.Errorf("condition: %w", err))
+			if r.cfg.LogDebug() {
+				log.Printf("rules[%s]: cannot evaluate condition: %s", name, err)
+			}
+			return
+		}
+		if c == 0 {
+			if r.cfg.LogDebug() {
+				log.Printf("rules[%s]: triggered by %s but condition is false", name, t)
+			}
+			return
+		}
+	}
+
+	if r.cfg.LogDebug() {
+		log.Printf("rules[%s]: fired by %s", name, t)
+	}
+	r.fireCount += 1
+	r.lastFired = now
+
+	ev := Event{Rule: name, Time: now, Trigger: t.String()}
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for _, a := range r.actions {
+			if err := a.execute(e.ctx, ev); err != nil {
+				log.Printf("rules[%s]: action failed: %s", name, err)
+				e.mutex.Lock()
+				r.setError(e.env.Clock.Now(), err)
+				e.mutex.Unlock()
+			}
+		}
+	}()
+}
+
+func (r *rule) setError(now time.Time, err error) {
+	r.lastError = err.Error()
+	r.lastErrorAt = now
+}
+
+// numericValue returns the value as used by triggers and conditions; bools and enums are represented by their index.
+func numericValue(v dataflow.Value) (float64, bool) {
+	switch tv := v.(type) {
+	case dataflow.NumericRegisterValue:
+		return tv.Value(), true
+	case dataflow.IntRegisterValue:
+		return float64(tv.Value()), true
+	case dataflow.BoolRegisterValue:
+		return float64(tv.EnumIdx()), true
+	case dataflow.EnumRegisterValue:
+		return float64(tv.EnumIdx()), true
+	default:
+		return 0, false
+	}
+}
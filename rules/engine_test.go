@@ -0,0 +1,397 @@
+package rules_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/clock"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/rules"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testRule struct {
+	name      string
+	triggers  []rules.TriggerConfig
+	condition string
+	actions   []rules.ActionConfig
+}
+
+func (r testRule) Name() string                    { return r.name }
+func (r testRule) Triggers() []rules.TriggerConfig { return r.triggers }
+func (r testRule) Condition() string               { return r.condition }
+func (r testRule) Actions() []rules.ActionConfig   { return r.actions }
+func (r testRule) LogDebug() bool                  { return false }
+
+type testTrigger struct {
+	kind       types.RuleTriggerKind
+	device     string
+	register   string
+	above      *float64
+	below      *float64
+	hysteresis float64
+	hold       time.Duration
+	available  *bool
+	schedule   string
+}
+
+func (t testTrigger) Kind() types.RuleTriggerKind { return t.kind }
+func (t testTrigger) Device() string              { return t.device }
+func (t testTrigger) Register() string            { return t.register }
+func (t testTrigger) Above() *float64             { return t.above }
+func (t testTrigger) Below() *float64             { return t.below }
+func (t testTrigger) Hysteresis() float64         { return t.hysteresis }
+func (t testTrigger) Hold() time.Duration         { return t.hold }
+func (t testTrigger) Available() *bool            { return t.available }
+func (t testTrigger) Schedule() string            { return t.schedule }
+
+type testAction struct {
+	kind       types.RuleActionKind
+	device     string
+	register   string
+	value      string
+	mqttClient string
+	topic      string
+	payload    string
+	url        *url.URL
+}
+
+func (a testAction) Kind() types.RuleActionKind { return a.kind }
+func (a testAction) Device() string             { return a.device }
+func (a testAction) Register() string           { return a.register }
+func (a testAction) Value() string              { return a.value }
+func (a testAction) MqttClient() string         { return a.mqttClient }
+func (a testAction) Topic() string              { return a.topic }
+func (a testAction) Payload() string            { return a.payload }
+func (a testAction) Qos() byte                  { return 0 }
+func (a testAction) Retain() bool               { return false }
+func (a testAction) Url() *url.URL              { return a.url }
+func (a testAction) Method() string             { return http.MethodPost }
+func (a testAction) Timeout() time.Duration     { return time.Second }
+
+type testPublisher struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (p *testPublisher) Publish(topic string, payload []byte, _ byte, _ bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.messages = append(p.messages, topic+": "+string(payload))
+}
+
+func (p *testPublisher) count() int {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return len(p.messages)
+}
+
+var (
+	socRegister     = dataflow.NewRegisterStruct("Battery", "SOC", "", dataflow.NumberRegister, nil, "%", 0, false)
+	currentRegister = dataflow.NewRegisterStruct("Battery", "Current", "", dataflow.NumberRegister, nil, "A", 1, false)
+	relayRegister   = dataflow.NewRegisterStruct("Relays", "CH1", "", dataflow.BoolRegister, nil, "", 0, true)
+	availRegister   = dataflow.NewRegisterStruct(
+		device.AvailabilityRegisterName, device.AvailabilityRegisterName, "",
+		dataflow.EnumRegister, map[int]string{0: "offline", 1: "online"}, "", 0, false,
+	)
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+type testSetup struct {
+	clock          *clock.Simulated
+	stateStorage   *dataflow.ValueStorage
+	commandStorage *dataflow.ValueStorage
+	publisher      *testPublisher
+	engine         *rules.Engine
+}
+
+func setup(t *testing.T, configs ...rules.Config) *testSetup {
+	t.Helper()
+
+	s := &testSetup{
+		clock:          clock.NewSimulated(time.Date(2024, 1, 1, 0, 5, 0, 0, time.UTC)),
+		stateStorage:   dataflow.NewValueStorage(),
+		commandStorage: dataflow.NewValueStorage(),
+		publisher:      &testPublisher{},
+	}
+
+	engine, err := rules.Run(&rules.Environment{
+		Clock:          s.clock,
+		StateStorage:   s.stateStorage,
+		CommandStorage: s.commandStorage,
+		RegisterByName: func(deviceName, registerName string) (dataflow.Register, bool) {
+			if deviceName == "relay0" && registerName == "CH1" {
+				return relayRegister, true
+			}
+			return nil, false
+		},
+		MqttClientByName: func(clientName string) (rules.Publisher, bool) {
+			return s.publisher, clientName == "local"
+		},
+	}, configs)
+	if err != nil {
+		t.Fatalf("cannot start engine: %s", err)
+	}
+	s.engine = engine
+
+	t.Cleanup(func() {
+		s.engine.Shutdown()
+		s.stateStorage.Shutdown()
+		s.commandStorage.Shutdown()
+	})
+	return s
+}
+
+func waitFor(t *testing.T, what string, check func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if check() {
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+	t.Fatalf("timeout waiting for %s", what)
+}
+
+func (s *testSetup) waitForTriggerState(t *testing.T, state rules.TriggerState) {
+	t.Helper()
+	waitFor(t, "trigger state "+state.String(), func() bool {
+		return s.engine.Status()[0].Triggers[0].State == state
+	})
+}
+
+func (s *testSetup) fireCount() int {
+	return s.engine.Status()[0].FireCount
+}
+
+func (s *testSetup) relayCommand() (value, ok bool) {
+	for _, v := range s.commandStorage.GetStateFiltered(func(v dataflow.Value) bool {
+		return v.DeviceName() == "relay0" && v.Register().Name() == "CH1"
+	}) {
+		if b, isBool := v.(dataflow.BoolRegisterValue); isBool {
+			return b.Value(), true
+		}
+	}
+	return false, false
+}
+
+func TestThresholdTrigger(t *testing.T) {
+	s := setup(t, testRule{
+		name: "low-soc",
+		triggers: []rules.TriggerConfig{testTrigger{
+			kind:       types.RuleTriggerThresholdKind,
+			device:     "bmv0",
+			register:   "SOC",
+			below:      ptr(20.0),
+			hysteresis: 5,
+			hold:       time.Minute,
+		}},
+		actions: []rules.ActionConfig{testAction{
+			kind:     types.RuleActionCommandKind,
+			device:   "relay0",
+			register: "CH1",
+			value:    "true",
+		}},
+	})
+
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 30))
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 15))
+	s.waitForTriggerState(t, rules.TriggerPending)
+
+	if expect, got := s.clock.Now().Add(time.Minute), s.engine.Status()[0].Triggers[0].Next; !expect.Equal(got) {
+		t.Errorf("expect Next to be %s but got %s", expect, got)
+	}
+
+	// within the hysteresis, the hold time is not restarted
+	s.clock.Advance(30 * time.Second)
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 22))
+	s.clock.Advance(20 * time.Second)
+	if got := s.fireCount(); got != 0 {
+		t.Errorf("expect no fire before the hold time but got %d", got)
+	}
+
+	s.clock.Advance(10 * time.Second)
+	s.waitForTriggerState(t, rules.TriggerActive)
+	if expect, got := 1, s.fireCount(); expect != got {
+		t.Errorf("expect fire count %d but got %d", expect, got)
+	}
+	waitFor(t, "relay command", func() bool {
+		v, ok := s.relayCommand()
+		return ok && v
+	})
+
+	// release requires the hysteresis to be passed
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 24))
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 25))
+	s.waitForTriggerState(t, rules.TriggerIdle)
+
+	// a dip shorter than the hold time does not fire
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 10))
+	s.waitForTriggerState(t, rules.TriggerPending)
+	s.clock.Advance(30 * time.Second)
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 30))
+	s.waitForTriggerState(t, rules.TriggerIdle)
+	s.clock.Advance(time.Minute)
+
+	if expect, got := 1, s.fireCount(); expect != got {
+		t.Errorf("expect fire count %d but got %d", expect, got)
+	}
+}
+
+func TestChangeTriggerWithCondition(t *testing.T) {
+	s := setup(t, testRule{
+		name: "current-changed",
+		triggers: []rules.TriggerConfig{testTrigger{
+			kind:     types.RuleTriggerChangeKind,
+			device:   "bmv0",
+			register: "Current",
+		}},
+		condition: "bmv0.SOC > 50",
+		actions: []rules.ActionConfig{testAction{
+			kind:       types.RuleActionMqttKind,
+			mqttClient: "local",
+			topic:      "alert",
+			payload:    "{{.Rule}} {{.Trigger}}",
+		}},
+	})
+
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 40))
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, 1))
+
+	// the first value is not a change; the second one is but the condition is false
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, 2))
+	waitFor(t, "trigger", func() bool {
+		return !s.engine.Status()[0].LastTriggered.IsZero()
+	})
+	if got := s.fireCount(); got != 0 {
+		t.Errorf("expect no fire while the condition is false but got %d", got)
+	}
+
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 60))
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", currentRegister, 3))
+	waitFor(t, "mqtt message", func() bool {
+		return s.publisher.count() == 1
+	})
+	if expect, got := "alert: current-changed Change bmv0.Current", s.publisher.messages[0]; expect != got {
+		t.Errorf("expect message '%s' but got '%s'", expect, got)
+	}
+}
+
+func TestAvailabilityTrigger(t *testing.T) {
+	s := setup(t, testRule{
+		name: "offline",
+		triggers: []rules.TriggerConfig{testTrigger{
+			kind:      types.RuleTriggerAvailabilityKind,
+			device:    "bmv0",
+			available: ptr(false),
+			hold:      5 * time.Minute,
+		}},
+		actions: []rules.ActionConfig{testAction{
+			kind:       types.RuleActionMqttKind,
+			mqttClient: "local",
+			topic:      "offline",
+		}},
+	})
+
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 1))
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 0))
+	s.waitForTriggerState(t, rules.TriggerPending)
+
+	// back online before the hold time passed
+	s.clock.Advance(time.Minute)
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 1))
+	s.waitForTriggerState(t, rules.TriggerIdle)
+
+	s.stateStorage.Fill(dataflow.NewEnumRegisterValue("bmv0", availRegister, 0))
+	s.waitForTriggerState(t, rules.TriggerPending)
+	s.clock.Advance(5 * time.Minute)
+	s.waitForTriggerState(t, rules.TriggerActive)
+
+	waitFor(t, "mqtt message", func() bool {
+		return s.publisher.count() == 1
+	})
+}
+
+func TestCronTriggerWebhook(t *testing.T) {
+	var mutex sync.Mutex
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		bodies = append(bodies, string(b))
+		mutex.Unlock()
+	}))
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	s := setup(t, testRule{
+		name: "quarter",
+		triggers: []rules.TriggerConfig{testTrigger{
+			kind:     types.RuleTriggerCronKind,
+			schedule: "*/15 * * * *",
+		}},
+		actions: []rules.ActionConfig{testAction{
+			kind: types.RuleActionWebhookKind,
+			url:  serverUrl,
+		}},
+	})
+
+	if expect, got := time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC), s.engine.Status()[0].Triggers[0].Next; !expect.Equal(got) {
+		t.Errorf("expect Next to be %s but got %s", expect, got)
+	}
+
+	s.clock.Advance(30 * time.Minute)
+	if expect, got := 2, s.fireCount(); expect != got {
+		t.Errorf("expect fire count %d but got %d", expect, got)
+	}
+	if expect, got := time.Date(2024, 1, 1, 0, 45, 0, 0, time.UTC), s.engine.Status()[0].Triggers[0].Next; !expect.Equal(got) {
+		t.Errorf("expect Next to be %s but got %s", expect, got)
+	}
+
+	waitFor(t, "webhook calls", func() bool {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return len(bodies) == 2
+	})
+	expect := `{"Rule":"quarter","Time":"2024-01-01T00:30:00Z","Trigger":"Cron */15 * * * *"}`
+	mutex.Lock()
+	defer mutex.Unlock()
+	if bodies[0] != expect && bodies[1] != expect {
+		t.Errorf("expect a body '%s' but got %v", expect, bodies)
+	}
+}
+
+func TestActionError(t *testing.T) {
+	s := setup(t, testRule{
+		name: "unknown-register",
+		triggers: []rules.TriggerConfig{testTrigger{
+			kind:     types.RuleTriggerThresholdKind,
+			device:   "bmv0",
+			register: "SOC",
+			above:    ptr(90.0),
+		}},
+		actions: []rules.ActionConfig{testAction{
+			kind:     types.RuleActionCommandKind,
+			device:   "relay0",
+			register: "CH2",
+			value:    "1",
+		}},
+	})
+
+	s.stateStorage.Fill(dataflow.NewNumericRegisterValue("bmv0", socRegister, 95))
+	waitFor(t, "action error", func() bool {
+		return s.engine.Status()[0].LastError != ""
+	})
+	if expect, got := "command: register relay0.CH2 not found", s.engine.Status()[0].LastError; expect != got {
+		t.Errorf("expect error '%s' but got '%s'", expect, got)
+	}
+}
@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"github.com/koestler/go-iotdevice/v3/types"
+	"time"
+)
+
+type RuleStatus struct {
+	Name          string
+	Condition     string
+	Triggers      []TriggerStatus
+	FireCount     int
+	LastTriggered time.Time
+	LastFired     time.Time
+	LastError     string
+	LastErrorAt   time.Time
+}
+
+type TriggerStatus struct {
+	Kind        types.RuleTriggerKind
+	Description string
+	State       TriggerState
+	Since       time.Time
+	// Next is the end of the hold time of a pending trigger or the next run of a cron trigger.
+	Next time.Time
+}
+
+// Status returns the current state of all rules in the configured order.
+func (e *Engine) Status() []RuleStatus {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	ret := make([]RuleStatus, len(e.rules))
+	for i, r := range e.rules {
+		triggers := make([]TriggerStatus, len(r.triggers))
+		for j, t := range r.triggers {
+			triggers[j] = TriggerStatus{
+				Kind:        t.cfg.Kind(),
+				Description: t.Description(),
+				State:       t.state,
+				Since:       t.since,
+				Next:        t.next,
+			}
+		}
+
+		ret[i] = RuleStatus{
+			Name:          r.cfg.Name(),
+			Condition:     r.cfg.Condition(),
+			Triggers:      triggers,
+			FireCount:     r.fireCount,
+			LastTriggered: r.lastTriggered,
+			LastFired:     r.lastFired,
+			LastError:     r.lastError,
+			LastErrorAt:   r.lastErrorAt,
+		}
+	}
+	return ret
+}
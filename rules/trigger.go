@@ -0,0 +1,143 @@
+package rules
+
+import (
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"strings"
+	"time"
+)
+
+type TriggerState int
+
+const (
+	// TriggerIdle means the trigger waits for its condition to become true.
+	TriggerIdle TriggerState = iota
+	// TriggerPending means the condition is true but the hold time has not passed yet.
+	TriggerPending
+	// TriggerActive means the trigger has fired and waits to be released.
+	TriggerActive
+)
+
+func (s TriggerState) String() string {
+	switch s {
+	case TriggerPending:
+		return "pending"
+	case TriggerActive:
+		return "active"
+	default:
+		return "idle"
+	}
+}
+
+type trigger struct {
+	cfg      TriggerConfig
+	schedule Schedule
+
+	state TriggerState
+	since time.Time
+	next  time.Time // end of the hold time or the next run of a cron trigger
+
+	last      dataflow.Value // change triggers: last value received
+	known     bool           // availability triggers: whether the availability is known
+	available bool
+
+	// gen is incremented whenever the timer is cancelled to ignore timers already being run
+	gen  int
+	stop func() bool
+}
+
+func newTrigger(cfg TriggerConfig) (*trigger, error) {
+	t := &trigger{cfg: cfg}
+	if cfg.Kind() == types.RuleTriggerCronKind {
+		s, err := ParseSchedule(cfg.Schedule())
+		if err != nil {
+			return nil, fmt.Errorf("schedule='%s': %w", cfg.Schedule(), err)
+		}
+		t.schedule = s
+	}
+	return t, nil
+}
+
+func (t *trigger) matches(v dataflow.Value) bool {
+	switch t.cfg.Kind() {
+	case types.RuleTriggerChangeKind, types.RuleTriggerThresholdKind:
+		return v.DeviceName() == t.cfg.Device() && v.Register().Name() == t.cfg.Register()
+	case types.RuleTriggerAvailabilityKind:
+		return v.DeviceName() == t.cfg.Device() && v.Register().Name() == device.AvailabilityRegisterName
+	default:
+		return false
+	}
+}
+
+// inside returns true when the value crossed the threshold.
+func (t *trigger) inside(f float64) bool {
+	if a := t.cfg.Above(); a != nil {
+		return f > *a
+	}
+	if b := t.cfg.Below(); b != nil {
+		return f < *b
+	}
+	return false
+}
+
+// released returns true when the value is back on the other side of the threshold including the hysteresis.
+func (t *trigger) released(f float64) bool {
+	if a := t.cfg.Above(); a != nil {
+		return f <= *a-t.cfg.Hysteresis()
+	}
+	if b := t.cfg.Below(); b != nil {
+		return f >= *b+t.cfg.Hysteresis()
+	}
+	return true
+}
+
+func (t *trigger) cancelTimer() {
+	t.gen += 1
+	if t.stop != nil {
+		t.stop()
+		t.stop = nil
+	}
+	if t.cfg.Kind() != types.RuleTriggerCronKind {
+		t.next = time.Time{}
+	}
+}
+
+// Description returns a short human-readable summary of the trigger configuration.
+func (t *trigger) Description() string {
+	c := t.cfg
+	var b strings.Builder
+	switch c.Kind() {
+	case types.RuleTriggerChangeKind:
+		fmt.Fprintf(&b, "%s.%s", c.Device(), c.Register())
+	case types.RuleTriggerThresholdKind:
+		if a := c.Above(); a != nil {
+			fmt.Fprintf(&b, "%s.%s > %g", c.Device(), c.Register(), *a)
+		} else if bl := c.Below(); bl != nil {
+			fmt.Fprintf(&b, "%s.%s < %g", c.Device(), c.Register(), *bl)
+		}
+		if h := c.Hysteresis(); h > 0 {
+			fmt.Fprintf(&b, " hysteresis %g", h)
+		}
+	case types.RuleTriggerAvailabilityKind:
+		b.WriteString(c.Device())
+		if a := c.Available(); a != nil {
+			if *a {
+				b.WriteString(" available")
+			} else {
+				b.WriteString(" unavailable")
+			}
+		}
+	case types.RuleTriggerCronKind:
+		b.WriteString(c.Schedule())
+	}
+	if h := c.Hold(); h > 0 {
+		fmt.Fprintf(&b, " hold %s", h)
+	}
+	return b.String()
+}
+
+func (t *trigger) String() string {
+	return t.cfg.Kind().String() + " " + t.Description()
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"github.com/koestler/go-iotdevice/v3/config"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/snapshot"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"log"
+)
+
+func runSnapshot(
+	cfg *config.Config,
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) *snapshot.Snapshotter {
+	snapshotCfg := cfg.Snapshot()
+	if !snapshotCfg.Enabled() {
+		return nil
+	}
+
+	if cfg.LogWorkerStart() {
+		log.Printf("snapshot: start: path=%s", snapshotCfg.Path())
+	}
+
+	s, err := snapshot.Open(snapshotCfg, getSnapshotPolicy(snapshotCfg), stateStorage, commandStorage)
+	if err != nil {
+		log.Printf("snapshot: cannot open: %s", err)
+		return nil
+	}
+	return s
+}
+
+// getSnapshotPolicy returns the configured restore policy of each value.
+func getSnapshotPolicy(snapshotCfg config.SnapshotConfig) snapshot.PolicyFunc {
+	return func(value dataflow.Value) types.RestorePolicy {
+		p := snapshotCfg.Policy(value.DeviceName(), value.Register().Name())
+		if value.Register().Name() == device.AvailabilityRegisterName && p.RestoreState() {
+			// availability is only known once the device is running again
+			if p.RestoreCommand() {
+				return types.RestorePolicyCommand
+			}
+			return types.RestorePolicyNone
+		}
+		return p
+	}
+}
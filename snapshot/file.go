@@ -0,0 +1,180 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const fileVersion = 1
+
+// file is the json document written to disk.
+type file struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	State   []entry   `json:"state"`
+	Command []entry   `json:"command"`
+}
+
+type entry struct {
+	Device     string          `json:"device"`
+	Register   register        `json:"register"`
+	Value      json.RawMessage `json:"value"`
+	MeasuredAt time.Time       `json:"measuredAt"`
+	SourceTime *time.Time      `json:"sourceTime,omitempty"`
+	Quality    string          `json:"quality"`
+}
+
+type register struct {
+	Category    string         `json:"category"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Type        string         `json:"type"`
+	Enum        map[int]string `json:"enum,omitempty"`
+	Unit        string         `json:"unit,omitempty"`
+	Sort        int            `json:"sort"`
+	Writable    bool           `json:"writable"`
+}
+
+var errUnsupportedValue = errors.New("unsupported value")
+
+func newEntry(value dataflow.Value) (e entry, err error) {
+	var payload interface{}
+	switch v := value.(type) {
+	case dataflow.NumericRegisterValue:
+		payload = v.Value()
+	case dataflow.TextRegisterValue:
+		payload = v.Value()
+	case dataflow.EnumRegisterValue:
+		payload = v.EnumIdx()
+	case dataflow.BoolRegisterValue:
+		payload = v.Value()
+	case dataflow.IntRegisterValue:
+		payload = v.Value()
+	default:
+		return e, errUnsupportedValue
+	}
+
+	if e.Value, err = json.Marshal(payload); err != nil {
+		return
+	}
+
+	reg := value.Register()
+	e.Device = value.DeviceName()
+	e.Register = register{
+		Category:    reg.Category(),
+		Name:        reg.Name(),
+		Description: reg.Description(),
+		Type:        reg.RegisterType().String(),
+		Enum:        reg.Enum(),
+		Unit:        reg.Unit(),
+		Sort:        reg.Sort(),
+		Writable:    reg.Writable(),
+	}
+	e.MeasuredAt = value.MeasuredAt()
+	if st := value.SourceTime(); !st.IsZero() {
+		e.SourceTime = &st
+	}
+	e.Quality = value.Quality().String()
+	return
+}
+
+func (e entry) value() (dataflow.Value, error) {
+	reg := dataflow.NewRegisterStruct(
+		e.Register.Category,
+		e.Register.Name,
+		e.Register.Description,
+		dataflow.RegisterTypeFromString(e.Register.Type),
+		e.Register.Enum,
+		e.Register.Unit,
+		e.Register.Sort,
+		e.Register.Writable,
+	)
+
+	var value dataflow.Value
+	switch reg.RegisterType() {
+	case dataflow.NumberRegister:
+		var v float64
+		if err := json.Unmarshal(e.Value, &v); err != nil {
+			return nil, err
+		}
+		value = dataflow.NewNumericRegisterValue(e.Device, reg, v)
+	case dataflow.TextRegister:
+		var v string
+		if err := json.Unmarshal(e.Value, &v); err != nil {
+			return nil, err
+		}
+		value = dataflow.NewTextRegisterValue(e.Device, reg, v)
+	case dataflow.EnumRegister:
+		var v int
+		if err := json.Unmarshal(e.Value, &v); err != nil {
+			return nil, err
+		}
+		value = dataflow.NewEnumRegisterValue(e.Device, reg, v)
+	case dataflow.BoolRegister:
+		var v bool
+		if err := json.Unmarshal(e.Value, &v); err != nil {
+			return nil, err
+		}
+		value = dataflow.NewBoolRegisterValue(e.Device, reg, v)
+	case dataflow.IntRegister:
+		var v int64
+		if err := json.Unmarshal(e.Value, &v); err != nil {
+			return nil, err
+		}
+		value = dataflow.NewIntRegisterValue(e.Device, reg, v)
+	default:
+		return nil, fmt.Errorf("unknown register type='%s'", e.Register.Type)
+	}
+
+	var sourceTime time.Time
+	if e.SourceTime != nil {
+		sourceTime = *e.SourceTime
+	}
+	return value.WithTimes(e.MeasuredAt, sourceTime).WithQuality(dataflow.QualityFromString(e.Quality)), nil
+}
+
+func readFile(path string) (f file, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	if err = json.Unmarshal(data, &f); err != nil {
+		return
+	}
+	if f.Version != fileVersion {
+		err = fmt.Errorf("unsupported version=%d", f.Version)
+	}
+	return
+}
+
+// writeFile replaces the file atomically; a crash during a write leaves the previous snapshot intact.
+func writeFile(path string, f file) error {
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,201 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Config interface {
+	Path() string
+	Interval() time.Duration
+	LogDebug() bool
+}
+
+// PolicyFunc returns the restore policy of the register of the given value.
+type PolicyFunc func(value dataflow.Value) types.RestorePolicy
+
+// Snapshotter periodically writes the state and the last commands to a json file and restores them at startup.
+// Commands are reset to null by most devices once they are executed, hence the last non-null command
+// of each register is tracked instead of the current content of the command storage.
+type Snapshotter struct {
+	cfg            Config
+	policy         PolicyFunc
+	stateStorage   *dataflow.ValueStorage
+	commandStorage *dataflow.ValueStorage
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+
+	mutex    sync.Mutex
+	commands map[valueKey]dataflow.Value
+}
+
+type valueKey struct {
+	device   string
+	register string
+}
+
+func keyOf(v dataflow.Value) valueKey {
+	return valueKey{device: v.DeviceName(), register: v.Register().Name()}
+}
+
+// Open restores the snapshot into the given storages and starts writing it periodically.
+// A missing or damaged snapshot file is logged and otherwise ignored.
+// Open must be called before the devices are started.
+func Open(
+	cfg Config,
+	policy PolicyFunc,
+	stateStorage *dataflow.ValueStorage,
+	commandStorage *dataflow.ValueStorage,
+) (*Snapshotter, error) {
+	if err := os.MkdirAll(filepath.Dir(cfg.Path()), 0755); err != nil {
+		return nil, fmt.Errorf("cannot create directory: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Snapshotter{
+		cfg:            cfg,
+		policy:         policy,
+		stateStorage:   stateStorage,
+		commandStorage: commandStorage,
+		ctx:            ctx,
+		ctxCancel:      cancel,
+		commands:       make(map[valueKey]dataflow.Value),
+	}
+
+	s.restore()
+
+	// the subscription is created after the restore, hence the restored commands are part of the initial values
+	commandSubscription := commandStorage.SubscribeSendInitial(ctx, func(v dataflow.Value) bool {
+		return s.policy(v).RestoreCommand()
+	})
+
+	s.wg.Add(1)
+	go s.mainRoutine(commandSubscription)
+
+	return s, nil
+}
+
+// Shutdown stops the periodic writes and writes the snapshot a last time.
+func (s *Snapshotter) Shutdown() {
+	s.ctxCancel()
+	s.wg.Wait()
+	s.write()
+}
+
+func (s *Snapshotter) mainRoutine(commandSubscription dataflow.ValueSubscription) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.Interval())
+	defer ticker.Stop()
+
+	commands := commandSubscription.Drain()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case v, ok := <-commands:
+			if !ok {
+				return
+			}
+			if _, isNull := v.(dataflow.NullRegisterValue); isNull {
+				continue
+			}
+			s.mutex.Lock()
+			s.commands[keyOf(v)] = v
+			s.mutex.Unlock()
+		case <-ticker.C:
+			s.write()
+		}
+	}
+}
+
+func (s *Snapshotter) restore() {
+	f, err := readFile(s.cfg.Path())
+	if err != nil {
+		if os.IsNotExist(err) {
+			if s.cfg.LogDebug() {
+				log.Printf("snapshot: no snapshot found at %s", s.cfg.Path())
+			}
+		} else {
+			log.Printf("snapshot: cannot read %s: %s", s.cfg.Path(), err)
+		}
+		return
+	}
+
+	state := s.restoreEntries(f.State, func(p types.RestorePolicy) bool { return p.RestoreState() })
+	for _, v := range state {
+		// a restored value is not confirmed by the device yet
+		s.stateStorage.Fill(v.WithQuality(dataflow.QualityUncertain))
+	}
+
+	commands := s.restoreEntries(f.Command, func(p types.RestorePolicy) bool { return p.RestoreCommand() })
+	for _, v := range commands {
+		s.commandStorage.Fill(v)
+	}
+
+	s.stateStorage.Wait()
+	s.commandStorage.Wait()
+
+	log.Printf("snapshot: restored %d state values and %d commands from %s written at %s",
+		len(state), len(commands), s.cfg.Path(), f.Time.Format(time.RFC3339),
+	)
+}
+
+func (s *Snapshotter) restoreEntries(entries []entry, include func(types.RestorePolicy) bool) (ret []dataflow.Value) {
+	ret = make([]dataflow.Value, 0, len(entries))
+	for _, e := range entries {
+		v, err := e.value()
+		if err != nil {
+			log.Printf("snapshot: skip %s->%s: %s", e.Device, e.Register.Name, err)
+			continue
+		}
+		if !include(s.policy(v)) {
+			continue
+		}
+		if s.cfg.LogDebug() {
+			log.Printf("snapshot: restore %s: %s", v.DeviceName(), v)
+		}
+		ret = append(ret, v)
+	}
+	return
+}
+
+func (s *Snapshotter) write() {
+	f := file{
+		Version: fileVersion,
+		Time:    time.Now(),
+	}
+
+	for _, v := range s.stateStorage.GetStateFiltered(func(v dataflow.Value) bool {
+		return s.policy(v).RestoreState()
+	}) {
+		if e, err := newEntry(v); err == nil {
+			f.State = append(f.State, e)
+		}
+	}
+
+	s.mutex.Lock()
+	for _, v := range s.commands {
+		if e, err := newEntry(v); err == nil {
+			f.Command = append(f.Command, e)
+		}
+	}
+	s.mutex.Unlock()
+
+	if err := writeFile(s.cfg.Path(), f); err != nil {
+		log.Printf("snapshot: cannot write %s: %s", s.cfg.Path(), err)
+		return
+	}
+	if s.cfg.LogDebug() {
+		log.Printf("snapshot: wrote %d state values and %d commands", len(f.State), len(f.Command))
+	}
+}
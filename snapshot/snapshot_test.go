@@ -0,0 +1,144 @@
+package snapshot_test
+
+import (
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/snapshot"
+	"github.com/koestler/go-iotdevice/v3/types"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	path string
+}
+
+func (c testConfig) Path() string            { return c.path }
+func (c testConfig) Interval() time.Duration { return time.Hour }
+func (c testConfig) LogDebug() bool          { return false }
+
+var (
+	numberRegister = dataflow.NewRegisterStruct("c", "Temp", "", dataflow.NumberRegister, nil, "°C", 0, false)
+	relayRegister  = dataflow.NewRegisterStruct("c", "Relay", "", dataflow.EnumRegister, map[int]string{0: "Off", 1: "On"}, "", 1, true)
+	skipRegister   = dataflow.NewRegisterStruct("c", "Skip", "", dataflow.TextRegister, nil, "", 2, true)
+)
+
+func testPolicy(value dataflow.Value) types.RestorePolicy {
+	if value.Register().Name() == skipRegister.Name() {
+		return types.RestorePolicyNone
+	}
+	return types.RestorePolicyAll
+}
+
+func getValue(storage *dataflow.ValueStorage, registerName string) dataflow.Value {
+	for _, v := range storage.GetStateFiltered(func(v dataflow.Value) bool {
+		return v.Register().Name() == registerName
+	}) {
+		return v
+	}
+	return nil
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	cfg := testConfig{path: filepath.Join(t.TempDir(), "sub", "snapshot.json")}
+	measuredAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	// first run: fill some values, reset the command like devices do after executing it
+	{
+		stateStorage := dataflow.NewValueStorage()
+		commandStorage := dataflow.NewValueStorage()
+
+		s, err := snapshot.Open(cfg, testPolicy, stateStorage, commandStorage)
+		if err != nil {
+			t.Fatalf("cannot open: %s", err)
+		}
+
+		stateStorage.Fill(dataflow.NewNumericRegisterValue("dev", numberRegister, 21.5).WithTimes(measuredAt, time.Time{}))
+		stateStorage.Fill(dataflow.NewTextRegisterValue("dev", skipRegister, "foo"))
+		commandStorage.Fill(dataflow.NewEnumRegisterValue("dev", relayRegister, 1))
+		commandStorage.Fill(dataflow.NewTextRegisterValue("dev", skipRegister, "bar"))
+		stateStorage.Wait()
+		commandStorage.Wait()
+
+		// give the snapshotter time to receive the command before it is reset
+		time.Sleep(50 * time.Millisecond)
+		commandStorage.Fill(dataflow.NewNullRegisterValue("dev", relayRegister))
+		commandStorage.Wait()
+
+		s.Shutdown()
+		stateStorage.Shutdown()
+		commandStorage.Shutdown()
+	}
+
+	if _, err := os.Stat(cfg.path); err != nil {
+		t.Fatalf("expect snapshot to be written: %s", err)
+	}
+
+	// second run: values must be restored according to the policy
+	stateStorage := dataflow.NewValueStorage()
+	defer stateStorage.Shutdown()
+	commandStorage := dataflow.NewValueStorage()
+	defer commandStorage.Shutdown()
+
+	s, err := snapshot.Open(cfg, testPolicy, stateStorage, commandStorage)
+	if err != nil {
+		t.Fatalf("cannot reopen: %s", err)
+	}
+	defer s.Shutdown()
+
+	if v, ok := getValue(stateStorage, "Temp").(dataflow.NumericRegisterValue); !ok {
+		t.Error("expect Temp to be restored")
+	} else {
+		if expect, got := 21.5, v.Value(); expect != got {
+			t.Errorf("expect %f but got %f", expect, got)
+		}
+		if expect, got := dataflow.QualityUncertain, v.Quality(); expect != got {
+			t.Errorf("expect quality %s but got %s", expect, got)
+		}
+		if !v.MeasuredAt().Equal(measuredAt) {
+			t.Errorf("expect measured at %s but got %s", measuredAt, v.MeasuredAt())
+		}
+		if expect, got := "°C", v.Register().Unit(); expect != got {
+			t.Errorf("expect unit %s but got %s", expect, got)
+		}
+	}
+
+	if v, ok := getValue(commandStorage, "Relay").(dataflow.EnumRegisterValue); !ok {
+		t.Error("expect the last non-null Relay command to be restored")
+	} else if expect, got := 1, v.EnumIdx(); expect != got {
+		t.Errorf("expect %d but got %d", expect, got)
+	}
+
+	if v := getValue(stateStorage, "Skip"); v != nil {
+		t.Errorf("expect Skip state not to be restored, got %s", v)
+	}
+	if v := getValue(commandStorage, "Skip"); v != nil {
+		t.Errorf("expect Skip command not to be restored, got %s", v)
+	}
+}
+
+func TestSnapshotDamagedFile(t *testing.T) {
+	cfg := testConfig{path: filepath.Join(t.TempDir(), "snapshot.json")}
+	if err := os.WriteFile(cfg.path, []byte(`{"version": 1, "state": [`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	stateStorage := dataflow.NewValueStorage()
+	defer stateStorage.Shutdown()
+	commandStorage := dataflow.NewValueStorage()
+	defer commandStorage.Shutdown()
+
+	s, err := snapshot.Open(cfg, testPolicy, stateStorage, commandStorage)
+	if err != nil {
+		t.Fatalf("expect a damaged snapshot to be ignored, got: %s", err)
+	}
+	s.Shutdown()
+
+	if len(stateStorage.GetState()) != 0 {
+		t.Error("expect nothing to be restored")
+	}
+	if _, err := os.ReadFile(cfg.path); err != nil {
+		t.Errorf("expect the snapshot to be rewritten: %s", err)
+	}
+}
@@ -2,16 +2,124 @@ package main
 
 import (
 	"context"
+	"github.com/koestler/go-iotdevice/v3/config"
 	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"time"
 )
 
 func runStorage(logPrefix string) *dataflow.ValueStorage {
 	valueStorage := dataflow.NewValueStorage()
 
 	if len(logPrefix) > 0 {
-		subscription := valueStorage.SubscribeSendInitial(context.Background(), dataflow.AllValueFilter)
+		subscription := valueStorage.SubscribeSendInitial(context.Background(), dataflow.AllValueFilter,
+			dataflow.WithName("log"),
+			dataflow.WithOverflowPolicy(dataflow.OverflowDropOldest),
+		)
 		go dataflow.SinkLog(logPrefix, subscription.Drain())
 	}
 
 	return valueStorage
 }
+
+// removeDeviceValues fills a null value for every register of the device; this removes them from the storage.
+func removeDeviceValues(storage *dataflow.ValueStorage, deviceName string) {
+	for _, v := range storage.GetStateFiltered(dataflow.DeviceNameValueFilter(deviceName)) {
+		storage.Fill(dataflow.NewNullRegisterValue(deviceName, v.Register()))
+	}
+}
+
+// removeUnknownRegisterValues removes the values of registers the device does not have anymore, e.g. after it was
+// restarted with a changed register filter. Since the registers are only known once the device is running,
+// this is done as soon as the device becomes available again.
+func removeUnknownRegisterValues(ctx context.Context, dev device.Device, storages ...*dataflow.ValueStorage) {
+	ctx, cancel := context.WithCancel(ctx)
+	availChan := dev.SubscribeAvailableSendInitial(ctx)
+
+	go func() {
+		defer func() {
+			cancel()
+			// the channel must be drained until it is closed
+			for range availChan {
+			}
+		}()
+
+		// the stopped instance leaves the device unavailable; wait for the new one to become available
+		wasUnavailable := false
+		for avail := range availChan {
+			if !avail {
+				wasUnavailable = true
+				continue
+			}
+			if !wasUnavailable {
+				continue
+			}
+
+			deviceName := dev.Name()
+			for _, storage := range storages {
+				for _, v := range storage.GetStateFiltered(dataflow.DeviceNameValueFilter(deviceName)) {
+					if _, ok := dev.RegisterDb().GetByName(v.Register().Name()); !ok {
+						storage.Fill(dataflow.NewNullRegisterValue(deviceName, v.Register()))
+					}
+				}
+			}
+			return
+		}
+	}()
+}
+
+// getStateStorageMaxAge returns the configured MaxAge / RegisterMaxAge of the device of each value.
+func getStateStorageMaxAge(cfg *config.Config) dataflow.MaxAgeFunc {
+	devices := make(map[string]config.DeviceConfig)
+	for _, d := range cfg.Devices() {
+		devices[d.Name()] = d
+	}
+
+	return func(value dataflow.Value) time.Duration {
+		registerName := value.Register().Name()
+		if registerName == device.AvailabilityRegisterName {
+			// availability is only sent on change, it never gets stale
+			return 0
+		}
+
+		if d, ok := devices[value.DeviceName()]; ok {
+			return d.MaxAge(registerName)
+		}
+		return 0
+	}
+}
+
+// getStateStorageHistory returns the configured History of the device of each value; nil when no device has a history.
+func getStateStorageHistory(cfg *config.Config) dataflow.HistoryConfigFunc {
+	type deviceHistory struct {
+		cfg    dataflow.HistoryConfig
+		filter dataflow.RegisterFilterFunc
+	}
+
+	devices := make(map[string]deviceHistory)
+	for _, d := range cfg.Devices() {
+		h := d.History()
+		if !h.Enabled() {
+			continue
+		}
+		devices[d.Name()] = deviceHistory{
+			cfg: dataflow.HistoryConfig{
+				MaxAge:     h.MaxAge(),
+				MaxSamples: h.MaxSamples(),
+				Resolution: h.Resolution(),
+			},
+			filter: dataflow.RegisterFilter(h.Filter()),
+		}
+	}
+
+	if len(devices) < 1 {
+		return nil
+	}
+
+	return func(value dataflow.Value) (dataflow.HistoryConfig, bool) {
+		if d, ok := devices[value.DeviceName()]; ok && d.filter(value.Register()) {
+			return d.cfg, true
+		}
+		return dataflow.HistoryConfig{}, false
+	}
+}
@@ -0,0 +1,192 @@
+package systemDevice
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/device"
+	"github.com/koestler/go-iotdevice/v3/mqttClient"
+	"github.com/koestler/go-iotdevice/v3/pool"
+	"log"
+	"runtime"
+	"time"
+)
+
+type Config interface {
+	PollInterval() time.Duration
+	DiskPath() string
+}
+
+// Storage is a ValueStorage whose value and subscription counts are reported.
+type Storage struct {
+	Name    string
+	Storage *dataflow.ValueStorage
+}
+
+// DeviceStruct reports the health of the go-iotdevice process and its host.
+// Registers are added when their value is available for the first time; e.g. the temperature
+// is only reported on hosts providing /sys/class/thermal.
+type DeviceStruct struct {
+	device.State
+	systemConfig Config
+
+	storages       []Storage
+	mqttClientPool *pool.Pool[mqttClient.Client]
+
+	registerFilter dataflow.RegisterFilterFunc
+	registers      map[string]dataflow.Register // key: register name; nil when filtered
+	sort           map[string]int               // key: category; number of registers
+	startedAt      time.Time
+}
+
+func NewDevice(
+	deviceConfig device.Config,
+	systemConfig Config,
+	stateStorage *dataflow.ValueStorage,
+	storages []Storage,
+	mqttClientPool *pool.Pool[mqttClient.Client],
+) *DeviceStruct {
+	return &DeviceStruct{
+		State: device.NewState(
+			deviceConfig,
+			stateStorage,
+		),
+		systemConfig:   systemConfig,
+		storages:       storages,
+		mqttClientPool: mqttClientPool,
+		registerFilter: dataflow.RegisterFilter(deviceConfig.Filter()),
+		registers:      make(map[string]dataflow.Register),
+		sort:           make(map[string]int),
+		startedAt:      time.Now(),
+	}
+}
+
+func (d *DeviceStruct) Model() string {
+	return "System"
+}
+
+func (d *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool) {
+	d.SetAvailable(true)
+	defer d.SetAvailable(false)
+
+	if d.Config().LogDebug() {
+		log.Printf("systemDevice[%s]: start polling, interval=%s", d.Name(), d.systemConfig.PollInterval())
+	}
+
+	d.poll()
+
+	ticker := time.NewTicker(d.systemConfig.PollInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, false
+		case <-ticker.C:
+			d.poll()
+		}
+	}
+}
+
+func (d *DeviceStruct) poll() {
+	start := time.Now()
+	d.pollProcess()
+	d.pollHost()
+	d.pollStorages()
+	d.pollMqttClients()
+	d.ObservePoll(time.Since(start))
+}
+
+func (d *DeviceStruct) pollProcess() {
+	const cat = "Process"
+
+	d.number(cat, "Uptime", "Uptime", "s", time.Since(d.startedAt).Round(time.Second).Seconds(), 0)
+	d.integer(cat, "Goroutines", "Goroutines", int64(runtime.NumGoroutine()))
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	d.number(cat, "HeapAlloc", "Heap allocated", "MB", float64(m.HeapAlloc)/1e6, 1)
+	d.number(cat, "HeapSys", "Heap obtained from the OS", "MB", float64(m.HeapSys)/1e6, 1)
+}
+
+func (d *DeviceStruct) pollHost() {
+	const cat = "Host"
+
+	h := readHost(d.systemConfig.DiskPath())
+	if h.loadOk {
+		d.number(cat, "Load1", "Load average 1min", "", h.load[0], 2)
+		d.number(cat, "Load5", "Load average 5min", "", h.load[1], 2)
+		d.number(cat, "Load15", "Load average 15min", "", h.load[2], 2)
+	}
+	if h.diskOk {
+		d.number(cat, "DiskFree", "Free disk space of "+d.systemConfig.DiskPath(), "GB", float64(h.diskFree)/1e9, 2)
+	}
+	for _, z := range h.thermalZones {
+		d.number(cat, "Temperature"+z.id, "Temperature "+z.name, "°C", z.temperature, 1)
+	}
+}
+
+func (d *DeviceStruct) pollStorages() {
+	const cat = "Storage"
+
+	for _, s := range d.storages {
+		d.integer(cat, s.Name+"Values", s.Name+" values", int64(s.Storage.ValueCount()))
+		d.integer(cat, s.Name+"Subscriptions", s.Name+" subscriptions", int64(s.Storage.SubscriptionCount()))
+	}
+}
+
+func (d *DeviceStruct) pollMqttClients() {
+	const cat = "Mqtt"
+
+	if d.mqttClientPool == nil {
+		return
+	}
+	for name, c := range d.mqttClientPool.GetAll() {
+		d.integer(cat, "Backlog"+name, "Backlog of "+name, int64(c.BacklogSize()))
+	}
+}
+
+func (d *DeviceStruct) number(category, registerName, description, unit string, value float64, precision int) {
+	register := d.addIgnoreRegister(registerName, func() dataflow.RegisterStruct {
+		return dataflow.NewRegisterStruct(
+			category, registerName, description, dataflow.NumberRegister, nil, unit, d.getRegisterSort(category), false,
+		).WithMeta(dataflow.MetaByUnit(unit).WithPrecision(precision))
+	})
+	if register == nil {
+		return
+	}
+	d.Output().Fill(dataflow.NewNumericRegisterValue(d.Name(), register, value))
+}
+
+func (d *DeviceStruct) integer(category, registerName, description string, value int64) {
+	register := d.addIgnoreRegister(registerName, func() dataflow.RegisterStruct {
+		return dataflow.NewRegisterStruct(
+			category, registerName, description, dataflow.IntRegister, nil, "", d.getRegisterSort(category), false,
+		).WithMeta(dataflow.RegisterMeta{StateClass: dataflow.StateClassMeasurement})
+	})
+	if register == nil {
+		return
+	}
+	d.Output().Fill(dataflow.NewIntRegisterValue(d.Name(), register, value))
+}
+
+// addIgnoreRegister creates the register on first use; it returns nil when the register is filtered.
+func (d *DeviceStruct) addIgnoreRegister(registerName string, create func() dataflow.RegisterStruct) dataflow.Register {
+	if r, ok := d.registers[registerName]; ok {
+		return r
+	}
+
+	r := create()
+	if !d.registerFilter(r) {
+		d.registers[registerName] = nil
+		return nil
+	}
+
+	d.RegisterDb().AddStruct(r)
+	d.registers[registerName] = r
+	return r
+}
+
+func (d *DeviceStruct) getRegisterSort(category string) int {
+	offset := map[string]int{"Process": 0, "Host": 1, "Storage": 2, "Mqtt": 3}[category] * 100
+	d.sort[category] += 1
+	return offset + d.sort[category] - 1
+}
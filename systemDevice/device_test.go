@@ -0,0 +1,88 @@
+package systemDevice_test
+
+import (
+	"context"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"github.com/koestler/go-iotdevice/v3/systemDevice"
+	"testing"
+	"time"
+)
+
+type filterConf struct{}
+
+func (filterConf) IncludeRegisters() []string  { return nil }
+func (filterConf) SkipRegisters() []string     { return []string{"HeapSys"} }
+func (filterConf) IncludeCategories() []string { return nil }
+func (filterConf) SkipCategories() []string    { return []string{"Host"} }
+func (filterConf) DefaultInclude() bool        { return true }
+
+type testConfig struct{}
+
+func (c testConfig) Name() string                                              { return "system" }
+func (c testConfig) Filter() dataflow.RegisterFilterConf                       { return filterConf{} }
+func (c testConfig) RegisterMeta() map[string]dataflow.RegisterMeta            { return nil }
+func (c testConfig) RegisterTransforms() map[string]dataflow.RegisterTransform { return nil }
+func (c testConfig) Deadband(dataflow.Register) (dataflow.Deadband, bool) {
+	return dataflow.Deadband{}, false
+}
+func (c testConfig) LogDebug() bool              { return false }
+func (c testConfig) LogComDebug() bool           { return false }
+func (c testConfig) PollInterval() time.Duration { return time.Hour }
+func (c testConfig) DiskPath() string            { return "/" }
+
+func TestSystemDevice(t *testing.T) {
+	storage := dataflow.NewValueStorage()
+	defer storage.Shutdown()
+
+	other := dataflow.NewValueStorage()
+	defer other.Shutdown()
+	reg := dataflow.NewRegisterStruct("c", "A", "", dataflow.NumberRegister, nil, "", 0, false)
+	other.Fill(dataflow.NewNumericRegisterValue("dev", reg, 1))
+	other.Fill(dataflow.NewNumericRegisterValue("dev", dataflow.NewRegisterStruct("c", "B", "", dataflow.NumberRegister, nil, "", 0, false), 2))
+	other.Wait()
+
+	dev := systemDevice.NewDevice(testConfig{}, testConfig{}, storage, []systemDevice.Storage{{Name: "Other", Storage: other}}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dev.Run(ctx)
+
+	value := func(registerName string) (v dataflow.Value) {
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			for _, v := range storage.GetStateFiltered(func(v dataflow.Value) bool {
+				return v.DeviceName() == "system" && v.Register().Name() == registerName
+			}) {
+				return v
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		return nil
+	}
+
+	if v := value("Goroutines"); v == nil {
+		t.Error("expect Goroutines to be reported")
+	} else if got := v.(dataflow.IntRegisterValue).Value(); got < 1 {
+		t.Errorf("expect at least one goroutine but got %d", got)
+	}
+	if v := value("OtherValues"); v == nil {
+		t.Error("expect OtherValues to be reported")
+	} else if expect, got := int64(2), v.(dataflow.IntRegisterValue).Value(); expect != got {
+		t.Errorf("expect %d values but got %d", expect, got)
+	}
+	if v := value("HeapAlloc"); v == nil {
+		t.Error("expect HeapAlloc to be reported")
+	} else if expect, got := "MB", v.Register().Unit(); expect != got {
+		t.Errorf("expect unit %s but got %s", expect, got)
+	}
+
+	// filtered registers are neither stored nor listed
+	for _, name := range []string{"HeapSys", "Load1", "DiskFree"} {
+		if _, ok := dev.RegisterDb().GetByName(name); ok {
+			t.Errorf("expect %s to be filtered", name)
+		}
+	}
+	if _, ok := dev.RegisterDb().GetByName("Uptime"); !ok {
+		t.Error("expect Uptime to be listed")
+	}
+}
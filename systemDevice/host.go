@@ -0,0 +1,17 @@
+package systemDevice
+
+type thermalZone struct {
+	id          string // number of the zone, e.g. 0 for thermal_zone0
+	name        string
+	temperature float64 // °C
+}
+
+type hostStats struct {
+	load   [3]float64
+	loadOk bool
+
+	diskFree uint64 // bytes available to unprivileged users
+	diskOk   bool
+
+	thermalZones []thermalZone
+}
@@ -0,0 +1,72 @@
+package systemDevice
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	loadAvgPath = "/proc/loadavg"
+	thermalPath = "/sys/class/thermal"
+)
+
+func readHost(diskPath string) (h hostStats) {
+	h.load, h.loadOk = readLoadAvg(loadAvgPath)
+
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(diskPath, &st); err == nil {
+		h.diskFree = st.Bavail * uint64(st.Bsize)
+		h.diskOk = true
+	}
+
+	h.thermalZones = readThermalZones(thermalPath)
+	return
+}
+
+// readLoadAvg parses the 1, 5 and 15 minute load averages, e.g. "0.20 0.18 0.12 1/80 11206".
+func readLoadAvg(path string) (load [3]float64, ok bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	fields := strings.Fields(string(b))
+	if len(fields) < 3 {
+		return
+	}
+	for i := range load {
+		if load[i], err = strconv.ParseFloat(fields[i], 64); err != nil {
+			return
+		}
+	}
+	return load, true
+}
+
+// readThermalZones reads all thermal_zone*/temp files; they contain the temperature in m°C.
+func readThermalZones(path string) (zones []thermalZone) {
+	dirs, _ := filepath.Glob(filepath.Join(path, "thermal_zone*"))
+	for _, dir := range dirs {
+		b, err := os.ReadFile(filepath.Join(dir, "temp"))
+		if err != nil {
+			continue
+		}
+		milli, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		base := filepath.Base(dir)
+		name := base
+		if t, err := os.ReadFile(filepath.Join(dir, "type")); err == nil {
+			name = strings.TrimSpace(string(t))
+		}
+		zones = append(zones, thermalZone{
+			id:          strings.TrimPrefix(base, "thermal_zone"),
+			name:        name,
+			temperature: float64(milli) / 1000,
+		})
+	}
+	return
+}
@@ -0,0 +1,57 @@
+package systemDevice
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadThermalZones(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("thermal_zone0/temp", "48312\n")
+	write("thermal_zone0/type", "cpu-thermal\n")
+	write("thermal_zone1/temp", "invalid\n")
+	write("thermal_zone10/temp", "-5000\n")
+	write("cooling_device0/cur_state", "0\n")
+
+	expect := []thermalZone{
+		{id: "0", name: "cpu-thermal", temperature: 48.312},
+		{id: "10", name: "thermal_zone10", temperature: -5},
+	}
+	if got := readThermalZones(dir); !reflect.DeepEqual(expect, got) {
+		t.Errorf("expect %v but got %v", expect, got)
+	}
+
+	if got := readThermalZones(filepath.Join(dir, "non-existent")); len(got) != 0 {
+		t.Errorf("expect no zones but got %v", got)
+	}
+}
+
+func TestReadLoadAvg(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "loadavg")
+	if err := os.WriteFile(p, []byte("0.20 0.18 0.12 1/80 11206\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	load, ok := readLoadAvg(p)
+	if !ok {
+		t.Fatal("expect ok")
+	}
+	if expect := [3]float64{0.2, 0.18, 0.12}; expect != load {
+		t.Errorf("expect %v but got %v", expect, load)
+	}
+
+	if _, ok := readLoadAvg(filepath.Join(filepath.Dir(p), "non-existent")); ok {
+		t.Error("expect not ok for a missing file")
+	}
+}
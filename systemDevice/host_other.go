@@ -0,0 +1,8 @@
+//go:build !linux
+
+package systemDevice
+
+// readHost is only implemented on linux; no host registers are reported on other platforms.
+func readHost(diskPath string) (h hostStats) {
+	return
+}
@@ -0,0 +1,215 @@
+package tsdb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SeriesFilter decides whether the series of the given device and register is included.
+type SeriesFilter func(device, register string) bool
+
+// Series maps the device name and the register name to its points ordered by time.
+type Series map[string]map[string][]Point
+
+// Query returns all points within [from, to] of the given resolution; zero times mean unbounded.
+// Rollups of the current period which are not yet written are included.
+func (s *Store) Query(res Resolution, from, to time.Time, filter SeriesFilter) (Series, error) {
+	s.mutex.Lock()
+	s.flush()
+	open := s.openPoints(res, from, to, filter)
+	s.mutex.Unlock()
+
+	days, err := s.listDaysInRange(res, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	collected := make(map[seriesKey][]Point)
+	for _, day := range days {
+		if err := s.scanDay(res, day, from, to, filter, func(k seriesKey, p Point) {
+			collected[k] = append(collected[k], p)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	for k, p := range open {
+		collected[k] = append(collected[k], p)
+	}
+
+	ret := make(Series)
+	for k, points := range collected {
+		if _, ok := ret[k.device]; !ok {
+			ret[k.device] = make(map[string][]Point)
+		}
+		ret[k.device][k.register] = sortAndMerge(res, points)
+	}
+	return ret, nil
+}
+
+// Export writes all points within [from, to] of the given resolution as csv to w.
+// The data is processed day by day, the memory usage does not depend on the size of the range.
+func (s *Store) Export(w io.Writer, res Resolution, from, to time.Time, filter SeriesFilter) error {
+	s.mutex.Lock()
+	s.flush()
+	s.mutex.Unlock()
+
+	days, err := s.listDaysInRange(res, from, to)
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"time", "device", "register", "min", "max", "avg", "count"}); err != nil {
+		return err
+	}
+
+	for _, day := range days {
+		collected := make(map[seriesKey][]Point)
+		if err := s.scanDay(res, day, from, to, filter, func(k seriesKey, p Point) {
+			collected[k] = append(collected[k], p)
+		}); err != nil {
+			return err
+		}
+
+		keys := make([]seriesKey, 0, len(collected))
+		for k := range collected {
+			keys = append(keys, k)
+		}
+		slices.SortFunc(keys, func(a, b seriesKey) int {
+			if c := strings.Compare(a.device, b.device); c != 0 {
+				return c
+			}
+			return strings.Compare(a.register, b.register)
+		})
+
+		for _, k := range keys {
+			for _, p := range sortAndMerge(res, collected[k]) {
+				if err := cw.Write([]string{
+					p.Time.Format(time.RFC3339Nano),
+					k.device,
+					k.register,
+					formatFloat(p.Min),
+					formatFloat(p.Max),
+					formatFloat(p.Avg),
+					strconv.Itoa(p.Count),
+				}); err != nil {
+					return err
+				}
+			}
+		}
+		cw.Flush()
+		if err := cw.Error(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) openPoints(res Resolution, from, to time.Time, filter SeriesFilter) map[seriesKey]Point {
+	ret := make(map[seriesKey]Point)
+	if res == Raw {
+		return ret
+	}
+	for k, b := range s.buckets[res] {
+		if inRange(b.start, from, to) && filter(k.device, k.register) {
+			ret[k] = b.point()
+		}
+	}
+	return ret
+}
+
+func (s *Store) scanDay(
+	res Resolution, day string, from, to time.Time, filter SeriesFilter, fn func(seriesKey, Point),
+) error {
+	f, err := os.Open(s.segmentPath(res, day))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		k, p, err := parseRecord(scanner.Text())
+		if err != nil {
+			// skip records damaged e.g. by a power loss during a write
+			continue
+		}
+		if inRange(p.Time, from, to) && filter(k.device, k.register) {
+			fn(k, p)
+		}
+	}
+	return scanner.Err()
+}
+
+func (s *Store) listDays(res Resolution) ([]string, error) {
+	entries, err := os.ReadDir(s.segmentDir(res))
+	if err != nil {
+		return nil, err
+	}
+	days := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if name, ok := strings.CutSuffix(e.Name(), ".log"); ok && !e.IsDir() {
+			days = append(days, name)
+		}
+	}
+	slices.Sort(days)
+	return days, nil
+}
+
+func (s *Store) listDaysInRange(res Resolution, from, to time.Time) ([]string, error) {
+	days, err := s.listDays(res)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]string, 0, len(days))
+	for _, day := range days {
+		if !from.IsZero() && day < dayOf(from) {
+			continue
+		}
+		if !to.IsZero() && day > dayOf(to) {
+			continue
+		}
+		ret = append(ret, day)
+	}
+	return ret, nil
+}
+
+func inRange(t, from, to time.Time) bool {
+	if !from.IsZero() && t.Before(from) {
+		return false
+	}
+	if !to.IsZero() && t.After(to) {
+		return false
+	}
+	return true
+}
+
+// sortAndMerge orders the points by time and merges rollups of the same period
+func sortAndMerge(res Resolution, points []Point) []Point {
+	slices.SortStableFunc(points, func(a, b Point) int {
+		return a.Time.Compare(b.Time)
+	})
+	if res == Raw {
+		return points
+	}
+
+	ret := make([]Point, 0, len(points))
+	for _, p := range points {
+		if l := len(ret); l > 0 && ret[l-1].Time.Equal(p.Time) {
+			ret[l-1] = ret[l-1].merge(p)
+		} else {
+			ret = append(ret, p)
+		}
+	}
+	return ret
+}
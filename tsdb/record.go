@@ -0,0 +1,182 @@
+package tsdb
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Resolution int
+
+const (
+	Raw Resolution = iota
+	Minute
+	Hour
+)
+
+func (r Resolution) String() string {
+	switch r {
+	case Minute:
+		return "1m"
+	case Hour:
+		return "1h"
+	default:
+		return "raw"
+	}
+}
+
+func (r Resolution) Duration() time.Duration {
+	switch r {
+	case Minute:
+		return time.Minute
+	case Hour:
+		return time.Hour
+	default:
+		return 0
+	}
+}
+
+func ResolutionFromString(s string) (Resolution, bool) {
+	switch s {
+	case "raw", "":
+		return Raw, true
+	case "1m":
+		return Minute, true
+	case "1h":
+		return Hour, true
+	default:
+		return Raw, false
+	}
+}
+
+// Point is either a raw sample (Count=1, Min=Max=Avg) or the rollup of all samples within a minute / hour.
+type Point struct {
+	Time  time.Time
+	Min   float64
+	Max   float64
+	Avg   float64
+	Count int
+}
+
+// merge combines two rollups of the same period; this happens when the store was restarted within a period.
+func (p Point) merge(o Point) Point {
+	count := p.Count + o.Count
+	return Point{
+		Time:  p.Time,
+		Min:   math.Min(p.Min, o.Min),
+		Max:   math.Max(p.Max, o.Max),
+		Avg:   (p.Avg*float64(p.Count) + o.Avg*float64(o.Count)) / float64(count),
+		Count: count,
+	}
+}
+
+type bucket struct {
+	start time.Time
+	min   float64
+	max   float64
+	sum   float64
+	count int
+	// carried is set when the bucket only holds the value carried forward from the previous period
+	carried bool
+}
+
+func newBucket(start time.Time) *bucket {
+	return &bucket{
+		start: start,
+		min:   math.Inf(1),
+		max:   math.Inf(-1),
+	}
+}
+
+// newCarriedBucket returns a bucket for a period without any samples holding the last known value.
+func newCarriedBucket(start time.Time, last float64) *bucket {
+	b := newBucket(start)
+	b.add(last)
+	b.carried = true
+	return b
+}
+
+func (b *bucket) add(v float64) {
+	b.min = math.Min(b.min, v)
+	b.max = math.Max(b.max, v)
+	b.sum += v
+	b.count += 1
+}
+
+func (b *bucket) point() Point {
+	return Point{
+		Time:  b.start,
+		Min:   b.min,
+		Max:   b.max,
+		Avg:   b.sum / float64(b.count),
+		Count: b.count,
+	}
+}
+
+const dayFormat = "2006-01-02"
+
+func dayOf(t time.Time) string {
+	return t.UTC().Format(dayFormat)
+}
+
+// records are tab separated lines:
+// raw:    <unix millis> <device> <register> <value>
+// rollup: <unix millis> <device> <register> <min> <max> <avg> <count>
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func formatRawRecord(t time.Time, k seriesKey, v float64) string {
+	return strconv.FormatInt(t.UnixMilli(), 10) + "\t" + k.device + "\t" + k.register + "\t" + formatFloat(v) + "\n"
+}
+
+func formatRollupRecord(t time.Time, k seriesKey, p Point) string {
+	return strings.Join([]string{
+		strconv.FormatInt(t.UnixMilli(), 10),
+		k.device,
+		k.register,
+		formatFloat(p.Min),
+		formatFloat(p.Max),
+		formatFloat(p.Avg),
+		strconv.Itoa(p.Count),
+	}, "\t") + "\n"
+}
+
+var errInvalidRecord = errors.New("invalid record")
+
+func parseRecord(line string) (k seriesKey, p Point, err error) {
+	fields := strings.Split(line, "\t")
+	if len(fields) != 4 && len(fields) != 7 {
+		return k, p, errInvalidRecord
+	}
+
+	ms, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return k, p, errInvalidRecord
+	}
+	p.Time = time.UnixMilli(ms).UTC()
+	k = seriesKey{device: fields[1], register: fields[2]}
+
+	values := make([]float64, 0, 3)
+	for _, f := range fields[3:min(len(fields), 6)] {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil {
+			return k, p, errInvalidRecord
+		}
+		values = append(values, v)
+	}
+
+	if len(fields) == 4 {
+		p.Min, p.Max, p.Avg, p.Count = values[0], values[0], values[0], 1
+		return
+	}
+
+	p.Min, p.Max, p.Avg = values[0], values[1], values[2]
+	if p.Count, err = strconv.Atoi(fields[6]); err != nil || p.Count < 1 {
+		return k, p, errInvalidRecord
+	}
+	return
+}
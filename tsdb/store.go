@@ -0,0 +1,342 @@
+package tsdb
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/koestler/go-iotdevice/v3/dataflow"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type Config interface {
+	Path() string
+	FlushInterval() time.Duration
+	RawRetention() time.Duration
+	MinuteRetention() time.Duration
+	HourRetention() time.Duration
+	LogDebug() bool
+}
+
+// Store is an append-only, file based time series store.
+// Every resolution is kept in its own directory containing one file per day (UTC).
+// Raw samples are written as received, minute and hour rollups (min / max / avg) are computed in memory
+// and appended once their period is over or when the store is shut down.
+// Since only changes are received, the last value of a series is carried forward into periods without samples.
+type Store struct {
+	cfg Config
+
+	ctx       context.Context
+	ctxCancel context.CancelFunc
+	wg        sync.WaitGroup
+
+	mutex   sync.Mutex
+	writers map[Resolution]*segmentWriter
+	buckets map[Resolution]map[seriesKey]*bucket
+	last    map[seriesKey]float64 // the last value of all series still being carried forward
+}
+
+type seriesKey struct {
+	device   string
+	register string
+}
+
+type segmentWriter struct {
+	day  string
+	file *os.File
+	buf  *bufio.Writer
+}
+
+var rollupResolutions = []Resolution{Minute, Hour}
+
+func Open(cfg Config) (*Store, error) {
+	for _, res := range []Resolution{Raw, Minute, Hour} {
+		if err := os.MkdirAll(filepath.Join(cfg.Path(), res.String()), 0755); err != nil {
+			return nil, fmt.Errorf("cannot create directory: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Store{
+		cfg:       cfg,
+		ctx:       ctx,
+		ctxCancel: cancel,
+		writers:   make(map[Resolution]*segmentWriter),
+		buckets:   make(map[Resolution]map[seriesKey]*bucket),
+		last:      make(map[seriesKey]float64),
+	}
+	for _, res := range rollupResolutions {
+		s.buckets[res] = make(map[seriesKey]*bucket)
+	}
+
+	s.applyRetention(time.Now())
+
+	s.wg.Add(1)
+	go s.mainRoutine()
+
+	return s, nil
+}
+
+// Shutdown writes all open rollups, flushes all buffers and closes the files.
+func (s *Store) Shutdown() {
+	s.ctxCancel()
+	s.wg.Wait()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, res := range rollupResolutions {
+		for k, b := range s.buckets[res] {
+			// the value is not known after the shutdown; the period is completed after the next start
+			if !b.carried {
+				s.writeBucket(res, k, b)
+			}
+		}
+		clear(s.buckets[res])
+	}
+
+	for res, w := range s.writers {
+		if err := w.close(); err != nil {
+			log.Printf("tsdb: cannot close %s segment: %s", res, err)
+		}
+	}
+	clear(s.writers)
+}
+
+func (s *Store) mainRoutine() {
+	defer s.wg.Done()
+
+	flushTicker := time.NewTicker(s.cfg.FlushInterval())
+	defer flushTicker.Stop()
+
+	retentionTicker := time.NewTicker(time.Hour)
+	defer retentionTicker.Stop()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case now := <-flushTicker.C:
+			s.mutex.Lock()
+			s.closeBuckets(now)
+			s.flush()
+			s.mutex.Unlock()
+		case now := <-retentionTicker.C:
+			s.applyRetention(now)
+		}
+	}
+}
+
+// Add appends a sample to the raw series and updates the rollups.
+func (s *Store) Add(device, register string, t time.Time, value float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	t = t.UTC()
+	k := seriesKey{device: device, register: register}
+
+	s.write(Raw, t, formatRawRecord(t, k, value))
+
+	for _, res := range rollupResolutions {
+		start := t.Truncate(res.Duration())
+		b, ok := s.buckets[res][k]
+		if ok && !b.start.Equal(start) {
+			s.writeBucket(res, k, b)
+			s.writeCarried(res, k, b.start.Add(res.Duration()), start)
+			ok = false
+		}
+		if !ok || b.carried {
+			b = newBucket(start)
+			s.buckets[res][k] = b
+		}
+		b.add(value)
+	}
+	s.last[k] = value
+}
+
+// Forget stops carrying the last value of the series forward, e.g. because the device became unavailable.
+func (s *Store) Forget(device, register string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	k := seriesKey{device: device, register: register}
+	delete(s.last, k)
+	for _, res := range rollupResolutions {
+		if b, ok := s.buckets[res][k]; ok && b.carried {
+			delete(s.buckets[res], k)
+		}
+	}
+}
+
+// closeBuckets writes all rollups whose period is over and carries the last value forward into the current period
+func (s *Store) closeBuckets(now time.Time) {
+	for _, res := range rollupResolutions {
+		current := now.UTC().Truncate(res.Duration())
+		for k, b := range s.buckets[res] {
+			if now.Before(b.start.Add(res.Duration())) {
+				continue
+			}
+			s.writeBucket(res, k, b)
+			delete(s.buckets[res], k)
+
+			if last, ok := s.last[k]; ok {
+				s.writeCarried(res, k, b.start.Add(res.Duration()), current)
+				s.buckets[res][k] = newCarriedBucket(current, last)
+			}
+		}
+	}
+}
+
+// writeCarried writes the last value of the series for every period from start until end (exclusive).
+func (s *Store) writeCarried(res Resolution, k seriesKey, start, end time.Time) {
+	last, ok := s.last[k]
+	if !ok {
+		return
+	}
+	for p := start; p.Before(end); p = p.Add(res.Duration()) {
+		s.writeBucket(res, k, newCarriedBucket(p, last))
+	}
+}
+
+func (s *Store) writeBucket(res Resolution, k seriesKey, b *bucket) {
+	s.write(res, b.start, formatRollupRecord(b.start, k, b.point()))
+}
+
+func (s *Store) write(res Resolution, t time.Time, record string) {
+	day := dayOf(t)
+
+	w, ok := s.writers[res]
+	if ok && w.day != day {
+		if err := w.close(); err != nil {
+			log.Printf("tsdb: cannot close %s segment: %s", res, err)
+		}
+		ok = false
+	}
+	if !ok {
+		var err error
+		w, err = openSegmentWriter(s.segmentPath(res, day), day)
+		if err != nil {
+			log.Printf("tsdb: cannot open %s segment: %s", res, err)
+			delete(s.writers, res)
+			return
+		}
+		s.writers[res] = w
+	}
+
+	if _, err := w.buf.WriteString(record); err != nil {
+		log.Printf("tsdb: cannot write %s record: %s", res, err)
+	}
+}
+
+func (s *Store) flush() {
+	for res, w := range s.writers {
+		if err := w.buf.Flush(); err != nil {
+			log.Printf("tsdb: cannot flush %s segment: %s", res, err)
+		}
+	}
+}
+
+func (s *Store) segmentDir(res Resolution) string {
+	return filepath.Join(s.cfg.Path(), res.String())
+}
+
+func (s *Store) segmentPath(res Resolution, day string) string {
+	return filepath.Join(s.segmentDir(res), day+".log")
+}
+
+func (s *Store) retention(res Resolution) time.Duration {
+	switch res {
+	case Minute:
+		return s.cfg.MinuteRetention()
+	case Hour:
+		return s.cfg.HourRetention()
+	default:
+		return s.cfg.RawRetention()
+	}
+}
+
+// applyRetention removes all segments only containing data older than the configured retention
+func (s *Store) applyRetention(now time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, res := range []Resolution{Raw, Minute, Hour} {
+		limit := now.Add(-s.retention(res)).UTC()
+		days, err := s.listDays(res)
+		if err != nil {
+			log.Printf("tsdb: cannot list %s segments: %s", res, err)
+			continue
+		}
+		for _, day := range days {
+			dayStart, err := time.Parse(dayFormat, day)
+			if err != nil || !dayStart.Add(24*time.Hour).Before(limit) {
+				continue
+			}
+			if w, ok := s.writers[res]; ok && w.day == day {
+				continue
+			}
+			if s.cfg.LogDebug() {
+				log.Printf("tsdb: remove %s segment of %s due to retention", res, day)
+			}
+			if err := os.Remove(s.segmentPath(res, day)); err != nil {
+				log.Printf("tsdb: cannot remove %s segment: %s", res, err)
+			}
+		}
+	}
+}
+
+func openSegmentWriter(path, day string) (*segmentWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &segmentWriter{
+		day:  day,
+		file: f,
+		buf:  bufio.NewWriter(f),
+	}, nil
+}
+
+func (w *segmentWriter) close() error {
+	err1 := w.buf.Flush()
+	err2 := w.file.Close()
+	if err1 != nil {
+		return err1
+	}
+	return err2
+}
+
+// Subscribe feeds all numeric, integer, boolean and enum values of good quality matching the filter into the store
+// until the store is shut down. Enum values are stored by their index, booleans as 0 / 1.
+// Values of other quality stop the last value from being carried forward.
+func (s *Store) Subscribe(storage *dataflow.ValueStorage, filter dataflow.ValueFilterFunc) {
+	// the store writes to disk; when it does not keep up, only the latest value per register is kept
+	subscription := storage.SubscribeSendInitial(s.ctx, filter,
+		dataflow.WithName("tsdb"),
+		dataflow.WithOverflowPolicy(dataflow.OverflowCoalesce),
+	)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for value := range subscription.Drain() {
+			if value.Quality() != dataflow.QualityGood {
+				s.Forget(value.DeviceName(), value.Register().Name())
+				continue
+			}
+			switch v := value.(type) {
+			case dataflow.NumericRegisterValue:
+				s.Add(v.DeviceName(), v.Register().Name(), v.SampleTime(), v.Value())
+			case dataflow.IntRegisterValue:
+				s.Add(v.DeviceName(), v.Register().Name(), v.SampleTime(), float64(v.Value()))
+			case dataflow.BoolRegisterValue:
+				s.Add(v.DeviceName(), v.Register().Name(), v.SampleTime(), float64(v.EnumIdx()))
+			case dataflow.EnumRegisterValue:
+				s.Add(v.DeviceName(), v.Register().Name(), v.SampleTime(), float64(v.EnumIdx()))
+			}
+		}
+	}()
+}
@@ -0,0 +1,251 @@
+package tsdb_test
+
+import (
+	"bytes"
+	"github.com/koestler/go-iotdevice/v3/tsdb"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	path          string
+	flushInterval time.Duration
+}
+
+func (c testConfig) Path() string { return c.path }
+func (c testConfig) FlushInterval() time.Duration {
+	if c.flushInterval > 0 {
+		return c.flushInterval
+	}
+	return time.Hour
+}
+func (c testConfig) RawRetention() time.Duration    { return 48 * time.Hour }
+func (c testConfig) MinuteRetention() time.Duration { return 30 * 24 * time.Hour }
+func (c testConfig) HourRetention() time.Duration   { return 365 * 24 * time.Hour }
+func (c testConfig) LogDebug() bool                 { return false }
+
+var allSeries tsdb.SeriesFilter = func(string, string) bool { return true }
+
+func TestStoreQuery(t *testing.T) {
+	cfg := testConfig{path: t.TempDir()}
+	base := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+
+	store, err := tsdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("cannot open store: %s", err)
+	}
+
+	store.Add("dev", "reg-a", base, 1)
+	store.Add("dev", "reg-a", base.Add(10*time.Second), 3)
+	store.Add("dev", "reg-a", base.Add(time.Minute), 5)
+	store.Add("dev", "reg-b", base, 100)
+
+	t.Run("raw", func(t *testing.T) {
+		series, err := store.Query(tsdb.Raw, time.Time{}, time.Time{}, allSeries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect, got := 3, len(series["dev"]["reg-a"]); expect != got {
+			t.Fatalf("expect %d points but got %d", expect, got)
+		}
+		if expect, got := 3.0, series["dev"]["reg-a"][1].Avg; expect != got {
+			t.Errorf("expect %f but got %f", expect, got)
+		}
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		series, err := store.Query(tsdb.Raw, time.Time{}, time.Time{}, func(_, register string) bool {
+			return register == "reg-b"
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := series["dev"]["reg-a"]; ok {
+			t.Errorf("expect reg-a to be filtered")
+		}
+		if expect, got := 1, len(series["dev"]["reg-b"]); expect != got {
+			t.Errorf("expect %d points but got %d", expect, got)
+		}
+	})
+
+	t.Run("minute", func(t *testing.T) {
+		series, err := store.Query(tsdb.Minute, time.Time{}, time.Time{}, allSeries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points := series["dev"]["reg-a"]
+		if expect, got := 2, len(points); expect != got {
+			t.Fatalf("expect %d points but got %d", expect, got)
+		}
+		if p := points[0]; p.Min != 1 || p.Max != 3 || p.Avg != 2 || p.Count != 2 || !p.Time.Equal(base) {
+			t.Errorf("unexpected first minute rollup %#v", p)
+		}
+	})
+
+	// restart the store; the rollups of the open hour are written on shutdown and must be merged afterwards
+	store.Shutdown()
+	store, err = tsdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("cannot reopen store: %s", err)
+	}
+	defer store.Shutdown()
+	store.Add("dev", "reg-a", base.Add(2*time.Minute), 11)
+
+	t.Run("hourAfterRestart", func(t *testing.T) {
+		series, err := store.Query(tsdb.Hour, time.Time{}, time.Time{}, allSeries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		points := series["dev"]["reg-a"]
+		if expect, got := 1, len(points); expect != got {
+			t.Fatalf("expect %d points but got %d", expect, got)
+		}
+		if p := points[0]; p.Min != 1 || p.Max != 11 || p.Avg != 5 || p.Count != 4 {
+			t.Errorf("unexpected hour rollup %#v", p)
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		series, err := store.Query(tsdb.Raw, base.Add(30*time.Second), base.Add(90*time.Second), allSeries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expect, got := 1, len(series["dev"]["reg-a"]); expect != got {
+			t.Errorf("expect %d points but got %d", expect, got)
+		}
+	})
+
+	t.Run("export", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := store.Export(&buf, tsdb.Raw, time.Time{}, time.Time{}, allSeries); err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if expect, got := "time,device,register,min,max,avg,count", lines[0]; expect != got {
+			t.Errorf("expect header '%s' but got '%s'", expect, got)
+		}
+		if expect, got := 6, len(lines); expect != got {
+			t.Errorf("expect %d lines but got %d: %v", expect, got, lines)
+		}
+	})
+}
+
+func TestStoreCarriesLastValueForward(t *testing.T) {
+	cfg := testConfig{path: t.TempDir(), flushInterval: 10 * time.Millisecond}
+	base := time.Now().UTC().Truncate(time.Minute).Add(-3 * time.Minute)
+
+	store, err := tsdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("cannot open store: %s", err)
+	}
+	defer store.Shutdown()
+
+	store.Add("dev", "constant", base, 7)
+	store.Add("dev", "forgotten", base, 3)
+	store.Forget("dev", "forgotten")
+
+	// wait for the flush ticker to close the rollups
+	var series tsdb.Series
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		series, err = store.Query(tsdb.Minute, time.Time{}, time.Time{}, allSeries)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(series["dev"]["constant"]) >= 4 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the minutes without samples up to the current one hold the constant value
+	points := series["dev"]["constant"]
+	if expect, got := 4, len(points); got < expect {
+		t.Fatalf("expect at least %d points but got %d: %v", expect, got, points)
+	}
+	for i, p := range points {
+		if expect := base.Add(time.Duration(i) * time.Minute); !p.Time.Equal(expect) || p.Avg != 7 || p.Min != 7 || p.Max != 7 {
+			t.Errorf("expect point %d to be 7 at %s but got %#v", i, expect, p)
+		}
+	}
+
+	if expect, got := 1, len(series["dev"]["forgotten"]); expect != got {
+		t.Errorf("expect %d point of the forgotten series but got %d", expect, got)
+	}
+
+	// a new sample replaces the carried value of the current period
+	now := time.Now().UTC()
+	store.Add("dev", "constant", now, 9)
+	series, err = store.Query(tsdb.Minute, now.Truncate(time.Minute), time.Time{}, allSeries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if points := series["dev"]["constant"]; len(points) != 1 || points[0].Avg != 9 || points[0].Count != 1 {
+		t.Errorf("expect the current minute to only contain the new sample but got %v", points)
+	}
+}
+
+func TestStoreSkipsDamagedRecords(t *testing.T) {
+	cfg := testConfig{path: t.TempDir()}
+	now := time.Now().UTC()
+
+	store, err := tsdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("cannot open store: %s", err)
+	}
+	store.Add("dev", "reg", now, 1)
+	store.Shutdown()
+
+	segment := filepath.Join(cfg.path, "raw", now.Format("2006-01-02")+".log")
+	f, err := os.OpenFile(segment, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("1234\tdev\tre")
+	_ = f.Close()
+
+	store, err = tsdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("cannot reopen store: %s", err)
+	}
+	defer store.Shutdown()
+
+	series, err := store.Query(tsdb.Raw, time.Time{}, time.Time{}, allSeries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect, got := 1, len(series["dev"]["reg"]); expect != got {
+		t.Errorf("expect %d points but got %d", expect, got)
+	}
+}
+
+func TestStoreRetention(t *testing.T) {
+	cfg := testConfig{path: t.TempDir()}
+
+	old := filepath.Join(cfg.path, "raw", time.Now().UTC().Add(-5*24*time.Hour).Format("2006-01-02")+".log")
+	recent := filepath.Join(cfg.path, "1m", time.Now().UTC().Add(-5*24*time.Hour).Format("2006-01-02")+".log")
+	for _, p := range []string{old, recent} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte{}, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store, err := tsdb.Open(cfg)
+	if err != nil {
+		t.Fatalf("cannot open store: %s", err)
+	}
+	defer store.Shutdown()
+
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expect raw segment older than retention to be removed")
+	}
+	if _, err := os.Stat(recent); err != nil {
+		t.Errorf("expect minute segment within retention to be kept: %s", err)
+	}
+}
@@ -0,0 +1,46 @@
+package types
+
+type AlarmKind int
+
+const (
+	AlarmUndefinedKind AlarmKind = iota
+	AlarmHighKind
+	AlarmLowKind
+	AlarmRateKind
+	AlarmStuckKind
+	AlarmOfflineKind
+)
+
+func (ak AlarmKind) String() string {
+	switch ak {
+	case AlarmHighKind:
+		return "High"
+	case AlarmLowKind:
+		return "Low"
+	case AlarmRateKind:
+		return "Rate"
+	case AlarmStuckKind:
+		return "Stuck"
+	case AlarmOfflineKind:
+		return "Offline"
+	default:
+		return "Undefined"
+	}
+}
+
+func AlarmKindFromString(s string) AlarmKind {
+	switch s {
+	case "High":
+		return AlarmHighKind
+	case "Low":
+		return AlarmLowKind
+	case "Rate":
+		return AlarmRateKind
+	case "Stuck":
+		return AlarmStuckKind
+	case "Offline":
+		return AlarmOfflineKind
+	default:
+		return AlarmUndefinedKind
+	}
+}
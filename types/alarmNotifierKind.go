@@ -0,0 +1,36 @@
+package types
+
+type AlarmNotifierKind int
+
+const (
+	AlarmNotifierUndefinedKind AlarmNotifierKind = iota
+	AlarmNotifierWebhookKind
+	AlarmNotifierSmtpKind
+	AlarmNotifierNtfyKind
+)
+
+func (nk AlarmNotifierKind) String() string {
+	switch nk {
+	case AlarmNotifierWebhookKind:
+		return "Webhook"
+	case AlarmNotifierSmtpKind:
+		return "Smtp"
+	case AlarmNotifierNtfyKind:
+		return "Ntfy"
+	default:
+		return "Undefined"
+	}
+}
+
+func AlarmNotifierKindFromString(s string) AlarmNotifierKind {
+	switch s {
+	case "Webhook":
+		return AlarmNotifierWebhookKind
+	case "Smtp":
+		return AlarmNotifierSmtpKind
+	case "Ntfy":
+		return AlarmNotifierNtfyKind
+	default:
+		return AlarmNotifierUndefinedKind
+	}
+}
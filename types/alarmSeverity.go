@@ -0,0 +1,36 @@
+package types
+
+type AlarmSeverity int
+
+const (
+	AlarmUndefinedSeverity AlarmSeverity = iota
+	AlarmInfoSeverity
+	AlarmWarningSeverity
+	AlarmCriticalSeverity
+)
+
+func (as AlarmSeverity) String() string {
+	switch as {
+	case AlarmInfoSeverity:
+		return "Info"
+	case AlarmWarningSeverity:
+		return "Warning"
+	case AlarmCriticalSeverity:
+		return "Critical"
+	default:
+		return "Undefined"
+	}
+}
+
+func AlarmSeverityFromString(s string) AlarmSeverity {
+	switch s {
+	case "Info":
+		return AlarmInfoSeverity
+	case "Warning":
+		return AlarmWarningSeverity
+	case "Critical":
+		return AlarmCriticalSeverity
+	default:
+		return AlarmUndefinedSeverity
+	}
+}
@@ -0,0 +1,36 @@
+package types
+
+type ModbusBusKind int
+
+const (
+	ModbusBusUndefinedKind ModbusBusKind = iota
+	ModbusBusSerialKind
+	ModbusBusTcpKind
+	ModbusBusRtuOverTcpKind
+)
+
+func (bk ModbusBusKind) String() string {
+	switch bk {
+	case ModbusBusSerialKind:
+		return "Serial"
+	case ModbusBusTcpKind:
+		return "Tcp"
+	case ModbusBusRtuOverTcpKind:
+		return "RtuOverTcp"
+	default:
+		return "Undefined"
+	}
+}
+
+func ModbusBusKindFromString(s string) ModbusBusKind {
+	switch s {
+	case "Serial":
+		return ModbusBusSerialKind
+	case "Tcp":
+		return ModbusBusTcpKind
+	case "RtuOverTcp":
+		return ModbusBusRtuOverTcpKind
+	default:
+		return ModbusBusUndefinedKind
+	}
+}
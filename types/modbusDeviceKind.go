@@ -6,6 +6,8 @@ const (
 	ModbusUndefinedKind ModbusDeviceKind = iota
 	ModbusWaveshareRtuRelay8Kind
 	ModbusFinder7M38Kind
+	ModbusGenericKind
+	ModbusBusStatusKind
 )
 
 func (dk ModbusDeviceKind) String() string {
@@ -14,6 +16,10 @@ func (dk ModbusDeviceKind) String() string {
 		return "WaveshareRtuRelay8"
 	case ModbusFinder7M38Kind:
 		return "Finder7M38"
+	case ModbusGenericKind:
+		return "Generic"
+	case ModbusBusStatusKind:
+		return "BusStatus"
 	default:
 		return "Undefined"
 	}
@@ -25,6 +31,10 @@ func ModbusDeviceKindFromString(s string) ModbusDeviceKind {
 		return ModbusWaveshareRtuRelay8Kind
 	case "Finder7M38":
 		return ModbusFinder7M38Kind
+	case "Generic":
+		return ModbusGenericKind
+	case "BusStatus":
+		return ModbusBusStatusKind
 	default:
 		return ModbusUndefinedKind
 	}
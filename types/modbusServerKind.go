@@ -0,0 +1,31 @@
+package types
+
+type ModbusServerKind int
+
+const (
+	ModbusServerUndefinedKind ModbusServerKind = iota
+	ModbusServerTcpKind
+	ModbusServerRtuKind
+)
+
+func (sk ModbusServerKind) String() string {
+	switch sk {
+	case ModbusServerTcpKind:
+		return "Tcp"
+	case ModbusServerRtuKind:
+		return "Rtu"
+	default:
+		return "Undefined"
+	}
+}
+
+func ModbusServerKindFromString(s string) ModbusServerKind {
+	switch s {
+	case "Tcp":
+		return ModbusServerTcpKind
+	case "Rtu":
+		return ModbusServerRtuKind
+	default:
+		return ModbusServerUndefinedKind
+	}
+}
@@ -0,0 +1,52 @@
+package types
+
+// RestorePolicy defines which values of a register are restored from the snapshot at startup.
+type RestorePolicy int
+
+const (
+	RestorePolicyUndefined RestorePolicy = iota
+	RestorePolicyNone
+	RestorePolicyState
+	RestorePolicyCommand
+	RestorePolicyAll
+)
+
+func (p RestorePolicy) String() string {
+	switch p {
+	case RestorePolicyNone:
+		return "None"
+	case RestorePolicyState:
+		return "State"
+	case RestorePolicyCommand:
+		return "Command"
+	case RestorePolicyAll:
+		return "All"
+	default:
+		return "Undefined"
+	}
+}
+
+func RestorePolicyFromString(s string) RestorePolicy {
+	switch s {
+	case "None":
+		return RestorePolicyNone
+	case "State":
+		return RestorePolicyState
+	case "Command":
+		return RestorePolicyCommand
+	case "All":
+		return RestorePolicyAll
+	default:
+		return RestorePolicyUndefined
+	}
+}
+
+// RestoreState is true when the last known state value is restored.
+func (p RestorePolicy) RestoreState() bool {
+	return p == RestorePolicyState || p == RestorePolicyAll
+}
+
+// RestoreCommand is true when the last command sent to the device is restored.
+func (p RestorePolicy) RestoreCommand() bool {
+	return p == RestorePolicyCommand || p == RestorePolicyAll
+}
@@ -0,0 +1,36 @@
+package types
+
+type RuleActionKind int
+
+const (
+	RuleActionUndefinedKind RuleActionKind = iota
+	RuleActionCommandKind
+	RuleActionMqttKind
+	RuleActionWebhookKind
+)
+
+func (ak RuleActionKind) String() string {
+	switch ak {
+	case RuleActionCommandKind:
+		return "Command"
+	case RuleActionMqttKind:
+		return "Mqtt"
+	case RuleActionWebhookKind:
+		return "Webhook"
+	default:
+		return "Undefined"
+	}
+}
+
+func RuleActionKindFromString(s string) RuleActionKind {
+	switch s {
+	case "Command":
+		return RuleActionCommandKind
+	case "Mqtt":
+		return RuleActionMqttKind
+	case "Webhook":
+		return RuleActionWebhookKind
+	default:
+		return RuleActionUndefinedKind
+	}
+}
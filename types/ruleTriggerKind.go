@@ -0,0 +1,41 @@
+package types
+
+type RuleTriggerKind int
+
+const (
+	RuleTriggerUndefinedKind RuleTriggerKind = iota
+	RuleTriggerChangeKind
+	RuleTriggerThresholdKind
+	RuleTriggerAvailabilityKind
+	RuleTriggerCronKind
+)
+
+func (tk RuleTriggerKind) String() string {
+	switch tk {
+	case RuleTriggerChangeKind:
+		return "Change"
+	case RuleTriggerThresholdKind:
+		return "Threshold"
+	case RuleTriggerAvailabilityKind:
+		return "Availability"
+	case RuleTriggerCronKind:
+		return "Cron"
+	default:
+		return "Undefined"
+	}
+}
+
+func RuleTriggerKindFromString(s string) RuleTriggerKind {
+	switch s {
+	case "Change":
+		return RuleTriggerChangeKind
+	case "Threshold":
+		return RuleTriggerThresholdKind
+	case "Availability":
+		return RuleTriggerAvailabilityKind
+	case "Cron":
+		return RuleTriggerCronKind
+	default:
+		return RuleTriggerUndefinedKind
+	}
+}
@@ -41,15 +41,15 @@ func NewDevice(
 func (c *DeviceStruct) Run(ctx context.Context) (err error, immediateError bool) {
 	switch c.victronConfig.Kind() {
 	case types.VictronVedirectKind:
-		return runVedirect(ctx, c, c.StateStorage())
+		return runVedirect(ctx, c, c.Output())
 	case types.VictronRandomBmvKind:
 		rl := veregister.NewRegisterList()
 		veregister.AppendBmv(&rl)
-		return runRandom(ctx, c, c.StateStorage(), rl)
+		return runRandom(ctx, c, c.Output(), rl)
 	case types.VictronRandomSolarKind:
 		rl := veregister.NewRegisterList()
 		veregister.AppendSolar(&rl)
-		return runRandom(ctx, c, c.StateStorage(), rl)
+		return runRandom(ctx, c, c.Output(), rl)
 	default:
 		return fmt.Errorf("unknown device kind: %s", c.victronConfig.Kind().String()), true
 	}
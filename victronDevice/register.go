@@ -4,6 +4,7 @@ import (
 	"github.com/koestler/go-iotdevice/v3/dataflow"
 	"github.com/koestler/go-victron/veconst"
 	"github.com/koestler/go-victron/veregister"
+	"math"
 )
 
 // Register is used as a wrapper for veregister.Register to implement dataflow.Register
@@ -48,6 +49,22 @@ func (r Register) Unit() string {
 	return ""
 }
 
+type factorer interface {
+	Factor() int
+}
+
+// Meta derives the device class from the unit and the precision from the factor of number registers.
+func (r Register) Meta() dataflow.RegisterMeta {
+	m := dataflow.MetaByUnit(r.Unit())
+	if nr, ok := r.Register.(factorer); ok && nr.Factor() > 1 {
+		m = m.WithPrecision(int(math.Ceil(math.Log10(float64(nr.Factor())))))
+	}
+	if r.Name() == "SOC" {
+		m = m.WithDeviceClass("battery").WithStateClass(dataflow.StateClassMeasurement).WithRange(0, 100)
+	}
+	return m
+}
+
 func addToRegisterDb(rdb *dataflow.RegisterDb, rl veregister.RegisterList) {
 	registers := rl.GetRegisters()
 	dataflowRegisters := make([]dataflow.RegisterStruct, len(registers))
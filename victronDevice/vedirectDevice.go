@@ -122,6 +122,7 @@ func runVedirect(ctx context.Context, c *DeviceStruct, output dataflow.Fillable)
 		}
 
 		took = time.Since(start)
+		c.ObservePoll(took)
 
 		if c.Config().LogDebug() {
 			log.Printf("device[%s]: %d registers fetched, took=%.3fs", deviceName, regs.Len(), took.Seconds())